@@ -2,46 +2,86 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 
 	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/artifact"
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
 	"github.com/ossf/package-analysis/internal/featureflags"
 	"github.com/ossf/package-analysis/internal/log"
 	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/report"
 	"github.com/ossf/package-analysis/internal/resultstore"
 	"github.com/ossf/package-analysis/internal/sandbox"
 	"github.com/ossf/package-analysis/internal/staticanalysis"
+	"github.com/ossf/package-analysis/internal/telemetry"
 	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/internal/verdict"
 	"github.com/ossf/package-analysis/internal/worker"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
 )
 
 var (
 	pkgName            = flag.String("package", "", "package name")
 	localPkg           = flag.String("local", "", "local package path")
+	gitSource          = flag.String("git", "", "git repository to fetch the package from instead of the registry, as REPOSITORY[#REF] (e.g. user/repo#v1.0.0, github:user/repo#<commit> or https://host/repo.git#branch). The package is analyzed at the commit REF resolves to, which is recorded as its version")
+	archiveDir         = flag.String("archive-dir", "", "directory to copy package archives from instead of downloading them from the registry, laid out as <ecosystem>/<name>/<version>/<archive>")
 	ecosystem          pkgecosystem.Ecosystem
-	version            = flag.String("version", "", "version")
+	version            = flag.String("version", "", "version, or tag of the version (e.g. next or beta for npm, or \"prerelease\" for the latest pre-release)")
 	noPull             = flag.Bool("nopull", false, "disables pulling down sandbox images")
 	imageTag           = flag.String("image-tag", "", "set image tag for analysis sandboxes")
-	dynamicBucket      = flag.String("dynamic-bucket", "", "bucket path for uploading dynamic analysis results")
-	staticBucket       = flag.String("static-bucket", "", "bucket path for uploading static analysis results")
-	executionLogBucket = flag.String("execution-log-bucket", "", "bucket path for uploading execution log (dynamic analysis)")
-	fileWritesBucket   = flag.String("file-writes-bucket", "", "bucket path for uploading file writes data (dynamic analysis)")
-	analyzedPkgBucket  = flag.String("analyzed-pkg-bucket", "", "bucket path for uploading analyzed packages")
+	dynamicBucket      = flag.String("dynamic-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving dynamic analysis results")
+	staticBucket       = flag.String("static-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving static analysis results")
+	normalizedBucket   = flag.String("normalized-dynamic-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving dynamic analysis results with run-specific values (PIDs, timestamps, random temp file names) masked")
+	executionLogBucket = flag.String("execution-log-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving execution log (dynamic analysis)")
+	fileWritesBucket   = flag.String("file-writes-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving file writes data (dynamic analysis)")
+	straceLogBucket    = flag.String("strace-log-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving the raw strace log of each phase (dynamic analysis)")
+	maxStraceLogSize   = flag.Int("max-strace-log-size", 16<<20, "maximum size in bytes of each raw strace log saved to -strace-log-bucket; the middle of larger logs is dropped")
+	analyzedPkgBucket  = flag.String("analyzed-pkg-bucket", "", "bucket URL or local directory (or several, separated by commas) for saving analyzed packages")
+	verdictWeights     = flag.String("verdict-weights", "", "path to a JSON file overriding the weights of the rules used to score the package verdict, e.g. {\"static.indirect_eval\": 3}")
+	credentialStores   = flag.String("credential-stores", "", "path to a JSON file listing the credential stores (e.g. browser login databases, ~/.ssh) whose reads are reported during dynamic analysis, replacing the default ones, e.g. [{\"name\": \"ssh\", \"paths\": [\".ssh\"]}]")
+	tamperingRules     = flag.String("tampering-rules", "", "path to a JSON file listing the actions that disable or evade security tooling (e.g. clearing shell history, killing monitoring agents) which are reported during dynamic analysis, replacing the default ones, e.g. [{\"name\": \"security_processes\", \"commands\": [\"pkill\"], \"targets\": [\"my-agent\"]}]")
+	artifactPath       = flag.String("artifact", "", "directory to write the full analysis results to, for offline analysis. If the path ends in .tar.gz, an archive is written instead")
 	offline            = flag.Bool("offline", false, "disables sandbox network access")
+	denyNetwork        = flag.Bool("deny-network", false, "run dynamic analysis with the network disabled, reporting the connections the package attempted as denied. Recommended for untrusted packages. The package is downloaded before the sandbox starts, so dependencies which must be fetched will fail to install")
 	customSandbox      = flag.String("sandbox-image", "", "override default dynamic analysis sandbox with custom image")
 	customAnalysisCmd  = flag.String("analysis-command", "", "override default dynamic analysis script path (use with custom sandbox image)")
 	listModes          = flag.Bool("list-modes", false, "prints out a list of available analysis modes")
 	features           = flag.String("features", "", "override features that are enabled/disabled by default")
 	listFeatures       = flag.Bool("list-features", false, "list available features that can be toggled")
+	listEcosystems     = flag.Bool("list-ecosystems", false, "list supported ecosystems and the analysis available for each")
+	listVersions       = flag.Bool("list-versions", false, "list the published versions, pre-releases and tags of -package")
+	commandOverrides   = make(dynamicanalysis.CommandOverrides)
+	phaseEnvironments  = make(dynamicanalysis.PhaseEnvironments)
+	stores             []dynamicanalysis.CredentialStore // loaded from -credential-stores
+	tampering          []dynamicanalysis.TamperingRule   // loaded from -tampering-rules
+	staticEntryPoints  = flag.Bool("static-entry-points", false, "only analyze the package's entry point files (e.g. package.json main/exports) during static analysis")
+	minimalCapture     = flag.Bool("minimal-capture", false, "run dynamic analysis in a fast mode that only records network connections, shell commands and writes outside the package, to find packages that need full analysis")
+	batchFile          = flag.String("batch", "", "file listing packages to analyze instead of -package, one per line as NAME [VERSION]. Results are saved under <ecosystem>/<name>/ in each bucket")
+	reportPath         = flag.String("report", "", "file to write a JSON report summarizing the analyses of -batch to. Packages analyzed by a previous run (see -checkpoint) are not included")
+	checkpointPath     = flag.String("checkpoint", "", "file recording the packages of -batch that have been analyzed, so that an interrupted batch can be resumed by running it again")
+	concurrency        = flag.Int("concurrency", 1, "maximum number of packages of -batch to analyze at the same time")
+	dependencyDepth    = flag.Int("dependency-depth", 0, "also analyze the dependencies of the package, up to this many levels deep (0 analyzes only the package)")
+	staticNestedDepth  = flag.Int("static-nested-code-depth", 0, "number of levels of string literals which look like code (e.g. eval payloads) to parse as JavaScript during static analysis")
+	staticFileTimeout  = flag.Duration("static-file-timeout", 0, "time given to parse each file during static analysis, as a budget for each batch of files parsed together; files in a batch that runs out of time and that take longer on their own are recorded as timed out (0 for no limit)")
+	staticPkgTimeout   = flag.Duration("static-package-timeout", 0, "maximum time to parse all files of the package during static analysis (0 for no limit)")
+	staticPaths        = utils.CommaSeparatedFlags("static-paths", nil, "comma-separated list of files or directories (relative to the extracted archive) to analyze during static analysis")
+	staticPrevious     = flag.String("static-previous-results", "", "raw static analysis results of the previous version of the package (see -static-raw-output); files that have not changed are not analyzed again, and their results are reused")
+	staticRawOutput    = flag.String("static-raw-output", "", "file to write the raw static analysis results to, for use with -static-previous-results when analyzing the next version")
 	help               = flag.Bool("help", false, "print help on available options")
 	analysisMode       = utils.CommaSeparatedFlags("mode", []string{"static", "dynamic"},
 		"list of analysis modes to run, separated by commas. Use -list-modes to see available options")
@@ -56,26 +96,28 @@ func usagef(format string, args ...any) error {
 	return usageError{fmt.Errorf(format, args...)}
 }
 
-func makeResultStores() worker.ResultStores {
-	rs := worker.ResultStores{}
-
-	if *analyzedPkgBucket != "" {
-		rs.AnalyzedPackage = resultstore.New(*analyzedPkgBucket)
-	}
-	if *dynamicBucket != "" {
-		rs.DynamicAnalysis = resultstore.New(*dynamicBucket)
-	}
-	if *executionLogBucket != "" {
-		rs.ExecutionLog = resultstore.New(*executionLogBucket)
-	}
-	if *fileWritesBucket != "" {
-		rs.FileWrites = resultstore.New(*fileWritesBucket)
+func makeResultStores(options ...resultstore.Option) (worker.ResultStores, error) {
+	var errs []error
+	open := func(dest string) resultstore.ResultStore {
+		if dest == "" {
+			return nil
+		}
+		rs, err := resultstore.Open(dest, options...)
+		errs = append(errs, err)
+		return rs
 	}
-	if *staticBucket != "" {
-		rs.StaticAnalysis = resultstore.New(*staticBucket)
+
+	rs := worker.ResultStores{
+		AnalyzedPackage:           open(*analyzedPkgBucket),
+		DynamicAnalysis:           open(*dynamicBucket),
+		NormalizedDynamicAnalysis: open(*normalizedBucket),
+		ExecutionLog:              open(*executionLogBucket),
+		StraceLog:                 open(*straceLogBucket),
+		FileWrites:                open(*fileWritesBucket),
+		StaticAnalysis:            open(*staticBucket),
 	}
 
-	return rs
+	return rs, errors.Join(errs...)
 }
 
 func printAnalysisModes() {
@@ -105,6 +147,41 @@ func printFeatureFlags() {
 	fmt.Println()
 }
 
+func printEcosystems() {
+	fmt.Printf("%-12s %-24s %-10s %s\n", "Ecosystem", "Phases", "Runtime", "Static parsing")
+	fmt.Printf("------------------------------------------------------------\n")
+
+	for _, c := range pkgmanager.Ecosystems() {
+		phases := make([]string, 0, len(c.Phases))
+		for _, phase := range c.Phases {
+			phases = append(phases, string(phase))
+		}
+		language := string(c.StaticLanguage)
+		if language == "" {
+			language = "-"
+		}
+		fmt.Printf("%-12s %-24s %-10s %s\n", c.Ecosystem, strings.Join(phases, ","), c.Runtime, language)
+	}
+
+	fmt.Println()
+}
+
+// printVersions prints the latest version, tags and pre-releases of the named package.
+func printVersions(manager *pkgmanager.PkgManager, name string) error {
+	versions, err := manager.Versions(name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("latest: %s\n", versions.Latest)
+	tags := maps.Keys(versions.Tags)
+	slices.Sort(tags)
+	for _, tag := range tags {
+		fmt.Printf("tag %s: %s\n", tag, versions.Tags[tag])
+	}
+	fmt.Printf("versions: %d, pre-releases: %s\n", len(versions.All), strings.Join(versions.Prereleases, ", "))
+	return nil
+}
+
 // makeSandboxOptions prepares options for the sandbox based on command line arguments.
 //
 // In particular:
@@ -112,11 +189,11 @@ func printFeatureFlags() {
 //  1. The image tag is always passed through. An empty tag is the same as "latest".
 //  2. A local package is mapped into the sandbox if applicable.
 //  3. Image pulling is disabled if the "-nopull" command-line flag was used.
-func makeSandboxOptions() []sandbox.Option {
+func makeSandboxOptions(pkg *pkgmanager.Pkg) []sandbox.Option {
 	sbOpts := []sandbox.Option{sandbox.Tag(*imageTag)}
 
-	if *localPkg != "" {
-		sbOpts = append(sbOpts, sandbox.Copy(*localPkg, *localPkg))
+	if pkg.IsLocal() {
+		sbOpts = append(sbOpts, sandbox.Copy(pkg.LocalPath(), pkg.LocalPath()))
 	}
 	if *noPull {
 		sbOpts = append(sbOpts, sandbox.NoPull())
@@ -128,21 +205,64 @@ func makeSandboxOptions() []sandbox.Option {
 	return sbOpts
 }
 
-func dynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, resultStores *worker.ResultStores) {
-	if !*offline {
+// dynamicAnalysis runs dynamic analysis on the package and saves the results. The
+// results are returned, or nil if the analysis was aborted or only the minimal
+// results were captured (with -minimal-capture). If the analysis was skipped
+// because the sandbox was unavailable (see worker.DynamicAnalysisSkipped), the
+// reason is returned as well. If the analysis was aborted or only the minimal
+// results were captured, the limit that this hit (e.g. analysisrun.LimitDynamicError)
+// is returned, since it is not known from the results. If static is not nil,
+// it is used to find the modules that were only loaded dynamically (see
+// worker.CrossReferenceLoadedModules).
+func dynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, resultStores *worker.ResultStores, static *staticapi.Record) (data *analysisrun.DynamicAnalysisData, skipped, limit string) {
+	if !*offline && !*denyNetwork {
 		sandbox.InitNetwork(ctx)
 	}
 
-	sbOpts := append(worker.DynamicSandboxOptions(), makeSandboxOptions()...)
+	sbOpts := append(worker.DynamicSandboxOptions(), makeSandboxOptions(pkg)...)
 
 	if *customSandbox != "" {
 		sbOpts = append(sbOpts, sandbox.Image(*customSandbox))
 	}
 
-	result, err := worker.RunDynamicAnalysis(ctx, pkg, sbOpts, *customAnalysisCmd)
+	var opts []worker.DynamicAnalysisOption
+	if *minimalCapture {
+		opts = append(opts, worker.MinimalCapture())
+	}
+	if resultStores.StraceLog != nil {
+		opts = append(opts, worker.RawStraceLog(*maxStraceLogSize))
+	}
+	if stores != nil {
+		opts = append(opts, worker.CredentialStores(stores))
+	}
+	if tampering != nil {
+		opts = append(opts, worker.TamperingRules(tampering))
+	}
+	if *denyNetwork {
+		opts = append(opts, worker.DenyNetwork())
+	}
+
+	result, err := worker.RunDynamicAnalysis(ctx, pkg, sbOpts, *customAnalysisCmd, commandOverrides, phaseEnvironments, opts...)
+	if reason, skipped := worker.DynamicAnalysisSkipped(err); skipped {
+		slog.WarnContext(ctx, "Dynamic analysis skipped, package analysed statically only", "reason", reason)
+		return nil, reason, ""
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Dynamic analysis aborted (run error)", "error", err)
-		return
+		return nil, "", analysisrun.LimitDynamicError
+	}
+
+	if err := worker.SaveRawStraceLogs(ctx, pkg, resultStores, result.RawStraceLogs); err != nil {
+		slog.ErrorContext(ctx, "Upload error", "error", err)
+	}
+
+	if *minimalCapture {
+		printMinimalSummary(result.Minimal)
+		if err := worker.SaveMinimalDynamicAnalysisData(ctx, pkg, resultStores, result.Minimal); err != nil {
+			slog.ErrorContext(ctx, "Upload error", "error", err)
+		}
+		// the minimal results are not complete enough to score
+		return nil, "", analysisrun.LimitDynamicMinimal
 	}
 
 	// this is only valid if RunDynamicAnalysis() returns nil err
@@ -152,29 +272,286 @@ func dynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, resultStores *wor
 			"status", string(result.LastStatus))
 	}
 
+	if static != nil {
+		worker.CrossReferenceLoadedModules(&static.Results, &result.Data)
+	}
+
 	if err := worker.SaveDynamicAnalysisData(ctx, pkg, resultStores, result.Data); err != nil {
 		slog.ErrorContext(ctx, "Upload error", "error", err)
 	}
+	return &result.Data, "", ""
 }
 
-func staticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, resultStores *worker.ResultStores) {
+// printMinimalSummary prints the results of dynamic analysis run with the
+// -minimal-capture flag, and whether the package needs full analysis.
+func printMinimalSummary(minimal analysisrun.DynamicAnalysisMinimalSummary) {
+	for _, phase := range analysisrun.AllDynamicPhases() {
+		m := minimal[phase]
+		if m == nil {
+			continue
+		}
+		fmt.Printf("%s: status %s, %d connections, %d commands (%d shell), %d writes outside package\n",
+			phase, m.Status, len(m.Connections), len(m.Commands), m.ShellCommands, len(m.OutsideWrites))
+		for _, c := range m.Connections {
+			fmt.Printf("  connection %s\n", c)
+		}
+		for _, w := range m.OutsideWrites {
+			fmt.Printf("  write %s\n", w)
+		}
+	}
+	if minimal.Tripped() {
+		fmt.Println("Full analysis recommended")
+	} else {
+		fmt.Println("Full analysis not needed")
+	}
+}
+
+// staticAnalysis runs static analysis on the package and saves the results. The
+// results are returned, or nil if the analysis was aborted or produced no data.
+func staticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, resultStores *worker.ResultStores) *staticapi.Record {
 	if !*offline {
 		sandbox.InitNetwork(ctx)
 	}
 
-	sbOpts := append(worker.StaticSandboxOptions(), makeSandboxOptions()...)
+	sbOpts := append(worker.StaticSandboxOptions(), makeSandboxOptions(pkg)...)
 
-	data, status, err := worker.RunStaticAnalysis(ctx, pkg, sbOpts, staticanalysis.All)
+	scope := worker.StaticAnalysisScope{
+		EntryPoints:     *staticEntryPoints,
+		Paths:           staticPaths.Values,
+		NestedCodeDepth: *staticNestedDepth,
+		FileTimeout:     *staticFileTimeout,
+		PackageTimeout:  *staticPkgTimeout,
+		PreviousResults: *staticPrevious,
+	}
+
+	data, status, err := worker.RunStaticAnalysis(ctx, pkg, sbOpts, scope, staticanalysis.All)
 	if err != nil {
 		slog.ErrorContext(ctx, "Static analysis aborted", "error", err)
-		return
+		return nil
 	}
 
 	slog.InfoContext(ctx, "Static analysis completed", "status", string(status))
 
+	if *staticRawOutput != "" && len(data) > 0 {
+		if err := os.WriteFile(*staticRawOutput, data, 0o644); err != nil {
+			slog.ErrorContext(ctx, "Failed to write raw static analysis results", "path", *staticRawOutput, "error", err)
+		}
+	}
+
 	if err := worker.SaveStaticAnalysisData(ctx, pkg, resultStores, data); err != nil {
 		slog.ErrorContext(ctx, "Upload error", "error", err)
 	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	record, err := worker.StaticAnalysisRecord(pkg, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert static analysis results", "error", err)
+		return nil
+	}
+	if timedOut := record.Results.TimedOutFiles(); len(timedOut) > 0 {
+		slog.WarnContext(ctx, "Static analysis timed out parsing some files", "files", timedOut)
+	}
+	if reused := record.Results.ReusedFiles(); len(reused) > 0 {
+		slog.InfoContext(ctx, "Static analysis reused the results of unchanged files",
+			"reused", len(reused), "analyzed", len(record.Results.Files)-len(reused))
+	}
+	return record
+}
+
+// writeArtifact writes the analysis results to the path given by the -artifact flag.
+func writeArtifact(a *artifact.Artifact) error {
+	if !strings.HasSuffix(*artifactPath, ".tar.gz") {
+		return artifact.Write(*artifactPath, a)
+	}
+
+	f, err := os.Create(*artifactPath)
+	if err != nil {
+		return err
+	}
+	if err := artifact.WriteTarball(f, a); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// analyzePackage runs the analyses selected by runMode on the package, saves the
+// results and scores the verdict.
+func analyzePackage(ctx context.Context, pkg *pkgmanager.Pkg, runMode map[analysis.Mode]bool, scorer *verdict.Scorer, resultStores *worker.ResultStores) (*artifact.Artifact, error) {
+	// Packages from a configured registry or downloader are downloaded here,
+	// so that the registry credentials are not exposed to the sandboxes.
+	if worker.NeedsHostDownload(pkg) {
+		slog.InfoContext(ctx, "Downloading package", "registry", pkg.Manager().Registry())
+		_, download := telemetry.StartStage(ctx, telemetry.StageDownload, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+		archivePath, err := worker.DownloadToTempDir(pkg)
+		download.End(ctx, analysis.StatusForError(err), err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error downloading package", "error", err)
+			return nil, err
+		}
+		defer os.RemoveAll(filepath.Dir(archivePath))
+		pkg = pkg.Manager().Local(pkg.Name(), pkg.Version(), archivePath)
+	}
+	pkg = worker.AttachArtifactHash(ctx, pkg)
+
+	ctx = log.ContextWithAttrs(ctx,
+		slog.String("name", pkg.Name()),
+		slog.String("version", pkg.Version()),
+	)
+
+	slog.InfoContext(ctx, "Processing resolved package", "package_path", pkg.LocalPath())
+
+	results := &artifact.Artifact{
+		Package: analysisrun.Key{
+			Ecosystem: pkg.Ecosystem(),
+			Name:      pkg.Name(),
+			Version:   pkg.Version(),
+		},
+		CreatedTimestamp: time.Now().UTC().Unix(),
+		ArtifactSHA256:   pkg.ArtifactSHA256(),
+		GitSource:        pkg.GitSource(),
+	}
+
+	if runMode[analysis.Static] {
+		slog.InfoContext(ctx, "Starting static analysis")
+		results.Static = staticAnalysis(ctx, pkg, resultStores)
+	}
+
+	// dynamicAnalysis() currently panics on error, so it's last
+	var dynamicLimit string
+	if runMode[analysis.Dynamic] {
+		slog.InfoContext(ctx, "Starting dynamic analysis")
+		results.Dynamic, results.DynamicSkipped, dynamicLimit = dynamicAnalysis(ctx, pkg, resultStores, results.Static)
+	}
+
+	var staticResults *staticapi.Results
+	if results.Static != nil {
+		staticResults = &results.Static.Results
+	}
+	v := scorer.Score(staticResults, results.Dynamic)
+	if results.DynamicSkipped != "" {
+		v.AddLimits(analysisrun.LimitDynamicSkipped)
+	}
+	if dynamicLimit != "" {
+		v.AddLimits(dynamicLimit)
+	}
+	if runMode[analysis.Static] && results.Static == nil {
+		v.AddLimits(analysisrun.LimitStaticError)
+	}
+	results.Verdict = &v
+	telemetry.RecordVerdict(ctx, v, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+	slog.InfoContext(ctx, "Package verdict",
+		"label", string(v.Label),
+		"score", v.Score,
+		"contributions", v.Contributions,
+		"limits", v.Completeness.Limits)
+
+	return results, nil
+}
+
+// printDependencyTree prints the verdict of each package in the dependency tree.
+func printDependencyTree(tree *worker.DependencyNode[*artifact.Artifact]) {
+	tree.Walk(func(n *worker.DependencyNode[*artifact.Artifact], depth int) {
+		line := strings.Repeat("  ", depth)
+		if n.Package != nil {
+			line += n.Package.Name() + "@" + n.Package.Version()
+		} else {
+			line += n.Dependency.Name + "@" + n.Dependency.Version
+		}
+		if n.Result != nil && n.Result.Verdict != nil {
+			line += fmt.Sprintf(": %s (score %g)", n.Result.Verdict.Label, n.Result.Verdict.Score)
+		}
+		if n.Result != nil && n.Result.DynamicSkipped != "" {
+			line += " (static analysis only)"
+		}
+		if n.Duplicate {
+			line += " (see above)"
+		}
+		if n.Err != nil {
+			line += fmt.Sprintf(" [error: %v]", n.Err)
+		}
+		fmt.Println(line)
+	})
+}
+
+// runBatch analyzes the packages listed in the file given by the -batch flag, and
+// prints the verdict of each package.
+func runBatch(ctx context.Context, manager *pkgmanager.PkgManager, analyze func(context.Context, *pkgmanager.Pkg) (*artifact.Artifact, error)) error {
+	f, err := os.Open(*batchFile)
+	if err != nil {
+		return usageError{err}
+	}
+	pkgs, err := worker.ReadBatch(f)
+	f.Close()
+	if err != nil {
+		return usageError{err}
+	}
+
+	opts := worker.BatchOptions[*artifact.Artifact]{
+		ResultRef: func(pkg *pkgmanager.Pkg, _ *artifact.Artifact) string {
+			return path.Join(pkg.EcosystemName(), pkg.Name(), resultstore.DefaultFilename(pkg))
+		},
+		Limiter: worker.NewLimiter(*concurrency),
+	}
+	dynamicanalysis.SetConcurrency(*concurrency)
+	if *checkpointPath != "" {
+		checkpoint, err := worker.OpenFileCheckpoint(*checkpointPath)
+		if err != nil {
+			return err
+		}
+		defer checkpoint.Close()
+		opts.Checkpoint = checkpoint
+	}
+
+	results, err := worker.AnalyzeBatch(ctx, manager, pkgs, opts, analyze)
+	failed := 0
+	var analyses []*artifact.Artifact
+	for _, r := range results {
+		analyses = append(analyses, r.Result)
+		line := r.Request.Name
+		if r.Package != nil {
+			line = r.Package.Name() + "@" + r.Package.Version()
+		}
+		switch {
+		case r.Err != nil:
+			failed++
+			line += fmt.Sprintf(" [error: %v]", r.Err)
+		case r.Resumed:
+			line += " (analyzed by a previous run)"
+		case r.Result.Verdict != nil:
+			line += fmt.Sprintf(": %s (score %g)", r.Result.Verdict.Label, r.Result.Verdict.Score)
+			if r.Result.DynamicSkipped != "" {
+				line += " (static analysis only)"
+			}
+		}
+		fmt.Println(line)
+	}
+	if *reportPath != "" {
+		if reportErr := writeReport(*reportPath, report.Summarize(analyses)); reportErr != nil {
+			return reportErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d packages could not be analyzed", failed, len(pkgs))
+	}
+	return nil
+}
+
+// writeReport writes r to the file at reportPath as indented JSON.
+func writeReport(reportPath string, r *report.Report) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
 }
 
 func run() error {
@@ -183,7 +560,16 @@ func run() error {
 	flag.TextVar(&ecosystem, "ecosystem", pkgecosystem.None, "package ecosystem. Available: "+
 		strings.Join(pkgecosystem.SupportedEcosystemsStrings, ", "))
 
+	flag.Func("phase-command", "override the command run for a dynamic analysis phase, as PHASE=COMMAND ARGS... "+
+		"(may be repeated). Placeholders {name}, {version}, {local} and {phase} are substituted.",
+		commandOverrides.ParseCommandOverride)
+
+	flag.Func("phase-env", "set an environment variable for the command run for a dynamic analysis phase, as PHASE:NAME=VALUE "+
+		"(may be repeated). Takes precedence over the default environment of the sandbox.",
+		phaseEnvironments.ParsePhaseEnv)
+
 	analysisMode.InitFlag()
+	staticPaths.InitFlag()
 	flag.Parse()
 
 	if err := featureflags.Update(*features); err != nil {
@@ -205,17 +591,56 @@ func run() error {
 		return nil
 	}
 
+	if *listEcosystems {
+		printEcosystems()
+		return nil
+	}
+
 	if ecosystem == pkgecosystem.None {
 		flag.Usage()
 		return usagef("missing ecosystem")
 	}
 
+	if err := pkgmanager.ConfigureRegistries(os.Getenv); err != nil {
+		return err
+	}
+	if *archiveDir != "" {
+		pkgmanager.ConfigureDownloader(pkgmanager.DirectoryDownloader{Dir: *archiveDir})
+	}
+
 	manager := pkgmanager.Manager(ecosystem)
 	if manager == nil {
 		return usagef("unsupported package ecosystem %q", ecosystem)
 	}
 
-	if *pkgName == "" {
+	if *listVersions {
+		if *pkgName == "" {
+			return usagef("-list-versions requires -package")
+		}
+		return printVersions(manager, *pkgName)
+	}
+
+	if (*staticPrevious != "" || *staticRawOutput != "") && (*batchFile != "" || *dependencyDepth > 0) {
+		return usagef("-static-previous-results and -static-raw-output cannot be used with -batch or -dependency-depth")
+	}
+
+	if *batchFile != "" {
+		if *pkgName != "" || *localPkg != "" || *gitSource != "" {
+			return usagef("-batch cannot be used with -package, -local or -git")
+		}
+		if *dependencyDepth > 0 || *artifactPath != "" {
+			return usagef("-batch cannot be used with -dependency-depth or -artifact")
+		}
+		if *concurrency < 1 {
+			return usagef("-concurrency must be at least 1")
+		}
+	} else if *checkpointPath != "" || *reportPath != "" || *concurrency != 1 {
+		return usagef("-checkpoint, -report and -concurrency can only be used with -batch")
+	} else if *gitSource != "" {
+		if *localPkg != "" || *version != "" {
+			return usagef("-git cannot be used with -local or -version")
+		}
+	} else if *pkgName == "" {
 		flag.Usage()
 		return usagef("missing package name")
 	}
@@ -230,38 +655,114 @@ func run() error {
 		runMode[mode] = true
 	}
 
+	var scorerOpts []verdict.Option
+	if *verdictWeights != "" {
+		weights, err := verdict.LoadWeights(*verdictWeights)
+		if err != nil {
+			return usageError{err}
+		}
+		scorerOpts = append(scorerOpts, verdict.Weights(weights))
+	}
+
+	if *credentialStores != "" {
+		var err error
+		if stores, err = dynamicanalysis.LoadCredentialStores(*credentialStores); err != nil {
+			return usageError{err}
+		}
+	}
+
+	if *tamperingRules != "" {
+		var err error
+		if tampering, err = dynamicanalysis.LoadTamperingRules(*tamperingRules); err != nil {
+			return usageError{err}
+		}
+	}
+
 	ctx := log.ContextWithAttrs(context.Background(),
 		slog.Any("ecosystem", ecosystem),
 	)
 
+	// Traces and metrics are only exported if a collector is configured.
+	shutdownTelemetry, err := telemetry.Setup(ctx, "package-analysis-analyze")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTelemetry(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to flush telemetry", "error", err)
+		}
+	}()
+
+	if *batchFile != "" {
+		// results of different packages are saved under their own paths
+		resultStores, err := makeResultStores(resultstore.ConstructPath())
+		if err != nil {
+			return usageError{err}
+		}
+		scorer := verdict.New(scorerOpts...)
+		return runBatch(ctx, manager, func(ctx context.Context, pkg *pkgmanager.Pkg) (*artifact.Artifact, error) {
+			// each package has its own copy, as packages may be analyzed concurrently
+			stores := resultStores
+			return analyzePackage(ctx, pkg, runMode, scorer, &stores)
+		})
+	}
+
 	slog.InfoContext(ctx, "Got request",
 		slog.String("requested_name", *pkgName),
 		slog.String("requested_version", *version),
 	)
 
-	pkg, err := worker.ResolvePkg(manager, *pkgName, *version, *localPkg)
+	var pkg *pkgmanager.Pkg
+	if *gitSource != "" {
+		pkg, err = worker.FetchGitPkg(ctx, manager, *pkgName, *gitSource)
+		if err == nil {
+			defer os.RemoveAll(pkg.LocalPath())
+			slog.InfoContext(ctx, "Fetched package from git",
+				slog.String("repository", pkg.GitSource().Repository),
+				slog.String("commit", pkg.GitSource().Commit),
+			)
+		}
+	} else {
+		pkg, err = worker.ResolvePkg(manager, *pkgName, *version, *localPkg)
+	}
 	if err != nil {
 		slog.ErrorContext(ctx, "Error resolving package", "error", err)
 		return err
 	}
 
-	ctx = log.ContextWithAttrs(ctx,
-		slog.String("name", pkg.Name()),
-		slog.String("version", pkg.Version()),
-	)
-
-	slog.InfoContext(ctx, "Processing resolved package", "package_path", *localPkg)
-	resultStores := makeResultStores()
+	resultStores, err := makeResultStores()
+	if err != nil {
+		return usageError{err}
+	}
+	scorer := verdict.New(scorerOpts...)
+	analyze := func(ctx context.Context, pkg *pkgmanager.Pkg) (*artifact.Artifact, error) {
+		return analyzePackage(ctx, pkg, runMode, scorer, &resultStores)
+	}
 
-	if runMode[analysis.Static] {
-		slog.InfoContext(ctx, "Starting static analysis")
-		staticAnalysis(ctx, pkg, &resultStores)
+	var results *artifact.Artifact
+	if *dependencyDepth > 0 {
+		tree, err := worker.AnalyzeDependencyTree(ctx, pkg, worker.DependencyTreeOptions{MaxDepth: *dependencyDepth}, analyze)
+		if err != nil {
+			return fmt.Errorf("failed to analyze dependency tree: %w", err)
+		}
+		printDependencyTree(tree)
+		// The error of the root (e.g. if its dependencies could not be read) is
+		// printed with the tree above, and does not discard its results.
+		if tree.Err != nil {
+			slog.ErrorContext(ctx, "Error analyzing package in dependency tree", "error", tree.Err)
+		}
+		if results = tree.Result; results == nil {
+			return tree.Err
+		}
+	} else if results, err = analyze(ctx, pkg); err != nil {
+		return err
 	}
 
-	// dynamicAnalysis() currently panics on error, so it's last
-	if runMode[analysis.Dynamic] {
-		slog.InfoContext(ctx, "Starting dynamic analysis")
-		dynamicAnalysis(ctx, pkg, &resultStores)
+	if *artifactPath != "" {
+		if err := writeArtifact(results); err != nil {
+			return fmt.Errorf("failed to write analysis artifact: %w", err)
+		}
+		slog.InfoContext(ctx, "Wrote analysis artifact", "path", *artifactPath)
 	}
 
 	return nil
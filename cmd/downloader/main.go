@@ -10,6 +10,7 @@ import (
 
 	"github.com/package-url/packageurl-go"
 
+	"github.com/ossf/package-analysis/internal/pkgmanager"
 	"github.com/ossf/package-analysis/internal/worker"
 )
 
@@ -97,6 +98,10 @@ func run() error {
 		return err
 	}
 
+	if err := pkgmanager.ConfigureRegistries(os.Getenv); err != nil {
+		return err
+	}
+
 	purlFile, err := os.Open(*purlFilePath)
 	if err != nil {
 		return err
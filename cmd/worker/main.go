@@ -10,6 +10,9 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
 
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/fileblob"
@@ -20,6 +23,8 @@ import (
 	_ "gocloud.dev/pubsub/kafkapubsub"
 
 	"github.com/ossf/package-analysis/cmd/worker/pubsubextender"
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
 	"github.com/ossf/package-analysis/internal/featureflags"
 	"github.com/ossf/package-analysis/internal/log"
 	"github.com/ossf/package-analysis/internal/notification"
@@ -27,7 +32,9 @@ import (
 	"github.com/ossf/package-analysis/internal/resultstore"
 	"github.com/ossf/package-analysis/internal/sandbox"
 	"github.com/ossf/package-analysis/internal/staticanalysis"
+	"github.com/ossf/package-analysis/internal/telemetry"
 	"github.com/ossf/package-analysis/internal/worker"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
@@ -37,11 +44,12 @@ const (
 
 // resultBucketPaths holds bucket paths for the different types of results.
 type resultBucketPaths struct {
-	analyzedPkg     string
-	dynamicAnalysis string
-	executionLog    string
-	fileWrites      string
-	staticAnalysis  string
+	analyzedPkg               string
+	dynamicAnalysis           string
+	normalizedDynamicAnalysis string
+	executionLog              string
+	fileWrites                string
+	staticAnalysis            string
 }
 
 type sandboxImageSpec struct {
@@ -77,26 +85,36 @@ func copyPackageToLocalFile(ctx context.Context, packagesBucket *blob.Bucket, bu
 	return fmt.Sprintf(localPkgPathFmt, path.Base(bucketPath)), f, nil
 }
 
-func makeResultStores(dest resultBucketPaths) worker.ResultStores {
-	resultStores := worker.ResultStores{}
-
-	if dest.analyzedPkg != "" {
-		resultStores.AnalyzedPackage = resultstore.New(dest.analyzedPkg, resultstore.ConstructPath())
-	}
-	if dest.dynamicAnalysis != "" {
-		resultStores.DynamicAnalysis = resultstore.New(dest.dynamicAnalysis, resultstore.ConstructPath())
-	}
-	if dest.executionLog != "" {
-		resultStores.ExecutionLog = resultstore.New(dest.executionLog, resultstore.ConstructPath())
+// openNDJSONOutput opens the file at path for appending NDJSON records,
+// creating it if necessary. A path of "-" denotes stdout.
+func openNDJSONOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return os.Stdout, nil
 	}
-	if dest.fileWrites != "" {
-		resultStores.FileWrites = resultstore.New(dest.fileWrites, resultstore.ConstructPath())
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+func makeResultStores(dest resultBucketPaths) (worker.ResultStores, error) {
+	var errs []error
+	open := func(dest string) resultstore.ResultStore {
+		if dest == "" {
+			return nil
+		}
+		rs, err := resultstore.Open(dest, resultstore.ConstructPath())
+		errs = append(errs, err)
+		return rs
 	}
-	if dest.staticAnalysis != "" {
-		resultStores.StaticAnalysis = resultstore.New(dest.staticAnalysis, resultstore.ConstructPath())
+
+	resultStores := worker.ResultStores{
+		AnalyzedPackage:           open(dest.analyzedPkg),
+		DynamicAnalysis:           open(dest.dynamicAnalysis),
+		NormalizedDynamicAnalysis: open(dest.normalizedDynamicAnalysis),
+		ExecutionLog:              open(dest.executionLog),
+		FileWrites:                open(dest.fileWrites),
+		StaticAnalysis:            open(dest.staticAnalysis),
 	}
 
-	return resultStores
+	return resultStores, errors.Join(errs...)
 }
 
 func handleMessage(ctx context.Context, msg *pubsub.Message, packagesBucket *blob.Bucket, resultStores *worker.ResultStores, imageSpec sandboxImageSpec, notificationTopic *pubsub.Topic) error {
@@ -132,6 +150,7 @@ func handleMessage(ctx context.Context, msg *pubsub.Message, packagesBucket *blo
 	)
 
 	localPkgPath := ""
+	hostPkgPath := ""
 	sandboxOpts := []sandbox.Option{sandbox.Tag(imageSpec.tag)}
 
 	if remotePkgPath != "" {
@@ -143,6 +162,7 @@ func handleMessage(ctx context.Context, msg *pubsub.Message, packagesBucket *blo
 		defer os.Remove(pkgFile.Name())
 
 		localPkgPath = tmpPkgPath
+		hostPkgPath = pkgFile.Name()
 		sandboxOpts = append(sandboxOpts, sandbox.Volume(pkgFile.Name(), localPkgPath))
 	}
 
@@ -156,22 +176,60 @@ func handleMessage(ctx context.Context, msg *pubsub.Message, packagesBucket *blo
 		return err
 	}
 
+	// Packages from a configured registry or downloader are downloaded here,
+	// so that the registry credentials are not exposed to the sandboxes.
+	if worker.NeedsHostDownload(pkg) {
+		_, download := telemetry.StartStage(ctx, telemetry.StageDownload, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+		archivePath, err := worker.DownloadToTempDir(pkg)
+		download.End(ctx, analysis.StatusForError(err), err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error downloading package", "error", err)
+			return err
+		}
+		defer os.RemoveAll(filepath.Dir(archivePath))
+
+		hostPkgPath = archivePath
+		localPkgPath = fmt.Sprintf(localPkgPathFmt, filepath.Base(archivePath))
+		sandboxOpts = append(sandboxOpts, sandbox.Volume(archivePath, localPkgPath))
+		pkg = manager.Local(pkg.Name(), pkg.Version(), localPkgPath)
+	}
+	// The archive is mounted into the sandboxes at localPkgPath, so it is hashed
+	// at its path on the host.
+	if hostPkgPath != "" {
+		hashed := worker.AttachArtifactHash(ctx, manager.Local(pkg.Name(), pkg.Version(), hostPkgPath))
+		pkg = pkg.WithArtifactSHA256(hashed.ArtifactSHA256())
+	}
+
 	staticSandboxOpts := append(worker.StaticSandboxOptions(), sandboxOpts...)
 	dynamicSandboxOpts := append(worker.DynamicSandboxOptions(), sandboxOpts...)
 
 	// run both dynamic and static analysis regardless of error status of either
 	// and return combined error(s) afterwards, if applicable
-	staticResults, _, staticAnalysisErr := worker.RunStaticAnalysis(ctx, pkg, staticSandboxOpts, staticanalysis.All)
+	staticResults, _, staticAnalysisErr := worker.RunStaticAnalysis(ctx, pkg, staticSandboxOpts, worker.StaticAnalysisScope{}, staticanalysis.All)
 	if staticAnalysisErr == nil {
 		staticAnalysisErr = worker.SaveStaticAnalysisData(ctx, pkg, resultStores, staticResults)
 	}
 
-	result, dynamicAnalysisErr := worker.RunDynamicAnalysis(ctx, pkg, dynamicSandboxOpts, "")
-	if dynamicAnalysisErr == nil {
+	var dynamicData *analysisrun.DynamicAnalysisData
+	var dynamicSkipped string
+	result, dynamicAnalysisErr := worker.RunDynamicAnalysis(ctx, pkg, dynamicSandboxOpts, "", nil, nil)
+	if reason, skipped := worker.DynamicAnalysisSkipped(dynamicAnalysisErr); skipped {
+		slog.WarnContext(ctx, "Dynamic analysis skipped, package analysed statically only", "reason", reason)
+		dynamicSkipped = reason
+		dynamicAnalysisErr = nil
+	} else if dynamicAnalysisErr == nil {
+		dynamicData = &result.Data
+		if staticAnalysisErr == nil && len(staticResults) > 0 {
+			if record, err := worker.StaticAnalysisRecord(pkg, staticResults); err == nil {
+				worker.CrossReferenceLoadedModules(&record.Results, dynamicData)
+			}
+		}
 		dynamicAnalysisErr = worker.SaveDynamicAnalysisData(ctx, pkg, resultStores, result.Data)
 	}
 
-	resultStores.AnalyzedPackageSaved = false
+	if err := worker.StreamPackageSummary(pkg, resultStores, staticResults, dynamicData, dynamicSkipped); err != nil {
+		slog.ErrorContext(ctx, "Failed to stream package summary", "error", err)
+	}
 
 	// combine errors
 	if analysisErr := errors.Join(dynamicAnalysisErr, staticAnalysisErr); analysisErr != nil {
@@ -187,7 +245,11 @@ func handleMessage(ctx context.Context, msg *pubsub.Message, packagesBucket *blo
 	return nil
 }
 
-func messageLoop(ctx context.Context, subURL, packagesBucket, notificationTopicURL string, imageSpec sandboxImageSpec, resultsBuckets *worker.ResultStores) error {
+// messageLoop receives the messages of the subscription at subURL, and handles each
+// one. Up to the limit of limiter messages are handled at the same time, and the
+// next message is only received once a slot of limiter is free, so that messages
+// which cannot be handled yet are left in the subscription for other workers.
+func messageLoop(ctx context.Context, subURL, packagesBucket, notificationTopicURL string, imageSpec sandboxImageSpec, resultsBuckets *worker.ResultStores, limiter *worker.Limiter) error {
 	sub, err := pubsub.OpenSubscription(ctx, subURL)
 	if err != nil {
 		return err
@@ -223,10 +285,19 @@ func messageLoop(ctx context.Context, subURL, packagesBucket, notificationTopicU
 		defer pkgsBkt.Close()
 	}
 
+	// Messages being handled are finished before returning, so that they are acked
+	// or nacked, and the topic and bucket are not closed while in use.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	slog.InfoContext(ctx, "Listening for messages to process...")
 	for {
+		if err := limiter.Acquire(ctx); err != nil {
+			return err
+		}
 		msg, err := sub.Receive(ctx)
 		if err != nil {
+			limiter.Release()
 			// All subsequent receive calls will return the same error, so we bail out.
 			return fmt.Errorf("error receiving message: %w", err)
 		}
@@ -238,6 +309,7 @@ func messageLoop(ctx context.Context, subURL, packagesBucket, notificationTopicU
 			slog.InfoContext(msgCtx, "Message Ack deadline extended", "message_meta", msg.Metadata)
 		})
 		if err != nil {
+			limiter.Release()
 			// If Start fails it will always fail, so we bail out.
 			// Nack the message if we can to indicate the failure.
 			if msg.Nackable() {
@@ -246,20 +318,29 @@ func messageLoop(ctx context.Context, subURL, packagesBucket, notificationTopicU
 			return fmt.Errorf("error starting message ack deadline extender: %w", err)
 		}
 
-		if err := handleMessage(msgCtx, msg, pkgsBkt, resultsBuckets, imageSpec, notificationTopic); err != nil {
-			slog.ErrorContext(msgCtx, "Failed to process message", "error", err)
-			if err := me.Stop(); err != nil {
-				slog.ErrorContext(msgCtx, "Extender failed", "error", err)
-			}
-			if msg.Nackable() {
-				msg.Nack()
-			}
-		} else {
-			if err := me.Stop(); err != nil {
-				slog.ErrorContext(msgCtx, "Extender failed", "error", err)
+		slog.InfoContext(msgCtx, "Handling message", "in_flight", limiter.InFlight(), "concurrency", limiter.Limit())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer limiter.Release()
+
+			// each message has its own copy, as messages are handled concurrently
+			resultStores := *resultsBuckets
+			if err := handleMessage(msgCtx, msg, pkgsBkt, &resultStores, imageSpec, notificationTopic); err != nil {
+				slog.ErrorContext(msgCtx, "Failed to process message", "error", err)
+				if err := me.Stop(); err != nil {
+					slog.ErrorContext(msgCtx, "Extender failed", "error", err)
+				}
+				if msg.Nackable() {
+					msg.Nack()
+				}
+			} else {
+				if err := me.Stop(); err != nil {
+					slog.ErrorContext(msgCtx, "Extender failed", "error", err)
+				}
+				msg.Ack()
 			}
-			msg.Ack()
-		}
+		}()
 	}
 }
 
@@ -272,19 +353,70 @@ func main() {
 	notificationTopicURL := os.Getenv("OSSF_MALWARE_NOTIFICATION_TOPIC")
 	enableProfiler := os.Getenv("OSSF_MALWARE_ANALYSIS_ENABLE_PROFILER")
 
+	// The number of messages handled at the same time defaults to 1.
+	concurrency := 1
+	if v := os.Getenv("OSSF_MALWARE_ANALYSIS_CONCURRENCY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			slog.Error("Invalid analysis concurrency, must be a positive integer", "value", v)
+			os.Exit(1)
+		}
+		concurrency = n
+	}
+	limiter := worker.NewLimiter(concurrency)
+	limiter.Publish("analyses")
+	dynamicanalysis.SetConcurrency(concurrency)
+
 	if err := featureflags.Update(os.Getenv("OSSF_MALWARE_FEATURE_FLAGS")); err != nil {
 		slog.Error("Failed to parse feature flags", "error", err)
 		os.Exit(1)
 	}
 
+	// Traces and metrics are only exported if a collector is configured with
+	// the OTEL_EXPORTER_OTLP_* environment variables.
+	shutdownTelemetry, err := telemetry.Setup(ctx, "package-analysis-worker")
+	if err != nil {
+		slog.Error("Failed to set up telemetry", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTelemetry(ctx); err != nil {
+			slog.Warn("Failed to flush telemetry", "error", err)
+		}
+	}()
+
 	resultsBuckets := resultBucketPaths{
-		analyzedPkg:     os.Getenv("OSSF_MALWARE_ANALYZED_PACKAGES"),
-		dynamicAnalysis: os.Getenv("OSSF_MALWARE_ANALYSIS_RESULTS"),
-		executionLog:    os.Getenv("OSSF_MALWARE_ANALYSIS_EXECUTION_LOGS"),
-		fileWrites:      os.Getenv("OSSF_MALWARE_ANALYSIS_FILE_WRITE_RESULTS"),
-		staticAnalysis:  os.Getenv("OSSF_MALWARE_STATIC_ANALYSIS_RESULTS"),
+		analyzedPkg:               os.Getenv("OSSF_MALWARE_ANALYZED_PACKAGES"),
+		dynamicAnalysis:           os.Getenv("OSSF_MALWARE_ANALYSIS_RESULTS"),
+		normalizedDynamicAnalysis: os.Getenv("OSSF_MALWARE_ANALYSIS_NORMALIZED_RESULTS"),
+		executionLog:              os.Getenv("OSSF_MALWARE_ANALYSIS_EXECUTION_LOGS"),
+		fileWrites:                os.Getenv("OSSF_MALWARE_ANALYSIS_FILE_WRITE_RESULTS"),
+		staticAnalysis:            os.Getenv("OSSF_MALWARE_STATIC_ANALYSIS_RESULTS"),
+	}
+	resultStores, err := makeResultStores(resultsBuckets)
+	if err != nil {
+		slog.Error("Failed to open result stores", "error", err)
+		os.Exit(1)
+	}
+
+	// If configured, stream a summary of each analyzed package as NDJSON.
+	if ndjsonOutput := os.Getenv("OSSF_MALWARE_ANALYSIS_NDJSON_OUTPUT"); ndjsonOutput != "" {
+		stream, err := openNDJSONOutput(ndjsonOutput)
+		if err != nil {
+			slog.Error("Failed to open NDJSON output", "path", ndjsonOutput, "error", err)
+			os.Exit(1)
+		}
+		defer stream.Close()
+		resultStores.Stream = worker.NewNDJSONWriter(stream)
+	}
+
+	if err := pkgmanager.ConfigureRegistries(os.Getenv); err != nil {
+		slog.Error("Failed to configure package registries", "error", err)
+		os.Exit(1)
+	}
+	for ecosystem, registry := range pkgmanager.Registries() {
+		slog.Info("Using package registry", "ecosystem", ecosystem, "registry", registry)
 	}
-	resultStores := makeResultStores(resultsBuckets)
 
 	imageSpec := sandboxImageSpec{
 		tag:    os.Getenv("OSSF_SANDBOX_IMAGE_TAG"),
@@ -294,7 +426,8 @@ func main() {
 	sandbox.InitNetwork(ctx)
 
 	// If configured, start a webserver so that Go's pprof can be accessed for
-	// debugging and profiling.
+	// debugging and profiling. It also serves the number of analyses in flight
+	// at /debug/vars.
 	if enableProfiler != "" {
 		go func() {
 			slog.Info("Starting profiler")
@@ -307,17 +440,20 @@ func main() {
 		"subscription", subURL,
 		"package_bucket", packagesBucket,
 		"results_bucket", resultsBuckets.dynamicAnalysis,
+		"normalized_results_bucket", resultsBuckets.normalizedDynamicAnalysis,
 		"static_results_bucket", resultsBuckets.staticAnalysis,
 		"file_write_results_bucket", resultsBuckets.fileWrites,
 		"analyzed_packages_bucket", resultsBuckets.analyzedPkg,
 		"execution_log_bucket", resultsBuckets.executionLog,
+		"ndjson_output", os.Getenv("OSSF_MALWARE_ANALYSIS_NDJSON_OUTPUT"),
 		"image_tag", imageSpec.tag,
 		"image_nopull", imageSpec.noPull,
 		"topic_notification", notificationTopicURL,
 		"feature_flags", featureflags.State(),
+		"concurrency", concurrency,
 	)
 
-	err := messageLoop(ctx, subURL, packagesBucket, notificationTopicURL, imageSpec, &resultStores)
+	err = messageLoop(ctx, subURL, packagesBucket, notificationTopicURL, imageSpec, &resultStores, limiter)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error encountered", "error", err)
 	}
@@ -45,3 +45,12 @@ func StatusForRunResult(r *sandbox.RunResult) Status {
 		return StatusErrorOther
 	}
 }
+
+// StatusForError returns the status of a step which either completes or fails
+// with err: StatusCompleted if err is nil, and StatusErrorOther otherwise.
+func StatusForError(err error) Status {
+	if err != nil {
+		return StatusErrorOther
+	}
+	return StatusCompleted
+}
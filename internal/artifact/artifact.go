@@ -0,0 +1,467 @@
+/*
+Package artifact persists the full analysis of a package to a directory (or a
+.tar.gz archive of one), so that it can be archived and later re-opened for
+offline analysis.
+
+The layout of an artifact directory is:
+
+	manifest.json                       Manifest: layout version, package, whether dynamic analysis was skipped, and checksums of all other files
+	static.json                         static analysis results (staticanalysis.Record)
+	verdict.json                        overall verdict for the package (verdict.Verdict)
+	dynamic/execution.log               execution log of the execute phase
+	dynamic/<phase>/strace.json         strace summary of a dynamic analysis phase (analysisrun.StraceSummary)
+	dynamic/<phase>/file_writes.json    file writes summary of the phase (analysisrun.FileWritesSummary)
+	dynamic/<phase>/write_buffer_ids.json  IDs of the write buffers saved for the phase
+	dynamic/<phase>/strace.log          raw strace log of the phase
+
+Only manifest.json is required; other files are present if the corresponding data
+was collected. Raw strace logs can be analyzed again with dynamicanalysis.AnalyzeStraceLogFile.
+*/
+package artifact
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/internal/verdict"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// LayoutVersion is the version of the artifact layout written by this package.
+// It is incremented when the layout changes in a way that older loaders cannot read.
+const LayoutVersion = 1
+
+const (
+	ManifestFilename = "manifest.json"
+
+	staticFilename         = "static.json"
+	verdictFilename        = "verdict.json"
+	dynamicDir             = "dynamic"
+	executionLogFilename   = "execution.log"
+	straceSummaryFilename  = "strace.json"
+	fileWritesFilename     = "file_writes.json"
+	writeBufferIdsFilename = "write_buffer_ids.json"
+	straceLogFilename      = "strace.log"
+)
+
+var (
+	// ErrNotArtifact is returned when loading a directory that has no manifest.
+	ErrNotArtifact = errors.New("not an analysis artifact")
+
+	// ErrUnsupportedLayout is returned when loading an artifact written with a
+	// newer layout version.
+	ErrUnsupportedLayout = errors.New("unsupported artifact layout version")
+
+	// ErrCorrupt is returned when a file of an artifact is missing or does not
+	// match the checksum in the manifest.
+	ErrCorrupt = errors.New("corrupt artifact")
+)
+
+// Artifact holds the full analysis of a single package.
+type Artifact struct {
+	Package analysisrun.Key
+	// CreatedTimestamp is the time the artifact was written, in Unix seconds.
+	CreatedTimestamp int64
+	// ArtifactSHA256 is the SHA256 hash of the analysed package archive, if it
+	// was computed.
+	ArtifactSHA256 string
+	// GitSource is the git repository the package was fetched from, if it was
+	// analysed from git.
+	GitSource *analysisrun.GitSource
+	// Static holds the static analysis results, or nil if static analysis was not run.
+	Static *staticanalysis.Record
+	// Dynamic holds the dynamic analysis results, or nil if dynamic analysis was not run.
+	Dynamic *analysisrun.DynamicAnalysisData
+	// DynamicSkipped is the reason dynamic analysis was skipped, if the package
+	// was only analysed statically because the sandbox was unavailable.
+	DynamicSkipped string
+	// Verdict holds the verdict computed from the results, if any.
+	Verdict *verdict.Verdict
+	// StraceLogs holds the paths of the raw strace logs of each dynamic analysis phase.
+	// It is optional; when written, each log is copied into the artifact, and when
+	// loaded, the paths refer to the copies inside the artifact directory.
+	StraceLogs map[analysisrun.DynamicPhase]string
+}
+
+// Manifest describes the contents of an artifact.
+type Manifest struct {
+	LayoutVersion    int             `json:"layout_version"`
+	Package          analysisrun.Key `json:"package"`
+	CreatedTimestamp int64           `json:"created_timestamp"`
+	// ArtifactSHA256 is the SHA256 hash of the analysed package archive, if known.
+	ArtifactSHA256 string `json:"artifact_sha256,omitempty"`
+	// GitSource is the git repository the package was fetched from, if it was.
+	GitSource *analysisrun.GitSource `json:"git_source,omitempty"`
+	// DynamicSkipped is the reason dynamic analysis was skipped, if it was.
+	DynamicSkipped string `json:"dynamic_skipped,omitempty"`
+	// Files lists every file of the artifact other than the manifest, ordered by path.
+	Files []File `json:"files"`
+}
+
+// File describes a single file of an artifact. Path uses forward slashes and is
+// relative to the root of the artifact.
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// writer writes the files of an artifact into its directory.
+type writer struct {
+	dir   string
+	files []File
+}
+
+// create creates the file at the slash-separated relative path p, and calls
+// write to write its contents.
+func (w *writer) create(p string, write func(io.Writer) error) error {
+	fullPath := filepath.Join(w.dir, filepath.FromSlash(p))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", p, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	file, err := describeFile(w.dir, p)
+	if err != nil {
+		return err
+	}
+	w.files = append(w.files, file)
+	return nil
+}
+
+func (w *writer) writeJSON(p string, v any) error {
+	return w.create(p, func(out io.Writer) error {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	})
+}
+
+func (w *writer) copyFile(p, src string) error {
+	return w.create(p, func(out io.Writer) error {
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
+
+// describeFile returns the size and checksum of the file at relative path p in dir.
+func describeFile(dir, p string) (File, error) {
+	fullPath := filepath.Join(dir, filepath.FromSlash(p))
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return File{}, err
+	}
+	hash, err := utils.SHA256Hash(fullPath)
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: p, Size: info.Size(), SHA256: hash}, nil
+}
+
+// phases returns the dynamic analysis phases that a has any data for, in the order
+// they are run (see analysisrun.SortDynamicPhases).
+func (a *Artifact) phases() []analysisrun.DynamicPhase {
+	seen := make(map[analysisrun.DynamicPhase]bool)
+	if a.Dynamic != nil {
+		for phase := range a.Dynamic.StraceSummary {
+			seen[phase] = true
+		}
+		for phase := range a.Dynamic.FileWritesSummary {
+			seen[phase] = true
+		}
+		for phase := range a.Dynamic.FileWriteBufferIds {
+			seen[phase] = true
+		}
+	}
+	for phase := range a.StraceLogs {
+		seen[phase] = true
+	}
+
+	phases := make([]analysisrun.DynamicPhase, 0, len(seen))
+	for phase := range seen {
+		phases = append(phases, phase)
+	}
+	analysisrun.SortDynamicPhases(phases)
+	return phases
+}
+
+/*
+Write writes the artifact into dir, which is created if it does not exist. The
+manifest is written last, so an artifact that was only partially written (e.g.
+due to an error) cannot be loaded.
+*/
+func Write(dir string, a *Artifact) error {
+	w := &writer{dir: dir}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	// Remove any manifest of a previous artifact until this one is complete.
+	if err := os.Remove(filepath.Join(dir, ManifestFilename)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if a.Static != nil {
+		if err := w.writeJSON(staticFilename, a.Static); err != nil {
+			return err
+		}
+	}
+
+	if a.Verdict != nil {
+		if err := w.writeJSON(verdictFilename, a.Verdict); err != nil {
+			return err
+		}
+	}
+
+	if a.Dynamic != nil && a.Dynamic.ExecutionLog != "" {
+		err := w.create(path.Join(dynamicDir, executionLogFilename), func(out io.Writer) error {
+			_, err := io.WriteString(out, string(a.Dynamic.ExecutionLog))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, phase := range a.phases() {
+		phaseDir := path.Join(dynamicDir, string(phase))
+		if a.Dynamic != nil {
+			if s := a.Dynamic.StraceSummary[phase]; s != nil {
+				if err := w.writeJSON(path.Join(phaseDir, straceSummaryFilename), s); err != nil {
+					return err
+				}
+			}
+			if f := a.Dynamic.FileWritesSummary[phase]; f != nil {
+				if err := w.writeJSON(path.Join(phaseDir, fileWritesFilename), f); err != nil {
+					return err
+				}
+			}
+			if ids := a.Dynamic.FileWriteBufferIds[phase]; len(ids) > 0 {
+				if err := w.writeJSON(path.Join(phaseDir, writeBufferIdsFilename), ids); err != nil {
+					return err
+				}
+			}
+		}
+		if logPath := a.StraceLogs[phase]; logPath != "" {
+			if err := w.copyFile(path.Join(phaseDir, straceLogFilename), logPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	slices.SortFunc(w.files, func(a, b File) int { return strings.Compare(a.Path, b.Path) })
+	manifest := Manifest{
+		LayoutVersion:    LayoutVersion,
+		Package:          a.Package,
+		CreatedTimestamp: a.CreatedTimestamp,
+		ArtifactSHA256:   a.ArtifactSHA256,
+		GitSource:        a.GitSource,
+		DynamicSkipped:   a.DynamicSkipped,
+		Files:            w.files,
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFilename), append(data, '\n'), 0o644)
+}
+
+// WriteTarball writes the artifact as a .tar.gz archive to out. The archive holds
+// the files of the artifact directory (see Write), and can be loaded by LoadTarball.
+func WriteTarball(out io.Writer, a *Artifact) error {
+	dir, err := os.MkdirTemp("", "artifact-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Write(dir, a); err != nil {
+		return err
+	}
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	paths := append([]string{ManifestFilename}, utils.Transform(manifest.Files, func(f File) string { return f.Path })...)
+	for _, p := range paths {
+		if err := addToTar(tw, dir, p); err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", p, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToTar(tw *tar.Writer, dir, p string) error {
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(p)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     p,
+		Size:     info.Size(),
+		Mode:     0o644,
+		ModTime:  info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func readManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s has no %s", ErrNotArtifact, dir, ManifestFilename)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest: %w", ErrCorrupt, err)
+	}
+	if manifest.LayoutVersion < 1 || manifest.LayoutVersion > LayoutVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedLayout, manifest.LayoutVersion)
+	}
+	return &manifest, nil
+}
+
+func readJSON(dir, p string, v any) error {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: invalid %s: %w", ErrCorrupt, p, err)
+	}
+	return nil
+}
+
+/*
+Load reads the artifact in dir, which was written by Write. The files listed in
+the manifest are checked against their checksums before being read, and files
+not listed in the manifest are ignored.
+*/
+func Load(dir string) (*Artifact, error) {
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Artifact{
+		Package:          manifest.Package,
+		CreatedTimestamp: manifest.CreatedTimestamp,
+		ArtifactSHA256:   manifest.ArtifactSHA256,
+		GitSource:        manifest.GitSource,
+		DynamicSkipped:   manifest.DynamicSkipped,
+	}
+	dynamic := func() *analysisrun.DynamicAnalysisData {
+		if a.Dynamic == nil {
+			a.Dynamic = &analysisrun.DynamicAnalysisData{
+				StraceSummary:      analysisrun.DynamicAnalysisStraceSummary{},
+				FileWritesSummary:  analysisrun.DynamicAnalysisFileWritesSummary{},
+				FileWriteBufferIds: analysisrun.DynamicAnalysisFileWriteBufferIds{},
+			}
+		}
+		return a.Dynamic
+	}
+
+	for _, f := range manifest.Files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return nil, fmt.Errorf("%w: invalid path %q", ErrCorrupt, f.Path)
+		}
+		if actual, err := describeFile(dir, f.Path); err != nil || actual != f {
+			return nil, fmt.Errorf("%w: %s is missing or does not match the manifest", ErrCorrupt, f.Path)
+		}
+
+		switch parts := strings.Split(f.Path, "/"); {
+		case f.Path == staticFilename:
+			a.Static = &staticanalysis.Record{}
+			err = readJSON(dir, f.Path, a.Static)
+		case f.Path == verdictFilename:
+			a.Verdict = &verdict.Verdict{}
+			err = readJSON(dir, f.Path, a.Verdict)
+		case f.Path == path.Join(dynamicDir, executionLogFilename):
+			var data []byte
+			data, err = os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+			dynamic().ExecutionLog = analysisrun.DynamicAnalysisExecutionLog(data)
+		case len(parts) == 3 && parts[0] == dynamicDir:
+			phase := analysisrun.DynamicPhase(parts[1])
+			switch parts[2] {
+			case straceSummaryFilename:
+				s := &analysisrun.StraceSummary{}
+				err = readJSON(dir, f.Path, s)
+				dynamic().StraceSummary[phase] = s
+			case fileWritesFilename:
+				w := &analysisrun.FileWritesSummary{}
+				err = readJSON(dir, f.Path, w)
+				dynamic().FileWritesSummary[phase] = w
+			case writeBufferIdsFilename:
+				var ids []string
+				err = readJSON(dir, f.Path, &ids)
+				dynamic().FileWriteBufferIds[phase] = ids
+			case straceLogFilename:
+				if a.StraceLogs == nil {
+					a.StraceLogs = map[analysisrun.DynamicPhase]string{}
+				}
+				a.StraceLogs[phase] = filepath.Join(dir, filepath.FromSlash(f.Path))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+	}
+
+	return a, nil
+}
+
+// LoadTarball extracts the artifact archive at tarballPath (written by WriteTarball)
+// into dir, and loads it as described by Load.
+func LoadTarball(tarballPath, dir string) (*Artifact, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := utils.ExtractTarGzFile(tarballPath, dir); err != nil {
+		return nil, fmt.Errorf("failed to extract artifact: %w", err)
+	}
+	return Load(dir)
+}
@@ -0,0 +1,269 @@
+package artifact
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/verdict"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+func testArtifact(t *testing.T) *Artifact {
+	t.Helper()
+	logPath := filepath.Join(t.TempDir(), "install.log")
+	if err := os.WriteFile(logPath, []byte("1 execve(\"/usr/bin/npm\") = 0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Artifact{
+		Package:          analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "left-pad", Version: "1.3.0"},
+		CreatedTimestamp: 1700000000,
+		ArtifactSHA256:   "e2f38b701acd56d4fbd0e8f1ea7d9c8a7b3b5e2d0d4597e2e5b4c1ac8e3b1f3c",
+		GitSource: &analysisrun.GitSource{
+			Repository: "https://github.com/left-pad/left-pad",
+			Ref:        "v1.3.0",
+			Commit:     "5c6ff1af7b1d7a8b5e0f3f5e9a3e6e5ab5f4e7c2",
+		},
+		Static: &staticanalysis.Record{
+			SchemaVersion:  staticanalysis.SchemaVersion,
+			Ecosystem:      "npm",
+			Name:           "left-pad",
+			Version:        "1.3.0",
+			Created:        time.Unix(1700000000, 0).UTC(),
+			ArtifactSHA256: "e2f38b701acd56d4fbd0e8f1ea7d9c8a7b3b5e2d0d4597e2e5b4c1ac8e3b1f3c",
+			Results: staticanalysis.Results{
+				Files: []staticanalysis.FileResult{{Filename: "index.js", Size: 100}},
+			},
+		},
+		Dynamic: &analysisrun.DynamicAnalysisData{
+			StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+				analysisrun.DynamicPhaseInstall: {
+					Status:       analysis.StatusCompleted,
+					Files:        []analysisrun.FileResult{{Path: "/app/index.js", Read: true}},
+					SyscallCount: 42,
+				},
+				analysisrun.DynamicPhaseImport: {Status: analysis.StatusCompleted},
+			},
+			FileWritesSummary: analysisrun.DynamicAnalysisFileWritesSummary{
+				analysisrun.DynamicPhaseInstall: {{Path: "/tmp/a", WriteInfo: []analysisrun.WriteInfo{{WriteBufferId: "abc", BytesWritten: 5}}}},
+			},
+			FileWriteBufferIds: analysisrun.DynamicAnalysisFileWriteBufferIds{
+				analysisrun.DynamicPhaseInstall: {"abc"},
+			},
+			ExecutionLog: "executed index.js\n",
+		},
+		Verdict: &verdict.Verdict{
+			Label: verdict.Suspicious,
+			Score: 4,
+			Contributions: []verdict.Contribution{
+				{Rule: verdict.RuleIndirectEval, Weight: 2, Count: 2, Score: 4, Examples: []string{"index.js: (0, eval)"}},
+			},
+		},
+		StraceLogs: map[analysisrun.DynamicPhase]string{
+			analysisrun.DynamicPhaseInstall: logPath,
+		},
+	}
+}
+
+// checkLoaded compares a loaded artifact with the original one, from which it
+// differs only in the paths of the strace logs.
+func checkLoaded(t *testing.T, dir string, got, want *Artifact) {
+	t.Helper()
+	for phase, logPath := range got.StraceLogs {
+		gotLog, err := os.ReadFile(logPath)
+		if err != nil {
+			t.Fatalf("failed to read strace log of %s: %v", phase, err)
+		}
+		wantLog, _ := os.ReadFile(want.StraceLogs[phase])
+		if !bytes.Equal(gotLog, wantLog) {
+			t.Errorf("strace log of %s = %q, want %q", phase, gotLog, wantLog)
+		}
+		if rel, err := filepath.Rel(dir, logPath); err != nil || !filepath.IsLocal(rel) {
+			t.Errorf("strace log of %s is at %s, want a path inside %s", phase, logPath, dir)
+		}
+	}
+	if len(got.StraceLogs) != len(want.StraceLogs) {
+		t.Errorf("Load() StraceLogs = %v, want phases of %v", got.StraceLogs, want.StraceLogs)
+	}
+
+	gotCopy, wantCopy := *got, *want
+	gotCopy.StraceLogs, wantCopy.StraceLogs = nil, nil
+	if !reflect.DeepEqual(gotCopy, wantCopy) {
+		t.Errorf("Load() = %+v\nwant %+v", gotCopy, wantCopy)
+	}
+}
+
+func TestWriteLoad(t *testing.T) {
+	dir := t.TempDir()
+	want := testArtifact(t)
+	if err := Write(dir, want); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	checkLoaded(t, dir, got, want)
+
+	manifest, err := readManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range manifest.Files {
+		paths = append(paths, f.Path)
+	}
+	wantPaths := []string{
+		"dynamic/execution.log",
+		"dynamic/import/strace.json",
+		"dynamic/install/file_writes.json",
+		"dynamic/install/strace.json",
+		"dynamic/install/strace.log",
+		"dynamic/install/write_buffer_ids.json",
+		"static.json",
+		"verdict.json",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("manifest files = %v, want %v", paths, wantPaths)
+	}
+}
+
+func TestPhases(t *testing.T) {
+	a := testArtifact(t)
+	a.StraceLogs[analysisrun.DynamicPhaseExecute] = a.StraceLogs[analysisrun.DynamicPhaseInstall]
+
+	want := []analysisrun.DynamicPhase{analysisrun.DynamicPhaseInstall, analysisrun.DynamicPhaseImport, analysisrun.DynamicPhaseExecute}
+	if got := a.phases(); !reflect.DeepEqual(got, want) {
+		t.Errorf("phases() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteLoadEmpty(t *testing.T) {
+	dir := t.TempDir()
+	want := &Artifact{Package: analysisrun.Key{Ecosystem: pkgecosystem.PyPI, Name: "requests"}}
+	if err := Write(dir, want); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteLoadDynamicSkipped(t *testing.T) {
+	dir := t.TempDir()
+	want := testArtifact(t)
+	want.Dynamic = nil
+	want.StraceLogs = nil
+	want.DynamicSkipped = "sandbox unavailable: error creating container: exit status 125"
+	if err := Write(dir, want); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTarball(t *testing.T) {
+	want := testArtifact(t)
+	tarballPath := filepath.Join(t.TempDir(), "artifact.tar.gz")
+	f, err := os.Create(tarballPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTarball(f, want); err != nil {
+		t.Fatalf("WriteTarball() error = %v", err)
+	}
+	f.Close()
+
+	dir := t.TempDir()
+	got, err := LoadTarball(tarballPath, dir)
+	if err != nil {
+		t.Fatalf("LoadTarball() error = %v", err)
+	}
+	checkLoaded(t, dir, got, want)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(t *testing.T, dir string)
+		wantErr error
+	}{
+		{
+			name: "no manifest",
+			modify: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, ManifestFilename)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrNotArtifact,
+		},
+		{
+			name: "newer layout",
+			modify: func(t *testing.T, dir string) {
+				data := []byte(`{"layout_version": 99, "files": []}`)
+				if err := os.WriteFile(filepath.Join(dir, ManifestFilename), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrUnsupportedLayout,
+		},
+		{
+			name: "modified file",
+			modify: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "static.json"), []byte("{}"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrCorrupt,
+		},
+		{
+			name: "missing file",
+			modify: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "dynamic", "install", "strace.json")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrCorrupt,
+		},
+		{
+			name: "path outside artifact",
+			modify: func(t *testing.T, dir string) {
+				data := []byte(`{"layout_version": 1, "files": [{"path": "../static.json"}]}`)
+				if err := os.WriteFile(filepath.Join(dir, ManifestFilename), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: ErrCorrupt,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := Write(dir, testArtifact(t)); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(t, dir)
+			if _, err := Load(dir); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Load() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
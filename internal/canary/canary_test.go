@@ -0,0 +1,74 @@
+package canary
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestGenerate(t *testing.T) {
+	files, err := Generate()
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(files) != len(templates) {
+		t.Fatalf("Generate() returned %d files, want %d", len(files), len(templates))
+	}
+
+	tokens := make(map[string]bool)
+	for _, f := range files {
+		if !strings.HasPrefix(f.Path, sandboxHomeDir+"/") {
+			t.Errorf("canary path %s is not in %s", f.Path, sandboxHomeDir)
+		}
+		if !strings.HasPrefix(f.Token, tokenPrefix) {
+			t.Errorf("canary token %s does not have prefix %s", f.Token, tokenPrefix)
+		}
+		if !bytes.Contains(f.Contents, []byte(f.Token)) {
+			t.Errorf("contents of %s do not contain token: %q", f.Path, f.Contents)
+		}
+		if bytes.Contains(f.Contents, []byte("%!")) {
+			t.Errorf("contents of %s have formatting error: %q", f.Path, f.Contents)
+		}
+		if tokens[f.Token] {
+			t.Errorf("duplicate canary token %s", f.Token)
+		}
+		tokens[f.Token] = true
+	}
+}
+
+func TestResults(t *testing.T) {
+	files := []File{
+		{Path: "/root/.aws/credentials", Token: "pkgcanary01"},
+		{Path: "/root/.ssh/id_ed25519", Token: "pkgcanary02"},
+		{Path: "/root/.kube/config", Token: "pkgcanary03"},
+	}
+
+	d := NewDetector(files)
+	d.Scan([]byte("POST / HTTP/1.1\r\n\r\nkey=pkgcanary02"))
+	d.Scan(nil)
+
+	accessed := []analysisrun.FileResult{
+		{Path: "/root/.aws/credentials", Read: true},
+		{Path: "/root/.ssh/id_ed25519", Read: true},
+		{Path: "/root/.kube/config", Write: true},
+	}
+
+	got := Results(files, accessed, d)
+	want := []analysisrun.CanaryResult{
+		{Path: "/root/.aws/credentials", Read: true, Exfiltrated: false},
+		{Path: "/root/.ssh/id_ed25519", Read: true, Exfiltrated: true},
+		{Path: "/root/.kube/config", Read: false, Exfiltrated: false},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Results() = %v, want %v", got, want)
+	}
+
+	gotNoDetector := Results(files[:1], accessed, nil)
+	wantNoDetector := []analysisrun.CanaryResult{{Path: "/root/.aws/credentials", Read: true}}
+	if !reflect.DeepEqual(gotNoDetector, wantNoDetector) {
+		t.Errorf("Results() with nil detector = %v, want %v", gotNoDetector, wantNoDetector)
+	}
+}
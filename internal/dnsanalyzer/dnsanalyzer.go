@@ -9,20 +9,39 @@ import (
 
 type empty struct{}
 
+type endpoint struct {
+	address string
+	port    int
+}
+
+// DNSAnalyzer collects the DNS queries made during analysis, and the hostnames
+// that IP addresses were resolved from. It also records the TCP connections that
+// were opened, so that connections made to an address without first resolving it
+// can be told apart (see Resolved).
 type DNSAnalyzer struct {
 	ipHostnames map[string]map[string]empty
 	questions   map[layers.DNSClass]map[string]map[layers.DNSType]empty
+
+	// layers counts the layers received, which are received in capture order.
+	layers int
+	// resolved and connected hold the number of layers received before each IP
+	// address was first returned in a DNS reply, and before the first TCP
+	// connection to each endpoint was opened.
+	resolved  map[string]int
+	connected map[endpoint]int
 }
 
 func New() *DNSAnalyzer {
 	return &DNSAnalyzer{
 		ipHostnames: make(map[string]map[string]empty),
 		questions:   make(map[layers.DNSClass]map[string]map[layers.DNSType]empty),
+		resolved:    make(map[string]int),
+		connected:   make(map[endpoint]int),
 	}
 }
 
 func (d *DNSAnalyzer) LayerTypes() []gopacket.LayerType {
-	return []gopacket.LayerType{layers.LayerTypeDNS}
+	return []gopacket.LayerType{layers.LayerTypeDNS, layers.LayerTypeTCP}
 }
 
 func (d *DNSAnalyzer) addQuestion(l *layers.DNS) {
@@ -72,6 +91,9 @@ func (d *DNSAnalyzer) addIPHostnames(l *layers.DNS) {
 			continue
 		}
 		ip := a.IP.String()
+		if _, exists := d.resolved[ip]; !exists {
+			d.resolved[ip] = d.layers
+		}
 		if _, exists := d.ipHostnames[ip]; !exists {
 			d.ipHostnames[ip] = make(map[string]empty)
 		}
@@ -81,7 +103,26 @@ func (d *DNSAnalyzer) addIPHostnames(l *layers.DNS) {
 	}
 }
 
+// addConnection records the first TCP connection opened to the destination of
+// p, which is a packet with the SYN flag (but not ACK) set.
+func (d *DNSAnalyzer) addConnection(tcp *layers.TCP, p gopacket.Packet) {
+	if !tcp.SYN || tcp.ACK || p.NetworkLayer() == nil {
+		return
+	}
+	dst := net.IP(p.NetworkLayer().NetworkFlow().Dst().Raw())
+	key := endpoint{address: dst.String(), port: int(tcp.DstPort)}
+	if _, exists := d.connected[key]; !exists {
+		d.connected[key] = d.layers
+	}
+}
+
 func (d *DNSAnalyzer) Receive(l gopacket.Layer, p gopacket.Packet) {
+	d.layers++
+	if tcp, ok := l.(*layers.TCP); ok {
+		d.addConnection(tcp, p)
+		return
+	}
+
 	// The layer must be DNS.
 	dns, ok := l.(*layers.DNS)
 	if !ok {
@@ -117,6 +158,26 @@ func (d *DNSAnalyzer) Hostnames(address string) []string {
 	return []string{}
 }
 
+/*
+Resolved returns whether the given IP address was returned by a DNS lookup before
+a connection was made to it on the given port, as opposed to being connected to
+directly (e.g. because it is hardcoded in the package). If no TCP connection to
+the address and port was captured (e.g. for UDP, or connections that could not
+be made), Resolved returns whether the address was returned by any lookup.
+*/
+func (d *DNSAnalyzer) Resolved(address string, port int) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	resolved, ok := d.resolved[ip.String()]
+	if !ok {
+		return false
+	}
+	connected, ok := d.connected[endpoint{address: ip.String(), port: port}]
+	return !ok || resolved < connected
+}
+
 // Questions returns all the DNS queries captured during the analysis run.
 //
 // Returns a map where each key is the DNS class (e.g. "IN" or "CH") and the
@@ -0,0 +1,83 @@
+package dnsanalyzer
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+)
+
+func serialize(t *testing.T, ls ...gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ls...); err != nil {
+		t.Fatal(err)
+	}
+	return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+}
+
+// reply returns a DNS reply resolving hostname to address.
+func reply(t *testing.T, hostname, address string) gopacket.Packet {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.IPv4(8, 8, 8, 8), DstIP: net.IPv4(10, 0, 0, 2)}
+	udp := &layers.UDP{SrcPort: 53, DstPort: 40000}
+	if err := udp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+	dns := &layers.DNS{
+		QR:        true,
+		Questions: []layers.DNSQuestion{{Name: []byte(hostname), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+		Answers:   []layers.DNSResourceRecord{{Name: []byte(hostname), Type: layers.DNSTypeA, Class: layers.DNSClassIN, IP: net.ParseIP(address)}},
+	}
+	return serialize(t, ip, udp, dns)
+}
+
+// syn returns the first packet of a TCP connection to address and port.
+func syn(t *testing.T, address string, port int) gopacket.Packet {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.IPv4(10, 0, 0, 2), DstIP: net.ParseIP(address)}
+	tcp := &layers.TCP{SrcPort: 40001, DstPort: layers.TCPPort(port), SYN: true}
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+	return serialize(t, ip, tcp)
+}
+
+func TestResolved(t *testing.T) {
+	analyzer := New()
+	for _, p := range []gopacket.Packet{
+		syn(t, "203.0.113.9", 443),
+		reply(t, "cdn.example.com", "203.0.113.5"),
+		syn(t, "203.0.113.5", 443),
+		syn(t, "198.51.100.7", 4444),
+		// resolved only after the connection was made
+		reply(t, "late.example.com", "203.0.113.9"),
+	} {
+		for _, lt := range analyzer.LayerTypes() {
+			if l := p.Layer(lt); l != nil {
+				analyzer.Receive(l, p)
+			}
+		}
+	}
+
+	tests := []struct {
+		address string
+		port    int
+		want    bool
+	}{
+		{"203.0.113.5", 443, true},
+		{"203.0.113.5", 53, true},
+		{"198.51.100.7", 4444, false},
+		{"203.0.113.9", 443, false},
+		{"203.0.113.9", 80, true},
+		{"not an ip", 443, false},
+	}
+	for _, tt := range tests {
+		if got := analyzer.Resolved(tt.address, tt.port); got != tt.want {
+			t.Errorf("Resolved(%q, %d) = %v, want %v", tt.address, tt.port, got, tt.want)
+		}
+	}
+	if got := analyzer.Hostnames("203.0.113.5"); len(got) != 1 || got[0] != "cdn.example.com" {
+		t.Errorf("Hostnames() = %v, want [cdn.example.com]", got)
+	}
+}
@@ -3,13 +3,18 @@ package dynamicanalysis
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"slices"
+	"sync"
 
 	"github.com/ossf/package-analysis/internal/analysis"
 	"github.com/ossf/package-analysis/internal/dnsanalyzer"
 	"github.com/ossf/package-analysis/internal/packetcapture"
 	"github.com/ossf/package-analysis/internal/sandbox"
 	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/internal/tlsanalyzer"
 	"github.com/ossf/package-analysis/internal/utils"
 	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 )
@@ -24,6 +29,9 @@ type Result struct {
 	// IDs that correlate to the name of the file that saves the actual write buffer contents.
 	// We save this separately so that we don't need to dig through the FileWritesSummary later on.
 	FileWriteBufferIds []string
+	// RawStraceLog holds the strace log of the run as produced, if WithRawStraceLog
+	// was used. It may have had its middle part removed (see WithRawStraceLog).
+	RawStraceLog []byte
 }
 
 var resultError = &Result{
@@ -32,14 +40,154 @@ var resultError = &Result{
 	},
 }
 
-func Run(ctx context.Context, sb sandbox.Sandbox, command string, args []string, straceLogger *slog.Logger) (*Result, error) {
+var (
+	// runSlots holds a token for each command run by Run at the same time (see
+	// SetConcurrency).
+	runSlots   = make(chan struct{}, 1)
+	runSlotsMu sync.Mutex
+)
+
+/*
+SetConcurrency sets the number of commands that Run runs at the same time to n,
+which should be the number of analyses that are run concurrently (e.g. the limit of
+a worker.Limiter). Calls to Run beyond the limit wait for a command to finish. A
+limit less than 1 is treated as 1, which is the default.
+
+All sandboxes are attached to sandbox.NetworkInterface, and the packet capture is
+not filtered by container, so the DNS and TLS results of commands which run at the
+same time may include the traffic of each other. Sockets, files and commands are
+traced per sandbox and are not affected.
+
+SetConcurrency should be called before Run is first called; commands already
+running are not counted against the new limit.
+*/
+func SetConcurrency(n int) {
+	runSlotsMu.Lock()
+	defer runSlotsMu.Unlock()
+	runSlots = make(chan struct{}, max(n, 1))
+}
+
+func currentRunSlots() chan struct{} {
+	runSlotsMu.Lock()
+	defer runSlotsMu.Unlock()
+	return runSlots
+}
+
+// RunOption configures optional behaviour of Run.
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	packetReceivers []packetcapture.PacketReceiver
+	env             map[string]string
+	minimalCapture  bool
+	rawLogBytes     int
+	packageName     string
+	stores          []CredentialStore
+	tamperingRules  []TamperingRule
+	networkDenied   bool
+}
+
+// WithPacketReceiver registers an extra receiver for the packets captured from
+// the sandbox network while the command is running.
+func WithPacketReceiver(r packetcapture.PacketReceiver) RunOption {
+	return func(o *runOptions) { o.packetReceivers = append(o.packetReceivers, r) }
+}
+
+// WithEnv sets extra environment variables for the command, which take precedence
+// over the default environment of the sandbox (see sandbox.Sandbox.RunWithLogHandler).
+func WithEnv(env map[string]string) RunOption {
+	return func(o *runOptions) { o.env = env }
+}
+
+// WithMinimalCapture makes Run only collect the files opened, the sockets used and
+// the commands run (see strace.ParseMinimal), and skip packet capture, so that it is
+// faster and uses less memory. Only the Status, Files, Sockets, Commands and
+// StraceLogTruncated fields of the StraceSummary are set, and packet receivers
+// (see WithPacketReceiver) are ignored. It is intended to be used with
+// analysisrun.NewMinimalSummary.
+func WithMinimalCapture() RunOption {
+	return func(o *runOptions) { o.minimalCapture = true }
+}
+
+// WithRawStraceLog makes Run keep the raw strace log of the run in Result.RawStraceLog,
+// e.g. for investigating behaviour that the summaries do not cover, or for developing
+// new detectors against real captures. At most maxBytes of the log are kept: if it is
+// larger, only the first and last maxBytes/2 bytes are kept, separated by a line
+// noting how many bytes were removed. The log is recorded in full even if it was
+// truncated by the sandbox (see StraceSummary.StraceLogTruncated).
+func WithRawStraceLog(maxBytes int) RunOption {
+	return func(o *runOptions) { o.rawLogBytes = maxBytes }
+}
+
+// WithPackageName gives the name of the package being analyzed, so that its writes
+// to its own directory are not mistaken for writes to other packages (see ModuleWrites).
+func WithPackageName(name string) RunOption {
+	return func(o *runOptions) { o.packageName = name }
+}
+
+// WithCredentialStores sets the credential stores whose reads are reported (see
+// CredentialStoreReads), instead of DefaultCredentialStores.
+func WithCredentialStores(stores []CredentialStore) RunOption {
+	return func(o *runOptions) { o.stores = stores }
+}
+
+// WithTamperingRules sets the rules of the actions that disable or evade security
+// tooling which are reported (see SecurityTampering), instead of DefaultTamperingRules.
+func WithTamperingRules(rules []TamperingRule) RunOption {
+	return func(o *runOptions) { o.tamperingRules = rules }
+}
+
+// WithNetworkDenied records that the network of the sandbox is disabled (see
+// sandbox.Offline), so that the sockets in the trace are the connections that were
+// attempted and denied. StraceSummary.NetworkDenied is set, and the remote hosts
+// (and hostnames, see DeniedConnections) that the package tried to reach are
+// listed in StraceSummary.DeniedConnections.
+func WithNetworkDenied() RunOption {
+	return func(o *runOptions) { o.networkDenied = true }
+}
+
+// Run runs the given command in the sandbox and analyses the strace log and network
+// traffic produced. If ctx is cancelled, the sandboxed process is stopped and an error
+// wrapping ctx.Err() (i.e. context.Canceled or context.DeadlineExceeded) is returned.
+//
+// Run may be called concurrently (e.g. by analyses of different packages), with up
+// to the limit set by SetConcurrency running at the same time. Other calls wait for
+// a command to finish, or for ctx to be done.
+func Run(ctx context.Context, sb sandbox.Sandbox, command string, args []string, straceLogger *slog.Logger, opts ...RunOption) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return resultError, err
+	}
+
 	slog.InfoContext(ctx, "Running dynamic analysis", "args", args)
 
+	var o runOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	slots := currentRunSlots()
+	select {
+	case slots <- struct{}{}:
+		defer func() { <-slots }()
+	case <-ctx.Done():
+		return resultError, ctx.Err()
+	}
+
+	if o.minimalCapture {
+		return runMinimal(ctx, sb, command, args, straceLogger, o)
+	}
+
 	slog.DebugContext(ctx, "Preparing packet capture")
 	pcap := packetcapture.New(sandbox.NetworkInterface)
 
 	dns := dnsanalyzer.New()
 	pcap.RegisterReceiver(dns)
+
+	sni := tlsanalyzer.New()
+	pcap.RegisterReceiver(sni)
+
+	for _, r := range o.packetReceivers {
+		pcap.RegisterReceiver(r)
+	}
 	if err := pcap.Start(); err != nil {
 		return resultError, fmt.Errorf("failed to start packet capture (%w)", err)
 	}
@@ -49,7 +197,14 @@ func Run(ctx context.Context, sb sandbox.Sandbox, command string, args []string,
 	slog.DebugContext(ctx, "Running dynamic analysis command",
 		"command", command,
 		"args", args)
-	r, err := sb.Run(ctx, command, args...)
+	// The strace log is parsed as it is produced, since it may be too large to be
+	// retained in full (see sandbox.LogLimit).
+	var straceResult *strace.Result
+	var parseErr error
+	rawLog := newRawLogRecorder(o.rawLogBytes)
+	r, err := sb.RunWithLogHandler(ctx, func(straceLog io.Reader) {
+		straceResult, parseErr = strace.Parse(ctx, rawLog.tee(straceLog), straceLogger)
+	}, o.env, command, args...)
 	if err != nil {
 		return resultError, fmt.Errorf("sandbox failed (%w)", err)
 	}
@@ -57,32 +212,143 @@ func Run(ctx context.Context, sb sandbox.Sandbox, command string, args []string,
 	slog.DebugContext(ctx, "Stop the packet capture")
 	pcap.Close()
 
-	// Grab the log file
-	slog.DebugContext(ctx, "Parsing the strace log")
-	l, err := r.Log()
+	if parseErr != nil {
+		return resultError, fmt.Errorf("strace parsing failed (%w)", parseErr)
+	}
+	if r.LogTruncated() {
+		slog.WarnContext(ctx, "Strace log was truncated", "size", r.LogSize())
+	}
+
+	analysisResult := &Result{}
+	analysisResult.setData(straceResult, dns, sni, o.packageName, o.stores, o.tamperingRules)
+	analysisResult.StraceSummary.StraceLogTruncated = r.LogTruncated()
+	if o.networkDenied {
+		analysisResult.StraceSummary.NetworkDenied = true
+		analysisResult.StraceSummary.DeniedConnections = DeniedConnections(analysisResult.StraceSummary.Sockets, straceResult.DNSQueries())
+	}
+	analysisResult.StraceSummary.Status = analysis.StatusForRunResult(r)
+	analysisResult.StraceSummary.Resources = ResourceUsage(r.ResourceUsage())
+	analysisResult.StraceSummary.Stdout = utils.LastNBytes(r.Stdout(), maxOutputBytes)
+	analysisResult.StraceSummary.Stderr = utils.LastNBytes(r.Stderr(), maxOutputBytes)
+	analysisResult.RawStraceLog = rawLog.bytes()
+	return analysisResult, nil
+}
+
+// runMinimal is the implementation of Run for WithMinimalCapture.
+func runMinimal(ctx context.Context, sb sandbox.Sandbox, command string, args []string, straceLogger *slog.Logger, o runOptions) (*Result, error) {
+	var straceResult *strace.Result
+	var parseErr error
+	rawLog := newRawLogRecorder(o.rawLogBytes)
+	r, err := sb.RunWithLogHandler(ctx, func(straceLog io.Reader) {
+		straceResult, parseErr = strace.ParseMinimal(ctx, rawLog.tee(straceLog), straceLogger)
+	}, o.env, command, args...)
 	if err != nil {
-		return resultError, fmt.Errorf("failed to open strace log (%w)", err)
+		return resultError, fmt.Errorf("sandbox failed (%w)", err)
+	}
+	if parseErr != nil {
+		return resultError, fmt.Errorf("strace parsing failed (%w)", parseErr)
+	}
+
+	analysisResult := &Result{}
+	for _, f := range withoutHarnessLogs(straceResult.Files()) {
+		analysisResult.StraceSummary.Files = append(analysisResult.StraceSummary.Files, analysisrun.FileResult{
+			Path:  f.Path,
+			Read:  f.Read,
+			Write: f.Write,
+		})
+	}
+	for _, s := range straceResult.Sockets() {
+		if onlyPackageManagerConnections(s) {
+			continue
+		}
+		analysisResult.StraceSummary.Sockets = append(analysisResult.StraceSummary.Sockets, analysisrun.SocketResult{
+			Family:  s.Family,
+			Address: s.Address,
+			Port:    s.Port,
+		})
+	}
+	for _, c := range straceResult.Commands() {
+		analysisResult.StraceSummary.Commands = append(analysisResult.StraceSummary.Commands, analysisrun.CommandResult{
+			Command:     c.Command,
+			Environment: c.Env,
+		})
 	}
-	defer l.Close()
+	analysisResult.StraceSummary.StraceLogTruncated = r.LogTruncated()
+	analysisResult.StraceSummary.Status = analysis.StatusForRunResult(r)
+	analysisResult.RawStraceLog = rawLog.bytes()
+	return analysisResult, nil
+}
+
+// withoutHarnessLogs returns files without the log files of the analysis commands
+// (e.g. the module load log), which they write in every phase.
+func withoutHarnessLogs(files []strace.FileInfo) []strace.FileInfo {
+	return slices.DeleteFunc(files, func(f strace.FileInfo) bool {
+		return analysisrun.IsHarnessLogFile(f.Path)
+	})
+}
+
+// onlyPackageManagerConnections returns whether the socket is known to have been
+// connected to (or bound) only by package managers. Without packet capture, their
+// connections to the package registries cannot be told apart by hostname, so they
+// are left out of the results of runMinimal.
+func onlyPackageManagerConnections(s strace.SocketInfo) bool {
+	if len(s.Connectors) == 0 {
+		return false
+	}
+	for _, c := range s.Connectors {
+		if !analysisrun.IsPackageManagerCommand(c.Command) {
+			return false
+		}
+	}
+	return true
+}
 
-	straceResult, err := strace.Parse(ctx, l, straceLogger)
+/*
+AnalyzeStraceLog produces a Result from a previously captured strace log, without
+running anything in a sandbox. This allows analysis to be repeated on stored logs.
+
+Since the captured log does not contain the status or output of the sandboxed process,
+or any network traffic, the Status, Stdout, Stderr and DNS fields of the StraceSummary
+are not populated, and no hostnames or server names are found for sockets. The
+name of the package is not known either, so ModuleWrites includes writes to the
+package's own directory in node_modules. CredentialStoreReads uses the default
+credential stores.
+*/
+func AnalyzeStraceLog(ctx context.Context, straceLog io.Reader, straceLogger *slog.Logger) (*Result, error) {
+	return analyzeStraceLog(ctx, straceLog, straceLogger, nil)
+}
+
+// AnalyzeStraceLogFile is like AnalyzeStraceLog, but reads the strace log from the given path.
+func AnalyzeStraceLogFile(ctx context.Context, path string, straceLogger *slog.Logger) (*Result, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return resultError, fmt.Errorf("strace parsing failed (%w)", err)
+		return nil, fmt.Errorf("failed to open strace log (%w)", err)
 	}
+	defer f.Close()
 
-	analysisResult := Result{
-		StraceSummary: analysisrun.StraceSummary{
-			Status: analysis.StatusForRunResult(r),
-			Stdout: utils.LastNBytes(r.Stdout(), maxOutputBytes),
-			Stderr: utils.LastNBytes(r.Stderr(), maxOutputBytes),
-		},
+	return AnalyzeStraceLog(ctx, f, straceLogger)
+}
+
+// analyzeStraceLog parses the strace log and produces a Result from it. If dns is non-nil,
+// it is used to resolve hostnames for sockets and populate DNS data in the result.
+func analyzeStraceLog(ctx context.Context, straceLog io.Reader, straceLogger *slog.Logger, dns *dnsanalyzer.DNSAnalyzer) (*Result, error) {
+	straceResult, err := strace.Parse(ctx, straceLog, straceLogger)
+	if err != nil {
+		return nil, fmt.Errorf("strace parsing failed (%w)", err)
 	}
-	analysisResult.setData(straceResult, dns)
-	return &analysisResult, nil
+
+	analysisResult := &Result{}
+	analysisResult.setData(straceResult, dns, nil, "", nil, nil)
+	analysisResult.StraceSummary.Canonicalize()
+	analysisResult.FileWritesSummary.Canonicalize()
+	return analysisResult, nil
 }
 
-func (d *Result) setData(straceResult *strace.Result, dns *dnsanalyzer.DNSAnalyzer) {
-	for _, f := range straceResult.Files() {
+func (d *Result) setData(straceResult *strace.Result, dns *dnsanalyzer.DNSAnalyzer, sni *tlsanalyzer.TLSAnalyzer, packageName string, stores []CredentialStore, tamperingRules []TamperingRule) {
+	d.StraceSummary.SyscallCount = straceResult.SyscallCount()
+
+	files := withoutHarnessLogs(straceResult.Files())
+	for _, f := range files {
 		d.StraceSummary.Files = append(d.StraceSummary.Files, analysisrun.FileResult{
 			Path:   f.Path,
 			Read:   f.Read,
@@ -103,10 +369,26 @@ func (d *Result) setData(straceResult *strace.Result, dns *dnsanalyzer.DNSAnalyz
 	}
 
 	for _, s := range straceResult.Sockets() {
-		d.StraceSummary.Sockets = append(d.StraceSummary.Sockets, analysisrun.SocketResult{
-			Address:   s.Address,
-			Port:      s.Port,
-			Hostnames: dns.Hostnames(s.Address),
+		socket := analysisrun.SocketResult{
+			Family:  s.Family,
+			Address: s.Address,
+			Port:    s.Port,
+		}
+		if dns != nil && s.Family != analysisrun.FamilyUnix {
+			socket.Hostnames = dns.Hostnames(s.Address)
+			socket.Resolved = dns.Resolved(s.Address, s.Port)
+		}
+		if sni != nil && s.Family != analysisrun.FamilyUnix {
+			socket.ServerNames = sni.ServerNames(s.Address, s.Port)
+		}
+		d.StraceSummary.Sockets = append(d.StraceSummary.Sockets, socket)
+	}
+	d.StraceSummary.PlainHTTPConnections = PlainHTTPConnections(d.StraceSummary.Sockets)
+
+	for _, s := range straceResult.PrivilegedSyscalls() {
+		d.StraceSummary.PrivilegedSyscalls = append(d.StraceSummary.PrivilegedSyscalls, analysisrun.SyscallResult{
+			Name:  s.Name,
+			Count: s.Count,
 		})
 	}
 
@@ -117,6 +399,51 @@ func (d *Result) setData(straceResult *strace.Result, dns *dnsanalyzer.DNSAnalyz
 		})
 	}
 
+	for _, s := range straceResult.Sleeps() {
+		d.StraceSummary.Sleeps = append(d.StraceSummary.Sleeps, analysisrun.SleepResult{
+			Syscall:    s.Syscall,
+			DurationMs: s.Duration.Milliseconds(),
+			Count:      s.Count,
+		})
+	}
+
+	d.StraceSummary.EnvironmentChecks = EnvironmentChecks(&d.StraceSummary)
+	d.StraceSummary.SandboxEvasion = LikelySandboxEvasion(d.StraceSummary.EnvironmentChecks)
+	d.StraceSummary.DelayedPayload = LikelyDelayedPayload(d.StraceSummary.Sleeps)
+	d.StraceSummary.SelfModifications = SelfModifications(straceResult.SelfModifications())
+	d.StraceSummary.Processes = Processes(straceResult.Processes())
+	d.StraceSummary.ForkBomb = LikelyForkBomb(d.StraceSummary.Processes)
+	d.StraceSummary.LingeringProcesses = LingeringProcesses(straceResult.LingeringProcesses())
+	d.StraceSummary.Links = Links(straceResult.Links())
+	d.StraceSummary.FileReads = FileReads(d.StraceSummary.Files)
+	d.StraceSummary.BroadFileReads = LikelyBroadFileReads(d.StraceSummary.FileReads)
+	d.StraceSummary.ModuleWrites = ModuleWrites(files, packageName)
+	d.StraceSummary.RegistryPublishes = RegistryPublishes(straceResult.Commands(), d.StraceSummary.Sockets)
+	d.StraceSummary.RegistryCredentialReads = RegistryCredentialReads(files)
+	d.StraceSummary.RegistryTakeover = len(d.StraceSummary.RegistryPublishes) > 0 && len(d.StraceSummary.RegistryCredentialReads) > 0
+	if stores == nil {
+		stores = defaultCredentialStores
+	}
+	d.StraceSummary.CredentialStoreReads = CredentialStoreReads(files, stores)
+	d.StraceSummary.ShellProfileWrites = ShellProfileWrites(files)
+	d.StraceSummary.PathModifications = PathModifications(straceResult.Commands(), files)
+	d.StraceSummary.PathHijack = LikelyPathHijack(d.StraceSummary.PathModifications)
+	d.StraceSummary.ExecutableDrops = ExecutableDrops(files)
+	d.StraceSummary.NativeLibraryLoads = NativeLibraryLoads(files, packageName)
+	d.StraceSummary.ReconExfiltration = ReconExfiltration(straceResult.ConnectionsAfterCommands(), d.StraceSummary.Sockets)
+	d.StraceSummary.SourceExfiltration = SourceExfiltration(files, straceResult.ConnectionsAfterReads, d.StraceSummary.Sockets)
+	d.StraceSummary.StagedExecutions = StagedExecutions(straceResult.WrittenFileRuns(), files)
+	if tamperingRules == nil {
+		tamperingRules = defaultTamperingRules
+	}
+	d.StraceSummary.SecurityTampering = SecurityTampering(straceResult.Commands(), files, tamperingRules)
+
+	if dns == nil {
+		return
+	}
+	// whether addresses were resolved is only known from captured traffic
+	d.StraceSummary.DirectIPConnections = DirectIPConnections(d.StraceSummary.Sockets)
+
 	for dnsClass, queries := range dns.Questions() {
 		c := analysisrun.DNSResult{Class: dnsClass}
 		for host, types := range queries {
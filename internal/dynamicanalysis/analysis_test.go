@@ -0,0 +1,164 @@
+package dynamicanalysis_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+var nopLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+const testStraceLog = `I1206 00:04:41.714862     175 strace.go:622] [  19] node E connect(0x1d socket:[57], 0x7f34c41402d0 {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10)
+I1206 00:04:41.714862     175 strace.go:622] [  19] node X connect(0x1d socket:[57], 0x7f34c41402d0 {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10) = 0x0 errno=115 (operation now in progress) (130.736µs)
+I1203 00:02:38.316076     171 strace.go:587] [   2] node E openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/foobar, O_CLOEXEC|O_CREAT|O_TRUNC, 0o666)
+I1203 00:02:38.316076     171 strace.go:587] [   2] node X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/foobar, O_CLOEXEC|O_CREAT|O_TRUNC, 0o666) = 0x3 (21.1µs)
+I1203 05:29:21.585712     173 strace.go:625] [   3] sh E execve(0x7f1c3a0a2620 /usr/bin/uname, 0x7f1c39e12930 ["uname", "-rs"], 0x55bbefc2d070 ["HOME=/root"])
+I1203 05:29:21.585712     173 strace.go:625] [   3] sh X execve(0x7f1c3a0a2620 /usr/bin/uname, 0x7f1c39e12930 ["uname", "-rs"], 0x55bbefc2d070 ["HOME=/root"]) = 0x0 (1.1ms)
+`
+
+func TestAnalyzeStraceLog(t *testing.T) {
+	want := analysisrun.StraceSummary{
+		Files: []analysisrun.FileResult{
+			{Path: "/app/foobar", Write: true},
+		},
+		Sockets: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "104.16.19.35", Port: 443},
+		},
+		Commands: []analysisrun.CommandResult{
+			{Command: []string{"uname", "-rs"}, Environment: []string{"HOME=/root"}},
+		},
+		SyscallCount: 3,
+		// the processes are spawned hours apart, so at most one is spawned per second
+		Processes: analysisrun.ProcessResult{Spawned: 2, Execs: 1, PeakCount: 3, PeakSpawnRate: 1},
+		// none of the processes exit, and the first one is the command being analyzed
+		LingeringProcesses: []analysisrun.LingeringProcessResult{
+			{Command: []string{"node"}},
+			{Command: []string{"uname", "-rs"}},
+		},
+	}
+
+	got, err := dynamicanalysis.AnalyzeStraceLog(context.Background(), strings.NewReader(testStraceLog), nopLogger)
+	if err != nil {
+		t.Fatalf("AnalyzeStraceLog() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.StraceSummary, want) {
+		t.Errorf("AnalyzeStraceLog() StraceSummary = %+v, want %+v", got.StraceSummary, want)
+	}
+}
+
+func TestAnalyzeStraceLogHarnessLogs(t *testing.T) {
+	log := `I1203 00:02:38.316076     171 strace.go:587] [   2] node X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /module-loads.log, O_WRONLY|O_CREAT|O_APPEND, 0o666) = 0x3 (21.1µs)
+I1203 00:02:38.316076     171 strace.go:587] [   2] node X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /execution.log, O_WRONLY|O_CREAT|O_TRUNC, 0o666) = 0x4 (21.1µs)
+I1203 00:02:38.316076     171 strace.go:587] [   2] node X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/foobar, O_WRONLY|O_CREAT|O_TRUNC, 0o666) = 0x5 (21.1µs)
+`
+	got, err := dynamicanalysis.AnalyzeStraceLog(context.Background(), strings.NewReader(log), nopLogger)
+	if err != nil {
+		t.Fatalf("AnalyzeStraceLog() error = %v", err)
+	}
+	want := []analysisrun.FileResult{{Path: "/app/foobar", Write: true}}
+	if !reflect.DeepEqual(got.StraceSummary.Files, want) {
+		t.Errorf("AnalyzeStraceLog() Files = %+v, want %+v", got.StraceSummary.Files, want)
+	}
+}
+
+// countingSandbox records the most commands run at the same time. Each run waits
+// until overlap runs are in progress (or a second has passed), and then fails.
+type countingSandbox struct {
+	sandbox.Sandbox
+	overlap             int
+	mu                  sync.Mutex
+	running, maxRunning int
+}
+
+func (s *countingSandbox) RunWithLogHandler(ctx context.Context, handler sandbox.LogHandler, env map[string]string, command string, args ...string) (*sandbox.RunResult, error) {
+	s.mu.Lock()
+	s.running++
+	s.maxRunning = max(s.maxRunning, s.running)
+	s.mu.Unlock()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		running := s.running
+		s.mu.Unlock()
+		if running >= s.overlap {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	return nil, errors.New("not run")
+}
+
+func TestRunConcurrency(t *testing.T) {
+	t.Cleanup(func() { dynamicanalysis.SetConcurrency(1) })
+	for _, limit := range []int{1, 2} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			dynamicanalysis.SetConcurrency(limit)
+			sb := &countingSandbox{overlap: limit}
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _ = dynamicanalysis.Run(context.Background(), sb, "analyze", nil, nopLogger, dynamicanalysis.WithMinimalCapture())
+				}()
+			}
+			wg.Wait()
+			if got := sb.maxRunning; got != limit {
+				t.Errorf("commands run at the same time = %d, want %d", got, limit)
+			}
+		})
+	}
+}
+
+// logSandbox passes log to the log handler of each run.
+type logSandbox struct {
+	sandbox.Sandbox
+	log string
+}
+
+func (s *logSandbox) RunWithLogHandler(ctx context.Context, handler sandbox.LogHandler, env map[string]string, command string, args ...string) (*sandbox.RunResult, error) {
+	handler(strings.NewReader(s.log))
+	return &sandbox.RunResult{}, nil
+}
+
+func TestRunMinimalPackageManagerConnections(t *testing.T) {
+	log := `I1203 05:29:21.100000     173 strace.go:625] [   2:   2] npm X execve(0x7f1c3a0a2620 /usr/local/bin/npm, 0x7f1c39e12930 ["npm", "install", "foo"], 0x55bbefc2d070 []) = 0x0 (10µs)
+I1203 05:29:21.200000     173 strace.go:625] [   2:   2] npm X connect(0x3 socket:[1], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 10.0.2.3, Port: 53}, 0x10) = 0x0 (10µs)
+I1203 05:29:21.300000     173 strace.go:625] [   2:   2] npm X connect(0x3 socket:[2], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 104.16.1.35, Port: 443}, 0x10) = 0x0 (10µs)
+I1203 05:29:21.400000     173 strace.go:625] [   3:   3] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 ["sh", "-c", "node install.js"], 0x55bbefc2d070 []) = 0x0 (10µs)
+I1203 05:29:21.500000     173 strace.go:625] [   3:   3] sh X connect(0x3 socket:[3], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 203.0.113.5, Port: 443}, 0x10) = 0x0 (10µs)
+`
+	got, err := dynamicanalysis.Run(context.Background(), &logSandbox{log: log}, "analyze", nil, nopLogger, dynamicanalysis.WithMinimalCapture())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []analysisrun.SocketResult{{Family: "AF_INET", Address: "203.0.113.5", Port: 443}}
+	if !reflect.DeepEqual(got.StraceSummary.Sockets, want) {
+		t.Errorf("Run() Sockets = %+v, want %+v", got.StraceSummary.Sockets, want)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The sandbox is not used if the context is already cancelled.
+	if _, err := dynamicanalysis.Run(ctx, nil, "analyze", nil, nopLogger); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,97 @@
+package dynamicanalysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// Placeholders that may be used in a CommandTemplate. They are substituted
+// with details of the package under analysis when the template is expanded.
+const (
+	PlaceholderName    = "{name}"
+	PlaceholderVersion = "{version}"
+	PlaceholderLocal   = "{local}"
+	PlaceholderPhase   = "{phase}"
+)
+
+// CommandTemplate is a command line (command followed by arguments) which
+// replaces the default analysis command and arguments for a phase.
+// Each element may contain placeholders (see PlaceholderName etc.).
+type CommandTemplate []string
+
+// ParseCommandTemplate splits a whitespace-separated command line into a
+// CommandTemplate. No shell quoting rules are applied.
+func ParseCommandTemplate(s string) (CommandTemplate, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty command template")
+	}
+	return fields, nil
+}
+
+// Expand substitutes placeholders in the template with details of the given
+// package and phase, and returns the resulting command and arguments.
+// Arguments which become empty after substitution (e.g. a lone {version}
+// placeholder when no version was requested) are dropped.
+func (t CommandTemplate) Expand(p *pkgmanager.Pkg, phase analysisrun.DynamicPhase) (string, []string) {
+	if len(t) == 0 {
+		return "", nil
+	}
+
+	r := strings.NewReplacer(
+		PlaceholderName, p.Name(),
+		PlaceholderVersion, p.Version(),
+		PlaceholderLocal, p.LocalPath(),
+		PlaceholderPhase, string(phase),
+	)
+
+	args := make([]string, 0, len(t)-1)
+	for _, arg := range t[1:] {
+		if expanded := r.Replace(arg); expanded != "" {
+			args = append(args, expanded)
+		}
+	}
+
+	return r.Replace(t[0]), args
+}
+
+// CommandOverrides maps dynamic analysis phases to command templates that
+// replace the default analysis command for that phase. Phases without an
+// entry use the default command for the ecosystem.
+type CommandOverrides map[analysisrun.DynamicPhase]CommandTemplate
+
+// ParseCommandOverride parses a phase override of the form
+// "PHASE=COMMAND ARGS..." and adds it to o.
+func (o CommandOverrides) ParseCommandOverride(s string) error {
+	phaseName, cmdLine, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("invalid command override %q: expected PHASE=COMMAND", s)
+	}
+
+	phase := analysisrun.DynamicPhase(strings.TrimSpace(phaseName))
+	if phase == "" {
+		return fmt.Errorf("invalid command override %q: missing phase", s)
+	}
+
+	tmpl, err := ParseCommandTemplate(cmdLine)
+	if err != nil {
+		return fmt.Errorf("invalid command override %q: %w", s, err)
+	}
+
+	o[phase] = tmpl
+	return nil
+}
+
+// Command returns the command and arguments to run in the sandbox for the
+// given phase of analysis of p. If there is an override for the phase, it is
+// expanded and returned; otherwise defaultCmd is returned together with the
+// arguments given by MakeAnalysisArgs.
+func (o CommandOverrides) Command(p *pkgmanager.Pkg, phase analysisrun.DynamicPhase, defaultCmd string) (string, []string) {
+	if tmpl, ok := o[phase]; ok && len(tmpl) > 0 {
+		return tmpl.Expand(p, phase)
+	}
+	return defaultCmd, MakeAnalysisArgs(p, phase)
+}
@@ -0,0 +1,80 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestCommandOverrides(t *testing.T) {
+	npm := pkgmanager.Manager(pkgecosystem.NPM)
+	const defaultCmd = "/usr/local/bin/analyze-node.js"
+
+	overrides := make(CommandOverrides)
+	if err := overrides.ParseCommandOverride("install=npm install --ignore-scripts {name}@{version}"); err != nil {
+		t.Fatalf("ParseCommandOverride() error = %v", err)
+	}
+	if err := overrides.ParseCommandOverride("import=node -e require('{name}') {version}"); err != nil {
+		t.Fatalf("ParseCommandOverride() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		pkg      *pkgmanager.Pkg
+		phase    analysisrun.DynamicPhase
+		wantCmd  string
+		wantArgs []string
+	}{
+		{
+			name:     "overridden install",
+			pkg:      npm.Package("left-pad", "1.3.0"),
+			phase:    analysisrun.DynamicPhaseInstall,
+			wantCmd:  "npm",
+			wantArgs: []string{"install", "--ignore-scripts", "left-pad@1.3.0"},
+		},
+		{
+			name:     "empty placeholder dropped",
+			pkg:      npm.Package("left-pad", ""),
+			phase:    analysisrun.DynamicPhaseImport,
+			wantCmd:  "node",
+			wantArgs: []string{"-e", "require('left-pad')"},
+		},
+		{
+			name:     "default for other phase",
+			pkg:      npm.Package("left-pad", "1.3.0"),
+			phase:    analysisrun.DynamicPhaseExecute,
+			wantCmd:  defaultCmd,
+			wantArgs: []string{"--version", "1.3.0", "execute", "left-pad"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCmd, gotArgs := overrides.Command(tt.pkg, tt.phase, defaultCmd)
+			if gotCmd != tt.wantCmd {
+				t.Errorf("Command() cmd = %q, want %q", gotCmd, tt.wantCmd)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("Command() args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseCommandOverrideInvalid(t *testing.T) {
+	tests := []string{
+		"install",
+		"=npm install",
+		"install=",
+		"install=   ",
+	}
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			if err := make(CommandOverrides).ParseCommandOverride(s); err == nil {
+				t.Errorf("ParseCommandOverride(%q) expected error", s)
+			}
+		})
+	}
+}
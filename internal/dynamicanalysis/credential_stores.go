@@ -0,0 +1,204 @@
+package dynamicanalysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// ErrInvalidCredentialStore is returned by LoadCredentialStores if a store has
+// no name, has no paths, or has a path that is not a valid pattern.
+var ErrInvalidCredentialStore = errors.New("invalid credential store")
+
+/*
+CredentialStore is a location where credentials targeted by infostealers are
+kept, such as the login database of a browser, SSH keys or the configuration of a
+cloud CLI.
+
+Paths are slash-separated paths relative to the home directory of a user, and may
+contain path.Match wildcards (e.g. ".ssh/id_*"). A file is in the store if its path
+relative to any home directory (/root, /home/<user> or, for macOS layouts,
+/Users/<user>) matches one of Paths, or is inside a directory that does. Since packages that target several platforms look for the paths used on each
+of them, the paths used on macOS (e.g. "Library/Application Support/...") and on
+Windows (e.g. "AppData/Local/...") are looked for as well.
+
+Reads by the tools that own the store (e.g. ssh for ~/.ssh), given by the base
+names of their executables in Tools, are not reported.
+*/
+type CredentialStore struct {
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+	Tools []string `json:"tools,omitempty"`
+}
+
+// chromiumStore returns the store of a browser based on Chromium, whose user data
+// directory is linux, macOS or windows on each platform.
+func chromiumStore(name, linux, macOS, windows string) CredentialStore {
+	s := CredentialStore{Name: name}
+	for _, dir := range []string{linux, macOS, windows} {
+		s.Paths = append(s.Paths,
+			dir+"/Local State", // holds the key encrypting the other files
+			dir+"/*/Login Data",
+			dir+"/*/Cookies",
+			dir+"/*/Network/Cookies",
+			dir+"/*/Web Data",
+		)
+	}
+	return s
+}
+
+// firefoxStore returns the store of Firefox, which keeps a directory for each
+// profile in the given directories.
+func firefoxStore(profileDirs ...string) CredentialStore {
+	s := CredentialStore{Name: "firefox"}
+	for _, dir := range profileDirs {
+		for _, file := range []string{"logins.json", "key4.db", "key3.db", "cookies.sqlite"} {
+			s.Paths = append(s.Paths, dir+"/*/"+file)
+		}
+	}
+	return s
+}
+
+var defaultCredentialStores = []CredentialStore{
+	chromiumStore("chrome", ".config/google-chrome", "Library/Application Support/Google/Chrome", "AppData/Local/Google/Chrome/User Data"),
+	chromiumStore("chromium", ".config/chromium", "Library/Application Support/Chromium", "AppData/Local/Chromium/User Data"),
+	chromiumStore("brave", ".config/BraveSoftware/Brave-Browser", "Library/Application Support/BraveSoftware/Brave-Browser", "AppData/Local/BraveSoftware/Brave-Browser/User Data"),
+	chromiumStore("edge", ".config/microsoft-edge", "Library/Application Support/Microsoft Edge", "AppData/Local/Microsoft/Edge/User Data"),
+	firefoxStore(".mozilla/firefox", "Library/Application Support/Firefox/Profiles", "AppData/Roaming/Mozilla/Firefox/Profiles"),
+	{Name: "ssh", Paths: []string{".ssh"}, Tools: []string{"ssh", "scp", "sftp", "ssh-add", "ssh-agent", "ssh-keygen"}},
+	{Name: "gnupg", Paths: []string{".gnupg", "AppData/Roaming/gnupg"}, Tools: []string{"gpg", "gpg2", "gpg-agent", "gpgv"}},
+	{Name: "aws", Paths: []string{".aws"}, Tools: []string{"aws"}},
+	{Name: "gcloud", Paths: []string{".config/gcloud", "AppData/Roaming/gcloud"}, Tools: []string{"gcloud", "gsutil", "bq"}},
+	{Name: "azure", Paths: []string{".azure"}, Tools: []string{"az"}},
+	{Name: "kubernetes", Paths: []string{".kube/config"}, Tools: []string{"kubectl", "helm"}},
+	{Name: "docker", Paths: []string{".docker/config.json"}, Tools: []string{"docker"}},
+	{Name: "keyring", Paths: []string{".local/share/keyrings", "Library/Keychains"}},
+}
+
+// DefaultCredentialStores returns the credential stores looked for by Run unless
+// others are given with WithCredentialStores.
+func DefaultCredentialStores() []CredentialStore {
+	stores := make([]CredentialStore, len(defaultCredentialStores))
+	for i, s := range defaultCredentialStores {
+		stores[i] = CredentialStore{Name: s.Name, Paths: slices.Clone(s.Paths), Tools: slices.Clone(s.Tools)}
+	}
+	return stores
+}
+
+/*
+LoadCredentialStores reads credential stores for WithCredentialStores from a JSON
+file holding an array of stores, e.g.
+
+	[{"name": "ssh", "paths": [".ssh"], "tools": ["ssh"]}]
+
+The stores replace the default ones (see DefaultCredentialStores). An error wrapping
+ErrInvalidCredentialStore is returned if a store is not valid, to catch mistakes in
+the configuration.
+*/
+func LoadCredentialStores(file string) ([]CredentialStore, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var stores []CredentialStore
+	if err := json.Unmarshal(data, &stores); err != nil {
+		return nil, fmt.Errorf("failed to parse credential stores: %w", err)
+	}
+	for _, s := range stores {
+		if s.Name == "" || len(s.Paths) == 0 {
+			return nil, fmt.Errorf("%w: %q must have a name and paths", ErrInvalidCredentialStore, s.Name)
+		}
+		for _, p := range s.Paths {
+			if _, err := path.Match(p, ""); err != nil || path.IsAbs(p) {
+				return nil, fmt.Errorf("%w: %q has invalid path %q", ErrInvalidCredentialStore, s.Name, p)
+			}
+		}
+	}
+	return stores, nil
+}
+
+/*
+CredentialStoreReads finds the files read in the given credential stores, such as
+browser login databases, SSH keys or cloud CLI configuration, which are targeted by
+infostealers and which a package has no reason to read while being installed or
+imported. The reads are grouped by store, in the order of stores, and the files of
+each are listed in the order they were first accessed. Files in the package
+directory (see analysisrun.IsPackageFile) are ignored, as are files read only by
+the tools that own the store.
+*/
+func CredentialStoreReads(files []strace.FileInfo, stores []CredentialStore) []analysisrun.CredentialStoreReadResult {
+	read := make(map[string][]string)
+	for _, f := range files {
+		if !f.Read || analysisrun.IsPackageFile(f.Path) {
+			continue
+		}
+		rel, ok := homeRelativePath(f.Path)
+		if !ok {
+			continue
+		}
+		for _, s := range stores {
+			if inCredentialStore(rel, s) && !readByOwner(f, s) {
+				read[s.Name] = append(read[s.Name], f.Path)
+			}
+		}
+	}
+
+	var results []analysisrun.CredentialStoreReadResult
+	for _, s := range stores {
+		if paths, ok := read[s.Name]; ok {
+			results = append(results, analysisrun.CredentialStoreReadResult{Store: s.Name, Paths: paths})
+			delete(read, s.Name)
+		}
+	}
+	return results
+}
+
+// homeRelativePath returns the path of p relative to the home directory that
+// contains it, if any.
+func homeRelativePath(p string) (string, bool) {
+	if rel, ok := strings.CutPrefix(p, "/root/"); ok {
+		return rel, true
+	}
+	for _, homes := range []string{"/home/", "/Users/"} {
+		if rest, ok := strings.CutPrefix(p, homes); ok {
+			if _, rel, found := strings.Cut(rest, "/"); found {
+				return rel, true
+			}
+		}
+	}
+	return "", false
+}
+
+// inCredentialStore returns whether the path rel, relative to a home directory,
+// matches one of the paths of s, or is inside a directory that does.
+func inCredentialStore(rel string, s CredentialStore) bool {
+	for _, pattern := range s.Paths {
+		for prefix := rel; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+			if matched, _ := path.Match(pattern, prefix); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readByOwner returns whether f was read only by the tools that own s. If the
+// readers of f are not known, it is not.
+func readByOwner(f strace.FileInfo, s CredentialStore) bool {
+	if len(f.Readers) == 0 || len(s.Tools) == 0 {
+		return false
+	}
+	for _, r := range f.Readers {
+		if len(r.Command) == 0 || !slices.Contains(s.Tools, path.Base(r.Command[0])) {
+			return false
+		}
+	}
+	return true
+}
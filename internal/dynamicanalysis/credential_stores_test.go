@@ -0,0 +1,97 @@
+package dynamicanalysis_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestCredentialStoreReads(t *testing.T) {
+	script := strace.CommandInfo{Command: []string{"node", "index.js"}}
+	ssh := strace.CommandInfo{Command: []string{"/usr/bin/ssh", "git@github.com"}}
+
+	files := []strace.FileInfo{
+		// not in a store
+		{Path: "/root/.bashrc", Read: true},
+		{Path: "/root/.config/google-chrome/Default/Preferences", Read: true},
+		{Path: "/app/.ssh/id_rsa", Read: true},
+		// only written
+		{Path: "/root/.aws/credentials", Write: true},
+		// read by the owner of the store
+		{Path: "/root/.ssh/known_hosts", Read: true, Readers: []strace.CommandInfo{ssh}},
+		// read by the package
+		{Path: "/root/.ssh/id_rsa", Read: true, Readers: []strace.CommandInfo{ssh, script}},
+		{Path: "/root/.config/google-chrome/Default/Login Data", Read: true, Readers: []strace.CommandInfo{script}},
+		{Path: "/root/.config/google-chrome/Profile 1/Network/Cookies", Read: true},
+		{Path: "/root/.ssh", Read: true},
+		{Path: "/home/user/.mozilla/firefox/abcd.default-release/logins.json", Read: true},
+		{Path: "/root/Library/Application Support/Google/Chrome/Local State", Read: true},
+		{Path: "/root/AppData/Roaming/Mozilla/Firefox/Profiles/abcd.default/key4.db", Read: true},
+		{Path: "/root/.config/gcloud/credentials.db", Read: true},
+	}
+
+	want := []analysisrun.CredentialStoreReadResult{
+		{Store: "chrome", Paths: []string{
+			"/root/.config/google-chrome/Default/Login Data",
+			"/root/.config/google-chrome/Profile 1/Network/Cookies",
+			"/root/Library/Application Support/Google/Chrome/Local State",
+		}},
+		{Store: "firefox", Paths: []string{
+			"/home/user/.mozilla/firefox/abcd.default-release/logins.json",
+			"/root/AppData/Roaming/Mozilla/Firefox/Profiles/abcd.default/key4.db",
+		}},
+		{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa", "/root/.ssh"}},
+		{Store: "gcloud", Paths: []string{"/root/.config/gcloud/credentials.db"}},
+	}
+	if got := dynamicanalysis.CredentialStoreReads(files, dynamicanalysis.DefaultCredentialStores()); !reflect.DeepEqual(got, want) {
+		t.Errorf("CredentialStoreReads() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	stores := []dynamicanalysis.CredentialStore{{Name: "wallet", Paths: []string{".electrum/wallets/*"}}}
+	files = []strace.FileInfo{
+		{Path: "/root/.electrum/wallets/default_wallet", Read: true},
+		{Path: "/root/.ssh/id_rsa", Read: true},
+	}
+	want = []analysisrun.CredentialStoreReadResult{{Store: "wallet", Paths: []string{"/root/.electrum/wallets/default_wallet"}}}
+	if got := dynamicanalysis.CredentialStoreReads(files, stores); !reflect.DeepEqual(got, want) {
+		t.Errorf("CredentialStoreReads() with configured stores = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadCredentialStores(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	got, err := dynamicanalysis.LoadCredentialStores(write("stores.json", `[{"name": "ssh", "paths": [".ssh"], "tools": ["ssh"]}]`))
+	if err != nil {
+		t.Fatalf("LoadCredentialStores() error = %v", err)
+	}
+	want := []dynamicanalysis.CredentialStore{{Name: "ssh", Paths: []string{".ssh"}, Tools: []string{"ssh"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadCredentialStores() = %+v, want %+v", got, want)
+	}
+
+	for _, contents := range []string{
+		`[{"paths": [".ssh"]}]`,
+		`[{"name": "ssh"}]`,
+		`[{"name": "ssh", "paths": ["/root/.ssh"]}]`,
+		`[{"name": "ssh", "paths": [".ssh/[id"]}]`,
+	} {
+		if _, err := dynamicanalysis.LoadCredentialStores(write("invalid.json", contents)); !errors.Is(err, dynamicanalysis.ErrInvalidCredentialStore) {
+			t.Errorf("LoadCredentialStores(%s) error = %v, want %v", contents, err, dynamicanalysis.ErrInvalidCredentialStore)
+		}
+	}
+}
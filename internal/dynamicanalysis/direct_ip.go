@@ -0,0 +1,33 @@
+package dynamicanalysis
+
+import (
+	"net"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+/*
+DirectIPConnections returns the sockets that connect to a remote host whose address
+was not resolved by a DNS lookup before the connection was made (see
+SocketResult.Resolved), i.e. the package dialled a bare IP address. This is typical
+of command and control servers that avoid DNS logging, whereas the hosts that benign
+packages connect to (e.g. registries and CDNs) are resolved from their hostnames.
+Connections to the local machine and to DNS servers (port 53), which are made to
+resolve hostnames, are excluded.
+
+Sockets must have Resolved set from captured network traffic for the result to be
+meaningful.
+*/
+func DirectIPConnections(sockets []analysisrun.SocketResult) []analysisrun.SocketResult {
+	var result []analysisrun.SocketResult
+	for _, s := range sockets {
+		if s.Resolved || s.Family == analysisrun.FamilyUnix || s.Port == 53 {
+			continue
+		}
+		if ip := net.ParseIP(s.Address); ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
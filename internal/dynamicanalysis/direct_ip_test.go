@@ -0,0 +1,26 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestDirectIPConnections(t *testing.T) {
+	sockets := []analysisrun.SocketResult{
+		{Family: analysisrun.FamilyInet, Address: "127.0.0.1", Port: 8080},
+		{Family: analysisrun.FamilyInet6, Address: "::", Port: 443},
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53},
+		{Family: analysisrun.FamilyUnix, Address: "/var/run/nscd/socket"},
+		{Family: analysisrun.FamilyInet, Address: "104.16.0.35", Port: 443, Hostnames: []string{"registry.npmjs.org"}, Resolved: true},
+		{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 4444},
+		{Family: analysisrun.FamilyInet6, Address: "2001:db8::1", Port: 443, ServerNames: []string{"cdn.example.com"}},
+	}
+
+	want := []analysisrun.SocketResult{sockets[5], sockets[6]}
+	if got := dynamicanalysis.DirectIPConnections(sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("DirectIPConnections() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,216 @@
+package dynamicanalysis
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// Categories of environment fingerprinting checks.
+const (
+	// EnvCheckVirtualization covers checks for hypervisor or virtual hardware details.
+	EnvCheckVirtualization = "virtualization"
+	// EnvCheckContainer covers checks for files specific to container runtimes.
+	EnvCheckContainer = "container"
+	// EnvCheckHardware covers checks of CPU details.
+	EnvCheckHardware = "hardware"
+	// EnvCheckNetwork covers checks of network interface details, e.g. MAC addresses.
+	EnvCheckNetwork = "network"
+	// EnvCheckHostname covers checks of the machine's hostname.
+	EnvCheckHostname = "hostname"
+)
+
+// Sources of environment fingerprinting checks.
+const (
+	EnvCheckSourceFile    = "file"
+	EnvCheckSourceCommand = "command"
+)
+
+// fingerprintFiles maps paths (or path prefixes, if ending in '/') that are
+// read to fingerprint the environment, to the category of check.
+//
+// Paths which language runtimes routinely read on startup (e.g. /proc/self/cgroup,
+// /proc/meminfo) are deliberately excluded, so that benign packages are not flagged.
+var fingerprintFiles = map[string]string{
+	"/sys/class/dmi/id/":           EnvCheckVirtualization,
+	"/sys/devices/virtual/dmi/id/": EnvCheckVirtualization,
+	"/sys/hypervisor/":             EnvCheckVirtualization,
+	"/proc/xen/":                   EnvCheckVirtualization,
+	"/proc/scsi/scsi":              EnvCheckVirtualization,
+	"/.dockerenv":                  EnvCheckContainer,
+	"/.dockerinit":                 EnvCheckContainer,
+	"/run/.containerenv":           EnvCheckContainer,
+	"/proc/1/cgroup":               EnvCheckContainer,
+	"/proc/1/sched":                EnvCheckContainer,
+	"/proc/cpuinfo":                EnvCheckHardware,
+	"/etc/hostname":                EnvCheckHostname,
+	"/proc/sys/kernel/hostname":    EnvCheckHostname,
+}
+
+// fingerprintCommands maps the names of executables that are run to
+// fingerprint the environment, to the category of check.
+var fingerprintCommands = map[string]string{
+	"systemd-detect-virt": EnvCheckVirtualization,
+	"virt-what":           EnvCheckVirtualization,
+	"dmidecode":           EnvCheckVirtualization,
+	"lscpu":               EnvCheckHardware,
+	"ifconfig":            EnvCheckNetwork,
+	"hostname":            EnvCheckHostname,
+}
+
+// fingerprintFileCategory returns the category of environment check for reading
+// the file at p, or the empty string if reading p is not known to be such a check.
+func fingerprintFileCategory(p string) string {
+	if category, ok := fingerprintFiles[p]; ok {
+		return category
+	}
+	for prefix, category := range fingerprintFiles {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(p, prefix) {
+			return category
+		}
+	}
+	// MAC address of a network interface, i.e. /sys/class/net/<iface>/address
+	if strings.HasPrefix(p, "/sys/class/net/") && path.Base(p) == "address" {
+		return EnvCheckNetwork
+	}
+	return ""
+}
+
+// fingerprintCommandCategory returns the category of environment check made
+// by running the given command line, or the empty string if there is none.
+func fingerprintCommandCategory(command []string) string {
+	if len(command) == 0 {
+		return ""
+	}
+	name := path.Base(command[0])
+	if category, ok := fingerprintCommands[name]; ok {
+		return category
+	}
+	// 'ip link' / 'ip addr' list interfaces and their MAC addresses
+	if name == "ip" && len(command) > 1 && (strings.HasPrefix(command[1], "l") || strings.HasPrefix(command[1], "a")) {
+		return EnvCheckNetwork
+	}
+	return ""
+}
+
+/*
+EnvironmentChecks returns the attempts to fingerprint the analysis environment
+that can be seen in the file accesses and commands of the given summary, such as
+reading DMI data from /sys/class/dmi/id, checking for /.dockerenv, or running
+systemd-detect-virt. Malware may use these checks to detect when it is running in
+a sandbox, and stay dormant to evade analysis.
+
+Checks are returned in the order that the files and commands appear in the summary.
+*/
+func EnvironmentChecks(s *analysisrun.StraceSummary) []analysisrun.EnvironmentCheckResult {
+	var checks []analysisrun.EnvironmentCheckResult
+	for _, f := range s.Files {
+		if !f.Read {
+			continue
+		}
+		if category := fingerprintFileCategory(f.Path); category != "" {
+			checks = append(checks, analysisrun.EnvironmentCheckResult{
+				Category:  category,
+				Source:    EnvCheckSourceFile,
+				Indicator: f.Path,
+			})
+		}
+	}
+	for _, c := range s.Commands {
+		if category := fingerprintCommandCategory(c.Command); category != "" {
+			checks = append(checks, analysisrun.EnvironmentCheckResult{
+				Category:  category,
+				Source:    EnvCheckSourceCommand,
+				Indicator: strings.Join(c.Command, " "),
+			})
+		}
+	}
+	return checks
+}
+
+/*
+LikelySandboxEvasion returns whether the given environment checks indicate a likely
+attempt to evade analysis. This is the case if there are any virtualization or
+container checks, since benign packages have little reason to make them, or if
+checks were made in at least two different categories. A single hardware, network
+or hostname check on its own is not considered sufficient, since benign packages
+occasionally make them (e.g. to count CPUs).
+*/
+func LikelySandboxEvasion(checks []analysisrun.EnvironmentCheckResult) bool {
+	var categories []string
+	for _, c := range checks {
+		if c.Category == EnvCheckVirtualization || c.Category == EnvCheckContainer {
+			return true
+		}
+		if !slices.Contains(categories, c.Category) {
+			categories = append(categories, c.Category)
+		}
+	}
+	return len(categories) >= 2
+}
+
+// LongSleepThreshold is the minimum duration of a sleep for it to be considered
+// an attempt to delay a payload. Benign install and import code rarely waits
+// this long, while the analysis of each phase is limited to a few minutes.
+const LongSleepThreshold = time.Minute
+
+// delaySyscalls are the syscalls which only sleep. The timeouts of the syscalls
+// which wait for events (e.g. epoll_wait) are not delays, since event loops such
+// as that of Node.js wait with long timeouts whenever they are idle, and return as
+// soon as an event arrives.
+var delaySyscalls = map[string]bool{
+	"nanosleep":       true,
+	"clock_nanosleep": true,
+}
+
+// LikelyDelayedPayload returns whether any of the given sleeps (see delaySyscalls)
+// lasts at least LongSleepThreshold, which suggests an attempt to delay some
+// behaviour until after the sandbox has finished observing the package.
+func LikelyDelayedPayload(sleeps []analysisrun.SleepResult) bool {
+	for _, s := range sleeps {
+		if delaySyscalls[s.Syscall] && time.Duration(s.DurationMs)*time.Millisecond >= LongSleepThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// selfModificationIgnoredDirs are prefixes of paths where files that are run and
+// then deleted are not reported as self-modifications. pip runs the build scripts
+// of source distributions from temporary directories which it deletes afterwards,
+// so removal of these files cannot be attributed to the package.
+var selfModificationIgnoredDirs = []string{
+	"/tmp/pip-",
+}
+
+/*
+SelfModifications returns the files that were run and then rewritten or deleted
+during a phase (see strace.Result.SelfModifications), such as a postinstall script
+that deletes itself after running. Malware does this to cover its tracks, so that
+the malicious code is no longer present for later inspection.
+
+Files in directories where the package manager itself cleans up scripts after
+running them are excluded.
+*/
+func SelfModifications(mods []strace.SelfModificationInfo) []analysisrun.SelfModificationResult {
+	var results []analysisrun.SelfModificationResult
+	for _, m := range mods {
+		ignored := slices.ContainsFunc(selfModificationIgnoredDirs, func(dir string) bool {
+			return strings.HasPrefix(m.Path, dir)
+		})
+		if ignored {
+			continue
+		}
+		results = append(results, analysisrun.SelfModificationResult{
+			Path:    m.Path,
+			Command: m.Command,
+			Write:   m.Write,
+			Delete:  m.Delete,
+		})
+	}
+	return results
+}
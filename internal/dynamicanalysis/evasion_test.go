@@ -0,0 +1,147 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestEnvironmentChecks(t *testing.T) {
+	summary := &analysisrun.StraceSummary{
+		Files: []analysisrun.FileResult{
+			{Path: "/app/index.js", Read: true},
+			{Path: "/sys/class/dmi/id/product_name", Read: true},
+			{Path: "/.dockerenv", Read: true},
+			{Path: "/sys/class/net/eth0/address", Read: true},
+			{Path: "/proc/self/cgroup", Read: true},
+			{Path: "/etc/hostname", Write: true},
+		},
+		Commands: []analysisrun.CommandResult{
+			{Command: []string{"npm", "install"}},
+			{Command: []string{"/usr/bin/systemd-detect-virt"}},
+			{Command: []string{"ip", "link", "show"}},
+			{Command: []string{"ip", "route"}},
+		},
+	}
+
+	want := []analysisrun.EnvironmentCheckResult{
+		{Category: EnvCheckVirtualization, Source: EnvCheckSourceFile, Indicator: "/sys/class/dmi/id/product_name"},
+		{Category: EnvCheckContainer, Source: EnvCheckSourceFile, Indicator: "/.dockerenv"},
+		{Category: EnvCheckNetwork, Source: EnvCheckSourceFile, Indicator: "/sys/class/net/eth0/address"},
+		{Category: EnvCheckVirtualization, Source: EnvCheckSourceCommand, Indicator: "/usr/bin/systemd-detect-virt"},
+		{Category: EnvCheckNetwork, Source: EnvCheckSourceCommand, Indicator: "ip link show"},
+	}
+
+	if got := EnvironmentChecks(summary); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvironmentChecks() = %v, want %v", got, want)
+	}
+}
+
+func TestLikelySandboxEvasion(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []analysisrun.EnvironmentCheckResult
+		want   bool
+	}{
+		{
+			name:   "no checks",
+			checks: nil,
+			want:   false,
+		},
+		{
+			name:   "single hardware check",
+			checks: []analysisrun.EnvironmentCheckResult{{Category: EnvCheckHardware}},
+			want:   false,
+		},
+		{
+			name:   "repeated hostname checks",
+			checks: []analysisrun.EnvironmentCheckResult{{Category: EnvCheckHostname}, {Category: EnvCheckHostname}},
+			want:   false,
+		},
+		{
+			name:   "container check",
+			checks: []analysisrun.EnvironmentCheckResult{{Category: EnvCheckContainer}},
+			want:   true,
+		},
+		{
+			name:   "virtualization check",
+			checks: []analysisrun.EnvironmentCheckResult{{Category: EnvCheckVirtualization}},
+			want:   true,
+		},
+		{
+			name:   "multiple categories",
+			checks: []analysisrun.EnvironmentCheckResult{{Category: EnvCheckHardware}, {Category: EnvCheckNetwork}},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LikelySandboxEvasion(tt.checks); got != tt.want {
+				t.Errorf("LikelySandboxEvasion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLikelyDelayedPayload(t *testing.T) {
+	tests := []struct {
+		name   string
+		sleeps []analysisrun.SleepResult
+		want   bool
+	}{
+		{
+			name:   "no sleeps",
+			sleeps: nil,
+			want:   false,
+		},
+		{
+			name:   "short sleeps",
+			sleeps: []analysisrun.SleepResult{{Syscall: "epoll_wait", DurationMs: 5000, Count: 20}},
+			want:   false,
+		},
+		{
+			name:   "long sleep",
+			sleeps: []analysisrun.SleepResult{{Syscall: "epoll_wait", DurationMs: 5000, Count: 1}, {Syscall: "nanosleep", DurationMs: 600000, Count: 1}},
+			want:   true,
+		},
+		{
+			name:   "long relative clock sleep",
+			sleeps: []analysisrun.SleepResult{{Syscall: "clock_nanosleep", DurationMs: 120000, Count: 1}},
+			want:   true,
+		},
+		{
+			name: "event loop timeouts",
+			sleeps: []analysisrun.SleepResult{
+				{Syscall: "epoll_wait", DurationMs: 600000, Count: 3},
+				{Syscall: "epoll_pwait", DurationMs: 3600000, Count: 1},
+				{Syscall: "epoll_pwait2", DurationMs: 120000, Count: 1},
+				{Syscall: "nanosleep", DurationMs: 100, Count: 10},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LikelyDelayedPayload(tt.sleeps); got != tt.want {
+				t.Errorf("LikelyDelayedPayload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfModifications(t *testing.T) {
+	mods := []strace.SelfModificationInfo{
+		{Path: "/app/node_modules/evil/install.js", Command: []string{"node", "install.js"}, Delete: true},
+		{Path: "/tmp/pip-install-abc/temps/setup.py", Command: []string{"python3", "setup.py"}, Delete: true},
+		{Path: "/tmp/payload", Command: []string{"/tmp/payload"}, Write: true},
+	}
+	want := []analysisrun.SelfModificationResult{
+		{Path: "/app/node_modules/evil/install.js", Command: []string{"node", "install.js"}, Delete: true},
+		{Path: "/tmp/payload", Command: []string{"/tmp/payload"}, Write: true},
+	}
+	if got := SelfModifications(mods); !reflect.DeepEqual(got, want) {
+		t.Errorf("SelfModifications() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package dynamicanalysis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// buildToolCommands are the names of compilers and linkers, whose output is an
+// executable that was built from source (e.g. a native addon built by node-gyp)
+// rather than dropped.
+var buildToolCommands = map[string]bool{
+	"cc": true, "c++": true, "gcc": true, "g++": true, "clang": true, "clang++": true,
+	"ld": true, "ld.bfd": true, "ld.gold": true, "ld.lld": true, "collect2": true,
+	"rustc": true,
+}
+
+// installerCommands are the names of the commands of package managers of other
+// ecosystems than npm (see packageManagerCommands), which write executables (e.g.
+// console scripts) when installing packages.
+var installerCommands = map[string]bool{
+	"pip": true, "pip3": true, "gem": true, "bundle": true, "composer": true, "cargo": true,
+}
+
+// ExecutableFormat returns the format of an executable file from its first bytes:
+// one of the analysisrun.Executable* constants, or the empty string if it is
+// not an executable. For scripts, interpreter is the rest of the shebang line
+// (e.g. "/usr/bin/env node").
+func ExecutableFormat(header []byte) (format, interpreter string) {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x7fELF")):
+		return analysisrun.ExecutableELF, ""
+	case bytes.HasPrefix(header, []byte{0xfe, 0xed, 0xfa, 0xce}), bytes.HasPrefix(header, []byte{0xfe, 0xed, 0xfa, 0xcf}),
+		bytes.HasPrefix(header, []byte{0xce, 0xfa, 0xed, 0xfe}), bytes.HasPrefix(header, []byte{0xcf, 0xfa, 0xed, 0xfe}):
+		return analysisrun.ExecutableMachO, ""
+	case isFatMachO(header):
+		return analysisrun.ExecutableMachO, ""
+	case bytes.HasPrefix(header, []byte("MZ")) && len(header) >= 64:
+		return analysisrun.ExecutablePE, ""
+	case bytes.HasPrefix(header, []byte("#!")):
+		line, _, _ := bytes.Cut(header[2:], []byte("\n"))
+		return analysisrun.ExecutableScript, strings.TrimSpace(string(line))
+	}
+	return "", ""
+}
+
+// isFatMachO returns whether header is that of a universal Mach-O binary. These
+// share their magic number with Java class files, which are told apart by the
+// number of architectures that follows it, as the version of a class file is
+// much larger.
+func isFatMachO(header []byte) bool {
+	if len(header) < 8 || !bytes.HasPrefix(header, []byte{0xca, 0xfe, 0xba, 0xbe}) {
+		return false
+	}
+	n := binary.BigEndian.Uint32(header[4:8])
+	return n > 0 && n < 20
+}
+
+/*
+ExecutableDrops finds the executables written by the package: binaries (ELF, Mach-O
+or PE, identified by their first bytes), and scripts with a shebang line that were
+also made executable. Dropping an executable is far more significant than writing a
+data file, and one that is then made executable by chmod (MadeExecutable) is armed
+to be run, as is typical of droppers that download and run a second stage.
+
+A result is returned for each command which wrote an executable, excluding package
+managers (e.g. npm extracting the bins of packages, or pip installing console
+scripts) and compilers (e.g. building a native addon). Writes by processes whose
+command is not known are not reported, since they cannot be told apart from those
+of the package manager. A file whose contents were not recorded (e.g. because it
+was copied with sendfile) but which was made executable is reported without a
+format.
+*/
+func ExecutableDrops(files []strace.FileInfo) []analysisrun.ExecutableDropResult {
+	var results []analysisrun.ExecutableDropResult
+	for _, f := range files {
+		if !f.Write {
+			continue
+		}
+		format, interpreter := ExecutableFormat(f.Header)
+		switch {
+		case format == "" && (len(f.Header) > 0 || !f.MadeExecutable):
+			continue
+		case format == analysisrun.ExecutableScript && !f.MadeExecutable:
+			continue
+		}
+		for _, w := range f.Writers {
+			if isPackageManager(w.Command) || isInstaller(w.Command) || isBuildTool(w.Command) {
+				continue
+			}
+			results = append(results, analysisrun.ExecutableDropResult{
+				Path:           f.Path,
+				Format:         format,
+				Interpreter:    interpreter,
+				MadeExecutable: f.MadeExecutable,
+				Command:        w.Command,
+			})
+		}
+	}
+	return results
+}
+
+// isInstaller returns whether cmd runs a package manager of another ecosystem
+// than npm (see installerCommands), including pip run as a Python module.
+func isInstaller(cmd []string) bool {
+	if len(cmd) == 0 {
+		return false
+	}
+	name := path.Base(cmd[0])
+	if strings.HasPrefix(name, "python") && len(cmd) > 2 && cmd[1] == "-m" {
+		name = cmd[2]
+	}
+	return installerCommands[name]
+}
+
+// isBuildTool returns whether cmd runs a compiler or linker (see buildToolCommands).
+func isBuildTool(cmd []string) bool {
+	return len(cmd) > 0 && buildToolCommands[path.Base(cmd[0])]
+}
@@ -0,0 +1,75 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestExecutableFormat(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          string
+		wantFormat      string
+		wantInterpreter string
+	}{
+		{name: "elf", header: "\x7fELF\x02\x01\x01", wantFormat: analysisrun.ExecutableELF},
+		{name: "mach-o 64", header: "\xcf\xfa\xed\xfe\x07\x00\x00\x01", wantFormat: analysisrun.ExecutableMachO},
+		{name: "universal mach-o", header: "\xca\xfe\xba\xbe\x00\x00\x00\x02", wantFormat: analysisrun.ExecutableMachO},
+		{name: "java class", header: "\xca\xfe\xba\xbe\x00\x00\x00\x34"},
+		{name: "pe", header: "MZ\x90\x00" + string(make([]byte, 60)), wantFormat: analysisrun.ExecutablePE},
+		{name: "short MZ", header: "MZ is not a binary"},
+		{name: "script", header: "#! /usr/bin/env node\nrequire('x')", wantFormat: analysisrun.ExecutableScript, wantInterpreter: "/usr/bin/env node"},
+		{name: "text", header: "hello"},
+		{name: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, interpreter := dynamicanalysis.ExecutableFormat([]byte(tt.header))
+			if format != tt.wantFormat || interpreter != tt.wantInterpreter {
+				t.Errorf("ExecutableFormat(%q) = %q, %q, want %q, %q", tt.header, format, interpreter, tt.wantFormat, tt.wantInterpreter)
+			}
+		})
+	}
+}
+
+func TestExecutableDrops(t *testing.T) {
+	script := strace.CommandInfo{Command: []string{"node", "install.js"}}
+	npm := strace.CommandInfo{Command: []string{"npm", "install"}}
+	pip := strace.CommandInfo{Command: []string{"python3", "-m", "pip", "install", "x"}}
+	linker := strace.CommandInfo{Command: []string{"/usr/bin/ld", "-o", "build/addon.node"}}
+	elf := []byte("\x7fELF\x02\x01\x01")
+
+	files := []strace.FileInfo{
+		// not written
+		{Path: "/usr/bin/ls", Read: true, Header: elf},
+		// not an executable
+		{Path: "/tmp/data.json", Write: true, Header: []byte("{}"), MadeExecutable: true, Writers: []strace.CommandInfo{script}},
+		// script not made executable
+		{Path: "/tmp/run.sh", Write: true, Header: []byte("#!/bin/sh\nid"), Writers: []strace.CommandInfo{script}},
+		// written by a package manager or a linker
+		{Path: "/app/node_modules/.bin/tool", Write: true, Header: []byte("#!/usr/bin/env node\n"), MadeExecutable: true, Writers: []strace.CommandInfo{npm}},
+		{Path: "/usr/local/bin/tool", Write: true, Header: []byte("#!/usr/bin/python3\n"), MadeExecutable: true, Writers: []strace.CommandInfo{pip}},
+		{Path: "/app/build/addon.node", Write: true, Header: elf, Writers: []strace.CommandInfo{linker}},
+		// writer not known
+		{Path: "/tmp/unknown", Write: true, Header: elf},
+		// drops
+		{Path: "/tmp/.x/miner", Write: true, Header: elf, MadeExecutable: true, Writers: []strace.CommandInfo{npm, script}},
+		{Path: "/tmp/payload.exe", Write: true, Header: append([]byte("MZ"), make([]byte, 62)...), Writers: []strace.CommandInfo{script}},
+		{Path: "/tmp/stage2", Write: true, Header: []byte("#!/bin/bash\ncurl"), MadeExecutable: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/tmp/copied", Write: true, MadeExecutable: true, Writers: []strace.CommandInfo{script}},
+	}
+
+	want := []analysisrun.ExecutableDropResult{
+		{Path: "/tmp/.x/miner", Format: analysisrun.ExecutableELF, MadeExecutable: true, Command: script.Command},
+		{Path: "/tmp/payload.exe", Format: analysisrun.ExecutablePE, Command: script.Command},
+		{Path: "/tmp/stage2", Format: analysisrun.ExecutableScript, Interpreter: "/bin/bash", MadeExecutable: true, Command: script.Command},
+		{Path: "/tmp/copied", MadeExecutable: true, Command: script.Command},
+	}
+	if got := dynamicanalysis.ExecutableDrops(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExecutableDrops() =\n%+v\nwant\n%+v", got, want)
+	}
+}
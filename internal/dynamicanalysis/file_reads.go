@@ -0,0 +1,146 @@
+package dynamicanalysis
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+const (
+	// BroadReadDirectories is the number of distinct directories (outside the
+	// package, runtime and cache directories) containing files read in a phase at
+	// which the package is considered to be walking the filesystem. Installing
+	// and importing benign packages reads files in only a handful of them.
+	BroadReadDirectories = 25
+
+	// BroadReadSensitiveDirectories is the number of distinct sensitive directories
+	// (see sensitiveReadDirs) with files read in a phase at which the package is
+	// considered to be harvesting data, rather than reading a single config file.
+	BroadReadSensitiveDirectories = 2
+
+	// BroadReadSensitiveFiles is the number of files read in a single sensitive
+	// directory at which the package is considered to be harvesting its contents.
+	BroadReadSensitiveFiles = 10
+)
+
+// routineReadDirs are prefixes of the paths of files that runtimes and package
+// managers routinely read, which are not counted towards the breadth of reads.
+// This includes system libraries, pseudo-filesystems, temporary build directories
+// and the caches of package managers in the home directory.
+var routineReadDirs = []string{
+	"/bin/", "/sbin/", "/lib/", "/lib64/", "/usr/", "/opt/",
+	"/proc/", "/sys/", "/dev/", "/run/",
+	"/tmp/", "/var/tmp/",
+	"/root/.npm/", "/root/.cache/", "/root/.local/", "/root/.cargo/", "/root/.rustup/",
+	"/root/.composer/", "/root/.config/composer/", "/root/.gem/", "/root/.bundle/",
+}
+
+// sensitiveReadDirs are directories which hold credentials, configuration of the
+// system or other users' data, and which benign packages have little reason to
+// read. Files read in them are reported by FileReads.
+var sensitiveReadDirs = []string{
+	"/etc",
+	"/home",
+	"/var/log",
+	"/root/.ssh",
+	"/root/.aws",
+	"/root/.azure",
+	"/root/.gnupg",
+	"/root/.docker",
+	"/root/.kube",
+	"/root/.config/gcloud",
+	"/root/.mozilla",
+	"/root/.config/google-chrome",
+}
+
+// routineEtcFiles are prefixes of the paths of files in /etc which are read by the
+// C library and language runtimes, e.g. to resolve hostnames and users, or to verify
+// TLS certificates.
+var routineEtcFiles = []string{
+	"/etc/ld.so.cache", "/etc/ld.so.preload", "/etc/nsswitch.conf", "/etc/resolv.conf",
+	"/etc/hosts", "/etc/host.conf", "/etc/gai.conf", "/etc/passwd", "/etc/group",
+	"/etc/localtime", "/etc/timezone", "/etc/mime.types", "/etc/os-release",
+	"/etc/ssl/", "/etc/pki/", "/etc/ca-certificates/", "/etc/python", "/etc/gitconfig",
+	"/etc/npmrc", "/etc/pip.conf", "/etc/debian_version", "/etc/alternatives/",
+}
+
+// isRoutineRead returns whether p is in one of routineReadDirs, or is a file in
+// /etc that is read routinely (see routineEtcFiles).
+func isRoutineRead(p string) bool {
+	for _, prefix := range routineReadDirs {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	for _, prefix := range routineEtcFiles {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitiveReadDir returns the entry of sensitiveReadDirs that contains p, or the
+// empty string if there is none.
+func sensitiveReadDir(p string) string {
+	for _, dir := range sensitiveReadDirs {
+		if strings.HasPrefix(p, dir+"/") {
+			return dir
+		}
+	}
+	return ""
+}
+
+/*
+FileReads summarises the breadth of the reads of the given files accessed in a phase,
+which distinguishes a package that walks the filesystem (e.g. everything under
+$HOME or /etc) to harvest data from one that reads a few files of its own.
+
+Directories counts the distinct directories containing files that were read,
+ignoring the package (see analysisrun.IsPackageFile), and the system, runtime and
+cache directories that language runtimes and package managers routinely read.
+SensitiveReads counts the files read in each of a list of directories which hold
+credentials or system configuration (e.g. /etc, ~/.ssh and ~/.aws), other than
+files read routinely (e.g. /etc/resolv.conf), sorted by directory.
+*/
+func FileReads(files []analysisrun.FileResult) analysisrun.FileReadResult {
+	var result analysisrun.FileReadResult
+	dirs := make(map[string]bool)
+	sensitive := make(map[string]int)
+	for _, f := range files {
+		if !f.Read || analysisrun.IsPackageFile(f.Path) || isRoutineRead(f.Path) {
+			continue
+		}
+		dirs[path.Dir(f.Path)] = true
+		if dir := sensitiveReadDir(f.Path); dir != "" {
+			sensitive[dir]++
+		}
+	}
+	result.Directories = len(dirs)
+	for dir, count := range sensitive {
+		result.SensitiveReads = append(result.SensitiveReads, analysisrun.SensitiveReadResult{Directory: dir, Files: count})
+	}
+	slices.SortFunc(result.SensitiveReads, func(a, b analysisrun.SensitiveReadResult) int {
+		return strings.Compare(a.Directory, b.Directory)
+	})
+	return result
+}
+
+// LikelyBroadFileReads returns whether the given summary of file reads shows files
+// read in at least BroadReadDirectories directories, in at least
+// BroadReadSensitiveDirectories sensitive directories, or at least
+// BroadReadSensitiveFiles files read in one sensitive directory, which suggests
+// that the package is snooping through the filesystem.
+func LikelyBroadFileReads(r analysisrun.FileReadResult) bool {
+	if r.Directories >= BroadReadDirectories || len(r.SensitiveReads) >= BroadReadSensitiveDirectories {
+		return true
+	}
+	for _, s := range r.SensitiveReads {
+		if s.Files >= BroadReadSensitiveFiles {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package dynamicanalysis
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestFileReads(t *testing.T) {
+	// a typical install, reading the package, the runtime and the npm cache
+	benign := []analysisrun.FileResult{
+		{Path: "/app/node_modules/pkg/index.js", Read: true},
+		{Path: "/usr/local/lib/node_modules/npm/package.json", Read: true},
+		{Path: "/root/.npm/_cacache/index-v5/1a/2b/3c", Read: true},
+		{Path: "/etc/resolv.conf", Read: true},
+		{Path: "/etc/ssl/certs/ca-certificates.crt", Read: true},
+		{Path: "/root/.npmrc", Read: true},
+		{Path: "/etc/shadow", Write: true},
+	}
+	// walking the home directory and /etc
+	var snooping []analysisrun.FileResult
+	for i := 0; i < 12; i++ {
+		snooping = append(snooping, analysisrun.FileResult{Path: fmt.Sprintf("/etc/conf%d.d/conf", i), Read: true})
+	}
+	snooping = append(snooping,
+		analysisrun.FileResult{Path: "/root/.ssh/id_rsa", Read: true},
+		analysisrun.FileResult{Path: "/root/.ssh/known_hosts", Read: true},
+	)
+
+	tests := []struct {
+		name      string
+		files     []analysisrun.FileResult
+		want      analysisrun.FileReadResult
+		wantBroad bool
+	}{
+		{
+			name:  "no files",
+			files: nil,
+		},
+		{
+			name:  "benign",
+			files: benign,
+			want:  analysisrun.FileReadResult{Directories: 1},
+		},
+		{
+			name:  "single sensitive file",
+			files: []analysisrun.FileResult{{Path: "/etc/shadow", Read: true}},
+			want: analysisrun.FileReadResult{
+				Directories:    1,
+				SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: 1}},
+			},
+		},
+		{
+			name:  "snooping",
+			files: snooping,
+			want: analysisrun.FileReadResult{
+				Directories: 13,
+				SensitiveReads: []analysisrun.SensitiveReadResult{
+					{Directory: "/etc", Files: 12},
+					{Directory: "/root/.ssh", Files: 2},
+				},
+			},
+			wantBroad: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FileReads(tt.files)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FileReads() = %+v, want %+v", got, tt.want)
+			}
+			if broad := LikelyBroadFileReads(got); broad != tt.wantBroad {
+				t.Errorf("LikelyBroadFileReads() = %v, want %v", broad, tt.wantBroad)
+			}
+		})
+	}
+}
+
+func TestLikelyBroadFileReads(t *testing.T) {
+	tests := []struct {
+		name  string
+		reads analysisrun.FileReadResult
+		want  bool
+	}{
+		{
+			name:  "few directories",
+			reads: analysisrun.FileReadResult{Directories: BroadReadDirectories - 1},
+			want:  false,
+		},
+		{
+			name:  "many directories",
+			reads: analysisrun.FileReadResult{Directories: BroadReadDirectories},
+			want:  true,
+		},
+		{
+			name: "many sensitive files",
+			reads: analysisrun.FileReadResult{
+				Directories:    1,
+				SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: BroadReadSensitiveFiles}},
+			},
+			want: true,
+		},
+		{
+			name: "several sensitive directories",
+			reads: analysisrun.FileReadResult{
+				Directories:    2,
+				SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/root/.aws", Files: 1}, {Directory: "/root/.ssh", Files: 1}},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LikelyBroadFileReads(tt.reads); got != tt.want {
+				t.Errorf("LikelyBroadFileReads() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
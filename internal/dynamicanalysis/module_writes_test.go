@@ -0,0 +1,65 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestModuleWrites(t *testing.T) {
+	npm := strace.CommandInfo{Command: []string{"npm", "install", "evil"}}
+	npmNode := strace.CommandInfo{Command: []string{"node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "install"}}
+	script := strace.CommandInfo{Command: []string{"sh", "-c", "node install.js"}, Env: []string{"npm_package_name=evil"}}
+	depScript := strace.CommandInfo{Command: []string{"sh", "-c", "node-gyp rebuild"}, Env: []string{"npm_package_name=@scope/native"}}
+
+	files := []strace.FileInfo{
+		// written by the package manager
+		{Path: "/root/.npm/_cacache/index-v5/00/ab", Write: true, Writers: []strace.CommandInfo{npm}},
+		{Path: "/app/node_modules/lodash/index.js", Write: true, Writers: []strace.CommandInfo{npmNode}},
+		// written by the package to its own directory
+		{Path: "/app/node_modules/evil/build/out.js", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/app/node_modules/evil/node_modules/dep/index.js", Write: true, Writers: []strace.CommandInfo{script}},
+		// written by a dependency to its own directory
+		{Path: "/app/node_modules/@scope/native/build/addon.node", Write: true, Writers: []strace.CommandInfo{depScript}},
+		// routine caches of build tools
+		{Path: "/app/node_modules/.cache/babel/x.json", Write: true, Writers: []strace.CommandInfo{script}},
+		// writer not known
+		{Path: "/app/node_modules/other/index.js", Write: true},
+		// written by the package elsewhere
+		{Path: "/app/node_modules/lodash/index.js", Write: true, Writers: []strace.CommandInfo{npm, script}},
+		{Path: "/app/node_modules/@scope/native/index.js", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/usr/local/lib/node_modules/npm/index.js", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/root/.npm/_cacache/content-v2/sha512/ab/cd", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/root/.cache/yarn/v6/npm-lodash/index.js", Write: true, Writers: []strace.CommandInfo{depScript}},
+		// outside caches and node_modules
+		{Path: "/tmp/x", Write: true, Writers: []strace.CommandInfo{script}},
+	}
+	want := []analysisrun.ModuleWriteResult{
+		{Path: "/app/node_modules/lodash/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: script.Command},
+		{Path: "/app/node_modules/@scope/native/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: script.Command},
+		{Path: "/usr/local/lib/node_modules/npm/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: script.Command},
+		{Path: "/root/.npm/_cacache/content-v2/sha512/ab/cd", Kind: analysisrun.ModuleWriteCache, Command: script.Command},
+		{Path: "/root/.cache/yarn/v6/npm-lodash/index.js", Kind: analysisrun.ModuleWriteCache, Command: depScript.Command},
+	}
+
+	if got := dynamicanalysis.ModuleWrites(files, "evil"); !reflect.DeepEqual(got, want) {
+		t.Errorf("ModuleWrites() = %+v, want %+v", got, want)
+	}
+}
+
+func TestModuleWritesNoPackageName(t *testing.T) {
+	script := strace.CommandInfo{Command: []string{"node", "index.js"}}
+	files := []strace.FileInfo{
+		{Path: "/app/node_modules/evil/build/out.js", Write: true, Writers: []strace.CommandInfo{script}},
+	}
+	want := []analysisrun.ModuleWriteResult{
+		{Path: "/app/node_modules/evil/build/out.js", Kind: analysisrun.ModuleWriteNodeModules, Command: script.Command},
+	}
+
+	if got := dynamicanalysis.ModuleWrites(files, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("ModuleWrites() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package dynamicanalysis
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// isSharedLibrary returns whether p is the path of a native addon (.node) or a
+// shared library (e.g. libx.so, libx.so.1 or libx.dylib).
+func isSharedLibrary(p string) bool {
+	name := path.Base(p)
+	switch path.Ext(name) {
+	case ".node", ".so", ".dylib":
+		return true
+	}
+	_, version, ok := strings.Cut(name, ".so.")
+	return ok && version != "" && strings.Trim(version, "0123456789.") == ""
+}
+
+/*
+NativeLibraryLoads finds the native addons and shared libraries that were opened
+for reading, as they are when loaded by dlopen (e.g. by require of a .node file or
+process.dlopen), from the directory of the package being analyzed, whose name is
+packageName, or that were written during the analysis (e.g. built by node-gyp, or
+downloaded). Native code runs outside the JavaScript engine, so what it does is
+only visible in the trace, and never to analysis of the code of the package.
+
+The libraries of the system and of the runtime (e.g. in /usr/lib) are not reported,
+nor are the native addons in the directory of the package, which are how packages
+such as bcrypt and sqlite3 bind to native code, whether they are shipped or built
+by node-gyp during installation.
+A result is returned for each command which opened a library, other than package
+managers and compilers, or a single result without a command if the readers of the
+library are not known.
+
+If packageName is empty, only libraries which were written are reported.
+*/
+func NativeLibraryLoads(files []strace.FileInfo, packageName string) []analysisrun.NativeLibraryLoadResult {
+	var results []analysisrun.NativeLibraryLoadResult
+	for _, f := range files {
+		if !f.Read || !isSharedLibrary(f.Path) {
+			continue
+		}
+		shipped := packageName != "" && strings.Contains(f.Path, nodeModulesDir+packageName+"/")
+		if !shipped && !f.Write || shipped && path.Ext(f.Path) == ".node" {
+			continue
+		}
+		if len(f.Readers) == 0 {
+			results = append(results, analysisrun.NativeLibraryLoadResult{Path: f.Path, Written: f.Write})
+		}
+		for _, r := range f.Readers {
+			if isPackageManager(r.Command) || isBuildTool(r.Command) {
+				continue
+			}
+			results = append(results, analysisrun.NativeLibraryLoadResult{Path: f.Path, Written: f.Write, Command: r.Command})
+		}
+	}
+	return results
+}
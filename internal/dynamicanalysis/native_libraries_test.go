@@ -0,0 +1,54 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestNativeLibraryLoads(t *testing.T) {
+	node := strace.CommandInfo{Command: []string{"node", "index.js"}}
+	npm := strace.CommandInfo{Command: []string{"npm", "install"}}
+	linker := strace.CommandInfo{Command: []string{"g++", "-shared", "-o", "addon.node"}}
+
+	files := []strace.FileInfo{
+		// system libraries
+		{Path: "/usr/lib/x86_64-linux-gnu/libstdc++.so.6", Read: true, Readers: []strace.CommandInfo{node}},
+		{Path: "/lib/x86_64-linux-gnu/libc.so.6", Read: true},
+		// not a library
+		{Path: "/app/node_modules/pkg/index.js", Read: true, Readers: []strace.CommandInfo{node}},
+		{Path: "/app/node_modules/pkg/lib.so.backup", Read: true, Readers: []strace.CommandInfo{node}},
+		// another package
+		{Path: "/app/node_modules/other/build/Release/other.node", Read: true, Readers: []strace.CommandInfo{node}},
+		// not read
+		{Path: "/app/node_modules/pkg/build/Release/unused.node", Write: true},
+		// the addons of the package, shipped or built by node-gyp
+		{Path: "/app/node_modules/pkg/build/Release/addon.node", Read: true, Write: true, Readers: []strace.CommandInfo{linker, npm, node}},
+		{Path: "/app/node_modules/pkg/prebuilds/linux-x64/pkg.node", Read: true, Readers: []strace.CommandInfo{node}},
+		// loads
+		{Path: "/tmp/.x/addon.node", Read: true, Write: true, Readers: []strace.CommandInfo{linker, npm, node}},
+		{Path: "/app/node_modules/pkg/prebuilds/libpayload.so.1.2", Read: true},
+		{Path: "/tmp/.x/lib.so", Write: true, Read: true, Readers: []strace.CommandInfo{node}},
+	}
+
+	want := []analysisrun.NativeLibraryLoadResult{
+		{Path: "/tmp/.x/addon.node", Written: true, Command: node.Command},
+		{Path: "/app/node_modules/pkg/prebuilds/libpayload.so.1.2"},
+		{Path: "/tmp/.x/lib.so", Written: true, Command: node.Command},
+	}
+	if got := dynamicanalysis.NativeLibraryLoads(files, "pkg"); !reflect.DeepEqual(got, want) {
+		t.Errorf("NativeLibraryLoads() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	want = []analysisrun.NativeLibraryLoadResult{
+		{Path: "/app/node_modules/pkg/build/Release/addon.node", Written: true, Command: node.Command},
+		want[0],
+		want[2],
+	}
+	if got := dynamicanalysis.NativeLibraryLoads(files, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("NativeLibraryLoads() with no package name =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package dynamicanalysis
+
+import (
+	"net"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+/*
+DeniedConnections returns the sockets that connect to a remote host, for a run in
+which the network of the sandbox was disabled (see WithNetworkDenied), so that
+each of them is a connection that the package intended to make but could not.
+Connections to the local machine and to DNS servers (port 53), which are made to
+resolve hostnames rather than to reach them, are excluded.
+
+Since no hostname can be resolved, the hosts that the package looked up are only
+known from the payloads of its DNS queries (see strace.DNSQueryInfo). A socket of
+each DNS server that was sent queries is included, with the queried hostnames,
+other than those of the package registries, as its Hostnames.
+*/
+func DeniedConnections(sockets []analysisrun.SocketResult, queries []strace.DNSQueryInfo) []analysisrun.SocketResult {
+	var result []analysisrun.SocketResult
+	for _, s := range sockets {
+		if s.Family == analysisrun.FamilyUnix || s.Port == strace.DNSPort {
+			continue
+		}
+		if ip := net.ParseIP(s.Address); ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+			continue
+		}
+		result = append(result, s)
+	}
+	for _, q := range queries {
+		var hostnames []string
+		for _, h := range q.Hostnames {
+			if !analysisrun.IsRegistryHost(h) {
+				hostnames = append(hostnames, h)
+			}
+		}
+		if len(hostnames) == 0 {
+			continue
+		}
+		result = append(result, analysisrun.SocketResult{
+			Family:    q.Family,
+			Address:   q.Address,
+			Port:      q.Port,
+			Hostnames: hostnames,
+		})
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestDeniedConnections(t *testing.T) {
+	sockets := []analysisrun.SocketResult{
+		{Family: analysisrun.FamilyInet, Address: "127.0.0.1", Port: 8080},
+		{Family: analysisrun.FamilyInet6, Address: "::", Port: 443},
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53},
+		{Family: analysisrun.FamilyUnix, Address: "/var/run/nscd/socket"},
+		{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443, Hostnames: []string{"evil.example.com"}},
+		{Family: analysisrun.FamilyInet6, Address: "2001:db8::1", Port: 8443},
+	}
+
+	queries := []strace.DNSQueryInfo{
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53, Hostnames: []string{"registry.npmjs.org", "c2.example.com"}},
+		{Family: analysisrun.FamilyInet, Address: "8.8.4.4", Port: 53, Hostnames: []string{"pypi.org"}},
+	}
+
+	want := []analysisrun.SocketResult{
+		sockets[4],
+		sockets[5],
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53, Hostnames: []string{"c2.example.com"}},
+	}
+	if got := dynamicanalysis.DeniedConnections(sockets, queries); !reflect.DeepEqual(got, want) {
+		t.Errorf("DeniedConnections() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+package dynamicanalysis
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// shellProfileFiles are the paths of the files, relative to a home directory,
+// that shells run when they start, so that a command written to one is run by
+// every later shell (e.g. to change PATH, or alias a command).
+var shellProfileFiles = []string{
+	".bashrc", ".bash_profile", ".bash_login", ".bash_logout", ".profile",
+	".zshrc", ".zshenv", ".zprofile", ".zlogin",
+	".kshrc", ".mkshrc", ".cshrc", ".tcshrc",
+	".config/fish/config.fish",
+}
+
+// systemProfileFiles are the paths of the profile files shared by all users, and
+// systemProfileDirs the directories whose files are run by them.
+var (
+	systemProfileFiles = []string{"/etc/profile", "/etc/bash.bashrc", "/etc/environment", "/etc/zshrc", "/etc/zsh/zshrc", "/etc/zsh/zshenv", "/etc/zsh/zprofile"}
+	systemProfileDirs  = []string{"/etc/profile.d/", "/etc/fish/conf.d/"}
+)
+
+// defaultPathDirs are the directories in the PATH of the sandbox, which are not
+// reported as added to PATH.
+var defaultPathDirs = map[string]bool{
+	"/usr/local/sbin":       true,
+	"/usr/local/bin":        true,
+	"/usr/sbin":             true,
+	"/usr/bin":              true,
+	"/sbin":                 true,
+	"/bin":                  true,
+	"/usr/local/cargo/bin":  true,
+	"/usr/local/bundle/bin": true,
+}
+
+// routinePathDirs are substrings of the directories that package managers add to
+// PATH while installing packages, e.g. for npm, the node_modules/.bin directories
+// of the packages whose lifecycle scripts are run, and for pip, the environment in
+// which a package is built.
+var routinePathDirs = []string{"/node_modules/.bin", "/node-gyp-bin", "/pip-build-env-", "/tmp/xfs-"}
+
+// isShellProfile returns whether p is a shell profile file, of any user or of the
+// whole system.
+func isShellProfile(p string) bool {
+	if slices.Contains(systemProfileFiles, p) {
+		return true
+	}
+	for _, dir := range systemProfileDirs {
+		if strings.HasPrefix(p, dir) {
+			return true
+		}
+	}
+	rel, ok := homeRelativePath(p)
+	return ok && slices.Contains(shellProfileFiles, rel)
+}
+
+/*
+ShellProfileWrites finds the writes to shell profile files (e.g. ~/.bashrc,
+~/.profile or /etc/profile.d/*), which a package has no reason to make while being
+installed or imported. Commands written to a profile are run by every later shell,
+so a package can use them to persist, to prepend a directory to PATH, or to alias
+common commands (e.g. sudo) to hijack them.
+
+A result is returned for each command which wrote to a profile, or a single result
+without a command if the writers of the profile are not known.
+*/
+func ShellProfileWrites(files []strace.FileInfo) []analysisrun.ShellProfileWriteResult {
+	var results []analysisrun.ShellProfileWriteResult
+	for _, f := range files {
+		if !f.Write || !isShellProfile(f.Path) {
+			continue
+		}
+		if len(f.Writers) == 0 {
+			results = append(results, analysisrun.ShellProfileWriteResult{Path: f.Path})
+		}
+		for _, w := range f.Writers {
+			results = append(results, analysisrun.ShellProfileWriteResult{Path: f.Path, Command: w.Command})
+		}
+	}
+	return results
+}
+
+/*
+PathModifications finds the directories added to the PATH of the commands that
+were run, other than the directories in the PATH of the sandbox and those that
+package managers routinely add (e.g. node_modules/.bin). A command run with a
+directory of the package, or a writable directory such as /tmp, at the front of
+its PATH runs the files there instead of the system commands of the same names.
+
+The directories are listed in the order they were first seen, with the first
+command run with each. Written lists the files written in the directory, which
+are likely commands planted to be run from PATH (see LikelyPathHijack).
+*/
+func PathModifications(commands []strace.CommandInfo, files []strace.FileInfo) []analysisrun.PathModificationResult {
+	var results []analysisrun.PathModificationResult
+	seen := make(map[string]bool)
+	for _, c := range commands {
+		for _, dir := range addedPathDirs(c.Env) {
+			if seen[dir] {
+				continue
+			}
+			seen[dir] = true
+			results = append(results, analysisrun.PathModificationResult{
+				Directory: dir,
+				Command:   c.Command,
+				Written:   writtenIn(files, dir),
+			})
+		}
+	}
+	return results
+}
+
+// addedPathDirs returns the directories in the PATH of env that are not in
+// defaultPathDirs, or routinely added by package managers.
+func addedPathDirs(env []string) []string {
+	var dirs []string
+	for _, e := range env {
+		value, ok := strings.CutPrefix(e, "PATH=")
+		if !ok {
+			continue
+		}
+		for _, dir := range strings.Split(value, ":") {
+			if dir == "" {
+				// an empty entry means the current directory
+				dir = "."
+			}
+			dir = path.Clean(dir)
+			if !defaultPathDirs[dir] && !isRoutinePathDir(dir) && !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+func isRoutinePathDir(dir string) bool {
+	for _, s := range routinePathDirs {
+		if strings.Contains(dir, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenIn returns the paths of the files that were written directly in dir.
+func writtenIn(files []strace.FileInfo, dir string) []string {
+	var written []string
+	for _, f := range files {
+		if f.Write && path.Dir(f.Path) == dir {
+			written = append(written, f.Path)
+		}
+	}
+	return written
+}
+
+// LikelyPathHijack returns whether a file was written into a directory that was
+// added to PATH, i.e. the package likely planted a command (e.g. a fake git or sudo)
+// and arranged for it to be run instead of the real one.
+func LikelyPathHijack(modifications []analysisrun.PathModificationResult) bool {
+	return slices.ContainsFunc(modifications, func(m analysisrun.PathModificationResult) bool {
+		return len(m.Written) > 0
+	})
+}
@@ -0,0 +1,70 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestShellProfileWrites(t *testing.T) {
+	script := strace.CommandInfo{Command: []string{"node", "install.js"}}
+
+	files := []strace.FileInfo{
+		// not written
+		{Path: "/root/.bashrc", Read: true},
+		// not a profile
+		{Path: "/root/.bashrc.d/x", Write: true},
+		{Path: "/app/.profile", Write: true},
+		// profiles
+		{Path: "/root/.bashrc", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/home/user/.zshrc", Write: true},
+		{Path: "/root/.config/fish/config.fish", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/etc/profile.d/update.sh", Write: true, Writers: []strace.CommandInfo{script}},
+		{Path: "/etc/environment", Write: true},
+	}
+
+	want := []analysisrun.ShellProfileWriteResult{
+		{Path: "/root/.bashrc", Command: script.Command},
+		{Path: "/home/user/.zshrc"},
+		{Path: "/root/.config/fish/config.fish", Command: script.Command},
+		{Path: "/etc/profile.d/update.sh", Command: script.Command},
+		{Path: "/etc/environment"},
+	}
+	if got := dynamicanalysis.ShellProfileWrites(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("ShellProfileWrites() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPathModifications(t *testing.T) {
+	const defaultPath = "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	commands := []strace.CommandInfo{
+		{Command: []string{"npm", "install"}, Env: []string{"HOME=/root", defaultPath}},
+		{Command: []string{"sh", "-c", "node install.js"}, Env: []string{"PATH=/app/node_modules/.bin:/usr/lib/node_modules/npm/node_modules/@npmcli/run-script/lib/node-gyp-bin:/usr/bin"}},
+		{Command: []string{"git", "status"}, Env: []string{"PATH=/tmp/.cache/bin:/usr/bin:/bin/"}},
+		{Command: []string{"sudo", "id"}, Env: []string{"PATH=/tmp/.cache/bin::/usr/bin"}},
+	}
+	files := []strace.FileInfo{
+		{Path: "/tmp/.cache/bin/git", Write: true},
+		{Path: "/tmp/.cache/bin/sudo", Write: true},
+		{Path: "/tmp/.cache/bin/README", Read: true},
+		{Path: "/tmp/.cache/bin/lib/x.so", Write: true},
+	}
+
+	got := dynamicanalysis.PathModifications(commands, files)
+	want := []analysisrun.PathModificationResult{
+		{Directory: "/tmp/.cache/bin", Command: []string{"git", "status"}, Written: []string{"/tmp/.cache/bin/git", "/tmp/.cache/bin/sudo"}},
+		{Directory: ".", Command: []string{"sudo", "id"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PathModifications() =\n%+v\nwant\n%+v", got, want)
+	}
+	if !dynamicanalysis.LikelyPathHijack(got) {
+		t.Errorf("LikelyPathHijack(%+v) = false, want true", got)
+	}
+	if dynamicanalysis.LikelyPathHijack(got[1:]) {
+		t.Errorf("LikelyPathHijack(%+v) = true, want false", got[1:])
+	}
+}
@@ -0,0 +1,49 @@
+package dynamicanalysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// PhaseEnvironments maps dynamic analysis phases to extra environment variables
+// set for the analysis command of that phase (see WithEnv). Phases without an
+// entry run with the default environment of the sandbox.
+type PhaseEnvironments map[analysisrun.DynamicPhase]map[string]string
+
+// ParsePhaseEnv parses a phase environment variable of the form
+// "PHASE:NAME=VALUE" and adds it to e. PHASE must be one of AllDynamicPhases,
+// and VALUE may be empty, but must not contain a newline.
+func (e PhaseEnvironments) ParsePhaseEnv(s string) error {
+	phaseName, variable, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("invalid phase environment variable %q: expected PHASE:NAME=VALUE", s)
+	}
+
+	phase := analysisrun.DynamicPhase(strings.TrimSpace(phaseName))
+	if phase == "" {
+		return fmt.Errorf("invalid phase environment variable %q: missing phase", s)
+	}
+	if !slices.Contains(analysisrun.AllDynamicPhases(), phase) {
+		return fmt.Errorf("invalid phase environment variable %q: unknown phase %q", s, phase)
+	}
+
+	name, value, ok := strings.Cut(variable, "=")
+	if !ok {
+		return fmt.Errorf("invalid phase environment variable %q: expected PHASE:NAME=VALUE", s)
+	}
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid phase environment variable %q: invalid name %q", s, name)
+	}
+	if strings.Contains(value, "\n") {
+		return fmt.Errorf("invalid phase environment variable %q: value contains a newline", s)
+	}
+
+	if e[phase] == nil {
+		e[phase] = make(map[string]string)
+	}
+	e[phase][name] = value
+	return nil
+}
@@ -0,0 +1,49 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestParsePhaseEnv(t *testing.T) {
+	env := make(PhaseEnvironments)
+	for _, s := range []string{
+		"install:CI=true",
+		"install:NODE_OPTIONS=--max-old-space-size=512",
+		"import:HOME=",
+		"install:CI=1",
+	} {
+		if err := env.ParsePhaseEnv(s); err != nil {
+			t.Fatalf("ParsePhaseEnv(%q) error = %v", s, err)
+		}
+	}
+
+	want := PhaseEnvironments{
+		analysisrun.DynamicPhaseInstall: {"CI": "1", "NODE_OPTIONS": "--max-old-space-size=512"},
+		analysisrun.DynamicPhaseImport:  {"HOME": ""},
+	}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("ParsePhaseEnv() = %v, want %v", env, want)
+	}
+}
+
+func TestParsePhaseEnvInvalid(t *testing.T) {
+	tests := []string{
+		"CI=true",
+		":CI=true",
+		"install:CI",
+		"install:=true",
+		"install:MY VAR=true",
+		"instal:CI=true",
+		"install:CI=true\nLD_PRELOAD=/tmp/evil.so",
+	}
+	for _, s := range tests {
+		t.Run(s, func(t *testing.T) {
+			if err := make(PhaseEnvironments).ParsePhaseEnv(s); err == nil {
+				t.Errorf("ParsePhaseEnv(%q) expected error", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package dynamicanalysis
+
+import (
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+const (
+	// ForkBombPeakCount is the number of processes alive at the same time at
+	// which a package is considered to be a likely fork bomb. Benign installs
+	// (including parallel native builds) rarely run more than a few dozen
+	// processes at once, while fork bombs grow until they exhaust the sandbox.
+	ForkBombPeakCount = 128
+
+	// ForkBombSpawnRate is the number of processes created within one second at
+	// which a package is considered to be a likely fork bomb. This is set well
+	// above the rate of shell-heavy build steps such as configure scripts, which
+	// run many short-lived processes one after another.
+	ForkBombSpawnRate = 500
+)
+
+// Processes converts the summary of processes in a phase to a ProcessResult.
+func Processes(p strace.ProcessInfo) analysisrun.ProcessResult {
+	return analysisrun.ProcessResult{
+		Spawned:       p.Spawned,
+		Execs:         p.Execs,
+		PeakCount:     p.PeakCount,
+		PeakSpawnRate: p.PeakSpawnRate,
+	}
+}
+
+// LingeringProcesses converts the processes left running at the end of a phase to
+// LingeringProcessResults. Process IDs are dropped, since they vary between runs.
+func LingeringProcesses(processes []strace.LingeringProcessInfo) []analysisrun.LingeringProcessResult {
+	var results []analysisrun.LingeringProcessResult
+	for _, p := range processes {
+		results = append(results, analysisrun.LingeringProcessResult{
+			Command:    p.Command,
+			Daemonized: p.Daemonized,
+		})
+	}
+	return results
+}
+
+// LikelyForkBomb returns whether the given processes summary shows processes alive
+// at once or being created at an anomalously high rate (see ForkBombPeakCount and
+// ForkBombSpawnRate), which suggests a fork bomb or other attempt to exhaust the
+// resources of the sandbox, rather than a package that legitimately runs many commands.
+func LikelyForkBomb(p analysisrun.ProcessResult) bool {
+	return p.PeakCount >= ForkBombPeakCount || p.PeakSpawnRate >= ForkBombSpawnRate
+}
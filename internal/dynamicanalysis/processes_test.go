@@ -0,0 +1,42 @@
+package dynamicanalysis
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestLikelyForkBomb(t *testing.T) {
+	tests := []struct {
+		name      string
+		processes analysisrun.ProcessResult
+		want      bool
+	}{
+		{
+			name: "no processes",
+			want: false,
+		},
+		{
+			name:      "busy build",
+			processes: analysisrun.ProcessResult{Spawned: 2000, Execs: 2000, PeakCount: 12, PeakSpawnRate: 150},
+			want:      false,
+		},
+		{
+			name:      "many processes alive",
+			processes: analysisrun.ProcessResult{Spawned: 300, PeakCount: ForkBombPeakCount, PeakSpawnRate: 100},
+			want:      true,
+		},
+		{
+			name:      "high spawn rate",
+			processes: analysisrun.ProcessResult{Spawned: 600, PeakCount: 20, PeakSpawnRate: ForkBombSpawnRate},
+			want:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LikelyForkBomb(tt.processes); got != tt.want {
+				t.Errorf("LikelyForkBomb() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package dynamicanalysis
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/ossf/package-analysis/internal/utils"
+)
+
+// rawLogRecorder keeps a copy of (part of) the strace log of a run, as it is read
+// by the parser, for Result.RawStraceLog. A nil *rawLogRecorder records nothing.
+type rawLogRecorder struct {
+	buf *utils.HeadTailBuffer
+}
+
+// newRawLogRecorder returns a rawLogRecorder which keeps at most maxBytes of the
+// log: the first and last maxBytes/2 bytes of it. If maxBytes is not positive,
+// nil is returned.
+func newRawLogRecorder(maxBytes int) *rawLogRecorder {
+	if maxBytes <= 0 {
+		return nil
+	}
+	head := maxBytes / 2
+	return &rawLogRecorder{buf: utils.NewHeadTailBuffer(head, maxBytes-head)}
+}
+
+// tee returns a reader which reads from log and records what is read.
+func (r *rawLogRecorder) tee(log io.Reader) io.Reader {
+	if r == nil {
+		return log
+	}
+	return io.TeeReader(log, r.buf)
+}
+
+// bytes returns the recorded log. If part of the log was discarded, a line noting
+// how many bytes were discarded marks where (see utils.HeadTailBuffer.WriteTo).
+func (r *rawLogRecorder) bytes() []byte {
+	if r == nil {
+		return nil
+	}
+	var b bytes.Buffer
+	r.buf.WriteTo(&b)
+	return b.Bytes()
+}
@@ -0,0 +1,58 @@
+package dynamicanalysis
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRawLogRecorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		log      string
+		want     string
+	}{
+		{
+			name:     "fits",
+			maxBytes: 32,
+			log:      "execve(\"/bin/sh\")\n",
+			want:     "execve(\"/bin/sh\")\n",
+		},
+		{
+			name:     "truncated",
+			maxBytes: 8,
+			log:      "0123456789abcdef",
+			want:     "0123\n[... 8 bytes truncated ...]\ncdef",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRawLogRecorder(tt.maxBytes)
+			read, err := io.ReadAll(r.tee(strings.NewReader(tt.log)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(read) != tt.log {
+				t.Errorf("tee() read %q, want %q", read, tt.log)
+			}
+			if got := string(r.bytes()); got != tt.want {
+				t.Errorf("bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawLogRecorderDisabled(t *testing.T) {
+	r := newRawLogRecorder(0)
+	if r != nil {
+		t.Fatalf("newRawLogRecorder(0) = %v, want nil", r)
+	}
+	log := strings.NewReader("log")
+	if got := r.tee(log); got != log {
+		t.Errorf("tee() = %v, want the log reader", got)
+	}
+	if got := r.bytes(); got != nil {
+		t.Errorf("bytes() = %q, want nil", got)
+	}
+}
@@ -0,0 +1,124 @@
+package dynamicanalysis
+
+import (
+	"net"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// reconTools are the programs that print information about the system, its
+// users and its network, which infostealers run to fingerprint their victims.
+// uname is not one of them, since the install scripts that download prebuilt
+// binaries run it to choose the binary for the platform.
+var reconTools = map[string]bool{
+	"whoami":      true,
+	"id":          true,
+	"groups":      true,
+	"users":       true,
+	"who":         true,
+	"w":           true,
+	"hostname":    true,
+	"hostnamectl": true,
+	"lsb_release": true,
+	"ifconfig":    true,
+	"ip":          true,
+	"netstat":     true,
+	"ss":          true,
+	"arp":         true,
+	"env":         true,
+	"printenv":    true,
+	"systeminfo":  true,
+	"ipconfig":    true,
+}
+
+// reconTool returns the name of the reconnaissance tool run by cmd, if any. The
+// env program is only counted when it prints the environment, rather than being
+// used to run another program (e.g. '/usr/bin/env node' in a shebang).
+func reconTool(cmd []string) (string, bool) {
+	if len(cmd) == 0 {
+		return "", false
+	}
+	tool := path.Base(cmd[0])
+	if !reconTools[tool] {
+		return "", false
+	}
+	if tool == "env" && slices.ContainsFunc(cmd[1:], func(arg string) bool {
+		return !strings.HasPrefix(arg, "-")
+	}) {
+		return "", false
+	}
+	return tool, true
+}
+
+/*
+ReconExfiltration finds the system reconnaissance commands (e.g. whoami, hostname,
+id or ifconfig) that were followed by connections to remote hosts, which is how
+infostealers fingerprint a machine and send the result to their server. Running
+one of these commands, or connecting out, is common on its own; it is the sequence
+that is suspicious.
+
+Since the process which made a connection is not known, any connection made after
+a command was first run is attributed to it. Connections to the local machine, to
+DNS servers and to package registries (e.g. by the package manager fetching other
+packages) are excluded. The hostnames of the destinations are taken from sockets.
+*/
+func ReconExfiltration(connections []strace.CommandConnectionsInfo, sockets []analysisrun.SocketResult) []analysisrun.ReconExfiltrationResult {
+	var results []analysisrun.ReconExfiltrationResult
+	seen := make(map[string]int)
+	for _, c := range connections {
+		tool, ok := reconTool(c.Command)
+		if !ok {
+			continue
+		}
+		var destinations []analysisrun.SocketResult
+		for _, s := range c.Sockets {
+			if dest, ok := remoteDestination(s, sockets); ok {
+				destinations = append(destinations, dest)
+			}
+		}
+		if len(destinations) == 0 {
+			continue
+		}
+		// The same command may have been run with different environments.
+		key := strings.Join(c.Command, "\x00")
+		if i, ok := seen[key]; ok {
+			for _, dest := range destinations {
+				if !slices.ContainsFunc(results[i].Destinations, func(s analysisrun.SocketResult) bool {
+					return s.Family == dest.Family && s.Address == dest.Address && s.Port == dest.Port
+				}) {
+					results[i].Destinations = append(results[i].Destinations, dest)
+				}
+			}
+			continue
+		}
+		seen[key] = len(results)
+		results = append(results, analysisrun.ReconExfiltrationResult{Tool: tool, Command: c.Command, Destinations: destinations})
+	}
+	return results
+}
+
+// remoteDestination returns the result for s in sockets (or one without hostnames,
+// if it is missing), unless s is not a connection to a remote host that could
+// receive exfiltrated data.
+func remoteDestination(s strace.SocketInfo, sockets []analysisrun.SocketResult) (analysisrun.SocketResult, bool) {
+	if s.Family == analysisrun.FamilyUnix || s.Port == 53 {
+		return analysisrun.SocketResult{}, false
+	}
+	if ip := net.ParseIP(s.Address); ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return analysisrun.SocketResult{}, false
+	}
+	dest := analysisrun.SocketResult{Family: s.Family, Address: s.Address, Port: s.Port}
+	if i := slices.IndexFunc(sockets, func(r analysisrun.SocketResult) bool {
+		return r.Family == s.Family && r.Address == s.Address && r.Port == s.Port
+	}); i >= 0 {
+		dest = sockets[i]
+	}
+	if slices.ContainsFunc(dest.Hostnames, isRegistryAPIHost) || slices.ContainsFunc(dest.ServerNames, isRegistryAPIHost) {
+		return analysisrun.SocketResult{}, false
+	}
+	return dest, true
+}
@@ -0,0 +1,54 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestReconExfiltration(t *testing.T) {
+	registry := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "104.16.19.35", Port: 443}
+	remote := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443}
+	other := strace.SocketInfo{Family: analysisrun.FamilyInet6, Address: "2001:db8::1", Port: 8443}
+	files := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "151.101.0.223", Port: 443}
+	local := []strace.SocketInfo{
+		{Family: analysisrun.FamilyInet, Address: "127.0.0.1", Port: 8080},
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53},
+		{Family: analysisrun.FamilyUnix, Address: "/var/run/nscd/socket"},
+	}
+	sockets := []analysisrun.SocketResult{
+		{Family: registry.Family, Address: registry.Address, Port: registry.Port, Hostnames: []string{"registry.npmjs.org"}},
+		{Family: remote.Family, Address: remote.Address, Port: remote.Port, Hostnames: []string{"evil.example.com"}},
+		{Family: files.Family, Address: files.Address, Port: files.Port, ServerNames: []string{"files.pythonhosted.org"}},
+	}
+
+	connections := []strace.CommandConnectionsInfo{
+		// not recon commands
+		{Command: []string{"node", "install.js"}, Sockets: []strace.SocketInfo{remote}},
+		{Command: []string{"/usr/bin/env", "node", "install.js"}, Sockets: []strace.SocketInfo{remote}},
+		// only followed by local, DNS and registry connections
+		{Command: []string{"hostname"}, Sockets: append([]strace.SocketInfo{registry, files}, local...)},
+		// platform detection by install scripts that download binaries
+		{Command: []string{"uname", "-m"}, Sockets: []strace.SocketInfo{remote}},
+		// recon
+		{Command: []string{"/usr/bin/whoami"}, Env: []string{"A=1"}, Sockets: []strace.SocketInfo{registry, remote}},
+		{Command: []string{"/usr/bin/whoami"}, Env: []string{"A=2"}, Sockets: []strace.SocketInfo{other, remote}},
+		{Command: []string{"env"}, Sockets: []strace.SocketInfo{other}},
+	}
+
+	want := []analysisrun.ReconExfiltrationResult{
+		{Tool: "whoami", Command: []string{"/usr/bin/whoami"}, Destinations: []analysisrun.SocketResult{
+			sockets[1],
+			{Family: other.Family, Address: other.Address, Port: other.Port},
+		}},
+		{Tool: "env", Command: []string{"env"}, Destinations: []analysisrun.SocketResult{
+			{Family: other.Family, Address: other.Address, Port: other.Port},
+		}},
+	}
+	if got := dynamicanalysis.ReconExfiltration(connections, sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("ReconExfiltration() =\n%+v\nwant\n%+v", got, want)
+	}
+}
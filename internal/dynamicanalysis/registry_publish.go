@@ -0,0 +1,259 @@
+package dynamicanalysis
+
+import (
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// publishTools maps the tools that publish packages to the subcommands which
+// publish packages or modify them (or their owners and access) on a registry, and
+// the host of the registry they use by default.
+var publishTools = map[string]struct {
+	subcommands map[string]bool
+	registry    string
+}{
+	"npm":    {npmPublishSubcommands, "registry.npmjs.org"},
+	"pnpm":   {npmPublishSubcommands, "registry.npmjs.org"},
+	"yarn":   {map[string]bool{"publish": true, "owner": true, "tag": true}, "registry.yarnpkg.com"},
+	"twine":  {map[string]bool{"upload": true, "register": true}, "upload.pypi.org"},
+	"poetry": {map[string]bool{"publish": true}, "upload.pypi.org"},
+	"flit":   {map[string]bool{"publish": true}, "upload.pypi.org"},
+	"hatch":  {map[string]bool{"publish": true}, "upload.pypi.org"},
+	"gem":    {map[string]bool{"push": true, "owner": true, "yank": true}, "rubygems.org"},
+	"cargo":  {map[string]bool{"publish": true, "owner": true, "yank": true}, "crates.io"},
+}
+
+var npmPublishSubcommands = map[string]bool{
+	"publish":   true,
+	"unpublish": true,
+	"owner":     true,
+	"access":    true,
+	"dist-tag":  true,
+	"deprecate": true,
+	"token":     true,
+	"team":      true,
+	"org":       true,
+}
+
+// registryAPIHosts are the hosts of the APIs of the package registries, other than
+// those that packages are installed from (see analysisrun.IsRegistryHost).
+var registryAPIHosts = map[string]bool{
+	"upload.pypi.org": true,
+	"test.pypi.org":   true,
+}
+
+// isRegistryAPIHost returns whether host is one of the package registries or
+// their APIs.
+func isRegistryAPIHost(host string) bool {
+	return analysisrun.IsRegistryHost(host) || registryAPIHosts[host]
+}
+
+// publishOnlyHosts are registry hosts that are only used for publishing, so that
+// a connection to them is an attempt to publish even if the command is not known.
+var publishOnlyHosts = []string{"upload.pypi.org"}
+
+// writeMethods are the HTTP methods that modify resources.
+var writeMethods = map[string]bool{"PUT": true, "POST": true, "DELETE": true, "PATCH": true}
+
+// registryCredentialFiles are the names of the files in which package managers
+// store credentials for registries.
+var registryCredentialFiles = []string{
+	"/.npmrc",
+	"/.yarnrc.yml",
+	"/.pypirc",
+	"/.gem/credentials",
+	"/.local/share/gem/credentials",
+	"/.cargo/credentials",
+	"/.cargo/credentials.toml",
+	"/composer/auth.json",
+	"/.composer/auth.json",
+}
+
+/*
+RegistryPublishes finds attempts to publish packages to a registry, or to modify
+packages (e.g. their owners, access or dist-tags) on it, among the commands run and
+the sockets connected. A package that does so while being installed is likely trying
+to spread to other packages that the credentials of the machine give access to, or
+to take them over.
+
+Attempts are found from the commands of publishing tools (e.g. npm publish or twine
+upload), requests that modify resources on a registry API made by HTTP clients
+(e.g. curl -X PUT https://registry.npmjs.org/...), and connections to hosts only
+used for publishing. Requests made by the code of the package itself cannot be
+seen, since their URLs are encrypted, unless they connect to such a host.
+*/
+func RegistryPublishes(commands []strace.CommandInfo, sockets []analysisrun.SocketResult) []analysisrun.RegistryPublishResult {
+	var results []analysisrun.RegistryPublishResult
+	for _, c := range commands {
+		if registry, action, ok := publishCommand(c.Command); ok {
+			results = append(results, analysisrun.RegistryPublishResult{
+				Registry:  registry,
+				Action:    action,
+				Command:   c.Command,
+				Connected: connectedTo(sockets, registry),
+			})
+		}
+	}
+	for _, host := range publishOnlyHosts {
+		if connectedTo(sockets, host) && !slices.ContainsFunc(results, func(r analysisrun.RegistryPublishResult) bool { return r.Registry == host }) {
+			results = append(results, analysisrun.RegistryPublishResult{
+				Registry:  host,
+				Action:    "connection",
+				Connected: true,
+			})
+		}
+	}
+	return results
+}
+
+/*
+RegistryCredentialReads returns the paths of the registry credential files (e.g.
+~/.npmrc or ~/.pypirc) in files that were read by a process that is not a package
+manager, or by a process that publishes to a registry (see RegistryPublishes). Package
+managers routinely read their configuration while installing a package, but the
+package itself has no reason to.
+
+Reads are only attributed to processes whose command is known, and tokens passed in
+environment variables (e.g. NPM_TOKEN) cannot be seen being read.
+*/
+func RegistryCredentialReads(files []strace.FileInfo) []string {
+	var paths []string
+	for _, f := range files {
+		if !f.Read || !isRegistryCredentialFile(f.Path) {
+			continue
+		}
+		if slices.ContainsFunc(f.Readers, func(r strace.CommandInfo) bool {
+			_, _, publishing := publishCommand(r.Command)
+			return publishing || !isPackageManager(r.Command)
+		}) {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+func isRegistryCredentialFile(p string) bool {
+	for _, suffix := range registryCredentialFiles {
+		if strings.HasSuffix(p, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectedTo returns whether any of sockets was connected to host, as found by
+// the DNS lookups or TLS server names for its address.
+func connectedTo(sockets []analysisrun.SocketResult, host string) bool {
+	for _, s := range sockets {
+		if slices.Contains(s.Hostnames, host) || slices.Contains(s.ServerNames, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishCommand returns whether cmd publishes to or modifies packages on a registry,
+// and if so, the host of the registry and a description of the action.
+func publishCommand(cmd []string) (registry, action string, ok bool) {
+	tool, args := commandTool(cmd)
+	switch tool {
+	case "curl", "wget", "http", "https":
+		return httpWriteRequest(tool, args)
+	}
+
+	info, known := publishTools[tool]
+	if !known {
+		return "", "", false
+	}
+	var positional []string
+	registry = info.registry
+	for i, a := range args {
+		if value, found := strings.CutPrefix(a, "--registry="); found {
+			registry = urlHost(value)
+		} else if a == "--registry" && i+1 < len(args) {
+			registry = urlHost(args[i+1])
+		} else if !strings.HasPrefix(a, "-") {
+			positional = append(positional, a)
+		}
+	}
+	// yarn 2+ runs registry commands as e.g. yarn npm publish
+	if tool == "yarn" && len(positional) > 0 && positional[0] == "npm" {
+		positional = positional[1:]
+	}
+	if len(positional) == 0 || !info.subcommands[positional[0]] {
+		return "", "", false
+	}
+	return registry, tool + " " + positional[0], true
+}
+
+// commandTool returns the name of the tool run by cmd, and its arguments. Tools
+// run as scripts by node (e.g. node npm-cli.js) or as modules by python (e.g.
+// python -m twine) are named by the script or module.
+func commandTool(cmd []string) (string, []string) {
+	if len(cmd) == 0 {
+		return "", nil
+	}
+	name, args := path.Base(cmd[0]), cmd[1:]
+	switch {
+	case name == "node" && len(args) > 0:
+		name, args = path.Base(args[0]), args[1:]
+		name = strings.TrimSuffix(strings.TrimSuffix(name, path.Ext(name)), "-cli")
+	case strings.HasPrefix(name, "python") && len(args) > 1 && args[0] == "-m":
+		name, args = args[1], args[2:]
+	}
+	return name, args
+}
+
+// httpWriteRequest returns whether the arguments of an HTTP client (curl, wget
+// or HTTPie) make a request which modifies a resource on a registry API, and if so,
+// the host of the registry and the method and URL of the request.
+func httpWriteRequest(tool string, args []string) (registry, action string, ok bool) {
+	method, target := "", ""
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case (tool == "http" || tool == "https") && i == 0 && writeMethods[a]:
+			method = a
+		case a == "-X" || a == "--request":
+			if i+1 < len(args) {
+				method = strings.ToUpper(args[i+1])
+				i++
+			}
+		case strings.HasPrefix(a, "-X") && tool == "curl":
+			method = strings.ToUpper(a[2:])
+		case strings.HasPrefix(a, "--method="):
+			method = strings.ToUpper(strings.TrimPrefix(a, "--method="))
+		case a == "-d" || strings.HasPrefix(a, "--data") || a == "--json" || a == "-F" || a == "--form" ||
+			strings.HasPrefix(a, "--post-") || strings.HasPrefix(a, "--body-"):
+			if method == "" {
+				method = "POST"
+			}
+		case a == "-T" || a == "--upload-file":
+			if method == "" {
+				method = "PUT"
+			}
+		case strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://"):
+			if target == "" && isRegistryAPIHost(urlHost(a)) {
+				target = a
+			}
+		}
+	}
+	if target == "" || !writeMethods[method] {
+		return "", "", false
+	}
+	return urlHost(target), method + " " + target, true
+}
+
+// urlHost returns the host name of the URL u, or the empty string if it is not valid.
+func urlHost(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return parsed.Hostname()
+}
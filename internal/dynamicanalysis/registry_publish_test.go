@@ -0,0 +1,87 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestRegistryPublishes(t *testing.T) {
+	command := func(args ...string) strace.CommandInfo {
+		return strace.CommandInfo{Command: args}
+	}
+	commands := []strace.CommandInfo{
+		// not publishing
+		command("npm", "install", "evil"),
+		command("node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "view", "lodash"),
+		command("curl", "https://registry.npmjs.org/lodash"),
+		command("curl", "-X", "PUT", "https://example.com/upload"),
+		command("twine", "check", "dist/*"),
+		command("sh", "-c", "npm publish"),
+		// publishing
+		command("node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "publish", "--access", "public"),
+		command("npm", "owner", "add", "attacker", "lodash", "--registry=https://registry.example.com/"),
+		command("yarn", "npm", "publish"),
+		command("/usr/bin/python3", "-m", "twine", "upload", "dist/evil-1.0.tar.gz"),
+		command("gem", "push", "evil-1.0.gem"),
+		command("curl", "-XPUT", "-d", "@package.json", "https://registry.npmjs.org/lodash"),
+		command("curl", "--data-binary", "@evil.gem", "https://rubygems.org/api/v1/gems"),
+		command("wget", "--method=DELETE", "https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz/-rev/1"),
+	}
+	sockets := []analysisrun.SocketResult{
+		{Address: "104.16.0.1", Port: 443, Hostnames: []string{"registry.npmjs.org"}},
+		{Address: "151.101.0.223", Port: 443, ServerNames: []string{"upload.pypi.org"}},
+	}
+
+	want := []analysisrun.RegistryPublishResult{
+		{Registry: "registry.npmjs.org", Action: "npm publish", Command: commands[6].Command, Connected: true},
+		{Registry: "registry.example.com", Action: "npm owner", Command: commands[7].Command},
+		{Registry: "registry.yarnpkg.com", Action: "yarn publish", Command: commands[8].Command},
+		{Registry: "upload.pypi.org", Action: "twine upload", Command: commands[9].Command, Connected: true},
+		{Registry: "rubygems.org", Action: "gem push", Command: commands[10].Command},
+		{Registry: "registry.npmjs.org", Action: "PUT https://registry.npmjs.org/lodash", Command: commands[11].Command, Connected: true},
+		{Registry: "rubygems.org", Action: "POST https://rubygems.org/api/v1/gems", Command: commands[12].Command},
+		{Registry: "registry.npmjs.org", Action: "DELETE https://registry.npmjs.org/lodash/-/lodash-4.17.21.tgz/-rev/1", Command: commands[13].Command, Connected: true},
+	}
+	if got := dynamicanalysis.RegistryPublishes(commands, sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("RegistryPublishes() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// A connection to a host only used for publishing is an attempt on its own.
+	want = []analysisrun.RegistryPublishResult{
+		{Registry: "upload.pypi.org", Action: "connection", Connected: true},
+	}
+	if got := dynamicanalysis.RegistryPublishes(commands[:6], sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("RegistryPublishes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRegistryCredentialReads(t *testing.T) {
+	npm := strace.CommandInfo{Command: []string{"npm", "install", "evil"}}
+	publish := strace.CommandInfo{Command: []string{"npm", "publish"}}
+	script := strace.CommandInfo{Command: []string{"node", "install.js"}}
+
+	files := []strace.FileInfo{
+		// read by the package manager
+		{Path: "/root/.npmrc", Read: true, Readers: []strace.CommandInfo{npm}},
+		// reader not known
+		{Path: "/root/.pypirc", Read: true},
+		// not a credential file
+		{Path: "/root/.bashrc", Read: true, Readers: []strace.CommandInfo{script}},
+		// only written
+		{Path: "/app/.npmrc", Write: true, Writers: []strace.CommandInfo{script}},
+		// read by the package, or to publish
+		{Path: "/app/.npmrc", Read: true, Readers: []strace.CommandInfo{npm, script}},
+		{Path: "/home/user/.npmrc", Read: true, Readers: []strace.CommandInfo{publish}},
+		{Path: "/root/.cargo/credentials.toml", Read: true, Readers: []strace.CommandInfo{script}},
+		{Path: "/root/.gem/credentials", Read: true, Readers: []strace.CommandInfo{script}},
+	}
+	want := []string{"/app/.npmrc", "/home/user/.npmrc", "/root/.cargo/credentials.toml", "/root/.gem/credentials"}
+
+	if got := dynamicanalysis.RegistryCredentialReads(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("RegistryCredentialReads() = %v, want %v", got, want)
+	}
+}
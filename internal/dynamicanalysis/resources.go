@@ -0,0 +1,24 @@
+package dynamicanalysis
+
+import (
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// ResourceUsage converts the resource accounting of the sandbox for a phase to a
+// ResourceUsageResult. If u is nil (i.e. the usage could not be measured), nil is
+// returned.
+func ResourceUsage(u *sandbox.ResourceUsage) *analysisrun.ResourceUsageResult {
+	if u == nil {
+		return nil
+	}
+	return &analysisrun.ResourceUsageResult{
+		CPUTimeMs:        u.CPUTime.Milliseconds(),
+		PeakMemoryBytes:  int64(u.PeakMemoryBytes),
+		BlockReadBytes:   int64(u.BlockReadBytes),
+		BlockWriteBytes:  int64(u.BlockWriteBytes),
+		NetReceivedBytes: int64(u.NetReceivedBytes),
+		NetSentBytes:     int64(u.NetSentBytes),
+		PeakThreads:      int(u.PeakThreads),
+	}
+}
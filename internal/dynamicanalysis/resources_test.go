@@ -0,0 +1,38 @@
+package dynamicanalysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestResourceUsage(t *testing.T) {
+	if got := ResourceUsage(nil); got != nil {
+		t.Errorf("ResourceUsage(nil) = %+v, want nil", got)
+	}
+
+	u := &sandbox.ResourceUsage{
+		CPUTime:          1500 * time.Millisecond,
+		PeakMemoryBytes:  1 << 30,
+		BlockReadBytes:   1,
+		BlockWriteBytes:  2,
+		NetReceivedBytes: 3,
+		NetSentBytes:     4,
+		PeakThreads:      64,
+	}
+	want := &analysisrun.ResourceUsageResult{
+		CPUTimeMs:        1500,
+		PeakMemoryBytes:  1 << 30,
+		BlockReadBytes:   1,
+		BlockWriteBytes:  2,
+		NetReceivedBytes: 3,
+		NetSentBytes:     4,
+		PeakThreads:      64,
+	}
+	if got := ResourceUsage(u); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourceUsage() = %+v, want %+v", got, want)
+	}
+}
@@ -27,6 +27,9 @@ func DefaultCommand(ecosystem pkgecosystem.Ecosystem) string {
 // MakeAnalysisArgs returns the arguments to pass to the dynamic analysis command in the sandbox
 // for the given phase of dynamic analysis on a package. The actual analysis command
 // depends on the ecosystem, see pkgmanager.PkgManager.DynamicAnalysisCommand()
+//
+// For a local package, the path passed with --local is either a package archive
+// or, for an extracted package (see pkgmanager.Pkg.IsExtracted), a directory.
 func MakeAnalysisArgs(p *pkgmanager.Pkg, phase analysisrun.DynamicPhase) []string {
 	args := make([]string, 0)
 
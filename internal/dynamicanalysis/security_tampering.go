@@ -0,0 +1,299 @@
+package dynamicanalysis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// ErrInvalidTamperingRule is returned by LoadTamperingRules if a rule has no name,
+// matches nothing, or has a pattern that is not valid.
+var ErrInvalidTamperingRule = errors.New("invalid tampering rule")
+
+/*
+TamperingRule describes an action which disables or evades security tooling, such as
+clearing the shell history, truncating logs or killing an antivirus or monitoring
+agent. Packages have no reason to do any of these while being installed or imported,
+so they are strong indicators of malice.
+
+A command matches the rule if the base name of its executable is one of Commands,
+one of its arguments matches one of Actions (if any are given), and one of its
+arguments matches one of Targets (if any are given). Arguments are matched with
+path.Match, both as given and by their base name without a ".service" suffix, so
+that e.g. "auditd" matches /sbin/auditd and auditd.service. The commands of scripts
+run by a shell with -c (e.g. sh -c "unset HISTFILE; ...") are matched as well, so
+that shell builtins such as unset and history can be given in Commands.
+
+A file matches the rule if it was written (including truncated) or deleted, and its
+path, or a directory containing it, matches one of Paths. Paths are absolute
+path.Match patterns, or patterns relative to any home directory if they start with
+"~/". Writes by the tools that own the files, given by the base names of their
+executables in Tools, are not reported.
+*/
+type TamperingRule struct {
+	Name     string   `json:"name"`
+	Commands []string `json:"commands,omitempty"`
+	Actions  []string `json:"actions,omitempty"`
+	Targets  []string `json:"targets,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+	Tools    []string `json:"tools,omitempty"`
+}
+
+// securityAgents are the names of the processes and services of antivirus, EDR,
+// audit and monitoring agents commonly deployed on Linux servers and CI runners.
+var securityAgents = []string{
+	"auditd", "auditbeat", "osqueryd", "falco", "tetragon", "tracee", "sysdig",
+	"falcon-sensor", "falcond", "cbagentd", "cbdaemon", "sentinel*", "mdatp", "wdavdaemon",
+	"wazuh-agentd", "ossec*", "clamd", "clamav-daemon", "freshclam", "sophos*", "savd",
+	"elastic-agent", "filebeat", "aide", "apparmor", "rsyslog", "syslog-ng",
+}
+
+var defaultTamperingRules = []TamperingRule{
+	{
+		Name:     "shell_history",
+		Commands: []string{"history", "unset", "export"},
+		Targets:  []string{"-c", "-d", "HISTFILE", "HISTFILE=/dev/null", "HISTFILE=", "HISTSIZE=0", "HISTFILESIZE=0"},
+		Paths: []string{
+			"~/.bash_history", "~/.zsh_history", "~/.sh_history", "~/.ash_history", "~/.history",
+			"~/.python_history", "~/.node_repl_history", "~/.mysql_history", "~/.psql_history",
+		},
+	},
+	{
+		Name:     "log_tampering",
+		Commands: []string{"journalctl"},
+		Targets:  []string{"--vacuum-*", "--rotate"},
+		Paths:    []string{"/var/log", "/var/run/utmp", "/run/utmp"},
+		Tools:    []string{"dpkg", "apt", "apt-get", "logrotate", "rsyslogd", "systemd-journald"},
+	},
+	{
+		Name:     "security_processes",
+		Commands: []string{"kill", "pkill", "killall"},
+		Targets:  securityAgents,
+	},
+	{
+		Name:     "security_processes",
+		Commands: []string{"systemctl", "service", "update-rc.d", "chkconfig"},
+		Actions:  []string{"stop", "disable", "mask", "kill", "off", "remove"},
+		Targets:  securityAgents,
+	},
+	{
+		Name:     "audit_config",
+		Commands: []string{"auditctl"},
+		Targets:  []string{"-D"},
+		Paths:    []string{"/etc/audit", "/etc/audisp", "/etc/rsyslog.conf", "/etc/rsyslog.d", "/etc/syslog.conf"},
+	},
+	{
+		Name:     "audit_config",
+		Commands: []string{"auditctl"},
+		Actions:  []string{"-e"},
+		Targets:  []string{"0"},
+	},
+	{
+		Name:     "security_controls",
+		Commands: []string{"setenforce"},
+		Targets:  []string{"0", "[Pp]ermissive"},
+	},
+	{
+		Name:     "security_controls",
+		Commands: []string{"ufw"},
+		Targets:  []string{"disable"},
+	},
+	{
+		Name:     "security_controls",
+		Commands: []string{"aa-teardown"},
+	},
+}
+
+// DefaultTamperingRules returns the tampering rules looked for by Run unless others
+// are given with WithTamperingRules.
+func DefaultTamperingRules() []TamperingRule {
+	rules := make([]TamperingRule, len(defaultTamperingRules))
+	for i, r := range defaultTamperingRules {
+		rules[i] = TamperingRule{
+			Name:     r.Name,
+			Commands: slices.Clone(r.Commands),
+			Actions:  slices.Clone(r.Actions),
+			Targets:  slices.Clone(r.Targets),
+			Paths:    slices.Clone(r.Paths),
+			Tools:    slices.Clone(r.Tools),
+		}
+	}
+	return rules
+}
+
+/*
+LoadTamperingRules reads tampering rules for WithTamperingRules from a JSON file
+holding an array of rules, e.g.
+
+	[{"name": "security_processes", "commands": ["pkill"], "targets": ["my-agent"]}]
+
+The rules replace the default ones (see DefaultTamperingRules). An error wrapping
+ErrInvalidTamperingRule is returned if a rule is not valid, to catch mistakes in the
+configuration.
+*/
+func LoadTamperingRules(file string) ([]TamperingRule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules []TamperingRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse tampering rules: %w", err)
+	}
+	for _, r := range rules {
+		if r.Name == "" || (len(r.Commands) == 0 && len(r.Paths) == 0) {
+			return nil, fmt.Errorf("%w: %q must have a name and commands or paths", ErrInvalidTamperingRule, r.Name)
+		}
+		if len(r.Commands) == 0 && (len(r.Actions) > 0 || len(r.Targets) > 0) {
+			return nil, fmt.Errorf("%w: %q has actions or targets but no commands", ErrInvalidTamperingRule, r.Name)
+		}
+		for _, p := range append(slices.Clone(r.Actions), r.Targets...) {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("%w: %q has invalid pattern %q", ErrInvalidTamperingRule, r.Name, p)
+			}
+		}
+		for _, p := range r.Paths {
+			rel, home := strings.CutPrefix(p, "~/")
+			if _, err := path.Match(rel, ""); err != nil || (!home && !path.IsAbs(p)) {
+				return nil, fmt.Errorf("%w: %q has invalid path %q", ErrInvalidTamperingRule, r.Name, p)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// shellNames are the shells whose -c scripts are searched for tampering commands.
+var shellNames = map[string]bool{"sh": true, "bash": true, "dash": true, "ash": true, "zsh": true, "ksh": true}
+
+// commandLines returns the command lines run by cmd: cmd itself, and the commands
+// of its script if it is a shell run with -c. The script is split into commands at
+// separators (;, &, | and newlines), without regard to quoting, and the leading
+// variable assignments and sudo of each command are dropped.
+func commandLines(cmd []string) [][]string {
+	lines := [][]string{cmd}
+	if len(cmd) == 0 || !shellNames[path.Base(cmd[0])] {
+		return lines
+	}
+	i := slices.Index(cmd, "-c")
+	if i < 0 || i+1 >= len(cmd) {
+		return lines
+	}
+	for _, segment := range strings.FieldsFunc(cmd[i+1], func(r rune) bool { return strings.ContainsRune(";&|\n", r) }) {
+		words := strings.Fields(segment)
+		for j := range words {
+			words[j] = strings.Trim(words[j], `"'`)
+		}
+		for len(words) > 0 && (words[0] == "sudo" || strings.Contains(words[0], "=")) {
+			words = words[1:]
+		}
+		if len(words) > 0 {
+			lines = append(lines, words)
+		}
+	}
+	return lines
+}
+
+// matchesArg returns whether one of args matches one of patterns (see TamperingRule).
+func matchesArg(args, patterns []string) bool {
+	for _, arg := range args {
+		name := strings.TrimSuffix(path.Base(arg), ".service")
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, arg); matched {
+				return true
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesCommand returns whether the command line matches the commands of r.
+func matchesCommand(line []string, r TamperingRule) bool {
+	if len(line) == 0 || !slices.Contains(r.Commands, path.Base(line[0])) {
+		return false
+	}
+	args := line[1:]
+	return (len(r.Actions) == 0 || matchesArg(args, r.Actions)) && (len(r.Targets) == 0 || matchesArg(args, r.Targets))
+}
+
+// matchesPath returns whether p, or a directory containing it, matches the paths
+// of r.
+func matchesPath(p string, r TamperingRule) bool {
+	rel, inHome := homeRelativePath(p)
+	for _, pattern := range r.Paths {
+		target, ok := p, true
+		if homePattern, found := strings.CutPrefix(pattern, "~/"); found {
+			pattern, target, ok = homePattern, rel, inHome
+		}
+		if !ok {
+			continue
+		}
+		for prefix := target; prefix != "." && prefix != "/"; prefix = path.Dir(prefix) {
+			if matched, _ := path.Match(pattern, prefix); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/*
+SecurityTampering finds the actions that disable or evade security tooling, as
+described by rules (see TamperingRule): commands that were run, such as killing a
+monitoring agent or clearing the shell history, and files that were written or
+deleted, such as logs or the audit configuration. Files in the package directory
+(see analysisrun.IsPackageFile) are ignored.
+
+A result is returned for each command that matches a rule, and for each writer of a
+file that matches a rule (or one without a command if the writers are not known),
+in the order of commands and then files. Results are not repeated for rules with the
+same name.
+*/
+func SecurityTampering(commands []strace.CommandInfo, files []strace.FileInfo, rules []TamperingRule) []analysisrun.SecurityTamperingResult {
+	var results []analysisrun.SecurityTamperingResult
+	seen := make(map[string]bool)
+	add := func(rule string, command []string, p string) {
+		key := rule + "\x01" + p + "\x01" + strings.Join(command, "\x00")
+		if !seen[key] {
+			seen[key] = true
+			results = append(results, analysisrun.SecurityTamperingResult{Rule: rule, Command: command, Path: p})
+		}
+	}
+
+	for _, c := range commands {
+		for _, r := range rules {
+			if slices.ContainsFunc(commandLines(c.Command), func(line []string) bool { return matchesCommand(line, r) }) {
+				add(r.Name, c.Command, "")
+			}
+		}
+	}
+
+	for _, f := range files {
+		if !(f.Write || f.Delete) || analysisrun.IsPackageFile(f.Path) {
+			continue
+		}
+		for _, r := range rules {
+			if !matchesPath(f.Path, r) {
+				continue
+			}
+			if len(f.Writers) == 0 {
+				add(r.Name, nil, f.Path)
+			}
+			for _, w := range f.Writers {
+				if len(w.Command) == 0 || !slices.Contains(r.Tools, path.Base(w.Command[0])) {
+					add(r.Name, w.Command, f.Path)
+				}
+			}
+		}
+	}
+	return results
+}
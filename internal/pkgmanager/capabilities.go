@@ -0,0 +1,72 @@
+package pkgmanager
+
+import (
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/featureflags"
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+// Capabilities describes the analysis supported for packages of an ecosystem.
+type Capabilities struct {
+	Ecosystem pkgecosystem.Ecosystem
+	// Phases are the dynamic analysis phases run for packages, in order.
+	Phases []analysisrun.DynamicPhase
+	// Runtime is the program used to install or run packages in the sandbox
+	// (e.g. "node" for npm), which is provided by SandboxImage.
+	Runtime      string
+	SandboxImage string
+	// StaticLanguage is the language of the source files of packages parsed by
+	// static analysis. It is parsing.NoLanguage if static analysis of the
+	// ecosystem is limited to the checks which do not need a parser.
+	StaticLanguage parsing.Language
+	// LifecycleScripts, EntryPoints and Manifest are whether the lifecycle
+	// scripts, entry points and manifest of packages can be read (see the
+	// PkgManager methods of the same names).
+	LifecycleScripts bool
+	EntryPoints      bool
+	Manifest         bool
+}
+
+// DynamicPhases returns the dynamic analysis phases run for packages of the
+// ecosystem, in order. The execute phase is only included if it is supported by
+// the ecosystem and the CodeExecution feature is enabled.
+func (p *PkgManager) DynamicPhases() []analysisrun.DynamicPhase {
+	phases := analysisrun.DefaultDynamicPhases()
+	if p.executePhase && featureflags.CodeExecution.Enabled() {
+		phases = append(phases, analysisrun.DynamicPhaseExecute)
+	}
+	return phases
+}
+
+// Capabilities returns the analysis supported for packages of the ecosystem.
+func (p *PkgManager) Capabilities() Capabilities {
+	return Capabilities{
+		Ecosystem:        p.ecosystem,
+		Phases:           p.DynamicPhases(),
+		Runtime:          p.runtime,
+		SandboxImage:     p.SandboxImage(),
+		StaticLanguage:   p.staticLanguage,
+		LifecycleScripts: p.lifecycleScripts != nil,
+		EntryPoints:      p.entryPoints != nil,
+		Manifest:         p.manifest != nil,
+	}
+}
+
+// Ecosystems returns the Capabilities of each supported ecosystem, sorted by
+// ecosystem name, so that callers need not hardcode the list of ecosystems.
+func Ecosystems() []Capabilities {
+	ecosystems := make([]pkgecosystem.Ecosystem, 0, len(supportedPkgManagers))
+	for e := range supportedPkgManagers {
+		ecosystems = append(ecosystems, e)
+	}
+	slices.Sort(ecosystems)
+
+	capabilities := make([]Capabilities, 0, len(ecosystems))
+	for _, e := range ecosystems {
+		capabilities = append(capabilities, supportedPkgManagers[e].Capabilities())
+	}
+	return capabilities
+}
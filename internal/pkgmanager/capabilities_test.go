@@ -0,0 +1,46 @@
+package pkgmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestEcosystems(t *testing.T) {
+	got := Ecosystems()
+
+	var ecosystems []pkgecosystem.Ecosystem
+	for _, c := range got {
+		ecosystems = append(ecosystems, c.Ecosystem)
+		if c.Runtime == "" || c.SandboxImage == "" {
+			t.Errorf("Ecosystems() has %+v, want a runtime and sandbox image", c)
+		}
+		if len(c.Phases) == 0 || c.Phases[0] != analysisrun.DynamicPhaseInstall {
+			t.Errorf("Ecosystems() has phases %v for %s, want install first", c.Phases, c.Ecosystem)
+		}
+	}
+	if !reflect.DeepEqual(ecosystems, pkgecosystem.SupportedEcosystems) {
+		t.Errorf("Ecosystems() = %v, want %v", ecosystems, pkgecosystem.SupportedEcosystems)
+	}
+
+	npm := Manager(pkgecosystem.NPM).Capabilities()
+	want := Capabilities{
+		Ecosystem:        pkgecosystem.NPM,
+		Phases:           analysisrun.DefaultDynamicPhases(),
+		Runtime:          "node",
+		SandboxImage:     DefaultSandboxImage,
+		StaticLanguage:   parsing.JavaScript,
+		LifecycleScripts: true,
+		EntryPoints:      true,
+		Manifest:         true,
+	}
+	if !reflect.DeepEqual(npm, want) {
+		t.Errorf("Capabilities() = %+v, want %+v", npm, want)
+	}
+	if pypi := Manager(pkgecosystem.PyPI).Capabilities(); pypi.StaticLanguage != parsing.NoLanguage {
+		t.Errorf("Capabilities() for pypi has static language %q, want none", pypi.StaticLanguage)
+	}
+}
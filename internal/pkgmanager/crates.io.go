@@ -2,8 +2,6 @@ package pkgmanager
 
 import (
 	"encoding/json"
-	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
@@ -15,8 +13,8 @@ type cratesJSON struct {
 	} `json:"versions"`
 }
 
-func getCratesLatest(pkg string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://crates.io/api/v1/crates/%s/versions", pkg))
+func getCratesLatest(r Registry, pkg string) (string, error) {
+	resp, err := r.get(r.endpoint("/api/v1/crates/%s/versions", pkg))
 	if err != nil {
 		return "", err
 	}
@@ -32,9 +30,9 @@ func getCratesLatest(pkg string) (string, error) {
 	return details.Versions[0].Num, nil
 }
 
-func getCratesArchiveURL(pkgName, version string) (string, error) {
-	pkgURL := fmt.Sprintf("https://crates.io/api/v1/crates/%s/%s/download", pkgName, version)
-	resp, err := http.Get(pkgURL)
+func getCratesArchiveURL(r Registry, pkgName, version string) (string, error) {
+	pkgURL := r.endpoint("/api/v1/crates/%s/%s/download", pkgName, version)
+	resp, err := r.get(pkgURL)
 	if err != nil {
 		return "", err
 	}
@@ -48,8 +46,10 @@ func getCratesArchiveFilename(pkgName, version, _ string) string {
 }
 
 var cratesPkgManager = PkgManager{
-	ecosystem:       pkgecosystem.CratesIO,
-	latestVersion:   getCratesLatest,
-	archiveURL:      getCratesArchiveURL,
-	archiveFilename: getCratesArchiveFilename,
+	ecosystem:          pkgecosystem.CratesIO,
+	defaultRegistryURL: "https://crates.io",
+	latestVersion:      getCratesLatest,
+	archiveURL:         getCratesArchiveURL,
+	archiveFilename:    getCratesArchiveFilename,
+	runtime:            "cargo",
 }
@@ -10,13 +10,14 @@ import (
 /*
 downloadToPath creates (and/or truncates) a file at the given path, then writes
 contents of whatever is at the given URL to that given file using downloadToFile,
-and finally closes the file.
+and finally closes the file. Registry credentials are sent if the URL is on the
+registry host.
 
 If any error occurs, the created file is removed.
 
 Callers must ensure that path and url are nonempty, otherwise the function will panic.
 */
-func downloadToPath(path, url string) error {
+func downloadToPath(registry Registry, path, url string) error {
 	if path == "" {
 		panic("path is empty")
 	}
@@ -29,7 +30,7 @@ func downloadToPath(path, url string) error {
 		return err
 	}
 
-	if downloadErr := downloadToFile(file, url); downloadErr != nil {
+	if downloadErr := downloadToFile(registry, file, url); downloadErr != nil {
 		// cleanup file
 		if removeErr := os.Remove(path); removeErr != nil {
 			return fmt.Errorf("%w\n%v", downloadErr, removeErr)
@@ -55,12 +56,12 @@ making the network request, then no file operations will be performed.
 
 Callers must ensure that url is nonempty, otherwise the function will panic.
 */
-func downloadToFile(dest *os.File, url string) error {
+func downloadToFile(registry Registry, dest *os.File, url string) error {
 	if url == "" {
 		panic("url is empty")
 	}
 
-	resp, err := http.Get(url)
+	resp, err := registry.get(url)
 	if err != nil {
 		return err
 	}
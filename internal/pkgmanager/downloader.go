@@ -0,0 +1,117 @@
+package pkgmanager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrArchiveNotFound is returned by DirectoryDownloader if there is no archive
+// for the requested package version.
+var ErrArchiveNotFound = errors.New("package archive not found")
+
+/*
+Downloader fetches the archive of a package version, e.g. from the registry of the
+ecosystem (see RegistryDownloader), a mirror or proxy, a store of signed artifacts,
+or a local directory (see DirectoryDownloader). The Downloader used by a PkgManager
+can be replaced with WithDownloader or ConfigureDownloader.
+
+Download saves the archive of the given package of manager's ecosystem in directory,
+and returns the path of the archive. The file extension of the archive must be one
+that manager can extract (see PkgManager.ExtractArchive). Implementations must be
+safe for concurrent use.
+*/
+type Downloader interface {
+	Download(manager *PkgManager, name, version, directory string) (string, error)
+}
+
+// RegistryDownloader is the default Downloader, which downloads archives from the
+// registry configured for the PkgManager (see WithRegistry), at the URL given by
+// the registry's metadata for the package. Archives are named according to
+// ecosystem-specific rules.
+type RegistryDownloader struct{}
+
+func (RegistryDownloader) Download(manager *PkgManager, name, version, directory string) (string, error) {
+	registry := manager.resolvedRegistry()
+	downloadURL, err := manager.archiveURL(registry, name, version)
+	if err != nil {
+		return "", err
+	}
+	if downloadURL == "" {
+		return "", fmt.Errorf("%w: package %s @ %s", ErrNoArchiveURL, name, version)
+	}
+
+	baseFilename := manager.archiveFilename(name, version, downloadURL)
+	if baseFilename == "" {
+		panic("base filename for archive is empty")
+	}
+
+	destPath := filepath.Join(directory, baseFilename)
+	if err := downloadToPath(registry, destPath, downloadURL); err != nil {
+		return "", err
+	}
+
+	return destPath, nil
+}
+
+/*
+DirectoryDownloader is a Downloader which copies archives from a local directory,
+for analysing packages offline or from artifacts fetched by other means, and for
+tests. The archive of each package version is the only file in the directory
+Dir/<ecosystem>/<name>/<version>, e.g. Dir/npm/@scope/name/1.0.0/name-1.0.0.tgz,
+and is copied with the same filename.
+*/
+type DirectoryDownloader struct {
+	Dir string
+}
+
+func (d DirectoryDownloader) Download(manager *PkgManager, name, version, directory string) (string, error) {
+	if !filepath.IsLocal(name) || !filepath.IsLocal(version) {
+		return "", fmt.Errorf("%w: invalid package %s @ %s", ErrArchiveNotFound, name, version)
+	}
+	versionDir := filepath.Join(d.Dir, string(manager.Ecosystem()), filepath.FromSlash(name), version)
+
+	entries, err := os.ReadDir(versionDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: package %s @ %s", ErrArchiveNotFound, name, version)
+	} else if err != nil {
+		return "", err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			files = append(files, e.Name())
+		}
+	}
+	if len(files) != 1 {
+		return "", fmt.Errorf("%w: expected one archive in %s, found %d files", ErrArchiveNotFound, versionDir, len(files))
+	}
+
+	destPath := filepath.Join(directory, files[0])
+	if err := copyFile(filepath.Join(versionDir, files[0]), destPath); err != nil {
+		return "", err
+	}
+	return destPath, nil
+}
+
+// copyFile copies the file at src to dest. If an error occurs, dest is removed.
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(out, in)
+	if err := errors.Join(copyErr, out.Close()); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pkgmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+// fakeDownloader records the package it was asked for and returns a fixed path.
+type fakeDownloader struct {
+	gotName, gotVersion, gotDir string
+}
+
+func (d *fakeDownloader) Download(_ *PkgManager, name, version, directory string) (string, error) {
+	d.gotName, d.gotVersion, d.gotDir = name, version, directory
+	return filepath.Join(directory, "archive.tgz"), nil
+}
+
+func TestWithDownloader(t *testing.T) {
+	d := &fakeDownloader{}
+	manager := Manager(pkgecosystem.NPM).WithDownloader(d)
+
+	got, err := manager.DownloadArchive("package", "1.0.0", "")
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	if want := "archive.tgz"; got != want {
+		t.Errorf("DownloadArchive() = %q, want %q", got, want)
+	}
+	if d.gotName != "package" || d.gotVersion != "1.0.0" || d.gotDir != "." {
+		t.Errorf("Download() called with (%q, %q, %q), want (\"package\", \"1.0.0\", \".\")", d.gotName, d.gotVersion, d.gotDir)
+	}
+
+	if _, ok := Manager(pkgecosystem.NPM).Downloader().(RegistryDownloader); !ok {
+		t.Errorf("default Downloader() = %T, want RegistryDownloader", Manager(pkgecosystem.NPM).Downloader())
+	}
+}
+
+func TestDirectoryDownloader(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, contents string) {
+		t.Helper()
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("npm/@scope/name/1.0.0/name-1.0.0.tgz", "archive")
+	write("npm/two/1.0.0/a.tgz", "a")
+	write("npm/two/1.0.0/b.tgz", "b")
+
+	manager := Manager(pkgecosystem.NPM).WithDownloader(DirectoryDownloader{Dir: dir})
+	dest := t.TempDir()
+
+	got, err := manager.DownloadArchive("@scope/name", "1.0.0", dest)
+	if err != nil {
+		t.Fatalf("DownloadArchive() error = %v", err)
+	}
+	if want := filepath.Join(dest, "name-1.0.0.tgz"); got != want {
+		t.Errorf("DownloadArchive() = %q, want %q", got, want)
+	}
+	if contents, err := os.ReadFile(got); err != nil || string(contents) != "archive" {
+		t.Errorf("downloaded archive = %q, %v, want \"archive\"", contents, err)
+	}
+
+	for _, test := range []struct{ name, version string }{
+		{"@scope/name", "2.0.0"},
+		{"missing", "1.0.0"},
+		{"two", "1.0.0"},
+		{"../npm/two", "1.0.0"},
+		{"two", "../../npm/@scope/name/1.0.0"},
+	} {
+		if _, err := manager.DownloadArchive(test.name, test.version, dest); !errors.Is(err, ErrArchiveNotFound) {
+			t.Errorf("DownloadArchive(%q, %q) error = %v, want %v", test.name, test.version, err, ErrArchiveNotFound)
+		}
+	}
+}
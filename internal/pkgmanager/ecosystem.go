@@ -4,21 +4,52 @@ import (
 	"errors"
 	"fmt"
 	"path"
-	"path/filepath"
 	"strings"
 
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/internal/utils"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
 var ErrNoArchiveURL = errors.New("archive URL not found")
 
+// DefaultSandboxImage is the container image of the dynamic analysis sandbox used
+// for ecosystems that do not require a different one (see SandboxImage). It provides
+// the runtimes of all ecosystems supported by default.
+const DefaultSandboxImage = "gcr.io/ossf-malware-analysis/dynamic-analysis"
+
 // PkgManager represents how packages from a common ecosystem are accessed.
 type PkgManager struct {
-	ecosystem       pkgecosystem.Ecosystem
-	latestVersion   func(name string) (string, error)
-	archiveURL      func(name, version string) (string, error)
+	ecosystem pkgecosystem.Ecosystem
+	// defaultRegistryURL is the URL of the public registry of the ecosystem,
+	// used if the registry URL has not been configured (see WithRegistry).
+	defaultRegistryURL string
+	registry           Registry
+	// downloader is optional; see Downloader
+	downloader    Downloader
+	latestVersion func(r Registry, name string) (string, error)
+	// resolveVersion is optional; see ResolveDependency
+	resolveVersion func(r Registry, name, versionRange string) (string, error)
+	// listVersions is optional; see Versions
+	listVersions    func(r Registry, name string) (*Versions, error)
+	archiveURL      func(r Registry, name, version string) (string, error)
 	archiveFilename func(name, version, downloadURL string) string
-	extractArchive  func(path, outputDir string) error
+	extractArchive  func(path, outputDir string, limits utils.ExtractLimits) error
+	// lifecycleScripts is optional; see LifecycleScripts
+	lifecycleScripts func(archivePath string) ([]LifecycleScript, error)
+	// entryPoints is optional; see EntryPoints
+	entryPoints func(extractDir string) ([]string, error)
+	// manifest is optional; see Manifest
+	manifest func(extractDir string) (*Manifest, error)
+	// sandboxImage is optional; see SandboxImage
+	sandboxImage string
+	// runtime is the program that installs or runs packages of the ecosystem
+	// in the sandbox; see Capabilities
+	runtime string
+	// executePhase is whether the execute phase is supported; see DynamicPhases
+	executePhase bool
+	// staticLanguage is optional; see Capabilities
+	staticLanguage parsing.Language
 }
 
 var (
@@ -54,9 +85,61 @@ func (p *PkgManager) Ecosystem() pkgecosystem.Ecosystem {
 	return p.ecosystem
 }
 
+// SandboxImage returns the container image of the dynamic analysis sandbox which
+// provides the runtime needed to install and run packages of the ecosystem (e.g.
+// node for npm or python for PyPI). If the ecosystem does not declare an image,
+// DefaultSandboxImage is returned.
+func (p *PkgManager) SandboxImage() string {
+	if p.sandboxImage != "" {
+		return p.sandboxImage
+	}
+	return DefaultSandboxImage
+}
+
+// WithRegistry returns a copy of the PkgManager which fetches packages from the
+// given registry, rather than the public registry of the ecosystem.
+func (p *PkgManager) WithRegistry(r Registry) *PkgManager {
+	manager := *p
+	manager.registry = r
+	return &manager
+}
+
+// Registry returns the registry configured for the PkgManager. The zero value
+// is returned if the public registry is used without credentials.
+func (p *PkgManager) Registry() Registry {
+	return p.registry
+}
+
+// WithDownloader returns a copy of the PkgManager which fetches package archives
+// with d, rather than from the registry.
+func (p *PkgManager) WithDownloader(d Downloader) *PkgManager {
+	manager := *p
+	manager.downloader = d
+	return &manager
+}
+
+// Downloader returns the Downloader used by DownloadArchive. If none has been
+// configured, this is RegistryDownloader.
+func (p *PkgManager) Downloader() Downloader {
+	if p.downloader != nil {
+		return p.downloader
+	}
+	return RegistryDownloader{}
+}
+
+// resolvedRegistry returns the configured registry, with the URL of the public
+// registry filled in if it was not configured.
+func (p *PkgManager) resolvedRegistry() Registry {
+	r := p.registry
+	if r.URL == "" {
+		r.URL = p.defaultRegistryURL
+	}
+	return r
+}
+
 func (p *PkgManager) Latest(name string) (*Pkg, error) {
 	name = normalizePkgName(name)
-	version, err := p.latestVersion(name)
+	version, err := p.latestVersion(p.resolvedRegistry(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +159,23 @@ func (p *PkgManager) Local(name, version, localPath string) *Pkg {
 	}
 }
 
+/*
+Extracted returns a Pkg for a package that has already been fetched and extracted
+into dir by the caller, e.g. from a cache or through a proxy that the built-in
+downloader does not support. The package is analysed from dir without being
+downloaded, using the same phases and commands as for other packages in the
+ecosystem. dir should have the same layout as the output of ExtractArchive.
+*/
+func (p *PkgManager) Extracted(name, version, dir string) *Pkg {
+	return &Pkg{
+		name:      normalizePkgName(name),
+		version:   version,
+		local:     dir,
+		extracted: true,
+		manager:   p,
+	}
+}
+
 func (p *PkgManager) Package(name, version string) *Pkg {
 	return &Pkg{
 		name:    normalizePkgName(name),
@@ -86,8 +186,9 @@ func (p *PkgManager) Package(name, version string) *Pkg {
 
 /*
 DownloadArchive downloads an archive of the given package name and version
-to the specified directory, and returns the path to the downloaded archive.
-The archive is named according to ecosystem-specific rules.
+to the specified directory with the Downloader of the PkgManager, and returns
+the path to the downloaded archive. By default, the archive is downloaded from
+the registry and named according to ecosystem-specific rules.
 
 directory specifies the destination directory for the archive.
 If an empty string is passed, the current directory is used.
@@ -99,31 +200,15 @@ func (p *PkgManager) DownloadArchive(name, version, directory string) (string, e
 	if directory == "" {
 		directory = "."
 	}
-
-	downloadURL, err := p.archiveURL(name, version)
-	if err != nil {
-		return "", err
-	}
-	if downloadURL == "" {
-		return "", fmt.Errorf("%w: package %s @ %s", ErrNoArchiveURL, name, version)
-	}
-
-	baseFilename := p.archiveFilename(name, version, downloadURL)
-	if baseFilename == "" {
-		panic("base filename for archive is empty")
-	}
-
-	destPath := filepath.Join(directory, baseFilename)
-	if err := downloadToPath(destPath, downloadURL); err != nil {
-		return "", err
-	}
-
-	return destPath, nil
+	return p.Downloader().Download(p, name, version, directory)
 }
 
-func (p *PkgManager) ExtractArchive(archivePath, outputDir string) error {
+// ExtractArchive extracts the package archive at archivePath into outputDir. If the
+// archive exceeds the given limits, extraction is aborted with an error wrapping
+// utils.ErrDecompressionBomb.
+func (p *PkgManager) ExtractArchive(archivePath, outputDir string, limits utils.ExtractLimits) error {
 	if p.extractArchive != nil {
-		return p.extractArchive(archivePath, outputDir)
+		return p.extractArchive(archivePath, outputDir, limits)
 	}
 	return fmt.Errorf("archive extraction not implemented for %s", p.Ecosystem())
 }
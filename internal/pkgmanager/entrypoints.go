@@ -0,0 +1,161 @@
+package pkgmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var ErrEntryPointsNotSupported = errors.New("entry points not supported")
+
+// SupportsEntryPoints returns whether EntryPoints is implemented for the ecosystem.
+func (p *PkgManager) SupportsEntryPoints() bool {
+	return p.entryPoints != nil
+}
+
+// EntryPoints returns the files declared as entry points of the package extracted
+// (by ExtractArchive) into extractDir, i.e. the files loaded when the package is
+// imported. Paths are relative to extractDir, and only files that exist are returned.
+// If the ecosystem does not support entry points, ErrEntryPointsNotSupported is returned.
+func (p *PkgManager) EntryPoints(extractDir string) ([]string, error) {
+	if p.entryPoints == nil {
+		return nil, fmt.Errorf("%w for %s", ErrEntryPointsNotSupported, p.Ecosystem())
+	}
+	return p.entryPoints(extractDir)
+}
+
+// npmResolveExtensions are tried in order when resolving a module path which
+// does not name a file, following Node's CommonJS resolution rules.
+var npmResolveExtensions = []string{"", ".js", ".json", ".node"}
+
+/*
+getNPMEntryPoints returns the entry points declared by the package.json of the NPM
+package extracted into extractDir, from its "main" and "exports" fields. If neither
+is present, the default entry point is index.js.
+
+The package root is either extractDir itself, or the single top level directory
+inside it (usually named 'package') for extracted package archives.
+*/
+func getNPMEntryPoints(extractDir string) ([]string, error) {
+	root, err := findNPMPackageRoot(extractDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifest npmManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding package.json: %w", err)
+	}
+
+	var targets []string
+	if manifest.Main != "" {
+		targets = append(targets, manifest.Main)
+	}
+	targets = append(targets, npmExportTargets(manifest.Exports)...)
+	if len(targets) == 0 {
+		targets = []string{"index.js"}
+	}
+
+	var entryPoints []string
+	for _, target := range targets {
+		resolved := resolveNPMEntryPoint(root, target)
+		if resolved == "" {
+			continue
+		}
+		rel, err := filepath.Rel(extractDir, resolved)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(entryPoints, rel) {
+			entryPoints = append(entryPoints, rel)
+		}
+	}
+	return entryPoints, nil
+}
+
+// findNPMPackageRoot returns the directory containing package.json in extractDir.
+func findNPMPackageRoot(extractDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(extractDir, "package.json")); err == nil {
+		return extractDir, nil
+	}
+
+	entries, err := os.ReadDir(extractDir)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(extractDir, e.Name())
+		if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("package.json not found in %s", extractDir)
+}
+
+// npmExportTargets returns the file paths in the "exports" field of a package.json,
+// in the order that they appear. Subpath patterns (containing '*') are ignored.
+func npmExportTargets(exports any) []string {
+	switch e := exports.(type) {
+	case string:
+		if strings.HasPrefix(e, "./") && !strings.Contains(e, "*") {
+			return []string{e}
+		}
+	case []any:
+		var targets []string
+		for _, alt := range e {
+			targets = append(targets, npmExportTargets(alt)...)
+		}
+		return targets
+	case map[string]any:
+		// encoding/json does not preserve object key order, so sort the keys
+		// to produce a deterministic result.
+		keys := make([]string, 0, len(e))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		var targets []string
+		for _, k := range keys {
+			if strings.Contains(k, "*") {
+				continue
+			}
+			targets = append(targets, npmExportTargets(e[k])...)
+		}
+		return targets
+	}
+	return nil
+}
+
+// resolveNPMEntryPoint returns the path of the file that target (relative
+// to the package root) refers to, or the empty string if there is none.
+// Targets which resolve outside the package root are ignored.
+func resolveNPMEntryPoint(root, target string) string {
+	p := filepath.Join(root, filepath.FromSlash(target))
+	if p != root && !strings.HasPrefix(p, root+string(filepath.Separator)) {
+		return ""
+	}
+
+	for _, ext := range npmResolveExtensions {
+		if info, err := os.Stat(p + ext); err == nil && info.Mode().IsRegular() {
+			return p + ext
+		}
+	}
+	for _, ext := range npmResolveExtensions[1:] {
+		index := filepath.Join(p, "index"+ext)
+		if info, err := os.Stat(index); err == nil && info.Mode().IsRegular() {
+			return index
+		}
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package pkgmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNPMEntryPoints(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "main",
+			files: map[string]string{
+				"package/package.json": `{"main": "lib/main.js"}`,
+				"package/lib/main.js":  "",
+				"package/index.js":     "",
+			},
+			want: []string{"package/lib/main.js"},
+		},
+		{
+			name: "default index",
+			files: map[string]string{
+				"package/package.json": `{"name": "x"}`,
+				"package/index.js":     "",
+			},
+			want: []string{"package/index.js"},
+		},
+		{
+			name: "main without extension and directory main",
+			files: map[string]string{
+				"package/package.json": `{"main": "./lib", "exports": "./dist/x"}`,
+				"package/lib/index.js": "",
+				"package/dist/x.js":    "",
+			},
+			want: []string{"package/lib/index.js", "package/dist/x.js"},
+		},
+		{
+			name: "conditional and subpath exports",
+			files: map[string]string{
+				"package/package.json": `{"exports": {
+					".": {"import": "./esm/index.mjs", "require": "./cjs/index.js"},
+					"./feature": "./feature.js",
+					"./utils/*": "./utils/*.js",
+					"./missing": "./missing.js"
+				}}`,
+				"package/esm/index.mjs": "",
+				"package/cjs/index.js":  "",
+				"package/feature.js":    "",
+			},
+			want: []string{"package/esm/index.mjs", "package/cjs/index.js", "package/feature.js"},
+		},
+		{
+			name: "package root is extract dir",
+			files: map[string]string{
+				"package.json": `{"main": "main.js"}`,
+				"main.js":      "",
+			},
+			want: []string{"main.js"},
+		},
+		{
+			name: "target outside package",
+			files: map[string]string{
+				"package/package.json": `{"main": "../outside.js"}`,
+				"outside.js":           "",
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tt.files)
+
+			got, err := Manager(pkgecosystem.NPM).EntryPoints(dir)
+			if err != nil {
+				t.Fatalf("EntryPoints() error = %v", err)
+			}
+			want := make([]string, 0, len(tt.want))
+			for _, p := range tt.want {
+				want = append(want, filepath.FromSlash(p))
+			}
+			if len(got) != 0 || len(want) != 0 {
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("EntryPoints() = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryPointsNotSupported(t *testing.T) {
+	_, err := Manager(pkgecosystem.PyPI).EntryPoints(t.TempDir())
+	if !errors.Is(err, ErrEntryPointsNotSupported) {
+		t.Errorf("EntryPoints() error = %v, want %v", err, ErrEntryPointsNotSupported)
+	}
+}
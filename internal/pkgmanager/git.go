@@ -0,0 +1,183 @@
+package pkgmanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+var (
+	ErrInvalidGitSource = errors.New("invalid git source")
+	ErrGitRefNotFound   = errors.New("git ref not found")
+)
+
+// gitHostShortcuts maps the host shortcuts accepted by npm for git dependencies
+// (e.g. "github:user/repo") to the URL prefix of their repositories.
+var gitHostShortcuts = map[string]string{
+	"github":    "https://github.com/",
+	"gitlab":    "https://gitlab.com/",
+	"bitbucket": "https://bitbucket.org/",
+}
+
+// githubShorthandPattern matches the "user/repo" shorthand for GitHub repositories.
+var githubShorthandPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+$`)
+
+/*
+ParseGitSource parses the location of a package in a git repository, given as
+REPOSITORY[#REF] in any of the forms npm accepts for git dependencies:
+
+  - a URL, e.g. "https://github.com/user/repo.git", "git://host/repo" or
+    "git+ssh://git@host/repo.git" (a "git+" prefix is removed)
+  - a host shortcut, e.g. "github:user/repo", "gitlab:user/repo" or
+    "bitbucket:user/repo"
+  - the GitHub shorthand "user/repo"
+
+REF is a branch, tag or commit, and defaults to the default branch of the
+repository. An error wrapping ErrInvalidGitSource is returned if spec is not
+of any of these forms, or if REF starts with "-", so that it could be taken as
+an option of git.
+*/
+func ParseGitSource(spec string) (*analysisrun.GitSource, error) {
+	repo, ref, _ := strings.Cut(strings.TrimSpace(spec), "#")
+	repo = strings.TrimPrefix(repo, "git+")
+
+	if host, p, ok := strings.Cut(repo, ":"); ok && gitHostShortcuts[host] != "" && !strings.HasPrefix(p, "//") {
+		repo = gitHostShortcuts[host] + p
+	} else if githubShorthandPattern.MatchString(repo) {
+		repo = gitHostShortcuts["github"] + repo
+	}
+
+	scheme, rest, ok := strings.Cut(repo, "://")
+	switch {
+	case !ok || rest == "":
+		return nil, fmt.Errorf("%w: %q", ErrInvalidGitSource, spec)
+	case scheme != "https" && scheme != "http" && scheme != "git" && scheme != "ssh" && scheme != "file":
+		return nil, fmt.Errorf("%w: unsupported scheme %q in %q", ErrInvalidGitSource, scheme, spec)
+	}
+	src := &analysisrun.GitSource{Repository: repo, Ref: ref}
+	if err := checkGitSource(src); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// checkGitSource returns an error wrapping ErrInvalidGitSource if the repository
+// or ref of src starts with "-", and so could be taken as an option of git.
+func checkGitSource(src *analysisrun.GitSource) error {
+	if strings.HasPrefix(src.Repository, "-") {
+		return fmt.Errorf("%w: repository %q", ErrInvalidGitSource, src.Repository)
+	}
+	if strings.HasPrefix(src.Ref, "-") {
+		return fmt.Errorf("%w: ref %q", ErrInvalidGitSource, src.Ref)
+	}
+	return nil
+}
+
+/*
+FetchGit fetches the repository of src at src.Ref into dir (which must not exist,
+or be empty), and returns a Pkg for analysing it as an extracted package (see
+Extracted). This covers packages that are installed directly from git (e.g.
+"npm install user/repo#ref"), whose code may not match any version published to
+the registry. The build and install steps of the package are then run by the
+install phase of dynamic analysis, as they would be when installing from git.
+
+The version of the returned package is the commit that src.Ref resolved to, which
+is also recorded in its GitSource, so that the results can be reproduced. The
+history of the repository is removed from dir once the commit is checked out. If
+name is empty, the name declared in the manifest of the package is used, or
+failing that, the name of the repository. If src.Ref is not found, an error
+wrapping ErrGitRefNotFound is returned, and if src is not valid (see
+ParseGitSource), an error wrapping ErrInvalidGitSource.
+*/
+func (p *PkgManager) FetchGit(ctx context.Context, name string, src *analysisrun.GitSource, dir string) (*Pkg, error) {
+	if err := checkGitSource(src); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if _, err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+		return nil, err
+	}
+
+	ref := src.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// A shallow fetch of the ref works for branches and tags, and for commits on
+	// hosts that allow fetching them directly. Otherwise the whole repository is
+	// fetched, and the ref resolved locally (e.g. for an abbreviated commit hash).
+	// The repository and ref are given after "--" (or "--end-of-options"), so
+	// that they are never taken as options.
+	commit := "FETCH_HEAD"
+	if _, err := runGit(ctx, dir, "fetch", "--quiet", "--depth=1", "--", src.Repository, ref); err != nil {
+		if _, err := runGit(ctx, dir, "fetch", "--quiet", "--tags", "--", src.Repository, "+HEAD:refs/remotes/origin/HEAD", "+refs/heads/*:refs/remotes/origin/*"); err != nil {
+			return nil, err
+		}
+		commit = ref
+		if !isCommitRef(ctx, dir, commit) {
+			commit = "origin/" + ref
+		}
+		if !isCommitRef(ctx, dir, commit) {
+			return nil, fmt.Errorf("%w: %q in %s", ErrGitRefNotFound, src.Ref, src.Repository)
+		}
+	}
+	// "--" before commit would make it a path, so it is after it, which makes
+	// commit a revision (it does not start with "-", see checkGitSource)
+	if _, err := runGit(ctx, dir, "checkout", "--quiet", "--detach", commit, "--"); err != nil {
+		return nil, err
+	}
+	out, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	commit = strings.TrimSpace(out)
+
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		return nil, err
+	}
+
+	if name == "" && p.SupportsManifest() {
+		if m, err := p.Manifest(dir); err == nil {
+			name = m.Name
+		}
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(src.Repository), ".git")
+	}
+
+	pkg := p.Extracted(name, commit, dir)
+	pkg.git = &analysisrun.GitSource{Repository: src.Repository, Ref: src.Ref, Commit: commit}
+	return pkg, nil
+}
+
+// isCommitRef returns whether ref names a commit in the repository in dir.
+func isCommitRef(ctx context.Context, dir, ref string) bool {
+	_, err := runGit(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	return err == nil
+}
+
+// runGit runs git with the given arguments in dir, and returns its output. Git
+// is not allowed to prompt for credentials, so that fetching a private repository
+// fails instead of blocking.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
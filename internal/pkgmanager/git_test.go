@@ -0,0 +1,132 @@
+package pkgmanager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    *analysisrun.GitSource
+		wantErr bool
+	}{
+		{spec: "user/repo#v1.0.0", want: &analysisrun.GitSource{Repository: "https://github.com/user/repo", Ref: "v1.0.0"}},
+		{spec: "github:user/repo#0123abcd", want: &analysisrun.GitSource{Repository: "https://github.com/user/repo", Ref: "0123abcd"}},
+		{spec: "gitlab:group/repo", want: &analysisrun.GitSource{Repository: "https://gitlab.com/group/repo"}},
+		{spec: "git+https://example.com/repo.git#main", want: &analysisrun.GitSource{Repository: "https://example.com/repo.git", Ref: "main"}},
+		{spec: "git+ssh://git@example.com/repo.git", want: &analysisrun.GitSource{Repository: "ssh://git@example.com/repo.git"}},
+		{spec: "git://example.com/repo#dev", want: &analysisrun.GitSource{Repository: "git://example.com/repo", Ref: "dev"}},
+		{spec: "repo", wantErr: true},
+		{spec: "ftp://example.com/repo", wantErr: true},
+		{spec: "#main", wantErr: true},
+		{spec: "user/repo#--upload-pack=touch /tmp/pwned", wantErr: true},
+		{spec: "github:user/repo#-b", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseGitSource(tt.spec)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidGitSource) {
+				t.Errorf("ParseGitSource(%q) error = %v, want %v", tt.spec, err, ErrInvalidGitSource)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseGitSource(%q) = %+v, %v, want %+v", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	ctx := context.Background()
+
+	// A repository with a tagged commit, followed by another commit.
+	repo := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := runGit(ctx, repo, append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+	writeFile := func(name, contents string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "--quiet")
+	writeFile("package.json", `{"name": "git-pkg", "version": "1.0.0"}`)
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "first")
+	git("tag", "v1.0.0")
+	tagged := git("rev-parse", "HEAD")
+	writeFile("index.js", `require("child_process").exec("id")`)
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "second")
+	head := git("rev-parse", "HEAD")
+
+	manager := Manager(pkgecosystem.NPM)
+	tests := []struct {
+		name       string
+		ref        string
+		wantCommit string
+		wantFiles  []string
+	}{
+		{name: "default branch", wantCommit: head, wantFiles: []string{"index.js", "package.json"}},
+		{name: "tag", ref: "v1.0.0", wantCommit: tagged, wantFiles: []string{"package.json"}},
+		{name: "abbreviated commit", ref: tagged[:10], wantCommit: tagged, wantFiles: []string{"package.json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "pkg")
+			src := &analysisrun.GitSource{Repository: "file://" + repo, Ref: tt.ref}
+			pkg, err := manager.FetchGit(ctx, "", src, dir)
+			if err != nil {
+				t.Fatalf("FetchGit() error = %v", err)
+			}
+			if pkg.Name() != "git-pkg" || pkg.Version() != tt.wantCommit || !pkg.IsExtracted() || pkg.LocalPath() != dir {
+				t.Errorf("FetchGit() = %s@%s (extracted %t) in %s, want git-pkg@%s in %s",
+					pkg.Name(), pkg.Version(), pkg.IsExtracted(), pkg.LocalPath(), tt.wantCommit, dir)
+			}
+			want := &analysisrun.GitSource{Repository: src.Repository, Ref: tt.ref, Commit: tt.wantCommit}
+			if !reflect.DeepEqual(pkg.GitSource(), want) {
+				t.Errorf("GitSource() = %+v, want %+v", pkg.GitSource(), want)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			for _, e := range entries {
+				files = append(files, e.Name())
+			}
+			if !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("fetched files = %v, want %v", files, tt.wantFiles)
+			}
+		})
+	}
+
+	src := &analysisrun.GitSource{Repository: "file://" + repo, Ref: "no-such-branch"}
+	if _, err := manager.FetchGit(ctx, "", src, filepath.Join(t.TempDir(), "pkg")); !errors.Is(err, ErrGitRefNotFound) {
+		t.Errorf("FetchGit(%q) error = %v, want %v", src.Ref, err, ErrGitRefNotFound)
+	}
+	src = &analysisrun.GitSource{Repository: "file://" + repo, Ref: "--upload-pack=touch " + filepath.Join(repo, "pwned")}
+	if _, err := manager.FetchGit(ctx, "", src, filepath.Join(t.TempDir(), "pkg")); !errors.Is(err, ErrInvalidGitSource) {
+		t.Errorf("FetchGit(%q) error = %v, want %v", src.Ref, err, ErrInvalidGitSource)
+	}
+}
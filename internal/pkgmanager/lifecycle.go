@@ -0,0 +1,34 @@
+package pkgmanager
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrLifecycleScriptsNotSupported = errors.New("lifecycle scripts not supported")
+
+// LifecycleScript is a script declared in a package manifest that the
+// package manager runs automatically while the package is being installed.
+type LifecycleScript struct {
+	// Name is the name of the lifecycle event, e.g. "postinstall" for NPM.
+	Name string
+	// Script is the command line that the package manager runs for the event.
+	Script string
+}
+
+// SupportsLifecycleScripts returns whether LifecycleScripts is
+// implemented for the ecosystem.
+func (p *PkgManager) SupportsLifecycleScripts() bool {
+	return p.lifecycleScripts != nil
+}
+
+// LifecycleScripts returns the install-time lifecycle scripts declared by the
+// package in the archive at archivePath, in the order that they are run by the
+// package manager. If the ecosystem does not support lifecycle scripts,
+// ErrLifecycleScriptsNotSupported is returned.
+func (p *PkgManager) LifecycleScripts(archivePath string) ([]LifecycleScript, error) {
+	if p.lifecycleScripts == nil {
+		return nil, fmt.Errorf("%w for %s", ErrLifecycleScriptsNotSupported, p.Ecosystem())
+	}
+	return p.lifecycleScripts(archivePath)
+}
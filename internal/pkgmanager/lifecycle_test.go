@@ -0,0 +1,121 @@
+package pkgmanager
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func writeTestTgz(t *testing.T, files map[string]string) string {
+	t.Helper()
+	archivePath := filepath.Join(t.TempDir(), "package.tgz")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gzWriter := gzip.NewWriter(f)
+	tarWriter := tar.NewWriter(gzWriter)
+	for name, contents := range files {
+		header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(contents)), Typeflag: tar.TypeReg}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return archivePath
+}
+
+func TestNPMLifecycleScripts(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []LifecycleScript
+		wantErr bool
+	}{
+		{
+			name: "install scripts in order",
+			files: map[string]string{
+				"package/package.json": `{"scripts": {"test": "jest", "postinstall": "node setup.js", "preinstall": "sh pre.sh"}}`,
+			},
+			want: []LifecycleScript{
+				{Name: "preinstall", Script: "sh pre.sh"},
+				{Name: "postinstall", Script: "node setup.js"},
+			},
+		},
+		{
+			name: "no scripts",
+			files: map[string]string{
+				"package/package.json": `{"name": "foo"}`,
+			},
+			want: nil,
+		},
+		{
+			name: "nested package.json ignored",
+			files: map[string]string{
+				"package/package.json":                  `{"name": "foo"}`,
+				"package/node_modules/bar/package.json": `{"scripts": {"install": "evil"}}`,
+			},
+			want: nil,
+		},
+		{
+			name: "non standard top level directory",
+			files: map[string]string{
+				"foo/package.json": `{"scripts": {"install": "node install.js"}}`,
+			},
+			want: []LifecycleScript{{Name: "install", Script: "node install.js"}},
+		},
+		{
+			name: "implicit node-gyp install",
+			files: map[string]string{
+				"package/package.json": `{"scripts": {"postinstall": "node done.js"}}`,
+				"package/binding.gyp":  `{}`,
+			},
+			want: []LifecycleScript{
+				{Name: "install", Script: npmDefaultGypScript},
+				{Name: "postinstall", Script: "node done.js"},
+			},
+		},
+		{
+			name:    "missing package.json",
+			files:   map[string]string{"package/index.js": ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Manager(pkgecosystem.NPM).LifecycleScripts(writeTestTgz(t, tt.files))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LifecycleScripts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LifecycleScripts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLifecycleScriptsNotSupported(t *testing.T) {
+	manager := Manager(pkgecosystem.PyPI)
+	if manager.SupportsLifecycleScripts() {
+		t.Fatalf("SupportsLifecycleScripts() = true, want false")
+	}
+	if _, err := manager.LifecycleScripts("foo.tar.gz"); !errors.Is(err, ErrLifecycleScriptsNotSupported) {
+		t.Errorf("LifecycleScripts() error = %v, want %v", err, ErrLifecycleScriptsNotSupported)
+	}
+}
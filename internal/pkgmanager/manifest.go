@@ -0,0 +1,284 @@
+package pkgmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrManifestNotSupported             = errors.New("manifest parsing not supported")
+	ErrDependencyResolutionNotSupported = errors.New("dependency resolution not supported")
+	ErrUnresolvableDependency           = errors.New("cannot resolve dependency")
+)
+
+// DependencyType describes when a declared dependency is installed or used.
+type DependencyType string
+
+const (
+	// RuntimeDependency is installed along with the package.
+	RuntimeDependency DependencyType = "runtime"
+	// DevDependency is only installed when developing the package itself.
+	DevDependency DependencyType = "dev"
+	// OptionalDependency is installed along with the package if possible,
+	// but installation does not fail if it cannot be installed.
+	OptionalDependency DependencyType = "optional"
+	// PeerDependency is expected to be installed by the user of the package.
+	PeerDependency DependencyType = "peer"
+)
+
+// Dependency is a dependency declared in a package manifest.
+type Dependency struct {
+	Name string
+	// Version is the version constraint given for the dependency, e.g. "^1.2.0".
+	Version string
+	Type    DependencyType
+}
+
+// Manifest holds the information declared by a package in its manifest
+// (e.g. package.json for NPM) which is relevant to analysis.
+type Manifest struct {
+	Name        string
+	Version     string
+	Description string
+	Keywords    []string
+	// Dependencies are sorted by type (in the order of the DependencyType
+	// constants), then name.
+	Dependencies []Dependency
+	// Scripts maps script names to the command line run for the script. For NPM,
+	// this includes lifecycle scripts (see LifecycleScripts) as well as scripts
+	// which are only run on request, e.g. "test".
+	Scripts map[string]string
+	// Bins maps the names of the executables installed by the package (e.g. into
+	// node_modules/.bin, or on PATH if it is installed globally) to their paths
+	// within the package.
+	Bins map[string]string
+	// LockedVersions maps the names of dependencies to the exact versions pinned
+	// by a lockfile shipped with the package (for NPM, npm-shrinkwrap.json or
+	// package-lock.json), if any. This includes transitive dependencies which
+	// are installed at the top level of the dependency tree.
+	LockedVersions map[string]string
+}
+
+// SupportsManifest returns whether Manifest is implemented for the ecosystem.
+func (p *PkgManager) SupportsManifest() bool {
+	return p.manifest != nil
+}
+
+// Manifest returns the manifest of the package extracted (by ExtractArchive) into
+// extractDir. If the ecosystem does not support manifest parsing, ErrManifestNotSupported
+// is returned.
+func (p *PkgManager) Manifest(extractDir string) (*Manifest, error) {
+	if p.manifest == nil {
+		return nil, fmt.Errorf("%w for %s", ErrManifestNotSupported, p.Ecosystem())
+	}
+	return p.manifest(extractDir)
+}
+
+// SupportsDependencyResolution returns whether ResolveDependency is implemented
+// for the ecosystem.
+func (p *PkgManager) SupportsDependencyResolution() bool {
+	return p.resolveVersion != nil
+}
+
+/*
+ResolveDependency returns the package that would be installed for the given
+dependency, i.e. the version of it chosen by the ecosystem's package manager for
+the version constraint of the dependency. If the ecosystem does not support
+dependency resolution, ErrDependencyResolutionNotSupported is returned, and if
+the constraint cannot be resolved to a version in the registry (e.g. it refers to
+a git repository), the error wraps ErrUnresolvableDependency.
+*/
+func (p *PkgManager) ResolveDependency(dep Dependency) (*Pkg, error) {
+	if p.resolveVersion == nil {
+		return nil, fmt.Errorf("%w for %s", ErrDependencyResolutionNotSupported, p.Ecosystem())
+	}
+	name := normalizePkgName(dep.Name)
+	version, err := p.resolveVersion(p.resolvedRegistry(), name, dep.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s@%s: %w", dep.Name, dep.Version, err)
+	}
+	return p.Package(name, version), nil
+}
+
+// npmDeclarations represents the parts of a package.json file that are
+// returned by getNPMManifest. It is kept separate from npmManifest so that
+// malformed declarations do not prevent lifecycle scripts and entry points
+// from being read.
+type npmDeclarations struct {
+	Name        string            `json:"name"`
+	Version     string            `json:"version"`
+	Description string            `json:"description"`
+	Keywords    any               `json:"keywords"`
+	Scripts     map[string]string `json:"scripts"`
+	// Bin may be a string (the path of a single executable, named after the
+	// package) or an object mapping executable names to paths.
+	Bin any `json:"bin"`
+
+	Dependencies         map[string]string `json:"dependencies"`
+	DevDependencies      map[string]string `json:"devDependencies"`
+	OptionalDependencies map[string]string `json:"optionalDependencies"`
+	PeerDependencies     map[string]string `json:"peerDependencies"`
+}
+
+// npmKeywords returns the keywords in the "keywords" field of a package.json,
+// which should be an array of strings, but is sometimes a single string.
+func npmKeywords(keywords any) []string {
+	switch k := keywords.(type) {
+	case string:
+		return strings.Fields(strings.ReplaceAll(k, ",", " "))
+	case []any:
+		var result []string
+		for _, keyword := range k {
+			if s, ok := keyword.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// npmBins returns the executables declared in the "bin" field of the package.json
+// of the package with the given name. As NPM does, a path given as a string is
+// installed under the name of the package (without its scope), and only the base
+// name of each executable name is used.
+func npmBins(bin any, pkgName string) map[string]string {
+	switch b := bin.(type) {
+	case string:
+		if _, name, ok := strings.Cut(pkgName, "/"); ok {
+			pkgName = name
+		}
+		if pkgName == "" || b == "" {
+			return nil
+		}
+		return map[string]string{pkgName: b}
+	case map[string]any:
+		result := map[string]string{}
+		for name, p := range b {
+			name = path.Base(name)
+			if p, ok := p.(string); ok && name != "." && name != "/" {
+				result[name] = p
+			}
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	}
+	return nil
+}
+
+// sortedDependencies returns the dependencies in deps with the given type,
+// sorted by name.
+func sortedDependencies(deps map[string]string, depType DependencyType) []Dependency {
+	result := make([]Dependency, 0, len(deps))
+	for name, version := range deps {
+		result = append(result, Dependency{Name: name, Version: version, Type: depType})
+	}
+	slices.SortFunc(result, func(a, b Dependency) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+// npmLockfileNames are the names of the lockfiles that may be shipped in an NPM
+// package, in order of precedence. npm-shrinkwrap.json is the lockfile intended for
+// publishing; package-lock.json is not normally published, but sometimes is.
+var npmLockfileNames = []string{"npm-shrinkwrap.json", "package-lock.json"}
+
+// npmLockfile represents the parts of an NPM lockfile giving the versions of the
+// installed packages. Lockfiles of version 2 and later list the packages by their
+// path in node_modules, while version 1 lists them by name.
+type npmLockfile struct {
+	Packages map[string]struct {
+		Version string `json:"version"`
+	} `json:"packages"`
+	Dependencies map[string]struct {
+		Version string `json:"version"`
+	} `json:"dependencies"`
+}
+
+// readNPMLockedVersions returns the versions of the packages installed at the top
+// level of node_modules according to the lockfile in the package root dir, or nil
+// if there is no lockfile. Entries which are not registry versions (e.g. links or
+// git URLs) are skipped. Since lockfiles are only used to pin the versions of
+// dependencies, a lockfile that cannot be read is ignored.
+func readNPMLockedVersions(root string) map[string]string {
+	for _, name := range npmLockfileNames {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		var lock npmLockfile
+		if err := json.Unmarshal(data, &lock); err != nil {
+			return nil
+		}
+		versions := make(map[string]string)
+		for path, p := range lock.Packages {
+			name, ok := strings.CutPrefix(path, "node_modules/")
+			if ok && !strings.Contains(name, "/node_modules/") {
+				if _, valid := parseSemver(p.Version); valid {
+					versions[name] = p.Version
+				}
+			}
+		}
+		for name, d := range lock.Dependencies {
+			if _, valid := parseSemver(d.Version); valid && versions[name] == "" {
+				versions[name] = d.Version
+			}
+		}
+		return versions
+	}
+	return nil
+}
+
+/*
+getNPMManifest reads the package.json of the NPM package extracted into extractDir.
+
+NPM treats a package listed in optionalDependencies as optional even if it is also
+listed in dependencies, so such packages are only returned as optional dependencies.
+Versions pinned by a lockfile in the package are returned in LockedVersions.
+*/
+func getNPMManifest(extractDir string) (*Manifest, error) {
+	root, err := findNPMPackageRoot(extractDir)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	var decl npmDeclarations
+	if err := json.Unmarshal(data, &decl); err != nil {
+		return nil, fmt.Errorf("error decoding package.json: %w", err)
+	}
+
+	runtimeDeps := map[string]string{}
+	for name, version := range decl.Dependencies {
+		if _, optional := decl.OptionalDependencies[name]; !optional {
+			runtimeDeps[name] = version
+		}
+	}
+
+	var deps []Dependency
+	deps = append(deps, sortedDependencies(runtimeDeps, RuntimeDependency)...)
+	deps = append(deps, sortedDependencies(decl.DevDependencies, DevDependency)...)
+	deps = append(deps, sortedDependencies(decl.OptionalDependencies, OptionalDependency)...)
+	deps = append(deps, sortedDependencies(decl.PeerDependencies, PeerDependency)...)
+
+	return &Manifest{
+		Name:           decl.Name,
+		Version:        decl.Version,
+		Description:    decl.Description,
+		Keywords:       npmKeywords(decl.Keywords),
+		Dependencies:   deps,
+		Scripts:        decl.Scripts,
+		Bins:           npmBins(decl.Bin, decl.Name),
+		LockedVersions: readNPMLockedVersions(root),
+	}, nil
+}
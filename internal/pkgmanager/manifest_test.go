@@ -0,0 +1,163 @@
+package pkgmanager
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestNPMManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package/package.json": `{
+			"name": "example",
+			"version": "1.0.0",
+			"description": "An example package",
+			"keywords": ["example", "test"],
+			"scripts": {"postinstall": "node setup.js", "test": "jest"},
+			"bin": {"example": "bin/example.js", "lib/ls": "bin/ls.js"},
+			"dependencies": {"lodash": "^4.17.21", "fsevents": "^2.3.2", "axios": "1.6.0"},
+			"devDependencies": {"jest": "^29.0.0"},
+			"optionalDependencies": {"fsevents": "^2.3.2"},
+			"peerDependencies": {"react": ">=16"}
+		}`,
+	})
+
+	got, err := Manager(pkgecosystem.NPM).Manifest(dir)
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	want := &Manifest{
+		Name:        "example",
+		Version:     "1.0.0",
+		Description: "An example package",
+		Keywords:    []string{"example", "test"},
+		Dependencies: []Dependency{
+			{Name: "axios", Version: "1.6.0", Type: RuntimeDependency},
+			{Name: "lodash", Version: "^4.17.21", Type: RuntimeDependency},
+			{Name: "jest", Version: "^29.0.0", Type: DevDependency},
+			{Name: "fsevents", Version: "^2.3.2", Type: OptionalDependency},
+			{Name: "react", Version: ">=16", Type: PeerDependency},
+		},
+		Scripts: map[string]string{"postinstall": "node setup.js", "test": "jest"},
+		Bins:    map[string]string{"example": "bin/example.js", "ls": "bin/ls.js"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Manifest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNPMManifestKeywordString(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package.json": `{"name": "example", "keywords": "http, client"}`,
+	})
+
+	got, err := Manager(pkgecosystem.NPM).Manifest(dir)
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	if want := []string{"http", "client"}; !reflect.DeepEqual(got.Keywords, want) {
+		t.Errorf("Manifest().Keywords = %v, want %v", got.Keywords, want)
+	}
+}
+
+func TestNPMManifestBinString(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package.json": `{"name": "@scope/git", "bin": "./cli.js"}`,
+	})
+
+	got, err := Manager(pkgecosystem.NPM).Manifest(dir)
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	if want := map[string]string{"git": "./cli.js"}; !reflect.DeepEqual(got.Bins, want) {
+		t.Errorf("Manifest().Bins = %v, want %v", got.Bins, want)
+	}
+}
+
+func TestManifestNotSupported(t *testing.T) {
+	manager := Manager(pkgecosystem.CratesIO)
+	if manager.SupportsManifest() {
+		t.Fatalf("SupportsManifest() = true for %s", manager)
+	}
+	if _, err := manager.Manifest(t.TempDir()); !errors.Is(err, ErrManifestNotSupported) {
+		t.Errorf("Manifest() error = %v, want %v", err, ErrManifestNotSupported)
+	}
+}
+
+func TestNPMManifestLockedVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"package/package.json": `{"name": "example", "dependencies": {"lodash": "^4.17.0", "debug": "^4.0.0"}}`,
+		"package/npm-shrinkwrap.json": `{
+			"lockfileVersion": 3,
+			"packages": {
+				"": {"name": "example"},
+				"node_modules/lodash": {"version": "4.17.21"},
+				"node_modules/debug": {"version": "4.3.4"},
+				"node_modules/ms": {"version": "2.1.2"},
+				"node_modules/debug/node_modules/ms": {"version": "2.0.0"},
+				"node_modules/local": {"version": "file:../local"}
+			}
+		}`,
+	})
+
+	got, err := Manager(pkgecosystem.NPM).Manifest(dir)
+	if err != nil {
+		t.Fatalf("Manifest() error = %v", err)
+	}
+	want := map[string]string{"lodash": "4.17.21", "debug": "4.3.4", "ms": "2.1.2"}
+	if !reflect.DeepEqual(got.LockedVersions, want) {
+		t.Errorf("Manifest().LockedVersions = %v, want %v", got.LockedVersions, want)
+	}
+}
+
+func TestSelectNPMVersion(t *testing.T) {
+	distTags := map[string]string{"latest": "2.1.0", "next": "3.0.0-rc.1"}
+	versions := []string{"1.0.0", "1.4.2", "1.10.0", "2.0.0", "2.1.0", "2.2.0-beta", "3.0.0-rc.1"}
+	tests := []struct {
+		versionRange string
+		want         string
+	}{
+		{"", "2.1.0"},
+		{"*", "2.1.0"},
+		{"latest", "2.1.0"},
+		{"next", "3.0.0-rc.1"},
+		{"^1.0.0", "1.10.0"},
+		{"~1.4.0", "1.4.2"},
+		{"^2.0.0", "2.1.0"},
+		{">=2.0.0", "2.1.0"},
+		{"2.0.0", "2.0.0"},
+		{"^4.0.0", ""},
+		{"git+https://github.com/example/example.git", ""},
+		{"npm:other@^1.0.0", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.versionRange, func(t *testing.T) {
+			got, err := selectNPMVersion(distTags, versions, tt.versionRange)
+			if tt.want == "" {
+				if !errors.Is(err, ErrUnresolvableDependency) {
+					t.Errorf("selectNPMVersion() = %q, %v, want error %v", got, err, ErrUnresolvableDependency)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("selectNPMVersion() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveDependencyNotSupported(t *testing.T) {
+	manager := Manager(pkgecosystem.CratesIO)
+	if manager.SupportsDependencyResolution() {
+		t.Fatalf("SupportsDependencyResolution() = true for %s", manager)
+	}
+	if _, err := manager.ResolveDependency(Dependency{Name: "serde", Version: "1"}); !errors.Is(err, ErrDependencyResolutionNotSupported) {
+		t.Errorf("ResolveDependency() error = %v, want %v", err, ErrDependencyResolutionNotSupported)
+	}
+}
@@ -1,12 +1,17 @@
 package pkgmanager
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"os"
+	"path"
 	"strings"
 
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
 	"github.com/ossf/package-analysis/internal/utils"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
@@ -29,8 +34,74 @@ type npmVersionJSON struct {
 	} `json:"dist"`
 }
 
-func getNPMLatest(pkg string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://registry.npmjs.org/%s", pkg))
+// npmPackageVersionsJSON represents the versions of a package listed in the NPM
+// registry response when package information is requested.
+type npmPackageVersionsJSON struct {
+	DistTags map[string]string          `json:"dist-tags"`
+	Versions map[string]json.RawMessage `json:"versions"`
+}
+
+/*
+selectNPMVersion chooses the version of a package that NPM would install for the
+given version range (or dist-tag, e.g. "next"), from the versions and dist-tags
+of the package. As in NPM, the version tagged "latest" is chosen if it is in the
+range, and otherwise the highest version in the range. An empty range or "*"
+means the latest version.
+
+Ranges that do not refer to versions in the registry, such as git URLs, local
+paths or aliases (e.g. "npm:other@^1.0.0"), cannot be resolved, and an error
+wrapping ErrUnresolvableDependency is returned, as it is if no version matches.
+*/
+func selectNPMVersion(distTags map[string]string, versions []string, versionRange string) (string, error) {
+	versionRange = strings.TrimSpace(versionRange)
+	if versionRange == "" || versionRange == "*" {
+		versionRange = "latest"
+	}
+	if tagged, ok := distTags[versionRange]; ok {
+		return tagged, nil
+	}
+	r, err := parseNPMRange(versionRange)
+	if err != nil {
+		return "", fmt.Errorf("%w: unsupported version range %q", ErrUnresolvableDependency, versionRange)
+	}
+	if latest, ok := parseSemver(distTags["latest"]); ok && r.matches(latest) {
+		return distTags["latest"], nil
+	}
+	best, bestVersion := "", semver{}
+	for _, s := range versions {
+		v, ok := parseSemver(s)
+		if ok && r.matches(v) && (best == "" || v.compare(bestVersion) > 0) {
+			best, bestVersion = s, v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("%w: no version matches %q", ErrUnresolvableDependency, versionRange)
+	}
+	return best, nil
+}
+
+// getNPMResolvedVersion returns the version of pkg that NPM would install for the
+// given version range (see selectNPMVersion).
+func getNPMResolvedVersion(r Registry, pkg, versionRange string) (string, error) {
+	resp, err := r.get(r.endpoint("/%s", pkg))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var details npmPackageVersionsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return "", err
+	}
+	versions := make([]string, 0, len(details.Versions))
+	for v := range details.Versions {
+		versions = append(versions, v)
+	}
+	return selectNPMVersion(details.DistTags, versions, versionRange)
+}
+
+func getNPMLatest(r Registry, pkg string) (string, error) {
+	resp, err := r.get(r.endpoint("/%s", pkg))
 	if err != nil {
 		return "", err
 	}
@@ -59,8 +130,8 @@ func getNPMArchiveFilename(pkgName, version, _ string) string {
 	return fmt.Sprintf("%s-%s.tgz", cleanedName, version)
 }
 
-func getNPMArchiveURL(pkgName, version string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://registry.npmjs.org/%s/%s", pkgName, version))
+func getNPMArchiveURL(r Registry, pkgName, version string) (string, error) {
+	resp, err := r.get(r.endpoint("/%s/%s", pkgName, version))
 	if err != nil {
 		return "", err
 	}
@@ -83,10 +154,104 @@ func getNPMArchiveURL(pkgName, version string) (string, error) {
 	return packageInfo.Dist.Tarball, nil
 }
 
+// npmInstallEvents lists the lifecycle events that NPM runs when installing
+// a package, in the order that they are run.
+// See https://docs.npmjs.com/cli/using-npm/scripts#npm-install
+var npmInstallEvents = []string{"preinstall", "install", "postinstall"}
+
+// npmDefaultGypScript is the install script that NPM runs by default if a package
+// contains a binding.gyp file and does not declare install or preinstall scripts.
+const npmDefaultGypScript = "node-gyp rebuild"
+
+// npmManifest represents relevant parts of a package.json file.
+type npmManifest struct {
+	Scripts map[string]string `json:"scripts"`
+	Main    string            `json:"main"`
+	// Exports may be a string, an array, or an object of subpaths or conditions.
+	// See https://nodejs.org/api/packages.html#package-entry-points
+	Exports any `json:"exports"`
+}
+
+/*
+getNPMLifecycleScripts reads the package.json from the NPM package archive at
+archivePath and returns the install lifecycle scripts it declares.
+
+NPM package archives contain a single top level directory (usually named
+'package'), and the manifest is the package.json file directly inside it.
+*/
+func getNPMLifecycleScripts(archivePath string) ([]LifecycleScript, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading gzip archive: %w", err)
+	}
+	defer gzReader.Close()
+
+	var manifest *npmManifest
+	hasGypFile := false
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading tar archive: %w", err)
+		}
+
+		dir, base := path.Split(path.Clean(header.Name))
+		if strings.Count(dir, "/") != 1 {
+			// only consider files directly inside the top level directory
+			continue
+		}
+
+		switch base {
+		case "package.json":
+			manifest = &npmManifest{}
+			if err := json.NewDecoder(tarReader).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("error decoding %s: %w", header.Name, err)
+			}
+		case "binding.gyp":
+			hasGypFile = true
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("package.json not found in %s", archivePath)
+	}
+
+	scripts := manifest.Scripts
+	if hasGypFile && scripts["install"] == "" && scripts["preinstall"] == "" {
+		scripts = map[string]string{"install": npmDefaultGypScript, "postinstall": scripts["postinstall"]}
+	}
+
+	var result []LifecycleScript
+	for _, event := range npmInstallEvents {
+		if script := strings.TrimSpace(scripts[event]); script != "" {
+			result = append(result, LifecycleScript{Name: event, Script: script})
+		}
+	}
+	return result, nil
+}
+
 var npmPkgManager = PkgManager{
-	ecosystem:       pkgecosystem.NPM,
-	latestVersion:   getNPMLatest,
-	archiveURL:      getNPMArchiveURL,
-	archiveFilename: getNPMArchiveFilename,
-	extractArchive:  utils.ExtractTarGzFile,
+	ecosystem:          pkgecosystem.NPM,
+	defaultRegistryURL: "https://registry.npmjs.org",
+	latestVersion:      getNPMLatest,
+	resolveVersion:     getNPMResolvedVersion,
+	listVersions:       getNPMVersions,
+	archiveURL:         getNPMArchiveURL,
+	archiveFilename:    getNPMArchiveFilename,
+	extractArchive:     utils.ExtractTarGzFileWithLimits,
+	lifecycleScripts:   getNPMLifecycleScripts,
+	entryPoints:        getNPMEntryPoints,
+	manifest:           getNPMManifest,
+	runtime:            "node",
+	staticLanguage:     parsing.JavaScript,
 }
@@ -0,0 +1,334 @@
+package pkgmanager
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var errInvalidRange = errors.New("invalid version range")
+
+// semver is a semantic version (see https://semver.org). Build metadata is ignored.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// semverPattern matches a version, capturing the major, minor and patch numbers,
+// and the prerelease identifiers.
+var semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// parseSemver parses a version such as "1.2.3" or "2.0.0-beta.1".
+func parseSemver(s string) (semver, bool) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return semver{}, false
+	}
+	v := semver{}
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+	if m[4] != "" {
+		v.prerelease = strings.Split(m[4], ".")
+	}
+	return v, true
+}
+
+// comparePrerelease compares prerelease identifiers. Numeric identifiers are
+// compared numerically and sort before alphanumeric ones, and a version without
+// a prerelease sorts after every prerelease of it.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.Atoi(a[i])
+		bn, bErr := strconv.Atoi(b[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return compareInts(an, bn)
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(a), len(b))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare returns -1, 0 or 1 if v is lower than, equal to or higher than w.
+func (v semver) compare(w semver) int {
+	if c := compareInts(v.major, w.major); c != 0 {
+		return c
+	}
+	if c := compareInts(v.minor, w.minor); c != 0 {
+		return c
+	}
+	if c := compareInts(v.patch, w.patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.prerelease, w.prerelease)
+}
+
+// sameRelease returns whether v and w have the same major, minor and patch numbers.
+func (v semver) sameRelease(w semver) bool {
+	return v.major == w.major && v.minor == w.minor && v.patch == w.patch
+}
+
+// comparator is a comparison of a version with a fixed version, e.g. ">=1.2.0".
+type comparator struct {
+	op      string
+	version semver
+}
+
+func (c comparator) matches(v semver) bool {
+	cmp := v.compare(c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return cmp == 0
+}
+
+// partialVersion is a possibly incomplete version in a range, e.g. "1.2" or "1.x".
+// Missing or wildcard parts are -1.
+type partialVersion struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// partialPattern matches a partial version, capturing its parts.
+var partialPattern = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*])(?:\.(\d+|[xX*])(?:-([0-9A-Za-z.-]+))?)?)?(?:\+[0-9A-Za-z.-]+)?$`)
+
+func parsePartial(s string) (partialVersion, error) {
+	if s == "" {
+		return partialVersion{-1, -1, -1, nil}, nil
+	}
+	m := partialPattern.FindStringSubmatch(s)
+	if m == nil {
+		return partialVersion{}, errInvalidRange
+	}
+	parts := [3]int{-1, -1, -1}
+	for i := range parts {
+		if n, err := strconv.Atoi(m[i+1]); err == nil {
+			parts[i] = n
+		} else {
+			break // parts after a wildcard are wildcards too
+		}
+	}
+	p := partialVersion{parts[0], parts[1], parts[2], nil}
+	if m[4] != "" && p.patch >= 0 {
+		p.prerelease = strings.Split(m[4], ".")
+	}
+	return p, nil
+}
+
+// lower returns the lowest version matching p, with missing parts set to zero.
+func (p partialVersion) lower() semver {
+	return semver{max(p.major, 0), max(p.minor, 0), max(p.patch, 0), p.prerelease}
+}
+
+// next returns the lowest version above all those matching p, e.g. 1.3.0-0 for
+// 1.2. The prerelease "0" makes prereleases of it sort higher.
+func (p partialVersion) next() semver {
+	if p.minor < 0 {
+		return semver{p.major + 1, 0, 0, []string{"0"}}
+	}
+	return semver{p.major, p.minor + 1, 0, []string{"0"}}
+}
+
+// xRange returns the comparators for a partial version, e.g. >=1.2.0 <1.3.0-0 for 1.2.
+func (p partialVersion) xRange() []comparator {
+	switch {
+	case p.major < 0:
+		return nil
+	case p.patch >= 0:
+		return []comparator{{"=", p.lower()}}
+	}
+	return []comparator{{">=", p.lower()}, {"<", p.next()}}
+}
+
+// tilde returns the comparators for ~p, which allows patch-level changes if a
+// minor version is given, and minor-level changes otherwise.
+func (p partialVersion) tilde() []comparator {
+	if p.major < 0 {
+		return nil
+	}
+	return []comparator{{">=", p.lower()}, {"<", partialVersion{p.major, p.minor, -1, nil}.next()}}
+}
+
+// caret returns the comparators for ^p, which allows changes that do not modify
+// the left-most non-zero part of the version.
+func (p partialVersion) caret() []comparator {
+	var upper semver
+	switch {
+	case p.major < 0:
+		return nil
+	case p.major > 0 || p.minor < 0:
+		upper = semver{p.major + 1, 0, 0, []string{"0"}}
+	case p.minor > 0 || p.patch < 0:
+		upper = semver{0, p.minor + 1, 0, []string{"0"}}
+	default:
+		upper = semver{0, 0, p.patch + 1, []string{"0"}}
+	}
+	return []comparator{{">=", p.lower()}, {"<", upper}}
+}
+
+// primitive returns the comparators for a comparison with a partial version, e.g. >1.2.
+func (p partialVersion) primitive(op string) []comparator {
+	switch {
+	case op == "=" || op == "":
+		return p.xRange()
+	case p.major < 0:
+		if op == "<" || op == ">" {
+			// nothing is less or greater than any version
+			return []comparator{{"<", semver{0, 0, 0, []string{"0"}}}}
+		}
+		return nil
+	case p.patch >= 0:
+		return []comparator{{op, p.lower()}}
+	}
+	switch op {
+	case ">":
+		return []comparator{{">=", p.next()}}
+	case "<=":
+		return []comparator{{"<", p.next()}}
+	case ">=", "<":
+		return []comparator{{op, p.lower()}}
+	}
+	return nil
+}
+
+// hyphen returns the comparators for the inclusive range "from - to".
+func hyphen(from, to partialVersion) []comparator {
+	var result []comparator
+	if from.major >= 0 {
+		result = append(result, comparator{">=", from.lower()})
+	}
+	switch {
+	case to.major < 0:
+	case to.patch >= 0:
+		result = append(result, comparator{"<=", to.lower()})
+	default:
+		result = append(result, comparator{"<", to.next()})
+	}
+	return result
+}
+
+// rangeOperatorPattern matches an operator of a range, and captures the
+// operator and the partial version that follows it.
+var rangeOperatorPattern = regexp.MustCompile(`^(<=|>=|<|>|=|~>|~|\^)?(.*)$`)
+
+// operatorSpacePattern matches whitespace between an operator and a version,
+// e.g. in ">= 1.2.3", which npm allows.
+var operatorSpacePattern = regexp.MustCompile(`(<=|>=|<|>|=|~>|~|\^)\s+`)
+
+// parseComparatorSet parses a range of comparators separated by whitespace, or
+// a hyphen range.
+func parseComparatorSet(s string) ([]comparator, error) {
+	fields := strings.Fields(operatorSpacePattern.ReplaceAllString(s, "$1"))
+	if len(fields) == 3 && fields[1] == "-" {
+		from, err := parsePartial(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parsePartial(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		return hyphen(from, to), nil
+	}
+
+	var result []comparator
+	for _, field := range fields {
+		m := rangeOperatorPattern.FindStringSubmatch(field)
+		p, err := parsePartial(m[2])
+		if err != nil {
+			return nil, err
+		}
+		switch m[1] {
+		case "~", "~>":
+			result = append(result, p.tilde()...)
+		case "^":
+			result = append(result, p.caret()...)
+		default:
+			result = append(result, p.primitive(m[1])...)
+		}
+	}
+	return result, nil
+}
+
+// npmRange is a version range in the syntax used by npm, e.g. "^1.2.0" or
+// ">=1.0.0 <2.0.0 || 3.x". See https://docs.npmjs.com/cli/v10/using-npm/semver.
+type npmRange [][]comparator
+
+// parseNPMRange parses a version range in the syntax used by npm.
+func parseNPMRange(s string) (npmRange, error) {
+	var r npmRange
+	for _, set := range strings.Split(s, "||") {
+		comparators, err := parseComparatorSet(set)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, comparators)
+	}
+	return r, nil
+}
+
+// matches returns whether v is in the range. As in npm, a prerelease version
+// only matches a set of comparators if one of them refers to a prerelease of
+// the same major, minor and patch version, so that "^1.2.0" does not match
+// "1.3.0-beta".
+func (r npmRange) matches(v semver) bool {
+	for _, set := range r {
+		if r.setMatches(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r npmRange) setMatches(set []comparator, v semver) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if len(v.prerelease) == 0 {
+		return true
+	}
+	for _, c := range set {
+		if len(c.version.prerelease) > 0 && c.version.sameRelease(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package pkgmanager
+
+import (
+	"testing"
+)
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{"0.9.0", "1.0.0-0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "1.0.1", "1.10.0", "2.0.0"}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, okA := parseSemver(ordered[i])
+		b, okB := parseSemver(ordered[i+1])
+		if !okA || !okB {
+			t.Fatalf("parseSemver(%q, %q) failed", ordered[i], ordered[i+1])
+		}
+		if a.compare(b) != -1 || b.compare(a) != 1 || a.compare(a) != 0 {
+			t.Errorf("compare(%s, %s) = %d, want -1", ordered[i], ordered[i+1], a.compare(b))
+		}
+	}
+	for _, invalid := range []string{"", "1.2", "1.2.x", "latest", "1.2.3.4"} {
+		if _, ok := parseSemver(invalid); ok {
+			t.Errorf("parseSemver(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestNPMRangeMatches(t *testing.T) {
+	tests := []struct {
+		versionRange string
+		matches      []string
+		nonMatches   []string
+	}{
+		{"", []string{"0.0.1", "3.2.1"}, []string{"1.0.0-beta"}},
+		{"*", []string{"1.0.0"}, []string{"1.0.0-beta"}},
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"=1.2.3", []string{"1.2.3"}, []string{"1.2.2"}},
+		{"1.x", []string{"1.0.0", "1.9.9"}, []string{"2.0.0", "0.9.0"}},
+		{"1.2", []string{"1.2.0", "1.2.9"}, []string{"1.3.0"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"1.2.2", "2.0.0", "1.3.0-beta"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"^1.2.3-beta.2", []string{"1.2.3-beta.4", "1.2.3", "1.5.0"}, []string{"1.2.3-alpha", "1.2.4-beta.1"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=1.0.0 <2.0.0", []string{"1.0.0", "1.5.0"}, []string{"2.0.0", "0.9.0"}},
+		{">= 1.0.0", []string{"1.0.0", "5.0.0"}, []string{"0.1.0"}},
+		{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3.0"}},
+		{"<1.2", []string{"1.1.9"}, []string{"1.2.0"}},
+		{"1.2.3 - 2.3", []string{"1.2.3", "2.3.9"}, []string{"1.2.2", "2.4.0"}},
+		{"1 - 2.3.4", []string{"1.0.0", "2.3.4"}, []string{"2.3.5"}},
+		{"^1.0.0 || ^3.0.0", []string{"1.1.0", "3.1.0"}, []string{"2.0.0"}},
+		{"v1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.versionRange, func(t *testing.T) {
+			r, err := parseNPMRange(tt.versionRange)
+			if err != nil {
+				t.Fatalf("parseNPMRange(%q) error = %v", tt.versionRange, err)
+			}
+			for _, s := range tt.matches {
+				if v, _ := parseSemver(s); !r.matches(v) {
+					t.Errorf("range %q does not match %s", tt.versionRange, s)
+				}
+			}
+			for _, s := range tt.nonMatches {
+				if v, _ := parseSemver(s); r.matches(v) {
+					t.Errorf("range %q matches %s", tt.versionRange, s)
+				}
+			}
+		})
+	}
+
+	for _, invalid := range []string{"latest", "git+https://github.com/a/b.git", "file:../lib", "1.2.3.4", "^x.y"} {
+		if _, err := parseNPMRange(invalid); err == nil {
+			t.Errorf("parseNPMRange(%q) succeeded", invalid)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package pkgmanager
 
 import (
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
@@ -9,6 +10,14 @@ type Pkg struct {
 	version string
 	manager *PkgManager
 	local   string
+	// extracted is true if local is a directory containing the extracted
+	// package, rather than a package archive.
+	extracted bool
+	// sha256 is the hex-encoded SHA256 hash of the package archive, if known.
+	sha256 string
+	// git is the repository the package was fetched from, if it was fetched
+	// with FetchGit.
+	git *analysisrun.GitSource
 }
 
 func (p *Pkg) Name() string {
@@ -31,6 +40,13 @@ func (p *Pkg) IsLocal() bool {
 	return p.local != ""
 }
 
+// IsExtracted returns whether the package was provided as a directory holding
+// the already extracted package (see PkgManager.Extracted). If so, LocalPath
+// returns the path of the directory.
+func (p *Pkg) IsExtracted() bool {
+	return p.extracted
+}
+
 func (p *Pkg) Manager() *PkgManager {
 	return p.manager
 }
@@ -38,3 +54,23 @@ func (p *Pkg) Manager() *PkgManager {
 func (p *Pkg) LocalPath() string {
 	return p.local
 }
+
+// ArtifactSHA256 returns the hex-encoded SHA256 hash of the package archive that
+// is analyzed, or the empty string if it was not computed (see WithArtifactSHA256).
+func (p *Pkg) ArtifactSHA256() string {
+	return p.sha256
+}
+
+// WithArtifactSHA256 returns a copy of p recording hash as the SHA256 hash of its
+// archive, so that the analysis results are tied to the exact artifact analyzed.
+func (p *Pkg) WithArtifactSHA256(hash string) *Pkg {
+	c := *p
+	c.sha256 = hash
+	return &c
+}
+
+// GitSource returns the git repository, ref and commit the package was fetched
+// from (see PkgManager.FetchGit), or nil if it was not fetched from git.
+func (p *Pkg) GitSource() *analysisrun.GitSource {
+	return p.git
+}
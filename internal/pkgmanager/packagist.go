@@ -2,8 +2,6 @@ package pkgmanager
 
 import (
 	"encoding/json"
-	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
@@ -39,8 +37,8 @@ type packagistJSON struct {
 	} `json:"packages"`
 }
 
-func getPackagistLatest(pkg string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://repo.packagist.org/p2/%s.json", pkg))
+func getPackagistLatest(r Registry, pkg string) (string, error) {
+	resp, err := r.get(r.endpoint("/p2/%s.json", pkg))
 	if err != nil {
 		return "", err
 	}
@@ -68,8 +66,8 @@ func getPackagistLatest(pkg string) (string, error) {
 	return latestVersion, nil
 }
 
-func getPackagistArchiveURL(pkgName, version string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://repo.packagist.org/p2/%s.json", pkgName))
+func getPackagistArchiveURL(r Registry, pkgName, version string) (string, error) {
+	resp, err := r.get(r.endpoint("/p2/%s.json", pkgName))
 	if err != nil {
 		return "", err
 	}
@@ -99,8 +97,10 @@ func getPackagistArchiveFilename(pkgName, version, _ string) string {
 }
 
 var packagistPkgManager = PkgManager{
-	ecosystem:       pkgecosystem.Packagist,
-	latestVersion:   getPackagistLatest,
-	archiveURL:      getPackagistArchiveURL,
-	archiveFilename: getPackagistArchiveFilename,
+	ecosystem:          pkgecosystem.Packagist,
+	defaultRegistryURL: "https://repo.packagist.org",
+	latestVersion:      getPackagistLatest,
+	archiveURL:         getPackagistArchiveURL,
+	archiveFilename:    getPackagistArchiveFilename,
+	runtime:            "php",
 }
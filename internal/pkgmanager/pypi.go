@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
 
 	"github.com/ossf/package-analysis/internal/utils"
@@ -29,8 +28,8 @@ type pypiPackageInfoJSON struct {
 	} `json:"urls"`
 }
 
-func getPyPILatest(pkg string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://pypi.org/pypi/%s/json", pkg))
+func getPyPILatest(r Registry, pkg string) (string, error) {
+	resp, err := r.get(r.endpoint("/pypi/%s/json", pkg))
 	if err != nil {
 		return "", err
 	}
@@ -46,8 +45,8 @@ func getPyPILatest(pkg string) (string, error) {
 	return details.Info.Version, nil
 }
 
-func getPyPIArchiveURL(pkgName, version string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://pypi.org/pypi/%s/%s/json", pkgName, version))
+func getPyPIArchiveURL(r Registry, pkgName, version string) (string, error) {
+	resp, err := r.get(r.endpoint("/pypi/%s/%s/json", pkgName, version))
 	if err != nil {
 		return "", err
 	}
@@ -79,9 +78,13 @@ func getPyPIArchiveURL(pkgName, version string) (string, error) {
 }
 
 var pypiPkgManager = PkgManager{
-	ecosystem:       pkgecosystem.PyPI,
-	latestVersion:   getPyPILatest,
-	archiveURL:      getPyPIArchiveURL,
-	archiveFilename: defaultArchiveFilename,
-	extractArchive:  utils.ExtractTarGzFile,
+	ecosystem:          pkgecosystem.PyPI,
+	defaultRegistryURL: "https://pypi.org",
+	latestVersion:      getPyPILatest,
+	listVersions:       getPyPIVersions,
+	archiveURL:         getPyPIArchiveURL,
+	archiveFilename:    defaultArchiveFilename,
+	extractArchive:     utils.ExtractTarGzFileWithLimits,
+	runtime:            "python3",
+	executePhase:       true,
 }
@@ -0,0 +1,203 @@
+package pkgmanager
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+var (
+	ErrInvalidRegistry  = errors.New("invalid registry configuration")
+	ErrInsecureRegistry = errors.New("registry credentials require an https URL")
+)
+
+// registryEnvPrefix is the prefix of the environment variables read by
+// ConfigureRegistries. See registryEnvName.
+const registryEnvPrefix = "OSSF_REGISTRY_"
+
+/*
+Registry configures the package registry that a PkgManager fetches package
+metadata and archives from, and the credentials used to access it.
+
+If URL is empty, the public registry of the ecosystem is used. Credentials are
+either a bearer token or a username and password (HTTP basic auth), and are only
+sent in requests to the host of the registry. Registry implements fmt.Stringer
+and slog.LogValuer so that credentials are not revealed if it is logged.
+*/
+type Registry struct {
+	URL      string
+	Token    string
+	Username string
+	Password string
+}
+
+// HasCredentials returns whether credentials are configured for the registry.
+func (r Registry) HasCredentials() bool {
+	return r.Token != "" || r.Username != "" || r.Password != ""
+}
+
+// IsDefault returns whether r is the zero value, i.e. the public registry of the
+// ecosystem is accessed without credentials.
+func (r Registry) IsDefault() bool {
+	return r == Registry{}
+}
+
+func (r Registry) credentialType() string {
+	switch {
+	case r.Token != "":
+		return "token"
+	case r.HasCredentials():
+		return "basic"
+	default:
+		return "none"
+	}
+}
+
+// String implements the Stringer interface. Credentials are redacted.
+func (r Registry) String() string {
+	return fmt.Sprintf("%s (credentials: %s)", r.URL, r.credentialType())
+}
+
+// LogValue implements the slog.LogValuer interface. Credentials are redacted.
+func (r Registry) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("url", r.URL), slog.String("credentials", r.credentialType()))
+}
+
+// Validate checks that the registry URL is well-formed, and that credentials
+// are only configured for a registry accessed over https.
+func (r Registry) Validate() error {
+	if r.Token != "" && (r.Username != "" || r.Password != "") {
+		return fmt.Errorf("%w: both a token and a username/password are set", ErrInvalidRegistry)
+	}
+	if r.URL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		// the URL is not included in the error in case it contains credentials
+		return fmt.Errorf("%w: could not parse URL", ErrInvalidRegistry)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URL must not contain credentials, set the username and password instead", ErrInvalidRegistry)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+		return fmt.Errorf("%w: URL %q must be an absolute http(s) URL", ErrInvalidRegistry, r.URL)
+	}
+	if u.Scheme != "https" && r.HasCredentials() {
+		return fmt.Errorf("%w: %s", ErrInsecureRegistry, r.URL)
+	}
+	return nil
+}
+
+// endpoint returns the URL of the given path (which must start with a '/')
+// on the registry.
+func (r Registry) endpoint(format string, args ...any) string {
+	return strings.TrimSuffix(r.URL, "/") + fmt.Sprintf(format, args...)
+}
+
+// authorize adds credentials to req if they are configured and req is for the
+// registry host. Requests to other hosts, e.g. a CDN serving archives of a public
+// registry, are left unchanged. Note that net/http also removes the credentials
+// if a request is redirected to a different host.
+func (r Registry) authorize(req *http.Request) {
+	if !r.HasCredentials() || req.URL.Scheme != "https" {
+		return
+	}
+	u, err := url.Parse(r.URL)
+	if err != nil || !strings.EqualFold(u.Host, req.URL.Host) {
+		return
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	} else {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+}
+
+// get issues a GET request for the given URL, adding the registry credentials
+// if applicable.
+func (r Registry) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.authorize(req)
+	return http.DefaultClient.Do(req)
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// registryEnvName returns the name of the environment variable holding the given
+// registry setting for an ecosystem, e.g. OSSF_REGISTRY_CRATES_IO_TOKEN.
+func registryEnvName(e pkgecosystem.Ecosystem, setting string) string {
+	return registryEnvPrefix + nonAlphanumeric.ReplaceAllString(strings.ToUpper(string(e)), "_") + "_" + setting
+}
+
+// RegistryFromEnv returns the registry configured for the ecosystem by the
+// OSSF_REGISTRY_<ECOSYSTEM>_{URL,TOKEN,USERNAME,PASSWORD} variables, looked
+// up using getenv. The ecosystem name is upper-cased, with runs of characters
+// other than letters and digits replaced by '_' (e.g. OSSF_REGISTRY_NPM_TOKEN).
+func RegistryFromEnv(e pkgecosystem.Ecosystem, getenv func(string) string) (Registry, error) {
+	r := Registry{
+		URL:      getenv(registryEnvName(e, "URL")),
+		Token:    getenv(registryEnvName(e, "TOKEN")),
+		Username: getenv(registryEnvName(e, "USERNAME")),
+		Password: getenv(registryEnvName(e, "PASSWORD")),
+	}
+	if err := r.Validate(); err != nil {
+		return Registry{}, fmt.Errorf("%s registry: %w", e, err)
+	}
+	return r, nil
+}
+
+/*
+ConfigureRegistries sets the registry of each supported ecosystem from the
+environment (see RegistryFromEnv), so that subsequent calls to Manager return
+a PkgManager which uses the configured registry. It should be called once at
+startup, before any PkgManager is used.
+
+Credentials are only used by the host process to download packages; they are
+never passed to the analysis sandboxes. Packages from a configured registry should
+therefore be downloaded and analyzed as local packages (see Registry.IsDefault).
+*/
+func ConfigureRegistries(getenv func(string) string) error {
+	var errs []error
+	for e, manager := range supportedPkgManagers {
+		r, err := RegistryFromEnv(e, getenv)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !r.IsDefault() {
+			supportedPkgManagers[e] = manager.WithRegistry(r)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ConfigureDownloader makes every supported ecosystem fetch package archives
+// with d (see WithDownloader).
+func ConfigureDownloader(d Downloader) {
+	for e, manager := range supportedPkgManagers {
+		supportedPkgManagers[e] = manager.WithDownloader(d)
+	}
+}
+
+// Registries returns the registries configured for each supported ecosystem,
+// omitting those which use the public registry without credentials.
+func Registries() map[pkgecosystem.Ecosystem]Registry {
+	registries := map[pkgecosystem.Ecosystem]Registry{}
+	for e, manager := range supportedPkgManagers {
+		if !manager.registry.IsDefault() {
+			registries[e] = manager.registry
+		}
+	}
+	return registries
+}
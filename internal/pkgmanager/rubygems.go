@@ -2,8 +2,6 @@ package pkgmanager
 
 import (
 	"encoding/json"
-	"fmt"
-	"net/http"
 
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
@@ -12,8 +10,8 @@ type rubygemsJSON struct {
 	Version string `json:"version"`
 }
 
-func getRubyGemsLatest(pkg string) (string, error) {
-	resp, err := http.Get(fmt.Sprintf("https://rubygems.org/api/v1/gems/%s.json", pkg))
+func getRubyGemsLatest(r Registry, pkg string) (string, error) {
+	resp, err := r.get(r.endpoint("/api/v1/gems/%s.json", pkg))
 	if err != nil {
 		return "", err
 	}
@@ -29,9 +27,9 @@ func getRubyGemsLatest(pkg string) (string, error) {
 	return details.Version, nil
 }
 
-func getRubyGemsArchiveURL(pkgName, version string) (string, error) {
-	pkgURL := fmt.Sprintf("https://rubygems.org/gems/%v-%v.gem", pkgName, version)
-	resp, err := http.Get(pkgURL)
+func getRubyGemsArchiveURL(r Registry, pkgName, version string) (string, error) {
+	pkgURL := r.endpoint("/gems/%v-%v.gem", pkgName, version)
+	resp, err := r.get(pkgURL)
 	if err != nil {
 		return "", err
 	}
@@ -41,8 +39,10 @@ func getRubyGemsArchiveURL(pkgName, version string) (string, error) {
 }
 
 var rubygemsPkgManager = PkgManager{
-	ecosystem:       pkgecosystem.RubyGems,
-	latestVersion:   getRubyGemsLatest,
-	archiveURL:      getRubyGemsArchiveURL,
-	archiveFilename: defaultArchiveFilename,
+	ecosystem:          pkgecosystem.RubyGems,
+	defaultRegistryURL: "https://rubygems.org",
+	latestVersion:      getRubyGemsLatest,
+	archiveURL:         getRubyGemsArchiveURL,
+	archiveFilename:    defaultArchiveFilename,
+	runtime:            "ruby",
 }
@@ -0,0 +1,212 @@
+package pkgmanager
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"unicode"
+)
+
+var (
+	ErrVersionListingNotSupported = errors.New("version listing not supported")
+	ErrUnknownTag                 = errors.New("unknown version tag")
+)
+
+// PrereleaseTag can be given to Resolve instead of a version to resolve the most
+// recently published pre-release of a package (see Versions.LatestPrerelease), for
+// ecosystems such as PyPI which do not tag versions. A tag of the package with
+// the same name takes precedence.
+const PrereleaseTag = "prerelease"
+
+// Versions lists the published versions of a package. Malicious code is sometimes
+// published only as a pre-release or under a tag other than the default one, so
+// that monitoring of the latest version misses it.
+type Versions struct {
+	// Latest is the version installed when no version is given, e.g. the version
+	// tagged "latest" on NPM, or the latest stable release on PyPI.
+	Latest string
+	// Tags maps the tags of the package (e.g. the NPM dist-tags "next", "beta"
+	// or "canary") to the versions they refer to. It is empty for ecosystems
+	// without tags.
+	Tags map[string]string
+	// All lists every published version, ordered by the time it was published
+	// (oldest first).
+	All []string
+	// Prereleases lists the versions in All which are pre-releases (e.g.
+	// "2.0.0-beta.1" on NPM, or "2.0rc1" on PyPI), in the same order.
+	Prereleases []string
+}
+
+// LatestPrerelease returns the most recently published pre-release, or the empty
+// string if there is none.
+func (v *Versions) LatestPrerelease() string {
+	if len(v.Prereleases) == 0 {
+		return ""
+	}
+	return v.Prereleases[len(v.Prereleases)-1]
+}
+
+// SupportsVersionListing returns whether Versions is implemented for the ecosystem.
+func (p *PkgManager) SupportsVersionListing() bool {
+	return p.listVersions != nil
+}
+
+// Versions returns the published versions and tags of the named package. If the
+// ecosystem does not support version listing, ErrVersionListingNotSupported is
+// returned.
+func (p *PkgManager) Versions(name string) (*Versions, error) {
+	if p.listVersions == nil {
+		return nil, fmt.Errorf("%w for %s", ErrVersionListingNotSupported, p.Ecosystem())
+	}
+	return p.listVersions(p.resolvedRegistry(), normalizePkgName(name))
+}
+
+/*
+Resolve returns the package for the given version of name, which may also be a tag
+of the package (e.g. "next" or "beta" for NPM), or PrereleaseTag for its most
+recently published pre-release. If the tag is not found, an error wrapping
+ErrUnknownTag is returned.
+
+Versions that start with a digit (optionally after a "v") are not tags, and are used
+as they are without checking that they exist. All versions are used as they are if
+the ecosystem does not support version listing.
+*/
+func (p *PkgManager) Resolve(name, version string) (*Pkg, error) {
+	if !isVersionTag(version) || p.listVersions == nil {
+		return p.Package(name, version), nil
+	}
+	versions, err := p.Versions(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", name, err)
+	}
+	tagged, ok := versions.Tags[version]
+	if !ok && version == PrereleaseTag {
+		tagged = versions.LatestPrerelease()
+	}
+	if tagged == "" {
+		return nil, fmt.Errorf("%w %q for %s", ErrUnknownTag, version, name)
+	}
+	return p.Package(name, tagged), nil
+}
+
+// isVersionTag returns whether version is a tag rather than a version, i.e. it does
+// not start with a digit, or with "v" and a digit.
+func isVersionTag(version string) bool {
+	runes := []rune(version)
+	if len(runes) > 1 && runes[0] == 'v' {
+		runes = runes[1:]
+	}
+	return len(runes) > 0 && !unicode.IsDigit(runes[0])
+}
+
+// publishedVersion is a version with the time it was published, as an RFC 3339
+// timestamp, so that versions can be ordered by publication.
+type publishedVersion struct {
+	version, time string
+}
+
+// newVersions returns the Versions of a package with the given latest version and
+// tags, and published versions, using isPrerelease to find the pre-releases.
+func newVersions(latest string, tags map[string]string, published []publishedVersion, isPrerelease func(string) bool) *Versions {
+	slices.SortFunc(published, func(a, b publishedVersion) int {
+		if c := cmp.Compare(a.time, b.time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.version, b.version)
+	})
+	v := &Versions{Latest: latest, Tags: tags}
+	for _, p := range published {
+		v.All = append(v.All, p.version)
+		if isPrerelease(p.version) {
+			v.Prereleases = append(v.Prereleases, p.version)
+		}
+	}
+	return v
+}
+
+// npmPackageTimesJSON represents the versions of a package, and the times they
+// were published, listed in the NPM registry response when package information
+// is requested.
+type npmPackageTimesJSON struct {
+	npmPackageVersionsJSON
+	Time map[string]string `json:"time"`
+}
+
+func getNPMVersions(r Registry, pkg string) (*Versions, error) {
+	resp, err := r.get(r.endpoint("/%s", pkg))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var details npmPackageTimesJSON
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, err
+	}
+	published := make([]publishedVersion, 0, len(details.Versions))
+	for v := range details.Versions {
+		published = append(published, publishedVersion{version: v, time: details.Time[v]})
+	}
+	tags := details.DistTags
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	return newVersions(tags["latest"], tags, published, isNPMPrerelease), nil
+}
+
+// isNPMPrerelease returns whether version is a semantic version with pre-release
+// identifiers, e.g. "2.0.0-beta.1".
+func isNPMPrerelease(version string) bool {
+	v, ok := parseSemver(version)
+	return ok && len(v.prerelease) > 0
+}
+
+// pypiReleasesJSON represents the releases of a package listed in the PyPI web
+// API response when package information is requested.
+type pypiReleasesJSON struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Releases map[string][]struct {
+		UploadTime string `json:"upload_time_iso_8601"`
+	} `json:"releases"`
+}
+
+func getPyPIVersions(r Registry, pkg string) (*Versions, error) {
+	resp, err := r.get(r.endpoint("/pypi/%s/json", pkg))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var details pypiReleasesJSON
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, err
+	}
+	published := make([]publishedVersion, 0, len(details.Releases))
+	for v, files := range details.Releases {
+		// A release is published when its first file is uploaded.
+		p := publishedVersion{version: v}
+		for _, f := range files {
+			if p.time == "" || f.UploadTime < p.time {
+				p.time = f.UploadTime
+			}
+		}
+		published = append(published, p)
+	}
+	return newVersions(details.Info.Version, map[string]string{}, published, isPyPIPrerelease), nil
+}
+
+// pypiPrereleasePattern matches the pre-release (e.g. "a1", "b2", "rc1") and
+// development release (e.g. ".dev0") segments of PEP 440 versions, including
+// their alternative spellings (e.g. "alpha", "-preview").
+var pypiPrereleasePattern = regexp.MustCompile(`(?i)\d[-_.]?(a|b|c|rc|alpha|beta|pre|preview|dev)[-_.]?\d*([-_.+]|$)`)
+
+// isPyPIPrerelease returns whether version is a pre-release or development
+// release, which pip does not install unless asked to.
+func isPyPIPrerelease(version string) bool {
+	return pypiPrereleasePattern.MatchString(version)
+}
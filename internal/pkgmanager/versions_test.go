@@ -0,0 +1,128 @@
+package pkgmanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestNPMVersions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pkg" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{
+			"dist-tags": {"latest": "1.1.0", "next": "2.0.0-beta.2", "canary": "2.0.0-canary.ab12"},
+			"versions": {"1.0.0": {}, "1.1.0": {}, "2.0.0-beta.1": {}, "2.0.0-beta.2": {}, "2.0.0-canary.ab12": {}},
+			"time": {
+				"created": "2024-01-01T00:00:00.000Z",
+				"1.0.0": "2024-01-01T00:00:00.000Z",
+				"2.0.0-beta.1": "2024-02-01T00:00:00.000Z",
+				"1.1.0": "2024-03-01T00:00:00.000Z",
+				"2.0.0-beta.2": "2024-04-01T00:00:00.000Z",
+				"2.0.0-canary.ab12": "2024-05-01T00:00:00.000Z"
+			}
+		}`)
+	}))
+	defer srv.Close()
+	manager := Manager(pkgecosystem.NPM).WithRegistry(Registry{URL: srv.URL})
+
+	got, err := manager.Versions("pkg")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	want := &Versions{
+		Latest:      "1.1.0",
+		Tags:        map[string]string{"latest": "1.1.0", "next": "2.0.0-beta.2", "canary": "2.0.0-canary.ab12"},
+		All:         []string{"1.0.0", "2.0.0-beta.1", "1.1.0", "2.0.0-beta.2", "2.0.0-canary.ab12"},
+		Prereleases: []string{"2.0.0-beta.1", "2.0.0-beta.2", "2.0.0-canary.ab12"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Versions() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		version string
+		want    string
+		wantErr error
+	}{
+		{version: "1.0.0", want: "1.0.0"},
+		{version: "v1.0.0", want: "v1.0.0"},
+		{version: "next", want: "2.0.0-beta.2"},
+		{version: "canary", want: "2.0.0-canary.ab12"},
+		{version: PrereleaseTag, want: "2.0.0-canary.ab12"},
+		{version: "beta", wantErr: ErrUnknownTag},
+	}
+	for _, tt := range tests {
+		pkg, err := manager.Resolve("pkg", tt.version)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Resolve(%q) error = %v, want %v", tt.version, err, tt.wantErr)
+		}
+		if err == nil && pkg.Version() != tt.want {
+			t.Errorf("Resolve(%q) version = %q, want %q", tt.version, pkg.Version(), tt.want)
+		}
+	}
+}
+
+func TestPyPIVersions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pypi/pkg/json" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{
+			"info": {"version": "1.0"},
+			"releases": {
+				"0.9.dev0": [{"upload_time_iso_8601": "2024-01-01T00:00:00Z"}],
+				"1.0": [{"upload_time_iso_8601": "2024-02-02T00:00:00Z"}, {"upload_time_iso_8601": "2024-02-01T00:00:00Z"}],
+				"1.0.post1": [{"upload_time_iso_8601": "2024-02-15T00:00:00Z"}],
+				"2.0rc1": [{"upload_time_iso_8601": "2024-03-01T00:00:00Z"}],
+				"2.0b1": [{"upload_time_iso_8601": "2024-02-20T00:00:00Z"}]
+			}
+		}`)
+	}))
+	defer srv.Close()
+	manager := Manager(pkgecosystem.PyPI).WithRegistry(Registry{URL: srv.URL})
+
+	got, err := manager.Versions("pkg")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	want := &Versions{
+		Latest:      "1.0",
+		Tags:        map[string]string{},
+		All:         []string{"0.9.dev0", "1.0", "1.0.post1", "2.0b1", "2.0rc1"},
+		Prereleases: []string{"0.9.dev0", "2.0b1", "2.0rc1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Versions() = %+v, want %+v", got, want)
+	}
+
+	pkg, err := manager.Resolve("pkg", PrereleaseTag)
+	if err != nil {
+		t.Fatalf("Resolve(%q) error = %v", PrereleaseTag, err)
+	}
+	if pkg.Version() != "2.0rc1" {
+		t.Errorf("Resolve(%q) version = %q, want %q", PrereleaseTag, pkg.Version(), "2.0rc1")
+	}
+}
+
+func TestVersionsNotSupported(t *testing.T) {
+	manager := Manager(pkgecosystem.CratesIO)
+	if manager.SupportsVersionListing() {
+		t.Fatal("SupportsVersionListing() = true, want false")
+	}
+	if _, err := manager.Versions("pkg"); !errors.Is(err, ErrVersionListingNotSupported) {
+		t.Errorf("Versions() error = %v, want %v", err, ErrVersionListingNotSupported)
+	}
+	// Tags are passed through as versions
+	if pkg, err := manager.Resolve("pkg", "next"); err != nil || pkg.Version() != "next" {
+		t.Errorf("Resolve(\"next\") = %v, %v, want version \"next\"", pkg, err)
+	}
+}
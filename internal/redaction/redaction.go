@@ -0,0 +1,92 @@
+// Package redaction masks secret values in data captured during analysis,
+// so that analysis results can be stored and shared safely.
+package redaction
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Types of secret values that are redacted by default.
+const (
+	AWSAccessKeyID = "aws_access_key_id"
+	GitHubToken    = "github_token"
+	NPMToken       = "npm_token"
+	SlackToken     = "slack_token"
+	GoogleAPIKey   = "google_api_key"
+	JWT            = "jwt"
+	PrivateKey     = "private_key"
+)
+
+type rule struct {
+	secretType string
+	pattern    *regexp.Regexp
+}
+
+var defaultRules = []rule{
+	{PrivateKey, regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{AWSAccessKeyID, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{GitHubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{NPMToken, regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{SlackToken, regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{GoogleAPIKey, regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{JWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]+`)},
+}
+
+// Redaction records that one or more values of a type were redacted.
+type Redaction struct {
+	Type  string
+	Count int
+}
+
+// Redactor replaces secret values with a placeholder that identifies the
+// type of the secret, but not its value. The zero value is not usable;
+// create a Redactor with New.
+type Redactor struct {
+	rules []rule
+}
+
+// New returns a Redactor that matches common secret formats.
+func New() *Redactor {
+	return &Redactor{rules: append([]rule{}, defaultRules...)}
+}
+
+// AddSentinel makes the Redactor redact all occurrences of the given value
+// (e.g. a fake credential seeded into the sandbox), recording them as secretType.
+// Sentinels are matched before the default patterns. Empty values are ignored.
+func (r *Redactor) AddSentinel(secretType, value string) {
+	if value == "" {
+		return
+	}
+	sentinel := rule{secretType, regexp.MustCompile(regexp.QuoteMeta(value))}
+	r.rules = append([]rule{sentinel}, r.rules...)
+}
+
+// Placeholder returns the string that replaces redacted values of the given type.
+func Placeholder(secretType string) string {
+	return "[REDACTED:" + secretType + "]"
+}
+
+// Redact returns a copy of data with all secret values replaced by their
+// placeholder, along with the number of values redacted for each type,
+// sorted by type. If nothing was redacted, data is returned unchanged and
+// the list of redactions is empty.
+func (r *Redactor) Redact(data []byte) ([]byte, []Redaction) {
+	counts := map[string]int{}
+	for _, rl := range r.rules {
+		placeholder := []byte(Placeholder(rl.secretType))
+		data = rl.pattern.ReplaceAllFunc(data, func([]byte) []byte {
+			counts[rl.secretType]++
+			return placeholder
+		})
+	}
+
+	redactions := make([]Redaction, 0, len(counts))
+	for secretType, count := range counts {
+		redactions = append(redactions, Redaction{Type: secretType, Count: count})
+	}
+	sort.Slice(redactions, func(i, j int) bool {
+		return redactions[i].Type < redactions[j].Type
+	})
+	return data, redactions
+}
@@ -0,0 +1,264 @@
+/*
+Package report aggregates the analyses of many packages (e.g. those of a batch, see
+worker.AnalyzeBatch) into a single Report, which summarises which detectors were
+triggered across the whole scan, where the packages connected to, which dangerous
+modules they imported, and which packages are likely malicious.
+*/
+package report
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/artifact"
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/internal/verdict"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// DefaultTopN is the default number of network destinations and dangerous
+// imports listed in a Report.
+const DefaultTopN = 10
+
+// dangerousModules are the modules (other than the Node.js network modules, see
+// detections.IsNodeNetworkModule) that give code the ability to run commands,
+// evaluate code or load native code, and so are commonly imported by malware.
+var dangerousModules = map[string]bool{
+	// Node.js built-in modules
+	"child_process":  true,
+	"vm":             true,
+	"worker_threads": true,
+	"inspector":      true,
+	"v8":             true,
+	// Python modules
+	"subprocess": true,
+	"socket":     true,
+	"ctypes":     true,
+	"pty":        true,
+	"marshal":    true,
+	"urllib":     true,
+	"requests":   true,
+}
+
+// Report summarises the analyses of many packages. The counts of each list are
+// the number of packages, not of occurrences, and lists are ordered from the
+// largest count to the smallest.
+type Report struct {
+	// Packages is the number of packages that were summarised.
+	Packages int `json:"packages"`
+	// DynamicSkipped is the number of packages that were only analysed statically,
+	// because the dynamic analysis sandbox was unavailable.
+	DynamicSkipped int `json:"dynamic_skipped"`
+	// Labels is the number of packages with each verdict label.
+	Labels map[verdict.Label]int `json:"labels"`
+	// Detectors lists the verdict rules found in at least one package.
+	Detectors []Count `json:"detectors"`
+	// NetworkDestinations lists the most common destinations of the connections
+	// made during dynamic analysis, as host:port, where the host is the first
+	// hostname or TLS server name seen for the address, or the address itself.
+	NetworkDestinations []Count `json:"network_destinations"`
+	// DangerousImports lists the most common imports of modules that are used to
+	// run commands, evaluate code, or access the network (e.g. child_process).
+	DangerousImports []Count `json:"dangerous_imports"`
+	// Malicious lists the packages labelled verdict.LikelyMalicious, from the
+	// largest score to the smallest.
+	Malicious []Flagged `json:"malicious"`
+}
+
+// Count is the number of packages that have some property, such as a detector
+// or network destination given by Name.
+type Count struct {
+	Name     string `json:"name"`
+	Packages int    `json:"packages"`
+}
+
+// Flagged is a package which was flagged by its verdict.
+type Flagged struct {
+	Package analysisrun.Key `json:"package"`
+	Score   float64         `json:"score"`
+	// Rules lists the rules that contributed to the verdict, from the largest
+	// contribution to the smallest.
+	Rules []string `json:"rules"`
+}
+
+type config struct {
+	topN   int
+	scorer *verdict.Scorer
+}
+
+type Option interface {
+	set(*config)
+}
+
+type option func(*config)
+
+func (o option) set(c *config) { o(c) }
+
+// TopN sets the number of network destinations and dangerous imports listed in
+// the Report. If n is zero or less, all of them are listed.
+func TopN(n int) Option {
+	return option(func(c *config) {
+		c.topN = n
+	})
+}
+
+// Scorer sets the Scorer used to compute the verdict of packages whose analysis
+// does not already have one. By default, verdict.New() is used.
+func Scorer(s *verdict.Scorer) Option {
+	return option(func(c *config) {
+		c.scorer = s
+	})
+}
+
+// Summarize aggregates the analyses of packages into a Report. Nil analyses are
+// skipped, so that the results of a batch can be passed in directly even if some
+// packages failed.
+func Summarize(analyses []*artifact.Artifact, opts ...Option) *Report {
+	c := config{topN: DefaultTopN}
+	for _, o := range opts {
+		o.set(&c)
+	}
+	if c.scorer == nil {
+		c.scorer = verdict.New()
+	}
+
+	r := &Report{Labels: make(map[verdict.Label]int)}
+	detectors := newCounter()
+	destinations := newCounter()
+	imports := newCounter()
+
+	for _, a := range analyses {
+		if a == nil {
+			continue
+		}
+		r.Packages++
+		if a.DynamicSkipped != "" {
+			r.DynamicSkipped++
+		}
+
+		v := a.Verdict
+		if v == nil {
+			var static *staticapi.Results
+			if a.Static != nil {
+				static = &a.Static.Results
+			}
+			scored := c.scorer.Score(static, a.Dynamic)
+			if a.DynamicSkipped != "" {
+				scored.AddLimits(analysisrun.LimitDynamicSkipped)
+			}
+			v = &scored
+		}
+		r.Labels[v.Label]++
+		var rules []string
+		for _, contribution := range v.Contributions {
+			rules = append(rules, contribution.Rule)
+		}
+		detectors.addPackage(rules)
+		if v.Label == verdict.LikelyMalicious {
+			r.Malicious = append(r.Malicious, Flagged{Package: a.Package, Score: v.Score, Rules: rules})
+		}
+
+		destinations.addPackage(networkDestinations(a.Dynamic))
+
+		if a.Static != nil {
+			var modules []string
+			for _, f := range a.Static.Results.Files {
+				for _, module := range f.Imports {
+					if name := dangerousModule(module); name != "" {
+						modules = append(modules, name)
+					}
+				}
+			}
+			imports.addPackage(modules)
+		}
+	}
+
+	r.Detectors = detectors.counts(0)
+	r.NetworkDestinations = destinations.counts(c.topN)
+	r.DangerousImports = imports.counts(c.topN)
+	slices.SortStableFunc(r.Malicious, func(a, b Flagged) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return r
+}
+
+// networkDestinations returns the destinations of the sockets of all phases of
+// the dynamic analysis d, which may be nil. Unix sockets are not included.
+func networkDestinations(d *analysisrun.DynamicAnalysisData) []string {
+	if d == nil {
+		return nil
+	}
+	var destinations []string
+	for _, s := range d.MergedStraceSummary().Sockets {
+		if s.Family == analysisrun.FamilyUnix || s.Address == "" {
+			continue
+		}
+		host := s.Address
+		if len(s.Hostnames) > 0 {
+			host = s.Hostnames[0]
+		} else if len(s.ServerNames) > 0 {
+			host = s.ServerNames[0]
+		}
+		destinations = append(destinations, net.JoinHostPort(host, strconv.Itoa(s.Port)))
+	}
+	return destinations
+}
+
+// dangerousModule returns the name of the dangerous module imported by module
+// (without any "node:" prefix, subpath or submodule), or the empty string if the
+// module is not dangerous.
+func dangerousModule(module string) string {
+	if builtin := detections.NodeBuiltinModule(module); builtin != "" {
+		if dangerousModules[builtin] || detections.IsNodeNetworkModule(builtin) {
+			return builtin
+		}
+		return ""
+	}
+	// e.g. urllib.request
+	name, _, _ := strings.Cut(module, ".")
+	if dangerousModules[name] {
+		return name
+	}
+	return ""
+}
+
+// counter counts the number of packages that have each name.
+type counter map[string]int
+
+func newCounter() counter {
+	return make(counter)
+}
+
+// addPackage counts the distinct names of a single package.
+func (c counter) addPackage(names []string) {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			c[name]++
+		}
+	}
+}
+
+// counts returns the n names with the most packages (or all of them if n <= 0),
+// ordered by count and then by name.
+func (c counter) counts(n int) []Count {
+	counts := make([]Count, 0, len(c))
+	for name, packages := range c {
+		counts = append(counts, Count{Name: name, Packages: packages})
+	}
+	slices.SortFunc(counts, func(a, b Count) int {
+		if a.Packages != b.Packages {
+			return cmp.Compare(b.Packages, a.Packages)
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if n > 0 && len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
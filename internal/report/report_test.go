@@ -0,0 +1,125 @@
+package report
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/artifact"
+	"github.com/ossf/package-analysis/internal/verdict"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+func TestSummarize(t *testing.T) {
+	key := func(name string) analysisrun.Key {
+		return analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: name, Version: "1.0.0"}
+	}
+	static := func(imports ...string) *staticapi.Record {
+		return &staticapi.Record{Results: staticapi.Results{Files: []staticapi.FileResult{{Filename: "index.js", Imports: imports}}}}
+	}
+	dynamic := func(sockets ...analysisrun.SocketResult) *analysisrun.DynamicAnalysisData {
+		return &analysisrun.DynamicAnalysisData{StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {Sockets: sockets},
+		}}
+	}
+	evil := analysisrun.SocketResult{Family: "AF_INET", Address: "192.0.2.1", Port: 443, Hostnames: []string{"evil.example.com"}}
+	registry := analysisrun.SocketResult{Family: "AF_INET", Address: "104.16.0.1", Port: 443, ServerNames: []string{"registry.npmjs.org"}}
+	unix := analysisrun.SocketResult{Family: "AF_UNIX", Address: "/var/run/nscd/socket"}
+
+	analyses := []*artifact.Artifact{
+		{
+			Package: key("benign"),
+			Static:  static("lodash", "node:fs"),
+			Dynamic: dynamic(registry, unix),
+			Verdict: &verdict.Verdict{Label: verdict.Benign},
+		},
+		{
+			Package:        key("static-only"),
+			Static:         static("lodash"),
+			DynamicSkipped: "sandbox unavailable",
+			Verdict:        &verdict.Verdict{Label: verdict.Benign},
+		},
+		nil, // failed to analyze
+		{
+			Package: key("suspicious"),
+			Static:  static("child_process", "node:http", "https"),
+			Dynamic: dynamic(registry, evil),
+			Verdict: &verdict.Verdict{Label: verdict.Suspicious, Score: 4, Contributions: []verdict.Contribution{
+				{Rule: verdict.RuleObfuscation, Score: 2}, {Rule: verdict.RuleIndirectEval, Score: 2},
+			}},
+		},
+		{
+			Package: key("malicious"),
+			Static:  static("child_process", "node:child_process", "net"),
+			Dynamic: dynamic(evil, analysisrun.SocketResult{Family: "AF_INET", Address: "198.51.100.7", Port: 4444}),
+			Verdict: &verdict.Verdict{Label: verdict.LikelyMalicious, Score: 12, Contributions: []verdict.Contribution{
+				{Rule: verdict.RuleReverseShell, Score: 10}, {Rule: verdict.RuleObfuscation, Score: 2},
+			}},
+		},
+	}
+
+	want := &Report{
+		Packages:       4,
+		DynamicSkipped: 1,
+		Labels:         map[verdict.Label]int{verdict.Benign: 2, verdict.Suspicious: 1, verdict.LikelyMalicious: 1},
+		Detectors: []Count{
+			{Name: verdict.RuleObfuscation, Packages: 2},
+			{Name: verdict.RuleIndirectEval, Packages: 1},
+			{Name: verdict.RuleReverseShell, Packages: 1},
+		},
+		NetworkDestinations: []Count{
+			{Name: "evil.example.com:443", Packages: 2},
+			{Name: "registry.npmjs.org:443", Packages: 2},
+			{Name: "198.51.100.7:4444", Packages: 1},
+		},
+		DangerousImports: []Count{
+			{Name: "child_process", Packages: 2},
+			{Name: "http", Packages: 1},
+			{Name: "https", Packages: 1},
+			{Name: "net", Packages: 1},
+		},
+		Malicious: []Flagged{
+			{Package: key("malicious"), Score: 12, Rules: []string{verdict.RuleReverseShell, verdict.RuleObfuscation}},
+		},
+	}
+
+	got := Summarize(analyses)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// The report must round-trip through JSON.
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, want) {
+		t.Errorf("decoded report =\n%+v\nwant\n%+v", &decoded, want)
+	}
+
+	if got := Summarize(analyses, TopN(1)); len(got.NetworkDestinations) != 1 || len(got.DangerousImports) != 1 {
+		t.Errorf("Summarize(TopN(1)) listed %d destinations and %d imports, want 1 of each",
+			len(got.NetworkDestinations), len(got.DangerousImports))
+	}
+}
+
+func TestSummarizeScoresMissingVerdicts(t *testing.T) {
+	analyses := []*artifact.Artifact{{
+		Package: analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "unscored", Version: "1.0.0"},
+		Static: &staticapi.Record{Results: staticapi.Results{Files: []staticapi.FileResult{{
+			Filename:      "index.js",
+			ReverseShells: []staticapi.ReverseShell{{SocketWired: true}},
+		}}}},
+	}}
+
+	got := Summarize(analyses, Scorer(verdict.New(verdict.Thresholds(1, 5))))
+	if got.Labels[verdict.LikelyMalicious] != 1 || len(got.Malicious) != 1 {
+		t.Errorf("Summarize() labels = %v, malicious = %v, want one likely malicious package", got.Labels, got.Malicious)
+	}
+}
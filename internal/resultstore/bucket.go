@@ -0,0 +1,91 @@
+package resultstore
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+)
+
+// BucketStore is a ResultStore which saves results to a cloud storage bucket
+// (e.g. GCS or S3) or a file:// URL, using gocloud.dev/blob.
+type BucketStore struct {
+	bucket    *url.URL
+	keyPrefix string
+	config
+}
+
+// New creates a new BucketStore instance with the given bucket URL and options.
+// If the bucket URL is invalid, a nil pointer is returned.
+func New(bucket string, options ...Option) *BucketStore {
+	bucketURL, err := url.Parse(bucket)
+	if err != nil {
+		return nil
+	}
+
+	var pathPrefix string
+
+	if bucketURL.Scheme == "file" {
+		// https://github.com/google/go-cloud/issues/3294
+		params := bucketURL.Query()
+		params.Set("no_tmp_dir", "true")
+		bucketURL.RawQuery = params.Encode()
+	} else {
+		// for non-file schemes, we need to separate the path because OpenBucket
+		// doesn't support turning the path into a key prefix
+		pathPrefix = bucketURL.Path
+		bucketURL.Path = ""
+		bucketURL.RawPath = ""
+	}
+
+	return &BucketStore{
+		bucket:    bucketURL,
+		keyPrefix: pathPrefix,
+		config:    newConfig(options),
+	}
+}
+
+func (bs *BucketStore) String() string {
+	// label when bucket path is constructed from package name
+	if bs.constructPath {
+		return bs.bucket.JoinPath(bs.keyPrefix, "<dynamic path>").String()
+	}
+
+	return bs.bucket.JoinPath(bs.keyPrefix).String()
+}
+
+func (bs *BucketStore) openBucket(ctx context.Context) (*blob.Bucket, error) {
+	return blob.OpenBucket(ctx, bs.bucket.String())
+}
+
+// Write implements ResultStore. If contents cannot be read, the object is not
+// created.
+func (bs *BucketStore) Write(ctx context.Context, p Pkg, name string, contents io.Reader) error {
+	bkt, err := bs.openBucket(ctx)
+	if err != nil {
+		return err
+	}
+	defer bkt.Close()
+
+	uploadPath := bs.generateKey(bs.keyPrefix, p, name)
+	slog.InfoContext(ctx, "Uploading results", "bucket", bs.bucket.String(), "path", uploadPath)
+
+	// cancelling the context passed to NewWriter aborts the write
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := bkt.NewWriter(writeCtx, uploadPath, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, contents); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
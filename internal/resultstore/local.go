@@ -0,0 +1,57 @@
+package resultstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// LocalStore is a ResultStore which saves results as files in a directory on the
+// local filesystem, e.g. for testing or running analysis offline.
+type LocalStore struct {
+	dir string
+	config
+}
+
+// NewLocal creates a LocalStore which saves results in dir, which is created
+// when the first result is written if it does not exist.
+func NewLocal(dir string, options ...Option) *LocalStore {
+	return &LocalStore{dir: dir, config: newConfig(options)}
+}
+
+func (ls *LocalStore) String() string {
+	return ls.dir
+}
+
+// Write implements ResultStore. The result is written to a temporary file which
+// is renamed once complete, so that a partial result is never left behind.
+func (ls *LocalStore) Write(ctx context.Context, p Pkg, name string, contents io.Reader) error {
+	key := filepath.FromSlash(ls.generateKey("", p, name))
+	if !filepath.IsLocal(key) {
+		return fmt.Errorf("result path %q is outside %s", key, ls.dir)
+	}
+	dest := filepath.Join(ls.dir, key)
+	slog.InfoContext(ctx, "Writing results", "path", dest)
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(dest), ".tmp-"+filepath.Base(dest)+"-")
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(f, contents)
+	if err := errors.Join(copyErr, f.Close()); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), dest); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package resultstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// multiStore is a ResultStore which writes each result to several stores.
+type multiStore []ResultStore
+
+// Multi returns a ResultStore which writes each result to all of the given stores,
+// e.g. to a bucket and a local directory. Nil stores are ignored. If there is only
+// one store, it is returned as is.
+//
+// The contents of each result are read into memory so that they can be written to
+// each store. A failure to write to one store does not prevent writing to the
+// others; the errors for all stores that failed are returned.
+func Multi(stores ...ResultStore) ResultStore {
+	var m multiStore
+	for _, s := range stores {
+		if s != nil {
+			m = append(m, s)
+		}
+	}
+	if len(m) == 1 {
+		return m[0]
+	}
+	return m
+}
+
+func (m multiStore) String() string {
+	names := make([]string, len(m))
+	for i, s := range m {
+		names[i] = fmt.Sprint(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Write implements ResultStore.
+func (m multiStore) Write(ctx context.Context, p Pkg, name string, contents io.Reader) error {
+	data, err := io.ReadAll(contents)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, s := range m {
+		if err := s.Write(ctx, p, name, bytes.NewReader(data)); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", s, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,6 +1,9 @@
 package resultstore
 
-import "github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+import (
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
 
 // Pkg describes the various package details used to populate the package part
 // of the analysis results.
@@ -9,4 +12,10 @@ type Pkg interface {
 	EcosystemName() string
 	Name() string
 	Version() string
+	// ArtifactSHA256 returns the SHA256 hash of the analyzed package artifact,
+	// or the empty string if it is not known.
+	ArtifactSHA256() string
+	// GitSource returns the git repository the package was fetched from, or nil
+	// if it was not fetched from git.
+	GitSource() *analysisrun.GitSource
 }
@@ -2,147 +2,142 @@ package resultstore
 
 import (
 	"archive/zip"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/url"
 	"os"
 	"path"
+	"strings"
 	"time"
 
-	"gocloud.dev/blob"
-	_ "gocloud.dev/blob/fileblob"
-	_ "gocloud.dev/blob/gcsblob"
-	_ "gocloud.dev/blob/s3blob"
-
 	"github.com/ossf/package-analysis/internal/pkgmanager"
 	"github.com/ossf/package-analysis/internal/utils"
 	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
 )
 
-type ResultStore struct {
-	bucket        *url.URL
-	keyPrefix     string
+// ResultStore is a destination for analysis results, such as a cloud storage
+// bucket or a local directory. Write saves contents as the result with the given
+// name (e.g. "1.0.0.json") for package p; how the name and package map to a
+// location is up to the implementation (see ConstructPath). The Save* functions
+// in this package encode each kind of result and pass it to Write.
+type ResultStore interface {
+	Write(ctx context.Context, p Pkg, name string, contents io.Reader) error
+}
+
+// config holds the options common to the ResultStore implementations in this package.
+type config struct {
 	constructPath bool
 }
 
 type (
-	Option interface{ set(*ResultStore) }
-	option func(*ResultStore) // option implements Option.
+	Option interface{ set(*config) }
+	option func(*config) // option implements Option.
 )
 
-func (o option) set(sb *ResultStore) { o(sb) }
+func (o option) set(c *config) { o(c) }
 
-// ConstructPath will cause Save() to append a suffix to the base path
+// ConstructPath will cause Write() to append a suffix to the base path
 // based on Pkg.EcosystemName() and Pkg.Name().
 func ConstructPath() Option {
-	return option(func(rs *ResultStore) { rs.constructPath = true })
+	return option(func(c *config) { c.constructPath = true })
 }
 
-// New creates a new ResultStore instance with the given bucket URL and options.
-// If the bucket URL is invalid, a nil pointer is returned.
-func New(bucket string, options ...Option) *ResultStore {
-	bucketURL, err := url.Parse(bucket)
-	if err != nil {
-		return nil
-	}
-
-	var pathPrefix string
-
-	if bucketURL.Scheme == "file" {
-		// https://github.com/google/go-cloud/issues/3294
-		params := bucketURL.Query()
-		params.Set("no_tmp_dir", "true")
-		bucketURL.RawQuery = params.Encode()
-	} else {
-		// for non-file schemes, we need to separate the path because OpenBucket
-		// doesn't support turning the path into a key prefix
-		pathPrefix = bucketURL.Path
-		bucketURL.Path = ""
-		bucketURL.RawPath = ""
-	}
-
-	rs := &ResultStore{
-		bucket:    bucketURL,
-		keyPrefix: pathPrefix,
-	}
-
+func newConfig(options []Option) config {
+	var c config
 	for _, o := range options {
-		o.set(rs)
+		o.set(&c)
 	}
-	return rs
-}
-
-func (rs *ResultStore) String() string {
-	// label when bucket path is constructed from package name
-	if rs.constructPath {
-		return rs.bucket.JoinPath(rs.keyPrefix, "<dynamic path>").String()
-	}
-
-	return rs.bucket.JoinPath(rs.keyPrefix).String()
+	return c
 }
 
 // generateKey creates an identifier key to store an object with.
 // If p is non-nil and the ResultStore was constructed with
 // the ConstructPath() option, then the base key will be prefixed
 // with the ecosystem and name of the given package (in that order).
-// Otherwise, the basename is returned.
-func (rs *ResultStore) generateKey(p Pkg, baseKey string) string {
-	if p != nil && rs.constructPath {
-		return path.Join(rs.keyPrefix, p.EcosystemName(), p.Name(), baseKey)
+// Otherwise, the basename is returned. Both are prefixed with keyPrefix.
+func (c config) generateKey(keyPrefix string, p Pkg, baseKey string) string {
+	if p != nil && c.constructPath {
+		return path.Join(keyPrefix, p.EcosystemName(), p.Name(), baseKey)
 	}
-	return path.Join(rs.keyPrefix, baseKey)
+	return path.Join(keyPrefix, baseKey)
 }
 
-func (rs *ResultStore) openBucket(ctx context.Context) (*blob.Bucket, error) {
-	return blob.OpenBucket(ctx, rs.bucket.String())
-}
-
-func (rs *ResultStore) SaveTempFilesToZip(ctx context.Context, p Pkg, zipName string, tempFileNames []string) error {
-	bkt, err := rs.openBucket(ctx)
-	if err != nil {
-		return err
+// Open returns a ResultStore for the given destination. If dest is a URL with a
+// scheme (e.g. "gs://bucket/path", "s3://bucket" or "file:///tmp/results"), a
+// BucketStore is returned; otherwise dest is taken to be the path of a local
+// directory and a LocalStore is returned. Several destinations may be given,
+// separated by commas, in which case results are written to all of them (see Multi).
+func Open(dest string, options ...Option) (ResultStore, error) {
+	var stores []ResultStore
+	for _, d := range strings.Split(dest, ",") {
+		d = strings.TrimSpace(d)
+		if d == "" {
+			continue
+		}
+		u, err := url.Parse(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result destination %q: %w", d, err)
+		}
+		if u.Scheme == "" {
+			stores = append(stores, NewLocal(d, options...))
+		} else {
+			stores = append(stores, New(d, options...))
+		}
 	}
-	defer bkt.Close()
-
-	uploadPath := rs.generateKey(p, zipName+".zip")
-	slog.InfoContext(ctx, "Uploading results", "bucket", rs.bucket.String(), "path", uploadPath)
-
-	bucketWriter, err := bkt.NewWriter(ctx, uploadPath, nil)
-	if err != nil {
-		return err
+	if len(stores) == 0 {
+		return nil, fmt.Errorf("invalid result destination %q", dest)
 	}
-	defer bucketWriter.Close()
+	return Multi(stores...), nil
+}
+
+// SaveTempFilesToZip saves the temp files with the given names (see utils.OpenTempFile)
+// to rs as a zip archive named zipName + ".zip", with each file named <name>.json.
+func SaveTempFilesToZip(ctx context.Context, rs ResultStore, p Pkg, zipName string, tempFileNames []string) error {
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeTempFilesZip(pw, tempFileNames))
+	}()
 
-	zipWriter := zip.NewWriter(bucketWriter)
-	defer zipWriter.Close()
+	err := rs.Write(ctx, p, zipName+".zip", pr)
+	// unblock the zip writer if Write returned before reading everything
+	pr.Close()
+	<-done
+	return err
+}
 
+func writeTempFilesZip(w io.Writer, tempFileNames []string) error {
+	zipWriter := zip.NewWriter(w)
 	for _, fileName := range tempFileNames {
 		file, err := utils.OpenTempFile(fileName)
 		if err != nil {
 			return err
 		}
 
-		w, err := zipWriter.Create(fileName + ".json")
+		fw, err := zipWriter.Create(fileName + ".json")
 		if err != nil {
+			file.Close()
 			return err
 		}
 
-		if _, err := io.Copy(w, file); err != nil {
-			return err
-		}
-
-		if err = file.Close(); err != nil {
+		_, copyErr := io.Copy(fw, file)
+		if err := errors.Join(copyErr, file.Close()); err != nil {
 			return err
 		}
 	}
-	return nil
+	return zipWriter.Close()
 }
 
-func (rs *ResultStore) SaveAnalyzedPackage(ctx context.Context, manager *pkgmanager.PkgManager, pkg Pkg) error {
+// SaveAnalyzedPackage downloads the archive of pkg and saves it to rs, named by
+// its version and SHA256 hash.
+func SaveAnalyzedPackage(ctx context.Context, rs ResultStore, manager *pkgmanager.PkgManager, pkg Pkg) error {
 	archivePath, err := manager.DownloadArchive(pkg.Name(), pkg.Version(), "")
 	if errors.Is(err, pkgmanager.ErrNoArchiveURL) {
 		slog.WarnContext(ctx, "unable to download archive", "error", err)
@@ -162,43 +157,18 @@ func (rs *ResultStore) SaveAnalyzedPackage(ctx context.Context, manager *pkgmana
 		return err
 	}
 
-	bkt, err := rs.openBucket(ctx)
-	if err != nil {
-		return err
-	}
-	defer bkt.Close()
-
-	uploadPath := rs.generateKey(pkg, pkg.Version()+"-"+hash)
-	slog.InfoContext(ctx, "Uploading analyzed package", "bucket", rs.bucket.String(), "path", uploadPath)
-
 	f, err := os.Open(archivePath)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	w, err := bkt.NewWriter(ctx, uploadPath, nil)
-	if err != nil {
-		return err
-	}
-
-	_, writeErr := io.Copy(w, f)
-	closeErr := w.Close()
-
-	if writeErr != nil {
-		// TODO golang 1.20: use errors.Join(writeErr, closeErr)
-		return writeErr
-	}
-	if closeErr != nil {
-		return closeErr
-	}
-
-	return nil
+	return rs.Write(ctx, pkg, pkg.Version()+"-"+hash, f)
 }
 
-// saveWithFilename marshals the given data to JSON and saves the marshalled data to the bucket,
+// saveWithFilename marshals the given data to JSON and saves the marshalled data to rs,
 // with the given filename / key. No processing is done on the data object.
-func (rs *ResultStore) saveWithFilename(ctx context.Context, p Pkg, data any, filename string) error {
+func saveWithFilename(ctx context.Context, rs ResultStore, p Pkg, data any, filename string) error {
 	if filename == "" {
 		return errors.New("filename cannot be empty")
 	}
@@ -208,28 +178,7 @@ func (rs *ResultStore) saveWithFilename(ctx context.Context, p Pkg, data any, fi
 		return err
 	}
 
-	bkt, err := rs.openBucket(ctx)
-	if err != nil {
-		return err
-	}
-	defer bkt.Close()
-
-	uploadPath := rs.generateKey(p, filename)
-	slog.InfoContext(ctx, "Uploading results", "bucket", rs.bucket.String(), "path", uploadPath)
-
-	w, err := bkt.NewWriter(ctx, uploadPath, nil)
-	if err != nil {
-		return err
-	}
-	if _, err := w.Write(b); err != nil {
-		return err
-	}
-	if err := w.Close(); err != nil {
-		return err
-	}
-
-	return nil
-
+	return rs.Write(ctx, p, filename, bytes.NewReader(b))
 }
 
 // DefaultFilename returns the basename (i.e. without directory-like prefixes) of the default filename (key)
@@ -242,9 +191,9 @@ func DefaultFilename(p Pkg) string {
 	return "results.json"
 }
 
-// SaveDynamicAnalysis wraps the analysis object with the DynamicAnalysisRecord struct and saves it to the bucket
+// SaveDynamicAnalysis wraps the analysis object with the DynamicAnalysisRecord struct and saves it to rs
 // using saveWithFilename. If filename is empty, a default filename (chosen using DefaultFilename) is used.
-func (rs *ResultStore) SaveDynamicAnalysis(ctx context.Context, p Pkg, analysis any, filename string) error {
+func SaveDynamicAnalysis(ctx context.Context, rs ResultStore, p Pkg, analysis any, filename string) error {
 	if filename == "" {
 		filename = DefaultFilename(p)
 	}
@@ -256,18 +205,20 @@ func (rs *ResultStore) SaveDynamicAnalysis(ctx context.Context, p Pkg, analysis
 			Version:   p.Version(),
 		},
 		CreatedTimestamp: time.Now().UTC().Unix(),
+		ArtifactSHA256:   p.ArtifactSHA256(),
+		GitSource:        p.GitSource(),
 		Analysis:         analysis,
 	}
 
-	return rs.saveWithFilename(ctx, p, data, filename)
+	return saveWithFilename(ctx, rs, p, data, filename)
 }
 
-// SaveStaticAnalysis wraps the results object with the Record struct and saves it to the bucket
-// using saveWithFilename. If filename is empty, a default filename (chosen using DefaultFilename) is used.
-func (rs *ResultStore) SaveStaticAnalysis(ctx context.Context, p Pkg, data *staticanalysis.Record, filename string) error {
+// SaveStaticAnalysis saves the static analysis record to rs using saveWithFilename.
+// If filename is empty, a default filename (chosen using DefaultFilename) is used.
+func SaveStaticAnalysis(ctx context.Context, rs ResultStore, p Pkg, data *staticanalysis.Record, filename string) error {
 	if filename == "" {
 		filename = DefaultFilename(p)
 	}
 
-	return rs.saveWithFilename(ctx, p, data, filename)
+	return saveWithFilename(ctx, rs, p, data, filename)
 }
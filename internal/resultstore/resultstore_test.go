@@ -2,13 +2,42 @@ package resultstore
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
+type testPkg struct {
+	name, version, sha256 string
+	git                   *analysisrun.GitSource
+}
+
+func (p testPkg) Ecosystem() pkgecosystem.Ecosystem { return pkgecosystem.NPM }
+func (p testPkg) EcosystemName() string             { return string(pkgecosystem.NPM) }
+func (p testPkg) Name() string                      { return p.name }
+func (p testPkg) Version() string                   { return p.version }
+func (p testPkg) ArtifactSHA256() string            { return p.sha256 }
+func (p testPkg) GitSource() *analysisrun.GitSource { return p.git }
+
+// failingStore is a ResultStore whose Write always fails.
+type failingStore struct{}
+
+var errWriteFailed = errors.New("write failed")
+
+func (failingStore) Write(ctx context.Context, p Pkg, name string, contents io.Reader) error {
+	return errWriteFailed
+}
+
 func TestFileBucket(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -58,3 +87,159 @@ func TestFileBucket(t *testing.T) {
 		t.Errorf("failed to close bucket: %v", err)
 	}
 }
+
+func TestFileBucketWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	rs := New("file://"+tmpDir, ConstructPath())
+
+	if err := rs.Write(context.Background(), testPkg{name: "pkg", version: "1.0.0"}, "1.0.0.json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(tmpDir, "npm", "pkg", "1.0.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "{}" {
+		t.Errorf("Write() wrote %q, want %q", got, "{}")
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []Option
+		pkg      Pkg
+		filename string
+		wantPath string
+		wantErr  bool
+	}{
+		{
+			name:     "basename",
+			pkg:      testPkg{name: "pkg", version: "1.0.0"},
+			filename: "1.0.0.json",
+			wantPath: "1.0.0.json",
+		},
+		{
+			name:     "construct path",
+			options:  []Option{ConstructPath()},
+			pkg:      testPkg{name: "@scope/pkg", version: "1.0.0"},
+			filename: "1.0.0.json",
+			wantPath: filepath.Join("npm", "@scope", "pkg", "1.0.0.json"),
+		},
+		{
+			name:     "outside directory",
+			options:  []Option{ConstructPath()},
+			pkg:      testPkg{name: "../../../pkg", version: "1.0.0"},
+			filename: "1.0.0.json",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "results")
+			rs := NewLocal(dir, tt.options...)
+
+			err := rs.Write(context.Background(), tt.pkg, tt.filename, strings.NewReader("data"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Write() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := os.ReadFile(filepath.Join(dir, tt.wantPath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "data" {
+				t.Errorf("Write() wrote %q, want %q", got, "data")
+			}
+		})
+	}
+}
+
+func TestMulti(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	pkg := testPkg{name: "pkg", version: "1.0.0"}
+
+	rs := Multi(NewLocal(dir1), nil, failingStore{}, NewLocal(dir2))
+	err := rs.Write(context.Background(), pkg, "1.0.0.json", strings.NewReader("data"))
+	if !errors.Is(err, errWriteFailed) {
+		t.Errorf("Write() error = %v, want %v", err, errWriteFailed)
+	}
+
+	// a failing store does not prevent writing to the others
+	for _, dir := range []string{dir1, dir2} {
+		got, err := os.ReadFile(filepath.Join(dir, "1.0.0.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "data" {
+			t.Errorf("Write() wrote %q to %s, want %q", got, dir, "data")
+		}
+	}
+
+	single := NewLocal(dir1)
+	if got := Multi(single, nil); got != single {
+		t.Errorf("Multi() with a single store = %v, want %v", got, single)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		dest    string
+		want    string
+		wantErr bool
+	}{
+		{dest: dir, want: dir},
+		{dest: "file://" + dir, want: "file://" + dir + "?no_tmp_dir=true"},
+		{dest: dir + ", file://" + dir, want: dir + ", file://" + dir + "?no_tmp_dir=true"},
+		{dest: "", wantErr: true},
+		{dest: "gs://bucket/%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			rs, err := Open(tt.dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := fmt.Sprint(rs); got != tt.want {
+				t.Errorf("Open() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveDynamicAnalysis(t *testing.T) {
+	dir := t.TempDir()
+	pkg := testPkg{name: "pkg", version: "1.0.0", sha256: "0123abcd", git: &analysisrun.GitSource{Repository: "https://github.com/user/pkg", Ref: "main", Commit: "0123abcd"}}
+
+	if err := SaveDynamicAnalysis(context.Background(), NewLocal(dir, ConstructPath()), pkg, map[string]int{"a": 1}, ""); err != nil {
+		t.Fatalf("SaveDynamicAnalysis() error = %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "npm", "pkg", DefaultFilename(pkg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record analysisrun.DynamicAnalysisRecord
+	if err := json.Unmarshal(b, &record); err != nil {
+		t.Fatal(err)
+	}
+	wantKey := analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "pkg", Version: "1.0.0"}
+	if record.Package != wantKey {
+		t.Errorf("saved package = %+v, want %+v", record.Package, wantKey)
+	}
+	if record.ArtifactSHA256 != pkg.sha256 {
+		t.Errorf("saved artifact hash = %q, want %q", record.ArtifactSHA256, pkg.sha256)
+	}
+	if !reflect.DeepEqual(record.GitSource, pkg.git) {
+		t.Errorf("saved git source = %+v, want %+v", record.GitSource, pkg.git)
+	}
+	if got := fmt.Sprint(record.Analysis); got != "map[a:1]" {
+		t.Errorf("saved analysis = %s, want map[a:1]", got)
+	}
+}
@@ -0,0 +1,132 @@
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/utils"
+)
+
+const (
+	// Default amounts of the start and end of the log of each run that are kept
+	// when strace is enabled. See LogLimit.
+	defaultLogHeadBytes = 64 * 1024 * 1024
+	defaultLogTailBytes = 16 * 1024 * 1024
+
+	// logCaptureTimeout is the maximum time to wait for the log to be closed by
+	// the sandbox after the container has stopped.
+	logCaptureTimeout = 30 * time.Second
+)
+
+// LogHandler processes the log of a run (see RunResult.Log). It is given the
+// complete log, even if only part of it is retained for RunResult.Log.
+type LogHandler func(log io.Reader)
+
+/*
+logCapture reads the log of a run through a named pipe created in place of the log
+file, so that the log never has to be stored in full. The log is streamed to an
+optional LogHandler while the run is in progress, and only the start and end of
+it are kept. Once the run has finished, the retained part is written to a regular
+file at the original path.
+
+Both ends of the pipe are opened when the capture starts, so that neither the
+sandbox nor the capture blocks waiting for the other. The write end held by the
+capture keeps the log from ending before the sandbox opens it, and is closed by
+finish, after which the log ends once the sandbox has closed it too.
+*/
+type logCapture struct {
+	path   string
+	writer *os.File
+	buf    *utils.HeadTailBuffer
+	done   chan struct{}
+	err    error
+}
+
+func startLogCapture(path string, headBytes, tailBytes int, handler LogHandler) (*logCapture, error) {
+	if err := syscall.Mkfifo(path, 0o666); err != nil {
+		return nil, fmt.Errorf("failed to create log pipe: %w", err)
+	}
+
+	// Opening the read end without O_NONBLOCK would block until there is a
+	// writer, and opening the write end with it fails if there is no reader.
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to open log pipe: %w", err)
+	}
+	w, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		r.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to open log pipe: %w", err)
+	}
+
+	c := &logCapture{
+		path:   path,
+		writer: w,
+		buf:    utils.NewHeadTailBuffer(headBytes, tailBytes),
+		done:   make(chan struct{}),
+	}
+	go c.run(r, handler)
+	return c, nil
+}
+
+func (c *logCapture) run(f *os.File, handler LogHandler) {
+	defer close(c.done)
+	defer f.Close()
+
+	if handler == nil {
+		_, c.err = io.Copy(c.buf, f)
+		return
+	}
+
+	pr, pw := io.Pipe()
+	handlerDone := make(chan struct{})
+	go func() {
+		defer close(handlerDone)
+		handler(pr)
+		// Keep reading in case the handler returned early, so that the log
+		// continues to be captured.
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+
+	_, c.err = io.Copy(io.MultiWriter(c.buf, pw), f)
+	pw.CloseWithError(c.err)
+	<-handlerDone
+}
+
+// finish waits for the log to be closed by the sandbox and replaces the named
+// pipe with the retained part of the log. It must be called after the container
+// has been stopped.
+func (c *logCapture) finish() error {
+	// The log ends once the sandbox has also closed it, or straight away if the
+	// sandbox never opened it.
+	c.writer.Close()
+
+	select {
+	case <-c.done:
+	case <-time.After(logCaptureTimeout):
+		return errors.New("timed out waiting for log to be closed")
+	}
+
+	if err := os.Remove(c.path); err != nil {
+		return fmt.Errorf("failed to remove log pipe: %w", err)
+	}
+	f, err := os.Create(c.path)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer f.Close()
+	if _, err := c.buf.WriteTo(f); err != nil {
+		return fmt.Errorf("failed to write log file: %w", err)
+	}
+
+	if c.err != nil {
+		return fmt.Errorf("failed to read log: %w", c.err)
+	}
+	return nil
+}
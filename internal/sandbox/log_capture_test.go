@@ -0,0 +1,65 @@
+package sandbox
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogCaptureFinish(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		// write opens the log as the sandbox would, if it does
+		write bool
+	}{
+		{name: "never opened"},
+		{name: "empty", write: true},
+		{name: "written", log: "line 0\nline 1\n", write: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log")
+			var handled []byte
+			c, err := startLogCapture(path, 1024, 1024, func(log io.Reader) {
+				handled, _ = io.ReadAll(log)
+			})
+			if err != nil {
+				t.Fatalf("startLogCapture() error = %v", err)
+			}
+
+			if tt.write {
+				// a blocking open, which only succeeds if the capture is reading
+				w, err := os.OpenFile(path, os.O_WRONLY, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := io.WriteString(w, tt.log); err != nil {
+					t.Fatal(err)
+				}
+				w.Close()
+			}
+
+			start := time.Now()
+			if err := c.finish(); err != nil {
+				t.Fatalf("finish() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > logCaptureTimeout/2 {
+				t.Errorf("finish() took %v", elapsed)
+			}
+
+			if string(handled) != tt.log {
+				t.Errorf("handler read %q, want %q", handled, tt.log)
+			}
+			retained, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(retained) != tt.log {
+				t.Errorf("retained log = %q, want %q", retained, tt.log)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// resourceSampleInterval is how often the resource usage of the sandbox is
+// sampled while a command is running, to find the peak memory and thread count.
+var resourceSampleInterval = time.Second
+
+// ResourceUsage is the resource accounting of the sandbox container (i.e. its
+// cgroup) for a run. CPUTime and the I/O byte counts are totals for the run.
+// PeakMemoryBytes and PeakThreads are the largest values seen while the command
+// was running; since they are sampled (about once per second), short spikes may
+// be missed.
+type ResourceUsage struct {
+	CPUTime          time.Duration
+	PeakMemoryBytes  uint64
+	BlockReadBytes   uint64
+	BlockWriteBytes  uint64
+	NetReceivedBytes uint64
+	NetSentBytes     uint64
+	PeakThreads      uint64
+}
+
+// containerStatsJSON holds the fields used from the stats reported by podman
+// for a container. PIDs counts the tasks (threads) in the container cgroup.
+type containerStatsJSON struct {
+	CPUNano     uint64
+	MemUsage    uint64
+	NetInput    uint64
+	NetOutput   uint64
+	BlockInput  uint64
+	BlockOutput uint64
+	PIDs        uint64
+}
+
+// parseContainerStats parses the output of "podman stats" for a single container,
+// formatted as JSON (see containerStatsCmd).
+func parseContainerStats(data []byte) (containerStatsJSON, error) {
+	var stats containerStatsJSON
+	if err := json.Unmarshal(bytes.TrimSpace(data), &stats); err != nil {
+		return stats, fmt.Errorf("failed to parse container stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *podmanSandbox) containerStats(ctx context.Context) (containerStatsJSON, error) {
+	cmd := podman(ctx, "stats", "--no-stream", "--format", "{{json .ContainerStats}}", s.container)
+	out, err := cmd.Output()
+	if err != nil {
+		return containerStatsJSON{}, fmt.Errorf("error getting container stats: %w", err)
+	}
+	return parseContainerStats(out)
+}
+
+// resourceMonitor accumulates samples of the stats of a container into a ResourceUsage.
+type resourceMonitor struct {
+	mu      sync.Mutex
+	usage   ResourceUsage
+	sampled bool
+}
+
+// add records a sample. Totals only increase while the container is running, but
+// the largest value is kept in case a sample is taken as the container stops.
+func (m *resourceMonitor) add(stats containerStatsJSON) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sampled = true
+	m.usage.CPUTime = max(m.usage.CPUTime, time.Duration(stats.CPUNano))
+	m.usage.PeakMemoryBytes = max(m.usage.PeakMemoryBytes, stats.MemUsage)
+	m.usage.BlockReadBytes = max(m.usage.BlockReadBytes, stats.BlockInput)
+	m.usage.BlockWriteBytes = max(m.usage.BlockWriteBytes, stats.BlockOutput)
+	m.usage.NetReceivedBytes = max(m.usage.NetReceivedBytes, stats.NetInput)
+	m.usage.NetSentBytes = max(m.usage.NetSentBytes, stats.NetOutput)
+	m.usage.PeakThreads = max(m.usage.PeakThreads, stats.PIDs)
+}
+
+// result returns the accumulated usage, or nil if no sample was recorded.
+func (m *resourceMonitor) result() *ResourceUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.sampled {
+		return nil
+	}
+	usage := m.usage
+	return &usage
+}
+
+// sample adds the current stats of the container to m. Errors are logged, since
+// resource accounting is not available for all container runtimes.
+func (s *podmanSandbox) sample(ctx context.Context, m *resourceMonitor) {
+	stats, err := s.containerStats(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.DebugContext(ctx, "could not sample sandbox resource usage", "error", err)
+		}
+		return
+	}
+	m.add(stats)
+}
+
+// monitorResources samples the resource usage of the container every
+// resourceSampleInterval until the returned function is called. That function
+// takes a final sample and returns the usage, or nil if it could not be measured.
+func (s *podmanSandbox) monitorResources(ctx context.Context) func() *ResourceUsage {
+	m := &resourceMonitor{}
+	sampleCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sampleCtx.Done():
+				return
+			case <-ticker.C:
+				s.sample(sampleCtx, m)
+			}
+		}
+	}()
+
+	return func() *ResourceUsage {
+		cancel()
+		<-done
+		if ctx.Err() == nil {
+			s.sample(ctx, m)
+		}
+		return m.result()
+	}
+}
@@ -0,0 +1,88 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseContainerStats(t *testing.T) {
+	data := []byte(`{"AvgCPU":1.5,"ContainerID":"abc","Name":"test","CPUNano":2500000000,"MemUsage":1048576,"MemLimit":2147483648,` +
+		`"NetInput":100,"NetOutput":200,"BlockInput":300,"BlockOutput":400,"PIDs":12,"UpTime":5000000000}` + "\n")
+	got, err := parseContainerStats(data)
+	if err != nil {
+		t.Fatalf("parseContainerStats() error = %v", err)
+	}
+	want := containerStatsJSON{CPUNano: 2500000000, MemUsage: 1048576, NetInput: 100, NetOutput: 200, BlockInput: 300, BlockOutput: 400, PIDs: 12}
+	if got != want {
+		t.Errorf("parseContainerStats() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseContainerStats([]byte("")); err == nil {
+		t.Errorf("parseContainerStats() of empty output succeeded")
+	}
+}
+
+func TestResourceMonitor(t *testing.T) {
+	m := &resourceMonitor{}
+	if got := m.result(); got != nil {
+		t.Errorf("result() without samples = %+v, want nil", got)
+	}
+
+	m.add(containerStatsJSON{CPUNano: 1e9, MemUsage: 500, NetInput: 10, PIDs: 30})
+	m.add(containerStatsJSON{CPUNano: 3e9, MemUsage: 200, NetInput: 50, BlockOutput: 70, PIDs: 4})
+	want := &ResourceUsage{
+		CPUTime:          3 * time.Second,
+		PeakMemoryBytes:  500,
+		BlockWriteBytes:  70,
+		NetReceivedBytes: 50,
+		PeakThreads:      30,
+	}
+	if got := m.result(); !reflect.DeepEqual(got, want) {
+		t.Errorf("result() = %+v, want %+v", got, want)
+	}
+}
+
+// fakePodmanStatsScript simulates podman, reporting fixed container stats.
+const fakePodmanStatsScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		stats) echo '{"CPUNano":2000000000,"MemUsage":4096,"BlockInput":1,"BlockOutput":2,"NetInput":3,"NetOutput":4,"PIDs":5}'; exit 0 ;;
+		exec) sleep 0.2; exit 0 ;;
+		create) echo test-container; exit 0 ;;
+	esac
+done
+exit 0
+`
+
+func TestRunResourceUsage(t *testing.T) {
+	fakePodman := filepath.Join(t.TempDir(), "podman")
+	if err := os.WriteFile(fakePodman, []byte(fakePodmanStatsScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	oldPodmanBin, oldInterval := podmanBin, resourceSampleInterval
+	podmanBin, resourceSampleInterval = fakePodman, 50*time.Millisecond
+	t.Cleanup(func() { podmanBin, resourceSampleInterval = oldPodmanBin, oldInterval })
+
+	sb := New(Image("test-image"), NoPull())
+	result, err := sb.Run(context.Background(), "/usr/local/bin/analyze.js", "install", "foo")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := &ResourceUsage{
+		CPUTime:          2 * time.Second,
+		PeakMemoryBytes:  4096,
+		BlockReadBytes:   1,
+		BlockWriteBytes:  2,
+		NetReceivedBytes: 3,
+		NetSentBytes:     4,
+		PeakThreads:      5,
+	}
+	if got := result.ResourceUsage(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourceUsage() = %+v, want %+v", got, want)
+	}
+}
@@ -11,18 +11,33 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/ossf/package-analysis/internal/log"
 )
 
+// podmanBin is the podman executable. It is a variable so that tests can replace it.
+var podmanBin = "podman"
+
+// ErrImageNotFound is returned by Init if the sandbox image is not available
+// locally and pulling images is disabled (see NoPull).
+var ErrImageNotFound = errors.New("sandbox image not found")
+
 const (
-	podmanBin     = "podman"
 	runtimeBin    = "/usr/local/bin/runsc_compat.sh"
 	rootDir       = "/var/run/runsc"
 	runLogFile    = "runsc.log.boot"
 	logDirPattern = "sandbox_logs_"
+	// envFileName is the file in the log directory of a run that holds the
+	// environment variables for the command run (see execContainerCmd).
+	envFileName = "env"
+
+	// stopTimeout is the maximum time allowed for stopping the container
+	// after the context passed to Run is cancelled.
+	stopTimeout = 30 * time.Second
 
 	// networkName is the name of the podman network defined in
 	// tools/network/podman-analysis.conflist. This network is the network
@@ -52,19 +67,42 @@ const (
 )
 
 type RunResult struct {
-	logPath string
-	status  RunStatus
-	stderr  *bytes.Buffer
-	stdout  *bytes.Buffer
+	logPath      string
+	logSize      int64
+	logTruncated bool
+	status       RunStatus
+	stderr       *bytes.Buffer
+	stdout       *bytes.Buffer
+	resources    *ResourceUsage
 }
 
 // Log returns the log file recorded during a run.
 //
-// This log will contain strace data.
+// This log will contain strace data. If strace is enabled, the log may have been
+// truncated to limit its size (see LogLimit and LogTruncated).
 func (r *RunResult) Log() (io.ReadCloser, error) {
 	return os.Open(r.logPath)
 }
 
+// LogTruncated returns whether part of the log was discarded because it exceeded
+// the limits set by LogLimit.
+func (r *RunResult) LogTruncated() bool {
+	return r.logTruncated
+}
+
+// LogSize returns the size of the complete log produced during a run, in bytes,
+// before any truncation. It is 0 if the size is not known.
+func (r *RunResult) LogSize() int64 {
+	return r.logSize
+}
+
+// ResourceUsage returns the resource accounting of the sandbox for the run, or
+// nil if it could not be measured. If the run was cancelled, it only covers the
+// samples taken before then.
+func (r *RunResult) ResourceUsage() *ResourceUsage {
+	return r.resources
+}
+
 func (r *RunResult) Status() RunStatus {
 	if r != nil {
 		return r.status
@@ -90,8 +128,20 @@ type Sandbox interface {
 	// until Clean() is called.
 	// The returned RunResult stores information about the execution.
 	// If any error occurs, it is returned with a partial RunResult.
+	// If ctx is cancelled while the command is running, the sandboxed
+	// process is stopped and ctx.Err() is returned.
 	Run(ctx context.Context, command string, args ...string) (*RunResult, error)
 
+	// RunWithLogHandler is like Run, but also calls handler with the complete
+	// log of the run (see RunResult.Log). If strace is enabled, the log is
+	// streamed to handler while the command is running, so that it can be
+	// processed in full even if it is too large to be retained.
+	// handler is called exactly once, and Run does not return until it has
+	// returned, unless the sandbox could not be initialised.
+	// env holds extra environment variables for the command only, which take
+	// precedence over those set by SetEnv and by the image. It may be nil.
+	RunWithLogHandler(ctx context.Context, handler LogHandler, env map[string]string, command string, args ...string) (*RunResult, error)
+
 	// Clean cleans up the Sandbox. Once called, the Sandbox cannot be used again.
 	Clean(ctx context.Context) error
 
@@ -134,6 +184,8 @@ type podmanSandbox struct {
 	noPull      bool
 	rawSockets  bool
 	strace      bool
+	logHead     int
+	logTail     int
 	offline     bool
 	logPackets  bool
 	logStdOut   bool
@@ -158,6 +210,8 @@ func New(options ...Option) Sandbox {
 	sb := &podmanSandbox{
 		logger:      slog.Default(),
 		environment: make(map[string]string),
+		logHead:     defaultLogHeadBytes,
+		logTail:     defaultLogTailBytes,
 	}
 	for _, o := range options {
 		o.set(sb)
@@ -185,6 +239,17 @@ func EnableStrace() Option {
 	return option(func(sb *podmanSandbox) { sb.strace = true })
 }
 
+// LogLimit sets the number of bytes at the start and end of the log of each run
+// that are kept when strace is enabled. Anything in between is discarded, so that
+// packages which make huge numbers of syscalls cannot exhaust memory or disk space.
+// Handlers passed to RunWithLogHandler still see the complete log.
+func LogLimit(headBytes, tailBytes int) Option {
+	return option(func(sb *podmanSandbox) {
+		sb.logHead = headBytes
+		sb.logTail = tailBytes
+	})
+}
+
 // Offline disables network functionality for the sandbox.
 func Offline() Option {
 	return option(func(sb *podmanSandbox) { sb.offline = true })
@@ -292,6 +357,16 @@ func (s *podmanSandbox) pullImage(ctx context.Context) error {
 	return podmanRun(ctx, "pull", s.imageWithTag())
 }
 
+// imageExists returns whether the image of the sandbox is available locally.
+func (s *podmanSandbox) imageExists(ctx context.Context) (bool, error) {
+	err := podmanRun(ctx, "image", "exists", s.imageWithTag())
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (s *podmanSandbox) createContainer(ctx context.Context) (string, error) {
 	args := []string{
 		"create",
@@ -361,9 +436,42 @@ func (s *podmanSandbox) forceStopContainer(ctx context.Context) error {
 		s.container)
 }
 
-func (s *podmanSandbox) execContainerCmd(ctx context.Context, execCmd string, execArgs []string) *exec.Cmd {
-	args := append([]string{"exec", s.container, execCmd}, execArgs...)
-	return podman(ctx, args...)
+// execContainerCmd returns the command that runs execCmd in the container. The
+// variables in env, if any, are passed in envFile, which is written here, rather
+// than on the command line of podman, where they would be visible to other users.
+func (s *podmanSandbox) execContainerCmd(ctx context.Context, env map[string]string, envFile string, execCmd string, execArgs []string) (*exec.Cmd, error) {
+	args := []string{"exec"}
+	if len(env) > 0 {
+		if err := writeEnvFile(envFile, env); err != nil {
+			return nil, err
+		}
+		args = append(args, "--env-file", envFile)
+	}
+	args = append(args, s.container, execCmd)
+	args = append(args, execArgs...)
+	return podman(ctx, args...), nil
+}
+
+// writeEnvFile writes env to path, sorted by name, in the format of the
+// --env-file option of podman, which has one NAME=VALUE line per variable.
+func writeEnvFile(path string, env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		if k == "" || strings.ContainsAny(k, "=\n") || strings.ContainsAny(env[k], "\n") {
+			return fmt.Errorf("invalid environment variable %q", k)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", k, env[k])
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write environment file: %w", err)
+	}
+	return nil
 }
 
 func (s *podmanSandbox) extraArgs() []string {
@@ -402,8 +510,12 @@ func (s *podmanSandbox) Init(ctx context.Context) error {
 	}
 	if !s.noPull {
 		if err := s.pullImage(ctx); err != nil {
-			return fmt.Errorf("error pulling image: %w", err)
+			return fmt.Errorf("error pulling image %s: %w", s.imageWithTag(), err)
 		}
+	} else if exists, err := s.imageExists(ctx); err != nil {
+		return fmt.Errorf("error checking for image %s: %w", s.imageWithTag(), err)
+	} else if !exists {
+		return fmt.Errorf("%w: %s", ErrImageNotFound, s.imageWithTag())
 	}
 	if id, err := s.createContainer(ctx); err != nil {
 		return fmt.Errorf("error creating container: %w", err)
@@ -428,6 +540,12 @@ func (s *podmanSandbox) Init(ctx context.Context) error {
 // Run implements the Sandbox interface.
 // If Init() has not yet been run, it will be called automatically before running
 func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string) (*RunResult, error) {
+	return s.RunWithLogHandler(ctx, nil, nil, command, args...)
+}
+
+// RunWithLogHandler implements the Sandbox interface.
+// If Init() has not yet been run, it will be called automatically before running
+func (s *podmanSandbox) RunWithLogHandler(ctx context.Context, handler LogHandler, env map[string]string, command string, args ...string) (*RunResult, error) {
 	if err := s.Init(ctx); err != nil {
 		return &RunResult{}, err
 	}
@@ -456,6 +574,50 @@ func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string)
 		stderr:  &stderr,
 	}
 
+	if !s.strace {
+		// The log is small, so there is no need to capture it as it is written.
+		err := s.run(ctx, result, logDir, &stdout, &stderr, env, command, args)
+		if handler != nil {
+			callHandlerWithFile(handler, result.logPath)
+		}
+		return result, err
+	}
+
+	capture, err := startLogCapture(result.logPath, s.logHead, s.logTail, handler)
+	if err != nil {
+		return result, err
+	}
+	err = s.run(ctx, result, logDir, &stdout, &stderr, env, command, args)
+	captureErr := capture.finish()
+	result.logSize = capture.buf.TotalBytes()
+	result.logTruncated = capture.buf.Truncated()
+	if result.logTruncated {
+		s.logger.WarnContext(ctx, "sandbox log truncated",
+			"size", result.logSize,
+			"discarded", capture.buf.DiscardedBytes())
+	}
+	if err == nil && captureErr != nil {
+		err = fmt.Errorf("error capturing log: %w", captureErr)
+	}
+	return result, err
+}
+
+// callHandlerWithFile calls handler with the contents of the file at path, or
+// with empty input if the file cannot be opened.
+func callHandlerWithFile(handler LogHandler, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		handler(strings.NewReader(""))
+		return
+	}
+	defer f.Close()
+	handler(f)
+}
+
+// run runs the command in the container, with the extra environment variables
+// in env, and records its status and output in result.
+func (s *podmanSandbox) run(ctx context.Context, result *RunResult, logDir string, stdout, stderr *bytes.Buffer, env map[string]string, command string, args []string) error {
+
 	// Prepare stdout and stderr writers
 	logOut := log.NewWriter(ctx,
 		s.logger.With("command", command, "args", args),
@@ -466,7 +628,7 @@ func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string)
 		slog.LevelWarn)
 	defer logErr.Close()
 
-	outWriters := []io.Writer{&stdout}
+	outWriters := []io.Writer{stdout}
 	if s.logStdOut {
 		outWriters = append(outWriters, logOut)
 	}
@@ -475,7 +637,7 @@ func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string)
 	}
 	outWriter := io.MultiWriter(outWriters...)
 
-	errWriters := []io.Writer{&stderr}
+	errWriters := []io.Writer{stderr}
 	if s.logStdErr {
 		errWriters = append(errWriters, logErr)
 	}
@@ -489,19 +651,37 @@ func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string)
 	startCmd.Stdout = logOut
 	startCmd.Stderr = logErr
 	if err := startCmd.Run(); err != nil {
-		return result, fmt.Errorf("error starting container: %w", err)
+		return fmt.Errorf("error starting container: %w", err)
 	}
 
 	// Run the command in the sandbox
-	cmd := s.execContainerCmd(ctx, command, args)
+	envFile := filepath.Join(logDir, envFileName)
+	defer os.Remove(envFile)
+	cmd, err := s.execContainerCmd(ctx, env, envFile, command, args)
+	if err != nil {
+		return err
+	}
 	cmd.Stdout = outWriter
 	cmd.Stderr = errWriter
 
 	if err := cmd.Start(); err != nil {
-		return result, fmt.Errorf("error execing command: %w", err)
+		return fmt.Errorf("error execing command: %w", err)
 	}
+	stopMonitor := s.monitorResources(ctx)
 
 	err = cmd.Wait()
+	result.resources = stopMonitor()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The podman exec process has been killed, but the command may still be
+		// running inside the container, so stop the container to tear it down.
+		// A new context is needed as the original one is already done.
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), stopTimeout)
+		defer cancel()
+		if stopErr := s.forceStopContainer(stopCtx); stopErr != nil {
+			s.logger.WarnContext(ctx, "failed to stop container after cancellation", "error", stopErr)
+		}
+		return ctxErr
+	}
 	if err == nil {
 		result.status = RunStatusSuccess
 	} else if _, ok := err.(*exec.ExitError); ok {
@@ -524,7 +704,7 @@ func (s *podmanSandbox) Run(ctx context.Context, command string, args ...string)
 		}
 	}
 
-	return result, err
+	return err
 }
 
 // Clean implements the Sandbox interface.
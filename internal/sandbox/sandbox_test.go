@@ -0,0 +1,267 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakePodmanScript simulates podman. The exec subcommand runs for a long time,
+// to simulate a slow analysis, and the stop subcommand is recorded in a file.
+const fakePodmanScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		exec) exec sleep 60 ;;
+		stop) echo stop >> "$FAKE_PODMAN_LOG"; exit 0 ;;
+		create) echo test-container; exit 0 ;;
+	esac
+done
+exit 0
+`
+
+func TestRunCancelled(t *testing.T) {
+	dir := t.TempDir()
+	fakePodman := filepath.Join(dir, "podman")
+	if err := os.WriteFile(fakePodman, []byte(fakePodmanScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stopLog := filepath.Join(dir, "stop.log")
+	t.Setenv("FAKE_PODMAN_LOG", stopLog)
+
+	oldPodmanBin := podmanBin
+	podmanBin = fakePodman
+	t.Cleanup(func() { podmanBin = oldPodmanBin })
+
+	sb := New(Image("test-image"), NoPull())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := sb.Run(ctx, "/usr/local/bin/analyze.js", "install", "foo")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed > 10*time.Second {
+		t.Errorf("Run() took %v after cancellation, want prompt return", elapsed)
+	}
+
+	stops, err := os.ReadFile(stopLog)
+	if err != nil {
+		t.Fatalf("container was not stopped: %v", err)
+	}
+	if !strings.Contains(string(stops), "stop") {
+		t.Errorf("container was not stopped after cancellation")
+	}
+}
+
+// fakePodmanLogScript simulates podman, writing LOG_LINES lines to the boot log
+// when the container is started.
+const fakePodmanLogScript = `#!/bin/sh
+log=""
+for arg in "$@"; do
+	case "$arg" in
+		--runtime-flag=debug-log=*) log=$(echo "${arg#--runtime-flag=debug-log=}" | sed 's/%COMMAND%/boot/') ;;
+		start)
+			start=1 ;;
+		create) echo test-container; exit 0 ;;
+	esac
+done
+if [ -n "$start" ] && [ "$LOG_LINES" -gt 0 ]; then
+	i=0
+	while [ $i -lt "$LOG_LINES" ]; do echo "line $i"; i=$((i+1)); done > "$log"
+fi
+exit 0
+`
+
+func TestRunWithLogHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         int
+		wantTruncated bool
+	}{
+		{name: "no log", lines: 0},
+		{name: "small log", lines: 2},
+		{name: "large log", lines: 1000, wantTruncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			fakePodman := filepath.Join(dir, "podman")
+			if err := os.WriteFile(fakePodman, []byte(fakePodmanLogScript), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("LOG_LINES", strconv.Itoa(tt.lines))
+
+			oldPodmanBin := podmanBin
+			podmanBin = fakePodman
+			t.Cleanup(func() { podmanBin = oldPodmanBin })
+
+			var wantLog strings.Builder
+			for i := 0; i < tt.lines; i++ {
+				fmt.Fprintf(&wantLog, "line %d\n", i)
+			}
+
+			sb := New(Image("test-image"), NoPull(), EnableStrace(), LogLimit(16, 16))
+			var handled []byte
+			r, err := sb.RunWithLogHandler(context.Background(), func(log io.Reader) {
+				handled, _ = io.ReadAll(log)
+			}, nil, "/usr/local/bin/analyze.js")
+			if err != nil {
+				t.Fatalf("RunWithLogHandler() error = %v", err)
+			}
+
+			if string(handled) != wantLog.String() {
+				t.Errorf("handler read %d bytes, want %d", len(handled), wantLog.Len())
+			}
+			if got := r.LogTruncated(); got != tt.wantTruncated {
+				t.Errorf("LogTruncated() = %v, want %v", got, tt.wantTruncated)
+			}
+			if got := r.LogSize(); got != int64(wantLog.Len()) {
+				t.Errorf("LogSize() = %d, want %d", got, wantLog.Len())
+			}
+
+			l, err := r.Log()
+			if err != nil {
+				t.Fatalf("Log() error = %v", err)
+			}
+			defer l.Close()
+			retained, err := io.ReadAll(l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantTruncated {
+				if !strings.HasPrefix(string(retained), "line 0\nline 1\nli") || !strings.HasSuffix(string(retained), "e 998\nline 999\n") {
+					t.Errorf("Log() = %q, want start and end of log", retained)
+				}
+			} else if string(retained) != wantLog.String() {
+				t.Errorf("Log() = %q, want %q", retained, wantLog.String())
+			}
+		})
+	}
+}
+
+// fakePodmanImageScript simulates podman, reporting that the image exists only
+// if IMAGE_EXISTS is set.
+const fakePodmanImageScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		exists) [ -n "$IMAGE_EXISTS" ]; exit $? ;;
+		create) echo test-container; exit 0 ;;
+	esac
+done
+exit 0
+`
+
+func TestInitImageNotFound(t *testing.T) {
+	tests := []struct {
+		name    string
+		exists  bool
+		wantErr error
+	}{
+		{name: "image exists", exists: true},
+		{name: "image missing", wantErr: ErrImageNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePodman := filepath.Join(t.TempDir(), "podman")
+			if err := os.WriteFile(fakePodman, []byte(fakePodmanImageScript), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			imageExists := ""
+			if tt.exists {
+				imageExists = "1"
+			}
+			t.Setenv("IMAGE_EXISTS", imageExists)
+
+			oldPodmanBin := podmanBin
+			podmanBin = fakePodman
+			t.Cleanup(func() { podmanBin = oldPodmanBin })
+
+			sb := New(Image("test-image"), NoPull())
+			err := sb.Init(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Init() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakePodmanExecScript simulates podman, recording the arguments of the exec
+// subcommand in a file, followed by the contents of its environment file.
+const fakePodmanExecScript = `#!/bin/sh
+for arg in "$@"; do
+	case "$arg" in
+		exec)
+			echo "$@" > "$FAKE_PODMAN_LOG"
+			env_file=""
+			for a in "$@"; do
+				[ "$env_file" = next ] && cat "$a" >> "$FAKE_PODMAN_LOG"
+				env_file=""
+				[ "$a" = --env-file ] && env_file=next
+			done
+			exit 0 ;;
+		create) echo test-container; exit 0 ;;
+	esac
+done
+exit 0
+`
+
+func TestRunWithEnv(t *testing.T) {
+	dir := t.TempDir()
+	fakePodman := filepath.Join(dir, "podman")
+	if err := os.WriteFile(fakePodman, []byte(fakePodmanExecScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	execLog := filepath.Join(dir, "exec.log")
+	t.Setenv("FAKE_PODMAN_LOG", execLog)
+
+	oldPodmanBin := podmanBin
+	podmanBin = fakePodman
+	t.Cleanup(func() { podmanBin = oldPodmanBin })
+
+	sb := New(Image("test-image"), NoPull(), SetEnv("CI", "false"))
+	env := map[string]string{"GITHUB_ACTIONS": "true", "CI": "true"}
+	if _, err := sb.RunWithLogHandler(context.Background(), nil, env, "/usr/local/bin/analyze.js", "install", "foo"); err != nil {
+		t.Fatalf("RunWithLogHandler() error = %v", err)
+	}
+
+	got, err := os.ReadFile(execLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the variables are not on the command line of podman
+	want := regexp.MustCompile(`exec --env-file \S+ test-container /usr/local/bin/analyze.js install foo\nCI=true\nGITHUB_ACTIONS=true\n$`)
+	if !want.Match(got) {
+		t.Errorf("podman called with %q, want match of %q", got, want)
+	}
+}
+
+func TestRunWithInvalidEnv(t *testing.T) {
+	dir := t.TempDir()
+	fakePodman := filepath.Join(dir, "podman")
+	if err := os.WriteFile(fakePodman, []byte(fakePodmanExecScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_PODMAN_LOG", filepath.Join(dir, "exec.log"))
+
+	oldPodmanBin := podmanBin
+	podmanBin = fakePodman
+	t.Cleanup(func() { podmanBin = oldPodmanBin })
+
+	sb := New(Image("test-image"), NoPull())
+	env := map[string]string{"CI": "true\nLD_PRELOAD=/tmp/evil.so"}
+	if _, err := sb.RunWithLogHandler(context.Background(), nil, env, "/usr/local/bin/analyze.js"); err == nil {
+		t.Errorf("RunWithLogHandler() with a newline in a value succeeded, want error")
+	}
+}
@@ -8,13 +8,21 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/ossf/package-analysis/internal/analysis"
 	"github.com/ossf/package-analysis/internal/log"
 	"github.com/ossf/package-analysis/internal/staticanalysis/basicdata"
-	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
 	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
 	"github.com/ossf/package-analysis/internal/staticanalysis/signals"
+	"github.com/ossf/package-analysis/internal/telemetry"
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
 )
 
 // enumeratePackageFiles returns a list of absolute paths to all (regular) files
@@ -34,6 +42,127 @@ func enumeratePackageFiles(extractDir string) ([]string, error) {
 	return paths, err
 }
 
+// selectPackageFiles returns a list of absolute paths to the (regular) files in
+// extractDir that are listed in selection, or that are in a directory that is
+// listed in selection. Paths in selection are relative to extractDir. It is an
+// error for a path in selection to not exist, or to refer to outside extractDir.
+func selectPackageFiles(extractDir string, selection []string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, selected := range selection {
+		p := filepath.Join(extractDir, filepath.FromSlash(selected))
+		if p != extractDir && !strings.HasPrefix(p, extractDir+string(os.PathSeparator)) {
+			return nil, fmt.Errorf("path %q is outside the package", selected)
+		}
+		selectedPaths, err := enumeratePackageFiles(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range selectedPaths {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// Option allows controlling the behaviour of AnalyzePackageFiles and
+// AnalyzeSelectedFiles with non-required arguments.
+type Option interface{ set(*analyzeConfig) }
+
+// option implements Option.
+type option func(*analyzeConfig)
+
+func (o option) set(config *analyzeConfig) { o(config) }
+
+// analyzeConfig stores all behaviour configuration for analyzing package files
+// which is adjustable by Option.
+type analyzeConfig struct {
+	// fileTimeout is the time each file is given to parse (no limit if zero)
+	fileTimeout time.Duration
+	// packageTimeout is the time all files are given to parse (no limit if zero)
+	packageTimeout time.Duration
+	// previous holds the results of the files of the previous version of the
+	// package, whose parsing data can be reused (see PreviousResults)
+	previous []SingleResult
+	// detectors makes the findings of each parsed file (see Detectors), or is nil
+	// for the built-in detectors only
+	detectors *staticanalysis.DetectorRegistry
+}
+
+// FileTimeout gives each file d to parse. Files are parsed in batches, which have
+// a budget of d for each of their files, so a file which takes longer than d is
+// only marked as timed out (see SingleResult.TimedOut) if its batch runs out of
+// time and the file takes longer than d when parsed again on its own (see
+// parsing.Registry.SetFileTimeout). Files which time out have no parsing or
+// signals data, while the other files are analyzed as normal. This stops a single
+// pathological file from holding up the analysis of a package.
+func FileTimeout(d time.Duration) Option {
+	return option(func(config *analyzeConfig) {
+		config.fileTimeout = d
+	})
+}
+
+// PackageTimeout limits the time taken to parse all files in the package to d.
+// Files which are not parsed before the time is up are marked as timed out (see
+// SingleResult.TimedOut), but the results for the files already parsed are kept.
+// This bounds the analysis of packages containing very many files.
+func PackageTimeout(d time.Duration) Option {
+	return option(func(config *analyzeConfig) {
+		config.packageTimeout = d
+	})
+}
+
+/*
+PreviousResults enables incremental analysis, reusing the results of the files of
+the previous version of the package, such as the Files of the Result it produced,
+for the files that have not changed. A file is unchanged if a file at the same
+path relative to the package root (see packageRoot) has the same SHA256 hash in
+previous, where the hash is that of its basic data. Unchanged files are not parsed
+again, and their parsing data is copied from previous (see SingleResult.Reused),
+while the other files are analyzed as normal. Entropy values are computed again
+across all files, so that they match those of a full analysis, and so are the
+signals of the unchanged files, which depend on the detectors and on the files
+around them (e.g. for imports from outside the package). Since packages which are
+monitored continuously often change only a few files in each release, this saves
+most of the time taken to parse them.
+
+The previous results must have been produced with the same analysis tasks, and the
+same parser configuration. Files which timed out are always analyzed again.
+*/
+func PreviousResults(previous []SingleResult) Option {
+	return option(func(config *analyzeConfig) {
+		config.previous = previous
+	})
+}
+
+/*
+Detectors makes the findings of each parsed file with the detectors registered in
+registry, rather than only the built-in ones (see signals.DefaultDetectorRegistry),
+so that custom detectors can contribute to the Findings of the signals task. It has
+no effect unless staticanalysis.Signals is run.
+
+Since custom detectors usually need the structure of the code, the parser is run
+with ParserConfig.FullAST set, so that each detector is given the syntax tree of
+the file. PreviousResults must then also have been produced with the full syntax
+tree, so that the detectors are given the trees of the unchanged files.
+*/
+func Detectors(registry *staticanalysis.DetectorRegistry) Option {
+	return option(func(config *analyzeConfig) {
+		config.detectors = registry
+	})
+}
+
+func newAnalyzeConfig(options []Option) analyzeConfig {
+	var config analyzeConfig
+	for _, o := range options {
+		o.set(&config)
+	}
+	return config
+}
+
 /*
 AnalyzePackageFiles walks a tree of extracted package files and runs the analysis tasks
 listed in analysisTasks to produce the result data.
@@ -44,10 +173,52 @@ in analysisTasks, then both tasks are performed.
 
 If staticanalysis.Parsing is not in the list of analysisTasks, jsParserConfig may be empty.
 
+Pass instances of Option to limit the time taken to parse files, to reuse the
+results of files which have not changed since the previous version of the package,
+or to run custom detectors.
+
 If an error occurs while traversing the extracted package directory tree, or an invalid
 task is requested, a nil result is returned along with the corresponding error object.
 */
-func AnalyzePackageFiles(ctx context.Context, extractDir string, jsParserConfig parsing.ParserConfig, analysisTasks []Task) ([]SingleResult, error) {
+func AnalyzePackageFiles(ctx context.Context, extractDir string, jsParserConfig parsing.ParserConfig, analysisTasks []Task, options ...Option) ([]SingleResult, error) {
+	runTask, err := tasksToRun(ctx, analysisTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := enumeratePackageFiles(extractDir)
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating package files: %w", err)
+	}
+
+	return analyzeFiles(ctx, extractDir, paths, jsParserConfig, runTask, newAnalyzeConfig(options)), nil
+}
+
+/*
+AnalyzeSelectedFiles is like AnalyzePackageFiles, but only analyzes the files listed
+in selection, which are given as paths relative to extractDir. If a path in selection
+is a directory, all files in it (or any descendent directory) are analyzed.
+
+This can be used to focus analysis on the files most likely to run, such as the
+package's entry points (see pkgmanager.PkgManager.EntryPoints).
+*/
+func AnalyzeSelectedFiles(ctx context.Context, extractDir string, selection []string, jsParserConfig parsing.ParserConfig, analysisTasks []Task, options ...Option) ([]SingleResult, error) {
+	runTask, err := tasksToRun(ctx, analysisTasks)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := selectPackageFiles(extractDir, selection)
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating selected package files: %w", err)
+	}
+
+	return analyzeFiles(ctx, extractDir, paths, jsParserConfig, runTask, newAnalyzeConfig(options)), nil
+}
+
+// tasksToRun returns the set of tasks to run for the given list of analysis tasks,
+// including any tasks that they depend on.
+func tasksToRun(ctx context.Context, analysisTasks []Task) (map[Task]bool, error) {
 	runTask := map[Task]bool{}
 
 	for _, task := range analysisTasks {
@@ -68,12 +239,126 @@ func AnalyzePackageFiles(ctx context.Context, extractDir string, jsParserConfig
 			return nil, fmt.Errorf("static analysis task not implemented: %s", task)
 		}
 	}
+	return runTask, nil
+}
 
-	paths, err := enumeratePackageFiles(extractDir)
+// maxConfigFileSize is the size in bytes of the largest config file that is scanned
+// for signals. Larger JSON and YAML files are more likely to hold data than config.
+const maxConfigFileSize = 1 << 20
+
+// analyzeConfigFile returns the signals found in the config file at path, which has
+// the given name in the package. If the file is too large or cannot be read, false
+// is returned.
+func analyzeConfigFile(ctx context.Context, path, filename string) (signals.FileSignals, bool) {
+	info, err := os.Stat(path)
 	if err != nil {
-		return nil, fmt.Errorf("error enumerating package files: %w", err)
+		slog.ErrorContext(ctx, "static analysis config file error", "error", err,
+			"filename", filename, log.Label("task", string(Signals)))
+		return signals.FileSignals{}, false
+	}
+	if info.Size() > maxConfigFileSize {
+		slog.InfoContext(ctx, "skipped signals analysis of large config file", "filename", filename, "size", info.Size())
+		return signals.FileSignals{}, false
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		slog.ErrorContext(ctx, "static analysis config file error", "error", err,
+			"filename", filename, log.Label("task", string(Signals)))
+		return signals.FileSignals{}, false
+	}
+	return signals.AnalyzeConfigFile(filename, contents), true
+}
+
+// packageRoot returns the slash-separated path, relative to the extracted archive, of
+// the directory that holds the package, which is the single top-level directory that
+// contains every file if there is one (e.g. "package" for NPM tarballs), or the empty
+// string for the root of the archive.
+func packageRoot(results []SingleResult) string {
+	root := ""
+	for _, r := range results {
+		dir, _, found := strings.Cut(filepath.ToSlash(r.Filename), "/")
+		if !found || (root != "" && dir != root) {
+			return ""
+		}
+		root = dir
+	}
+	return root
+}
+
+// pathInPackage returns the slash-separated path of filename, a path of a file
+// relative to the extracted archive, relative to the package root instead.
+func pathInPackage(filename, root string) string {
+	p := filepath.ToSlash(filename)
+	if root == "" {
+		return p
+	}
+	return strings.TrimPrefix(p, root+"/")
+}
+
+// isTimeout returns whether err means that a file was not parsed in time.
+func isTimeout(err error) bool {
+	return errors.Is(err, parsing.ErrTimeout) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// reusableResults returns the entries of previous which can be reused for the files
+// of fileResults, keyed by their index in fileResults. hashes holds the SHA256 hash
+// of each file in fileResults, or the empty string if it is not known.
+func reusableResults(fileResults []SingleResult, hashes []string, previous []SingleResult) map[int]SingleResult {
+	previousRoot := packageRoot(previous)
+	byPath := make(map[string]SingleResult, len(previous))
+	for _, r := range previous {
+		if r.Basic != nil && r.Basic.SHA256 != "" && !r.TimedOut {
+			byPath[pathInPackage(r.Filename, previousRoot)] = r
+		}
 	}
 
+	root := packageRoot(fileResults)
+	reusable := make(map[int]SingleResult)
+	for i, r := range fileResults {
+		if hashes[i] == "" {
+			continue
+		}
+		if prev, ok := byPath[pathInPackage(r.Filename, root)]; ok && prev.Basic.SHA256 == hashes[i] {
+			reusable[i] = prev
+		}
+	}
+	return reusable
+}
+
+// reusedParsing returns a copy of the parsing data of a reused file, whose entropy
+// values can be set without changing those of the previous results.
+func reusedParsing(p *parsing.SingleResult) *parsing.SingleResult {
+	if p == nil {
+		return nil
+	}
+	reused := *p
+	reused.Identifiers = slices.Clone(p.Identifiers)
+	reused.StringLiterals = slices.Clone(p.StringLiterals)
+	return &reused
+}
+
+// fileHashes returns the SHA256 hash of each file in fileResults, taken from its
+// basic data if it has any, or the empty string if it cannot be computed.
+func fileHashes(ctx context.Context, fileResults []SingleResult, getAbsolutePath func(string) string) []string {
+	hashes := make([]string, len(fileResults))
+	for i, r := range fileResults {
+		if r.Basic != nil {
+			hashes[i] = r.Basic.SHA256
+			continue
+		}
+		hash, err := utils.SHA256Hash(getAbsolutePath(r.Filename))
+		if err != nil {
+			slog.WarnContext(ctx, "could not hash file for incremental analysis", "filename", r.Filename, "error", err)
+			continue
+		}
+		hashes[i] = hash
+	}
+	return hashes
+}
+
+// analyzeFiles runs the analysis tasks in runTask over the files at the given paths
+// in extractDir, and returns the results.
+func analyzeFiles(ctx context.Context, extractDir string, paths []string, jsParserConfig parsing.ParserConfig, runTask map[Task]bool, config analyzeConfig) []SingleResult {
 	getPathInArchive := func(absolutePath string) string {
 		return strings.TrimPrefix(absolutePath, extractDir+string(os.PathSeparator))
 	}
@@ -102,30 +387,96 @@ func AnalyzePackageFiles(ctx context.Context, extractDir string, jsParserConfig
 		}
 	}
 
+	var reused map[int]SingleResult
+	if len(config.previous) > 0 && runTask[Parsing] {
+		reused = reusableResults(fileResults, fileHashes(ctx, fileResults, getAbsolutePath), config.previous)
+		slog.InfoContext(ctx, "incremental analysis", "reused_files", len(reused), "analyzed_files", len(fileResults)-len(reused))
+	}
+
 	if runTask[Parsing] {
 		slog.InfoContext(ctx, "run parsing analysis")
 
-		input := externalcmd.MultipleFileInput(paths)
-		parsingResults, err := parsing.Analyze(ctx, jsParserConfig, input, false)
+		parsePaths := paths
+		if len(reused) > 0 {
+			parsePaths = make([]string, 0, len(paths)-len(reused))
+			for i, path := range paths {
+				if _, ok := reused[i]; !ok {
+					parsePaths = append(parsePaths, path)
+				}
+			}
+		}
 
-		if err != nil {
-			slog.ErrorContext(ctx, "static analysis parsing error", "error", err)
-		} else if len(parsingResults) != len(fileResults) {
-			slog.ErrorContext(ctx, fmt.Sprintf("parsing.Analyze() returned %d results, expecting %d",
-				len(parsingResults), len(fileResults)), log.Label("task", string(Basic)))
-		} else {
-			for i, r := range fileResults {
-				fileParseResult := parsingResults[getAbsolutePath(r.Filename)]
+		parseCtx := ctx
+		if config.packageTimeout > 0 {
+			var cancel context.CancelFunc
+			parseCtx, cancel = context.WithTimeout(ctx, config.packageTimeout)
+			defer cancel()
+		}
+
+		if config.detectors != nil {
+			jsParserConfig.FullAST = true
+		}
+		registry := parsing.DefaultRegistry(jsParserConfig)
+		registry.SetFileTimeout(config.fileTimeout)
+		parseCtx, parseStage := telemetry.StartStage(parseCtx, telemetry.StageParse, attribute.Int("files", len(parsePaths)))
+		parsingResults, parsingErrors := registry.AnalyzeConcurrently(parseCtx, parsePaths, runtime.NumCPU())
+
+		var timedOut []string
+		for i, r := range fileResults {
+			if prev, ok := reused[i]; ok {
+				fileResults[i].Reused = true
+				fileResults[i].Parsing = reusedParsing(prev.Parsing)
+				continue
+			}
+			path := getAbsolutePath(r.Filename)
+			if err, failed := parsingErrors[path]; failed {
+				if ctx.Err() == nil && isTimeout(err) {
+					fileResults[i].TimedOut = true
+					timedOut = append(timedOut, r.Filename)
+					continue
+				}
+				slog.ErrorContext(ctx, "static analysis parsing error", "error", err,
+					"filename", r.Filename, log.Label("task", string(Parsing)))
+				continue
+			}
+			if fileParseResult, ok := parsingResults[path]; ok {
 				fileResults[i].Parsing = &fileParseResult
 			}
 		}
+		if len(reused) > 0 {
+			// entropy values depend on all files of the package, so those of the
+			// reused files, and of the files parsed again, are computed together
+			// to match the values of analysing every file
+			parsed := make([]*parsing.SingleResult, len(fileResults))
+			for i := range fileResults {
+				parsed[i] = fileResults[i].Parsing
+			}
+			parsing.PopulateEntropies(parsed)
+		}
+		parseStatus := analysis.StatusCompleted
+		if len(timedOut) > 0 {
+			parseStatus = analysis.StatusErrorTimeout
+			slog.WarnContext(ctx, "static analysis parsing timed out", "files", timedOut,
+				"file_timeout", config.fileTimeout, "package_timeout", config.packageTimeout,
+				log.Label("task", string(Parsing)))
+		}
+		parseStage.End(parseCtx, parseStatus, nil)
 	}
 
 	if runTask[Signals] {
 		slog.InfoContext(ctx, "run signals analysis")
+		detectors := config.detectors
+		if detectors == nil {
+			detectors = signals.DefaultDetectorRegistry()
+		}
+		root := packageRoot(fileResults)
 		for i, r := range fileResults {
-			if r.Parsing != nil {
-				singleData := signals.AnalyzeSingle(*r.Parsing)
+			if signals.IsConfigFile(r.Filename) {
+				if singleData, ok := analyzeConfigFile(ctx, getAbsolutePath(r.Filename), r.Filename); ok {
+					fileResults[i].Signals = &singleData
+				}
+			} else if r.Parsing != nil {
+				singleData := signals.AnalyzeSingleWith(detectors, *r.Parsing, pathInPackage(r.Filename, root))
 				fileResults[i].Signals = &singleData
 			} else {
 				slog.WarnContext(ctx, "skipped signals analysis due to no parsing data", "filename", r.Filename)
@@ -133,5 +484,5 @@ func AnalyzePackageFiles(ctx context.Context, extractDir string, jsParserConfig
 		}
 	}
 
-	return fileResults, nil
+	return fileResults
 }
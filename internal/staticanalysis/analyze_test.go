@@ -48,12 +48,19 @@ func makeDesiredResult(files ...testFile) []SingleResult {
 			Parsing: &parsing.SingleResult{
 				Language:    parsing.JavaScript,
 				Identifiers: []token.Identifier{},
+				IdentifierCounts: map[token.IdentifierType]int{
+					token.Unknown: 0, token.Function: 0, token.Variable: 0, token.Parameter: 0, token.Class: 0,
+					token.Member: 1, token.Property: 0, token.StatementLabel: 0, token.Other: 0,
+				},
 				StringLiterals: []token.String{
-					{Value: "hi", Raw: `"hi"`, Entropy: math.Log(2.0)},
+					{Value: "hi", Raw: `"hi"`, Entropy: math.Log(2.0), Pos: token.Position{1, 12}},
 				},
 				IntLiterals:   []token.Int{},
 				FloatLiterals: []token.Float{},
 				Comments:      []token.Comment{},
+				Calls: []token.Call{
+					{Callee: "console.log", Args: []token.CallArg{{Type: "String", Value: "hi"}}, Pos: token.Position{1, 0}},
+				},
 			},
 			Signals: &signals.FileSignals{
 				IdentifierLengths:     valuecounts.New(),
@@ -64,6 +71,27 @@ func makeDesiredResult(files ...testFile) []SingleResult {
 				HexStrings:            []string{},
 				IPAddresses:           []string{},
 				URLs:                  []string{},
+				IndirectEvals:         []staticanalysis.IndirectEval{},
+				WasmInstantiations:    []staticanalysis.WasmInstantiation{},
+				LongDelays:            []staticanalysis.LongDelay{},
+				WalletAddresses:       []staticanalysis.WalletAddress{},
+				ClipboardAccesses:     []staticanalysis.ClipboardAccess{},
+				ReverseShells:         []staticanalysis.ReverseShell{},
+				InsecureTransport:     []staticanalysis.InsecureTransport{},
+				InternalAPIUsages:     []staticanalysis.InternalAPIUsage{},
+				NetworkRequests:       []staticanalysis.NetworkRequest{},
+				PlatformConditions:    []staticanalysis.PlatformCondition{},
+				EarlyExits:            []staticanalysis.EarlyExit{},
+				ConfigCommands:        []staticanalysis.ConfigCommand{},
+				InputCaptures:         []staticanalysis.InputCapture{},
+				TimingChecks:          []staticanalysis.TimingCheck{},
+				PackedCode:            []staticanalysis.PackedCode{},
+				ThreadUsages:          []staticanalysis.ThreadUsage{},
+				DecodedExecutions:     []staticanalysis.DecodedExecution{},
+				OutsideImports:        []staticanalysis.OutsideImport{},
+				NativeAddonLoads:      []staticanalysis.NativeAddonLoad{},
+				BinaryPayloads:        []staticanalysis.BinaryPayload{},
+				Findings:              []staticanalysis.Finding{},
 			},
 		}
 	}
@@ -117,3 +145,156 @@ func TestAnalyzePackageFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectPackageFiles(t *testing.T) {
+	extractDir := t.TempDir()
+	for _, name := range []string{"package/index.js", "package/lib/a.js", "package/lib/b.js", "package/test/t.js"} {
+		p := filepath.Join(extractDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abs := func(paths ...string) []string {
+		var result []string
+		for _, p := range paths {
+			result = append(result, filepath.Join(extractDir, filepath.FromSlash(p)))
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		selection []string
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "single file",
+			selection: []string{"package/index.js"},
+			want:      abs("package/index.js"),
+		},
+		{
+			name:      "directory and duplicate file",
+			selection: []string{"package/lib", "package/lib/a.js", "package/index.js"},
+			want:      abs("package/lib/a.js", "package/lib/b.js", "package/index.js"),
+		},
+		{
+			name:      "missing file",
+			selection: []string{"package/missing.js"},
+			wantErr:   true,
+		},
+		{
+			name:      "outside package",
+			selection: []string{"../etc/passwd"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectPackageFiles(extractDir, tt.selection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectPackageFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectPackageFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageRoot(t *testing.T) {
+	results := func(filenames ...string) []SingleResult {
+		var r []SingleResult
+		for _, f := range filenames {
+			r = append(r, SingleResult{Filename: f})
+		}
+		return r
+	}
+	tests := []struct {
+		name    string
+		results []SingleResult
+		want    string
+	}{
+		{"npm tarball", results("package/index.js", "package/lib/a.js"), "package"},
+		{"several top-level directories", results("pkg/__init__.py", "pkg-1.0.dist-info/METADATA"), ""},
+		{"file at the root", results("package/index.js", "setup.py"), ""},
+		{"no files", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := packageRoot(tt.results); got != tt.want {
+				t.Errorf("packageRoot() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if got := pathInPackage("package/lib/a.js", "package"); got != "lib/a.js" {
+		t.Errorf("pathInPackage() = %q, want %q", got, "lib/a.js")
+	}
+}
+
+func TestReusableResults(t *testing.T) {
+	previousResult := func(filename, hash string) SingleResult {
+		return SingleResult{
+			Filename: filename,
+			Basic:    &basicdata.FileData{SHA256: hash},
+			Parsing:  &parsing.SingleResult{Language: parsing.JavaScript},
+		}
+	}
+	unchanged := previousResult("pkg-1.0/index.js", "aaa")
+	timedOut := previousResult("pkg-1.0/big.js", "ccc")
+	timedOut.TimedOut = true
+	previous := []SingleResult{
+		unchanged,
+		previousResult("pkg-1.0/lib/changed.js", "bbb"),
+		timedOut,
+		{Filename: "pkg-1.0/nobasic.js"},
+		previousResult("pkg-1.0/moved.js", "ddd"),
+	}
+
+	fileResults := []SingleResult{
+		{Filename: "pkg-1.1/index.js"},
+		{Filename: "pkg-1.1/lib/changed.js"},
+		{Filename: "pkg-1.1/big.js"},
+		{Filename: "pkg-1.1/nobasic.js"},
+		{Filename: "pkg-1.1/lib/moved.js"},
+		{Filename: "pkg-1.1/new.js"},
+		{Filename: "pkg-1.1/unhashed.js"},
+	}
+	hashes := []string{"aaa", "bbb2", "ccc", "eee", "ddd", "fff", ""}
+
+	want := map[int]SingleResult{0: unchanged}
+	if got := reusableResults(fileResults, hashes, previous); !reflect.DeepEqual(got, want) {
+		t.Errorf("reusableResults() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeFilesReused(t *testing.T) {
+	extractDir := t.TempDir()
+	path := filepath.Join(extractDir, helloWorldJs.filename)
+	if err := os.WriteFile(path, helloWorldJs.contents, 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	want := makeDesiredResult(helloWorldJs)[0]
+	// the signals of the previous results are stale, and are computed again
+	previous := want
+	previous.Signals = &signals.FileSignals{URLs: []string{"https://example.com/stale"}}
+
+	// the parser is not run, since the only file is unchanged
+	runTask := map[Task]bool{Parsing: true, Signals: true}
+	config := newAnalyzeConfig([]Option{PreviousResults([]SingleResult{previous})})
+	got := analyzeFiles(context.Background(), extractDir, []string{path}, parsing.ParserConfig{}, runTask, config)
+	if len(got) != 1 || !got[0].Reused {
+		t.Fatalf("analyzeFiles() = %v, want a reused result", got)
+	}
+	if !reflect.DeepEqual(got[0].Parsing, want.Parsing) {
+		t.Errorf("Parsing = %v, want %v", got[0].Parsing, want.Parsing)
+	}
+	if !reflect.DeepEqual(got[0].Signals, want.Signals) {
+		t.Errorf("Signals = %v, want %v", got[0].Signals, want.Signals)
+	}
+}
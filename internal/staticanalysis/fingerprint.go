@@ -0,0 +1,85 @@
+package staticanalysis
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// simHashBits is the number of bits in a package fingerprint.
+const simHashBits = 64
+
+// fingerprintFeatures returns the number of occurrences of each feature of the
+// code in the parsed files in results. Features are identifier names, call targets,
+// modules imported by declarations and string literal values, prefixed by their kind so that e.g. a string with the
+// same value as an identifier is a distinct feature.
+func fingerprintFeatures(results []SingleResult) map[string]int {
+	features := make(map[string]int)
+	for _, r := range results {
+		if r.Parsing == nil {
+			continue
+		}
+		for _, ident := range r.Parsing.Identifiers {
+			features["identifier:"+ident.Name]++
+		}
+		for _, call := range r.Parsing.Calls {
+			features["call:"+call.Callee]++
+		}
+		for _, i := range r.Parsing.Imports {
+			features["import:"+i.Module]++
+		}
+		for _, s := range r.Parsing.StringLiterals {
+			features["string:"+s.Value]++
+		}
+	}
+	return features
+}
+
+// simHash computes the simhash of the given weighted features. Each bit of the
+// result is set if the total weight of the features whose hash has that bit set
+// is more than the total weight of those that do not.
+func simHash(features map[string]int) uint64 {
+	var weights [simHashBits]int
+	for feature, weight := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i] += weight
+			} else {
+				weights[i] -= weight
+			}
+		}
+	}
+
+	var result uint64
+	for i, w := range weights {
+		if w > 0 {
+			result |= 1 << i
+		}
+	}
+	return result
+}
+
+/*
+Fingerprint computes a locality-sensitive hash (simhash) over the identifiers, call
+targets and string literals in the files in results (as recorded by the Parsing task),
+weighted by the number of times they occur. Unlike a cryptographic hash, a small change
+to the code only changes a few bits of the fingerprint, so the fingerprints of two
+packages can be compared (see staticanalysis.Fingerprint.Distance) to cluster copied
+or forked malware, or to find a typosquat that copies the code of a popular package.
+
+Nil is returned if no files were parsed, or they have no features.
+*/
+func Fingerprint(results []SingleResult) *staticanalysis.Fingerprint {
+	features := fingerprintFeatures(results)
+	if len(features) == 0 {
+		return nil
+	}
+	return &staticanalysis.Fingerprint{
+		SimHash:  fmt.Sprintf("%016x", simHash(features)),
+		Features: len(features),
+	}
+}
@@ -0,0 +1,58 @@
+package staticanalysis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// fingerprintFile returns a parse result for a file with identifiers and strings
+// named by prefix, and the given calls.
+func fingerprintFile(prefix string, n int, calls ...string) SingleResult {
+	result := &parsing.SingleResult{}
+	for i := 0; i < n; i++ {
+		result.Identifiers = append(result.Identifiers, token.Identifier{Name: fmt.Sprintf("%sIdent%d", prefix, i), Type: token.Variable})
+		result.StringLiterals = append(result.StringLiterals, token.String{Value: fmt.Sprintf("%s string %d", prefix, i)})
+	}
+	for _, callee := range calls {
+		result.Calls = append(result.Calls, token.Call{Callee: callee})
+	}
+	return SingleResult{Filename: prefix + ".js", Parsing: result}
+}
+
+func TestFingerprint(t *testing.T) {
+	original := []SingleResult{fingerprintFile("pad", 20, "require", "String.prototype.padStart")}
+	// a copy with an extra call, as might be added to a typosquat
+	copied := []SingleResult{fingerprintFile("pad", 20, "require", "String.prototype.padStart", "child_process.exec")}
+	unrelated := []SingleResult{fingerprintFile("color", 20, "require", "console.log")}
+
+	fp := Fingerprint(original)
+	if fp == nil || len(fp.SimHash) != 16 || fp.Features != 42 {
+		t.Fatalf("Fingerprint() = %+v, want 16 digit hash of 42 features", fp)
+	}
+	if again := Fingerprint(original); *again != *fp {
+		t.Errorf("Fingerprint() = %+v, then %+v", fp, again)
+	}
+
+	copiedDistance, err := fp.Distance(*Fingerprint(copied))
+	if err != nil {
+		t.Fatalf("Distance() error = %v", err)
+	}
+	unrelatedDistance, err := fp.Distance(*Fingerprint(unrelated))
+	if err != nil {
+		t.Fatalf("Distance() error = %v", err)
+	}
+	if copiedDistance > 8 || unrelatedDistance <= copiedDistance {
+		t.Errorf("Distance() for copied code = %d, unrelated code = %d, want at most 8 and more", copiedDistance, unrelatedDistance)
+	}
+
+	if got := Fingerprint([]SingleResult{{Filename: "README.md"}}); got != nil {
+		t.Errorf("Fingerprint() of unparsed files = %+v, want nil", got)
+	}
+	if _, err := fp.Distance(staticanalysis.Fingerprint{SimHash: "not hex"}); err == nil {
+		t.Errorf("Distance() of invalid fingerprint succeeded")
+	}
+}
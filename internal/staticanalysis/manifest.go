@@ -0,0 +1,149 @@
+package staticanalysis
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// networkPurpose matches words in the name, description or keywords of a package
+// which suggest that it is expected to access the network.
+var networkPurpose = regexp.MustCompile(`(?i)\b(?:https?|network\w*|server|client|request\w*|fetch\w*|api|rest|proxy|sockets?|websockets?|download\w*|upload\w*|url|web|tcp|udp|dns|ip|ping|ssh|ftp|smtp|mail|graphql|rpc|grpc)\b`)
+
+// importedModules returns the modules imported by the files in results, in the
+// order they are first imported, whether by a call (e.g. to require) or by an import
+// declaration. Only files which were parsed are considered.
+func importedModules(results []SingleResult) []string {
+	var modules []string
+	for _, r := range results {
+		if r.Parsing == nil {
+			continue
+		}
+		imports := slices.Clone(r.Parsing.Imports)
+		for _, call := range r.Parsing.Calls {
+			if module := detections.ImportedModule(call); module != "" {
+				imports = append(imports, token.Import{Module: module, Pos: call.Pos})
+			}
+		}
+		slices.SortStableFunc(imports, func(a, b token.Import) int {
+			return utils.FirstNonZero(cmp.Compare(a.Pos.Row(), b.Pos.Row()), cmp.Compare(a.Pos.Col(), b.Pos.Col()))
+		})
+		for _, i := range imports {
+			if !slices.Contains(modules, i.Module) {
+				modules = append(modules, i.Module)
+			}
+		}
+	}
+	return modules
+}
+
+// hasNetworkPurpose returns whether the name, description or keywords of the
+// package suggest that it is expected to access the network.
+func hasNetworkPurpose(manifest *pkgmanager.Manifest) bool {
+	// split names such as "http-client" and "node_fetch" into words
+	words := strings.NewReplacer("-", " ", "_", " ").Replace(manifest.Name)
+	text := strings.Join(append([]string{words, manifest.Description}, manifest.Keywords...), " ")
+	return networkPurpose.MatchString(text)
+}
+
+/*
+CheckManifest compares the dependencies declared in the manifest of a package with
+the modules imported by the files in results (as recorded by the Parsing task), and
+returns the declared dependencies, scripts and executables along with any discrepancies.
+
+Imports of packages that are not declared as dependencies can indicate code that
+relies on packages installed some other way (e.g. by an install script), while
+declared runtime dependencies that are never imported may be unused, or only used
+by code that was not analysed. The import of built-in network modules such as http
+or net is noted as unexplained if the manifest does not suggest that the package
+accesses the network. These discrepancies are not necessarily malicious, but can be
+used to prioritise packages for further review. Executables named after common
+system commands are reported, since they can hijack those commands.
+
+Currently this is only meaningful for NPM packages, since only JavaScript files are
+parsed.
+*/
+func CheckManifest(manifest *pkgmanager.Manifest, results []SingleResult) *staticanalysis.ManifestResult {
+	result := &staticanalysis.ManifestResult{
+		Dependencies:       []staticanalysis.DeclaredDependency{},
+		Scripts:            []staticanalysis.DeclaredScript{},
+		UndeclaredImports:  []string{},
+		UnusedDependencies: []string{},
+		NetworkImports:     []string{},
+		Bins:               []staticanalysis.DeclaredBin{},
+		ShadowedCommands:   []string{},
+	}
+
+	declared := map[string]bool{manifest.Name: true}
+	for _, dep := range manifest.Dependencies {
+		declared[dep.Name] = true
+		result.Dependencies = append(result.Dependencies, staticanalysis.DeclaredDependency{
+			Name:    dep.Name,
+			Version: dep.Version,
+			Type:    string(dep.Type),
+		})
+	}
+
+	scriptNames := make([]string, 0, len(manifest.Scripts))
+	for name := range manifest.Scripts {
+		scriptNames = append(scriptNames, name)
+	}
+	slices.Sort(scriptNames)
+	for _, name := range scriptNames {
+		result.Scripts = append(result.Scripts, staticanalysis.DeclaredScript{Name: name, Script: manifest.Scripts[name]})
+	}
+
+	binNames := make([]string, 0, len(manifest.Bins))
+	for name := range manifest.Bins {
+		binNames = append(binNames, name)
+	}
+	slices.Sort(binNames)
+	for _, name := range binNames {
+		result.Bins = append(result.Bins, staticanalysis.DeclaredBin{Name: name, Path: manifest.Bins[name]})
+		if detections.IsSystemCommand(name) {
+			result.ShadowedCommands = append(result.ShadowedCommands, name)
+		}
+	}
+
+	imported := map[string]bool{}
+	for _, module := range importedModules(results) {
+		if detections.IsNodeNetworkModule(module) {
+			if name := detections.NodeBuiltinModule(module); !slices.Contains(result.NetworkImports, name) {
+				result.NetworkImports = append(result.NetworkImports, name)
+			}
+		}
+		pkg := detections.NPMPackageName(module)
+		if pkg == "" || imported[pkg] {
+			continue
+		}
+		imported[pkg] = true
+		if !declared[pkg] {
+			result.UndeclaredImports = append(result.UndeclaredImports, pkg)
+		}
+	}
+	slices.Sort(result.UndeclaredImports)
+	slices.Sort(result.NetworkImports)
+
+	for _, dep := range manifest.Dependencies {
+		if dep.Type != pkgmanager.RuntimeDependency || imported[dep.Name] {
+			continue
+		}
+		usedInScript := false
+		for _, script := range manifest.Scripts {
+			usedInScript = usedInScript || strings.Contains(script, dep.Name)
+		}
+		if !usedInScript {
+			result.UnusedDependencies = append(result.UnusedDependencies, dep.Name)
+		}
+	}
+
+	result.UnexplainedNetworkImports = len(result.NetworkImports) > 0 && !hasNetworkPurpose(manifest)
+	return result
+}
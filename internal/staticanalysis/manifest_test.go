@@ -0,0 +1,103 @@
+package staticanalysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func importCall(callee, module string) token.Call {
+	return token.Call{Callee: callee, Args: []token.CallArg{{Type: "String", Value: module}}}
+}
+
+func TestCheckManifest(t *testing.T) {
+	manifest := &pkgmanager.Manifest{
+		Name:        "left-pad-utils",
+		Description: "String padding utilities",
+		Dependencies: []pkgmanager.Dependency{
+			{Name: "lodash", Version: "^4.17.21", Type: pkgmanager.RuntimeDependency},
+			{Name: "node-pre-gyp", Version: "^0.17.0", Type: pkgmanager.RuntimeDependency},
+			{Name: "unused", Version: "1.0.0", Type: pkgmanager.RuntimeDependency},
+			{Name: "jest", Version: "^29.0.0", Type: pkgmanager.DevDependency},
+		},
+		Scripts: map[string]string{"test": "jest", "install": "node-pre-gyp install"},
+		Bins:    map[string]string{"pad": "bin/pad.js", "ls": "bin/ls.js", "NPM.cmd": "bin/npm.cmd"},
+	}
+	files := []SingleResult{
+		{
+			Filename: "package/index.js",
+			Parsing: &parsing.SingleResult{Calls: []token.Call{
+				importCall("require", "lodash/fp"),
+				importCall("require", "./lib/pad"),
+				importCall("require", "node:https"),
+				importCall("import", "axios"),
+				{Callee: "https.get", Args: []token.CallArg{{Type: "String", Value: "https://example.com"}}},
+			}},
+		},
+		{
+			Filename: "package/lib/pad.js",
+			Parsing: &parsing.SingleResult{
+				Calls: []token.Call{
+					importCall("require", "@scope/hidden/sub"),
+					importCall("require", "left-pad-utils/package.json"),
+				},
+				Imports: []token.Import{{Module: "net"}},
+			},
+		},
+		{
+			Filename: "package/README.md",
+		},
+	}
+
+	got := CheckManifest(manifest, files)
+	want := &staticanalysis.ManifestResult{
+		Dependencies: []staticanalysis.DeclaredDependency{
+			{Name: "lodash", Version: "^4.17.21", Type: "runtime"},
+			{Name: "node-pre-gyp", Version: "^0.17.0", Type: "runtime"},
+			{Name: "unused", Version: "1.0.0", Type: "runtime"},
+			{Name: "jest", Version: "^29.0.0", Type: "dev"},
+		},
+		Scripts: []staticanalysis.DeclaredScript{
+			{Name: "install", Script: "node-pre-gyp install"},
+			{Name: "test", Script: "jest"},
+		},
+		Bins: []staticanalysis.DeclaredBin{
+			{Name: "NPM.cmd", Path: "bin/npm.cmd"},
+			{Name: "ls", Path: "bin/ls.js"},
+			{Name: "pad", Path: "bin/pad.js"},
+		},
+		UndeclaredImports:         []string{"@scope/hidden", "axios"},
+		UnusedDependencies:        []string{"unused"},
+		NetworkImports:            []string{"https", "net"},
+		UnexplainedNetworkImports: true,
+		ShadowedCommands:          []string{"NPM.cmd", "ls"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckManifest() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckManifestNetworkPurpose(t *testing.T) {
+	files := []SingleResult{{Parsing: &parsing.SingleResult{Calls: []token.Call{importCall("require", "http")}}}}
+	tests := []struct {
+		name     string
+		manifest *pkgmanager.Manifest
+		want     bool
+	}{
+		{"name", &pkgmanager.Manifest{Name: "simple-http-client"}, false},
+		{"description", &pkgmanager.Manifest{Name: "x", Description: "Fetch data from a REST API"}, false},
+		{"keywords", &pkgmanager.Manifest{Name: "x", Keywords: []string{"proxy"}}, false},
+		{"unrelated", &pkgmanager.Manifest{Name: "color-utils", Description: "Convert between colour spaces"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckManifest(tt.manifest, files).UnexplainedNetworkImports; got != tt.want {
+				t.Errorf("CheckManifest().UnexplainedNetworkImports = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
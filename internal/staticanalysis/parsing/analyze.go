@@ -15,23 +15,40 @@ func processJsData(fileData singleParseData) SingleResult {
 	result := SingleResult{
 		Language: NoLanguage,
 		// Initialise with empty slices to avoid null values in JSON
-		Identifiers:    []token.Identifier{},
-		StringLiterals: []token.String{},
-		IntLiterals:    []token.Int{},
-		FloatLiterals:  []token.Float{},
-		Comments:       []token.Comment{},
+		Identifiers:       []token.Identifier{},
+		IdentifierCounts:  make(map[token.IdentifierType]int),
+		StringLiterals:    []token.String{},
+		IntLiterals:       []token.Int{},
+		FloatLiterals:     []token.Float{},
+		Comments:          []token.Comment{},
+		Calls:             []token.Call{},
+		Imports:           []token.Import{},
+		Assignments:       []token.Assignment{},
+		Conditions:        []token.Condition{},
+		TimingChecks:      []token.TimingCheck{},
+		DecodedExecutions: []token.DecodedExecution{},
+		BinaryPayloads:    []token.BinaryPayload{},
+		SourceEncoding:    fileData.SourceEncoding,
+		EmbeddedCode:      fileData.EmbeddedCode,
+		Stats:             fileData.Stats,
 	}
 
 	if !fileData.ValidInput {
+		result.Err = fileData.Err()
 		return result
 	}
 
 	// JavaScript is the only currently supported / valid language
 	result.Language = JavaScript
+	result.AST = fileData.AST
+
+	for _, t := range token.IdentifierTypes() {
+		result.IdentifierCounts[t] = fileData.IdentifierCounts[t]
+	}
 
 	for _, d := range fileData.Literals {
 		if d.GoType == "string" {
-			result.StringLiterals = append(result.StringLiterals, token.String{Value: d.Value.(string), Raw: d.RawValue})
+			result.StringLiterals = append(result.StringLiterals, token.String{Value: d.Value.(string), Raw: d.RawValue, Pos: d.Pos})
 		} else if d.GoType == "float64" {
 			if intValue, err := strconv.ParseInt(d.RawValue, 0, 64); err == nil {
 				result.IntLiterals = append(result.IntLiterals, token.Int{Value: intValue, Raw: d.RawValue})
@@ -50,8 +67,16 @@ func processJsData(fileData singleParseData) SingleResult {
 	}
 
 	for _, c := range fileData.Comments {
-		result.Comments = append(result.Comments, token.Comment{Text: c.Data})
+		result.Comments = append(result.Comments, token.Comment{Text: c.Data, Pos: c.Pos})
 	}
+
+	result.Calls = append(result.Calls, fileData.Calls...)
+	result.Imports = append(result.Imports, fileData.Imports...)
+	result.Assignments = append(result.Assignments, fileData.Assignments...)
+	result.Conditions = append(result.Conditions, fileData.Conditions...)
+	result.TimingChecks = append(result.TimingChecks, fileData.TimingChecks...)
+	result.DecodedExecutions = append(result.DecodedExecutions, fileData.DecodedExecutions...)
+	result.BinaryPayloads = append(result.BinaryPayloads, fileData.BinaryPayloads...)
 	return result
 }
 
@@ -90,6 +115,7 @@ If parsing is attempted in a given langauge and fails due to syntax errors, the
 for that language in the returned map is nil, with no other error.
 
 If an internal error occurs during parsing, parsing is interrupted and the error returned.
+See ErrParserCrashed, ErrDecodeFailed and ErrTimeout.
 
 Note: In JavaScript, there is no distinction between integer and floating point literals;
 they are normally both parsed as floating point. This function records a numeric literal
@@ -111,9 +137,45 @@ func Analyze(ctx context.Context, parserConfig ParserConfig, input externalcmd.I
 		resultsByFile[filename] = processJsData(jsData)
 	}
 
+	populateEntropies(resultsByFile)
+
+	return resultsByFile, nil
+}
+
+/*
+PopulateEntropies computes and sets the entropy values for identifiers and string
+literals in each of the given results, using character distributions aggregated
+across all results of the same language, as Registry.AnalyzeConcurrently does.
+
+It is for results which were not parsed together, e.g. when the results of files
+which have not changed are reused from an earlier analysis, so that their entropy
+values are the same as if all files had been parsed at once. Nil results are
+ignored.
+*/
+func PopulateEntropies(results []*SingleResult) {
+	byLanguage := map[Language]map[string]SingleResult{}
+	for i, r := range results {
+		if r == nil {
+			continue
+		}
+		if byLanguage[r.Language] == nil {
+			byLanguage[r.Language] = map[string]SingleResult{}
+		}
+		// the keys only need to be distinct
+		byLanguage[r.Language][strconv.Itoa(i)] = *r
+	}
+	for _, resultsByFile := range byLanguage {
+		populateEntropies(resultsByFile)
+	}
+}
+
+// populateEntropies computes and sets the entropy values for identifiers and
+// string literals in each of the given results, using character distributions
+// aggregated across all results.
+func populateEntropies(resultsByFile map[string]SingleResult) {
 	// TODO replace this with a global count across many packages from an ecosystem.
-	//  If more languages are added before this is done, the function below should be
-	//  modified to compute a separate distribution for identifiers each language.
+	//  Registry.AnalyzeConcurrently calls this separately for the results of each
+	//  backend, so that each language has its own distribution.
 	identifierProbs, stringProbs := computeCharacterDistributions(resultsByFile)
 
 	// populate entropy values for identifiers and string literals.
@@ -125,6 +187,4 @@ func Analyze(ctx context.Context, parserConfig ParserConfig, input externalcmd.I
 			r.StringLiterals[i].ComputeEntropy(stringProbs)
 		}
 	}
-
-	return resultsByFile, nil
 }
@@ -26,6 +26,16 @@ var identifierCharProbs = []map[rune]float64{
 	stringentropy.CharacterProbabilities([]string{"test", "a", "b", "c"}),
 }
 
+// identifierCounts returns a map with an entry for every identifier type,
+// with counts taken from the given map or zero.
+func identifierCounts(counts map[token.IdentifierType]int) map[token.IdentifierType]int {
+	result := make(map[token.IdentifierType]int)
+	for _, t := range token.IdentifierTypes() {
+		result[t] = counts[t]
+	}
+	return result
+}
+
 var analyzeTestcases = []analyzeTestcase{
 	{
 		name:     "console log hi",
@@ -36,12 +46,16 @@ var analyzeTestcases = []analyzeTestcase{
 				// Members excluded
 				//{Name: "log", Type: token.Member, Entropy: math.Log(3)},
 			},
+			IdentifierCounts: identifierCounts(map[token.IdentifierType]int{token.Member: 1}),
 			StringLiterals: []token.String{
-				{Value: "hi", Raw: `"hi"`, Entropy: math.Log(2)},
+				{Value: "hi", Raw: `"hi"`, Entropy: math.Log(2), Pos: token.Position{1, 12}},
 			},
 			IntLiterals:   []token.Int{},
 			FloatLiterals: []token.Float{},
 			Comments:      []token.Comment{},
+			Calls: []token.Call{
+				{Callee: "console.log", Unconditional: true, Args: []token.CallArg{{Type: "String", Value: "hi"}}, Pos: token.Position{1, 0}},
+			},
 		},
 	},
 	{
@@ -54,12 +68,14 @@ var a = "hello"
 			Identifiers: []token.Identifier{
 				{Name: "a", Type: token.Variable, Entropy: stringentropy.Calculate("a", identifierCharProbs[0])},
 			},
+			IdentifierCounts: identifierCounts(map[token.IdentifierType]int{token.Variable: 1}),
 			StringLiterals: []token.String{
-				{Value: "hello", Raw: `"hello"`, Entropy: stringentropy.Calculate("hello", literalCharProbs[0])},
+				{Value: "hello", Raw: `"hello"`, Entropy: stringentropy.Calculate("hello", literalCharProbs[0]), Pos: token.Position{2, 8}},
 			},
 			IntLiterals:   []token.Int{},
 			FloatLiterals: []token.Float{},
 			Comments:      []token.Comment{},
+			Calls:         []token.Call{},
 		},
 	},
 	{
@@ -83,9 +99,15 @@ function test(a, b = 2) {
 				{Name: "b", Type: token.Parameter, Entropy: stringentropy.Calculate("b", identifierCharProbs[1])},
 				{Name: "c", Type: token.Variable, Entropy: stringentropy.Calculate("c", identifierCharProbs[1])},
 			},
+			IdentifierCounts: identifierCounts(map[token.IdentifierType]int{
+				token.Function:  1,
+				token.Parameter: 2,
+				token.Member:    1,
+				token.Variable:  1,
+			}),
 			StringLiterals: []token.String{
-				{Value: "hello", Raw: `"hello"`, Entropy: stringentropy.Calculate("hello", literalCharProbs[1])},
-				{Value: "apple", Raw: `"apple"`, Entropy: stringentropy.Calculate("apple", literalCharProbs[1])},
+				{Value: "hello", Raw: `"hello"`, Entropy: stringentropy.Calculate("hello", literalCharProbs[1]), Pos: token.Position{3, 13}},
+				{Value: "apple", Raw: `"apple"`, Entropy: stringentropy.Calculate("apple", literalCharProbs[1]), Pos: token.Position{8, 9}},
 			},
 			IntLiterals: []token.Int{
 				{Value: 2, Raw: "2"},
@@ -94,18 +116,23 @@ function test(a, b = 2) {
 			},
 			FloatLiterals: []token.Float{},
 			Comments:      []token.Comment{},
+			Calls: []token.Call{
+				{Callee: "console.log", Args: []token.CallArg{{Type: "String", Value: "hello"}}, Pos: token.Position{3, 1}},
+			},
 		},
 	},
 	{
 		name:     "invalid 1",
 		jsSource: "this is not JavaScript",
 		expectedData: SingleResult{
-			Language:       NoLanguage,
-			Identifiers:    []token.Identifier{},
-			StringLiterals: []token.String{},
-			IntLiterals:    []token.Int{},
-			FloatLiterals:  []token.Float{},
-			Comments:       []token.Comment{},
+			Language:         NoLanguage,
+			Identifiers:      []token.Identifier{},
+			IdentifierCounts: map[token.IdentifierType]int{},
+			StringLiterals:   []token.String{},
+			IntLiterals:      []token.Int{},
+			FloatLiterals:    []token.Float{},
+			Comments:         []token.Comment{},
+			Calls:            []token.Call{},
 		},
 	},
 }
@@ -132,6 +159,9 @@ func TestAnalyze(t *testing.T) {
 			if !reflect.DeepEqual(got.Identifiers, tt.expectedData.Identifiers) {
 				t.Errorf("Identifiers mismatch: got %#v, want %v", got.Identifiers, tt.expectedData.Identifiers)
 			}
+			if !reflect.DeepEqual(got.IdentifierCounts, tt.expectedData.IdentifierCounts) {
+				t.Errorf("Identifier counts mismatch: got %v, want %v", got.IdentifierCounts, tt.expectedData.IdentifierCounts)
+			}
 			if !reflect.DeepEqual(got.StringLiterals, tt.expectedData.StringLiterals) {
 				t.Errorf("String literals mismatch: got %#v, want %v", got.StringLiterals, tt.expectedData.StringLiterals)
 			}
@@ -144,6 +174,9 @@ func TestAnalyze(t *testing.T) {
 			if !reflect.DeepEqual(got.Comments, tt.expectedData.Comments) {
 				t.Errorf("Comments mismatch: got %#v, want %v", got.Comments, tt.expectedData.Comments)
 			}
+			if !reflect.DeepEqual(got.Calls, tt.expectedData.Calls) {
+				t.Errorf("Calls mismatch: got %#v, want %v", got.Calls, tt.expectedData.Calls)
+			}
 		})
 	}
 }
@@ -0,0 +1,252 @@
+package parsing
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+)
+
+// ErrUnsafeArchiveEntry is returned (wrapped) for archive entries that are not
+// parsed because they are links, or have a path that escapes the archive root.
+var ErrUnsafeArchiveEntry = errors.New("unsafe archive entry")
+
+// ErrArchiveEntryTooLarge is returned (wrapped) for JavaScript archive entries that
+// are not parsed because they are larger than maxArchiveEntrySize.
+var ErrArchiveEntryTooLarge = errors.New("archive entry too large")
+
+// maxArchiveEntrySize is the maximum size in bytes of an archive entry that is
+// parsed. Only this many bytes (and one more) are read from larger entries.
+const maxArchiveEntrySize = 16 << 20
+
+// gzipMagic is the header at the start of gzip-compressed data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// archiveEntry is a single entry of a tar or zip archive.
+type archiveEntry struct {
+	name string
+	mode fs.FileMode
+	// link is true for symbolic links and hard links.
+	link bool
+	open func() (io.ReadCloser, error)
+}
+
+/*
+AnalyzeTarArchive parses the JavaScript files in the tar archive read from r (which
+may be gzip-compressed, as for npm packages), without extracting the archive. The
+JavaScript files are copied to a temporary directory, and parsed together with a
+single run of the parser. Files larger than maxArchiveEntrySize are not parsed, and
+have an error wrapping ErrArchiveEntryTooLarge.
+
+Results and errors are keyed by the cleaned path of each entry in the archive, and
+entropy values are computed over all the parsed files, as for AnalyzeConcurrently.
+Files are parsed if they have a JavaScript extension (e.g. ".js") or an interpreter
+directive naming node; other entries are skipped. Links (which are never followed)
+and entries with an absolute path or one that escapes the archive root (e.g.
+"../index.js") are not parsed, and have an error wrapping ErrUnsafeArchiveEntry.
+An error parsing the files (e.g. a timeout of the parser) is recorded for each of
+them, while an error reading the archive itself is returned, with no results.
+*/
+func AnalyzeTarArchive(ctx context.Context, parserConfig ParserConfig, r io.Reader) (map[string]SingleResult, map[string]error, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	next := func() (*archiveEntry, error) {
+		header, err := tr.Next()
+		if err != nil {
+			return nil, err
+		}
+		return &archiveEntry{
+			name: header.Name,
+			mode: header.FileInfo().Mode(),
+			link: header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink,
+			open: func() (io.ReadCloser, error) { return io.NopCloser(tr), nil },
+		}, nil
+	}
+	return analyzeArchive(ctx, parserConfig, next)
+}
+
+/*
+AnalyzeZipArchive parses the JavaScript files in the zip archive of the given size
+read from r, without extracting it to disk. Entries are selected and parsed as
+described by AnalyzeTarArchive.
+*/
+func AnalyzeZipArchive(ctx context.Context, parserConfig ParserConfig, r io.ReaderAt, size int64) (map[string]SingleResult, map[string]error, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read zip archive: %w", err)
+	}
+
+	files := zr.File
+	next := func() (*archiveEntry, error) {
+		if len(files) == 0 {
+			return nil, io.EOF
+		}
+		f := files[0]
+		files = files[1:]
+		return &archiveEntry{
+			name: f.Name,
+			mode: f.Mode(),
+			link: f.Mode()&fs.ModeSymlink != 0,
+			open: f.Open,
+		}, nil
+	}
+	return analyzeArchive(ctx, parserConfig, next)
+}
+
+// analyzeArchive parses the JavaScript files among the archive entries returned
+// by next, which returns io.EOF after the last entry.
+func analyzeArchive(ctx context.Context, parserConfig ParserConfig, next func() (*archiveEntry, error)) (map[string]SingleResult, map[string]error, error) {
+	dir, err := os.MkdirTemp("", "package-analysis-archive-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp directory for archive entries: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			slog.ErrorContext(ctx, "could not remove archive entry directory", "path", dir, "error", err)
+		}
+	}()
+
+	resultsByFile := make(map[string]SingleResult)
+	errorsByFile := make(map[string]error)
+	// names of the entries copied to each path in dir
+	names := make(map[string]string)
+	var paths []string
+	for {
+		entry, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read archive entry: %w", err)
+		}
+
+		name, err := archiveEntryName(entry)
+		if err != nil {
+			errorsByFile[entry.name] = err
+			continue
+		}
+		if !entry.mode.IsRegular() {
+			continue
+		}
+
+		// the extension is kept for the parser, which may use it (e.g. for modules)
+		ext := strings.ToLower(path.Ext(name))
+		if !slices.Contains(javaScriptExtensions, ext) {
+			ext = ".js"
+		}
+		entryPath := filepath.Join(dir, fmt.Sprintf("%d%s", len(paths), ext))
+		ok, err := copyJavaScriptEntry(name, entry, entryPath)
+		if errors.Is(err, ErrArchiveEntryTooLarge) {
+			errorsByFile[name] = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			names[entryPath] = name
+			paths = append(paths, entryPath)
+		}
+	}
+	if len(paths) == 0 {
+		return resultsByFile, errorsByFile, nil
+	}
+
+	jsResults, _, err := parseJS(ctx, parserConfig, externalcmd.MultipleFileInput(paths))
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if err != nil {
+		for _, name := range names {
+			errorsByFile[name] = err
+		}
+		return resultsByFile, errorsByFile, nil
+	}
+	for entryPath, jsData := range jsResults {
+		if name, ok := names[entryPath]; ok {
+			resultsByFile[name] = processJsData(jsData)
+		}
+	}
+
+	populateEntropies(resultsByFile)
+
+	return resultsByFile, errorsByFile, nil
+}
+
+// archiveEntryName returns the cleaned path of the entry, or an error wrapping
+// ErrUnsafeArchiveEntry if it is a link or its path is not local to the archive.
+func archiveEntryName(entry *archiveEntry) (string, error) {
+	if entry.link {
+		return "", fmt.Errorf("%w: %s is a link", ErrUnsafeArchiveEntry, entry.name)
+	}
+	name := path.Clean(strings.ReplaceAll(entry.name, `\`, "/"))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%w: %s escapes the archive root", ErrUnsafeArchiveEntry, entry.name)
+	}
+	return name, nil
+}
+
+// copyJavaScriptEntry copies the contents of the archive entry with the given name
+// to dest, and returns true, if it is a JavaScript file. Only the start of other
+// files is read. If the entry is larger than maxArchiveEntrySize, an error wrapping
+// ErrArchiveEntryTooLarge is returned, and dest is removed.
+func copyJavaScriptEntry(name string, entry *archiveEntry, dest string) (bool, error) {
+	rc, err := entry.open()
+	if err != nil {
+		return false, fmt.Errorf("failed to open archive entry %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	br := bufio.NewReaderSize(rc, maxShebangLength)
+	if !slices.Contains(javaScriptExtensions, strings.ToLower(path.Ext(name))) {
+		start, _ := br.Peek(maxShebangLength)
+		if !slices.Contains(javaScriptInterpreters, parseInterpreter(string(start))) {
+			return false, nil
+		}
+	}
+
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return false, fmt.Errorf("failed to create file for archive entry %s: %w", name, err)
+	}
+	n, err := io.Copy(f, io.LimitReader(br, maxArchiveEntrySize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxArchiveEntrySize {
+		err = fmt.Errorf("%w: %s is larger than %d bytes", ErrArchiveEntryTooLarge, name, maxArchiveEntrySize)
+	}
+	if err != nil {
+		_ = os.Remove(dest)
+		if errors.Is(err, ErrArchiveEntryTooLarge) {
+			return false, err
+		}
+		return false, fmt.Errorf("failed to read archive entry %s: %w", name, err)
+	}
+	return true, nil
+}
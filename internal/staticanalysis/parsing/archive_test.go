@@ -0,0 +1,171 @@
+package parsing
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testArchiveEntry struct {
+	name    string
+	content string
+	symlink bool
+}
+
+var testArchiveEntries = []testArchiveEntry{
+	{name: "package/index.js", content: `console.log("index");`},
+	{name: "package/bin/cli", content: "#!/usr/bin/env node\nconsole.log(\"cli\");"},
+	{name: "package/README.md", content: "# readme"},
+	{name: "package/../../evil.js", content: `console.log("evil");`},
+	{name: "/etc/evil.js", content: `console.log("evil");`},
+	{name: "package/link.js", content: "/etc/passwd", symlink: true},
+}
+
+func makeTarGz(t *testing.T, entries []testArchiveEntry) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		if e.symlink {
+			header = &tar.Header{Name: e.name, Mode: 0o777, Typeflag: tar.TypeSymlink, Linkname: e.content}
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if !e.symlink {
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries []testArchiveEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name}
+		header.SetMode(0o644)
+		if e.symlink {
+			header.SetMode(0o777 | fs.ModeSymlink)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyzeArchive(t *testing.T) {
+	parserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	tarGz := makeTarGz(t, testArchiveEntries)
+	zipData := makeZip(t, testArchiveEntries)
+	tests := []struct {
+		name    string
+		analyze func() (map[string]SingleResult, map[string]error, error)
+	}{
+		{
+			name: "tar.gz",
+			analyze: func() (map[string]SingleResult, map[string]error, error) {
+				return AnalyzeTarArchive(context.Background(), parserConfig, bytes.NewReader(tarGz))
+			},
+		},
+		{
+			name: "zip",
+			analyze: func() (map[string]SingleResult, map[string]error, error) {
+				return AnalyzeZipArchive(context.Background(), parserConfig, bytes.NewReader(zipData), int64(len(zipData)))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, errs, err := tt.analyze()
+			if err != nil {
+				t.Fatalf("analyze() error = %v", err)
+			}
+
+			var parsed []string
+			for name := range results {
+				parsed = append(parsed, name)
+			}
+			slices.Sort(parsed)
+			if want := []string{"package/bin/cli", "package/index.js"}; !reflect.DeepEqual(parsed, want) {
+				t.Errorf("analyze() parsed %v, want %v", parsed, want)
+			}
+			r := results["package/index.js"]
+			literals := []string{}
+			for _, s := range r.StringLiterals {
+				literals = append(literals, s.Value)
+			}
+			if r.Language != JavaScript || !slices.Contains(literals, "index") {
+				t.Errorf("result for index.js = %s with strings %v, want JavaScript with %q", r.Language, literals, "index")
+			}
+
+			wantErrs := []string{"/etc/evil.js", "package/../../evil.js", "package/link.js"}
+			var gotErrs []string
+			for name, err := range errs {
+				gotErrs = append(gotErrs, name)
+				if !errors.Is(err, ErrUnsafeArchiveEntry) {
+					t.Errorf("error for %s = %v, want %v", name, err, ErrUnsafeArchiveEntry)
+				}
+			}
+			slices.Sort(gotErrs)
+			if !reflect.DeepEqual(gotErrs, wantErrs) {
+				t.Errorf("analyze() errors for %v, want %v", gotErrs, wantErrs)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTarArchiveInvalid(t *testing.T) {
+	// the archive is read before the parser is run
+	_, _, err := AnalyzeTarArchive(context.Background(), ParserConfig{}, bytes.NewReader([]byte("not a tar archive, but long enough to have a header")))
+	if err == nil {
+		t.Errorf("AnalyzeTarArchive() of invalid archive succeeded")
+	}
+}
+
+func TestAnalyzeTarArchiveTooLarge(t *testing.T) {
+	// the parser is not run, since there is no other JavaScript file
+	tarGz := makeTarGz(t, []testArchiveEntry{
+		{name: "package/big.js", content: strings.Repeat("x", maxArchiveEntrySize+1)},
+		{name: "package/README.md", content: "readme"},
+	})
+	results, errs, err := AnalyzeTarArchive(context.Background(), ParserConfig{}, bytes.NewReader(tarGz))
+	if err != nil {
+		t.Fatalf("AnalyzeTarArchive() error = %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("AnalyzeTarArchive() results = %v, want none", results)
+	}
+	if err := errs["package/big.js"]; !errors.Is(err, ErrArchiveEntryTooLarge) || len(errs) != 1 {
+		t.Errorf("AnalyzeTarArchive() errors = %v, want %v for package/big.js", errs, ErrArchiveEntryTooLarge)
+	}
+}
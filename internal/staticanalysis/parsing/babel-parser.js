@@ -22,11 +22,25 @@ function position(node) {
 
 // Holds all parsing data for a single file
 class ParseData {
-    constructor() {
+    // tokenTypes is a Set of the token types to log, or null to log all types
+    constructor(tokenTypes = null) {
         // holds token information (function, variable names)
         this.tokens = [];
         // holds status information (info, errors)
         this.status = [];
+        this.tokenTypes = tokenTypes;
+    }
+
+    // returns whether tokens of the given type should be logged
+    wants(tokenType) {
+        return this.tokenTypes === null || this.tokenTypes.has(tokenType);
+    }
+
+    // tokenTypes is not part of the output
+    toJSON() {
+        const output = { ...this };
+        delete output.tokenTypes;
+        return output;
     }
 
     static makeOutputDict(type, subtype, data, pos, extra = null) {
@@ -42,10 +56,16 @@ class ParseData {
     }
 
     logComment(commentType, comment, pos) {
+        if (!this.wants("Comment")) {
+            return;
+        }
         this.tokens.push(ParseData.makeOutputDict("Comment", commentType, comment, pos));
     }
 
     logIdentifierOrPrivateName(identifierType, node) {
+        if (!this.wants("Identifier")) {
+            return;
+        }
         // if node is a PrivateName, the corresponding Identifier can be found as node.id
         let identifierNode;
         switch (node.type) {
@@ -71,6 +91,9 @@ class ParseData {
     }
 
     logLiteral(literalType, value, pos, inArray, extra = null) {
+        if (!this.wants("Literal")) {
+            return;
+        }
         if (value === undefined) {
             console.log("Error: undefined literal value at pos " + pos);
             return;
@@ -85,6 +108,9 @@ class ParseData {
     }
 
     logTemplate(literal, pos, inArray) {
+        if (!this.wants("Literal")) {
+            return;
+        }
         // template info contains list of strings in between templated parts, plus list of template expressions.
         // We only log the string parts, concatenated together. Expressions are logged elsewhere (as literals)
         const cookedStrings = [];
@@ -103,6 +129,959 @@ class ParseData {
 
         this.logLiteral("StringTemplate", cookedStrings.join(sep), pos, inArray, extra);
     }
+
+    logCall(callType, callee, pos, extra) {
+        if (!this.wants("Call")) {
+            return;
+        }
+        this.tokens.push(ParseData.makeOutputDict("Call", callType, callee, pos, extra));
+    }
+
+    logImport(module, pos, isExport) {
+        if (!this.wants("Import")) {
+            return;
+        }
+        this.tokens.push(ParseData.makeOutputDict("Import", isExport ? "Export" : "Import", module, pos));
+    }
+
+    logAssignment(assignmentType, target, pos, value) {
+        if (!this.wants("Assignment")) {
+            return;
+        }
+        this.tokens.push(ParseData.makeOutputDict("Assignment", assignmentType, target, pos, { value: value }));
+    }
+
+    logCondition(conditionType, subject, pos, operator, value, gatedLength) {
+        if (!this.wants("Condition")) {
+            return;
+        }
+        const extra = { operator: operator, value: value, gatedLength: gatedLength };
+        this.tokens.push(ParseData.makeOutputDict("Condition", conditionType, subject, pos, extra));
+    }
+
+    logTimingCheck(api, pos, startPos, endPos, gatedLength) {
+        if (!this.wants("TimingCheck")) {
+            return;
+        }
+        const extra = { startPos: startPos, endPos: endPos, gatedLength: gatedLength };
+        this.tokens.push(ParseData.makeOutputDict("TimingCheck", "Subtraction", api, pos, extra));
+    }
+
+    logDecodedExecution(sink, pos, decoder, encoding, decodePos, variable) {
+        if (!this.wants("DecodedExecution")) {
+            return;
+        }
+        const extra = { decoder: decoder, encoding: encoding, decodePos: decodePos, variable: variable };
+        this.tokens.push(ParseData.makeOutputDict("DecodedExecution", "Call", sink, pos, extra));
+    }
+
+    logBinaryPayload(constructor, pos, size, writes, sink) {
+        if (!this.wants("BinaryPayload")) {
+            return;
+        }
+        const extra = { size: size, sink: sink };
+        this.tokens.push(ParseData.makeOutputDict("BinaryPayload", writes ? "Writes" : "Array", constructor, pos, extra));
+    }
+}
+
+/*
+ staticString returns the value of an expression that evaluates to a constant string,
+ e.g. "eval", `eval` or "ev" + "al". If the value cannot be determined, null is returned.
+ */
+function staticString(node) {
+    switch (node.type) {
+        case "StringLiteral":
+            return node.value;
+        case "NumericLiteral":
+            return String(node.value);
+        case "TemplateLiteral":
+            if (node.expressions.length === 0) {
+                return node.quasis.map((q) => (q.value.cooked !== null) ? q.value.cooked : "").join("");
+            }
+            return null;
+        case "BinaryExpression":
+            if (node.operator === "+") {
+                const left = staticString(node.left);
+                const right = staticString(node.right);
+                if (left !== null && right !== null) {
+                    return left + right;
+                }
+            }
+            return null;
+        default:
+            return null;
+    }
+}
+
+/*
+ stringTemplate describes an expression that builds a string from constant and non-constant
+ parts (a template literal, or a concatenation with a constant string) as a template, in
+ which each non-constant part is replaced by ${name}. The name is that of the variable,
+ member or function call used (see expressionName), or empty if it cannot be determined,
+ e.g. "https://" + host + "/?d=" + process.env.HOME is described as
+ "https://${host}/?d=${process.env.HOME}". If node does not build a string, null is returned.
+ */
+function stringTemplate(node) {
+    const value = staticString(node);
+    if (value !== null) {
+        return value;
+    }
+    switch (node.type) {
+        case "TemplateLiteral":
+            return node.quasis.map((q, i) => {
+                const text = (q.value.cooked !== null) ? q.value.cooked : "";
+                return (i < node.expressions.length) ? text + templatePlaceholder(node.expressions[i]) : text;
+            }).join("");
+        case "BinaryExpression": {
+            if (node.operator !== "+") {
+                return null;
+            }
+            const left = stringTemplate(node.left);
+            const right = stringTemplate(node.right);
+            if (left === null && right === null) {
+                return null;
+            }
+            return ((left !== null) ? left : templatePlaceholder(node.left)) +
+                ((right !== null) ? right : templatePlaceholder(node.right));
+        }
+        case "ParenthesizedExpression":
+            return stringTemplate(node.expression);
+        default:
+            return null;
+    }
+}
+
+// templatePlaceholder returns the placeholder for a non-constant part of a string template.
+function templatePlaceholder(node) {
+    const name = expressionName(node, { computed: false, indirect: false });
+    return "${" + ((name !== null) ? name : "") + "}";
+}
+
+/*
+ expressionName returns the dotted name of an expression that refers to a (possibly nested)
+ object member, e.g. "console.log" or "this.eval". Computed member accesses are resolved
+ if the property evaluates to a constant string, e.g. this["ev" + "al"] is named "this.eval".
+ For sequence expressions such as (0, eval), the name of the last expression is returned.
+ The result of a call is named after the function called, e.g. "getObject().method", except
+ for calls to require() or import() with a constant module name, which are named e.g.
+ 'require("fs")'.
+ If a name cannot be determined, null is returned.
+
+ info.computed is set to true if a computed member access was resolved, and info.indirect
+ is set to true if a sequence expression was resolved.
+ */
+function expressionName(node, info) {
+    switch (node.type) {
+        case "Identifier":
+            return node.name;
+        case "ThisExpression":
+            return "this";
+        case "Super":
+            return "super";
+        case "Import":
+            // callee of a dynamic import, e.g. import("module")
+            return "import";
+        case "SequenceExpression":
+            info.indirect = true;
+            return expressionName(node.expressions[node.expressions.length - 1], info);
+        case "CallExpression":
+        case "OptionalCallExpression": {
+            const calleeName = expressionName(node.callee, info);
+            if (calleeName === null) {
+                return null;
+            }
+            if ((calleeName === "require" || calleeName === "import") && node.arguments.length > 0) {
+                const moduleName = staticString(node.arguments[0]);
+                if (moduleName !== null) {
+                    return `${calleeName}(${JSON.stringify(moduleName)})`;
+                }
+            }
+            return calleeName + "()";
+        }
+        case "MemberExpression":
+        case "OptionalMemberExpression": {
+            const objectName = expressionName(node.object, info);
+            if (objectName === null) {
+                return null;
+            }
+            let propertyName = null;
+            if (!node.computed) {
+                propertyName = (node.property.type === "PrivateName") ? "#" + node.property.id.name : node.property.name;
+            } else {
+                propertyName = staticString(node.property);
+                info.computed = true;
+            }
+            return (propertyName !== null) ? objectName + "." + propertyName : null;
+        }
+        default:
+            return null;
+    }
+}
+
+/*
+ staticNumber returns the value of an expression made up of numeric literals and the
+ arithmetic operators +, -, * and / (e.g. 5 * 60 * 1000), or null if it is not such
+ an expression or the result is not finite.
+ */
+function staticNumber(node) {
+    switch (node.type) {
+        case "NumericLiteral":
+            return node.value;
+        case "ParenthesizedExpression":
+            return staticNumber(node.expression);
+        case "UnaryExpression": {
+            const value = (node.operator === "-" || node.operator === "+") ? staticNumber(node.argument) : null;
+            return (value !== null && node.operator === "-") ? -value : value;
+        }
+        case "BinaryExpression": {
+            const left = staticNumber(node.left);
+            const right = (left !== null) ? staticNumber(node.right) : null;
+            if (right === null) {
+                return null;
+            }
+            let result;
+            switch (node.operator) {
+                case "+": result = left + right; break;
+                case "-": result = left - right; break;
+                case "*": result = left * right; break;
+                case "/": result = left / right; break;
+                default: return null;
+            }
+            return Number.isFinite(result) ? result : null;
+        }
+        default:
+            return null;
+    }
+}
+
+// describeArgument returns the type and (where it can be determined) value of a call argument.
+function describeArgument(node) {
+    switch (node.type) {
+        case "NumericLiteral":
+        case "BigIntLiteral":
+            return { type: "Numeric", value: String(node.value) };
+        case "BinaryExpression":
+        case "UnaryExpression":
+        case "ParenthesizedExpression": {
+            const value = staticNumber(node);
+            if (value !== null) {
+                return { type: "Numeric", value: String(value) };
+            }
+            break;
+        }
+        case "BooleanLiteral":
+            return { type: "Boolean", value: String(node.value) };
+        case "NullLiteral":
+            return { type: "Null", value: "" };
+        case "FunctionExpression":
+        case "ArrowFunctionExpression":
+            return { type: "Function", value: "" };
+        case "ObjectExpression":
+            return { type: "Object", value: "" };
+        case "ArrayExpression":
+            return { type: "Array", value: "" };
+        case "SpreadElement":
+            return { type: "Spread", value: "" };
+    }
+
+    const value = staticString(node);
+    if (value !== null) {
+        return { type: "String", value: value };
+    }
+
+    const template = stringTemplate(node);
+    if (template !== null) {
+        const type = (node.type === "TemplateLiteral") ? "Template" : "Concatenation";
+        return { type: type, value: template };
+    }
+
+    const info = { computed: false, indirect: false };
+    switch (node.type) {
+        case "Identifier":
+            return { type: "Identifier", value: node.name };
+        case "MemberExpression":
+        case "OptionalMemberExpression": {
+            const name = expressionName(node, info);
+            return { type: "Member", value: (name !== null) ? name : "" };
+        }
+        case "CallExpression":
+        case "OptionalCallExpression":
+        case "NewExpression": {
+            const name = expressionName(node.callee, info);
+            return { type: "Call", value: (name !== null) ? name : "" };
+        }
+        default:
+            return { type: "Expression", value: "" };
+    }
+}
+
+/*
+ isUnconditional returns whether the expression at path is evaluated whenever the
+ program is run (or the module is loaded), i.e. it is part of a statement at the top
+ level of the program, and not inside a function, a loop or try statement, or a
+ branch of a conditional. The right-hand side of a logical expression (e.g. f() in
+ a || f()) and optional calls (e.g. a?.f()) are conditional too.
+ */
+function isUnconditional(path) {
+    let child = path.node;
+    for (let parent = path.parentPath; parent !== null; parent = parent.parentPath) {
+        const node = parent.node;
+        switch (node.type) {
+            case "Program":
+                return true;
+            case "LogicalExpression":
+                if (child !== node.left) {
+                    return false;
+                }
+                break;
+            case "IfStatement":
+            case "ConditionalExpression":
+                if (child !== node.test) {
+                    return false;
+                }
+                break;
+            case "ExpressionStatement":
+            case "BlockStatement":
+            case "CallExpression":
+            case "NewExpression":
+            case "MemberExpression":
+            case "BinaryExpression":
+            case "SequenceExpression":
+            case "ParenthesizedExpression":
+            case "AwaitExpression":
+            case "UnaryExpression":
+            case "AssignmentExpression":
+            case "VariableDeclarator":
+            case "VariableDeclaration":
+                break;
+            default:
+                return false;
+        }
+        child = node;
+    }
+    return false;
+}
+
+/*
+ visitCall logs calls to functions (and constructors) whose name can be determined,
+ along with information about their arguments.
+ */
+function visitCall(path, parseData) {
+    const node = path.node;
+    const info = { computed: false, indirect: false };
+    const callee = expressionName(node.callee, info);
+    if (callee === null) {
+        return;
+    }
+
+    const callType = (node.type === "NewExpression") ? "New" : "Call";
+    const extra = {
+        computed: info.computed,
+        indirect: info.indirect,
+        unconditional: isUnconditional(path),
+        args: node.arguments.map(describeArgument),
+    };
+    parseData.logCall(callType, callee, position(node), extra);
+}
+
+/*
+ visitModuleDeclaration logs import declarations and re-exports of another module
+ (e.g. import x from "module" or export * from "module") as imports of the module.
+ Dynamic imports (import("module")) are logged as calls instead.
+ */
+function visitModuleDeclaration(path, parseData) {
+    const node = path.node;
+    if (!node.source || node.source.type !== "StringLiteral") {
+        return;
+    }
+    parseData.logImport(node.source.value, position(node), node.type !== "ImportDeclaration");
+}
+
+// matches property names in the style of environment variables, e.g. NODE_OPTIONS
+const envVarNamePattern = /^[A-Z][A-Z0-9]*(_[A-Z0-9]+)+$/;
+
+/*
+ constantValue describes the value of node, like describeArgument, if it is a boolean,
+ number, string or null constant. Minified booleans (!0 and !1) are described as Boolean.
+ Otherwise, null is returned.
+ */
+function constantValue(node) {
+    if (node.type === "UnaryExpression" && node.operator === "!" && node.argument.type === "NumericLiteral") {
+        return { type: "Boolean", value: String(!node.argument.value) };
+    }
+    const value = describeArgument(node);
+    switch (value.type) {
+        case "Boolean":
+        case "Numeric":
+        case "String":
+        case "Null":
+            return value;
+        default:
+            return null;
+    }
+}
+
+/*
+ visitAssignment logs constant values assigned to named properties, either in an object
+ literal (e.g. { rejectUnauthorized: false }) or by assignment to an object member (e.g.
+ process.env.NODE_TLS_REJECT_UNAUTHORIZED = "0"). The target of a property in an object
+ literal is the property name, and that of a member assignment is its dotted name, as for
+ a callee. To limit the size of the output, string values are only logged for properties
+ with names in the style of environment variables, since other string values are already
+ logged as literals.
+ */
+function visitAssignment(path, parseData) {
+    const node = path.node;
+    let assignmentType, target, valueNode;
+    if (node.type === "ObjectProperty") {
+        if (node.computed || node.shorthand) {
+            return;
+        }
+        assignmentType = "Property";
+        target = (node.key.type === "StringLiteral") ? node.key.value : node.key.name;
+        valueNode = node.value;
+    } else {
+        if (node.operator !== "=" || (node.left.type !== "MemberExpression" && node.left.type !== "OptionalMemberExpression")) {
+            return;
+        }
+        assignmentType = "Member";
+        target = expressionName(node.left, { computed: false, indirect: false });
+        valueNode = node.right;
+    }
+    if (typeof target !== "string") {
+        return;
+    }
+
+    const value = constantValue(valueNode);
+    if (value === null) {
+        return;
+    }
+    const name = target.substring(target.lastIndexOf(".") + 1);
+    if (value.type === "String" && !envVarNamePattern.test(name)) {
+        return;
+    }
+    parseData.logAssignment(assignmentType, target, position(node), value);
+}
+
+/*
+ platformSubjectPattern matches the names of expressions (see expressionName) that
+ identify the operating system the code is running on, e.g. process.platform,
+ os.platform() or require("os").type().
+ */
+const platformSubjectPattern = /^(process\.platform|.+\.platform\(\)|(os|require\("(node:)?os"\))\.type\(\))$/;
+
+function platformSubject(node) {
+    const name = expressionName(node, { computed: false, indirect: false });
+    return (name !== null && platformSubjectPattern.test(name)) ? name : null;
+}
+
+// sourceLength returns the number of characters of source code spanned by node,
+// or 0 if node is null.
+function sourceLength(node) {
+    if (node === null || node === undefined || typeof node.start !== "number" || typeof node.end !== "number") {
+        return 0;
+    }
+    return node.end - node.start;
+}
+
+/*
+ gatedLength returns the number of characters of source code whose execution depends
+ on the value of the condition at path: the branches of an if statement or conditional
+ expression in whose test it appears, and the right-hand side of any logical expressions
+ that it is the left-hand side of, e.g. for a === b in (a === b && f()) || g().
+ */
+function gatedLength(path) {
+    let length = 0;
+    let child = path.node;
+    for (let parent = path.parentPath; parent !== null; parent = parent.parentPath) {
+        const node = parent.node;
+        switch (node.type) {
+            case "LogicalExpression":
+                if (child === node.left) {
+                    length += sourceLength(node.right);
+                }
+                break;
+            case "UnaryExpression":
+                if (node.operator !== "!") {
+                    return length;
+                }
+                break;
+            case "ParenthesizedExpression":
+                break;
+            case "IfStatement":
+            case "ConditionalExpression":
+                if (child === node.test) {
+                    length += sourceLength(node.consequent) + sourceLength(node.alternate);
+                }
+                return length;
+            default:
+                return length;
+        }
+        child = node;
+    }
+    return length;
+}
+
+/*
+ visitCondition logs comparisons of an expression identifying the platform with a
+ constant string, e.g. process.platform === "win32", along with the amount of code
+ that is only run depending on the result. For switch statements on such an expression,
+ each case with a constant string is logged, with the length of its statements.
+ */
+function visitCondition(path, parseData) {
+    const node = path.node;
+    if (node.type === "SwitchStatement") {
+        const subject = platformSubject(node.discriminant);
+        if (subject === null) {
+            return;
+        }
+        for (const switchCase of node.cases) {
+            const value = (switchCase.test !== null) ? staticString(switchCase.test) : null;
+            if (value === null) {
+                continue;
+            }
+            const length = switchCase.consequent.reduce((sum, statement) => sum + sourceLength(statement), 0);
+            parseData.logCondition("SwitchCase", subject, position(switchCase), "case", value, length);
+        }
+        return;
+    }
+
+    if (!["===", "==", "!==", "!="].includes(node.operator)) {
+        return;
+    }
+    let subject = platformSubject(node.left);
+    let valueNode = node.right;
+    if (subject === null) {
+        subject = platformSubject(node.right);
+        valueNode = node.left;
+    }
+    if (subject === null) {
+        return;
+    }
+    const value = staticString(valueNode);
+    if (value === null) {
+        return;
+    }
+    parseData.logCondition("Comparison", subject, position(node), node.operator, value, gatedLength(path));
+}
+
+/*
+ timingReadFunctions are the names of functions (see expressionName) which return the
+ current time, for measuring how long code takes to run.
+ */
+const timingReadFunctions = new Set(["Date.now", "performance.now", "process.hrtime.bigint"]);
+
+/*
+ timingRead returns the name of the API used if node reads the current time, e.g.
+ Date.now(), performance.now(), new Date().getTime() or +new Date(). Otherwise, null
+ is returned.
+ */
+function timingRead(node) {
+    switch (node.type) {
+        case "ParenthesizedExpression":
+            return timingRead(node.expression);
+        case "UnaryExpression":
+            return (node.operator === "+") ? timingRead(node.argument) : null;
+        case "NewExpression":
+            // a Date is converted to a number of milliseconds when subtracted
+            return (node.callee.type === "Identifier" && node.callee.name === "Date" && node.arguments.length === 0) ? "new Date()" : null;
+        case "CallExpression":
+        case "OptionalCallExpression": {
+            const callee = node.callee;
+            if (node.arguments.length !== 0) {
+                return null;
+            }
+            if (callee.type === "MemberExpression" && !callee.computed && ["getTime", "valueOf"].includes(callee.property.name)) {
+                return (timingRead(callee.object) === "new Date()") ? "new Date()" : null;
+            }
+            let name = expressionName(callee, { computed: false, indirect: false });
+            if (name === null) {
+                return null;
+            }
+            name = name.replace(/^(window|self|globalThis|global)\./, "");
+            return timingReadFunctions.has(name) ? name : null;
+        }
+        default:
+            return null;
+    }
+}
+
+/*
+ timingOperand returns the node that reads the time for an operand of a subtraction:
+ the operand itself, or the initial value of a variable declared in the same function,
+ e.g. Date.now() for start in Date.now() - start after const start = Date.now().
+ Otherwise, null is returned. Variables can only be followed if scope tracking is
+ enabled (see traverseAst).
+ */
+function timingOperand(path) {
+    const node = path.node;
+    if (timingRead(node) !== null) {
+        return node;
+    }
+    if (node.type !== "Identifier" || !path.scope) {
+        return null;
+    }
+    const binding = path.scope.getBinding(node.name);
+    if (binding === undefined || binding.path.node.type !== "VariableDeclarator" || binding.path.node.init === null) {
+        return null;
+    }
+    // a time stored in an outer scope (e.g. when a function was last run) is
+    // typical of throttling rather than measuring how long code takes to run
+    if (binding.scope.getFunctionParent() !== path.scope.getFunctionParent()) {
+        return null;
+    }
+    return (timingRead(binding.path.node.init) !== null) ? binding.path.node.init : null;
+}
+
+const relationalOperators = ["<", "<=", ">", ">="];
+
+/*
+ timingComparisons returns the paths of comparisons of the time difference at path
+ with another value (e.g. Date.now() - start > 100), either directly or through a
+ variable that it is assigned to, e.g. elapsed in const elapsed = end - start.
+ */
+function timingComparisons(path) {
+    let parent = path.parentPath;
+    while (parent.node.type === "ParenthesizedExpression") {
+        parent = parent.parentPath;
+    }
+    if (parent.node.type === "BinaryExpression" && relationalOperators.includes(parent.node.operator)) {
+        return [parent];
+    }
+    if (parent.node.type !== "VariableDeclarator" || parent.node.id.type !== "Identifier" || !parent.scope) {
+        return [];
+    }
+    const binding = parent.scope.getBinding(parent.node.id.name);
+    if (binding === undefined) {
+        return [];
+    }
+    return binding.referencePaths
+        .map((ref) => ref.parentPath)
+        .filter((p) => p.node.type === "BinaryExpression" && relationalOperators.includes(p.node.operator));
+}
+
+/*
+ visitTimingCheck logs subtractions of two reads of the current time (e.g. Date.now()
+ - start, where start = Date.now() earlier in the same function) whose result is
+ compared to choose which code to run. Obfuscated malware uses this to detect that it
+ is being stepped through in a debugger or slowed down by instrumentation, and to
+ stop before its payload is run. The API used and positions of both reads are logged,
+ along with the amount of code gated by the comparison.
+ */
+function visitTimingCheck(path, parseData) {
+    const node = path.node;
+    if (node.type !== "BinaryExpression" || node.operator !== "-") {
+        return;
+    }
+    const endRead = timingOperand(path.get("left"));
+    const startRead = timingOperand(path.get("right"));
+    if (endRead === null || startRead === null) {
+        return;
+    }
+    const length = timingComparisons(path).reduce((max, p) => Math.max(max, gatedLength(p)), 0);
+    if (length === 0) {
+        return;
+    }
+    parseData.logTimingCheck(timingRead(endRead), position(node), position(startRead), position(endRead), length);
+}
+
+/*
+ executionSinks are the names of functions (see expressionName) which run (or load) code
+ given as a string, mapped to whether only their first argument is code. All arguments
+ of Function are checked, since the body is the last one.
+ */
+const executionSinks = new Map([
+    ["eval", true],
+    ["Function", false],
+    ["require", true],
+    ["vm.runInThisContext", true],
+    ["vm.runInNewContext", true],
+    ["vm.Script", true],
+]);
+
+// decodingEncodings are the encodings of Buffer.from() that decode text to bytes.
+const decodingEncodings = new Set(["base64", "base64url", "hex"]);
+
+/*
+ passthroughFunctions are functions whose result is (a trivial transformation of) the
+ string given as their first argument, e.g. decodeURIComponent(escape(atob(s))), which
+ is a common way of decoding UTF-8 text from base64.
+ */
+const passthroughFunctions = new Set(["decodeURIComponent", "decodeURI", "unescape", "escape", "String"]);
+
+// passthroughMethods are methods whose result is (a trivial transformation of) the value they are called on.
+const passthroughMethods = new Set(["toString", "trim"]);
+
+// maxDecodeDepth limits how many variables are followed to find a decoded value.
+const maxDecodeDepth = 3;
+
+// globalName returns name without a leading reference to the global object, e.g. "atob" for "window.atob".
+function globalName(name) {
+    return name.replace(/^(window|self|globalThis|global)\./, "");
+}
+
+/*
+ decodedValue returns a description of the call that decodes base64 or hex text if the
+ expression at path evaluates to (a trivial transformation of) its result, e.g.
+ atob(s), Buffer.from(s, "base64").toString() or "" + atob(s), or a variable declared
+ with one as its initial value. The description is an object with the decoder called
+ (e.g. "atob" or "Buffer.from"), the encoding, the node of the call, and the name of
+ the variable the result was passed through, if any. Otherwise, null is returned.
+ */
+function decodedValue(path, depth = 0) {
+    const node = path.node;
+    switch (node.type) {
+        case "ParenthesizedExpression":
+            return decodedValue(path.get("expression"), depth);
+        case "BinaryExpression":
+            if (node.operator !== "+") {
+                return null;
+            }
+            return decodedValue(path.get("left"), depth) || decodedValue(path.get("right"), depth);
+        case "Identifier": {
+            if (depth >= maxDecodeDepth || !path.scope) {
+                return null;
+            }
+            const binding = path.scope.getBinding(node.name);
+            if (binding === undefined || binding.path.node.type !== "VariableDeclarator" || !binding.path.node.init) {
+                return null;
+            }
+            const decoded = decodedValue(binding.path.get("init"), depth + 1);
+            return (decoded !== null) ? { ...decoded, variable: decoded.variable || node.name } : null;
+        }
+        case "CallExpression":
+        case "OptionalCallExpression":
+        case "NewExpression":
+            break;
+        default:
+            return null;
+    }
+
+    const args = node.arguments;
+    const callee = node.callee;
+    if (node.type !== "NewExpression" && callee.type === "MemberExpression" && !callee.computed &&
+        passthroughMethods.has(callee.property.name)) {
+        return decodedValue(path.get("callee.object"), depth);
+    }
+    const name = expressionName(callee, { computed: false, indirect: false });
+    if (name === null) {
+        return null;
+    }
+    switch (globalName(name)) {
+        case "atob":
+            return (args.length > 0) ? { decoder: "atob", encoding: "base64", node: node, variable: "" } : null;
+        case "Buffer.from":
+        case "Buffer": {
+            const encoding = (args.length > 1) ? staticString(args[1]) : null;
+            if (encoding === null || !decodingEncodings.has(encoding.toLowerCase())) {
+                return null;
+            }
+            return { decoder: globalName(name), encoding: encoding.toLowerCase(), node: node, variable: "" };
+        }
+    }
+    if (passthroughFunctions.has(globalName(name)) && args.length > 0) {
+        return decodedValue(path.get("arguments.0"), depth);
+    }
+    return null;
+}
+
+/*
+ visitDecodedExecution logs calls that run or load code which is decoded from base64 or
+ hex, e.g. eval(atob("...")), new Function(Buffer.from(s, "base64").toString()) or
+ require(atob(name)), either directly or through a variable declared in an enclosing
+ scope. This is the most common way for malware to hide its payload. The sink called,
+ the decoder and encoding used, the position of the decoding call and the variable
+ that the decoded value was passed through (if any) are logged.
+ */
+function visitDecodedExecution(path, parseData) {
+    const node = path.node;
+    const name = expressionName(node.callee, { computed: false, indirect: false });
+    if (name === null) {
+        return;
+    }
+    const sink = globalName(name);
+    if (!executionSinks.has(sink)) {
+        return;
+    }
+    const argPaths = path.get("arguments");
+    const checked = executionSinks.get(sink) ? argPaths.slice(0, 1) : argPaths;
+    for (const argPath of checked) {
+        const decoded = decodedValue(argPath);
+        if (decoded !== null) {
+            parseData.logDecodedExecution(sink, position(node), decoded.decoder, decoded.encoding,
+                position(decoded.node), decoded.variable);
+            return;
+        }
+    }
+}
+
+// byteArrayTypes are the constructors (see globalName) of arrays of bytes.
+const byteArrayTypes = new Set(["Buffer", "Uint8Array", "Int8Array", "Uint8ClampedArray"]);
+
+// byteAllocators are the functions (see globalName) that create a buffer of a given size.
+const byteAllocators = new Set(["Buffer.alloc", "Buffer.allocUnsafe", "Buffer.allocUnsafeSlow"]);
+
+// byteWriteMethods are the methods of Buffer that write the number given as their
+// first argument, mapped to the number of bytes written.
+const byteWriteMethods = new Map([
+    ["writeUInt8", 1], ["writeUint8", 1], ["writeInt8", 1],
+    ["writeUInt16LE", 2], ["writeUInt16BE", 2], ["writeUint16LE", 2], ["writeUint16BE", 2],
+    ["writeInt16LE", 2], ["writeInt16BE", 2],
+    ["writeUInt32LE", 4], ["writeUInt32BE", 4], ["writeUint32LE", 4], ["writeUint32BE", 4],
+    ["writeInt32LE", 4], ["writeInt32BE", 4],
+]);
+
+/*
+ fileWriteSinks are (the final part of the names of) functions which write the bytes
+ given as their second argument to a file, e.g. fs.writeFileSync(path, data).
+ wasmSinks are the functions which compile the bytes given as their first argument
+ as a WebAssembly module.
+ */
+const fileWriteSinks = new Set(["writeFile", "writeFileSync", "appendFile", "appendFileSync", "writeSync"]);
+const wasmSinks = new Set(["WebAssembly.instantiate", "WebAssembly.compile", "WebAssembly.Module"]);
+
+/*
+ minBinaryPayloadBytes is the number of bytes of literal data from which assembled
+ bytes are logged. Ordinary code builds short magic numbers and lookup tables (e.g. of
+ 256 entries) in this way, but rarely more. Bytes which are written to a file or
+ compiled as WebAssembly are logged from minSinkPayloadBytes, which is enough for
+ shellcode or a small executable.
+ */
+const minBinaryPayloadBytes = 512;
+const minSinkPayloadBytes = 64;
+
+// literalBytes returns the number of elements if all of them are constant integers
+// that fit in a byte, e.g. [0x7f, 0x45, -1], or null otherwise.
+function literalBytes(elements) {
+    for (const element of elements) {
+        const value = (element !== null) ? staticNumber(element) : null;
+        if (value === null || !Number.isInteger(value) || value < -128 || value > 255) {
+            return null;
+        }
+    }
+    return elements.length;
+}
+
+/*
+ payloadSink returns the name of the function that the value at path is passed to, if
+ it writes the value to a file or compiles it as WebAssembly, either directly or
+ through the .buffer of a typed array. Otherwise, null is returned.
+ */
+function payloadSink(path) {
+    let child = path;
+    let parent = path.parentPath;
+    while (parent !== null && (parent.node.type === "ParenthesizedExpression" ||
+        (parent.node.type === "MemberExpression" && parent.node.object === child.node &&
+            !parent.node.computed && parent.node.property.name === "buffer"))) {
+        child = parent;
+        parent = parent.parentPath;
+    }
+    if (parent === null || !["CallExpression", "OptionalCallExpression", "NewExpression"].includes(parent.node.type)) {
+        return null;
+    }
+    const name = expressionName(parent.node.callee, { computed: false, indirect: false });
+    if (name === null) {
+        return null;
+    }
+    const index = parent.node.arguments.indexOf(child.node);
+    const sink = globalName(name);
+    if (wasmSinks.has(sink)) {
+        return (index === 0) ? sink : null;
+    }
+    return (index === 1 && fileWriteSinks.has(sink.substring(sink.lastIndexOf(".") + 1))) ? sink : null;
+}
+
+// variableReferences returns the paths of the references to the variable that the
+// value at path is the initial value of, or an empty array if there is none.
+function variableReferences(path) {
+    const parent = path.parentPath;
+    if (parent.node.type !== "VariableDeclarator" || parent.node.init !== path.node ||
+        parent.node.id.type !== "Identifier" || !path.scope) {
+        return [];
+    }
+    const binding = path.scope.getBinding(parent.node.id.name);
+    return (binding !== undefined) ? binding.referencePaths : [];
+}
+
+/*
+ writtenBytes returns the number of bytes written with constant values into the buffer
+ referred to by refs: indexed assignments of constant integers (buf[i] = 0x90), calls
+ to Buffer methods such as buf.writeUInt32LE(0xdeadbeef, 4), and copies of array
+ literals with buf.set([...]).
+ */
+function writtenBytes(refs) {
+    let size = 0;
+    for (const ref of refs) {
+        const member = ref.parentPath;
+        if (member.node.type !== "MemberExpression" || member.node.object !== ref.node) {
+            continue;
+        }
+        const use = member.parentPath.node;
+        if (member.node.computed) {
+            if (use.type === "AssignmentExpression" && use.operator === "=" && use.left === member.node &&
+                literalBytes([use.right]) !== null) {
+                size++;
+            }
+            continue;
+        }
+        if (use.type !== "CallExpression" || use.callee !== member.node || use.arguments.length === 0) {
+            continue;
+        }
+        const method = member.node.property.name;
+        const arg = use.arguments[0];
+        if (byteWriteMethods.has(method) && staticNumber(arg) !== null) {
+            size += byteWriteMethods.get(method);
+        } else if (method === "set" && arg.type === "ArrayExpression") {
+            size += literalBytes(arg.elements) || 0;
+        }
+    }
+    return size;
+}
+
+/*
+ visitBinaryPayload logs bytes assembled from literal data, which is how packed
+ payloads and shellcode are built in JavaScript: arrays of byte values passed to a
+ Buffer or byte array constructor (e.g. new Uint8Array([0x7f, 0x45, ...]),
+ Buffer.from([...]) or Uint8Array.of(...)), and buffers allocated with Buffer.alloc
+ or a byte array constructor which are then filled in with constant values one write
+ at a time. The constructor, the number of bytes of literal data and the function
+ that the bytes are passed to, if they are written to a file or compiled as
+ WebAssembly, are logged. Only payloads of at least minBinaryPayloadBytes (or
+ minSinkPayloadBytes, if there is a sink) are logged.
+ */
+function visitBinaryPayload(path, parseData) {
+    const node = path.node;
+    const name = expressionName(node.callee, { computed: false, indirect: false });
+    if (name === null || node.arguments.length === 0) {
+        return;
+    }
+    const constructor = globalName(name);
+    const [type, method] = constructor.split(".");
+    const arg = node.arguments[0];
+
+    let size = null;
+    let writes = false;
+    if (byteArrayTypes.has(type) && (method === undefined || method === "from") && arg.type === "ArrayExpression") {
+        size = literalBytes(arg.elements);
+    } else if (byteArrayTypes.has(type) && type !== "Buffer" && method === "of") {
+        size = literalBytes(node.arguments);
+    } else if (byteAllocators.has(constructor) ||
+        (byteArrayTypes.has(type) && type !== "Buffer" && method === undefined && staticNumber(arg) !== null)) {
+        size = writtenBytes(variableReferences(path));
+        writes = true;
+    }
+    if (size === null || size < minSinkPayloadBytes) {
+        return;
+    }
+
+    let sink = payloadSink(path);
+    for (const ref of variableReferences(path)) {
+        sink = sink || payloadSink(ref);
+    }
+    if (sink === null && size < minBinaryPayloadBytes) {
+        return;
+    }
+    parseData.logBinaryPayload(constructor, position(node), size, writes, sink || "");
 }
 
 function visitIdentifierOrPrivateName(path, parseData) {
@@ -210,6 +1189,18 @@ function traverseAst(ast, parseData, disableScope) {
         TemplateLiteral: function(path) {
             const loc = position(path.node);
             this.parseData.logTemplate(path.node, loc, true);
+        },
+        "CallExpression|OptionalCallExpression|NewExpression": function(path) {
+            visitCall(path, this.parseData);
+            visitDecodedExecution(path, this.parseData);
+            visitBinaryPayload(path, this.parseData);
+        },
+        "ObjectProperty|AssignmentExpression": function(path) {
+            visitAssignment(path, this.parseData);
+        },
+        "BinaryExpression|SwitchStatement": function(path) {
+            visitCondition(path, this.parseData);
+            visitTimingCheck(path, this.parseData);
         }
     };
 
@@ -249,17 +1240,220 @@ function traverseAst(ast, parseData, disableScope) {
         TemplateLiteral: function(path) {
             const loc = position(path.node);
             this.parseData.logTemplate(path.node, loc, false);
+        },
+        "CallExpression|OptionalCallExpression|NewExpression": function(path) {
+            visitCall(path, this.parseData);
+            visitDecodedExecution(path, this.parseData);
+            visitBinaryPayload(path, this.parseData);
+        },
+        "ImportDeclaration|ExportAllDeclaration|ExportNamedDeclaration": function(path) {
+            visitModuleDeclaration(path, this.parseData);
+        },
+        "ObjectProperty|AssignmentExpression": function(path) {
+            visitAssignment(path, this.parseData);
+        },
+        "BinaryExpression|SwitchStatement": function(path) {
+            visitCondition(path, this.parseData);
+            visitTimingCheck(path, this.parseData);
         }
     };
 
     traverse(ast, astVisitor, null, { parseData });
 }
 
-function parseFile(fileName, allowSyntaxErrors, includeAST) {
-    const sourceCode = fs.readFileSync(fileName, "utf8");
+// Guesses whether buf holds BOM-less UTF-16 text, by looking for the zero high bytes
+// that result from encoding ASCII characters. Returns "utf-16le", "utf-16be" or null.
+function guessUTF16(buf) {
+    const sampleLength = Math.min(buf.length, 4096) & ~1;
+    if (sampleLength < 4) {
+        return null;
+    }
+    let evenZeros = 0, oddZeros = 0;
+    for (let i = 0; i < sampleLength; i += 2) {
+        if (buf[i] === 0) evenZeros++;
+        if (buf[i + 1] === 0) oddZeros++;
+    }
+    const pairs = sampleLength / 2;
+    // UTF-8 text never contains zero bytes, so a large fraction of zeros
+    // on one side only is a strong signal of UTF-16 encoding.
+    if (oddZeros >= 0.4 * pairs && evenZeros === 0) {
+        return "utf-16le";
+    }
+    if (evenZeros >= 0.4 * pairs && oddZeros === 0) {
+        return "utf-16be";
+    }
+    return null;
+}
+
+/*
+ Decodes the raw contents of a source file. A UTF-8 byte order mark is stripped,
+ and UTF-16 (either with a byte order mark, or detected by guessUTF16) is transcoded.
+ Returns the source code and the name of the original encoding if it was normalised,
+ or null if the source was plain UTF-8.
+ */
+function decodeSource(buf) {
+    if (buf.length >= 3 && buf[0] === 0xEF && buf[1] === 0xBB && buf[2] === 0xBF) {
+        return [buf.toString("utf8", 3), "utf-8-bom"];
+    }
+
+    let encoding = null, start = 0;
+    if (buf.length >= 2 && buf[0] === 0xFF && buf[1] === 0xFE) {
+        encoding = "utf-16le";
+        start = 2;
+    } else if (buf.length >= 2 && buf[0] === 0xFE && buf[1] === 0xFF) {
+        encoding = "utf-16be";
+        start = 2;
+    } else {
+        encoding = guessUTF16(buf);
+    }
+
+    switch (encoding) {
+        case "utf-16le":
+            return [buf.toString("utf16le", start), encoding];
+        case "utf-16be": {
+            // copy (ignoring any odd trailing byte) so that the input buffer is not modified
+            const swapped = Buffer.from(buf.subarray(start, start + ((buf.length - start) & ~1)));
+            return [swapped.swap16().toString("utf16le"), encoding];
+        }
+        default:
+            return [buf.toString("utf8"), null];
+    }
+}
+
+// version of the schema of the trees output with --ast, see pkg/api/staticanalysis/ast
+const astSchemaVersion = 1;
+
+// properties of Babel nodes which are not part of the trees output with --ast
+const astOmittedKeys = new Set(["type", "start", "end", "loc", "range", "extra", "comments", "tokens", "errors",
+    "leadingComments", "trailingComments", "innerComments"]);
+
+function endPosition(node) {
+    return (node.loc !== null && node.loc !== undefined) ? [node.loc.end.line, node.loc.end.column] : [];
+}
+
+function isNode(value) {
+    return value !== null && typeof value === "object" && typeof value.type === "string";
+}
+
+function isScalar(value) {
+    return typeof value === "string" || typeof value === "number" || typeof value === "boolean";
+}
+
+/*
+ scopeInfo collects the scope data of the tree output with --ast: the ID of the scope
+ created by each node that creates one, and the binding that each identifier refers
+ to or declares. Scopes are numbered from 1 in the order that they are first seen,
+ so the Program scope is 1. Returns maps from nodes to their scope ID and binding.
+ */
+function scopeInfo(ast) {
+    const scopeIds = new Map();
+    const scopeId = (scope) => {
+        if (!scopeIds.has(scope)) {
+            scopeIds.set(scope, scopeIds.size + 1);
+        }
+        return scopeIds.get(scope);
+    };
+
+    const scopes = new Map();
+    const bindings = new Map();
+    traverse(ast, {
+        enter(path) {
+            if (path.scope.block === path.node) {
+                scopes.set(path.node, scopeId(path.scope));
+            }
+            if (path.isReferencedIdentifier() || path.isBindingIdentifier()) {
+                const binding = path.scope.getBinding(path.node.name);
+                if (binding !== undefined) {
+                    bindings.set(path.node, {
+                        scope: scopeId(binding.scope),
+                        kind: binding.kind,
+                        declaration: binding.identifier === path.node,
+                    });
+                }
+            }
+        }
+    });
+    return { scopes, bindings };
+}
+
+// treeNode converts a Babel node, held in the given field of its parent, to the
+// schema output with --ast. info is the result of scopeInfo, or null.
+function treeNode(node, field, info) {
+    const output = { type: node.type };
+    if (field !== null) {
+        output.field = field;
+    }
+    output.pos = position(node);
+    output.end = endPosition(node);
 
-    const parseData = new ParseData();
+    const attrs = {};
+    const children = [];
+    for (const [key, value] of Object.entries(node)) {
+        if (astOmittedKeys.has(key) || value === null || value === undefined) {
+            continue;
+        }
+        if (isNode(value)) {
+            children.push(treeNode(value, key, info));
+        } else if (Array.isArray(value)) {
+            for (const element of value) {
+                if (isNode(element)) {
+                    children.push(treeNode(element, key, info));
+                }
+            }
+        } else if (typeof value === "object") {
+            // e.g. the raw and cooked strings of a TemplateElement
+            for (const [k, v] of Object.entries(value)) {
+                if (isScalar(v)) {
+                    attrs[key + "." + k] = v;
+                }
+            }
+        } else if (isScalar(value)) {
+            attrs[key] = value;
+        }
+    }
+    if (node.extra !== undefined && node.extra !== null && typeof node.extra.raw === "string") {
+        attrs.raw = node.extra.raw;
+    }
+
+    if (Object.keys(attrs).length > 0) {
+        output.attrs = attrs;
+    }
+    if (children.length > 0) {
+        output.children = children;
+    }
+    if (info !== null) {
+        if (info.scopes.has(node)) {
+            output.scope = info.scopes.get(node);
+        }
+        if (info.bindings.has(node)) {
+            output.binding = info.bindings.get(node);
+        }
+    }
+    return output;
+}
+
+/*
+ buildTree returns the syntax tree of a file in the schema output with --ast, which
+ is a pruned version of the Babel AST that is stable across parser versions. Scope
+ data is only included if withScopes is true, since scopes cannot always be built
+ for files with syntax errors.
+ */
+function buildTree(ast, withScopes) {
+    const info = withScopes ? scopeInfo(ast) : null;
+    return { version: astSchemaVersion, root: treeNode(ast.program, null, info) };
+}
+
+function parseFile(fileName, allowSyntaxErrors, includeAST, tokenTypes) {
+    const startTime = process.hrtime.bigint();
+    const input = fs.readFileSync(fileName);
+    const [sourceCode, sourceEncoding] = decodeSource(input);
+
+    const parseData = new ParseData(tokenTypes);
     parseData.logInfo("InputLength", sourceCode.length.toString());
+    parseData.logInfo("InputBytes", input.length.toString());
+    if (sourceEncoding !== null) {
+        parseData.logInfo("SourceEncoding", sourceEncoding);
+    }
 
     try {
         const ast = parser.parse(sourceCode, {
@@ -268,7 +1462,7 @@ function parseFile(fileName, allowSyntaxErrors, includeAST) {
         });
 
         if (includeAST) {
-            parseData.ast = ast;
+            parseData.ast = buildTree(ast, !allowSyntaxErrors);
         }
 
         for (let e of ast.errors) {
@@ -281,7 +1475,10 @@ function parseFile(fileName, allowSyntaxErrors, includeAST) {
             parseData.logComment(c.type, c.value, loc);
         }
 
-        traverseAst(ast, parseData, allowSyntaxErrors);
+        // comments are logged from the list above, so only they can be logged without a traversal
+        if (tokenTypes === null || [...tokenTypes].some((t) => t !== "Comment")) {
+            traverseAst(ast, parseData, allowSyntaxErrors);
+        }
 
     } catch (e) {
         if (e instanceof SyntaxError) {
@@ -293,6 +1490,10 @@ function parseFile(fileName, allowSyntaxErrors, includeAST) {
         }
     }
 
+    // wall time taken to read, parse and traverse the file, in milliseconds
+    const parseTimeMs = Number(process.hrtime.bigint() - startTime) / 1e6;
+    parseData.logInfo("ParseTimeMs", parseTimeMs.toFixed(3));
+
     return parseData;
 }
 
@@ -300,9 +1501,11 @@ function usage(full = false) {
     // abbreviate full path to node and script with just base names
     const program = path.basename(process.argv[0]) + " " + path.basename(process.argv[1]);
     console.log("usage: " + program + " [--file <input.js> | --batch <paths.txt>] " +
-        " [--output <out.json>] [--ast] [--permissive]");
+        " [--output <out.json>] [--ast] [--permissive] [--only <type,...>]");
     if (full) {
         console.log("Default behaviour is to parse stdin and output to stdout");
+        console.log("--ast also outputs the syntax tree of each file (see pkg/api/staticanalysis/ast)");
+        console.log("--only limits the output to tokens of the given types (e.g. Call,Literal)");
     }
 }
 
@@ -313,6 +1516,7 @@ const cliOptions = {
     ast: { type: "boolean", short: "a", default: false },
     help: { type: "boolean", short: "h", default: false },
     permissive: { type: "boolean", short: "p", default: false },
+    only: { type: "string", default: "" },
 };
 
 // Parse command line arguments
@@ -353,6 +1557,8 @@ function main() {
      */
     let allowSyntaxErrors = cliArgs.permissive;
     let withAST = cliArgs.ast;
+    // null means all token types are logged
+    let tokenTypes = (cliArgs.only === "") ? null : new Set(cliArgs.only.split(",").map((t) => t.trim()));
 
     let outputData = {};
     if (cliArgs.batch !== "") {
@@ -363,7 +1569,7 @@ function main() {
         for (const sourceFile of fileNames) {
             if (sourceFile.trim().length > 0) {
                 try {
-                    outputData[sourceFile] = parseFile(sourceFile, allowSyntaxErrors, withAST);
+                    outputData[sourceFile] = parseFile(sourceFile, allowSyntaxErrors, withAST, tokenTypes);
                 } catch (e) {
                     let data = new ParseData();
                     data.logError(e.type, e.message, []);
@@ -386,7 +1592,7 @@ function main() {
             sourceFileName = cliArgs.file;
         }
 
-        outputData[sourceFileName] = parseFile(sourceFile, allowSyntaxErrors, withAST);
+        outputData[sourceFileName] = parseFile(sourceFile, allowSyntaxErrors, withAST, tokenTypes);
     }
 
     const outputString = JSON.stringify(outputData, null, "  ");
@@ -0,0 +1,51 @@
+package parsing
+
+import (
+	"context"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+)
+
+// Backend parses source files written in a single language. Implementations
+// must be comparable, since a Registry groups files by their backend.
+type Backend interface {
+	// Language returns the language parsed by the backend.
+	Language() Language
+
+	// Parse parses the files at the given paths and returns the results keyed
+	// by file path. Entropy values of identifiers and string literals are not
+	// computed, since they depend on the other files in a package. If an internal
+	// error occurs, the error is returned and no results are valid.
+	Parse(ctx context.Context, paths []string) (map[string]SingleResult, error)
+}
+
+// jsBackend parses JavaScript using the Babel parser installed by InitParser.
+type jsBackend struct {
+	config ParserConfig
+}
+
+// JavaScriptBackend returns a Backend that parses JavaScript files using the
+// parser installed by InitParser. The backend is a pointer, since a ParserConfig
+// is not comparable.
+func JavaScriptBackend(config ParserConfig) Backend {
+	config.SymbolTypes = slices.Clone(config.SymbolTypes)
+	return &jsBackend{config: config}
+}
+
+func (b jsBackend) Language() Language {
+	return JavaScript
+}
+
+func (b jsBackend) Parse(ctx context.Context, paths []string) (map[string]SingleResult, error) {
+	jsResults, _, err := parseJS(ctx, b.config, externalcmd.MultipleFileInput(paths))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]SingleResult, len(jsResults))
+	for filename, jsData := range jsResults {
+		results[filename] = processJsData(jsData)
+	}
+	return results, nil
+}
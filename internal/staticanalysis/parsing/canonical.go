@@ -0,0 +1,212 @@
+package parsing
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// OutputIdentifier is an identifier found by the parser, as recorded in ParserOutput.
+type OutputIdentifier struct {
+	Type token.IdentifierType `json:"type"`
+	Name string               `json:"name"`
+	Pos  token.Position       `json:"pos"`
+}
+
+// OutputLiteral is a literal found by the parser, as recorded in ParserOutput.
+// GoType is the Go type of Value after decoding, e.g. "string", "float64" or "big.Int".
+type OutputLiteral struct {
+	Type    string         `json:"type"`
+	GoType  string         `json:"go_type"`
+	Value   any            `json:"value"`
+	Raw     string         `json:"raw"`
+	InArray bool           `json:"in_array,omitempty"`
+	Pos     token.Position `json:"pos"`
+}
+
+// OutputComment is a comment found by the parser, as recorded in ParserOutput.
+type OutputComment struct {
+	Type string         `json:"type"`
+	Text string         `json:"text"`
+	Pos  token.Position `json:"pos"`
+}
+
+// OutputStatus is an information or error message reported by the parser,
+// as recorded in ParserOutput.
+type OutputStatus struct {
+	Name    string         `json:"name"`
+	Message string         `json:"message"`
+	Pos     token.Position `json:"pos"`
+}
+
+/*
+ParserOutput holds all the data produced by the parser for a single file, including
+source positions and parser status messages, which are not kept in SingleResult.
+
+ParserOutput values returned by ParseCanonical are canonicalized: each slice is
+sorted by source position (and then by its other fields), nil slices are replaced
+by empty ones, and the parse time (which varies between runs) is omitted from Info,
+so that the JSON serialization of the output is the same each time the same input
+is parsed. This makes it suitable for comparison against
+golden files in tests.
+*/
+type ParserOutput struct {
+	ValidInput        bool                         `json:"valid_input"`
+	Identifiers       []OutputIdentifier           `json:"identifiers"`
+	IdentifierCounts  map[token.IdentifierType]int `json:"identifier_counts"`
+	Literals          []OutputLiteral              `json:"literals"`
+	Comments          []OutputComment              `json:"comments"`
+	Calls             []token.Call                 `json:"calls"`
+	Imports           []token.Import               `json:"imports"`
+	Assignments       []token.Assignment           `json:"assignments"`
+	Conditions        []token.Condition            `json:"conditions"`
+	TimingChecks      []token.TimingCheck          `json:"timing_checks"`
+	DecodedExecutions []token.DecodedExecution     `json:"decoded_executions"`
+	BinaryPayloads    []token.BinaryPayload        `json:"binary_payloads"`
+	SourceEncoding    string                       `json:"source_encoding,omitempty"`
+	Info              []OutputStatus               `json:"info"`
+	Errors            []OutputStatus               `json:"errors"`
+}
+
+/*
+ParseCanonical parses the given input like Analyze, but returns the complete parser
+output for each file in canonical form (see ParserOutput), rather than the processed
+SingleResult. It is intended for inspecting the parser and for golden tests that
+detect changes in its behaviour.
+
+Errors are returned as for Analyze.
+*/
+func ParseCanonical(ctx context.Context, parserConfig ParserConfig, input externalcmd.Input) (map[string]ParserOutput, error) {
+	jsResults, _, err := parseJS(ctx, parserConfig, input)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(map[string]ParserOutput, len(jsResults))
+	for filename, data := range jsResults {
+		outputs[filename] = canonicalOutput(data)
+	}
+	return outputs, nil
+}
+
+func comparePos(a, b token.Position) int {
+	return utils.FirstNonZero(cmp.Compare(a.Row(), b.Row()), cmp.Compare(a.Col(), b.Col()))
+}
+
+func compareStatus(a, b OutputStatus) int {
+	return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Message, b.Message))
+}
+
+func canonicalStatuses(statuses []parserStatus) []OutputStatus {
+	result := make([]OutputStatus, 0, len(statuses))
+	for _, s := range statuses {
+		if s.Type == parseInfo && s.Name == parseTimeInfo {
+			continue
+		}
+		result = append(result, OutputStatus{Name: s.Name, Message: s.Message, Pos: s.Pos})
+	}
+	slices.SortStableFunc(result, compareStatus)
+	return result
+}
+
+// canonicalOutput converts the given parse data to a ParserOutput with each
+// slice sorted in a stable order. Ties between tokens at the same position are
+// broken by their other fields, except for literals and calls, which keep the
+// order in which they were reported by the parser.
+func canonicalOutput(data singleParseData) ParserOutput {
+	output := ParserOutput{
+		ValidInput:        data.ValidInput,
+		Identifiers:       make([]OutputIdentifier, 0, len(data.Identifiers)),
+		IdentifierCounts:  make(map[token.IdentifierType]int),
+		Literals:          make([]OutputLiteral, 0, len(data.Literals)),
+		Comments:          make([]OutputComment, 0, len(data.Comments)),
+		Calls:             make([]token.Call, 0, len(data.Calls)),
+		Imports:           make([]token.Import, 0, len(data.Imports)),
+		Assignments:       make([]token.Assignment, 0, len(data.Assignments)),
+		Conditions:        make([]token.Condition, 0, len(data.Conditions)),
+		TimingChecks:      make([]token.TimingCheck, 0, len(data.TimingChecks)),
+		DecodedExecutions: make([]token.DecodedExecution, 0, len(data.DecodedExecutions)),
+		BinaryPayloads:    make([]token.BinaryPayload, 0, len(data.BinaryPayloads)),
+		SourceEncoding:    data.SourceEncoding,
+		Info:              canonicalStatuses(data.Info),
+		Errors:            canonicalStatuses(data.Errors),
+	}
+
+	for t, count := range data.IdentifierCounts {
+		output.IdentifierCounts[t] = count
+	}
+
+	for _, i := range data.Identifiers {
+		output.Identifiers = append(output.Identifiers, OutputIdentifier{Type: i.Type, Name: i.Name, Pos: i.Pos})
+	}
+	slices.SortStableFunc(output.Identifiers, func(a, b OutputIdentifier) int {
+		return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Type, b.Type))
+	})
+
+	for _, l := range data.Literals {
+		output.Literals = append(output.Literals, OutputLiteral{
+			Type:    l.Type,
+			GoType:  l.GoType,
+			Value:   l.Value,
+			Raw:     l.RawValue,
+			InArray: l.InArray,
+			Pos:     l.Pos,
+		})
+	}
+	slices.SortStableFunc(output.Literals, func(a, b OutputLiteral) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	for _, c := range data.Comments {
+		output.Comments = append(output.Comments, OutputComment{Type: c.Type, Text: c.Data, Pos: c.Pos})
+	}
+	slices.SortStableFunc(output.Comments, func(a, b OutputComment) int {
+		return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Text, b.Text))
+	})
+
+	for _, c := range data.Calls {
+		if c.Args == nil {
+			c.Args = []token.CallArg{}
+		}
+		output.Calls = append(output.Calls, c)
+	}
+	slices.SortStableFunc(output.Calls, func(a, b token.Call) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	output.Imports = append(output.Imports, data.Imports...)
+	slices.SortStableFunc(output.Imports, func(a, b token.Import) int {
+		return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Module, b.Module))
+	})
+
+	output.Assignments = append(output.Assignments, data.Assignments...)
+	slices.SortStableFunc(output.Assignments, func(a, b token.Assignment) int {
+		return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Target, b.Target))
+	})
+
+	output.Conditions = append(output.Conditions, data.Conditions...)
+	slices.SortStableFunc(output.Conditions, func(a, b token.Condition) int {
+		return utils.FirstNonZero(comparePos(a.Pos, b.Pos), cmp.Compare(a.Value, b.Value))
+	})
+
+	output.TimingChecks = append(output.TimingChecks, data.TimingChecks...)
+	slices.SortStableFunc(output.TimingChecks, func(a, b token.TimingCheck) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	output.DecodedExecutions = append(output.DecodedExecutions, data.DecodedExecutions...)
+	slices.SortStableFunc(output.DecodedExecutions, func(a, b token.DecodedExecution) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	output.BinaryPayloads = append(output.BinaryPayloads, data.BinaryPayloads...)
+	slices.SortStableFunc(output.BinaryPayloads, func(a, b token.BinaryPayload) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	return output
+}
@@ -0,0 +1,145 @@
+package parsing
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata/golden")
+
+func TestCanonicalOutput(t *testing.T) {
+	data := singleParseData{
+		ValidInput: true,
+		Identifiers: []parsedIdentifier{
+			{token.Variable, "b", token.Position{2, 4}},
+			{token.Variable, "a", token.Position{1, 4}},
+			{token.Function, "a", token.Position{1, 4}},
+		},
+		IdentifierCounts: map[token.IdentifierType]int{token.Variable: 2, token.Function: 1},
+		Literals: []parsedLiteral[any]{
+			{"String", "string", "y", `"y"`, false, token.Position{2, 8}},
+			{"String", "string", "x", `"x"`, false, token.Position{1, 8}},
+		},
+		Comments: []parsedComment{
+			{"Line", "second", token.Position{3, 0}},
+			{"Block", "first", token.Position{0, 0}},
+		},
+		Calls: []token.Call{
+			{Callee: "g", Pos: token.Position{2, 0}},
+			{Callee: "f", Args: []token.CallArg{{Type: "String", Value: "x"}}, Pos: token.Position{1, 0}},
+		},
+		Imports: []token.Import{
+			{Module: "b", Pos: token.Position{0, 0}},
+			{Module: "a", Export: true, Pos: token.Position{0, 0}},
+		},
+		Assignments: []token.Assignment{
+			{Target: "y", Value: token.CallArg{Type: "Boolean", Value: "true"}, Pos: token.Position{2, 10}},
+			{Target: "x", Value: token.CallArg{Type: "Numeric", Value: "1"}, Pos: token.Position{2, 10}},
+		},
+		Info: []parserStatus{
+			{Type: parseInfo, Name: "B", Message: "2", Pos: token.Position{1, 0}},
+			{Type: parseInfo, Name: "A", Message: "1", Pos: token.Position{1, 0}},
+		},
+	}
+
+	want := ParserOutput{
+		ValidInput: true,
+		Identifiers: []OutputIdentifier{
+			{Type: token.Function, Name: "a", Pos: token.Position{1, 4}},
+			{Type: token.Variable, Name: "a", Pos: token.Position{1, 4}},
+			{Type: token.Variable, Name: "b", Pos: token.Position{2, 4}},
+		},
+		IdentifierCounts: map[token.IdentifierType]int{token.Variable: 2, token.Function: 1},
+		Literals: []OutputLiteral{
+			{Type: "String", GoType: "string", Value: "x", Raw: `"x"`, Pos: token.Position{1, 8}},
+			{Type: "String", GoType: "string", Value: "y", Raw: `"y"`, Pos: token.Position{2, 8}},
+		},
+		Comments: []OutputComment{
+			{Type: "Block", Text: "first", Pos: token.Position{0, 0}},
+			{Type: "Line", Text: "second", Pos: token.Position{3, 0}},
+		},
+		Calls: []token.Call{
+			{Callee: "f", Args: []token.CallArg{{Type: "String", Value: "x"}}, Pos: token.Position{1, 0}},
+			{Callee: "g", Args: []token.CallArg{}, Pos: token.Position{2, 0}},
+		},
+		Imports: []token.Import{
+			{Module: "a", Export: true, Pos: token.Position{0, 0}},
+			{Module: "b", Pos: token.Position{0, 0}},
+		},
+		Assignments: []token.Assignment{
+			{Target: "x", Value: token.CallArg{Type: "Numeric", Value: "1"}, Pos: token.Position{2, 10}},
+			{Target: "y", Value: token.CallArg{Type: "Boolean", Value: "true"}, Pos: token.Position{2, 10}},
+		},
+		Conditions:        []token.Condition{},
+		TimingChecks:      []token.TimingCheck{},
+		DecodedExecutions: []token.DecodedExecution{},
+		BinaryPayloads:    []token.BinaryPayload{},
+		Info: []OutputStatus{
+			{Name: "A", Message: "1", Pos: token.Position{1, 0}},
+			{Name: "B", Message: "2", Pos: token.Position{1, 0}},
+		},
+		Errors: []OutputStatus{},
+	}
+
+	if got := canonicalOutput(data); !reflect.DeepEqual(got, want) {
+		t.Errorf("canonicalOutput() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+// TestParseCanonicalGolden compares the parser output for each JavaScript file in
+// testdata/golden with the JSON file of the same name. Run with -update to
+// regenerate the JSON files after an intended change in parser behaviour.
+func TestParseCanonicalGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "golden", "*.js"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".js")
+		t.Run(name, func(t *testing.T) {
+			source, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outputs, err := ParseCanonical(context.Background(), parserConfig, externalcmd.StringInput(string(source)))
+			if err != nil {
+				t.Fatalf("ParseCanonical() error = %v", err)
+			}
+			got, err := json.MarshalIndent(outputs["stdin"], "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			goldenPath := strings.TrimSuffix(input, ".js") + ".json"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("reading golden file: %v (run with -update to create it)", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("parser output for %s does not match %s:\n%s", input, goldenPath, got)
+			}
+		})
+	}
+}
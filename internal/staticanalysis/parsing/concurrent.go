@@ -0,0 +1,170 @@
+package parsing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxBatchSize is the maximum number of files passed to a single
+// invocation of the parser when parsing concurrently.
+const maxBatchSize = 50
+
+/*
+AnalyzeConcurrently parses the given list of files, splitting them into batches
+which are parsed in parallel by a pool of at most concurrency workers. Each worker
+runs a separate parser process for each batch it handles.
+
+Results are returned keyed by file path. If parsing a batch of files fails (due
+to an internal parser error, or cancellation of ctx), each file in that batch has
+the error recorded in the returned error map and is absent from the result map.
+Files in other batches are unaffected.
+
+If concurrency is less than 1, a single worker is used.
+*/
+func AnalyzeConcurrently(ctx context.Context, parserConfig ParserConfig, paths []string, concurrency int) (map[string]SingleResult, map[string]error) {
+	resultsByFile, errorsByFile := parseConcurrently(ctx, JavaScriptBackend(parserConfig).Parse, paths, concurrency, 0)
+	populateEntropies(resultsByFile)
+	return resultsByFile, errorsByFile
+}
+
+// parseBatchFunc parses a batch of files and returns the results keyed by file path.
+type parseBatchFunc func(ctx context.Context, batch []string) (map[string]SingleResult, error)
+
+// parseConcurrently implements AnalyzeConcurrently using parse to parse each
+// batch of files, without computing entropy values. Each batch is parsed by
+// parseWithTimeout, which gives it fileTimeout for each file in it.
+func parseConcurrently(ctx context.Context, parse parseBatchFunc, paths []string, concurrency int, fileTimeout time.Duration) (map[string]SingleResult, map[string]error) {
+	batches := makeBatches(paths, concurrency, maxBatchSize)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type batchResult struct {
+		batch   []string
+		results map[string]SingleResult
+		errs    map[string]error
+	}
+
+	batchCh := make(chan []string)
+	resultCh := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batchCh {
+				results, errs := parseWithTimeout(ctx, parse, batch, fileTimeout)
+				resultCh <- batchResult{batch: batch, results: results, errs: errs}
+			}
+		}()
+	}
+
+	go func() {
+		for _, batch := range batches {
+			batchCh <- batch
+		}
+		close(batchCh)
+		wg.Wait()
+		close(resultCh)
+	}()
+
+	resultsByFile := make(map[string]SingleResult, len(paths))
+	errorsByFile := make(map[string]error)
+	for r := range resultCh {
+		for _, path := range r.batch {
+			if err, failed := r.errs[path]; failed {
+				errorsByFile[path] = err
+			} else if result, ok := r.results[path]; ok {
+				resultsByFile[path] = result
+			} else {
+				errorsByFile[path] = fmt.Errorf("no parser output for file %s", path)
+			}
+		}
+	}
+
+	return resultsByFile, errorsByFile
+}
+
+/*
+parseWithTimeout parses a batch of files using parse, and returns the results and
+the errors for files which could not be parsed, keyed by file path.
+
+If fileTimeout is positive, the batch is given fileTimeout for each file in it to
+parse, as a budget shared by the files. If it does not finish in time, each file in
+the batch is parsed on its own with a deadline of fileTimeout, so that the files
+which take too long can be told apart from the rest, which are still parsed. The
+error for files which take too long wraps ErrTimeout. A file in a batch which
+finishes in time is not timed out, even if it took longer than fileTimeout, since
+the time taken by each file in a batch is not known. A deadline of ctx applies to all batches, and files which
+are not parsed before it passes also have an error wrapping ErrTimeout or
+context.DeadlineExceeded.
+*/
+func parseWithTimeout(ctx context.Context, parse parseBatchFunc, batch []string, fileTimeout time.Duration) (map[string]SingleResult, map[string]error) {
+	batchErrors := func(err error) map[string]error {
+		errs := make(map[string]error, len(batch))
+		for _, path := range batch {
+			errs[path] = err
+		}
+		return errs
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, batchErrors(err)
+	}
+
+	parseCtx := ctx
+	if fileTimeout > 0 {
+		var cancel context.CancelFunc
+		parseCtx, cancel = context.WithTimeout(ctx, fileTimeout*time.Duration(len(batch)))
+		defer cancel()
+	}
+
+	results, err := parse(parseCtx, batch)
+	if err == nil {
+		return results, nil
+	}
+	if fileTimeout <= 0 || len(batch) == 1 || !errors.Is(err, ErrTimeout) || ctx.Err() != nil {
+		return nil, batchErrors(err)
+	}
+
+	// find the files which took too long
+	results = make(map[string]SingleResult, len(batch))
+	errs := make(map[string]error)
+	for _, path := range batch {
+		fileResults, fileErrs := parseWithTimeout(ctx, parse, []string{path}, fileTimeout)
+		for p, result := range fileResults {
+			results[p] = result
+		}
+		for p, err := range fileErrs {
+			errs[p] = err
+		}
+	}
+	return results, errs
+}
+
+// makeBatches splits paths into batches such that there is (where possible)
+// at least one batch for each worker, and no batch is larger than maxSize.
+func makeBatches(paths []string, workers, maxSize int) [][]string {
+	if len(paths) == 0 {
+		return nil
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	size := (len(paths) + workers - 1) / workers
+	if size > maxSize {
+		size = maxSize
+	}
+
+	var batches [][]string
+	for start := 0; start < len(paths); start += size {
+		end := min(start+size, len(paths))
+		batches = append(batches, paths[start:end])
+	}
+	return batches
+}
@@ -0,0 +1,139 @@
+package parsing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMakeBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		workers int
+		maxSize int
+		want    [][]string
+	}{
+		{
+			name:    "empty",
+			paths:   nil,
+			workers: 4,
+			maxSize: 10,
+			want:    nil,
+		},
+		{
+			name:    "one batch per worker",
+			paths:   []string{"a", "b", "c", "d"},
+			workers: 2,
+			maxSize: 10,
+			want:    [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name:    "uneven split",
+			paths:   []string{"a", "b", "c"},
+			workers: 2,
+			maxSize: 10,
+			want:    [][]string{{"a", "b"}, {"c"}},
+		},
+		{
+			name:    "more workers than files",
+			paths:   []string{"a", "b"},
+			workers: 8,
+			maxSize: 10,
+			want:    [][]string{{"a"}, {"b"}},
+		},
+		{
+			name:    "max size limits batch",
+			paths:   []string{"a", "b", "c", "d", "e"},
+			workers: 1,
+			maxSize: 2,
+			want:    [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		},
+		{
+			name:    "zero workers",
+			paths:   []string{"a", "b"},
+			workers: 0,
+			maxSize: 10,
+			want:    [][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := makeBatches(tt.paths, tt.workers, tt.maxSize)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("makeBatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeConcurrentlyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	paths := []string{"a.js", "b.js", "c.js"}
+	results, errs := AnalyzeConcurrently(ctx, ParserConfig{}, paths, 2)
+
+	if len(results) != 0 {
+		t.Errorf("AnalyzeConcurrently() returned %d results, want 0", len(results))
+	}
+	for _, path := range paths {
+		if err := errs[path]; !errors.Is(err, context.Canceled) {
+			t.Errorf("AnalyzeConcurrently() error for %s = %v, want %v", path, err, context.Canceled)
+		}
+	}
+}
+
+func TestParseConcurrentlyFileTimeout(t *testing.T) {
+	// parse never finishes a batch containing a file named slow.js
+	parse := func(ctx context.Context, batch []string) (map[string]SingleResult, error) {
+		for _, path := range batch {
+			if path == "slow.js" {
+				<-ctx.Done()
+				return nil, fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+			}
+		}
+		results := map[string]SingleResult{}
+		for _, path := range batch {
+			results[path] = SingleResult{}
+		}
+		return results, nil
+	}
+
+	paths := []string{"a.js", "slow.js", "b.js", "c.js"}
+	results, errs := parseConcurrently(context.Background(), parse, paths, 1, 10*time.Millisecond)
+
+	for _, path := range []string{"a.js", "b.js", "c.js"} {
+		if _, ok := results[path]; !ok {
+			t.Errorf("parseConcurrently() has no result for %s (error %v)", path, errs[path])
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs["slow.js"], ErrTimeout) {
+		t.Errorf("parseConcurrently() errors = %v, want ErrTimeout for slow.js", errs)
+	}
+}
+
+func TestParseConcurrentlyDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	parse := func(ctx context.Context, batch []string) (map[string]SingleResult, error) {
+		<-ctx.Done()
+		return nil, fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+
+	paths := []string{"a.js", "b.js", "c.js"}
+	results, errs := parseConcurrently(ctx, parse, paths, 2, time.Hour)
+
+	if len(results) != 0 {
+		t.Errorf("parseConcurrently() returned %d results, want 0", len(results))
+	}
+	for _, path := range paths {
+		if err := errs[path]; !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("parseConcurrently() error for %s = %v, want %v", path, err, context.DeadlineExceeded)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package parsing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned (wrapped) by the parsing functions in this package.
+// Callers should use errors.Is to check for them.
+//
+// ErrParserCrashed, ErrDecodeFailed and ErrTimeout are returned when parsing
+// as a whole fails, and no results are produced for any of the input files.
+//
+// ErrSyntaxError is never returned for a whole parsing run, since syntax errors
+// are specific to each file. A file that the parser could not parse due to syntax
+// errors (e.g. because it is not JavaScript) is instead signalled by its
+// SingleResult having Language set to NoLanguage, and its Err field set to an
+// error wrapping ErrSyntaxError.
+var (
+	// ErrSyntaxError means that the input could not be parsed because of
+	// syntax errors that the parser could not recover from.
+	ErrSyntaxError = errors.New("syntax error")
+
+	// ErrParserCrashed means that the parser process could not be run,
+	// or exited abnormally.
+	ErrParserCrashed = errors.New("parser crashed")
+
+	// ErrDecodeFailed means that the output of the parser could not be decoded.
+	ErrDecodeFailed = errors.New("failed to decode parser output")
+
+	// ErrTimeout means parsing did not complete before the context deadline.
+	ErrTimeout = errors.New("parser timed out")
+)
+
+// Err returns an error wrapping ErrSyntaxError if the parse data is not for
+// valid input, otherwise nil. The error message includes the fatal errors
+// reported by the parser.
+func (d singleParseData) Err() error {
+	if d.ValidInput {
+		return nil
+	}
+
+	var messages []string
+	for _, e := range d.Errors {
+		if strings.Contains(e.Message, fatalSyntaxErrorMarker) {
+			messages = append(messages, e.String())
+		}
+	}
+	if len(messages) == 0 {
+		return ErrSyntaxError
+	}
+	return fmt.Errorf("%w: %s", ErrSyntaxError, strings.Join(messages, "; "))
+}
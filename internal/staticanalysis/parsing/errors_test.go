@@ -0,0 +1,85 @@
+package parsing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+)
+
+func TestParseJSErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name:    "crash",
+			script:  "process.exit(3);",
+			wantErr: ErrParserCrashed,
+		},
+		{
+			name: "bad output",
+			script: "const out = process.argv[process.argv.indexOf('--output') + 1];\n" +
+				"require('fs').writeFileSync(out, 'not json');",
+			wantErr: ErrDecodeFailed,
+		},
+		{
+			name:    "timeout",
+			script:  "setTimeout(() => {}, 10000);",
+			timeout: 100 * time.Millisecond,
+			wantErr: ErrTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parserPath := filepath.Join(t.TempDir(), "parser.js")
+			if err := os.WriteFile(parserPath, []byte(tt.script), 0o644); err != nil {
+				t.Fatalf("failed to write parser script: %v", err)
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			config := ParserConfig{ParserPath: parserPath}
+			_, _, err := parseJS(ctx, config, externalcmd.StringInput("var a = 1;"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("parseJS() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSingleParseDataErr(t *testing.T) {
+	valid := singleParseData{ValidInput: true}
+	if err := valid.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	invalid := singleParseData{
+		ValidInput: false,
+		Errors: []parserStatus{
+			{Type: parseError, Name: "SyntaxError", Message: "Unexpected token"},
+			{Type: parseError, Name: "SyntaxError", Message: fatalSyntaxErrorMarker + ": Missing semicolon"},
+		},
+	}
+	if err := invalid.Err(); !errors.Is(err, ErrSyntaxError) {
+		t.Errorf("Err() = %v, want %v", err, ErrSyntaxError)
+	}
+
+	if result := processJsData(valid); result.Err != nil {
+		t.Errorf("processJsData() of valid data Err = %v, want nil", result.Err)
+	}
+	if result := processJsData(invalid); !errors.Is(result.Err, ErrSyntaxError) || result.Language != NoLanguage {
+		t.Errorf("processJsData() of invalid data = (%v, %v), want (%v, %v)", result.Language, result.Err, NoLanguage, ErrSyntaxError)
+	}
+}
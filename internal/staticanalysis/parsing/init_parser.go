@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/ossf/package-analysis/internal/utils"
 )
@@ -42,6 +44,46 @@ const npmCacheDir = "/npm_cache"
 type ParserConfig struct {
 	InstallDir string
 	ParserPath string
+
+	// SymbolTypes limits the parser output to symbols of the given types, so
+	// that focused analyses (e.g. of only imports or string literals) do not pay
+	// for collecting the rest. If empty, symbols of all types are collected.
+	SymbolTypes []SymbolType
+
+	// NestedCodeDepth is the number of levels of string literals which look like
+	// code (e.g. eval payloads) that are parsed as JavaScript, with the tokens found
+	// attributed to the position of the literal. String literals in the parsed code
+	// are in turn parsed, up to MaxNestedCodeDepth levels. If zero, string literals
+	// are not parsed. It has no effect if SymbolTypes excludes LiteralSymbols.
+	NestedCodeDepth int
+
+	// FullAST makes the parser also return the syntax tree of each file (see
+	// SingleResult.AST), for analyses which need the structure of the code rather
+	// than just its tokens. Trees are much larger than the other output and take
+	// longer to produce, so they are only output if requested.
+	FullAST bool
+}
+
+// parserArgs returns the extra command line arguments for the parser which
+// apply the options in the config.
+func (c ParserConfig) parserArgs() []string {
+	var args []string
+	if c.FullAST {
+		args = append(args, "--ast")
+	}
+	if len(c.SymbolTypes) > 0 {
+		types := make([]string, len(c.SymbolTypes))
+		for i, t := range c.SymbolTypes {
+			types[i] = string(t)
+		}
+		args = append(args, "--only", strings.Join(types, ","))
+	}
+	return args
+}
+
+// wants returns whether the parser output should include tokens of type t.
+func (c ParserConfig) wants(t tokenType) bool {
+	return len(c.SymbolTypes) == 0 || slices.Contains(c.SymbolTypes, SymbolType(t))
 }
 
 type parserFile struct {
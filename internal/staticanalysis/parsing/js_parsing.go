@@ -3,15 +3,20 @@ package parsing
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"math/big"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
 )
 
@@ -22,6 +27,7 @@ type parseOutputJSON map[string]parseDataJSON
 type parseDataJSON struct {
 	Tokens []parserTokenJSON  `json:"tokens"`
 	Status []parserStatusJSON `json:"status"`
+	AST    *ast.Tree          `json:"ast"`
 }
 
 type parserTokenJSON struct {
@@ -39,6 +45,17 @@ type parserStatusJSON struct {
 	Pos           [2]int     `json:"pos"`
 }
 
+// sourceEncodingInfo is the name of the parser info status which reports the
+// original encoding of a file that was normalised before parsing.
+const sourceEncodingInfo = "SourceEncoding"
+
+// Names of the parser info statuses which report the size of a file in bytes,
+// and the time taken to parse it in milliseconds. See ParseStats.
+const (
+	inputBytesInfo = "InputBytes"
+	parseTimeInfo  = "ParseTimeMs"
+)
+
 // fatalSyntaxErrorMarker is used by the parser to signal that it is unable to
 // parse a file completely due to syntax errors that cannot be recovered from.
 const fatalSyntaxErrorMarker = "FATAL SYNTAX ERROR"
@@ -65,9 +82,25 @@ either by filename (jsFilePath) or piping jsSource to the program's stdin.
 If sourcePath is empty, sourceString will be parsed as JS code.
 */
 func runParser(ctx context.Context, parserPath string, input externalcmd.Input, extraArgs ...string) (string, error) {
+	var output string
+	err := runParserWithOutput(ctx, parserPath, input, func(r io.Reader) error {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("runParser failed to read output file: %w", err)
+		}
+		output = string(data)
+		return nil
+	}, extraArgs...)
+	return output, err
+}
+
+// runParserWithOutput is like runParser, but instead of returning the output of the
+// parser as a string, it calls handleOutput with a reader for the output file. The
+// error returned by handleOutput (if any) is returned.
+func runParserWithOutput(ctx context.Context, parserPath string, input externalcmd.Input, handleOutput func(io.Reader) error, extraArgs ...string) error {
 	workingDir, err := os.MkdirTemp("", "package-analysis-run-parser-*")
 	if err != nil {
-		return "", fmt.Errorf("runParser failed to create temp working directory: %w", err)
+		return fmt.Errorf("runParser failed to create temp working directory: %w", err)
 	}
 	defer func() {
 		if err := os.RemoveAll(workingDir); err != nil {
@@ -85,30 +118,73 @@ func runParser(ctx context.Context, parserPath string, input externalcmd.Input,
 	cmd := exec.CommandContext(ctx, "node", nodeArgs...)
 
 	if err := input.SendTo(cmd, parserArgsHandler{}, workingDir); err != nil {
-		return "", fmt.Errorf("runParser failed to prepare parsing input: %w", err)
+		return fmt.Errorf("runParser failed to prepare parsing input: %w", err)
 	}
 
 	if _, err := cmd.Output(); err != nil {
-		return "", err
+		if ctxErr := ctx.Err(); errors.Is(ctxErr, context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		} else if ctxErr != nil {
+			return fmt.Errorf("%w: %w", ctxErr, err)
+		}
+		return fmt.Errorf("%w: %w", ErrParserCrashed, err)
+	}
+
+	f, err := os.Open(outFilePath)
+	if err != nil {
+		return fmt.Errorf("runParser failed to open output file: %w", err)
+	}
+	defer f.Close()
+
+	return handleOutput(f)
+}
+
+// processCallArgs converts the list of call argument descriptions in the
+// extra data of a call token to a list of token.CallArg.
+func processCallArgs(argsData any) []token.CallArg {
+	argsList, _ := argsData.([]any)
+	args := make([]token.CallArg, 0, len(argsList))
+	for _, a := range argsList {
+		argData, _ := a.(map[string]any)
+		argType, _ := argData["type"].(string)
+		argValue, _ := argData["value"].(string)
+		args = append(args, token.CallArg{Type: argType, Value: argValue})
 	}
+	return args
+}
 
-	if output, err := os.ReadFile(outFilePath); err != nil {
-		return "", fmt.Errorf("runParser failed to read output file: %w", err)
-	} else {
-		return string(output), nil
+// processPosition converts a position in the extra data of a token, which is
+// decoded from JSON as a slice of float64, or returns the zero Position if it is
+// malformed.
+func processPosition(data any) token.Position {
+	values, ok := data.([]any)
+	if !ok || len(values) != 2 {
+		return token.Position{}
 	}
+	row, _ := values[0].(float64)
+	col, _ := values[1].(float64)
+	return token.Position{int(row), int(col)}
 }
 
-func (pd parseDataJSON) process(ctx context.Context) singleParseData {
+// process converts the parser output for a single file. Tokens of types not
+// wanted by config are skipped, in case the parser did not filter them.
+func (pd parseDataJSON) process(ctx context.Context, config ParserConfig) singleParseData {
 	processed := singleParseData{
-		ValidInput: true,
+		ValidInput:       true,
+		IdentifierCounts: make(map[token.IdentifierType]int),
+		Stats:            ParseStats{OutputElements: len(pd.Tokens)},
+		AST:              pd.AST,
 	}
 
 	// process source code tokens
 	for _, t := range pd.Tokens {
+		if !config.wants(t.TokenType) {
+			continue
+		}
 		switch t.TokenType {
 		case identifier:
 			symbolSubtype := token.ParseIdentifierType(t.TokenSubType)
+			processed.IdentifierCounts[symbolSubtype]++
 			if symbolSubtype == token.Other || symbolSubtype == token.Unknown {
 				break
 			}
@@ -150,6 +226,103 @@ func (pd parseDataJSON) process(ctx context.Context) singleParseData {
 				Data: t.Data.(string),
 				Pos:  t.Pos,
 			})
+		case call:
+			callee, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			processed.Calls = append(processed.Calls, token.Call{
+				Callee:        callee,
+				New:           t.TokenSubType == "New",
+				Computed:      t.Extra["computed"] == true,
+				Indirect:      t.Extra["indirect"] == true,
+				Unconditional: t.Extra["unconditional"] == true,
+				Args:          processCallArgs(t.Extra["args"]),
+				Pos:           t.Pos,
+			})
+		case moduleImport:
+			module, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			processed.Imports = append(processed.Imports, token.Import{
+				Module: module,
+				Export: t.TokenSubType == "Export",
+				Pos:    t.Pos,
+			})
+		case assignment:
+			target, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			valueData, _ := t.Extra["value"].(map[string]any)
+			valueType, _ := valueData["type"].(string)
+			value, _ := valueData["value"].(string)
+			processed.Assignments = append(processed.Assignments, token.Assignment{
+				Target: target,
+				Member: t.TokenSubType == "Member",
+				Value:  token.CallArg{Type: valueType, Value: value},
+				Pos:    t.Pos,
+			})
+		case condition:
+			subject, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			operator, _ := t.Extra["operator"].(string)
+			value, _ := t.Extra["value"].(string)
+			// JSON numbers are decoded as float64
+			gatedLength, _ := t.Extra["gatedLength"].(float64)
+			processed.Conditions = append(processed.Conditions, token.Condition{
+				Subject:     subject,
+				Operator:    operator,
+				Value:       value,
+				GatedLength: int(gatedLength),
+				Pos:         t.Pos,
+			})
+		case timingCheck:
+			api, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			gatedLength, _ := t.Extra["gatedLength"].(float64)
+			processed.TimingChecks = append(processed.TimingChecks, token.TimingCheck{
+				API:         api,
+				StartPos:    processPosition(t.Extra["startPos"]),
+				EndPos:      processPosition(t.Extra["endPos"]),
+				GatedLength: int(gatedLength),
+				Pos:         t.Pos,
+			})
+		case decodedExecution:
+			sink, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			decoder, _ := t.Extra["decoder"].(string)
+			encoding, _ := t.Extra["encoding"].(string)
+			variable, _ := t.Extra["variable"].(string)
+			processed.DecodedExecutions = append(processed.DecodedExecutions, token.DecodedExecution{
+				Sink:      sink,
+				Decoder:   decoder,
+				Encoding:  encoding,
+				Variable:  variable,
+				DecodePos: processPosition(t.Extra["decodePos"]),
+				Pos:       t.Pos,
+			})
+		case binaryPayload:
+			constructor, ok := t.Data.(string)
+			if !ok {
+				break
+			}
+			size, _ := t.Extra["size"].(float64)
+			sink, _ := t.Extra["sink"].(string)
+			processed.BinaryPayloads = append(processed.BinaryPayloads, token.BinaryPayload{
+				Constructor: constructor,
+				Size:        int(size),
+				Writes:      t.TokenSubType == "Writes",
+				Sink:        sink,
+				Pos:         t.Pos,
+			})
 		default:
 			slog.WarnContext(ctx, fmt.Sprintf("parseJS: unrecognised token type %s", t.TokenType))
 		}
@@ -165,6 +338,18 @@ func (pd parseDataJSON) process(ctx context.Context) singleParseData {
 		switch s.StatusType {
 		case parseInfo:
 			processed.Info = append(processed.Info, status)
+			switch status.Name {
+			case sourceEncodingInfo:
+				processed.SourceEncoding = status.Message
+			case inputBytesInfo:
+				if n, err := strconv.ParseInt(status.Message, 10, 64); err == nil {
+					processed.Stats.InputBytes = n
+				}
+			case parseTimeInfo:
+				if ms, err := strconv.ParseFloat(status.Message, 64); err == nil {
+					processed.Stats.ParseTime = time.Duration(ms * float64(time.Millisecond))
+				}
+			}
 		case parseError:
 			processed.Errors = append(processed.Errors, status)
 			if strings.Contains(status.Message, fatalSyntaxErrorMarker) {
@@ -179,17 +364,22 @@ func (pd parseDataJSON) process(ctx context.Context) singleParseData {
 /*
 parseJS extracts source code identifiers and string literals from JavaScript code.
 
-parserConfig specifies options relevant to the parser itself, and is produced by InitParser
+parserConfig specifies options relevant to the parser itself, and is produced by InitParser.
+If parserConfig.NestedCodeDepth is set, string literals which look like code are also
+parsed (see expandNestedCode). If parserConfig.FullAST is set, the syntax tree of each
+file is returned as well.
 
 If internal errors occurred during parsing, then a nil map is returned.
 The other two return values are the raw parser output and the error respectively.
-Otherwise, the first return value points to the parsing result object while the second
-contains the raw JSON output from the parser.
+The error wraps one of ErrParserCrashed, ErrDecodeFailed or ErrTimeout (or ctx.Err()
+if parsing was cancelled). Otherwise, the first return value points to the parsing
+result object while the second contains the raw JSON output from the parser.
 */
 func parseJS(ctx context.Context, parserConfig ParserConfig, input externalcmd.Input) (map[string]singleParseData, string, error) {
-	rawOutput, err := runParser(ctx, parserConfig.ParserPath, input)
+	rawOutput, err := runParser(ctx, parserConfig.ParserPath, input, parserConfig.parserArgs()...)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
 			rawOutput = string(exitErr.Stderr)
 		}
 		return nil, rawOutput, err
@@ -199,13 +389,19 @@ func parseJS(ctx context.Context, parserConfig ParserConfig, input externalcmd.I
 
 	var parseOutput parseOutputJSON
 	if err := decoder.Decode(&parseOutput); err != nil {
-		return nil, rawOutput, err
+		return nil, rawOutput, fmt.Errorf("%w: %w", ErrDecodeFailed, err)
 	}
 
 	// convert the elements into more natural data structure
 	result := map[string]singleParseData{}
 	for filename, data := range parseOutput {
-		result[filename] = data.process(ctx)
+		result[filename] = data.process(ctx, parserConfig)
+	}
+
+	if parserConfig.NestedCodeDepth > 0 {
+		if err := expandNestedCode(ctx, parserConfig, result); err != nil {
+			return nil, rawOutput, err
+		}
 	}
 
 	return result, rawOutput, nil
@@ -2,12 +2,22 @@ package parsing
 
 import (
 	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
 )
 
@@ -393,6 +403,258 @@ a = w w;
 
 		printJSON: false,
 	},
+	{
+		name: "test calls",
+		inputJS: `this["ev" + "al"]("1");
+(0, eval)(code);
+new window.Function("a", b);
+require("fs").readFileSync(p);
+`,
+		want: singleParseData{
+			ValidInput: true,
+			Identifiers: []parsedIdentifier{
+				{token.Member, "Function", token.Position{3, 11}},
+				{token.Member, "readFileSync", token.Position{4, 14}},
+			},
+			Literals: []parsedLiteral[any]{
+				{"String", "string", "ev", `"ev"`, false, token.Position{1, 5}},
+				{"String", "string", "al", `"al"`, false, token.Position{1, 12}},
+				{"String", "string", "1", `"1"`, false, token.Position{1, 18}},
+				{"Numeric", "float64", 0.0, "0", false, token.Position{2, 1}},
+				{"String", "string", "a", `"a"`, false, token.Position{3, 20}},
+				{"String", "string", "fs", `"fs"`, false, token.Position{4, 8}},
+			},
+			Calls: []token.Call{
+				{
+					Callee:        "this.eval",
+					Computed:      true,
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "String", Value: "1"}},
+					Pos:           token.Position{1, 0},
+				},
+				{
+					Callee:        "eval",
+					Indirect:      true,
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Identifier", Value: "code"}},
+					Pos:           token.Position{2, 0},
+				},
+				{
+					Callee:        "window.Function",
+					New:           true,
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "String", Value: "a"}, {Type: "Identifier", Value: "b"}},
+					Pos:           token.Position{3, 0},
+				},
+				{
+					Callee:        `require("fs").readFileSync`,
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Identifier", Value: "p"}},
+					Pos:           token.Position{4, 0},
+				},
+				{
+					Callee:        "require",
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "String", Value: "fs"}},
+					Pos:           token.Position{4, 0},
+				},
+			},
+		},
+	},
+	{
+		name: "test platform conditions",
+		inputJS: `if (process.platform === "win32") {
+    run("payload.exe");
+}
+const isMac = "darwin" == os.platform();
+switch (require("os").type()) {
+case "Linux":
+    f();
+    break;
+}
+`,
+		want: singleParseData{
+			ValidInput: true,
+			Identifiers: []parsedIdentifier{
+				{token.Member, "platform", token.Position{1, 12}},
+				{token.Variable, "isMac", token.Position{4, 6}},
+				{token.Member, "platform", token.Position{4, 29}},
+				{token.Member, "type", token.Position{5, 22}},
+			},
+			Literals: []parsedLiteral[any]{
+				{"String", "string", "win32", `"win32"`, false, token.Position{1, 25}},
+				{"String", "string", "payload.exe", `"payload.exe"`, false, token.Position{2, 8}},
+				{"String", "string", "darwin", `"darwin"`, false, token.Position{4, 14}},
+				{"String", "string", "os", `"os"`, false, token.Position{5, 16}},
+				{"String", "string", "Linux", `"Linux"`, false, token.Position{6, 5}},
+			},
+			Conditions: []token.Condition{
+				{Subject: "process.platform", Operator: "===", Value: "win32", GatedLength: 27, Pos: token.Position{1, 4}},
+				{Subject: "os.platform()", Operator: "==", Value: "darwin", Pos: token.Position{4, 14}},
+				{Subject: `require("os").type()`, Operator: "case", Value: "Linux", GatedLength: 10, Pos: token.Position{6, 0}},
+			},
+		},
+	},
+	{
+		name: "test timing checks",
+		inputJS: `function check() {
+    const start = Date.now();
+    if (Date.now() - start > 100) {
+        process.exit(0);
+    }
+}
+var t0 = performance.now();
+var elapsed = performance.now() - t0;
+elapsed > 50 ? bail() : run();
+let last = 0;
+if (Date.now() - last > 1000) refresh();
+`,
+		want: singleParseData{
+			ValidInput: true,
+			Identifiers: []parsedIdentifier{
+				{token.Function, "check", token.Position{1, 9}},
+				{token.Variable, "start", token.Position{2, 10}},
+				{token.Member, "now", token.Position{2, 23}},
+				{token.Member, "now", token.Position{3, 13}},
+				{token.Member, "exit", token.Position{4, 16}},
+				{token.Variable, "t0", token.Position{7, 4}},
+				{token.Member, "now", token.Position{7, 21}},
+				{token.Variable, "elapsed", token.Position{8, 4}},
+				{token.Member, "now", token.Position{8, 26}},
+				{token.Variable, "last", token.Position{10, 4}},
+				{token.Member, "now", token.Position{11, 9}},
+			},
+			Literals: []parsedLiteral[any]{
+				{"Numeric", "float64", 100.0, "100", false, token.Position{3, 29}},
+				{"Numeric", "float64", 0.0, "0", false, token.Position{4, 21}},
+				{"Numeric", "float64", 50.0, "50", false, token.Position{9, 10}},
+				{"Numeric", "float64", 0.0, "0", false, token.Position{10, 11}},
+				{"Numeric", "float64", 1000.0, "1000", false, token.Position{11, 24}},
+			},
+			TimingChecks: []token.TimingCheck{
+				{API: "Date.now", StartPos: token.Position{2, 18}, EndPos: token.Position{3, 8}, GatedLength: 32, Pos: token.Position{3, 8}},
+				{API: "performance.now", StartPos: token.Position{7, 9}, EndPos: token.Position{8, 14}, GatedLength: 11, Pos: token.Position{8, 14}},
+			},
+		},
+	},
+	{
+		name: "test decoded executions",
+		inputJS: `eval(atob("Y29uc29sZS5sb2coMSk="));
+function run(s) {
+    const code = Buffer.from(s, "hex").toString();
+    return new Function(code);
+}
+console.log(atob("aGk="));
+`,
+		want: singleParseData{
+			ValidInput: true,
+			Identifiers: []parsedIdentifier{
+				{token.Function, "run", token.Position{2, 9}},
+				{token.Parameter, "s", token.Position{2, 13}},
+				{token.Variable, "code", token.Position{3, 10}},
+				{token.Member, "from", token.Position{3, 24}},
+				{token.Member, "toString", token.Position{3, 39}},
+				{token.Member, "log", token.Position{6, 8}},
+			},
+			Literals: []parsedLiteral[any]{
+				{"String", "string", "Y29uc29sZS5sb2coMSk=", `"Y29uc29sZS5sb2coMSk="`, false, token.Position{1, 10}},
+				{"String", "string", "hex", `"hex"`, false, token.Position{3, 32}},
+				{"String", "string", "aGk=", `"aGk="`, false, token.Position{6, 17}},
+			},
+			DecodedExecutions: []token.DecodedExecution{
+				{Sink: "eval", Decoder: "atob", Encoding: "base64", DecodePos: token.Position{1, 5}, Pos: token.Position{1, 0}},
+				{Sink: "Function", Decoder: "Buffer.from", Encoding: "hex", Variable: "code", DecodePos: token.Position{3, 17}, Pos: token.Position{4, 11}},
+			},
+		},
+	},
+	{
+		name: "test imports",
+		inputJS: `import fs from "fs";
+export * from "./lib";
+import("axios").then(run);
+`,
+		want: singleParseData{
+			ValidInput: true,
+			Identifiers: []parsedIdentifier{
+				{token.Member, "then", token.Position{3, 16}},
+			},
+			Literals: []parsedLiteral[any]{
+				{"String", "string", "fs", `"fs"`, false, token.Position{1, 15}},
+				{"String", "string", "./lib", `"./lib"`, false, token.Position{2, 14}},
+				{"String", "string", "axios", `"axios"`, false, token.Position{3, 7}},
+			},
+			Imports: []token.Import{
+				{Module: "fs", Pos: token.Position{1, 0}},
+				{Module: "./lib", Export: true, Pos: token.Position{2, 0}},
+			},
+			Calls: []token.Call{
+				{
+					Callee:        `import("axios").then`,
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Identifier", Value: "run"}},
+					Pos:           token.Position{3, 0},
+				},
+				{
+					Callee:        "import",
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "String", Value: "axios"}},
+					Pos:           token.Position{3, 0},
+				},
+			},
+		},
+	},
+	{
+		name:    "test constant numeric arguments",
+		inputJS: `setTimeout(run, 5 * 60 * 1000);`,
+		want: singleParseData{
+			ValidInput: true,
+			Literals: []parsedLiteral[any]{
+				{"Numeric", "float64", 5.0, "5", false, token.Position{1, 16}},
+				{"Numeric", "float64", 60.0, "60", false, token.Position{1, 20}},
+				{"Numeric", "float64", 1000.0, "1000", false, token.Position{1, 25}},
+			},
+			Calls: []token.Call{
+				{
+					Callee:        "setTimeout",
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Identifier", Value: "run"}, {Type: "Numeric", Value: "300000"}},
+					Pos:           token.Position{1, 0},
+				},
+			},
+		},
+	},
+	{
+		name: "test dynamic string arguments",
+		inputJS: `fetch("https://" + host + "/?d=" + process.env.HOME);
+https.get(` + "`https://${host}/x`" + `, cb);`,
+		want: singleParseData{
+			ValidInput: true,
+			Literals: []parsedLiteral[any]{
+				{"String", "string", "https://", `"https://"`, false, token.Position{1, 6}},
+				{"String", "string", "/?d=", `"/?d="`, false, token.Position{1, 26}},
+				{"StringTemplate", "string", "https://${}/x", "`https://${}/x`", false, token.Position{2, 10}},
+			},
+			Identifiers: []parsedIdentifier{
+				{token.Member, "env", token.Position{1, 43}},
+				{token.Member, "HOME", token.Position{1, 47}},
+				{token.Member, "get", token.Position{2, 6}},
+			},
+			Calls: []token.Call{
+				{
+					Callee:        "fetch",
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Concatenation", Value: "https://${host}/?d=${process.env.HOME}"}},
+					Pos:           token.Position{1, 0},
+				},
+				{
+					Callee:        "https.get",
+					Unconditional: true,
+					Args:          []token.CallArg{{Type: "Template", Value: "https://${host}/x"}, {Type: "Identifier", Value: "cb"}},
+					Pos:           token.Position{2, 0},
+				},
+			},
+		},
+	},
 }
 
 func TestParseJS(t *testing.T) {
@@ -440,6 +702,31 @@ func TestParseJS(t *testing.T) {
 				}
 			}
 
+			// only check calls for test cases that specify them
+			if tt.want.Calls != nil && !reflect.DeepEqual(got.Calls, tt.want.Calls) {
+				t.Errorf("Calls mismatch:\ngot  %v\nwant %v", got.Calls, tt.want.Calls)
+			}
+
+			// only check imports for test cases that specify them
+			if tt.want.Imports != nil && !reflect.DeepEqual(got.Imports, tt.want.Imports) {
+				t.Errorf("Imports mismatch:\ngot  %v\nwant %v", got.Imports, tt.want.Imports)
+			}
+
+			// only check conditions for test cases that specify them
+			if tt.want.Conditions != nil && !reflect.DeepEqual(got.Conditions, tt.want.Conditions) {
+				t.Errorf("Conditions mismatch:\ngot  %v\nwant %v", got.Conditions, tt.want.Conditions)
+			}
+
+			// only check timing checks for test cases that specify them
+			if tt.want.TimingChecks != nil && !reflect.DeepEqual(got.TimingChecks, tt.want.TimingChecks) {
+				t.Errorf("TimingChecks mismatch:\ngot  %v\nwant %v", got.TimingChecks, tt.want.TimingChecks)
+			}
+
+			// only check decoded executions for test cases that specify them
+			if tt.want.DecodedExecutions != nil && !reflect.DeepEqual(got.DecodedExecutions, tt.want.DecodedExecutions) {
+				t.Errorf("DecodedExecutions mismatch:\ngot  %v\nwant %v", got.DecodedExecutions, tt.want.DecodedExecutions)
+			}
+
 			if len(tt.want.Errors) != len(got.Errors) {
 				t.Errorf("Mismatch in number of errors: want %d, got %d", len(tt.want.Errors), len(got.Errors))
 			}
@@ -459,3 +746,501 @@ func TestParseJS(t *testing.T) {
 		})
 	}
 }
+
+// fakeNodeScript simulates the parser, writing the contents of $FAKE_NODE_OUTPUT
+// to the file given with --output, or nothing if it is not set.
+const fakeNodeScript = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	if [ "$1" = "--output" ] && [ -n "$FAKE_NODE_OUTPUT" ]; then
+		printf '%s' "$FAKE_NODE_OUTPUT" > "$2"
+	fi
+	shift
+done
+exit 0
+`
+
+func TestRunParserOutputErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "node"), []byte(fakeNodeScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	input := externalcmd.StringInput("f(1)")
+
+	// the parser exits without writing its output file
+	t.Setenv("FAKE_NODE_OUTPUT", "")
+	if _, err := runParser(context.Background(), "parser.js", input); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("runParser() without output error = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	t.Setenv("FAKE_NODE_OUTPUT", "{}")
+	errHandle := errors.New("handle error")
+	err := runParserWithOutput(context.Background(), "parser.js", input, func(r io.Reader) error {
+		if data, err := io.ReadAll(r); err != nil || string(data) != "{}" {
+			t.Errorf("output = %q (error %v), want %q", data, err, "{}")
+		}
+		return errHandle
+	})
+	if !errors.Is(err, errHandle) {
+		t.Errorf("runParserWithOutput() error = %v, want %v", err, errHandle)
+	}
+}
+
+func TestProcessCallTokens(t *testing.T) {
+	data := parseDataJSON{
+		Tokens: []parserTokenJSON{
+			{
+				TokenType:    call,
+				TokenSubType: "Call",
+				Data:         "this.eval",
+				Pos:          [2]int{1, 0},
+				Extra: map[string]any{
+					"computed": true,
+					"indirect": false,
+					"args":     []any{map[string]any{"type": "String", "value": "1+1"}},
+				},
+			},
+			{
+				TokenType:    call,
+				TokenSubType: "New",
+				Data:         "Function",
+				Pos:          [2]int{2, 4},
+				Extra:        map[string]any{"args": []any{}},
+			},
+		},
+	}
+	want := []token.Call{
+		{Callee: "this.eval", Computed: true, Args: []token.CallArg{{Type: "String", Value: "1+1"}}, Pos: token.Position{1, 0}},
+		{Callee: "Function", New: true, Args: []token.CallArg{}, Pos: token.Position{2, 4}},
+	}
+
+	got := data.process(context.Background(), ParserConfig{})
+	if !reflect.DeepEqual(got.Calls, want) {
+		t.Errorf("process() calls = %v, want %v", got.Calls, want)
+	}
+}
+
+func TestProcessImportTokens(t *testing.T) {
+	data := parseDataJSON{
+		Tokens: []parserTokenJSON{
+			{TokenType: moduleImport, TokenSubType: "Import", Data: "fs", Pos: [2]int{1, 0}},
+			{TokenType: moduleImport, TokenSubType: "Export", Data: "./lib", Pos: [2]int{2, 0}},
+		},
+	}
+	want := []token.Import{
+		{Module: "fs", Pos: token.Position{1, 0}},
+		{Module: "./lib", Export: true, Pos: token.Position{2, 0}},
+	}
+
+	got := data.process(context.Background(), ParserConfig{})
+	if !reflect.DeepEqual(got.Imports, want) {
+		t.Errorf("process() imports = %v, want %v", got.Imports, want)
+	}
+	if len(got.Calls) != 0 {
+		t.Errorf("process() calls = %v, want none", got.Calls)
+	}
+}
+
+func TestProcessIdentifierCounts(t *testing.T) {
+	data := parseDataJSON{
+		Tokens: []parserTokenJSON{
+			{TokenType: identifier, TokenSubType: "Function", Data: "f"},
+			{TokenType: identifier, TokenSubType: "Member", Data: "log"},
+			{TokenType: identifier, TokenSubType: "Member", Data: "error"},
+			{TokenType: identifier, TokenSubType: "Other", Data: "x"},
+			{TokenType: identifier, TokenSubType: "NotAType", Data: "y"},
+		},
+	}
+	want := map[token.IdentifierType]int{
+		token.Function: 1,
+		token.Member:   2,
+		token.Other:    1,
+		token.Unknown:  1,
+	}
+
+	got := data.process(context.Background(), ParserConfig{})
+	if !reflect.DeepEqual(got.IdentifierCounts, want) {
+		t.Errorf("process() identifier counts = %v, want %v", got.IdentifierCounts, want)
+	}
+	if len(got.Identifiers) != 3 {
+		t.Errorf("process() recorded %d identifiers, want 3", len(got.Identifiers))
+	}
+}
+
+func TestParseJSSymbolTypes(t *testing.T) {
+	const source = `// comment
+const fs = require("fs");
+var x = "a string";
+`
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	tests := []struct {
+		name        string
+		symbolTypes []SymbolType
+		want        map[tokenType]bool
+	}{
+		{
+			name: "all",
+			want: map[tokenType]bool{identifier: true, literal: true, comment: true, call: true},
+		},
+		{
+			name:        "calls only",
+			symbolTypes: []SymbolType{CallSymbols},
+			want:        map[tokenType]bool{call: true},
+		},
+		{
+			name:        "literals and comments",
+			symbolTypes: []SymbolType{LiteralSymbols, CommentSymbols},
+			want:        map[tokenType]bool{literal: true, comment: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := jsParserConfig
+			config.SymbolTypes = tt.symbolTypes
+			result, rawOutput, err := parseJS(context.Background(), config, externalcmd.StringInput(source))
+			if err != nil {
+				t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+			}
+			got := result["stdin"]
+
+			gotTypes := map[tokenType]bool{
+				identifier: len(got.Identifiers) > 0,
+				literal:    len(got.Literals) > 0,
+				comment:    len(got.Comments) > 0,
+				call:       len(got.Calls) > 0,
+			}
+			for typ, present := range gotTypes {
+				if present != tt.want[typ] {
+					t.Errorf("parseJS() collected %s = %v, want %v", typ, present, tt.want[typ])
+				}
+			}
+			if !got.ValidInput || got.Stats.InputBytes != int64(len(source)) {
+				t.Errorf("parseJS() valid = %v, input bytes = %d, want true, %d", got.ValidInput, got.Stats.InputBytes, len(source))
+			}
+		})
+	}
+}
+
+// byteList returns a comma-separated list of n byte values, e.g. "0x00, 0x01, 0x02".
+func byteList(n int) string {
+	values := make([]string, n)
+	for i := range values {
+		values[i] = fmt.Sprintf("0x%02x", i%256)
+	}
+	return strings.Join(values, ", ")
+}
+
+func TestParseJSBinaryPayloads(t *testing.T) {
+	var writes strings.Builder
+	for i := 0; i < 60; i++ {
+		fmt.Fprintf(&writes, "buf[%d] = 0x90;\n", i)
+	}
+	tests := []struct {
+		name    string
+		inputJS string
+		want    []token.BinaryPayload
+	}{
+		{
+			name:    "short array",
+			inputJS: "const magic = Buffer.from([" + byteList(4) + "]);\nfs.writeFileSync(p, magic);\n",
+		},
+		{
+			name:    "lookup table",
+			inputJS: "const table = new Uint8Array([" + byteList(256) + "]);\n",
+		},
+		{
+			name:    "computed table",
+			inputJS: "const t = new Uint8Array(256);\nfor (let i = 0; i < 256; i++) t[i] = i;\n",
+		},
+		{
+			name:    "not bytes",
+			inputJS: "const f = new Uint8Array([" + strings.Repeat("1.5, ", 600) + "]);\n",
+		},
+		{
+			name:    "large array",
+			inputJS: "const payload = Buffer.from([" + byteList(600) + "]);\n",
+			want:    []token.BinaryPayload{{Constructor: "Buffer.from", Size: 600, Pos: token.Position{1, 16}}},
+		},
+		{
+			name:    "array written to file",
+			inputJS: "const sc = new Uint8Array([" + byteList(80) + "]);\nrequire(\"fs\").writeFileSync(\"/tmp/x\", sc);\n",
+			want: []token.BinaryPayload{
+				{Constructor: "Uint8Array", Size: 80, Sink: `require("fs").writeFileSync`, Pos: token.Position{1, 11}},
+			},
+		},
+		{
+			name:    "array compiled as wasm",
+			inputJS: "WebAssembly.instantiate(Uint8Array.of(" + byteList(100) + ").buffer);\n",
+			want: []token.BinaryPayload{
+				{Constructor: "Uint8Array.of", Size: 100, Sink: "WebAssembly.instantiate", Pos: token.Position{1, 24}},
+			},
+		},
+		{
+			name: "bytes written one at a time",
+			inputJS: "const buf = Buffer.alloc(100);\n" + writes.String() +
+				"buf.writeUInt32LE(0xdeadbeef, 60);\nbuf.set([1, 2, 3, 4], 64);\nfs.writeFile(\"/tmp/y\", buf, cb);\n",
+			want: []token.BinaryPayload{
+				{Constructor: "Buffer.alloc", Size: 68, Writes: true, Sink: "fs.writeFile", Pos: token.Position{1, 12}},
+			},
+		},
+	}
+
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	jsParserConfig.SymbolTypes = []SymbolType{BinaryPayloadSymbols}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, rawOutput, err := parseJS(context.Background(), jsParserConfig, externalcmd.StringInput(tt.inputJS))
+			if err != nil {
+				t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+			}
+			if got := result["stdin"].BinaryPayloads; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseJS() binary payloads = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessBinaryPayloadTokens(t *testing.T) {
+	data := parseDataJSON{
+		Tokens: []parserTokenJSON{
+			{
+				TokenType:    binaryPayload,
+				TokenSubType: "Array",
+				Data:         "Uint8Array",
+				Pos:          [2]int{1, 10},
+				Extra:        map[string]any{"size": 80.0, "sink": "fs.writeFileSync"},
+			},
+			{
+				TokenType:    binaryPayload,
+				TokenSubType: "Writes",
+				Data:         "Buffer.alloc",
+				Pos:          [2]int{3, 12},
+				Extra:        map[string]any{"size": 600.0, "sink": ""},
+			},
+		},
+	}
+	want := []token.BinaryPayload{
+		{Constructor: "Uint8Array", Size: 80, Sink: "fs.writeFileSync", Pos: token.Position{1, 10}},
+		{Constructor: "Buffer.alloc", Size: 600, Writes: true, Pos: token.Position{3, 12}},
+	}
+
+	got := data.process(context.Background(), ParserConfig{})
+	if !reflect.DeepEqual(got.BinaryPayloads, want) {
+		t.Errorf("process() binary payloads = %v, want %v", got.BinaryPayloads, want)
+	}
+}
+
+func TestProcessSymbolTypes(t *testing.T) {
+	data := parseDataJSON{
+		Tokens: []parserTokenJSON{
+			{TokenType: identifier, TokenSubType: "Variable", Data: "x"},
+			{TokenType: literal, TokenSubType: "String", Data: "s"},
+			{TokenType: comment, TokenSubType: "CommentLine", Data: "c"},
+		},
+	}
+
+	got := data.process(context.Background(), ParserConfig{SymbolTypes: []SymbolType{LiteralSymbols}})
+	if len(got.Literals) != 1 || got.Identifiers != nil || got.Comments != nil {
+		t.Errorf("process() = %v, want only literals", got)
+	}
+}
+
+func TestParserArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config ParserConfig
+		want   []string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:   "symbol types",
+			config: ParserConfig{SymbolTypes: []SymbolType{CallSymbols, LiteralSymbols}},
+			want:   []string{"--only", "Call,Literal"},
+		},
+		{
+			name:   "full AST",
+			config: ParserConfig{FullAST: true, SymbolTypes: []SymbolType{CallSymbols}},
+			want:   []string{"--ast", "--only", "Call"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.parserArgs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parserArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessAST(t *testing.T) {
+	const output = `{"stdin": {
+  "tokens": [],
+  "status": [],
+  "ast": {"version": 1, "root": {"type": "Program", "pos": [1, 0], "end": [1, 2], "scope": 1, "children": [
+    {"type": "ExpressionStatement", "field": "body", "pos": [1, 0], "end": [1, 2], "children": [
+      {"type": "Identifier", "field": "expression", "pos": [1, 0], "end": [1, 1], "attrs": {"name": "x"}}
+    ]}
+  ]}}
+}}`
+	var parsed parseOutputJSON
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	got := processJsData(parsed["stdin"].process(context.Background(), ParserConfig{FullAST: true}))
+	if got.AST == nil || got.AST.Version != ast.SchemaVersion {
+		t.Fatalf("processJsData() AST = %+v, want a version %d tree", got.AST, ast.SchemaVersion)
+	}
+	if name := got.AST.Root.Child("body").Child("expression").StringAttr("name"); name != "x" {
+		t.Errorf("processJsData() AST identifier = %q, want x", name)
+	}
+}
+
+func TestParseJSFullAST(t *testing.T) {
+	const source = "function f(x) { return x; }\nf(1);\n"
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	result, rawOutput, err := parseJS(context.Background(), jsParserConfig, externalcmd.StringInput(source))
+	if err != nil {
+		t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+	}
+	if result["stdin"].AST != nil {
+		t.Errorf("parseJS() returned an AST without FullAST")
+	}
+
+	jsParserConfig.FullAST = true
+	result, rawOutput, err = parseJS(context.Background(), jsParserConfig, externalcmd.StringInput(source))
+	if err != nil {
+		t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+	}
+	tree := result["stdin"].AST
+	if tree == nil || tree.Version != ast.SchemaVersion || tree.Root.Type != "Program" || tree.Root.Scope != 1 {
+		t.Fatalf("parseJS() AST = %+v, want a version %d Program", tree, ast.SchemaVersion)
+	}
+	// calls are still collected
+	if len(result["stdin"].Calls) != 1 {
+		t.Errorf("parseJS() calls = %v, want f(1)", result["stdin"].Calls)
+	}
+
+	type boundName struct {
+		name    string
+		pos     token.Position
+		binding ast.Binding
+	}
+	var got []boundName
+	ast.Walk(tree.Root, func(n, parent *ast.Node) bool {
+		if n.Type == "Identifier" && n.Binding != nil {
+			got = append(got, boundName{n.StringAttr("name"), n.Pos, *n.Binding})
+		}
+		return true
+	})
+	want := []boundName{
+		{"f", token.Position{1, 9}, ast.Binding{Scope: 1, Kind: "hoisted", Declaration: true}},
+		{"x", token.Position{1, 11}, ast.Binding{Scope: 2, Kind: "param", Declaration: true}},
+		{"x", token.Position{1, 23}, ast.Binding{Scope: 2, Kind: "param"}},
+		{"f", token.Position{2, 0}, ast.Binding{Scope: 1, Kind: "hoisted"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJS() AST identifiers = %v, want %v", got, want)
+	}
+}
+
+// encodeUTF16 encodes s as UTF-16 with the given byte order, optionally with a byte order mark.
+func encodeUTF16(s string, order binary.ByteOrder, bom bool) []byte {
+	units := utf16.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		order.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func TestParseJSSourceEncodings(t *testing.T) {
+	const source = "var greeting = \"héllo\";\n"
+	want := singleParseData{
+		Identifiers: []parsedIdentifier{
+			{token.Variable, "greeting", token.Position{1, 4}},
+		},
+		Literals: []parsedLiteral[any]{
+			{"String", "string", "héllo", `"héllo"`, false, token.Position{1, 15}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		contents     []byte
+		wantEncoding string
+	}{
+		{
+			name:         "plain utf-8",
+			contents:     []byte(source),
+			wantEncoding: "",
+		},
+		{
+			name:         "utf-8 with bom",
+			contents:     append([]byte{0xEF, 0xBB, 0xBF}, source...),
+			wantEncoding: "utf-8-bom",
+		},
+		{
+			name:         "utf-16le with bom",
+			contents:     encodeUTF16(source, binary.LittleEndian, true),
+			wantEncoding: "utf-16le",
+		},
+		{
+			name:         "utf-16le without bom",
+			contents:     encodeUTF16(source, binary.LittleEndian, false),
+			wantEncoding: "utf-16le",
+		},
+		{
+			name:         "utf-16be with bom",
+			contents:     encodeUTF16(source, binary.BigEndian, true),
+			wantEncoding: "utf-16be",
+		},
+	}
+
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "index.js")
+			if err := os.WriteFile(path, tt.contents, 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			result, rawOutput, err := parseJS(context.Background(), jsParserConfig, externalcmd.SingleFileInput(path))
+			if err != nil {
+				t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+			}
+			got := result[path]
+
+			if got.SourceEncoding != tt.wantEncoding {
+				t.Errorf("SourceEncoding = %q, want %q", got.SourceEncoding, tt.wantEncoding)
+			}
+			if !reflect.DeepEqual(got.Identifiers, want.Identifiers) {
+				t.Errorf("Identifiers = %v, want %v", got.Identifiers, want.Identifiers)
+			}
+			if !reflect.DeepEqual(got.Literals, want.Literals) {
+				t.Errorf("Literals = %v, want %v", got.Literals, want.Literals)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package parsing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+const (
+	// MaxNestedCodeDepth is the largest supported value of ParserConfig.NestedCodeDepth.
+	// Larger values are treated as this value.
+	MaxNestedCodeDepth = 3
+
+	// minNestedCodeLength is the length of the shortest string literal which is
+	// parsed as nested code.
+	minNestedCodeLength = 16
+
+	// maxNestedCodeStrings is the largest number of string literals parsed as
+	// nested code at each level of nesting, across all files parsed together.
+	maxNestedCodeStrings = 100
+)
+
+// nestedCallPattern matches a call, e.g. "f(" or "a[0](".
+var nestedCallPattern = regexp.MustCompile(`[\w$\])]\s*\(`)
+
+// nestedCodePattern matches keywords, names and punctuation that rarely occur
+// outside code, to distinguish code from prose that happens to contain parentheses.
+var nestedCodePattern = regexp.MustCompile(`\b(?:function|return|var|let|const|require|import|eval|new|this|module|exports|process)\b|=>|[;{]`)
+
+// looksLikeCode returns whether the string literal s is shaped like JavaScript code,
+// i.e. it contains a call as well as a keyword or a statement or block delimiter.
+// High-entropy strings which are not shaped like code (e.g. base64 data) are not
+// selected, since they would not parse.
+func looksLikeCode(s string) bool {
+	return len(s) >= minNestedCodeLength && nestedCallPattern.MatchString(s) && nestedCodePattern.MatchString(s)
+}
+
+// nestedSource is a string literal to be parsed as nested code. Pos is the position
+// of the outermost literal in file, to which all tokens found in value are attributed.
+type nestedSource struct {
+	file  string
+	pos   token.Position
+	value string
+}
+
+// nestedSources appends to sources the string literals in literals which look
+// like code (see looksLikeCode), and have not been seen before in the same file.
+// If outerPos is not nil, the literals were themselves found in nested code, and
+// are attributed to that position rather than their own.
+func nestedSources(sources []nestedSource, seen map[nestedSource]bool, file string, outerPos *token.Position, literals []parsedLiteral[any]) []nestedSource {
+	for _, l := range literals {
+		value, ok := l.Value.(string)
+		if !ok || !looksLikeCode(value) {
+			continue
+		}
+		src := nestedSource{file: file, pos: l.Pos, value: value}
+		if outerPos != nil {
+			src.pos = *outerPos
+		}
+		if key := (nestedSource{file: file, value: value}); !seen[key] {
+			seen[key] = true
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// addNested adds the tokens found by parsing a string literal at pos to d. All
+// tokens are attributed to pos, since their positions in the literal do not
+// correspond to positions in the file.
+func (d *singleParseData) addNested(nested singleParseData, pos token.Position) {
+	d.EmbeddedCode = append(d.EmbeddedCode, pos)
+	for _, ident := range nested.Identifiers {
+		ident.Pos = pos
+		d.Identifiers = append(d.Identifiers, ident)
+	}
+	if d.IdentifierCounts == nil {
+		d.IdentifierCounts = make(map[token.IdentifierType]int)
+	}
+	for t, n := range nested.IdentifierCounts {
+		d.IdentifierCounts[t] += n
+	}
+	for _, l := range nested.Literals {
+		l.Pos = pos
+		d.Literals = append(d.Literals, l)
+	}
+	for _, c := range nested.Comments {
+		c.Pos = pos
+		d.Comments = append(d.Comments, c)
+	}
+	for _, c := range nested.Calls {
+		c.Pos = pos
+		d.Calls = append(d.Calls, c)
+	}
+	for _, i := range nested.Imports {
+		i.Pos = pos
+		d.Imports = append(d.Imports, i)
+	}
+	for _, a := range nested.Assignments {
+		a.Pos = pos
+		d.Assignments = append(d.Assignments, a)
+	}
+	for _, c := range nested.Conditions {
+		c.Pos = pos
+		d.Conditions = append(d.Conditions, c)
+	}
+	for _, c := range nested.TimingChecks {
+		c.Pos, c.StartPos, c.EndPos = pos, pos, pos
+		d.TimingChecks = append(d.TimingChecks, c)
+	}
+	for _, e := range nested.DecodedExecutions {
+		e.Pos, e.DecodePos = pos, pos
+		d.DecodedExecutions = append(d.DecodedExecutions, e)
+	}
+	for _, p := range nested.BinaryPayloads {
+		p.Pos = pos
+		d.BinaryPayloads = append(d.BinaryPayloads, p)
+	}
+}
+
+// parseNestedSources parses the values of sources as JavaScript together, by
+// writing each to a temporary file. The result for each source that could be
+// parsed is returned, keyed by its index in sources.
+func parseNestedSources(ctx context.Context, config ParserConfig, sources []nestedSource) (map[int]singleParseData, error) {
+	dir, err := os.MkdirTemp("", "package-analysis-nested-code-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory for nested code: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			slog.ErrorContext(ctx, "could not remove nested code directory", "path", dir, "error", err)
+		}
+	}()
+
+	paths := make([]string, len(sources))
+	indices := make(map[string]int, len(sources))
+	for i, src := range sources {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("%d.js", i))
+		indices[paths[i]] = i
+		if err := os.WriteFile(paths[i], []byte(src.value), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to write nested code: %w", err)
+		}
+	}
+
+	// the trees of nested code are not kept
+	config.FullAST = false
+	parsed, _, err := parseJS(ctx, config, externalcmd.MultipleFileInput(paths))
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[int]singleParseData, len(parsed))
+	for path, data := range parsed {
+		if i, ok := indices[path]; ok && data.ValidInput {
+			results[i] = data
+		}
+	}
+	return results, nil
+}
+
+/*
+expandNestedCode parses string literals in the given parse results which look like
+JavaScript code (e.g. eval payloads, or modules bundled as strings) as code, and
+adds the tokens found to the result for the file containing the literal, attributed
+to the position of the literal (see singleParseData.addNested). String literals found
+in nested code are in turn parsed as code, up to config.NestedCodeDepth levels of
+nesting (at most MaxNestedCodeDepth). Each string is parsed at most once per file,
+so that code which contains itself cannot be expanded repeatedly, and at most
+maxNestedCodeStrings strings are parsed at each level.
+
+Literals which cannot be parsed as code are skipped. If parsing fails with an
+internal error, expansion stops and the results found so far are kept, unless
+the error is due to ctx being done, in which case it is returned.
+*/
+func expandNestedCode(ctx context.Context, config ParserConfig, results map[string]singleParseData) error {
+	depth := min(config.NestedCodeDepth, MaxNestedCodeDepth)
+	nestedConfig := config
+	nestedConfig.NestedCodeDepth = 0
+
+	files := make([]string, 0, len(results))
+	for file := range results {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	seen := make(map[nestedSource]bool)
+	var sources []nestedSource
+	for _, file := range files {
+		sources = nestedSources(sources, seen, file, nil, results[file].Literals)
+	}
+
+	for level := 0; level < depth && len(sources) > 0; level++ {
+		if len(sources) > maxNestedCodeStrings {
+			slog.WarnContext(ctx, "too many string literals to parse as nested code", "count", len(sources), "limit", maxNestedCodeStrings)
+			sources = sources[:maxNestedCodeStrings]
+		}
+		parsed, err := parseNestedSources(ctx, nestedConfig, sources)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			slog.WarnContext(ctx, "failed to parse nested code", "error", err)
+			return nil
+		}
+
+		var next []nestedSource
+		for i, src := range sources {
+			nested, ok := parsed[i]
+			if !ok {
+				continue
+			}
+			data := results[src.file]
+			data.addNested(nested, src.pos)
+			results[src.file] = data
+			next = nestedSources(next, seen, src.file, &src.pos, nested.Literals)
+		}
+		sources = next
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package parsing
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestLooksLikeCode(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"eval payload", `require("child_process").exec("id")`, true},
+		{"function", `function f(a) { return a + 1 }`, true},
+		{"arrow function", `x.map(y => y * 2)`, true},
+		{"statements", `a=b(c);d=e(f);g=h(i)`, true},
+		{"too short", `eval(x);`, false},
+		{"prose with parentheses", `Hello world (and everyone else)`, false},
+		{"base64", `Y29uc29sZS5sb2coImhlbGxvIik7Cg==`, false},
+		{"url", `https://example.com/path?query=1`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := looksLikeCode(tt.s); got != tt.want {
+				t.Errorf("looksLikeCode(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSNestedCode(t *testing.T) {
+	const source = `var payload = 'var cp = require("child_process"); eval("cp.exec(\\"curl http://example.com\\");");';
+eval(payload);
+`
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	literalPos := token.Position{1, 14}
+
+	tests := []struct {
+		name             string
+		depth            int
+		wantCallees      []string
+		wantEmbeddedCode []token.Position
+	}{
+		{
+			name:        "disabled",
+			wantCallees: []string{"eval"},
+		},
+		{
+			name:             "one level",
+			depth:            1,
+			wantCallees:      []string{"eval", "require", "eval"},
+			wantEmbeddedCode: []token.Position{literalPos},
+		},
+		{
+			name:             "two levels",
+			depth:            2,
+			wantCallees:      []string{"eval", "require", "eval", "cp.exec"},
+			wantEmbeddedCode: []token.Position{literalPos, literalPos},
+		},
+		{
+			name:             "depth is limited",
+			depth:            100,
+			wantCallees:      []string{"eval", "require", "eval", "cp.exec"},
+			wantEmbeddedCode: []token.Position{literalPos, literalPos},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := jsParserConfig
+			config.NestedCodeDepth = tt.depth
+			result, rawOutput, err := parseJS(context.Background(), config, externalcmd.StringInput(source))
+			if err != nil {
+				t.Fatalf("parseJS() error = %v\nParser output:\n%s", err, rawOutput)
+			}
+			got := result["stdin"]
+
+			var callees []string
+			for i, c := range got.Calls {
+				callees = append(callees, c.Callee)
+				if i > 0 && c.Pos != literalPos {
+					t.Errorf("call %s at %v, want position of literal %v", c.Callee, c.Pos, literalPos)
+				}
+			}
+			if !reflect.DeepEqual(callees, tt.wantCallees) {
+				t.Errorf("parseJS() calls = %v, want %v", callees, tt.wantCallees)
+			}
+			if !reflect.DeepEqual(got.EmbeddedCode, tt.wantEmbeddedCode) {
+				t.Errorf("parseJS() embedded code = %v, want %v", got.EmbeddedCode, tt.wantEmbeddedCode)
+			}
+		})
+	}
+}
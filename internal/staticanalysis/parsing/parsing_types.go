@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
 )
 
@@ -38,6 +39,27 @@ const (
 	// comment means any comment in the source code
 	comment tokenType = "Comment"
 
+	// call means a call to a function or constructor with a known name
+	call tokenType = "Call"
+
+	// moduleImport means an import declaration, or a re-export of another module
+	moduleImport tokenType = "Import"
+
+	// assignment means a constant value assigned to a named property
+	assignment tokenType = "Assignment"
+
+	// condition means a comparison of the platform the code runs on with a constant string
+	condition tokenType = "Condition"
+
+	// timingCheck means a comparison of the difference between two reads of the current time
+	timingCheck tokenType = "TimingCheck"
+
+	// decodedExecution means a call which runs code decoded from base64 or hex
+	decodedExecution tokenType = "DecodedExecution"
+
+	// binaryPayload means bytes assembled from literal data, e.g. new Uint8Array([...])
+	binaryPayload tokenType = "BinaryPayload"
+
 	// parseInfo means any metadata about the parsing, e.g. number of bytes read by parser.
 	parseInfo statusType = "Info"
 
@@ -45,6 +67,26 @@ const (
 	parseError statusType = "Error"
 )
 
+// SymbolType is a type of source code symbol which may be collected by the
+// parser. See ParserConfig.SymbolTypes.
+type SymbolType string
+
+const (
+	IdentifierSymbols SymbolType = SymbolType(identifier)
+	LiteralSymbols    SymbolType = SymbolType(literal)
+	CommentSymbols    SymbolType = SymbolType(comment)
+
+	// CallSymbols includes calls to require and dynamic imports, while import
+	// declarations are ImportSymbols.
+	CallSymbols             SymbolType = SymbolType(call)
+	ImportSymbols           SymbolType = SymbolType(moduleImport)
+	AssignmentSymbols       SymbolType = SymbolType(assignment)
+	ConditionSymbols        SymbolType = SymbolType(condition)
+	TimingCheckSymbols      SymbolType = SymbolType(timingCheck)
+	DecodedExecutionSymbols SymbolType = SymbolType(decodedExecution)
+	BinaryPayloadSymbols    SymbolType = SymbolType(binaryPayload)
+)
+
 type parsedIdentifier struct {
 	Type token.IdentifierType
 	Name string
@@ -95,28 +137,89 @@ func (s parserStatus) String() string {
 
 // singleParseData holds package-internal data for a single file processed by a single language parser.
 type singleParseData struct {
+	// ValidInput is false if the parser could not parse the input due to
+	// syntax errors it could not recover from. See Err.
 	ValidInput  bool
 	Identifiers []parsedIdentifier
-	Literals    []parsedLiteral[any]
-	Comments    []parsedComment
-	Info        []parserStatus
-	Errors      []parserStatus
+	// IdentifierCounts holds the number of identifiers of each type found by the
+	// parser, including types which are not recorded in Identifiers.
+	IdentifierCounts  map[token.IdentifierType]int
+	Literals          []parsedLiteral[any]
+	Comments          []parsedComment
+	Calls             []token.Call
+	Imports           []token.Import
+	Assignments       []token.Assignment
+	Conditions        []token.Condition
+	TimingChecks      []token.TimingCheck
+	DecodedExecutions []token.DecodedExecution
+	BinaryPayloads    []token.BinaryPayload
+	// SourceEncoding is the original encoding of the file if the parser had to
+	// normalise it before parsing (see SingleResult.SourceEncoding), otherwise empty.
+	SourceEncoding string
+	// EmbeddedCode holds the positions of string literals which were parsed as
+	// nested code (see ParserConfig.NestedCodeDepth).
+	EmbeddedCode []token.Position
+	// AST is the syntax tree of the file, if ParserConfig.FullAST is set.
+	AST    *ast.Tree
+	Stats  ParseStats
+	Info   []parserStatus
+	Errors []parserStatus
 }
 
 func (d singleParseData) String() string {
 	identifiers := utils.Transform(d.Identifiers, func(pi parsedIdentifier) string { return pi.String() })
 	literals := utils.Transform(d.Literals, func(pl parsedLiteral[any]) string { return pl.String() })
 	comments := utils.Transform(d.Comments, func(c parsedComment) string { return c.String() })
+	calls := utils.Transform(d.Calls, func(c token.Call) string {
+		return fmt.Sprintf("%s %v pos %d:%d", c.Callee, c.Args, c.Pos.Row(), c.Pos.Col())
+	})
+	imports := utils.Transform(d.Imports, func(i token.Import) string {
+		return fmt.Sprintf("%q (export %t) pos %d:%d", i.Module, i.Export, i.Pos.Row(), i.Pos.Col())
+	})
+	assignments := utils.Transform(d.Assignments, func(a token.Assignment) string {
+		return fmt.Sprintf("%s = %v pos %d:%d", a.Target, a.Value, a.Pos.Row(), a.Pos.Col())
+	})
+	conditions := utils.Transform(d.Conditions, func(c token.Condition) string {
+		return fmt.Sprintf("%s %s %q (gates %d) pos %d:%d", c.Subject, c.Operator, c.Value, c.GatedLength, c.Pos.Row(), c.Pos.Col())
+	})
+	timingChecks := utils.Transform(d.TimingChecks, func(c token.TimingCheck) string {
+		return fmt.Sprintf("%s %d:%d - %d:%d (gates %d) pos %d:%d", c.API, c.EndPos.Row(), c.EndPos.Col(),
+			c.StartPos.Row(), c.StartPos.Col(), c.GatedLength, c.Pos.Row(), c.Pos.Col())
+	})
+	decodedExecutions := utils.Transform(d.DecodedExecutions, func(e token.DecodedExecution) string {
+		return fmt.Sprintf("%s(%s %s %d:%d via %q) pos %d:%d", e.Sink, e.Decoder, e.Encoding, e.DecodePos.Row(),
+			e.DecodePos.Col(), e.Variable, e.Pos.Row(), e.Pos.Col())
+	})
+	binaryPayloads := utils.Transform(d.BinaryPayloads, func(p token.BinaryPayload) string {
+		return fmt.Sprintf("%s %d bytes (writes %t, sink %q) pos %d:%d", p.Constructor, p.Size, p.Writes, p.Sink,
+			p.Pos.Row(), p.Pos.Col())
+	})
 	info := utils.Transform(d.Info, func(i parserStatus) string { return i.String() })
 	errors := utils.Transform(d.Errors, func(e parserStatus) string { return e.String() })
 
 	parts := []string{
 		"== Identifiers ==",
 		strings.Join(identifiers, "\n"),
+		"== Identifier counts ==",
+		fmt.Sprintf("%v", d.IdentifierCounts),
 		"== Literals ==",
 		strings.Join(literals, "\n"),
 		"== Comments ==",
 		strings.Join(comments, "\n"),
+		"== Calls ==",
+		strings.Join(calls, "\n"),
+		"== Imports ==",
+		strings.Join(imports, "\n"),
+		"== Assignments ==",
+		strings.Join(assignments, "\n"),
+		"== Conditions ==",
+		strings.Join(conditions, "\n"),
+		"== Timing checks ==",
+		strings.Join(timingChecks, "\n"),
+		"== Decoded executions ==",
+		strings.Join(decodedExecutions, "\n"),
+		"== Binary payloads ==",
+		strings.Join(binaryPayloads, "\n"),
 		"== Info ==",
 		strings.Join(info, "\n"),
 		"== Errors ==",
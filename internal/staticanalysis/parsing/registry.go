@@ -0,0 +1,193 @@
+package parsing
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxShebangLength is the maximum number of bytes read from the start of a file
+// when looking for an interpreter directive (e.g. "#!/usr/bin/env node").
+const maxShebangLength = 256
+
+// javaScriptExtensions are the file extensions of JavaScript source files.
+var javaScriptExtensions = []string{".js", ".mjs", ".cjs", ".jsx"}
+
+// javaScriptInterpreters are the interpreters named by the interpreter directive
+// of executable JavaScript files without an extension.
+var javaScriptInterpreters = []string{"node", "nodejs"}
+
+/*
+Registry maps files to the Backend that parses them, so that a package containing
+source files in multiple languages can be parsed without the caller routing each
+file to a parser.
+
+A file is parsed by the backend registered for its extension. If there is none,
+the backend registered for the interpreter named by the file's interpreter
+directive (e.g. "#!/usr/bin/env node") is used. Otherwise, the fallback backend
+is used, if one is set.
+
+The zero value is not usable; use NewRegistry or DefaultRegistry.
+*/
+type Registry struct {
+	extensions   map[string]Backend
+	interpreters map[string]Backend
+	fallback     Backend
+	fileTimeout  time.Duration
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		extensions:   map[string]Backend{},
+		interpreters: map[string]Backend{},
+	}
+}
+
+// DefaultRegistry returns a Registry with all supported backends. JavaScript is
+// also the fallback, so that files with unknown extensions are still parsed if
+// they contain valid JavaScript.
+func DefaultRegistry(jsConfig ParserConfig) *Registry {
+	js := JavaScriptBackend(jsConfig)
+
+	r := NewRegistry()
+	r.RegisterExtensions(js, javaScriptExtensions...)
+	r.RegisterInterpreters(js, javaScriptInterpreters...)
+	r.SetFallback(js)
+	return r
+}
+
+// RegisterExtensions sets b as the backend for files with the given extensions
+// (e.g. ".js"), replacing any backend previously registered for them. Extensions
+// are matched case-insensitively.
+func (r *Registry) RegisterExtensions(b Backend, extensions ...string) {
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		r.extensions[strings.ToLower(ext)] = b
+	}
+}
+
+// RegisterInterpreters sets b as the backend for files whose interpreter directive
+// names one of the given interpreters (e.g. "node" or "python3"), replacing any
+// backend previously registered for them.
+func (r *Registry) RegisterInterpreters(b Backend, interpreters ...string) {
+	for _, interpreter := range interpreters {
+		r.interpreters[interpreter] = b
+	}
+}
+
+// SetFallback sets b as the backend for files that do not match any registered
+// extension or interpreter. If b is nil, these files are not parsed.
+func (r *Registry) SetFallback(b Backend) {
+	r.fallback = b
+}
+
+// SetFileTimeout sets the time that each file is given to parse in AnalyzeConcurrently.
+// This is a budget for each batch of files parsed together, of d for each file in the
+// batch. If a batch runs out of time, its files are parsed again one at a time, and
+// those which take longer than d are not parsed, and their error wraps ErrTimeout,
+// while the other files are parsed as normal. So a slow file is only timed out if its
+// batch runs out of time. If d is not positive (the default), files are only limited
+// by the deadline of the context.
+func (r *Registry) SetFileTimeout(d time.Duration) {
+	r.fileTimeout = d
+}
+
+// BackendFor returns the backend that parses the file at path, or nil if there is none.
+func (r *Registry) BackendFor(path string) Backend {
+	if b, ok := r.extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return b
+	}
+	if len(r.interpreters) > 0 {
+		if b, ok := r.interpreters[readInterpreter(path)]; ok {
+			return b
+		}
+	}
+	return r.fallback
+}
+
+/*
+AnalyzeConcurrently parses each of the given files using the backend returned by
+BackendFor, and merges the results. Files for each backend are parsed as described
+by the package-level AnalyzeConcurrently function (with the time budget for each file
+set by SetFileTimeout), and entropy values are computed separately for the files
+parsed by each backend.
+
+Files without a backend are absent from both returned maps.
+*/
+func (r *Registry) AnalyzeConcurrently(ctx context.Context, paths []string, concurrency int) (map[string]SingleResult, map[string]error) {
+	var backends []Backend
+	pathsByBackend := map[Backend][]string{}
+	for _, path := range paths {
+		b := r.BackendFor(path)
+		if b == nil {
+			continue
+		}
+		if _, seen := pathsByBackend[b]; !seen {
+			backends = append(backends, b)
+		}
+		pathsByBackend[b] = append(pathsByBackend[b], path)
+	}
+
+	resultsByFile := make(map[string]SingleResult, len(paths))
+	errorsByFile := make(map[string]error)
+	for _, b := range backends {
+		results, errs := parseConcurrently(ctx, b.Parse, pathsByBackend[b], concurrency, r.fileTimeout)
+		populateEntropies(results)
+
+		for path, result := range results {
+			resultsByFile[path] = result
+		}
+		for path, err := range errs {
+			errorsByFile[path] = err
+		}
+	}
+
+	return resultsByFile, errorsByFile
+}
+
+// readInterpreter returns the name of the interpreter in the interpreter directive
+// at the start of the file at path, e.g. "node" for "#!/usr/bin/env node". It returns
+// an empty string if the file has no interpreter directive or cannot be read.
+func readInterpreter(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReaderSize(f, maxShebangLength).Peek(maxShebangLength)
+	return parseInterpreter(string(line))
+}
+
+// parseInterpreter returns the name of the interpreter in the interpreter directive
+// on the first line of s, or an empty string if there is none.
+func parseInterpreter(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	directive, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return ""
+	}
+
+	fields := strings.Fields(directive)
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter != "env" {
+		return interpreter
+	}
+
+	// #!/usr/bin/env [-S] [NAME=VALUE...] interpreter
+	for _, arg := range fields[1:] {
+		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+			return filepath.Base(arg)
+		}
+	}
+	return ""
+}
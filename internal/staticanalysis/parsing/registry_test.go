@@ -0,0 +1,192 @@
+package parsing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// fakeBackend returns a result with a single string literal for each file,
+// or fails if err is set.
+type fakeBackend struct {
+	language Language
+	err      error
+}
+
+func (b fakeBackend) Language() Language {
+	return b.language
+}
+
+func (b fakeBackend) Parse(ctx context.Context, paths []string) (map[string]SingleResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	results := map[string]SingleResult{}
+	for _, path := range paths {
+		results[path] = SingleResult{
+			Language:       b.language,
+			StringLiterals: []token.String{{Value: string(b.language) + ":" + filepath.Base(path)}},
+		}
+	}
+	return results, nil
+}
+
+func TestParseInterpreter(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"#!/usr/bin/env node\nconsole.log(1)", "node"},
+		{"#!/usr/local/bin/python3", "python3"},
+		{"#! /bin/sh -e", "sh"},
+		{"#!/usr/bin/env -S NODE_OPTIONS=--x node --harmony", "node"},
+		{"#!/usr/bin/env", ""},
+		{"console.log('#!/usr/bin/env node')", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := parseInterpreter(tt.line); got != tt.want {
+				t.Errorf("parseInterpreter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryBackendFor(t *testing.T) {
+	js := fakeBackend{language: JavaScript}
+	py := fakeBackend{language: "Python"}
+	fallback := fakeBackend{language: "Fallback"}
+
+	dir := t.TempDir()
+	writeFile := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	r := NewRegistry()
+	r.RegisterExtensions(js, ".js", "mjs")
+	r.RegisterExtensions(py, ".py")
+	r.RegisterInterpreters(py, "python3")
+
+	tests := []struct {
+		name     string
+		path     string
+		fallback Backend
+		want     Backend
+	}{
+		{"extension", "/pkg/index.js", nil, js},
+		{"extension without dot", "/pkg/index.mjs", nil, js},
+		{"upper case extension", "/pkg/SETUP.PY", nil, py},
+		{"interpreter", writeFile("run", "#!/usr/bin/env python3\nprint(1)\n"), nil, py},
+		{"extension takes precedence", writeFile("run.js", "#!/usr/bin/env python3\n"), nil, js},
+		{"unknown", writeFile("README", "hello"), nil, nil},
+		{"missing file", filepath.Join(dir, "missing"), nil, nil},
+		{"fallback", "/pkg/data.wasm", fallback, fallback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.SetFallback(tt.fallback)
+			if got := r.BackendFor(tt.path); got != tt.want {
+				t.Errorf("BackendFor(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryAnalyzeConcurrently(t *testing.T) {
+	errFailed := errors.New("parser failed")
+
+	r := NewRegistry()
+	r.RegisterExtensions(fakeBackend{language: JavaScript}, ".js")
+	r.RegisterExtensions(fakeBackend{language: "Python"}, ".py")
+	r.RegisterExtensions(fakeBackend{language: "Broken", err: errFailed}, ".broken")
+
+	paths := []string{"/pkg/a.js", "/pkg/setup.py", "/pkg/b.js", "/pkg/x.broken", "/pkg/image.png"}
+	results, errs := r.AnalyzeConcurrently(context.Background(), paths, 2)
+
+	wantLiterals := map[string]string{
+		"/pkg/a.js":     "JavaScript:a.js",
+		"/pkg/b.js":     "JavaScript:b.js",
+		"/pkg/setup.py": "Python:setup.py",
+	}
+	gotLiterals := map[string]string{}
+	for path, result := range results {
+		if len(result.StringLiterals) != 1 {
+			t.Fatalf("result for %s has %d string literals, want 1", path, len(result.StringLiterals))
+		}
+		gotLiterals[path] = result.StringLiterals[0].Value
+		if result.StringLiterals[0].Entropy == 0 {
+			t.Errorf("result for %s has no entropy computed", path)
+		}
+	}
+	if !reflect.DeepEqual(gotLiterals, wantLiterals) {
+		t.Errorf("AnalyzeConcurrently() results = %v, want %v", gotLiterals, wantLiterals)
+	}
+
+	if len(errs) != 1 || !errors.Is(errs["/pkg/x.broken"], errFailed) {
+		t.Errorf("AnalyzeConcurrently() errors = %v, want error for /pkg/x.broken", errs)
+	}
+}
+
+func TestRegistryAnalyzeConcurrentlyJavaScriptBackend(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"a.js", "b.mjs", "README"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("var x = 1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	// the parser is not installed in dir, so every file fails to parse, but the
+	// files must still be grouped by backend without panicking
+	config := ParserConfig{InstallDir: dir, ParserPath: filepath.Join(dir, "missing.js"), SymbolTypes: []SymbolType{CallSymbols, LiteralSymbols}}
+	results, errs := DefaultRegistry(config).AnalyzeConcurrently(context.Background(), paths, 2)
+	for _, path := range paths {
+		_, parsed := results[path]
+		if _, failed := errs[path]; parsed == failed {
+			t.Errorf("AnalyzeConcurrently() parsed = %v, failed = %v for %s, want exactly one", parsed, failed, path)
+		}
+	}
+}
+
+func TestPopulateEntropiesIncremental(t *testing.T) {
+	r := NewRegistry()
+	r.RegisterExtensions(fakeBackend{language: JavaScript}, ".js")
+	r.RegisterExtensions(fakeBackend{language: "Python"}, ".py")
+	ctx := context.Background()
+
+	full, _ := r.AnalyzeConcurrently(ctx, []string{"/pkg/a.js", "/pkg/b.js", "/pkg/setup.py"}, 2)
+
+	// a.js is reused from the analysis of a previous version, which also had old.js,
+	// and the other files are parsed again
+	previous, _ := r.AnalyzeConcurrently(ctx, []string{"/pkg/a.js", "/pkg/old.js"}, 2)
+	parsed, _ := r.AnalyzeConcurrently(ctx, []string{"/pkg/b.js", "/pkg/setup.py"}, 2)
+	incremental := map[string]SingleResult{"/pkg/a.js": previous["/pkg/a.js"]}
+	for path, result := range parsed {
+		incremental[path] = result
+	}
+	if reflect.DeepEqual(incremental, full) {
+		t.Fatalf("entropy values of the incremental results already match")
+	}
+
+	var results []*SingleResult
+	for path := range incremental {
+		result := incremental[path]
+		results = append(results, &result, nil)
+	}
+	PopulateEntropies(results)
+	if !reflect.DeepEqual(incremental, full) {
+		t.Errorf("PopulateEntropies() results = %v, want %v", incremental, full)
+	}
+}
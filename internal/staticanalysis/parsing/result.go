@@ -3,30 +3,84 @@ package parsing
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
 )
 
 // SingleResult holds processed information about source code tokens
 // found in a single file by a single language parser
 type SingleResult struct {
-	Language       Language           `json:"language"`
-	Identifiers    []token.Identifier `json:"identifiers"`
-	StringLiterals []token.String     `json:"string_literals"`
-	IntLiterals    []token.Int        `json:"int_literals"`
-	FloatLiterals  []token.Float      `json:"float_literals"`
-	Comments       []token.Comment    `json:"comments"`
-	// future: external function calls / references (e.g. eval)
+	Language    Language           `json:"language"`
+	Identifiers []token.Identifier `json:"identifiers"`
+	// IdentifierCounts holds the number of identifiers of each type in the file,
+	// including types (such as Member, Other and Unknown) which are excluded from
+	// Identifiers. Every type has an entry, even if the count is zero.
+	IdentifierCounts  map[token.IdentifierType]int `json:"identifier_counts"`
+	StringLiterals    []token.String               `json:"string_literals"`
+	IntLiterals       []token.Int                  `json:"int_literals"`
+	FloatLiterals     []token.Float                `json:"float_literals"`
+	Comments          []token.Comment              `json:"comments"`
+	Calls             []token.Call                 `json:"calls"`
+	Imports           []token.Import               `json:"imports"`
+	Assignments       []token.Assignment           `json:"assignments"`
+	Conditions        []token.Condition            `json:"conditions"`
+	TimingChecks      []token.TimingCheck          `json:"timing_checks"`
+	DecodedExecutions []token.DecodedExecution     `json:"decoded_executions"`
+	BinaryPayloads    []token.BinaryPayload        `json:"binary_payloads"`
+	// SourceEncoding records that the file was not plain UTF-8 and was normalised
+	// before parsing. It is "utf-8-bom" if a UTF-8 byte order mark was stripped,
+	// or "utf-16le" or "utf-16be" if the file was transcoded from UTF-16.
+	// It is empty for plain UTF-8 files.
+	SourceEncoding string `json:"source_encoding,omitempty"`
+	// EmbeddedCode holds the positions of string literals which were parsed as
+	// JavaScript (see ParserConfig.NestedCodeDepth). The tokens found in them are
+	// included in the other fields, with the position of the literal.
+	EmbeddedCode []token.Position `json:"embedded_code,omitempty"`
+	// AST is the syntax tree of the file, if ParserConfig.FullAST is set and the
+	// file could be parsed. It does not include the trees of nested code.
+	AST *ast.Tree `json:"ast,omitempty"`
+	// Stats records the size of the file and the cost of parsing it.
+	Stats ParseStats `json:"stats"`
+	// Err wraps ErrSyntaxError if the file could not be parsed because of syntax
+	// errors, in which case Language is NoLanguage and no tokens are recorded. It
+	// is nil otherwise, and is not serialized.
+	Err error `json:"-"`
+}
+
+// ParseStats records the size of a single file and the cost of parsing it,
+// for profiling the parser and choosing limits on the size of files to parse.
+type ParseStats struct {
+	// InputBytes is the size of the file in bytes, before any normalisation.
+	InputBytes int64 `json:"input_bytes"`
+	// ParseTime is the wall time spent by the parser reading, parsing and
+	// traversing the file.
+	ParseTime time.Duration `json:"parse_time"`
+	// OutputElements is the number of tokens (identifiers, literals, comments
+	// and calls) produced by the parser for the file.
+	OutputElements int `json:"output_elements"`
 }
 
 func (r SingleResult) String() string {
 	parts := []string{
 		fmt.Sprintf("language: %s", r.Language),
 		fmt.Sprintf("identifiers\n%v", r.Identifiers),
+		fmt.Sprintf("identifier counts\n%v", r.IdentifierCounts),
 		fmt.Sprintf("string literals\n%v", r.StringLiterals),
 		fmt.Sprintf("integer literals\n%v", r.IntLiterals),
 		fmt.Sprintf("float literals\n%v", r.FloatLiterals),
 		fmt.Sprintf("comments\n%v", r.Comments),
+		fmt.Sprintf("calls\n%v", r.Calls),
+		fmt.Sprintf("imports\n%v", r.Imports),
+		fmt.Sprintf("assignments\n%v", r.Assignments),
+		fmt.Sprintf("conditions\n%v", r.Conditions),
+		fmt.Sprintf("timing checks\n%v", r.TimingChecks),
+		fmt.Sprintf("decoded executions\n%v", r.DecodedExecutions),
+		fmt.Sprintf("binary payloads\n%v", r.BinaryPayloads),
+		fmt.Sprintf("source encoding: %s", r.SourceEncoding),
+		fmt.Sprintf("embedded code: %v", r.EmbeddedCode),
+		fmt.Sprintf("stats: %+v", r.Stats),
 	}
 	return strings.Join(parts, "\n")
 }
@@ -0,0 +1,7 @@
+const fs = require("fs");
+console.log("reading", fs.readFileSync("/etc/passwd"));
+setTimeout(() => run(), 5 * 60 * 1000);
+new Function("a", "return a");
+this["ev" + "al"]("1");
+https.get({ host: "example.com", rejectUnauthorized: !1, timeout: 5000, path: "/" });
+process.env.NODE_TLS_REJECT_UNAUTHORIZED = "0";
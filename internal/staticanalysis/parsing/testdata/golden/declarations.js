@@ -0,0 +1,10 @@
+// declarations of functions, variables and parameters
+function greet(name, greeting) {
+    const message = greeting + ", " + name;
+    let count = 3;
+    var ratio = 0.25;
+    return message;
+}
+
+/* block comment */
+var items = ["a", "b", 10];
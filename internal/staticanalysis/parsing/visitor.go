@@ -0,0 +1,167 @@
+package parsing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// ElementKind identifies the kind of an Element of parser output.
+type ElementKind string
+
+const (
+	ElementIdentifier       ElementKind = ElementKind(identifier)
+	ElementLiteral          ElementKind = ElementKind(literal)
+	ElementComment          ElementKind = ElementKind(comment)
+	ElementCall             ElementKind = ElementKind(call)
+	ElementImport           ElementKind = ElementKind(moduleImport)
+	ElementCondition        ElementKind = ElementKind(condition)
+	ElementTimingCheck      ElementKind = ElementKind(timingCheck)
+	ElementDecodedExecution ElementKind = ElementKind(decodedExecution)
+	ElementBinaryPayload    ElementKind = ElementKind(binaryPayload)
+	ElementInfo             ElementKind = ElementKind(parseInfo)
+	ElementError            ElementKind = ElementKind(parseError)
+)
+
+// Element is a single source code token or status message output by the parser
+// for a file, as passed to an ElementVisitor. It contains the data as decoded
+// from the parser output, without any of the processing done by Analyze.
+type Element struct {
+	Kind ElementKind
+	// SubType further classifies the element, e.g. "Variable" for identifiers,
+	// "String" for literals, or "InputLength" for info messages.
+	SubType string
+	// Data holds the identifier name, literal value, comment text, callee,
+	// imported module, or status message.
+	Data any
+	Pos  token.Position
+	// Extra holds additional data for tokens, e.g. "raw" for literals and
+	// "args" for calls. It is nil for status messages.
+	Extra map[string]any
+}
+
+// ElementVisitor is called for each Element of parser output for the file
+// called filename. If it returns an error, decoding is stopped and
+// the error is returned from ParseWithVisitor.
+type ElementVisitor func(filename string, e Element) error
+
+/*
+ParseWithVisitor parses the given input as JavaScript, and calls visit for each element
+of the parser output as it is decoded. Unlike Analyze, no results are accumulated, so
+consumers which only need an aggregate of the parser output (e.g. counts of each kind of
+token) do not need to hold the data for every element in memory.
+
+For each file, tokens are visited in the order that the parser outputs them, followed
+by status elements. Files are visited in the order that they appear in the output.
+
+The errors returned are as for Analyze, except that errors returned by visit are
+returned unwrapped.
+*/
+func ParseWithVisitor(ctx context.Context, parserConfig ParserConfig, input externalcmd.Input, visit ElementVisitor) error {
+	return runParserWithOutput(ctx, parserConfig.ParserPath, input, func(r io.Reader) error {
+		return decodeWithVisitor(r, visit)
+	}, parserConfig.parserArgs()...)
+}
+
+// decodeWithVisitor incrementally decodes parser output from r, calling visit
+// for each element. See ParseWithVisitor.
+func decodeWithVisitor(r io.Reader, visit ElementVisitor) error {
+	dec := json.NewDecoder(r)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		filename, err := expectString(dec)
+		if err != nil {
+			return err
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			field, err := expectString(dec)
+			if err != nil {
+				return err
+			}
+			switch field {
+			case "tokens":
+				err = decodeArray(dec, func(t parserTokenJSON) error {
+					return visit(filename, Element{
+						Kind:    ElementKind(t.TokenType),
+						SubType: t.TokenSubType,
+						Data:    t.Data,
+						Pos:     t.Pos,
+						Extra:   t.Extra,
+					})
+				})
+			case "status":
+				err = decodeArray(dec, func(s parserStatusJSON) error {
+					return visit(filename, Element{
+						Kind:    ElementKind(s.StatusType),
+						SubType: s.StatusSubType,
+						Data:    s.Message,
+						Pos:     s.Pos,
+					})
+				})
+			default:
+				// skip other data, e.g. the AST
+				var skip json.RawMessage
+				if decodeErr := dec.Decode(&skip); decodeErr != nil {
+					err = fmt.Errorf("%w: %w", ErrDecodeFailed, decodeErr)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeArray decodes a JSON array of T from dec, calling f for each element in turn.
+func decodeArray[T any](dec *json.Decoder, f func(T) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for dec.More() {
+		var elem T
+		if err := dec.Decode(&elem); err != nil {
+			return fmt.Errorf("%w: %w", ErrDecodeFailed, err)
+		}
+		if err := f(elem); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	t, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDecodeFailed, err)
+	}
+	if d, ok := t.(json.Delim); !ok || d != want {
+		return fmt.Errorf("%w: expected %v, got %v", ErrDecodeFailed, want, t)
+	}
+	return nil
+}
+
+func expectString(dec *json.Decoder) (string, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecodeFailed, err)
+	}
+	s, ok := t.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: expected string, got %v", ErrDecodeFailed, t)
+	}
+	return s, nil
+}
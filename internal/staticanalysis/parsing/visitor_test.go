@@ -0,0 +1,133 @@
+package parsing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/externalcmd"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+type visitedElement struct {
+	filename string
+	element  Element
+}
+
+func TestDecodeWithVisitor(t *testing.T) {
+	output := `{
+  "a.js": {
+    "tokens": [
+      {"type": "Identifier", "subtype": "Variable", "data": "x", "pos": [1, 4], "extra": {}},
+      {"type": "Literal", "subtype": "String", "data": "hi", "pos": [1, 8], "extra": {"raw": "\"hi\""}}
+    ],
+    "status": [
+      {"type": "Info", "subtype": "InputLength", "data": "13", "pos": []}
+    ],
+    "ast": {"type": "File", "program": {"body": []}}
+  },
+  "b.js": {
+    "tokens": [],
+    "status": [
+      {"type": "Error", "subtype": "SyntaxError", "data": "FATAL SYNTAX ERROR", "pos": [2, 0]}
+    ]
+  }
+}`
+
+	want := []visitedElement{
+		{"a.js", Element{Kind: ElementIdentifier, SubType: "Variable", Data: "x", Pos: token.Position{1, 4}, Extra: map[string]any{}}},
+		{"a.js", Element{Kind: ElementLiteral, SubType: "String", Data: "hi", Pos: token.Position{1, 8}, Extra: map[string]any{"raw": `"hi"`}}},
+		{"a.js", Element{Kind: ElementInfo, SubType: "InputLength", Data: "13"}},
+		{"b.js", Element{Kind: ElementError, SubType: "SyntaxError", Data: "FATAL SYNTAX ERROR", Pos: token.Position{2, 0}}},
+	}
+
+	var got []visitedElement
+	err := decodeWithVisitor(strings.NewReader(output), func(filename string, e Element) error {
+		got = append(got, visitedElement{filename, e})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("decodeWithVisitor() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeWithVisitor() visited\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestDecodeWithVisitorErrors(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name    string
+		output  string
+		visit   ElementVisitor
+		wantErr error
+		// number of elements visited before the error
+		wantVisits int
+	}{
+		{
+			name:       "visitor error stops decoding",
+			output:     `{"a.js": {"tokens": [{"type": "Comment"}, {"type": "Comment"}], "status": []}}`,
+			visit:      func(string, Element) error { return errStop },
+			wantErr:    errStop,
+			wantVisits: 1,
+		},
+		{
+			name:       "truncated output",
+			output:     `{"a.js": {"tokens": [{"type": "Comment"}, `,
+			visit:      func(string, Element) error { return nil },
+			wantErr:    ErrDecodeFailed,
+			wantVisits: 1,
+		},
+		{
+			name:       "not an object",
+			output:     `[]`,
+			visit:      func(string, Element) error { return nil },
+			wantErr:    ErrDecodeFailed,
+			wantVisits: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			visits := 0
+			err := decodeWithVisitor(strings.NewReader(tt.output), func(filename string, e Element) error {
+				visits++
+				return tt.visit(filename, e)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("decodeWithVisitor() error = %v, want %v", err, tt.wantErr)
+			}
+			if visits != tt.wantVisits {
+				t.Errorf("decodeWithVisitor() visited %d elements, want %d", visits, tt.wantVisits)
+			}
+		})
+	}
+}
+
+func TestParseWithVisitor(t *testing.T) {
+	jsParserConfig, err := InitParser(context.Background(), t.TempDir())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	counts := make(map[ElementKind]int)
+	err = ParseWithVisitor(context.Background(), jsParserConfig, externalcmd.StringInput(`var a = "x"; // c
+f(a);`), func(filename string, e Element) error {
+		if filename != "stdin" {
+			t.Errorf("visited element for file %q, want stdin", filename)
+		}
+		counts[e.Kind]++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ParseWithVisitor() error = %v", err)
+	}
+
+	for _, kind := range []ElementKind{ElementIdentifier, ElementLiteral, ElementComment, ElementCall, ElementInfo} {
+		if counts[kind] == 0 {
+			t.Errorf("no elements of kind %s visited; counts = %v", kind, counts)
+		}
+	}
+}
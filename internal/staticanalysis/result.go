@@ -3,6 +3,7 @@ package staticanalysis
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/basicdata"
 	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
@@ -15,6 +16,14 @@ import (
 type Result struct {
 	Archive ArchiveResult
 	Files   []SingleResult
+	// Manifest is the result of CheckManifest, if the package manifest was
+	// parsed.
+	Manifest *staticanalysis.ManifestResult
+	// Fingerprint is the result of Fingerprint, if the package files were parsed.
+	Fingerprint *staticanalysis.Fingerprint
+	// Limits lists the limits (e.g. analysisrun.LimitStaticScope) which were hit by
+	// the analysis of the package as a whole (see staticanalysis.Results.Limits).
+	Limits []string
 }
 
 type ArchiveResult struct {
@@ -38,6 +47,17 @@ type SingleResult struct {
 	// Filename is the relative path to the file within the package
 	Filename string
 
+	// TimedOut is true if the file was not parsed because it took longer than
+	// the time limit for each file, or the time limit for the package ran out
+	// before it was parsed (see FileTimeout and PackageTimeout).
+	TimedOut bool
+
+	// Reused is true if the parsing data of the file was copied from the results
+	// of the previous version of the package, since the file had not changed,
+	// rather than the file being parsed again (see PreviousResults). Its signals
+	// data is still computed again.
+	Reused bool
+
 	Basic   *basicdata.FileData
 	Parsing *parsing.SingleResult
 	Signals *signals.FileSignals
@@ -57,11 +77,17 @@ func (r SingleResult) String() string {
 // ToAPIResults converts the data in this Result object into the
 // public staticanalysis.Results format defined in pkg/api/staticanalysis.
 func (r *Result) ToAPIResults() *staticanalysis.Results {
-	results := &staticanalysis.Results{}
+	results := &staticanalysis.Results{
+		Manifest:       r.Manifest,
+		Fingerprint:    r.Fingerprint,
+		AnalysisLimits: r.Limits,
+	}
 
 	for _, f := range r.Files {
 		fr := staticanalysis.FileResult{
 			Filename: f.Filename,
+			TimedOut: f.TimedOut,
+			Reused:   f.Reused,
 		}
 		if f.Basic != nil {
 			fr.DetectedType = f.Basic.DetectedType
@@ -80,21 +106,19 @@ func (r *Result) ToAPIResults() *staticanalysis.Results {
 				FloatLiterals:  f.Parsing.FloatLiterals,
 				Comments:       f.Parsing.Comments,
 			}
+			fr.SourceEncoding = f.Parsing.SourceEncoding
+			fr.Imports = importedModules([]SingleResult{f})
 		}
-		if f.Signals != nil {
-			// only populate value counts if nonempty
-			if f.Signals.IdentifierLengths.Len() > 0 {
-				fr.IdentifierLengths = &f.Signals.IdentifierLengths
-			}
-			if f.Signals.StringLengths.Len() > 0 {
-				fr.StringLengths = &f.Signals.StringLengths
+		// only populate parse stats if they were recorded by the parser
+		if f.Parsing != nil && f.Parsing.Stats != (parsing.ParseStats{}) {
+			fr.ParseStats = &staticanalysis.ParseStats{
+				InputBytes:     f.Parsing.Stats.InputBytes,
+				ParseTimeMs:    float64(f.Parsing.Stats.ParseTime) / float64(time.Millisecond),
+				OutputElements: f.Parsing.Stats.OutputElements,
 			}
-			fr.Base64Strings = f.Signals.Base64Strings
-			fr.HexStrings = f.Signals.HexStrings
-			fr.IPAddresses = f.Signals.IPAddresses
-			fr.URLs = f.Signals.URLs
-			fr.EscapedStrings = f.Signals.EscapedStrings
-			fr.SuspiciousIdentifiers = f.Signals.SuspiciousIdentifiers
+		}
+		if f.Signals != nil {
+			f.Signals.PopulateFileResult(&fr)
 		}
 
 		results.Files = append(results.Files, fr)
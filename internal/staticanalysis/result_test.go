@@ -3,6 +3,7 @@ package staticanalysis
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/basicdata"
 	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
@@ -41,6 +42,23 @@ func TestResult_ToAPIResults(t *testing.T) {
 				},
 			}},
 		},
+		{
+			name: "timed out",
+			result: Result{Files: []SingleResult{
+				{
+					Filename: "slow.js",
+					Basic:    &basicdata.FileData{Size: 10},
+					TimedOut: true,
+				},
+			}},
+			want: &staticanalysis.Results{Files: []staticanalysis.FileResult{
+				{
+					Filename: "slow.js",
+					Size:     10,
+					TimedOut: true,
+				},
+			}},
+		},
 		{
 			name: "simple no js",
 			result: Result{Files: []SingleResult{
@@ -130,6 +148,16 @@ func TestResult_ToAPIResults(t *testing.T) {
 								Text: "This is a comment",
 							},
 						},
+						Calls: []token.Call{
+							{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "fs"}}},
+							{Callee: "require", Args: []token.CallArg{{Type: "Identifier", Value: "name"}}},
+							{Callee: "import", Args: []token.CallArg{{Type: "String", Value: "./lib"}}},
+						},
+						Stats: parsing.ParseStats{
+							InputBytes:     100,
+							ParseTime:      1500 * time.Microsecond,
+							OutputElements: 6,
+						},
 					},
 					Signals: &signals.FileSignals{
 						IdentifierLengths: valuecounts.Count([]int{5}),
@@ -161,6 +189,11 @@ func TestResult_ToAPIResults(t *testing.T) {
 					Size:         100,
 					SHA256:       "abc123def456",
 					LineLengths:  ptr(valuecounts.Count([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})),
+					ParseStats: &staticanalysis.ParseStats{
+						InputBytes:     100,
+						ParseTimeMs:    1.5,
+						OutputElements: 6,
+					},
 					Js: &staticanalysis.JsData{
 						Identifiers: []token.Identifier{
 							{
@@ -214,6 +247,7 @@ func TestResult_ToAPIResults(t *testing.T) {
 							},
 						},
 					},
+					Imports:           []string{"fs", "./lib"},
 					IdentifierLengths: ptr(valuecounts.Count([]int{5})),
 					StringLengths:     ptr(valuecounts.Count([]int{5})),
 					SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{
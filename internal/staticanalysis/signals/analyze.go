@@ -22,31 +22,78 @@ func countLengths(symbols []string) valuecounts.ValueCounts {
 	return valuecounts.Count(lengths)
 }
 
+// newFileSignals returns a FileSignals with all lists of signals empty (rather than nil).
+func newFileSignals() FileSignals {
+	return FileSignals{
+		Base64Strings:         []string{},
+		HexStrings:            []string{},
+		EscapedStrings:        []staticanalysis.EscapedString{},
+		SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{},
+		URLs:                  []string{},
+		IPAddresses:           []string{},
+		IndirectEvals:         []staticanalysis.IndirectEval{},
+		WasmInstantiations:    []staticanalysis.WasmInstantiation{},
+		LongDelays:            []staticanalysis.LongDelay{},
+		WalletAddresses:       []staticanalysis.WalletAddress{},
+		ClipboardAccesses:     []staticanalysis.ClipboardAccess{},
+		ReverseShells:         []staticanalysis.ReverseShell{},
+		InsecureTransport:     []staticanalysis.InsecureTransport{},
+		InternalAPIUsages:     []staticanalysis.InternalAPIUsage{},
+		NetworkRequests:       []staticanalysis.NetworkRequest{},
+		PlatformConditions:    []staticanalysis.PlatformCondition{},
+		EarlyExits:            []staticanalysis.EarlyExit{},
+		ConfigCommands:        []staticanalysis.ConfigCommand{},
+		InputCaptures:         []staticanalysis.InputCapture{},
+		TimingChecks:          []staticanalysis.TimingCheck{},
+		PackedCode:            []staticanalysis.PackedCode{},
+		ThreadUsages:          []staticanalysis.ThreadUsage{},
+		DecodedExecutions:     []staticanalysis.DecodedExecution{},
+		OutsideImports:        []staticanalysis.OutsideImport{},
+		NativeAddonLoads:      []staticanalysis.NativeAddonLoad{},
+		BinaryPayloads:        []staticanalysis.BinaryPayload{},
+		Findings:              []staticanalysis.Finding{},
+	}
+}
+
 // AnalyzeSingle collects signals of interest for a file in a package, operating on a single
 // parsing result (i.e. from one language parser). It returns a FileSignals object, containing
 // information that may be useful to determine whether the file contains malicious code.
-func AnalyzeSingle(parseData parsing.SingleResult) FileSignals {
+// pkgPath is the slash-separated path of the file relative to the package root, against
+// which relative imports are resolved (see detections.IsOutsidePackagePath). It may be
+// empty if the path is not known.
+//
+// The Findings are made by the detectors of DefaultDetectorRegistry; use AnalyzeSingleWith
+// to also run custom detectors.
+func AnalyzeSingle(parseData parsing.SingleResult, pkgPath string) FileSignals {
+	return AnalyzeSingleWith(DefaultDetectorRegistry(), parseData, pkgPath)
+}
+
+// importDeclaration describes the given import declaration in the way that calls
+// are described in signals, e.g. `import "node:v8"` or `export from "node:v8"`.
+func importDeclaration(i token.Import) string {
+	if i.Export {
+		return `export from "` + i.Module + `"`
+	}
+	return `import "` + i.Module + `"`
+}
+
+// collectSignals returns the signals of the file with the given parsing result and
+// path in the package (see AnalyzeSingle), without any Findings.
+func collectSignals(parseData parsing.SingleResult, pkgPath string) FileSignals {
 	identifierNames := utils.Transform(parseData.Identifiers, func(i token.Identifier) string { return i.Name })
 	stringLiterals := utils.Transform(parseData.StringLiterals, func(s token.String) string { return s.Value })
 
 	identifierLengths := countLengths(identifierNames)
 	stringLengths := countLengths(stringLiterals)
 
-	signals := FileSignals{
-		IdentifierLengths:     identifierLengths,
-		StringLengths:         stringLengths,
-		Base64Strings:         []string{},
-		HexStrings:            []string{},
-		EscapedStrings:        []staticanalysis.EscapedString{},
-		SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{},
-		URLs:                  []string{},
-		IPAddresses:           []string{},
-	}
+	signals := newFileSignals()
+	signals.IdentifierLengths = identifierLengths
+	signals.StringLengths = stringLengths
 
 	for _, name := range identifierNames {
 		for rule, pattern := range detections.SuspiciousIdentifierPatterns {
 			if pattern.MatchString(name) {
-				signals.SuspiciousIdentifiers = append(signals.SuspiciousIdentifiers, staticanalysis.SuspiciousIdentifier{name, rule})
+				signals.SuspiciousIdentifiers = append(signals.SuspiciousIdentifiers, staticanalysis.SuspiciousIdentifier{Name: name, Rule: rule})
 				break // don't bother searching for multiple matching rules
 			}
 		}
@@ -57,6 +104,20 @@ func AnalyzeSingle(parseData parsing.SingleResult) FileSignals {
 		signals.HexStrings = append(signals.HexStrings, detections.FindHexSubstrings(sl.Value)...)
 		signals.URLs = append(signals.URLs, detections.FindURLs(sl.Value)...)
 		signals.IPAddresses = append(signals.IPAddresses, detections.FindIPAddresses(sl.Value)...)
+		for _, w := range detections.FindWalletAddresses(sl.Value) {
+			signals.WalletAddresses = append(signals.WalletAddresses, staticanalysis.WalletAddress{
+				Currency: w.Currency,
+				Address:  w.Address,
+				Pos:      sl.Pos,
+			})
+		}
+		if command, found := detections.FindInsecureCommand(sl.Value); found {
+			signals.InsecureTransport = append(signals.InsecureTransport, staticanalysis.InsecureTransport{
+				Type:   detections.TLSVerificationDisabled,
+				Detail: command,
+				Pos:    sl.Pos,
+			})
+		}
 		if detections.IsHighlyEscaped(sl, 8, 0.25) {
 			escapedString := staticanalysis.EscapedString{
 				Value:           sl.Value,
@@ -67,5 +128,210 @@ func AnalyzeSingle(parseData parsing.SingleResult) FileSignals {
 		}
 	}
 
+	networkSend := false
+	for _, call := range parseData.Calls {
+		if target, found := detections.FindIndirectEval(call); found {
+			signals.IndirectEvals = append(signals.IndirectEvals, staticanalysis.IndirectEval{
+				Target: target,
+				Callee: call.Callee,
+				Pos:    call.Pos,
+			})
+		}
+		if function, source, found := detections.FindWasmInstantiation(call); found {
+			signals.WasmInstantiations = append(signals.WasmInstantiations, staticanalysis.WasmInstantiation{
+				Function: function,
+				Source:   source,
+				Pos:      call.Pos,
+			})
+		}
+		if api, found := detections.FindClipboardAccess(call); found {
+			signals.ClipboardAccesses = append(signals.ClipboardAccesses, staticanalysis.ClipboardAccess{
+				API: api,
+				Pos: call.Pos,
+			})
+		}
+		if url, found := detections.FindPlainHTTPRequest(call); found {
+			signals.InsecureTransport = append(signals.InsecureTransport, staticanalysis.InsecureTransport{
+				Type:   detections.PlainHTTP,
+				Detail: url,
+				Pos:    call.Pos,
+			})
+		}
+		if apiType, api, found := detections.FindInternalAPIUsage(call); found {
+			signals.InternalAPIUsages = append(signals.InternalAPIUsages, staticanalysis.InternalAPIUsage{
+				Type: apiType,
+				API:  api,
+				Pos:  call.Pos,
+			})
+		}
+		if url, source, found := detections.FindNetworkRequest(call); found {
+			signals.NetworkRequests = append(signals.NetworkRequests, staticanalysis.NetworkRequest{
+				API:       call.Callee,
+				URL:       url,
+				URLSource: source,
+				Pos:       call.Pos,
+			})
+		}
+		if function, delayMs, found := detections.FindLongDelay(call); found {
+			signals.LongDelays = append(signals.LongDelays, staticanalysis.LongDelay{
+				Function: function,
+				DelayMs:  delayMs,
+				Pos:      call.Pos,
+			})
+		}
+		if function, found := detections.FindEarlyExit(call); found {
+			signals.EarlyExits = append(signals.EarlyExits, staticanalysis.EarlyExit{
+				Function: function,
+				Pos:      call.Pos,
+			})
+		}
+		if event, found := detections.FindInputListener(call); found {
+			signals.InputCaptures = append(signals.InputCaptures, staticanalysis.InputCapture{
+				Type:  detections.InputListener,
+				API:   call.Callee,
+				Event: event,
+				Pos:   call.Pos,
+			})
+		} else if api, found := detections.FindFormAccess(call); found {
+			signals.InputCaptures = append(signals.InputCaptures, staticanalysis.InputCapture{
+				Type: detections.FormAccess,
+				API:  api,
+				Pos:  call.Pos,
+			})
+		}
+		if usageType, api, source, found := detections.FindThreadUsage(call); found {
+			signals.ThreadUsages = append(signals.ThreadUsages, staticanalysis.ThreadUsage{
+				Type:         usageType,
+				API:          api,
+				WorkerSource: source,
+				Pos:          call.Pos,
+			})
+		}
+		networkSend = networkSend || detections.IsNetworkSend(call)
+	}
+
+	for _, a := range parseData.Assignments {
+		if setting, found := detections.FindDisabledTLSVerification(a); found {
+			signals.InsecureTransport = append(signals.InsecureTransport, staticanalysis.InsecureTransport{
+				Type:   detections.TLSVerificationDisabled,
+				Detail: setting,
+				Pos:    a.Pos,
+			})
+		}
+		if setting, found := detections.FindDebuggerActivation(a); found {
+			signals.InternalAPIUsages = append(signals.InternalAPIUsages, staticanalysis.InternalAPIUsage{
+				Type: detections.DebuggerActivation,
+				API:  setting,
+				Pos:  a.Pos,
+			})
+		}
+		if event, found := detections.FindInputHandlerAssignment(a); found {
+			signals.InputCaptures = append(signals.InputCaptures, staticanalysis.InputCapture{
+				Type:  detections.InputListener,
+				API:   a.Target,
+				Event: event,
+				Pos:   a.Pos,
+			})
+		}
+	}
+	for i := range signals.InputCaptures {
+		signals.InputCaptures[i].NetworkSend = networkSend
+	}
+
+	for _, e := range parseData.DecodedExecutions {
+		signals.DecodedExecutions = append(signals.DecodedExecutions, staticanalysis.DecodedExecution{
+			Sink:      e.Sink,
+			Decoder:   e.Decoder,
+			Encoding:  e.Encoding,
+			Variable:  e.Variable,
+			DecodePos: e.DecodePos,
+			Pos:       e.Pos,
+		})
+	}
+
+	for _, p := range parseData.BinaryPayloads {
+		signals.BinaryPayloads = append(signals.BinaryPayloads, staticanalysis.BinaryPayload{
+			Constructor: p.Constructor,
+			Size:        p.Size,
+			Writes:      p.Writes,
+			Sink:        p.Sink,
+			Pos:         p.Pos,
+		})
+	}
+
+	for _, c := range parseData.Calls {
+		if module := detections.ImportedModule(c); module != "" && detections.IsOutsidePackagePath(module, pkgPath) {
+			signals.OutsideImports = append(signals.OutsideImports, staticanalysis.OutsideImport{Module: module, Pos: c.Pos})
+		}
+		if loadType, library, found := detections.FindNativeAddonLoad(c); found {
+			signals.NativeAddonLoads = append(signals.NativeAddonLoads, staticanalysis.NativeAddonLoad{Type: loadType, Library: library, Pos: c.Pos})
+		}
+	}
+
+	for _, i := range parseData.Imports {
+		if apiType, found := detections.FindInternalModuleImport(i.Module); found {
+			signals.InternalAPIUsages = append(signals.InternalAPIUsages, staticanalysis.InternalAPIUsage{
+				Type: apiType,
+				API:  importDeclaration(i),
+				Pos:  i.Pos,
+			})
+		}
+		if detections.IsWorkerThreadsModule(i.Module) {
+			signals.ThreadUsages = append(signals.ThreadUsages, staticanalysis.ThreadUsage{
+				Type: detections.ThreadWorkerThreads,
+				API:  i.Module,
+				Pos:  i.Pos,
+			})
+		}
+		if detections.IsOutsidePackagePath(i.Module, pkgPath) {
+			signals.OutsideImports = append(signals.OutsideImports, staticanalysis.OutsideImport{Module: i.Module, Pos: i.Pos})
+		}
+		if loadType, found := detections.FindNativeAddonModule(i.Module); found {
+			signals.NativeAddonLoads = append(signals.NativeAddonLoads, staticanalysis.NativeAddonLoad{Type: loadType, Library: i.Module, Pos: i.Pos})
+		}
+	}
+
+	for _, c := range parseData.TimingChecks {
+		signals.TimingChecks = append(signals.TimingChecks, staticanalysis.TimingCheck{
+			API:         c.API,
+			StartPos:    c.StartPos,
+			EndPos:      c.EndPos,
+			GatedLength: c.GatedLength,
+			Pos:         c.Pos,
+		})
+	}
+
+	for _, c := range parseData.Conditions {
+		if platform, significant, found := detections.FindPlatformCondition(c); found {
+			signals.PlatformConditions = append(signals.PlatformConditions, staticanalysis.PlatformCondition{
+				Platform:    platform,
+				Subject:     c.Subject,
+				Operator:    c.Operator,
+				GatedLength: c.GatedLength,
+				Significant: significant,
+				Pos:         c.Pos,
+			})
+		}
+	}
+
+	if m, found := detections.FindPackedCode(parseData.Calls); found {
+		signals.PackedCode = append(signals.PackedCode, staticanalysis.PackedCode{
+			Confidence: m.Confidence,
+			Indicators: m.Indicators,
+			Pos:        m.Call.Pos,
+		})
+	}
+
+	for _, m := range detections.FindReverseShells(parseData.Calls, parseData.Imports) {
+		signals.ReverseShells = append(signals.ReverseShells, staticanalysis.ReverseShell{
+			Shell:        m.Shell,
+			SocketCallee: m.SocketCall.Callee,
+			SocketPos:    m.SocketCall.Pos,
+			ShellCallee:  m.ShellCall.Callee,
+			Pos:          m.ShellCall.Pos,
+			SocketWired:  m.SocketWired,
+		})
+	}
+
 	return signals
 }
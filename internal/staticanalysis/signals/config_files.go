@@ -0,0 +1,229 @@
+package signals
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/valuecounts"
+)
+
+// configFormat is the syntax of a manifest or config file.
+type configFormat int
+
+const (
+	notConfig configFormat = iota
+	jsonConfig
+	yamlConfig
+	// iniConfig is the key=value (or key value) syntax of .npmrc and .yarnrc files.
+	iniConfig
+)
+
+// lockFiles are the names of JSON and YAML files which only record the resolved
+// dependencies of a package. They are not scanned, since they contain a URL for
+// every dependency.
+var lockFiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"pnpm-lock.yaml":      true,
+	"composer.lock":       true,
+}
+
+// configValue is a string value in a config file. Key is the path of the value in
+// the file, with the keys of nested objects separated by dots (e.g. "scripts.test").
+type configValue struct {
+	Key   string
+	Value string
+}
+
+// configFormatOf returns the format of the file with the given name, or notConfig
+// if it is not a config file.
+func configFormatOf(filename string) configFormat {
+	base := path.Base(filepath.ToSlash(filename))
+	switch {
+	case lockFiles[base]:
+		return notConfig
+	case base == ".npmrc" || base == ".yarnrc":
+		return iniConfig
+	}
+	switch strings.ToLower(path.Ext(base)) {
+	case ".json":
+		return jsonConfig
+	case ".yml", ".yaml":
+		return yamlConfig
+	}
+	return notConfig
+}
+
+// IsConfigFile returns whether the file with the given name is a manifest or config
+// file which is scanned by AnalyzeConfigFile, i.e. a JSON or YAML file (other than
+// a lock file), or an .npmrc or .yarnrc file.
+func IsConfigFile(filename string) bool {
+	return configFormatOf(filename) != notConfig
+}
+
+// unquote removes matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// jsonValues returns the string values in the decoded JSON value v, whose key is key.
+func jsonValues(key string, v any) []configValue {
+	switch v := v.(type) {
+	case string:
+		return []configValue{{Key: key, Value: v}}
+	case []any:
+		var values []configValue
+		for i, elem := range v {
+			values = append(values, jsonValues(fmt.Sprintf("%s[%d]", key, i), elem)...)
+		}
+		return values
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		var values []configValue
+		for _, k := range keys {
+			elemKey := k
+			if key != "" {
+				elemKey = key + "." + k
+			}
+			values = append(values, jsonValues(elemKey, v[k])...)
+		}
+		return values
+	}
+	return nil
+}
+
+// yamlValues returns the (scalar) values in a YAML file. This is not a full YAML
+// parser: values are found line by line, and Key is the key of the innermost
+// mapping entry (e.g. "run" for a step of a GitHub Actions workflow). Each line of a
+// block scalar (e.g. "run: |") is a separate value. A trailing comma is dropped from
+// values, so that JSON which is not valid (e.g. because it has comments) can also be
+// scanned this way.
+func yamlValues(contents string) []configValue {
+	var values []configValue
+	var key string
+	blockIndent := -1 // indentation of the key of the current block scalar, if any
+	for _, line := range strings.Split(contents, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if trimmed == "" {
+				continue
+			}
+			if indent > blockIndent {
+				values = append(values, configValue{Key: key, Value: trimmed})
+				continue
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		entry := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+		if k, v, ok := strings.Cut(entry, ": "); ok || strings.HasSuffix(entry, ":") {
+			if !ok {
+				k = strings.TrimSuffix(entry, ":")
+			}
+			key = unquote(strings.TrimSpace(k))
+			entry = strings.TrimSpace(v)
+			if strings.HasPrefix(entry, "|") || strings.HasPrefix(entry, ">") {
+				blockIndent = indent
+				continue
+			}
+		}
+		if entry = unquote(strings.TrimSuffix(entry, ",")); entry != "" {
+			values = append(values, configValue{Key: key, Value: entry})
+		}
+	}
+	return values
+}
+
+// iniValues returns the values in an .npmrc or .yarnrc file, where each line is
+// either key=value or (for .yarnrc) key value.
+func iniValues(contents string) []configValue {
+	var values []configValue
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			key, value, _ = strings.Cut(line, " ")
+		}
+		if value = unquote(strings.TrimSpace(value)); value != "" {
+			values = append(values, configValue{Key: unquote(strings.TrimSpace(key)), Value: value})
+		}
+	}
+	return values
+}
+
+// configValues returns the string values in the config file with the given name
+// and contents. If a JSON file cannot be decoded, it is scanned as YAML.
+func configValues(filename string, contents []byte) []configValue {
+	switch configFormatOf(filename) {
+	case jsonConfig:
+		var decoded any
+		if err := json.Unmarshal(contents, &decoded); err == nil {
+			return jsonValues("", decoded)
+		}
+		return yamlValues(string(contents))
+	case yamlConfig:
+		return yamlValues(string(contents))
+	case iniConfig:
+		return iniValues(string(contents))
+	}
+	return nil
+}
+
+// isScriptKey returns whether values with the given key in the named config file
+// are always shell commands, like the scripts in package.json.
+func isScriptKey(filename, key string) bool {
+	return path.Base(filepath.ToSlash(filename)) == "package.json" && strings.HasPrefix(key, "scripts.")
+}
+
+/*
+AnalyzeConfigFile collects signals of interest from a manifest or config file (see
+IsConfigFile), which is scanned without a language parser. Packages often hide
+behaviour in these files rather than in code, e.g. a "postinstall" script in
+package.json that runs "curl https://... | sh", or a registry URL in .npmrc.
+
+URLs and IP addresses are collected from all string values in the file, and
+ConfigCommands holds the package.json scripts, along with any other values which
+look like shell commands that fetch or run code. Since the file is not parsed as
+code, no positions are recorded (including in the Findings made from ConfigCommands),
+and the other signals are empty.
+*/
+func AnalyzeConfigFile(filename string, contents []byte) FileSignals {
+	signals := newFileSignals()
+	signals.IdentifierLengths = valuecounts.New()
+	signals.StringLengths = valuecounts.New()
+	for _, v := range configValues(filename, contents) {
+		signals.URLs = append(signals.URLs, detections.FindURLs(v.Value)...)
+		signals.IPAddresses = append(signals.IPAddresses, detections.FindIPAddresses(v.Value)...)
+		if isScriptKey(filename, v.Key) || detections.IsShellCommand(v.Value) {
+			signals.ConfigCommands = append(signals.ConfigCommands, staticanalysis.ConfigCommand{
+				Key:             v.Key,
+				Command:         v.Value,
+				RemoteExecution: detections.IsRemoteExecution(v.Value),
+			})
+		}
+	}
+	var source staticanalysis.Source
+	signals.PopulateFileResult(&source.Signals)
+	signals.Findings = DefaultDetectorRegistry().Findings(nil, source)
+	return signals
+}
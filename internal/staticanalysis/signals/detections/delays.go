@@ -0,0 +1,73 @@
+package detections
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// LongDelayThresholdMs is the minimum delay (in milliseconds) passed to a timer
+// function for it to be reported as a long delay. Legitimate code rarely schedules
+// work this far in the future, while malicious packages may do so to outlast
+// analysis in a sandbox before running their payload.
+const LongDelayThresholdMs = 60 * 1000
+
+// timerFunctions maps the names of timer functions to the index of the argument
+// holding the delay in milliseconds.
+var timerFunctions = map[string]int{
+	"setTimeout":  1,
+	"setInterval": 1,
+}
+
+// promiseTimerFunctions are (the final part of the names of) timer functions from
+// the timers/promises module, which take the delay as their first argument.
+var promiseTimerFunctions = map[string]bool{
+	"setTimeout":  true,
+	"setInterval": true,
+}
+
+/*
+FindLongDelay checks whether the given call schedules code to run after a long,
+constant delay, e.g. setTimeout(payload, 10 * 60 * 1000). Calls to
+setTimeout and setInterval (optionally qualified by a global object name), and to
+the timers/promises variants (e.g. require("timers/promises").setTimeout(600000))
+are considered. Since a number is not a valid callback for the global functions,
+a call such as setTimeout(600000) is assumed to be to a timers/promises function
+imported by destructuring. If the delay is known and at least LongDelayThresholdMs, the name
+of the timer function and the delay in milliseconds are returned.
+*/
+func FindLongDelay(call token.Call) (function string, delayMs int64, found bool) {
+	parts := strings.Split(call.Callee, ".")
+	function = parts[len(parts)-1]
+
+	argIndex, ok := timerFunctions[function]
+	if !ok {
+		return "", 0, false
+	}
+	globalCall := true
+	for _, objectName := range parts[:len(parts)-1] {
+		if !globalObjectNames[objectName] {
+			globalCall = false
+			break
+		}
+	}
+	if !globalCall {
+		if !promiseTimerFunctions[function] || !strings.Contains(call.Callee, "timers/promises") {
+			return "", 0, false
+		}
+		argIndex = 0
+	} else if len(call.Args) > 0 && call.Args[0].Type == "Numeric" {
+		argIndex = 0
+	}
+
+	if argIndex >= len(call.Args) || call.Args[argIndex].Type != "Numeric" {
+		return "", 0, false
+	}
+	delay, err := strconv.ParseFloat(call.Args[argIndex].Value, 64)
+	if err != nil || delay < LongDelayThresholdMs {
+		return "", 0, false
+	}
+
+	return function, int64(delay), true
+}
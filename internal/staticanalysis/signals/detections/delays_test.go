@@ -0,0 +1,87 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindLongDelay(t *testing.T) {
+	args := func(typesAndValues ...string) []token.CallArg {
+		var result []token.CallArg
+		for i := 0; i < len(typesAndValues); i += 2 {
+			result = append(result, token.CallArg{Type: typesAndValues[i], Value: typesAndValues[i+1]})
+		}
+		return result
+	}
+	tests := []struct {
+		name         string
+		call         token.Call
+		wantFunction string
+		wantDelay    int64
+		wantFound    bool
+	}{
+		{
+			name:      "unrelated call",
+			call:      token.Call{Callee: "console.log", Args: args("Numeric", "600000")},
+			wantFound: false,
+		},
+		{
+			name:      "short timeout",
+			call:      token.Call{Callee: "setTimeout", Args: args("Function", "", "Numeric", "1000")},
+			wantFound: false,
+		},
+		{
+			name:         "long timeout",
+			call:         token.Call{Callee: "setTimeout", Args: args("Function", "", "Numeric", "600000")},
+			wantFunction: "setTimeout",
+			wantDelay:    600000,
+			wantFound:    true,
+		},
+		{
+			name:         "global interval",
+			call:         token.Call{Callee: "globalThis.setInterval", Args: args("Identifier", "run", "Numeric", "3600000")},
+			wantFunction: "setInterval",
+			wantDelay:    3600000,
+			wantFound:    true,
+		},
+		{
+			name:      "non-constant delay",
+			call:      token.Call{Callee: "setTimeout", Args: args("Function", "", "Identifier", "delay")},
+			wantFound: false,
+		},
+		{
+			name:      "missing delay",
+			call:      token.Call{Callee: "setTimeout", Args: args("Function", "")},
+			wantFound: false,
+		},
+		{
+			name:      "method of other object",
+			call:      token.Call{Callee: "scheduler.setTimeout", Args: args("Function", "", "Numeric", "600000")},
+			wantFound: false,
+		},
+		{
+			name:         "timers/promises",
+			call:         token.Call{Callee: `require("timers/promises").setTimeout`, Args: args("Numeric", "900000")},
+			wantFunction: "setTimeout",
+			wantDelay:    900000,
+			wantFound:    true,
+		},
+		{
+			name:         "destructured timers/promises",
+			call:         token.Call{Callee: "setTimeout", Args: args("Numeric", "120000")},
+			wantFunction: "setTimeout",
+			wantDelay:    120000,
+			wantFound:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function, delay, found := FindLongDelay(tt.call)
+			if found != tt.wantFound || function != tt.wantFunction || delay != tt.wantDelay {
+				t.Errorf("FindLongDelay() = (%q, %d, %v), want (%q, %d, %v)",
+					function, delay, found, tt.wantFunction, tt.wantDelay, tt.wantFound)
+			}
+		})
+	}
+}
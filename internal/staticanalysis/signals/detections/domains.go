@@ -0,0 +1,77 @@
+package detections
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// domainRegexp matches strings that look like (ASCII) domain names. Each label is
+// 1-63 characters from [a-z0-9-], not starting or ending with a hyphen, and the
+// final (top level) label consists only of letters.
+var domainRegexp = regexp.MustCompile(`(?i)(?:^|[^\w.-])((?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63})(?:$|[^\w-])`)
+
+// nonDomainTLDs contains TLDs that are used far more often in source code as file
+// extensions or property names than as domains. Strings ending with these are not
+// reported as domains, e.g. setup.py, README.md or user.name.
+var nonDomainTLDs = map[string]bool{
+	"py":    true,
+	"sh":    true,
+	"md":    true,
+	"rs":    true,
+	"pl":    true,
+	"so":    true,
+	"ps":    true,
+	"zip":   true,
+	"mov":   true,
+	"name":  true,
+	"email": true,
+	"map":   true,
+	"json":  true,
+	"php":   true,
+	"rb":    true,
+}
+
+// IsValidDomain reports whether s is a plausible internet domain name, i.e. it
+// has at least two labels and ends with a top level domain managed by ICANN.
+// Strings that are common in source code but unlikely to refer to domains, such
+// as file names (index.js, setup.py) and version strings (v1.2.3) are rejected.
+func IsValidDomain(s string) bool {
+	s = strings.ToLower(strings.TrimSuffix(s, "."))
+	labels := strings.Split(s, ".")
+	if len(labels) < 2 || len(s) > 253 {
+		return false
+	}
+
+	tld := labels[len(labels)-1]
+	if nonDomainTLDs[tld] {
+		return false
+	}
+
+	suffix, icann := publicsuffix.PublicSuffix(s)
+	if !icann || suffix == s {
+		// unknown TLD, or s is itself a public suffix (e.g. co.uk)
+		return false
+	}
+
+	for _, label := range labels {
+		if label == "" || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindDomains returns all valid domain names (see IsValidDomain) found in s, including
+// the hosts of URLs. Domains are returned in lower case, in the order they appear in s.
+func FindDomains(s string) []string {
+	var domains []string
+	for _, match := range domainRegexp.FindAllStringSubmatch(s, -1) {
+		if candidate := match[1]; IsValidDomain(candidate) {
+			domains = append(domains, strings.ToLower(candidate))
+		}
+	}
+	return domains
+}
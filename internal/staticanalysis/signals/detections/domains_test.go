@@ -0,0 +1,37 @@
+package detections
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindDomains(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"bare domain", "evil.com", []string{"evil.com"}},
+		{"subdomain", "connect to api.Example.co.uk now", []string{"api.example.co.uk"}},
+		{"url host", "https://cdn.example.org:8443/path?q=1", []string{"cdn.example.org"}},
+		{"email", "contact admin@example.net", []string{"example.net"}},
+		{"multiple", "a.com, b.io", []string{"a.com", "b.io"}},
+		{"version string", "v1.2.3", nil},
+		{"version with letters", "1.0.0-beta.1", nil},
+		{"js file", "index.js", nil},
+		{"python file", "setup.py", nil},
+		{"markdown file", "README.md", nil},
+		{"property access", "user.name", nil},
+		{"unknown tld", "foo.notarealtld", nil},
+		{"public suffix only", "co.uk", nil},
+		{"ip address", "1.2.3.4", nil},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindDomains(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDomains(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// exitFunctions are the functions of the process object which end the process.
+var exitFunctions = map[string]bool{
+	"exit":       true,
+	"abort":      true,
+	"reallyExit": true,
+}
+
+// processObjectNames are names by which the process object may be referenced.
+var processObjectNames = map[string]bool{
+	"process":                 true,
+	`require("process")`:      true,
+	`require("node:process")`: true,
+}
+
+/*
+FindEarlyExit checks whether the given call unconditionally ends the process, e.g.
+process.exit() at the top level of a file, which stops any code after it (or in files
+loaded later) from running. Malicious packages may exit straight after running their
+payload, to avoid leaving traces of benign-looking code being skipped, or to prevent
+later code that would reveal their behaviour from being analyzed. Calls to the exit,
+abort and reallyExit functions of the process object (optionally qualified by a global
+object name), and to process.kill(process.pid), are considered. If found, the name of
+the function called is returned, e.g. "process.exit".
+*/
+func FindEarlyExit(call token.Call) (function string, found bool) {
+	if !call.Unconditional {
+		return "", false
+	}
+
+	i := strings.LastIndex(call.Callee, ".")
+	if i < 0 {
+		return "", false
+	}
+	object, name := call.Callee[:i], call.Callee[i+1:]
+	if j := strings.Index(object, "."); j >= 0 && globalObjectNames[object[:j]] {
+		object = object[j+1:]
+	}
+	if !processObjectNames[object] {
+		return "", false
+	}
+
+	switch {
+	case exitFunctions[name]:
+	case name == "kill" && len(call.Args) > 0 && call.Args[0].Type == "Member" && strings.HasSuffix(call.Args[0].Value, "process.pid"):
+	default:
+		return "", false
+	}
+	return "process." + name, true
+}
@@ -0,0 +1,64 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindEarlyExit(t *testing.T) {
+	tests := []struct {
+		name         string
+		call         token.Call
+		wantFunction string
+		wantFound    bool
+	}{
+		{
+			name:         "process.exit",
+			call:         token.Call{Callee: "process.exit", Unconditional: true, Args: []token.CallArg{{Type: "Numeric", Value: "0"}}},
+			wantFunction: "process.exit",
+			wantFound:    true,
+		},
+		{
+			name:      "conditional process.exit",
+			call:      token.Call{Callee: "process.exit", Args: []token.CallArg{{Type: "Numeric", Value: "1"}}},
+			wantFound: false,
+		},
+		{
+			name:         "global process.abort",
+			call:         token.Call{Callee: "globalThis.process.abort", Unconditional: true},
+			wantFunction: "process.abort",
+			wantFound:    true,
+		},
+		{
+			name:         "required process",
+			call:         token.Call{Callee: `require("node:process").reallyExit`, Unconditional: true},
+			wantFunction: "process.reallyExit",
+			wantFound:    true,
+		},
+		{
+			name:         "kill own process",
+			call:         token.Call{Callee: "process.kill", Unconditional: true, Args: []token.CallArg{{Type: "Member", Value: "process.pid"}, {Type: "String", Value: "SIGKILL"}}},
+			wantFunction: "process.kill",
+			wantFound:    true,
+		},
+		{
+			name:      "kill other process",
+			call:      token.Call{Callee: "process.kill", Unconditional: true, Args: []token.CallArg{{Type: "Identifier", Value: "pid"}}},
+			wantFound: false,
+		},
+		{
+			name:      "unrelated exit",
+			call:      token.Call{Callee: "app.exit", Unconditional: true},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function, found := FindEarlyExit(tt.call)
+			if function != tt.wantFunction || found != tt.wantFound {
+				t.Errorf("FindEarlyExit() = (%q, %v), want (%q, %v)", function, found, tt.wantFunction, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package detections
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// nodeBuiltinModules are the modules built into Node.js, which can be
+// imported without being installed. Subpaths such as fs/promises are
+// handled by NodeBuiltinModule.
+var nodeBuiltinModules = map[string]bool{
+	"assert":              true,
+	"async_hooks":         true,
+	"buffer":              true,
+	"child_process":       true,
+	"cluster":             true,
+	"console":             true,
+	"constants":           true,
+	"crypto":              true,
+	"dgram":               true,
+	"diagnostics_channel": true,
+	"dns":                 true,
+	"domain":              true,
+	"events":              true,
+	"fs":                  true,
+	"http":                true,
+	"http2":               true,
+	"https":               true,
+	"inspector":           true,
+	"module":              true,
+	"net":                 true,
+	"os":                  true,
+	"path":                true,
+	"perf_hooks":          true,
+	"process":             true,
+	"punycode":            true,
+	"querystring":         true,
+	"readline":            true,
+	"repl":                true,
+	"stream":              true,
+	"string_decoder":      true,
+	"sys":                 true,
+	"timers":              true,
+	"tls":                 true,
+	"trace_events":        true,
+	"tty":                 true,
+	"url":                 true,
+	"util":                true,
+	"v8":                  true,
+	"vm":                  true,
+	"wasi":                true,
+	"worker_threads":      true,
+	"zlib":                true,
+}
+
+// nodeNetworkModules are the Node.js built-in modules used for network access.
+var nodeNetworkModules = map[string]bool{
+	"dgram": true,
+	"dns":   true,
+	"http":  true,
+	"http2": true,
+	"https": true,
+	"net":   true,
+	"tls":   true,
+}
+
+// ImportedModule returns the module imported by the given call to require or
+// import, or the empty string if call does not import a module (or the module
+// name is not constant). Import declarations such as import x from "module"
+// are not calls, and are recorded by the parser as a token.Import instead.
+func ImportedModule(call token.Call) string {
+	if call.Callee != "require" && call.Callee != "import" {
+		return ""
+	}
+	if len(call.Args) == 0 || call.Args[0].Type != "String" {
+		return ""
+	}
+	return call.Args[0].Value
+}
+
+// NodeBuiltinModule returns the name of the Node.js built-in module (without any
+// "node:" prefix or subpath) that module refers to, or the empty string if module
+// is not a built-in module. For example, "node:fs/promises" returns "fs".
+func NodeBuiltinModule(module string) string {
+	name, prefixed := strings.CutPrefix(module, "node:")
+	name, _, _ = strings.Cut(name, "/")
+	if prefixed || nodeBuiltinModules[name] {
+		return name
+	}
+	return ""
+}
+
+// IsNodeNetworkModule returns whether module refers to a Node.js built-in
+// module used for network access, such as http or net.
+func IsNodeNetworkModule(module string) bool {
+	return nodeNetworkModules[NodeBuiltinModule(module)]
+}
+
+/*
+NPMPackageName returns the name of the NPM package that provides module, which is
+the first component of the module name, or the first two for scoped packages.
+For example, "lodash/fp" returns "lodash", and "@babel/core/lib/index" returns
+"@babel/core".
+
+The empty string is returned for relative or absolute paths, URLs and Node.js
+built-in modules, which are not provided by a package.
+*/
+func NPMPackageName(module string) string {
+	if module == "" || strings.HasPrefix(module, ".") || strings.HasPrefix(module, "/") ||
+		strings.Contains(module, ":") || NodeBuiltinModule(module) != "" {
+		return ""
+	}
+	parts := strings.SplitN(module, "/", 3)
+	if strings.HasPrefix(module, "@") {
+		if len(parts) < 2 || parts[1] == "" {
+			return ""
+		}
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// windowsAbsolutePath matches paths starting with a Windows drive letter, e.g. C:\.
+var windowsAbsolutePath = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+/*
+IsOutsidePackagePath returns whether module, imported by the file at the slash-separated
+path file relative to the package root, refers to a path outside the package: an
+absolute path (including file: URLs and Windows drive paths), or a relative path with
+enough ".." components to leave the package root, e.g. "../../other/index.js" from a
+file at the root. Such imports reach into the host or into sibling packages in
+node_modules, rather than into the package itself or its dependencies.
+
+Relative paths are resolved against the directory of file, so if file is empty, only
+absolute paths are reported.
+*/
+func IsOutsidePackagePath(module, file string) bool {
+	if strings.HasPrefix(module, "/") || strings.HasPrefix(module, "file:") || windowsAbsolutePath.MatchString(module) {
+		return true
+	}
+	specifier := strings.ReplaceAll(module, "\\", "/")
+	if file == "" || !isRelativeSpecifier(specifier) {
+		return false
+	}
+	resolved := path.Join(path.Dir(file), specifier)
+	return resolved == ".." || strings.HasPrefix(resolved, "../")
+}
+
+// isRelativeSpecifier returns whether module is a path relative to the importing
+// file, rather than the name of a package.
+func isRelativeSpecifier(module string) bool {
+	return module == "." || module == ".." || strings.HasPrefix(module, "./") || strings.HasPrefix(module, "../")
+}
@@ -0,0 +1,92 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestImportedModule(t *testing.T) {
+	tests := []struct {
+		name string
+		call token.Call
+		want string
+	}{
+		{"require", token.Call{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "fs"}}}, "fs"},
+		{"import", token.Call{Callee: "import", Args: []token.CallArg{{Type: "String", Value: "./lib"}}}, "./lib"},
+		{"non-constant", token.Call{Callee: "require", Args: []token.CallArg{{Type: "Identifier", Value: "name"}}}, ""},
+		{"no arguments", token.Call{Callee: "require"}, ""},
+		{"other call", token.Call{Callee: "console.log", Args: []token.CallArg{{Type: "String", Value: "fs"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ImportedModule(tt.call); got != tt.want {
+				t.Errorf("ImportedModule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNPMPackageName(t *testing.T) {
+	tests := []struct {
+		module      string
+		wantPackage string
+		wantBuiltin string
+	}{
+		{"lodash", "lodash", ""},
+		{"lodash/fp", "lodash", ""},
+		{"@babel/core", "@babel/core", ""},
+		{"@babel/core/lib/index.js", "@babel/core", ""},
+		{"@babel", "", ""},
+		{"./lib/index", "", ""},
+		{"../x", "", ""},
+		{"/usr/lib/node/x", "", ""},
+		{"https://example.com/x.js", "", ""},
+		{"fs", "", "fs"},
+		{"fs/promises", "", "fs"},
+		{"node:http", "", "http"},
+		{"node:test", "", "test"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.module, func(t *testing.T) {
+			if got := NPMPackageName(tt.module); got != tt.wantPackage {
+				t.Errorf("NPMPackageName() = %q, want %q", got, tt.wantPackage)
+			}
+			if got := NodeBuiltinModule(tt.module); got != tt.wantBuiltin {
+				t.Errorf("NodeBuiltinModule() = %q, want %q", got, tt.wantBuiltin)
+			}
+		})
+	}
+}
+
+func TestIsOutsidePackagePath(t *testing.T) {
+	tests := []struct {
+		module string
+		file   string
+		want   bool
+	}{
+		{"fs", "index.js", false},
+		{"lodash/fp", "index.js", false},
+		{"./lib", "index.js", false},
+		{"../index", "lib/util.js", false},
+		{"../../index", "lib/a/util.js", false},
+		{"..", "lib/util.js", false},
+		{"../other/index.js", "index.js", true},
+		{"..", "index.js", true},
+		{"../../../.ssh/config", "lib/util.js", true},
+		{"./../x", "index.js", true},
+		{"..\\..\\x", "lib/util.js", true},
+		{"/etc/passwd", "index.js", true},
+		{"/usr/lib/node_modules/npm", "", true},
+		{"file:///root/.npmrc", "index.js", true},
+		{"C:\\Users\\x\\a.js", "index.js", true},
+		{"../other", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.module+" from "+tt.file, func(t *testing.T) {
+			if got := IsOutsidePackagePath(tt.module, tt.file); got != tt.want {
+				t.Errorf("IsOutsidePackagePath(%q, %q) = %v, want %v", tt.module, tt.file, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// globalObjectNames are names that refer to the JavaScript global object.
+var globalObjectNames = map[string]bool{
+	"this":       true,
+	"globalThis": true,
+	"window":     true,
+	"global":     true,
+	"self":       true,
+}
+
+// dynamicExecutionFunctions are global functions that execute strings as code.
+var dynamicExecutionFunctions = map[string]bool{
+	"eval":     true,
+	"Function": true,
+}
+
+/*
+FindIndirectEval checks whether the given call is an indirect call to eval or the
+Function constructor, and if so, returns the name of the function that was resolved
+as the target of the call, i.e. "eval" or "Function".
+
+Indirect calls include those made through a member of the global object (e.g.
+globalThis["Function"] or window.eval), those where the function name is computed
+from constant strings (e.g. this["ev" + "al"]), and those made through a sequence
+expression (e.g. (0, eval)). Such constructs are often used to hide dynamic code
+execution from simple scanners. Direct calls (e.g. eval(code)) are not matched.
+*/
+func FindIndirectEval(call token.Call) (string, bool) {
+	parts := strings.Split(call.Callee, ".")
+	target := parts[len(parts)-1]
+	if !dynamicExecutionFunctions[target] {
+		return "", false
+	}
+
+	// the function must be referenced via the global object (if at all)
+	for _, objectName := range parts[:len(parts)-1] {
+		if !globalObjectNames[objectName] {
+			return "", false
+		}
+	}
+
+	if len(parts) == 1 && !call.Indirect {
+		// direct call
+		return "", false
+	}
+
+	return target, true
+}
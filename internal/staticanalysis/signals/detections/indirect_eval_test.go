@@ -0,0 +1,75 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindIndirectEval(t *testing.T) {
+	tests := []struct {
+		name       string
+		call       token.Call
+		wantTarget string
+		wantFound  bool
+	}{
+		{
+			name:      "direct eval",
+			call:      token.Call{Callee: "eval"},
+			wantFound: false,
+		},
+		{
+			name:      "direct Function",
+			call:      token.Call{Callee: "Function", New: true},
+			wantFound: false,
+		},
+		{
+			name:       "computed this member",
+			call:       token.Call{Callee: "this.eval", Computed: true},
+			wantTarget: "eval",
+			wantFound:  true,
+		},
+		{
+			name:       "globalThis Function",
+			call:       token.Call{Callee: "globalThis.Function", Computed: true},
+			wantTarget: "Function",
+			wantFound:  true,
+		},
+		{
+			name:       "window eval",
+			call:       token.Call{Callee: "window.eval"},
+			wantTarget: "eval",
+			wantFound:  true,
+		},
+		{
+			name:       "sequence expression",
+			call:       token.Call{Callee: "eval", Indirect: true},
+			wantTarget: "eval",
+			wantFound:  true,
+		},
+		{
+			name:       "nested global objects",
+			call:       token.Call{Callee: "window.self.Function", New: true},
+			wantTarget: "Function",
+			wantFound:  true,
+		},
+		{
+			name:      "method named eval",
+			call:      token.Call{Callee: "expression.eval"},
+			wantFound: false,
+		},
+		{
+			name:      "other global function",
+			call:      token.Call{Callee: "globalThis.setTimeout", Computed: true},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTarget, gotFound := FindIndirectEval(tt.call)
+			if gotTarget != tt.wantTarget || gotFound != tt.wantFound {
+				t.Errorf("FindIndirectEval() = %q, %v, want %q, %v", gotTarget, gotFound, tt.wantTarget, tt.wantFound)
+			}
+		})
+	}
+}
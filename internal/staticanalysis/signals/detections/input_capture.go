@@ -0,0 +1,170 @@
+package detections
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Types of input capture found by FindInputListener, FindInputHandlerAssignment
+// and FindFormAccess.
+const (
+	// InputListener means an event listener for user input, e.g. keystrokes.
+	InputListener = "listener"
+
+	// FormAccess means access to the fields of forms on a web page.
+	FormAccess = "form_access"
+)
+
+// inputEvents are the DOM events which are fired as a user types into a page or
+// submits a form, and which are listened for by keyloggers and form skimmers.
+var inputEvents = map[string]bool{
+	"keydown":     true,
+	"keyup":       true,
+	"keypress":    true,
+	"input":       true,
+	"beforeinput": true,
+	"change":      true,
+	"submit":      true,
+}
+
+// listenerFunctions are methods which add an event listener for the event given
+// as the first argument: the DOM addEventListener, and attachEvent in old versions
+// of Internet Explorer (which prefixes the event with "on").
+var listenerFunctions = map[string]bool{
+	"addEventListener": true,
+	"attachEvent":      true,
+}
+
+// jQueryListenerFunctions are the jQuery equivalents of listenerFunctions. They are
+// only matched on jQuery objects, since event emitters in Node.js have an on method
+// too, e.g. for "change" events of file watchers.
+var jQueryListenerFunctions = map[string]bool{
+	"on":   true,
+	"bind": true,
+}
+
+// isJQueryObject returns whether object is the name of a jQuery object, e.g. "$()"
+// for $("input"), or "$().find()" for $("form").find("input").
+func isJQueryObject(object string) bool {
+	for _, prefix := range []string{"$()", "jQuery()"} {
+		if object == prefix || strings.HasPrefix(object, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// formQueryFunctions are DOM methods which find elements using a selector, tag
+// name or name given as the first argument.
+var formQueryFunctions = map[string]bool{
+	"querySelector":        true,
+	"querySelectorAll":     true,
+	"getElementsByTagName": true,
+	"getElementsByName":    true,
+}
+
+// formFieldPattern matches selectors, tag names and element names of form fields,
+// in particular those holding payment card details and passwords.
+var formFieldPattern = regexp.MustCompile(`(?i)\b(?:input|form|textarea|select)\b|passw|card|cvv|cvc|expir`)
+
+// browserSendFunctions are browser APIs (and jQuery functions) which send data
+// to a server, in addition to those found by FindNetworkRequest. XMLHttpRequest
+// and WebSocket are reported when constructed.
+var browserSendFunctions = map[string]bool{
+	"navigator.sendBeacon": true,
+	"XMLHttpRequest":       true,
+	"WebSocket":            true,
+	"$.ajax":               true,
+	"$.post":               true,
+	"$.get":                true,
+	"jQuery.ajax":          true,
+	"jQuery.post":          true,
+	"jQuery.get":           true,
+}
+
+/*
+FindInputListener checks whether the given call adds an event listener for user
+input, e.g. document.addEventListener("keydown", handler),
+form.addEventListener("submit", handler) or $("input").on("change", handler).
+If so, the (lowercase) name of the event is returned.
+*/
+func FindInputListener(call token.Call) (event string, found bool) {
+	i := strings.LastIndex(call.Callee, ".")
+	if i < 0 {
+		return "", false
+	}
+	object, function := call.Callee[:i], call.Callee[i+1:]
+	if !listenerFunctions[function] && !(jQueryListenerFunctions[function] && isJQueryObject(object)) {
+		return "", false
+	}
+	if len(call.Args) == 0 || call.Args[0].Type != "String" {
+		return "", false
+	}
+	event = strings.ToLower(call.Args[0].Value)
+	if function == "attachEvent" {
+		event = strings.TrimPrefix(event, "on")
+	}
+	// jQuery allows several space-separated events, e.g. "keyup change"
+	for _, e := range strings.Fields(event) {
+		if inputEvents[e] {
+			return e, true
+		}
+	}
+	return "", false
+}
+
+// FindInputHandlerAssignment checks whether the given assignment sets an event
+// handler property for user input, e.g. document.onkeydown = handler. If so, the
+// name of the event is returned.
+func FindInputHandlerAssignment(a token.Assignment) (event string, found bool) {
+	if !a.Member {
+		return "", false
+	}
+	i := strings.LastIndex(a.Target, ".")
+	if i < 0 {
+		return "", false
+	}
+	property := strings.ToLower(a.Target[i+1:])
+	if event, ok := strings.CutPrefix(property, "on"); ok && inputEvents[event] {
+		return event, true
+	}
+	return "", false
+}
+
+/*
+FindFormAccess checks whether the given call accesses the fields of forms on a web
+page, e.g. document.querySelectorAll("input[type=password]") or $("form input"),
+a method of document.forms, new FormData(form), or a call passing document.forms as
+an argument. If so, a description of the API used is returned: the callee, with the
+selector for DOM queries (e.g. `document.querySelector("#card-number")`).
+*/
+func FindFormAccess(call token.Call) (api string, found bool) {
+	if call.Callee == "FormData" && call.New {
+		return call.Callee, true
+	}
+	if strings.HasPrefix(call.Callee, "document.forms.") {
+		return call.Callee, true
+	}
+	parts := strings.Split(call.Callee, ".")
+	if (len(parts) >= 2 && formQueryFunctions[parts[len(parts)-1]]) || call.Callee == "$" || call.Callee == "jQuery" {
+		if len(call.Args) > 0 && call.Args[0].Type == "String" && formFieldPattern.MatchString(call.Args[0].Value) {
+			return call.Callee + `("` + call.Args[0].Value + `")`, true
+		}
+		return "", false
+	}
+	for _, arg := range call.Args {
+		if arg.Type == "Member" && (arg.Value == "document.forms" || strings.HasPrefix(arg.Value, "document.forms.")) {
+			return arg.Value, true
+		}
+	}
+	return "", false
+}
+
+// IsNetworkSend returns whether the given call may send data to a server: an HTTP
+// request found by FindNetworkRequest, or one of the browser APIs for sending data,
+// e.g. navigator.sendBeacon(url, data) or new XMLHttpRequest().
+func IsNetworkSend(call token.Call) bool {
+	return isNetworkRequest(call.Callee) || browserSendFunctions[call.Callee]
+}
@@ -0,0 +1,110 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindInputListener(t *testing.T) {
+	call := func(callee string, args ...token.CallArg) token.Call {
+		return token.Call{Callee: callee, Args: args}
+	}
+	str := func(s string) token.CallArg { return token.CallArg{Type: "String", Value: s} }
+	handler := token.CallArg{Type: "Function"}
+	tests := []struct {
+		name      string
+		call      token.Call
+		wantEvent string
+	}{
+		{"keydown", call("document.addEventListener", str("keydown"), handler), "keydown"},
+		{"form submit", call("form.addEventListener", str("submit"), handler), "submit"},
+		{"upper case", call("window.addEventListener", str("KeyUp"), handler), "keyup"},
+		{"attachEvent", call("document.attachEvent", str("onkeypress"), handler), "keypress"},
+		{"jquery", call("$().on", str("keyup change"), handler), "keyup"},
+		{"chained jquery", call("jQuery().find().bind", str("input"), handler), "input"},
+		{"click", call("document.addEventListener", str("click"), handler), ""},
+		{"event emitter", call("watcher.on", str("change"), handler), ""},
+		{"dynamic event", call("el.addEventListener", token.CallArg{Type: "Identifier", Value: "ev"}, handler), ""},
+		{"no arguments", call("el.addEventListener"), ""},
+		{"global function", call("addEventListener", str("keydown"), handler), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindInputListener(tt.call)
+			if got != tt.wantEvent || found != (tt.wantEvent != "") {
+				t.Errorf("FindInputListener() = (%q, %v), want %q", got, found, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestFindInputHandlerAssignment(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment token.Assignment
+		wantEvent  string
+	}{
+		{"onkeydown", token.Assignment{Target: "document.onkeydown", Member: true}, "keydown"},
+		{"oninput", token.Assignment{Target: "this.field.oninput", Member: true}, "input"},
+		{"onclick", token.Assignment{Target: "document.onclick", Member: true}, ""},
+		{"variable", token.Assignment{Target: "onkeydown"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindInputHandlerAssignment(tt.assignment)
+			if got != tt.wantEvent || found != (tt.wantEvent != "") {
+				t.Errorf("FindInputHandlerAssignment() = (%q, %v), want %q", got, found, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestFindFormAccess(t *testing.T) {
+	str := func(s string) token.CallArg { return token.CallArg{Type: "String", Value: s} }
+	tests := []struct {
+		name    string
+		call    token.Call
+		wantAPI string
+	}{
+		{"password query", token.Call{Callee: "document.querySelector", Args: []token.CallArg{str("input[type=password]")}}, `document.querySelector("input[type=password]")`},
+		{"card field", token.Call{Callee: "form.querySelectorAll", Args: []token.CallArg{str("#cc-card-number")}}, `form.querySelectorAll("#cc-card-number")`},
+		{"tag name", token.Call{Callee: "document.getElementsByTagName", Args: []token.CallArg{str("INPUT")}}, `document.getElementsByTagName("INPUT")`},
+		{"jquery", token.Call{Callee: "$", Args: []token.CallArg{str("form input")}}, `$("form input")`},
+		{"forms method", token.Call{Callee: "document.forms.namedItem", Args: []token.CallArg{str("checkout")}}, "document.forms.namedItem"},
+		{"form data", token.Call{Callee: "FormData", New: true, Args: []token.CallArg{{Type: "Identifier", Value: "f"}}}, "FormData"},
+		{"forms argument", token.Call{Callee: "Array.from", Args: []token.CallArg{{Type: "Member", Value: "document.forms"}}}, "document.forms"},
+		{"other query", token.Call{Callee: "document.querySelector", Args: []token.CallArg{str("#header")}}, ""},
+		{"dynamic selector", token.Call{Callee: "document.querySelector", Args: []token.CallArg{{Type: "Identifier", Value: "sel"}}}, ""},
+		{"other call", token.Call{Callee: "console.log", Args: []token.CallArg{str("input")}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindFormAccess(tt.call)
+			if got != tt.wantAPI || found != (tt.wantAPI != "") {
+				t.Errorf("FindFormAccess() = (%q, %v), want %q", got, found, tt.wantAPI)
+			}
+		})
+	}
+}
+
+func TestIsNetworkSend(t *testing.T) {
+	tests := []struct {
+		callee string
+		want   bool
+	}{
+		{"fetch", true},
+		{"navigator.sendBeacon", true},
+		{"XMLHttpRequest", true},
+		{"$.ajax", true},
+		{`require("https").request`, true},
+		{"console.log", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.callee, func(t *testing.T) {
+			if got := IsNetworkSend(token.Call{Callee: tt.callee}); got != tt.want {
+				t.Errorf("IsNetworkSend(%q) = %v, want %v", tt.callee, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package detections
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Types of insecure transport found by the functions in this file.
+const (
+	// TLSVerificationDisabled means that code turns off verification of TLS
+	// certificates, so that connections to hosts with self-signed or otherwise
+	// invalid certificates succeed.
+	TLSVerificationDisabled = "tls_verification_disabled"
+
+	// PlainHTTP means that code makes a request to a remote host over plain
+	// (unencrypted) HTTP.
+	PlainHTTP = "plain_http"
+)
+
+// tlsRejectEnvVar is the environment variable that disables verification of
+// TLS certificates by Node.js if it is set to 0.
+const tlsRejectEnvVar = "NODE_TLS_REJECT_UNAUTHORIZED"
+
+// tlsVerificationOptions are options for connecting to a host (or of clients or
+// package managers) which disable verification of TLS certificates if false.
+var tlsVerificationOptions = map[string]bool{
+	"rejectUnauthorized": true,
+	"strictSSL":          true,
+	"strict-ssl":         true,
+}
+
+// insecureCommandPattern matches shell commands (or parts of commands) that disable
+// verification of TLS certificates, e.g. "NODE_TLS_REJECT_UNAUTHORIZED=0 node x.js",
+// "curl -k https://..." or "wget --no-check-certificate https://...".
+var insecureCommandPattern = regexp.MustCompile(`\b` + tlsRejectEnvVar + `=["']?(?:0|false)\b|` +
+	`\bcurl\b[^|;&\n]*\s(?:-k|--insecure)\b|` +
+	`\bwget\b[^|;&\n]*\s--no-check-certificate\b|` +
+	`\bnpm\s+config\s+set\s+strict-ssl\s+false\b`)
+
+// httpRequestFunctions are the names of functions (or methods) which make an HTTP
+// request to a URL given as an argument, e.g. fetch(url), http.get(url), axios.post(url)
+// or request(url).
+var httpRequestFunctions = map[string]bool{
+	"fetch":    true,
+	"get":      true,
+	"post":     true,
+	"put":      true,
+	"patch":    true,
+	"request":  true,
+	"download": true,
+	"axios":    true,
+	"got":      true,
+	"needle":   true,
+}
+
+// httpMethods are the methods of HTTP requests, which are passed as the first
+// argument of XMLHttpRequest.open.
+var httpMethods = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+	"HEAD":   true,
+}
+
+/*
+FindDisabledTLSVerification checks whether the given assignment turns off
+verification of TLS certificates, e.g. { rejectUnauthorized: false } in the
+options of https.request, or process.env.NODE_TLS_REJECT_UNAUTHORIZED = "0".
+This is often done by malware to connect to a command and control server with
+a self-signed certificate.
+
+If so, a description of the setting is returned, e.g. "rejectUnauthorized=false".
+*/
+func FindDisabledTLSVerification(a token.Assignment) (setting string, found bool) {
+	name := a.PropertyName()
+	value := a.Value.Value
+
+	falsy := (a.Value.Type == "Boolean" && value == "false") || (a.Value.Type == "Numeric" && value == "0")
+	if a.Value.Type == "String" && name == tlsRejectEnvVar {
+		// environment variables are strings; Node.js only checks for "0"
+		falsy = value == "0"
+	}
+	if !falsy || (!tlsVerificationOptions[name] && name != tlsRejectEnvVar) {
+		return "", false
+	}
+	return name + "=" + value, true
+}
+
+// FindInsecureCommand checks whether the given string contains a shell command that
+// disables verification of TLS certificates, e.g. by setting NODE_TLS_REJECT_UNAUTHORIZED=0
+// or running curl with the -k option. If so, the matching part of the command is returned.
+func FindInsecureCommand(s string) (command string, found bool) {
+	command = insecureCommandPattern.FindString(s)
+	return command, command != ""
+}
+
+// isLoopbackHost returns whether host refers to the local machine.
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
+
+// plainHTTPURL returns whether s is an absolute http:// URL of a remote host.
+func plainHTTPURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || !strings.EqualFold(u.Scheme, "http") || u.Hostname() == "" {
+		return false
+	}
+	return !isLoopbackHost(u.Hostname())
+}
+
+/*
+FindPlainHTTPRequest checks whether the given call makes an HTTP request to a
+constant http:// URL of a remote host, e.g. fetch("http://example.com/payload"),
+http.get("http://...") or xhr.open("GET", "http://..."). Requests to the local
+machine are not reported. Payloads downloaded over plain HTTP may be tampered
+with in transit, and allow the download to be hidden from tools that only
+inspect TLS connections.
+
+If so, the URL is returned.
+*/
+func FindPlainHTTPRequest(call token.Call) (url string, found bool) {
+	args := call.Args
+	switch function := call.FunctionName(); {
+	case function == "open":
+		// XMLHttpRequest.open(method, url)
+		if len(args) < 2 || args[0].Type != "String" || !httpMethods[strings.ToUpper(args[0].Value)] {
+			return "", false
+		}
+		args = args[1:]
+	case !httpRequestFunctions[function]:
+		return "", false
+	}
+
+	if len(args) > 0 && args[0].Type == "String" && plainHTTPURL(args[0].Value) {
+		return args[0].Value, true
+	}
+	return "", false
+}
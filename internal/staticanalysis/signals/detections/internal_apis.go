@@ -0,0 +1,107 @@
+package detections
+
+import (
+	"regexp"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Types of internal API usage found by FindInternalAPIUsage.
+const (
+	// InternalBinding means that code accesses the native bindings of Node.js
+	// directly (e.g. process.binding("fs")), bypassing the public APIs.
+	InternalBinding = "internal_binding"
+
+	// V8Internals means that code imports the v8 module, which exposes internals
+	// of the JavaScript engine (e.g. heap snapshots and runtime flags).
+	V8Internals = "v8_internals"
+
+	// Inspector means that code imports the inspector module, which can attach a
+	// debugger to the running process or evaluate code through it.
+	Inspector = "inspector"
+
+	// DebuggerActivation means that code activates the debugger of a Node.js process,
+	// e.g. by running node with --inspect or sending SIGUSR1 to another process.
+	DebuggerActivation = "debugger_activation"
+)
+
+// internalBindingFunctions are the functions of the process object that give
+// access to the native bindings of Node.js, or activate the debugger of a process.
+var internalBindingFunctions = map[string]string{
+	"process.binding":        InternalBinding,
+	"process._linkedBinding": InternalBinding,
+	"process._debugProcess":  DebuggerActivation,
+}
+
+// internalModules are the Node.js built-in modules that expose engine or debugger internals.
+var internalModules = map[string]string{
+	"v8":        V8Internals,
+	"inspector": Inspector,
+}
+
+// inspectFlagPattern matches the command line options which activate the Node.js
+// inspector, e.g. --inspect, --inspect-brk=0.0.0.0:9229 or --inspect-port=9229.
+var inspectFlagPattern = regexp.MustCompile(`(?:^|\s)--inspect(?:-brk|-wait|-port)?(?:=\S*)?(?:\s|$)`)
+
+// nodeOptionsEnvVar is the environment variable holding options for Node.js.
+const nodeOptionsEnvVar = "NODE_OPTIONS"
+
+/*
+FindInternalAPIUsage checks whether the given call uses a low-level Node.js API
+for accessing internals of the runtime or debugging a process. These are rarely
+used by published packages, and may indicate an attempt to escape a sandbox or to
+introspect the analysis environment. The APIs detected are:
+
+  - process.binding and process._linkedBinding (type InternalBinding)
+  - imports of the v8 module (type V8Internals)
+  - imports of the inspector module (type Inspector)
+  - process._debugProcess, process.kill(pid, "SIGUSR1") and constant --inspect
+    options passed to a function, e.g. execSync("node --inspect=9229 x.js")
+    (type DebuggerActivation)
+
+If so, the type of API and a description of it are returned: the callee name, or
+the call itself (e.g. `require("node:v8")`) for imports and debugger activation.
+*/
+func FindInternalAPIUsage(call token.Call) (apiType, api string, found bool) {
+	if t, ok := internalBindingFunctions[call.Callee]; ok {
+		return t, call.Callee, true
+	}
+
+	if module := ImportedModule(call); module != "" {
+		if t, ok := FindInternalModuleImport(module); ok {
+			return t, call.Callee + `("` + module + `")`, true
+		}
+		return "", "", false
+	}
+
+	if call.Callee == "process.kill" && len(call.Args) >= 2 && call.Args[1].Type == "String" && call.Args[1].Value == "SIGUSR1" {
+		return DebuggerActivation, `process.kill(..., "SIGUSR1")`, true
+	}
+
+	for _, arg := range call.Args {
+		if arg.Type == "String" && inspectFlagPattern.MatchString(arg.Value) {
+			return DebuggerActivation, call.Callee + `("` + arg.Value + `")`, true
+		}
+	}
+	return "", "", false
+}
+
+// FindInternalModuleImport checks whether module is one of the Node.js built-in
+// modules that expose internals of the runtime, i.e. v8 or inspector, as imported
+// by an import declaration or a call (see FindInternalAPIUsage). If so, the type
+// of API is returned.
+func FindInternalModuleImport(module string) (apiType string, found bool) {
+	apiType, found = internalModules[NodeBuiltinModule(module)]
+	return apiType, found
+}
+
+// FindDebuggerActivation checks whether the given assignment activates the Node.js
+// inspector for child processes by setting NODE_OPTIONS, e.g. in the environment
+// passed to spawn, or process.env.NODE_OPTIONS = "--inspect". If so, the setting
+// is returned, e.g. "NODE_OPTIONS=--inspect".
+func FindDebuggerActivation(a token.Assignment) (setting string, found bool) {
+	if a.PropertyName() != nodeOptionsEnvVar || a.Value.Type != "String" || !inspectFlagPattern.MatchString(a.Value.Value) {
+		return "", false
+	}
+	return nodeOptionsEnvVar + "=" + a.Value.Value, true
+}
@@ -0,0 +1,66 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindInternalAPIUsage(t *testing.T) {
+	str := func(values ...string) []token.CallArg {
+		var args []token.CallArg
+		for _, v := range values {
+			args = append(args, token.CallArg{Type: "String", Value: v})
+		}
+		return args
+	}
+	tests := []struct {
+		name     string
+		call     token.Call
+		wantType string
+		wantAPI  string
+	}{
+		{"process.binding", token.Call{Callee: "process.binding", Args: str("fs")}, InternalBinding, "process.binding"},
+		{"process._linkedBinding", token.Call{Callee: "process._linkedBinding", Args: str("x")}, InternalBinding, "process._linkedBinding"},
+		{"require v8", token.Call{Callee: "require", Args: str("v8")}, V8Internals, `require("v8")`},
+		{"import node:inspector", token.Call{Callee: "import", Args: str("node:inspector")}, Inspector, `import("node:inspector")`},
+		{"inspector subpath", token.Call{Callee: "require", Args: str("inspector/promises")}, Inspector, `require("inspector/promises")`},
+		{"debug process", token.Call{Callee: "process._debugProcess", Args: []token.CallArg{{Type: "Numeric", Value: "1"}}}, DebuggerActivation, "process._debugProcess"},
+		{"SIGUSR1", token.Call{Callee: "process.kill", Args: []token.CallArg{{Type: "Identifier", Value: "pid"}, {Type: "String", Value: "SIGUSR1"}}}, DebuggerActivation, `process.kill(..., "SIGUSR1")`},
+		{"inspect flag", token.Call{Callee: "execSync", Args: str("node --inspect-brk=0.0.0.0:9229 index.js")}, DebuggerActivation, `execSync("node --inspect-brk=0.0.0.0:9229 index.js")`},
+		{"SIGTERM", token.Call{Callee: "process.kill", Args: str("1", "SIGTERM")}, "", ""},
+		{"require fs", token.Call{Callee: "require", Args: str("fs")}, "", ""},
+		{"v8 package", token.Call{Callee: "require", Args: str("v8-compile-cache")}, "", ""},
+		{"inspect in text", token.Call{Callee: "console.log", Args: str("use --inspection mode")}, "", ""},
+		{"binding method", token.Call{Callee: "addon.binding", Args: str("x")}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotAPI, found := FindInternalAPIUsage(tt.call)
+			if gotType != tt.wantType || gotAPI != tt.wantAPI || found != (tt.wantType != "") {
+				t.Errorf("FindInternalAPIUsage() = (%q, %q, %v), want (%q, %q)", gotType, gotAPI, found, tt.wantType, tt.wantAPI)
+			}
+		})
+	}
+}
+
+func TestFindDebuggerActivation(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment token.Assignment
+		want       string
+	}{
+		{"env property", token.Assignment{Target: "NODE_OPTIONS", Value: token.CallArg{Type: "String", Value: "--inspect"}}, "NODE_OPTIONS=--inspect"},
+		{"process.env", token.Assignment{Target: "process.env.NODE_OPTIONS", Member: true, Value: token.CallArg{Type: "String", Value: "--max-old-space-size=4096 --inspect=9229"}}, "NODE_OPTIONS=--max-old-space-size=4096 --inspect=9229"},
+		{"other options", token.Assignment{Target: "NODE_OPTIONS", Value: token.CallArg{Type: "String", Value: "--max-old-space-size=4096"}}, ""},
+		{"other variable", token.Assignment{Target: "DEBUG", Value: token.CallArg{Type: "String", Value: "--inspect"}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindDebuggerActivation(tt.assignment)
+			if got != tt.want || found != (tt.want != "") {
+				t.Errorf("FindDebuggerActivation() = (%q, %v), want %q", got, found, tt.want)
+			}
+		})
+	}
+}
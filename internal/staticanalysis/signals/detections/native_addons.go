@@ -0,0 +1,74 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Ways in which code loads a native addon.
+const (
+	// NativeAddonRequire is a require or import of a .node file.
+	NativeAddonRequire = "require"
+	// NativeAddonDlopen is a call to process.dlopen, which loads any shared library.
+	NativeAddonDlopen = "dlopen"
+	// NativeAddonLoader is a require of a module which finds and loads the addon
+	// built by node-gyp (or a prebuilt one), e.g. bindings or node-gyp-build.
+	NativeAddonLoader = "loader"
+)
+
+// nativeAddonLoaders are the modules whose purpose is to load native addons.
+var nativeAddonLoaders = map[string]bool{
+	"bindings":                         true,
+	"node-gyp-build":                   true,
+	"node-gyp-build-optional-packages": true,
+	"node-pre-gyp":                     true,
+	"@mapbox/node-pre-gyp":             true,
+}
+
+/*
+FindNativeAddonLoad checks whether the given call loads a native addon: a require or
+import of a .node file, a call to process.dlopen, or a require of a module that loads
+addons (see NativeAddonLoader). If so, it returns the way the addon is loaded (one of
+the NativeAddon constants), and the path of the library (or the name of the loader
+module) if it is constant.
+
+Native code runs outside the JavaScript engine, so nothing it does is visible to the
+analysis of the JavaScript code of the package.
+*/
+func FindNativeAddonLoad(call token.Call) (loadType, library string, found bool) {
+	if module := ImportedModule(call); module != "" {
+		if loadType, found := FindNativeAddonModule(module); found {
+			return loadType, module, true
+		}
+		return "", "", false
+	}
+
+	parts := strings.Split(call.Callee, ".")
+	if len(parts) < 2 || parts[len(parts)-2] != "process" || parts[len(parts)-1] != "dlopen" {
+		return "", "", false
+	}
+	for _, objectName := range parts[:len(parts)-2] {
+		if !globalObjectNames[objectName] {
+			return "", "", false
+		}
+	}
+	// process.dlopen(module, filename[, flags])
+	if len(call.Args) > 1 && call.Args[1].Type == "String" {
+		library = call.Args[1].Value
+	}
+	return NativeAddonDlopen, library, true
+}
+
+// FindNativeAddonModule checks whether importing module, by an import declaration
+// or a call (see FindNativeAddonLoad), loads a native addon: module is a .node file
+// or a module that loads addons. If so, it returns the way the addon is loaded.
+func FindNativeAddonModule(module string) (loadType string, found bool) {
+	switch {
+	case strings.HasSuffix(module, ".node"):
+		return NativeAddonRequire, true
+	case nativeAddonLoaders[module]:
+		return NativeAddonLoader, true
+	}
+	return "", false
+}
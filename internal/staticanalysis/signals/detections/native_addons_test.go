@@ -0,0 +1,76 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindNativeAddonLoad(t *testing.T) {
+	str := func(values ...string) []token.CallArg {
+		var args []token.CallArg
+		for _, v := range values {
+			args = append(args, token.CallArg{Type: "String", Value: v})
+		}
+		return args
+	}
+	tests := []struct {
+		name        string
+		call        token.Call
+		wantType    string
+		wantLibrary string
+		wantFound   bool
+	}{
+		{
+			name: "require of a js module",
+			call: token.Call{Callee: "require", Args: str("./lib/index.js")},
+		},
+		{
+			name:        "require of a .node file",
+			call:        token.Call{Callee: "require", Args: str("./build/Release/addon.node")},
+			wantType:    NativeAddonRequire,
+			wantLibrary: "./build/Release/addon.node",
+			wantFound:   true,
+		},
+		{
+			name:        "import of a .node file",
+			call:        token.Call{Callee: "import", Args: str("../prebuilds/linux-x64/x.node")},
+			wantType:    NativeAddonRequire,
+			wantLibrary: "../prebuilds/linux-x64/x.node",
+			wantFound:   true,
+		},
+		{
+			name:        "require of bindings",
+			call:        token.Call{Callee: "require", Args: str("bindings")},
+			wantType:    NativeAddonLoader,
+			wantLibrary: "bindings",
+			wantFound:   true,
+		},
+		{
+			name:        "dlopen of a shared library",
+			call:        token.Call{Callee: "process.dlopen", Args: []token.CallArg{{Type: "Identifier", Value: "module"}, {Type: "String", Value: "/tmp/.x/lib.so"}}},
+			wantType:    NativeAddonDlopen,
+			wantLibrary: "/tmp/.x/lib.so",
+			wantFound:   true,
+		},
+		{
+			name:      "dlopen through globalThis with a variable path",
+			call:      token.Call{Callee: "globalThis.process.dlopen", Args: []token.CallArg{{Type: "Identifier", Value: "m"}, {Type: "Identifier", Value: "p"}}},
+			wantType:  NativeAddonDlopen,
+			wantFound: true,
+		},
+		{
+			name: "dlopen of another object",
+			call: token.Call{Callee: "lib.process.dlopen", Args: str("x", "y")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadType, library, found := FindNativeAddonLoad(tt.call)
+			if loadType != tt.wantType || library != tt.wantLibrary || found != tt.wantFound {
+				t.Errorf("FindNativeAddonLoad() = %q, %q, %v, want %q, %q, %v",
+					loadType, library, found, tt.wantType, tt.wantLibrary, tt.wantFound)
+			}
+		})
+	}
+}
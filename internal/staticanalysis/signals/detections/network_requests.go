@@ -0,0 +1,129 @@
+package detections
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Sources of the URL of a network request found by FindNetworkRequest.
+const (
+	// StaticURL means that the URL is a constant string.
+	StaticURL = "static"
+
+	// DynamicURL means that the URL is constructed at runtime, e.g. it is held
+	// in a variable, or is concatenated from constant and non-constant parts.
+	DynamicURL = "dynamic"
+
+	// EnvironmentURL means that the URL (or part of it) is read from an
+	// environment variable.
+	EnvironmentURL = "environment"
+)
+
+// networkRequestFunctions are functions (and modules exporting a function) which
+// make an HTTP request to a URL given as the first argument, e.g. fetch(url) or axios(url).
+var networkRequestFunctions = map[string]bool{
+	"fetch":            true,
+	"globalThis.fetch": true,
+	"window.fetch":     true,
+	"self.fetch":       true,
+	"node-fetch":       true,
+	"axios":            true,
+	"got":              true,
+}
+
+// networkClientMethods maps HTTP client modules to their methods which make a request
+// to a URL given as the first argument, e.g. https.get(url) or axios.post(url, data).
+var networkClientMethods = map[string]map[string]bool{
+	"http":   {"request": true, "get": true},
+	"https":  {"request": true, "get": true},
+	"axios":  {"request": true, "get": true, "delete": true, "head": true, "options": true, "post": true, "put": true, "patch": true},
+	"got":    {"get": true, "post": true, "put": true, "patch": true, "delete": true, "head": true, "stream": true},
+	"needle": {"get": true, "head": true, "post": true, "put": true, "patch": true, "delete": true},
+}
+
+// envPrefix is the prefix of the names of environment variables in Node.js.
+const envPrefix = "process.env."
+
+// importCalleePattern matches the name of a call to require or import with a constant
+// module name, e.g. `require("https")`, capturing the module name.
+var importCalleePattern = regexp.MustCompile(`^(?:require|import)\("([^"]*)"\)$`)
+
+// clientModule returns the name of the module that the object of a method call refers
+// to, e.g. "https" for `require("node:https")` or https, and "axios" for this.axios.
+func clientModule(object string) string {
+	if m := importCalleePattern.FindStringSubmatch(object); m != nil {
+		object = m[1]
+	} else if i := strings.LastIndex(object, "."); i >= 0 {
+		object = object[i+1:]
+	}
+	if builtin := NodeBuiltinModule(object); builtin != "" {
+		return builtin
+	}
+	return object
+}
+
+// isNetworkRequest returns whether callee makes an HTTP request to the URL given
+// as its first argument.
+func isNetworkRequest(callee string) bool {
+	if m := importCalleePattern.FindStringSubmatch(callee); m != nil {
+		// e.g. require("node-fetch")(url)
+		return networkRequestFunctions[m[1]]
+	}
+	if networkRequestFunctions[callee] {
+		return true
+	}
+	i := strings.LastIndex(callee, ".")
+	return i >= 0 && networkClientMethods[clientModule(callee[:i])][callee[i+1:]]
+}
+
+/*
+FindNetworkRequest checks whether the given call makes an HTTP request using
+fetch, the http and https modules, or the axios, got and needle clients, e.g.
+fetch(url), https.get(url, callback) or axios.post(url, data). If so, the URL
+passed as the first argument is returned with its source:
+
+  - StaticURL if the URL is a constant string; url is its value.
+  - EnvironmentURL if the URL (or part of it) is read from an environment
+    variable, e.g. fetch(process.env.ENDPOINT).
+  - DynamicURL if the URL is otherwise constructed at runtime, e.g. it is a
+    variable, the result of a call, or a concatenation such as "https://" + host.
+
+For URLs that are (partly) not constant, url is a template in which each
+non-constant part is replaced by ${name}, e.g. "https://${host}/", or "${}" if
+nothing is known about the URL. Requests whose URL comes from an options object
+(e.g. http.request({ host: h })) are not reported.
+
+Requests to a URL built at runtime are much more suspicious than ones to a
+constant URL, since they can hide the destination from static analysis or
+send data (such as environment variables) in the URL.
+*/
+func FindNetworkRequest(call token.Call) (url, source string, found bool) {
+	if !isNetworkRequest(call.Callee) || len(call.Args) == 0 {
+		return "", "", false
+	}
+
+	arg := call.Args[0]
+	switch arg.Type {
+	case "String":
+		return arg.Value, StaticURL, true
+	case "Template", "Concatenation":
+		url = arg.Value
+	case "Identifier", "Member":
+		url = "${" + arg.Value + "}"
+	case "Call":
+		url = "${" + arg.Value + "()}"
+	case "Expression":
+		url = "${}"
+	default:
+		// options objects, callbacks, etc.
+		return "", "", false
+	}
+
+	source = DynamicURL
+	if strings.HasPrefix(arg.Value, envPrefix) || strings.Contains(url, "${"+envPrefix) {
+		source = EnvironmentURL
+	}
+	return url, source, true
+}
@@ -0,0 +1,41 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindNetworkRequest(t *testing.T) {
+	call := func(callee, argType, argValue string) token.Call {
+		return token.Call{Callee: callee, Args: []token.CallArg{{Type: argType, Value: argValue}}}
+	}
+	tests := []struct {
+		name       string
+		call       token.Call
+		wantURL    string
+		wantSource string
+	}{
+		{"static fetch", call("fetch", "String", "https://example.com/"), "https://example.com/", StaticURL},
+		{"variable", call("https.get", "Identifier", "url"), "${url}", DynamicURL},
+		{"template", call("axios.post", "Template", "https://${host}/upload"), "https://${host}/upload", DynamicURL},
+		{"concatenation with env", call("fetch", "Concatenation", "https://x.com/?h=${process.env.HOME}"), "https://x.com/?h=${process.env.HOME}", EnvironmentURL},
+		{"env variable", call(`require("node:http").request`, "Member", "process.env.ENDPOINT"), "${process.env.ENDPOINT}", EnvironmentURL},
+		{"decoded url", call("got", "Call", "atob"), "${atob()}", DynamicURL},
+		{"unknown expression", call("window.fetch", "Expression", ""), "${}", DynamicURL},
+		{"required client", call(`require("node-fetch")`, "Identifier", "u"), "${u}", DynamicURL},
+		{"client member", call("this.needle.post", "String", "https://example.com/"), "https://example.com/", StaticURL},
+		{"options object", call("http.request", "Object", ""), "", ""},
+		{"map get", call("cache.get", "Identifier", "key"), "", ""},
+		{"other method", call("https.createServer", "Identifier", "handler"), "", ""},
+		{"no arguments", token.Call{Callee: "fetch"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotURL, gotSource, found := FindNetworkRequest(tt.call)
+			if gotURL != tt.wantURL || gotSource != tt.wantSource || found != (tt.wantSource != "") {
+				t.Errorf("FindNetworkRequest() = (%q, %q, %v), want (%q, %q)", gotURL, gotSource, found, tt.wantURL, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package detections
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Indicators of packed code found by FindPackedCode.
+const (
+	// PackedEval means code is run with eval or the Function constructor from
+	// the result of a call, e.g. eval(function(p,a,c,k,e,d){...}(...)) or eval(atob(s)).
+	PackedEval = "eval"
+
+	// PackedArrayDispatch means many values are fetched from a string array through
+	// an accessor function with a generated name, e.g. _0x3b2a(0x1f), or the array
+	// is rotated at startup with arr.push(arr.shift()).
+	PackedArrayDispatch = "array_dispatch"
+
+	// PackedStringBuilding means strings are built from character codes, decoded or
+	// substituted into a template, e.g. with String.fromCharCode or the
+	// RegExp('\\b' + e(c) + '\\b') word substitution of Dean Edwards' packer.
+	PackedStringBuilding = "string_building"
+)
+
+// Confidence levels of packed code found by FindPackedCode.
+const (
+	PackedConfidenceLow    = "low"
+	PackedConfidenceMedium = "medium"
+	PackedConfidenceHigh   = "high"
+)
+
+// minArrayDispatchCalls is the number of calls to a string array accessor needed
+// for PackedArrayDispatch. Obfuscators replace every string in the code with such
+// a call, so there are usually many more.
+const minArrayDispatchCalls = 3
+
+// stringDecodingFunctions are functions whose result, when run as code, means the
+// code was rebuilt from an encoded form.
+var stringDecodingFunctions = map[string]bool{
+	"fromCharCode":       true,
+	"atob":               true,
+	"unescape":           true,
+	"decodeURIComponent": true,
+	"join":               true,
+	"replace":            true,
+}
+
+// PackedCodeMatch describes packed code found in a file. Indicators lists which of
+// PackedEval, PackedArrayDispatch and PackedStringBuilding were found (in that
+// order), Confidence is one of the PackedConfidence levels and Call is the eval
+// call, or the first string array access if there is none.
+type PackedCodeMatch struct {
+	Indicators []string
+	Confidence string
+	Call       token.Call
+}
+
+/*
+FindPackedCode checks whether the calls made in a file are typical of packed or
+self-extracting code, which rebuilds its real source at runtime, and if so, returns
+the indicators found and how confident the classification is.
+
+Each indicator on its own occurs in ordinary code, so the confidence depends on how
+they are combined:
+  - high: code rebuilt by a call is run with eval (or Function), and the file also
+    dispatches through a string array or builds strings from pieces. This is the
+    output of classic packers, e.g. eval(function(p,a,c,k,e,d){...}).
+  - medium: strings are fetched from a string array and built from pieces, but not
+    evaluated, as in the output of javascript-obfuscator.
+  - low: only one of eval of rebuilt code or string array dispatch is found.
+
+Minified bundles rename identifiers to short names rather than generated hex
+names, and do not eval the result of calls, so are not matched. Building strings
+alone is too common to be reported.
+*/
+func FindPackedCode(calls []token.Call) (PackedCodeMatch, bool) {
+	var evalCall, dispatchCall *token.Call
+	dispatchCalls := 0
+	building := false
+	for i, call := range calls {
+		if target, found := packedEvalTarget(call); found {
+			if evalCall == nil {
+				evalCall = &calls[i]
+			}
+			if stringDecodingFunctions[target] {
+				building = true
+			}
+		}
+		if isArrayRotation(call) {
+			dispatchCalls = minArrayDispatchCalls
+			if dispatchCall == nil {
+				dispatchCall = &calls[i]
+			}
+		} else if isArrayDispatch(call) {
+			dispatchCalls++
+			if dispatchCall == nil {
+				dispatchCall = &calls[i]
+			}
+		}
+		building = building || isStringBuilding(call)
+	}
+	dispatch := dispatchCalls >= minArrayDispatchCalls
+
+	var m PackedCodeMatch
+	switch {
+	case evalCall != nil && (dispatch || building):
+		m.Confidence = PackedConfidenceHigh
+	case dispatch && building:
+		m.Confidence = PackedConfidenceMedium
+	case evalCall != nil || dispatch:
+		m.Confidence = PackedConfidenceLow
+	default:
+		return PackedCodeMatch{}, false
+	}
+
+	if evalCall != nil {
+		m.Indicators = append(m.Indicators, PackedEval)
+		m.Call = *evalCall
+	}
+	if dispatch {
+		m.Indicators = append(m.Indicators, PackedArrayDispatch)
+		if evalCall == nil {
+			m.Call = *dispatchCall
+		}
+	}
+	if building {
+		m.Indicators = append(m.Indicators, PackedStringBuilding)
+	}
+	return m, true
+}
+
+// packedEvalTarget checks whether call runs code which is the result of another
+// call with eval or the Function constructor (in any form, including indirect
+// calls), and if so, returns the name of the function that produced the code,
+// which is empty if it could not be determined (e.g. for an inline function).
+func packedEvalTarget(call token.Call) (string, bool) {
+	parts := strings.Split(call.Callee, ".")
+	target := parts[len(parts)-1]
+	if !dynamicExecutionFunctions[target] || len(call.Args) == 0 {
+		return "", false
+	}
+	for _, objectName := range parts[:len(parts)-1] {
+		if !globalObjectNames[objectName] {
+			return "", false
+		}
+	}
+
+	// the code is the only argument of eval and the last argument of Function
+	code := call.Args[len(call.Args)-1]
+	if target == "eval" {
+		code = call.Args[0]
+	}
+	if code.Type != "Call" {
+		return "", false
+	}
+	if i := strings.LastIndex(code.Value, "."); i >= 0 {
+		return code.Value[i+1:], true
+	}
+	return code.Value, true
+}
+
+// isArrayDispatch returns whether call fetches a value from a string array through
+// an accessor with a generated hex name, e.g. _0x3b2a(0x1f) or _0x3b2a("0x1f", "key").
+func isArrayDispatch(call token.Call) bool {
+	if call.New || len(call.Args) == 0 || len(call.Args) > 2 {
+		return false
+	}
+	if !strings.HasPrefix(call.Callee, "_0x") || !hexIdentifier.MatchString(call.Callee) {
+		return false
+	}
+	index := call.Args[0]
+	switch index.Type {
+	case "Numeric":
+		return true
+	case "String":
+		digits, found := strings.CutPrefix(index.Value, "0x")
+		if !found {
+			return false
+		}
+		_, err := strconv.ParseUint(digits, 16, 64)
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// isArrayRotation returns whether call rotates an array by moving its first element
+// to the end, i.e. arr.push(arr.shift()), which obfuscators run at startup so that
+// the strings in the code are not in the order they are used.
+func isArrayRotation(call token.Call) bool {
+	array, found := strings.CutSuffix(call.Callee, ".push")
+	if !found || array == "" || len(call.Args) != 1 {
+		return false
+	}
+	arg := call.Args[0]
+	return arg.Type == "Call" && arg.Value == array+".shift"
+}
+
+// isStringBuilding returns whether call builds a string from character codes or by
+// substituting words into a template, in the way packers rebuild code.
+func isStringBuilding(call token.Call) bool {
+	if call.Callee == "String.fromCharCode" {
+		return true
+	}
+	if call.Callee == "RegExp" && len(call.Args) > 0 {
+		// the word substitution of Dean Edwards' packer: RegExp('\\b' + e(c) + '\\b', 'g')
+		arg := call.Args[0]
+		return arg.Type == "Concatenation" && strings.HasPrefix(arg.Value, `\b${`) && strings.HasSuffix(arg.Value, `}\b`)
+	}
+	return false
+}
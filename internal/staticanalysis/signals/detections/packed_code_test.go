@@ -0,0 +1,150 @@
+package detections
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindPackedCode(t *testing.T) {
+	packerEval := token.Call{Callee: "eval", Args: []token.CallArg{{Type: "Call", Value: ""}}}
+	dispatch := func(index token.CallArg) token.Call {
+		return token.Call{Callee: "_0x2b3c", Args: []token.CallArg{index}}
+	}
+	tests := []struct {
+		name           string
+		calls          []token.Call
+		wantIndicators []string
+		wantConfidence string
+		wantCall       token.Call
+		wantFound      bool
+	}{
+		{
+			name: "dean edwards packer",
+			calls: []token.Call{
+				packerEval,
+				{Callee: "c.toString", Args: []token.CallArg{{Type: "Numeric", Value: "36"}}},
+				{Callee: "RegExp", Args: []token.CallArg{{Type: "Concatenation", Value: `\b${e()}\b`}, {Type: "String", Value: "g"}}},
+			},
+			wantIndicators: []string{PackedEval, PackedStringBuilding},
+			wantConfidence: PackedConfidenceHigh,
+			wantCall:       packerEval,
+			wantFound:      true,
+		},
+		{
+			name: "eval of decoded string",
+			calls: []token.Call{
+				{Callee: "eval", Args: []token.CallArg{{Type: "Call", Value: "atob"}}},
+			},
+			wantIndicators: []string{PackedEval, PackedStringBuilding},
+			wantConfidence: PackedConfidenceHigh,
+			wantCall:       token.Call{Callee: "eval", Args: []token.CallArg{{Type: "Call", Value: "atob"}}},
+			wantFound:      true,
+		},
+		{
+			name: "Function of joined array",
+			calls: []token.Call{
+				{Callee: "Function", New: true, Args: []token.CallArg{{Type: "String", Value: "a"}, {Type: "Call", Value: "parts.join"}}},
+			},
+			wantIndicators: []string{PackedEval, PackedStringBuilding},
+			wantConfidence: PackedConfidenceHigh,
+			wantCall:       token.Call{Callee: "Function", New: true, Args: []token.CallArg{{Type: "String", Value: "a"}, {Type: "Call", Value: "parts.join"}}},
+			wantFound:      true,
+		},
+		{
+			name: "eval of string array access",
+			calls: []token.Call{
+				{Callee: "window.eval", Args: []token.CallArg{{Type: "Call", Value: "_0x2b3c"}}},
+				dispatch(token.CallArg{Type: "Numeric", Value: "1"}),
+				dispatch(token.CallArg{Type: "Numeric", Value: "2"}),
+				dispatch(token.CallArg{Type: "Numeric", Value: "3"}),
+			},
+			wantIndicators: []string{PackedEval, PackedArrayDispatch},
+			wantConfidence: PackedConfidenceHigh,
+			wantCall:       token.Call{Callee: "window.eval", Args: []token.CallArg{{Type: "Call", Value: "_0x2b3c"}}},
+			wantFound:      true,
+		},
+		{
+			name: "obfuscator string array",
+			calls: []token.Call{
+				{Callee: "_0x3c4d.push", Args: []token.CallArg{{Type: "Call", Value: "_0x3c4d.shift"}}},
+				dispatch(token.CallArg{Type: "String", Value: "0x0"}),
+				{Callee: "String.fromCharCode", Args: []token.CallArg{{Type: "Identifier", Value: "c"}}},
+			},
+			wantIndicators: []string{PackedArrayDispatch, PackedStringBuilding},
+			wantConfidence: PackedConfidenceMedium,
+			wantCall:       token.Call{Callee: "_0x3c4d.push", Args: []token.CallArg{{Type: "Call", Value: "_0x3c4d.shift"}}},
+			wantFound:      true,
+		},
+		{
+			name: "string array dispatch only",
+			calls: []token.Call{
+				dispatch(token.CallArg{Type: "String", Value: "0x0"}),
+				dispatch(token.CallArg{Type: "String", Value: "0x1"}),
+				{Callee: "_0x2b3c", Args: []token.CallArg{{Type: "Numeric", Value: "18"}, {Type: "String", Value: "key"}}},
+			},
+			wantIndicators: []string{PackedArrayDispatch},
+			wantConfidence: PackedConfidenceLow,
+			wantCall:       dispatch(token.CallArg{Type: "String", Value: "0x0"}),
+			wantFound:      true,
+		},
+		{
+			name: "too few string array accesses",
+			calls: []token.Call{
+				dispatch(token.CallArg{Type: "Numeric", Value: "1"}),
+				dispatch(token.CallArg{Type: "Numeric", Value: "2"}),
+				{Callee: "String.fromCharCode", Args: []token.CallArg{{Type: "Identifier", Value: "c"}}},
+			},
+			wantFound: false,
+		},
+		{
+			name: "hex named function with non-index arguments",
+			calls: []token.Call{
+				dispatch(token.CallArg{Type: "String", Value: "abc"}),
+				dispatch(token.CallArg{Type: "Identifier", Value: "x"}),
+				dispatch(token.CallArg{Type: "String", Value: "0xzz"}),
+			},
+			wantFound: false,
+		},
+		{
+			name: "minified bundle",
+			calls: []token.Call{
+				{Callee: "Function", Args: []token.CallArg{{Type: "String", Value: "return this"}}},
+				{Callee: "eval", Args: []token.CallArg{{Type: "String", Value: "require"}}},
+				{Callee: "Function", Args: []token.CallArg{{Type: "Identifier", Value: "n"}, {Type: "Concatenation", Value: "return ${s}"}}},
+				{Callee: "e.push", Args: []token.CallArg{{Type: "Call", Value: "t.shift"}}},
+				{Callee: "a", Args: []token.CallArg{{Type: "Numeric", Value: "1"}}},
+				{Callee: "String.fromCharCode", Args: []token.CallArg{{Type: "Identifier", Value: "c"}}},
+			},
+			wantFound: false,
+		},
+		{
+			name: "method named eval",
+			calls: []token.Call{
+				{Callee: "vm.eval", Args: []token.CallArg{{Type: "Call", Value: "atob"}}},
+			},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindPackedCode(tt.calls)
+			if found != tt.wantFound {
+				t.Fatalf("FindPackedCode() found = %v, want %v", found, tt.wantFound)
+			}
+			if !tt.wantFound {
+				return
+			}
+			if !reflect.DeepEqual(got.Indicators, tt.wantIndicators) {
+				t.Errorf("FindPackedCode() indicators = %v, want %v", got.Indicators, tt.wantIndicators)
+			}
+			if got.Confidence != tt.wantConfidence {
+				t.Errorf("FindPackedCode() confidence = %v, want %v", got.Confidence, tt.wantConfidence)
+			}
+			if !reflect.DeepEqual(got.Call, tt.wantCall) {
+				t.Errorf("FindPackedCode() call = %v, want %v", got.Call, tt.wantCall)
+			}
+		})
+	}
+}
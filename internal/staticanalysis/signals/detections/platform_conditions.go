@@ -0,0 +1,58 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// SignificantGatedLength is the minimum number of characters of source code gated by
+// a platform condition for it to be reported as significant. Shorter branches are
+// typically used for portability, e.g. to choose a path separator or the name of an
+// executable, rather than to run a separate payload.
+const SignificantGatedLength = 200
+
+// platforms are the possible values of process.platform and os.platform().
+var platforms = map[string]bool{
+	"aix":     true,
+	"android": true,
+	"cygwin":  true,
+	"darwin":  true,
+	"freebsd": true,
+	"linux":   true,
+	"netbsd":  true,
+	"openbsd": true,
+	"sunos":   true,
+	"win32":   true,
+}
+
+// osTypePlatforms maps values of os.type() to the corresponding value of process.platform.
+var osTypePlatforms = map[string]string{
+	"AIX":        "aix",
+	"Darwin":     "darwin",
+	"FreeBSD":    "freebsd",
+	"Linux":      "linux",
+	"NetBSD":     "netbsd",
+	"OpenBSD":    "openbsd",
+	"SunOS":      "sunos",
+	"Windows_NT": "win32",
+}
+
+/*
+FindPlatformCondition checks whether the given condition compares the operating system
+that the code is running on with a known platform, e.g. process.platform === "win32" or
+os.type() === "Windows_NT". If so, the platform is returned as a value of process.platform
+(e.g. "win32"), along with whether the code gated by the condition is at least
+SignificantGatedLength characters long.
+*/
+func FindPlatformCondition(c token.Condition) (platform string, significant bool, found bool) {
+	if strings.HasSuffix(c.Subject, ".type()") {
+		platform, found = osTypePlatforms[c.Value]
+	} else if c.Subject == "process.platform" || strings.HasSuffix(c.Subject, ".platform()") {
+		platform, found = c.Value, platforms[c.Value]
+	}
+	if !found {
+		return "", false, false
+	}
+	return platform, c.GatedLength >= SignificantGatedLength, true
+}
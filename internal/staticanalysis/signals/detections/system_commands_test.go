@@ -0,0 +1,28 @@
+package detections
+
+import "testing"
+
+func TestIsSystemCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"ls", true},
+		{"git", true},
+		{"npm", true},
+		{"Node", true},
+		{"git.exe", true},
+		{"bin/sudo", true},
+		{"left-pad", false},
+		{"gitx", false},
+		{"tsc", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsSystemCommand(tt.name); got != tt.want {
+				t.Errorf("IsSystemCommand(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Types of ThreadUsage.
+const (
+	// ThreadWorker is the creation of a thread with new Worker(...), either a
+	// Web Worker or a worker_threads Worker, or with new SharedWorker(...).
+	ThreadWorker = "worker"
+	// ThreadWorkerThreads is an import of the Node.js worker_threads module.
+	ThreadWorkerThreads = "worker_threads"
+	// ThreadSharedMemory is the use of memory shared between threads, i.e. a
+	// SharedArrayBuffer or the Atomics functions that operate on it.
+	ThreadSharedMemory = "shared_memory"
+)
+
+// Possible sources of the code run by a Worker.
+const (
+	// WorkerSourceInline means that the code is embedded in the source, e.g. as
+	// a string evaluated with { eval: true }, or a Blob or data: URL.
+	WorkerSourceInline = "inline"
+	// WorkerSourceFile means that the code is loaded from a constant path or URL.
+	WorkerSourceFile = "file"
+	// WorkerSourceDynamic means that the code (or where to load it from) is
+	// built at runtime, e.g. passed in a variable.
+	WorkerSourceDynamic = "dynamic"
+)
+
+// workerConstructors are the constructors that start a thread running a script.
+var workerConstructors = map[string]bool{
+	"Worker":       true,
+	"SharedWorker": true,
+}
+
+// workerScriptExtensions are the file extensions of scripts that a Worker may run.
+var workerScriptExtensions = []string{".js", ".mjs", ".cjs", ".ts"}
+
+// workerFileFunctions are (the final part of the names of) functions which return
+// the path or URL of a file, e.g. new URL("./worker.js", import.meta.url) or
+// path.join(__dirname, "worker.js").
+var workerFileFunctions = map[string]bool{
+	"URL":     true,
+	"join":    true,
+	"resolve": true,
+}
+
+/*
+FindThreadUsage checks whether the given call creates a thread or uses memory shared
+between threads, either of which can be used to run a payload (e.g. a crypto miner)
+away from the main thread, where it is less likely to be seen. If so, the type of
+usage (one of the Thread constants) and the API used are returned.
+
+For Workers, source is the likely source of the code run by the thread (one of the
+WorkerSource constants), determined from the first argument.
+*/
+func FindThreadUsage(call token.Call) (usageType, api, source string, found bool) {
+	if module := ImportedModule(call); IsWorkerThreadsModule(module) {
+		return ThreadWorkerThreads, module, "", true
+	}
+
+	parts := strings.Split(call.Callee, ".")
+	name := parts[len(parts)-1]
+	if call.New && workerConstructors[name] {
+		return ThreadWorker, call.Callee, workerSource(call.Args), true
+	}
+
+	for _, objectName := range parts[:len(parts)-1] {
+		if !globalObjectNames[objectName] && objectName != "Atomics" {
+			return "", "", "", false
+		}
+	}
+	if name == "SharedArrayBuffer" || (len(parts) > 1 && parts[len(parts)-2] == "Atomics") {
+		return ThreadSharedMemory, call.Callee, "", true
+	}
+	return "", "", "", false
+}
+
+// IsWorkerThreadsModule returns whether module refers to the Node.js worker_threads
+// module, whose import is a ThreadUsage of type ThreadWorkerThreads.
+func IsWorkerThreadsModule(module string) bool {
+	return NodeBuiltinModule(module) == "worker_threads"
+}
+
+func workerSource(args []token.CallArg) string {
+	if len(args) == 0 {
+		return WorkerSourceDynamic
+	}
+	arg := args[0]
+	switch arg.Type {
+	case "String":
+		if strings.HasPrefix(arg.Value, "data:") || strings.HasPrefix(arg.Value, "blob:") || looksLikeCode(arg.Value) {
+			return WorkerSourceInline
+		}
+		return WorkerSourceFile
+	case "Template", "Concatenation":
+		// e.g. `${__dirname}/worker.js`
+		for _, ext := range workerScriptExtensions {
+			if strings.HasSuffix(arg.Value, ext) {
+				return WorkerSourceFile
+			}
+		}
+	case "Identifier":
+		if arg.Value == "__filename" {
+			return WorkerSourceFile
+		}
+	case "Call":
+		switch {
+		case strings.HasSuffix(arg.Value, "createObjectURL"):
+			// URL.createObjectURL(new Blob([code]))
+			return WorkerSourceInline
+		case workerFileFunctions[arg.Value[strings.LastIndex(arg.Value, ".")+1:]]:
+			return WorkerSourceFile
+		}
+	}
+	return WorkerSourceDynamic
+}
+
+// looksLikeCode returns whether s is more likely to be JavaScript code than
+// the path or URL of a script.
+func looksLikeCode(s string) bool {
+	return strings.ContainsAny(s, "(){};=\n")
+}
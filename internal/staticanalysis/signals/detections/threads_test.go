@@ -0,0 +1,128 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindThreadUsage(t *testing.T) {
+	arg := func(typ, value string) []token.CallArg {
+		return []token.CallArg{{Type: typ, Value: value}}
+	}
+	tests := []struct {
+		name       string
+		call       token.Call
+		wantType   string
+		wantAPI    string
+		wantSource string
+		wantFound  bool
+	}{
+		{
+			name:      "unrelated call",
+			call:      token.Call{Callee: "console.log", Args: arg("String", "hi")},
+			wantFound: false,
+		},
+		{
+			name:      "Worker without new",
+			call:      token.Call{Callee: "Worker", Args: arg("String", "worker.js")},
+			wantFound: false,
+		},
+		{
+			name:       "worker from file",
+			call:       token.Call{Callee: "Worker", New: true, Args: arg("String", "./worker.js")},
+			wantType:   ThreadWorker,
+			wantAPI:    "Worker",
+			wantSource: WorkerSourceFile,
+			wantFound:  true,
+		},
+		{
+			name:       "worker from path built from dirname",
+			call:       token.Call{Callee: `require("worker_threads").Worker`, New: true, Args: arg("Template", "${__dirname}/worker.mjs")},
+			wantType:   ThreadWorker,
+			wantAPI:    `require("worker_threads").Worker`,
+			wantSource: WorkerSourceFile,
+			wantFound:  true,
+		},
+		{
+			name:       "worker from URL",
+			call:       token.Call{Callee: "Worker", New: true, Args: arg("Call", "URL")},
+			wantType:   ThreadWorker,
+			wantAPI:    "Worker",
+			wantSource: WorkerSourceFile,
+			wantFound:  true,
+		},
+		{
+			name:       "worker from eval string",
+			call:       token.Call{Callee: "Worker", New: true, Args: arg("String", "require('child_process').exec('sh')")},
+			wantType:   ThreadWorker,
+			wantAPI:    "Worker",
+			wantSource: WorkerSourceInline,
+			wantFound:  true,
+		},
+		{
+			name:       "shared worker from blob",
+			call:       token.Call{Callee: "window.SharedWorker", New: true, Args: arg("Call", "URL.createObjectURL")},
+			wantType:   ThreadWorker,
+			wantAPI:    "window.SharedWorker",
+			wantSource: WorkerSourceInline,
+			wantFound:  true,
+		},
+		{
+			name:       "worker from variable",
+			call:       token.Call{Callee: "Worker", New: true, Args: arg("Identifier", "src")},
+			wantType:   ThreadWorker,
+			wantAPI:    "Worker",
+			wantSource: WorkerSourceDynamic,
+			wantFound:  true,
+		},
+		{
+			name:       "worker from concatenation",
+			call:       token.Call{Callee: "Worker", New: true, Args: arg("Concatenation", "${prefix}${payload}")},
+			wantType:   ThreadWorker,
+			wantAPI:    "Worker",
+			wantSource: WorkerSourceDynamic,
+			wantFound:  true,
+		},
+		{
+			name:      "require worker_threads",
+			call:      token.Call{Callee: "require", Args: arg("String", "node:worker_threads")},
+			wantType:  ThreadWorkerThreads,
+			wantAPI:   "node:worker_threads",
+			wantFound: true,
+		},
+		{
+			name:      "require other module",
+			call:      token.Call{Callee: "require", Args: arg("String", "fs")},
+			wantFound: false,
+		},
+		{
+			name:      "new SharedArrayBuffer",
+			call:      token.Call{Callee: "SharedArrayBuffer", New: true, Args: arg("Numeric", "1024")},
+			wantType:  ThreadSharedMemory,
+			wantAPI:   "SharedArrayBuffer",
+			wantFound: true,
+		},
+		{
+			name:      "Atomics function",
+			call:      token.Call{Callee: "globalThis.Atomics.wait", Args: arg("Identifier", "view")},
+			wantType:  ThreadSharedMemory,
+			wantAPI:   "globalThis.Atomics.wait",
+			wantFound: true,
+		},
+		{
+			name:      "method of another object",
+			call:      token.Call{Callee: "lib.Atomics.wait"},
+			wantFound: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotType, gotAPI, gotSource, gotFound := FindThreadUsage(test.call)
+			if gotType != test.wantType || gotAPI != test.wantAPI || gotSource != test.wantSource || gotFound != test.wantFound {
+				t.Errorf("FindThreadUsage() = (%q, %q, %q, %v), want (%q, %q, %q, %v)",
+					gotType, gotAPI, gotSource, gotFound, test.wantType, test.wantAPI, test.wantSource, test.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package detections
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Cryptocurrencies whose wallet addresses are detected by FindWalletAddresses.
+const (
+	CurrencyBitcoin  = "bitcoin"
+	CurrencyLitecoin = "litecoin"
+	CurrencyDogecoin = "dogecoin"
+	CurrencyTron     = "tron"
+	CurrencyEthereum = "ethereum"
+	CurrencyMonero   = "monero"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var (
+	// base58Address matches candidate Base58Check-encoded addresses, which are
+	// validated further using their checksum (see decodeBase58Check).
+	base58Address = regexp.MustCompile(`\b[13LMDT][` + base58Alphabet + `]{25,34}\b`)
+	// bech32Address matches candidate (lowercase) segwit addresses, which are
+	// validated further using their checksum (see validBech32).
+	bech32Address = regexp.MustCompile(`\b(?:bc|ltc)1[ac-hj-np-z02-9]{11,71}\b`)
+	// ethereumAddress matches candidate 20-byte hex addresses, as used by Ethereum
+	// and other EVM-based chains, which are validated further using their mixed-case
+	// checksum (see validEIP55) or the rest of the string (see ethereumContext).
+	ethereumAddress = regexp.MustCompile(`\b0x[[:xdigit:]]{40}\b`)
+	// ethereumContext matches words which show that a 20-byte hex constant without
+	// a checksum is used as a wallet address, rather than e.g. as a hash or a key.
+	ethereumContext = regexp.MustCompile(`(?i)\b(?:wallets?|address(?:es)?|addr|recipient|receiver|eth|ether|ethereum|erc-?20|usdt|bsc|bnb)\b`)
+	// moneroAddress matches standard Monero addresses. These have no simple
+	// checksum, but their length and prefix are distinctive.
+	moneroAddress = regexp.MustCompile(`\b4[0-9AB][` + base58Alphabet + `]{93}\b`)
+)
+
+// base58Versions maps the version byte of a Base58Check address to its currency.
+var base58Versions = map[byte]string{
+	0x00: CurrencyBitcoin, // P2PKH, '1' prefix
+	0x05: CurrencyBitcoin, // P2SH, '3' prefix
+	0x30: CurrencyLitecoin,
+	0x32: CurrencyLitecoin,
+	0x1e: CurrencyDogecoin,
+	0x41: CurrencyTron,
+}
+
+// bech32Currencies maps the human-readable part of a segwit address to its currency.
+var bech32Currencies = map[string]string{
+	"bc":  CurrencyBitcoin,
+	"ltc": CurrencyLitecoin,
+}
+
+// WalletAddressMatch is a cryptocurrency wallet address found in a string.
+type WalletAddressMatch struct {
+	Currency string
+	Address  string
+}
+
+/*
+FindWalletAddresses returns the cryptocurrency wallet addresses found in the given
+string. Hardcoded wallet addresses are a sign of 'clipper' malware, which replaces
+addresses copied to the clipboard (or written to output) with the attacker's own.
+
+Bitcoin, Litecoin, Dogecoin and Tron addresses are only returned if their checksum
+is valid, which makes false positives very unlikely. Ethereum (and other EVM-based)
+addresses are returned if their EIP-55 mixed-case checksum is valid, or, since the
+checksum is optional, if the rest of the string shows that they are used as an address
+(e.g. "wallet" or "ETH"); other 20-byte hex constants, such as hashes, are not. Monero
+addresses are matched on their format alone.
+*/
+func FindWalletAddresses(s string) []WalletAddressMatch {
+	var addresses []WalletAddressMatch
+	for _, candidate := range base58Address.FindAllString(s, -1) {
+		version, valid := decodeBase58Check(candidate)
+		if currency, ok := base58Versions[version]; valid && ok {
+			addresses = append(addresses, WalletAddressMatch{Currency: currency, Address: candidate})
+		}
+	}
+	for _, candidate := range bech32Address.FindAllString(s, -1) {
+		hrp, _, _ := strings.Cut(candidate, "1")
+		if validBech32(candidate) {
+			addresses = append(addresses, WalletAddressMatch{Currency: bech32Currencies[hrp], Address: candidate})
+		}
+	}
+	for _, candidate := range ethereumAddress.FindAllString(s, -1) {
+		if validEIP55(candidate) || ethereumContext.MatchString(strings.ReplaceAll(s, candidate, " ")) {
+			addresses = append(addresses, WalletAddressMatch{Currency: CurrencyEthereum, Address: candidate})
+		}
+	}
+	for _, candidate := range moneroAddress.FindAllString(s, -1) {
+		addresses = append(addresses, WalletAddressMatch{Currency: CurrencyMonero, Address: candidate})
+	}
+	return addresses
+}
+
+// decodeBase58 decodes a Base58 string, returning nil if it contains invalid characters.
+func decodeBase58(s string) []byte {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return nil
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	decoded := n.Bytes()
+	// leading '1's represent leading zero bytes
+	leadingZeros := len(s) - len(strings.TrimLeft(s, "1"))
+	return append(make([]byte, leadingZeros), decoded...)
+}
+
+// decodeBase58Check decodes a 25 byte Base58Check address (a version byte, 20 byte
+// hash and 4 byte checksum), and returns its version byte if the checksum is valid.
+func decodeBase58Check(s string) (version byte, valid bool) {
+	decoded := decodeBase58(s)
+	if len(decoded) != 25 {
+		return 0, false
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	return decoded[0], bytes.Equal(second[:4], decoded[21:])
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// validBech32 returns whether the given lowercase segwit address has a valid
+// bech32 (BIP 173) or bech32m (BIP 350) checksum.
+func validBech32(s string) bool {
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || len(s)-sep < 7 {
+		return false
+	}
+	hrp, data := s[:sep], s[sep+1:]
+
+	values := make([]byte, 0, 2*len(hrp)+1+len(data))
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	for _, r := range data {
+		i := strings.IndexRune(bech32Charset, r)
+		if i < 0 {
+			return false
+		}
+		values = append(values, byte(i))
+	}
+
+	polymod := bech32Polymod(values)
+	return polymod == 1 || polymod == 0x2bc830a3
+}
+
+// validEIP55 returns whether the given Ethereum address ("0x" and 40 hex digits) has
+// a valid EIP-55 checksum, in which each letter is uppercase if the corresponding
+// nibble of the Keccak-256 hash of the lowercase address is 8 or more. Addresses in
+// a single case have no checksum, and are very unlikely to pass.
+func validEIP55(address string) bool {
+	digits := address[2:]
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(strings.ToLower(digits)))
+	hash := h.Sum(nil)
+	for i := 0; i < len(digits); i++ {
+		c := digits[i]
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0xf
+		}
+		if (nibble >= 8) != (c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
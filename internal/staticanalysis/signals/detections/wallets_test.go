@@ -0,0 +1,92 @@
+package detections
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindWalletAddresses(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []WalletAddressMatch
+	}{
+		{
+			name:  "no addresses",
+			input: "hello world 1234567890",
+			want:  nil,
+		},
+		{
+			name:  "bitcoin P2PKH",
+			input: "send to 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa now",
+			want:  []WalletAddressMatch{{Currency: CurrencyBitcoin, Address: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}},
+		},
+		{
+			name:  "bitcoin P2SH",
+			input: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+			want:  []WalletAddressMatch{{Currency: CurrencyBitcoin, Address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"}},
+		},
+		{
+			name:  "base58 with bad checksum",
+			input: "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb",
+			want:  nil,
+		},
+		{
+			name:  "bitcoin segwit",
+			input: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+			want:  []WalletAddressMatch{{Currency: CurrencyBitcoin, Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}},
+		},
+		{
+			name:  "bitcoin taproot",
+			input: "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297",
+			want:  []WalletAddressMatch{{Currency: CurrencyBitcoin, Address: "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297"}},
+		},
+		{
+			name:  "segwit with bad checksum",
+			input: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdg",
+			want:  nil,
+		},
+		{
+			name:  "litecoin, dogecoin and tron",
+			input: "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9,DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L,TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t",
+			want: []WalletAddressMatch{
+				{Currency: CurrencyLitecoin, Address: "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9"},
+				{Currency: CurrencyDogecoin, Address: "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L"},
+				{Currency: CurrencyTron, Address: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+			},
+		},
+		{
+			name:  "ethereum",
+			input: `{"to": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"}`,
+			want:  []WalletAddressMatch{{Currency: CurrencyEthereum, Address: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"}},
+		},
+		{
+			name:  "ethereum with bad checksum",
+			input: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+			want:  nil,
+		},
+		{
+			// e.g. a hash or a key, rather than an address
+			name:  "hex constant without checksum",
+			input: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			want:  nil,
+		},
+		{
+			name:  "ethereum without checksum used as an address",
+			input: "send ETH to wallet 0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			want:  []WalletAddressMatch{{Currency: CurrencyEthereum, Address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}},
+		},
+		{
+			name:  "longer hex string is not an address",
+			input: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e00",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindWalletAddresses(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindWalletAddresses(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
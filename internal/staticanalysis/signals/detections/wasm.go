@@ -0,0 +1,107 @@
+package detections
+
+import (
+	"strings"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Possible sources of the WebAssembly module bytes passed to a WebAssembly API call.
+const (
+	// WasmSourceInline means that the bytes are embedded in the source code,
+	// e.g. as an array literal or as a string decoded with Buffer.from or atob.
+	WasmSourceInline = "inline"
+	// WasmSourceFetched means that the bytes are fetched from a network resource.
+	WasmSourceFetched = "fetched"
+	// WasmSourceFile means that the bytes are read from a file.
+	WasmSourceFile = "file"
+	// WasmSourceUnknown means that the source of the bytes could not be determined,
+	// e.g. because they were passed in a variable.
+	WasmSourceUnknown = "unknown"
+)
+
+// wasmFunctions are the WebAssembly API functions that compile or instantiate a module.
+// Streaming functions take a fetch Response (or promise of one) rather than bytes.
+var wasmFunctions = map[string]struct{ streaming bool }{
+	"instantiate":          {},
+	"compile":              {},
+	"Module":               {},
+	"instantiateStreaming": {streaming: true},
+	"compileStreaming":     {streaming: true},
+}
+
+// inlineByteSources are functions and constructors which create bytes from literal data.
+var inlineByteSources = map[string]bool{
+	"atob":            true,
+	"Buffer":          true,
+	"Buffer.from":     true,
+	"Uint8Array":      true,
+	"Uint8Array.from": true,
+	"Uint8Array.of":   true,
+}
+
+// fetchFunctions are functions which fetch network resources.
+var fetchFunctions = map[string]bool{
+	"fetch":            true,
+	"globalThis.fetch": true,
+	"window.fetch":     true,
+	"self.fetch":       true,
+}
+
+// fileReadFunctions are (the final part of the names of) functions which read files.
+var fileReadFunctions = map[string]bool{
+	"readFile":     true,
+	"readFileSync": true,
+}
+
+/*
+FindWasmInstantiation checks whether the given call compiles or instantiates a
+WebAssembly module, i.e. is a call to WebAssembly.instantiate, WebAssembly.compile,
+their streaming variants, or the WebAssembly.Module constructor. If so, the name
+of the WebAssembly function is returned, together with the likely source of the
+module bytes (one of the WasmSource constants), determined from the first argument.
+*/
+func FindWasmInstantiation(call token.Call) (function string, source string, found bool) {
+	parts := strings.Split(call.Callee, ".")
+	if len(parts) < 2 || parts[len(parts)-2] != "WebAssembly" {
+		return "", "", false
+	}
+	for _, objectName := range parts[:len(parts)-2] {
+		if !globalObjectNames[objectName] {
+			return "", "", false
+		}
+	}
+
+	function = parts[len(parts)-1]
+	info, ok := wasmFunctions[function]
+	if !ok {
+		return "", "", false
+	}
+
+	if info.streaming {
+		return function, WasmSourceFetched, true
+	}
+	if len(call.Args) == 0 {
+		return function, WasmSourceUnknown, true
+	}
+
+	return function, wasmArgumentSource(call.Args[0]), true
+}
+
+func wasmArgumentSource(arg token.CallArg) string {
+	switch arg.Type {
+	case "Array", "String", "Template":
+		return WasmSourceInline
+	case "Call":
+		switch {
+		case inlineByteSources[arg.Value]:
+			return WasmSourceInline
+		case fetchFunctions[arg.Value] || strings.HasSuffix(arg.Value, ".arrayBuffer"):
+			// Response.arrayBuffer() reads the body of a fetched resource
+			return WasmSourceFetched
+		case fileReadFunctions[arg.Value[strings.LastIndex(arg.Value, ".")+1:]]:
+			return WasmSourceFile
+		}
+	}
+	return WasmSourceUnknown
+}
@@ -0,0 +1,101 @@
+package detections
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestFindWasmInstantiation(t *testing.T) {
+	arg := func(typ, value string) []token.CallArg {
+		return []token.CallArg{{Type: typ, Value: value}}
+	}
+	tests := []struct {
+		name         string
+		call         token.Call
+		wantFunction string
+		wantSource   string
+		wantFound    bool
+	}{
+		{
+			name:      "unrelated call",
+			call:      token.Call{Callee: "console.log", Args: arg("String", "hi")},
+			wantFound: false,
+		},
+		{
+			name:      "non-WebAssembly instantiate",
+			call:      token.Call{Callee: "foo.instantiate"},
+			wantFound: false,
+		},
+		{
+			name:      "validate is not instantiation",
+			call:      token.Call{Callee: "WebAssembly.validate", Args: arg("Identifier", "bytes")},
+			wantFound: false,
+		},
+		{
+			name:         "instantiate from base64 buffer",
+			call:         token.Call{Callee: "WebAssembly.instantiate", Args: arg("Call", "Buffer.from")},
+			wantFunction: "instantiate",
+			wantSource:   WasmSourceInline,
+			wantFound:    true,
+		},
+		{
+			name:         "compile from new Uint8Array",
+			call:         token.Call{Callee: "WebAssembly.compile", Args: arg("Call", "Uint8Array")},
+			wantFunction: "compile",
+			wantSource:   WasmSourceInline,
+			wantFound:    true,
+		},
+		{
+			name:         "module from array literal",
+			call:         token.Call{Callee: "WebAssembly.Module", New: true, Args: arg("Array", "")},
+			wantFunction: "Module",
+			wantSource:   WasmSourceInline,
+			wantFound:    true,
+		},
+		{
+			name:         "instantiateStreaming",
+			call:         token.Call{Callee: "WebAssembly.instantiateStreaming", Args: arg("Identifier", "response")},
+			wantFunction: "instantiateStreaming",
+			wantSource:   WasmSourceFetched,
+			wantFound:    true,
+		},
+		{
+			name:         "instantiate from response arrayBuffer",
+			call:         token.Call{Callee: "WebAssembly.instantiate", Args: arg("Call", "res.arrayBuffer")},
+			wantFunction: "instantiate",
+			wantSource:   WasmSourceFetched,
+			wantFound:    true,
+		},
+		{
+			name:         "instantiate from file",
+			call:         token.Call{Callee: "globalThis.WebAssembly.instantiate", Args: arg("Call", `require("fs").readFileSync`)},
+			wantFunction: "instantiate",
+			wantSource:   WasmSourceFile,
+			wantFound:    true,
+		},
+		{
+			name:         "instantiate from variable",
+			call:         token.Call{Callee: "WebAssembly.instantiate", Args: arg("Identifier", "wasm")},
+			wantFunction: "instantiate",
+			wantSource:   WasmSourceUnknown,
+			wantFound:    true,
+		},
+		{
+			name:         "no arguments",
+			call:         token.Call{Callee: "WebAssembly.compile"},
+			wantFunction: "compile",
+			wantSource:   WasmSourceUnknown,
+			wantFound:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			function, source, found := FindWasmInstantiation(tt.call)
+			if found != tt.wantFound || function != tt.wantFunction || source != tt.wantSource {
+				t.Errorf("FindWasmInstantiation() = (%q, %q, %v), want (%q, %q, %v)",
+					function, source, found, tt.wantFunction, tt.wantSource, tt.wantFound)
+			}
+		})
+	}
+}
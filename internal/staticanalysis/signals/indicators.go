@@ -0,0 +1,125 @@
+package signals
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// IndicatorType is the kind of value held by an Indicator.
+type IndicatorType string
+
+const (
+	IndicatorDomain IndicatorType = "domain"
+	IndicatorIP     IndicatorType = "ip"
+)
+
+// IndicatorSource identifies what part of the parsed source an indicator was found in.
+type IndicatorSource string
+
+const (
+	SourceStringLiteral IndicatorSource = "string_literal"
+	SourceComment       IndicatorSource = "comment"
+	// SourceCallArgument is used for constant strings passed to a function call,
+	// including those assembled by concatenation, e.g. fetch("http://" + "evil.com").
+	SourceCallArgument IndicatorSource = "call_argument"
+)
+
+// IndicatorLocation records where in a package an indicator appeared.
+type IndicatorLocation struct {
+	File   string
+	Source IndicatorSource
+	Pos    token.Position
+}
+
+// Indicator is a domain name or IP address referenced in a package's source
+// code, which can be used as an indicator of compromise (IOC).
+type Indicator struct {
+	Value     string
+	Type      IndicatorType
+	Locations []IndicatorLocation
+}
+
+/*
+ExtractIndicators returns the set of all domain names and IP addresses referenced in
+string literals, comments and constant string call arguments in the given parsing
+results, which are keyed by file path. Domains are validated using detections.IsValidDomain
+to avoid reporting strings such as file names or version numbers.
+
+Each indicator is returned once, together with all the distinct locations where it
+appeared. Indicators are sorted by type and then value, and locations are sorted by
+file, position and source.
+*/
+func ExtractIndicators(parseResults map[string]parsing.SingleResult) []Indicator {
+	type key struct {
+		value string
+		typ   IndicatorType
+	}
+	found := make(map[key]map[IndicatorLocation]bool)
+
+	add := func(s string, loc IndicatorLocation) {
+		var keys []key
+		for _, domain := range detections.FindDomains(s) {
+			keys = append(keys, key{domain, IndicatorDomain})
+		}
+		for _, ip := range detections.FindIPAddresses(s) {
+			keys = append(keys, key{strings.ToLower(ip), IndicatorIP})
+		}
+		for _, k := range keys {
+			if found[k] == nil {
+				found[k] = make(map[IndicatorLocation]bool)
+			}
+			found[k][loc] = true
+		}
+	}
+
+	for file, result := range parseResults {
+		for _, s := range result.StringLiterals {
+			add(s.Value, IndicatorLocation{File: file, Source: SourceStringLiteral, Pos: s.Pos})
+		}
+		for _, c := range result.Comments {
+			add(c.Text, IndicatorLocation{File: file, Source: SourceComment, Pos: c.Pos})
+		}
+		for _, call := range result.Calls {
+			for _, arg := range call.Args {
+				if arg.Type == "String" {
+					add(arg.Value, IndicatorLocation{File: file, Source: SourceCallArgument, Pos: call.Pos})
+				}
+			}
+		}
+	}
+
+	indicators := make([]Indicator, 0, len(found))
+	for k, locations := range found {
+		locs := maps.Keys(locations)
+		slices.SortFunc(locs, compareLocations)
+		indicators = append(indicators, Indicator{Value: k.value, Type: k.typ, Locations: locs})
+	}
+
+	slices.SortFunc(indicators, func(a, b Indicator) int {
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+
+	return indicators
+}
+
+func compareLocations(a, b IndicatorLocation) int {
+	if c := strings.Compare(a.File, b.File); c != 0 {
+		return c
+	}
+	if a.Pos.Row() != b.Pos.Row() {
+		return a.Pos.Row() - b.Pos.Row()
+	}
+	if a.Pos.Col() != b.Pos.Col() {
+		return a.Pos.Col() - b.Pos.Col()
+	}
+	return strings.Compare(string(a.Source), string(b.Source))
+}
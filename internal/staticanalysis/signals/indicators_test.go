@@ -0,0 +1,56 @@
+package signals
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestExtractIndicators(t *testing.T) {
+	parseResults := map[string]parsing.SingleResult{
+		"b.js": {
+			StringLiterals: []token.String{
+				{Value: "https://evil.com/payload", Pos: token.Position{3, 4}},
+				{Value: "version 1.2.3", Pos: token.Position{5, 0}},
+			},
+		},
+		"a.js": {
+			StringLiterals: []token.String{
+				{Value: "EVIL.com", Pos: token.Position{1, 10}},
+				{Value: "10.0.0.1", Pos: token.Position{2, 0}},
+			},
+			Comments: []token.Comment{
+				{Text: "// mirror: files.example.org", Pos: token.Position{1, 0}},
+			},
+			Calls: []token.Call{
+				{
+					Callee: "fetch",
+					Args:   []token.CallArg{{Type: "String", Value: "http://c2.example.net"}, {Type: "Identifier", Value: "opts.com"}},
+					Pos:    token.Position{7, 2},
+				},
+			},
+		},
+	}
+
+	want := []Indicator{
+		{Value: "c2.example.net", Type: IndicatorDomain, Locations: []IndicatorLocation{
+			{File: "a.js", Source: SourceCallArgument, Pos: token.Position{7, 2}},
+		}},
+		{Value: "evil.com", Type: IndicatorDomain, Locations: []IndicatorLocation{
+			{File: "a.js", Source: SourceStringLiteral, Pos: token.Position{1, 10}},
+			{File: "b.js", Source: SourceStringLiteral, Pos: token.Position{3, 4}},
+		}},
+		{Value: "files.example.org", Type: IndicatorDomain, Locations: []IndicatorLocation{
+			{File: "a.js", Source: SourceComment, Pos: token.Position{1, 0}},
+		}},
+		{Value: "10.0.0.1", Type: IndicatorIP, Locations: []IndicatorLocation{
+			{File: "a.js", Source: SourceStringLiteral, Pos: token.Position{2, 0}},
+		}},
+	}
+
+	if got := ExtractIndicators(parseResults); !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractIndicators() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -16,7 +16,8 @@ const (
 
 	// Signals analysis involves using applying certain detection rules to extract
 	// signals of interest from the code. It depends on the output of the Parsing task,
-	// and does not require reading files directly.
+	// and does not require reading files directly, except for manifest and config
+	// files (see signals.IsConfigFile), which are scanned without being parsed.
 	Signals Task = "signals"
 
 	// All is not a task itself, but represents/'depends on' all other tasks.
@@ -10,24 +10,35 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+
 	"github.com/ossf/package-analysis/internal/featureflags"
 	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 )
 
 var ErrParseFailure = errors.New("parse failure")
 
 var (
 	// 510 06:34:52.506847   43512 strace.go:587] [   2] python3 E openat(AT_FDCWD /app, 0x7f13f2254c50 /root/.ssh, O_RDONLY|O_CLOEXEC|O_DIRECTORY|O_NONBLOCK, 0o0)
-	stracePattern = regexp.MustCompile(`.*strace.go:\d+\] \[.*?\] (.+) (E|X) (\S+)\((.*)\)`)
+	// The timestamp and the contents of the square brackets (the process and thread ID,
+	// or just the thread ID) are captured, followed by the command, event, syscall and args.
+	stracePattern = regexp.MustCompile(`^(?:[IWEF](\d{4} \d{2}:\d{2}:\d{2}\.\d{6}))?.*strace.go:\d+\] \[(.*?)\] (.+) (E|X) (\S+)\((.*)\)`)
 	// 0x7f1c3a0a2620 /usr/bin/uname, 0x7f1c39e12930 ["uname", "-rs"], 0x55bbefc2d070 ["HOSTNAME=63d5c9dbacb6", "PYTHON_PIP_VERSION=21.0.1", "HOME=/root"]
 	execvePattern = regexp.MustCompile(`.*?(\[.*\])`)
+	// The path of the executable in the args of execve, given above.
+	execvePathPattern = regexp.MustCompile(`^0x[a-f\d]+ ([^,]+), `)
 	// 0x7f13f201a0a3 /path, 0x0
 	creatPattern = regexp.MustCompile(`\S+ ([^,]+)`)
 	// 0x7f13f201a0a3 /proc/self/fd, O_RDONLY|O_CLOEXEC,
@@ -43,6 +54,11 @@ var (
 	// 0x3 socket:[4], 0x55ed873bb510 {Family: AF_INET6, Addr: 2001:67c:1360:8001::24, Port: 80}, 0x1c
 	// 0x3 socket:[16], 0x5568c5caf2d0 {Family: AF_INET, Addr: , Port: 5000}, 0x10
 	socketPattern = regexp.MustCompile(`{Family: ([^,]+), (Addr: ([^,]*), Port: ([0-9]+)|[^}]+)}`)
+	// 0x3 socket:[2], ...
+	socketFDPattern = regexp.MustCompile(`^\S+ (socket:\[\d+\])`)
+	// Addr: "/var/run/nscd/socket"
+	// Addr: "\x00/tmp/abstract"
+	unixSocketAddrPattern = regexp.MustCompile(`^Addr: (".*")$`)
 
 	// 0x7fe003272980 /tmp/jpu6po61
 	unlinkPatten = regexp.MustCompile(`0x[a-f\d]+ ([^)]+)?`)
@@ -51,12 +67,77 @@ var (
 	// unlinkat(AT_FDCWD /app, 0x5569a7e83380 /app/vendor/composer/e06632ca, 0x200)
 	unlinkatPattern = regexp.MustCompile(`\S+ ([^,]+), 0x[a-f\d]+ ([^,]+), 0x[a-f\d]+`)
 
+	// symlink(0x7f3c1e2a1000 /etc, 0x7f3c1e2a1010 /app/node_modules/evil/etc) = 0 (0x0) (10µs)
+	// link(0x7f3c1e2a1000 /etc/passwd, 0x7f3c1e2a1010 /app/passwd) = 0 (0x0) (10µs)
+	linkPattern = regexp.MustCompile(`^\S+ ([^,]+), \S+ ([^)]+)`)
+	// symlinkat(0x7f3c1e2a1000 /etc, AT_FDCWD /app, 0x7f3c1e2a1010 etc) = 0 (0x0) (10µs)
+	symlinkatPattern = regexp.MustCompile(`^\S+ ([^,]+), \S+ ([^,]+), \S+ ([^)]+)`)
+	// linkat(AT_FDCWD /app, 0x7f3c1e2a1000 /etc/passwd, AT_FDCWD /app, 0x7f3c1e2a1010 passwd, 0x0) = 0 (0x0) (10µs)
+	linkatPattern = regexp.MustCompile(`^\S+ ([^,]+), \S+ ([^,]+), \S+ ([^,]+), \S+ ([^,]+),`)
+
+	// chmod(0x7f3c1e2a1000 /tmp/payload, 0o755) = 0 (0x0) (10µs)
+	// fchmod(0x3 /tmp/payload, 0o755) = 0 (0x0) (10µs)
+	chmodPattern = regexp.MustCompile(`^\S+ ([^,]+), (0o?[0-7]+)`)
+	// fchmodat(AT_FDCWD /app, 0x7f3c1e2a1000 payload, 0o755) = 0 (0x0) (10µs)
+	fchmodatPattern = regexp.MustCompile(`^\S+ ([^,]+), \S+ ([^,]+), (0o?[0-7]+)`)
+
 	// This regex parses just the file path. Bytes written is parsed further below as the nature of the write buffer makes it unideal to parse through regex.
 	// TODO: We can see how we can potentially reuse regex patterns.
 	// I0928 00:18:54.794008     365 strace.go:593] [   6:   6] uname E write(0x1 pipe:[5], 0x555695ceaab0 "Linux 4.4.0\n", 0xc)
 	writePattern = regexp.MustCompile(`\S+ ([^,]+),.*`)
+
+	// This regex parses the requested duration of a sleep from its timespec argument.
+	// I0928 00:18:54.794008     365 strace.go:593] [   6:   6] node E nanosleep(0x7ffd1f0c2a70 {sec=300 nsec=0}, 0x7ffd1f0c2a80)
+	timespecPattern = regexp.MustCompile(`\{sec=(\d+),? nsec=(\d+)\}`)
+
+	// This regex parses the return value of a syscall from the end of the args of an exit event,
+	// e.g. the ID of the new process returned by clone.
+	// I1203 05:29:21.585001     173 strace.go:625] [   2:   2] sh X clone(CLONE_VM|CLONE_VFORK|SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x5 (1.2ms)
+	returnValuePattern = regexp.MustCompile(`\) = (0x[a-f\d]+|\d+)(?: \(|$)`)
 )
 
+// processCreationSyscalls are the syscalls that return the ID of a new process
+// (or thread) to the caller.
+var processCreationSyscalls = map[string]struct{}{
+	"clone":  {},
+	"clone3": {},
+	"fork":   {},
+	"vfork":  {},
+}
+
+// MinRecordedSleep is the shortest sleep duration recorded by Parse. Shorter
+// sleeps and waits are made constantly by benign programs and are ignored.
+const MinRecordedSleep = time.Second
+
+// timerAbsTime is the TIMER_ABSTIME flag of clock_nanosleep, which means that
+// the timespec argument is an absolute time rather than a duration.
+const timerAbsTime = 0x1
+
+// privilegedSyscalls are syscalls that are very rarely made by benign packages
+// and may indicate privilege escalation or sandbox escape attempts.
+var privilegedSyscalls = map[string]struct{}{
+	"setuid":        {},
+	"setgid":        {},
+	"setreuid":      {},
+	"setregid":      {},
+	"setresuid":     {},
+	"setresgid":     {},
+	"ptrace":        {},
+	"init_module":   {},
+	"finit_module":  {},
+	"delete_module": {},
+	"mount":         {},
+	"umount2":       {},
+}
+
+// logTimestampLayout is the layout of the timestamp at the start of each log line.
+// It does not include the year.
+const logTimestampLayout = "0102 15:04:05.000000"
+
+// spawnRateWindow is the time period over which the rate of process creation
+// is measured for ProcessInfo.PeakSpawnRate.
+const spawnRateWindow = time.Second
+
 // We expect bytes written in the write syscall to be in hex.
 const hexPrefix = "0x"
 
@@ -66,8 +147,28 @@ type FileInfo struct {
 	Write     bool
 	Delete    bool
 	WriteInfo WriteInfo
+	// Writers lists the distinct commands (and their environments) of the processes
+	// that opened the file for writing or created a link at it, as far as they are
+	// known (see LingeringProcessInfo.Command). It tells writes made by the package
+	// manager apart from those made by the package.
+	Writers []CommandInfo
+	// Readers lists the distinct commands (and their environments) of the processes
+	// that opened the file for reading, in the same way as Writers. Files that were
+	// only read by stat are not attributed to a reader.
+	Readers []CommandInfo
+	// Header holds the first bytes (up to HeaderSize) of the contents of the first
+	// write to the file, from which the format of the file can be identified. It
+	// is recorded even if the WriteFileContents feature is disabled, and is empty
+	// if the file was not written by write (e.g. it was copied with sendfile).
+	Header []byte
+	// MadeExecutable is true if the file was given a mode with an execute bit by
+	// chmod, fchmod or fchmodat, as is done to run a file that was dropped.
+	MadeExecutable bool
 }
 
+// HeaderSize is the largest number of bytes of a file recorded in FileInfo.Header.
+const HeaderSize = 256
+
 type WriteInfo []WriteContentInfo
 
 type WriteContentInfo struct {
@@ -76,8 +177,35 @@ type WriteContentInfo struct {
 }
 
 type SocketInfo struct {
+	// Family is the address family of the socket, one of analysisrun.FamilyInet,
+	// analysisrun.FamilyInet6 or analysisrun.FamilyUnix.
+	Family string
+	// Address is the IP address for AF_INET and AF_INET6 sockets, and the
+	// path for AF_UNIX sockets. Sockets in the abstract namespace have their
+	// path prefixed with '@'.
+	Address string
+	// Port is the port number for AF_INET and AF_INET6 sockets, otherwise 0.
+	Port int
+	// Connectors lists the distinct commands (and their environments) of the
+	// processes that connected to or bound the socket, in the same way as
+	// FileInfo.Writers.
+	Connectors []CommandInfo
+}
+
+// DNSPort is the port of DNS servers.
+const DNSPort = 53
+
+// DNSQueryInfo describes the DNS queries sent to the server at Address and Port
+// by sendto, sendmsg or write (either to that address, or on a socket connected
+// to it), as found in their payloads. Queries sent by sendmmsg, and payloads cut
+// short in the log, are not recorded.
+type DNSQueryInfo struct {
+	Family  string
 	Address string
 	Port    int
+	// Hostnames lists the distinct names in the questions of the queries, in the
+	// order they were first queried.
+	Hostnames []string
 }
 
 type CommandInfo struct {
@@ -85,12 +213,143 @@ type CommandInfo struct {
 	Env     []string
 }
 
+// SelfModificationInfo describes a file that was run by a command, and then
+// written to or deleted later in the strace.
+type SelfModificationInfo struct {
+	Path    string
+	Command []string
+	Write   bool
+	Delete  bool
+}
+
+// LinkInfo describes a link created at Path. For symbolic links, Target is the
+// target as given to symlink, which is relative to the directory containing the
+// link if it is not absolute. For hard links (when Hard is true), Target is the
+// path of the existing file that was linked to.
+type LinkInfo struct {
+	Path   string
+	Target string
+	Hard   bool
+}
+
+// CommandConnectionsInfo describes a command, and the sockets that were
+// connected to after it was first run.
+type CommandConnectionsInfo struct {
+	Command []string
+	Env     []string
+	Sockets []SocketInfo
+}
+
+// WrittenFileRunInfo describes a file that was written, and then run by Command
+// later in the strace, either as the executable of the command or as the script
+// passed to an interpreter.
+type WrittenFileRunInfo struct {
+	Path    string
+	Command []string
+}
+
+// fileEvents holds the positions of the most recent accesses of each kind to
+// a file, and of the first write to it, in the sequence of events recorded by
+// Result.
+type fileEvents struct {
+	lastRead, lastWrite, lastDelete int
+	firstRead, firstWrite           int
+}
+
+// runEvent holds the position of the first run of a file by a command.
+type runEvent struct {
+	path  string
+	cmd   []string
+	event int
+}
+
+// ProcessInfo summarises the processes that appear in the strace.
+type ProcessInfo struct {
+	// Spawned is the number of processes created, excluding the first process.
+	Spawned int
+	// Execs is the number of calls to execve or execveat.
+	Execs int
+	// PeakCount is the largest number of processes alive at the same time.
+	PeakCount int
+	// PeakSpawnRate is the largest number of processes created within
+	// spawnRateWindow (one second).
+	PeakSpawnRate int
+}
+
+// LingeringProcessInfo describes a process that was still alive at the end of
+// the strace, e.g. a background process or daemon started by a package.
+type LingeringProcessInfo struct {
+	PID int
+	// Command is the command most recently run by the process (or its parent,
+	// if it did not run one itself). If neither is known, it holds just the name
+	// of the process given in the log.
+	Command []string
+	// Daemonized is true if the process, or the process that created it, called
+	// setsid to detach from the session of the command being analyzed.
+	Daemonized bool
+}
+
+type SyscallInfo struct {
+	Name  string
+	Count int
+}
+
+// SleepInfo records that Syscall was called Count times to sleep or wait
+// (with a timeout) for Duration.
+type SleepInfo struct {
+	Syscall  string
+	Duration time.Duration
+	Count    int
+}
+
+type sleepKey struct {
+	syscall  string
+	duration time.Duration
+}
+
 type Result struct {
 	files    map[string]*FileInfo
 	sockets  map[string]*SocketInfo
 	commands map[string]*CommandInfo
 	// Map to track all seen write buffers so that we don't duplicate writing files to disk.
 	allWriteBufferId map[string]struct{}
+	// Number of syscalls seen in the log, counted by their entry events.
+	syscallCount int
+	// Number of calls to each privileged syscall, counted by their entry events.
+	privilegedSyscalls map[string]int
+	// Number of sleeps of at least MinRecordedSleep, keyed by syscall and duration.
+	sleeps map[sleepKey]int
+	// Number of file accesses, commands and connections recorded so far, used
+	// to order them.
+	events int
+	// Positions of the accesses to each file, keyed by path.
+	fileEvents map[string]*fileEvents
+	// Position of the first run of each command, keyed like commands.
+	commandEvents map[string]int
+	// Position of the most recent connection to each socket, keyed like sockets.
+	connectionEvents map[string]int
+	// First runs of each file by each command, keyed by path and command.
+	runEvents map[string]*runEvent
+	// Processes seen so far, keyed by process ID, and whether they are still alive.
+	processes     map[int]bool
+	liveProcesses int
+	firstProcess  int
+	// Most recent command run by each process, and the processes that detached
+	// from the session (see LingeringProcessInfo), keyed by process ID.
+	processCommands   map[int][]string
+	processEnvs       map[int][]string
+	daemonizedProcess map[int]bool
+	processNames      map[int]string
+	// Times at which processes were spawned within the last spawnRateWindow.
+	recentSpawns []time.Time
+	processInfo  ProcessInfo
+	// Links created, and the set of links seen so far, to avoid duplicates.
+	links     []LinkInfo
+	seenLinks map[LinkInfo]bool
+	// DNS servers that sockets are connected to, keyed by the name of the socket
+	// (see socketFDPattern), and the queries sent to each server, keyed like sockets.
+	dnsServers map[string]DNSQueryInfo
+	dnsQueries map[string]*DNSQueryInfo
 }
 
 func parseOpenFlags(openFlags string) (read, write bool) {
@@ -153,10 +412,77 @@ func (r *Result) recordFileAccess(file string, read, write, del bool) {
 	r.files[file].Read = r.files[file].Read || read
 	r.files[file].Write = r.files[file].Write || write
 	r.files[file].Delete = r.files[file].Delete || del
+
+	r.events++
+	if _, exists := r.fileEvents[file]; !exists {
+		r.fileEvents[file] = &fileEvents{}
+	}
+	e := r.fileEvents[file]
+	if read {
+		e.lastRead = r.events
+		if e.firstRead == 0 {
+			e.firstRead = r.events
+		}
+	}
+	if write {
+		e.lastWrite = r.events
+		if e.firstWrite == 0 {
+			e.firstWrite = r.events
+		}
+	}
+	if del {
+		e.lastDelete = r.events
+	}
+}
+
+// recordFileWriter records the command run by the process with the given ID as
+// one of the Writers of file, which must already have been recorded as accessed.
+// Nothing is recorded if the command of the process is not known.
+func (r *Result) recordFileWriter(pid int, file string) {
+	r.files[file].Writers = r.appendProcessCommand(r.files[file].Writers, pid)
+}
+
+// recordFileReader records the command run by the process with the given ID as
+// one of the Readers of file, which must already have been recorded as accessed.
+func (r *Result) recordFileReader(pid int, file string) {
+	r.files[file].Readers = r.appendProcessCommand(r.files[file].Readers, pid)
+}
+
+// appendProcessCommand appends the command and environment of the process with
+// the given ID to cmds, unless it is unknown or already in cmds.
+func (r *Result) appendProcessCommand(cmds []CommandInfo, pid int) []CommandInfo {
+	cmd, ok := r.processCommands[pid]
+	if !ok {
+		return cmds
+	}
+	env := r.processEnvs[pid]
+	for _, c := range cmds {
+		if slices.Equal(c.Command, cmd) && slices.Equal(c.Env, env) {
+			return cmds
+		}
+	}
+	return append(cmds, CommandInfo{Command: cmd, Env: env})
+}
+
+// recordLink records the creation of a link at path by the process with the
+// given ID, which is also recorded as a write to path.
+func (r *Result) recordLink(pid int, path, target string, hard bool) {
+	r.recordFileAccess(path, false, true, false)
+	r.recordFileWriter(pid, path)
+	link := LinkInfo{Path: path, Target: target, Hard: hard}
+	if r.seenLinks[link] {
+		return
+	}
+	r.seenLinks[link] = true
+	r.links = append(r.links, link)
 }
 
 func (r *Result) recordFileWrite(file string, writeBuffer []byte, bytesWritten int64) error {
 	r.recordFileAccess(file, false, true, false)
+	if f := r.files[file]; len(f.Header) == 0 && len(writeBuffer) > 0 {
+		header := unescapeWriteBuffer(writeBuffer)
+		f.Header = slices.Clone(header[:min(len(header), HeaderSize)])
+	}
 	if !featureflags.WriteFileContents.Enabled() {
 		// Abort writing file contents when feature is disabled.
 		return nil
@@ -175,16 +501,146 @@ func (r *Result) recordFileWrite(file string, writeBuffer []byte, bytesWritten i
 	return nil
 }
 
-func (r *Result) recordSocket(address string, port int) {
+// unescapeWriteBuffer returns the bytes of a write buffer as logged by strace,
+// which escapes them like a quoted Go string. If the buffer cannot be unquoted
+// (e.g. it was cut short in the middle of an escape sequence), the part before
+// the last escape sequence is unquoted if possible, and otherwise it is returned
+// as it is.
+func unescapeWriteBuffer(buf []byte) []byte {
+	s := string(buf)
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return []byte(u)
+	}
+	if i := strings.LastIndex(s, `\`); i > 0 {
+		if u, err := strconv.Unquote(`"` + s[:i] + `"`); err == nil {
+			return []byte(u)
+		}
+	}
+	return buf
+}
+
+// recordChmod records that the mode of file was changed to mode, which is given
+// in octal. Only changes that make a file executable are recorded.
+func (r *Result) recordChmod(file, mode string) error {
+	m, err := strconv.ParseUint(mode, 0, 32)
+	if err != nil {
+		return fmt.Errorf("%w: mode: %w", ErrParseFailure, err)
+	}
+	if m&0o111 == 0 {
+		return nil
+	}
+	if _, exists := r.files[file]; !exists {
+		r.files[file] = &FileInfo{Path: file}
+	}
+	r.files[file].MadeExecutable = true
+	return nil
+}
+
+// recordSocket records a socket that was bound or, if connect is true,
+// connected to by the process with the given ID.
+func (r *Result) recordSocket(pid int, family, address string, port int, connect bool) {
 	// Use a '-' dash as the address may contain colons if IPv6
 	// Pad the integer field so that keys can be sorted.
-	key := fmt.Sprintf("%s-%05d", address, port)
+	key := fmt.Sprintf("%s-%05d-%s", address, port, family)
 	if _, exists := r.sockets[key]; !exists {
 		r.sockets[key] = &SocketInfo{
+			Family:  family,
 			Address: address,
 			Port:    port,
 		}
 	}
+	r.sockets[key].Connectors = r.appendProcessCommand(r.sockets[key].Connectors, pid)
+	if connect {
+		r.events++
+		r.connectionEvents[key] = r.events
+	}
+}
+
+// recordDNSServer records the server that a socket, whose file descriptor is at
+// the start of args, was connected to if it is a DNS server, so that the queries
+// written to the socket are attributed to it.
+func (r *Result) recordDNSServer(args, family, address string, port int) {
+	match := socketFDPattern.FindStringSubmatch(args)
+	if match == nil {
+		return
+	}
+	if port != DNSPort {
+		delete(r.dnsServers, match[1])
+		return
+	}
+	r.dnsServers[match[1]] = DNSQueryInfo{Family: family, Address: address, Port: port}
+}
+
+// recordDNSQuery records the hostnames queried by the payload of a syscall that
+// sends data on a socket, if it is sent to a DNS server, either because the
+// destination address in args is one or because the socket is connected to one,
+// and the payload is a DNS query.
+func (r *Result) recordDNSQuery(args string, logger *slog.Logger) {
+	var server DNSQueryInfo
+	if match := socketPattern.FindStringSubmatch(args); match != nil {
+		if match[1] != analysisrun.FamilyInet && match[1] != analysisrun.FamilyInet6 {
+			return
+		}
+		port, err := parsePort(match[4])
+		if err != nil || port != DNSPort {
+			return
+		}
+		server = DNSQueryInfo{Family: match[1], Address: match[3], Port: port}
+	} else if match := socketFDPattern.FindStringSubmatch(args); match != nil {
+		var ok bool
+		if server, ok = r.dnsServers[match[1]]; !ok {
+			return
+		}
+	} else {
+		return
+	}
+
+	i := strings.Index(args, `"`)
+	if i < 0 {
+		return
+	}
+	quoted, err := strconv.QuotedPrefix(args[i:])
+	if err != nil {
+		return
+	}
+	payload, err := strconv.Unquote(quoted)
+	if err != nil {
+		return
+	}
+	var query layers.DNS
+	if err := query.DecodeFromBytes([]byte(payload), gopacket.NilDecodeFeedback); err != nil || query.QR {
+		return
+	}
+
+	key := fmt.Sprintf("%s-%05d-%s", server.Address, server.Port, server.Family)
+	if _, exists := r.dnsQueries[key]; !exists {
+		r.dnsQueries[key] = &server
+	}
+	info := r.dnsQueries[key]
+	for _, q := range query.Questions {
+		name := string(q.Name)
+		logger.Debug("dns query", "address", server.Address, "name", name)
+		if name != "" && !slices.Contains(info.Hostnames, name) {
+			info.Hostnames = append(info.Hostnames, name)
+		}
+	}
+}
+
+// parseUnixSocketAddr parses the quoted path of an AF_UNIX socket address.
+// The leading NUL byte of paths in the abstract namespace is replaced with '@'.
+func parseUnixSocketAddr(addr string) (string, error) {
+	match := unixSocketAddrPattern.FindStringSubmatch(addr)
+	if match == nil {
+		return "", fmt.Errorf("%w: unix socket address: %s", ErrParseFailure, addr)
+	}
+	path, err := strconv.Unquote(match[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: unix socket path: %w", ErrParseFailure, err)
+	}
+	if strings.HasPrefix(path, "\x00") {
+		path = "@" + path[1:]
+	}
+	return path, nil
 }
 
 func (r *Result) recordCommand(cmd, env []string) {
@@ -194,12 +650,219 @@ func (r *Result) recordCommand(cmd, env []string) {
 			Command: cmd,
 			Env:     env,
 		}
+		r.events++
+		r.commandEvents[key] = r.events
+	}
+}
+
+// recordRun records that the file at path was run by cmd. Only the first run is
+// recorded.
+func (r *Result) recordRun(path string, cmd []string) {
+	key := path + "\x00" + strings.Join(cmd, "\x00")
+	if _, exists := r.runEvents[key]; !exists {
+		r.events++
+		r.runEvents[key] = &runEvent{path: path, cmd: cmd, event: r.events}
+	}
+}
+
+func (r *Result) recordSyscall(syscall string) {
+	r.syscallCount++
+	if syscall == "execve" || syscall == "execveat" {
+		r.processInfo.Execs++
+	}
+	if _, privileged := privilegedSyscalls[syscall]; privileged {
+		r.privilegedSyscalls[syscall]++
+	}
+}
+
+/*
+recordProcess records that a syscall event was logged for the process with the given
+ID at the given time (which is the zero time if the log line has no timestamp). A
+process is considered to be created when it is first seen, and to end when it calls
+exit_group (or exit, if the log only gives thread IDs). This avoids having to distinguish new processes from new threads in the
+arguments of clone and clone3. Process IDs that are reused after a process ends are
+counted as a new process.
+*/
+func (r *Result) recordProcess(pid int, timestamp time.Time) {
+	if r.processes[pid] {
+		return
+	}
+	firstProcess := len(r.processes) == 0
+	r.processes[pid] = true
+	r.liveProcesses++
+	r.processInfo.PeakCount = max(r.processInfo.PeakCount, r.liveProcesses)
+	if firstProcess {
+		r.firstProcess = pid
+		return
+	}
+
+	r.processInfo.Spawned++
+	if timestamp.IsZero() {
+		return
+	}
+	// Drop spawns that are no longer in the window. Log lines may be slightly
+	// out of order, so this is an approximation.
+	i := 0
+	for i < len(r.recentSpawns) && timestamp.Sub(r.recentSpawns[i]) >= spawnRateWindow {
+		i++
+	}
+	r.recentSpawns = append(r.recentSpawns[i:], timestamp)
+	r.processInfo.PeakSpawnRate = max(r.processInfo.PeakSpawnRate, len(r.recentSpawns))
+}
+
+// recordProcessExit records that the process with the given ID has ended.
+func (r *Result) recordProcessExit(pid int) {
+	if r.processes[pid] {
+		r.processes[pid] = false
+		r.liveProcesses--
+	}
+	delete(r.processCommands, pid)
+	delete(r.processEnvs, pid)
+	delete(r.daemonizedProcess, pid)
+	delete(r.processNames, pid)
+}
+
+/*
+recordProcessSyscall records the exit event of a syscall that changes how the
+process with the given ID (or 0 if it is not known) is tracked for LingeringProcesses: the command it runs
+(execve), whether it detached from its session (setsid), and the properties that
+a new process inherits from it (clone, fork and vfork).
+*/
+func (r *Result) recordProcessSyscall(pid int, syscall, args string, cmd, env []string) {
+	if pid <= 0 {
+		// The process ID was not in the log line.
+		return
+	}
+	switch {
+	case cmd != nil:
+		r.processCommands[pid] = cmd
+		r.processEnvs[pid] = env
+	case syscall == "setsid":
+		if !strings.Contains(args, "errno=") {
+			r.daemonizedProcess[pid] = true
+		}
+	default:
+		if _, ok := processCreationSyscalls[syscall]; !ok {
+			return
+		}
+		match := returnValuePattern.FindStringSubmatch(args)
+		if match == nil {
+			return
+		}
+		child, err := strconv.ParseInt(match[1], 0, 64)
+		if err != nil || child <= 0 || int(child) == pid {
+			return
+		}
+		if cmd, ok := r.processCommands[pid]; ok {
+			if _, ok := r.processCommands[int(child)]; !ok {
+				r.processCommands[int(child)] = cmd
+				r.processEnvs[int(child)] = r.processEnvs[pid]
+			}
+		}
+		if r.daemonizedProcess[pid] {
+			r.daemonizedProcess[int(child)] = true
+		}
+	}
+}
+
+// parseTaskIDs returns the process ID from the contents of the square brackets in a
+// log line, which is either "pid:tid" or (in older versions) just the thread ID.
+func parseTaskIDs(ids string) (pid int, ok bool) {
+	ids, _, _ = strings.Cut(ids, ":")
+	pid, err := strconv.Atoi(strings.TrimSpace(ids))
+	return pid, err == nil
+}
+
+func (r *Result) recordSleep(syscall string, duration time.Duration) {
+	if duration >= MinRecordedSleep {
+		r.sleeps[sleepKey{syscall, duration}]++
+	}
+}
+
+// parseTimespec parses the duration of the timespec in the given syscall argument.
+func parseTimespec(arg string) (time.Duration, error) {
+	match := timespecPattern.FindStringSubmatch(arg)
+	if match == nil {
+		return 0, fmt.Errorf("%w: timespec not found in %q", ErrParseFailure, arg)
+	}
+	sec, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: timespec seconds: %w", ErrParseFailure, err)
+	}
+	nsec, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: timespec nanoseconds: %w", ErrParseFailure, err)
+	}
+	if sec > math.MaxInt64/int64(time.Second) {
+		return math.MaxInt64, nil
+	}
+	return time.Duration(sec)*time.Second + time.Duration(nsec), nil
+}
+
+// parseEpollTimeout parses the (millisecond) timeout argument of an epoll wait
+// syscall. Negative timeouts, which mean to wait indefinitely, are returned as 0.
+func parseEpollTimeout(arg string) (time.Duration, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(arg), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: epoll timeout: %w", ErrParseFailure, err)
+	}
+	// The timeout is a C int, which may have been sign-extended.
+	timeout := int32(uint32(value))
+	if timeout < 0 {
+		return 0, nil
 	}
+	return time.Duration(timeout) * time.Millisecond, nil
 }
 
 func (r *Result) parseEnterSyscall(syscall, args string, logger *slog.Logger) error {
 	switch syscall {
+	case "nanosleep":
+		duration, err := parseTimespec(args)
+		if err != nil {
+			return err
+		}
+		logger.Debug("nanosleep", "duration", duration)
+		r.recordSleep(syscall, duration)
+	case "clock_nanosleep":
+		fields := strings.Split(args, ", ")
+		if len(fields) < 3 {
+			return fmt.Errorf("%w: clock_nanosleep has unexpected arguments %q", ErrParseFailure, args)
+		}
+		flags, err := strconv.ParseUint(fields[1], 0, 64)
+		if err != nil {
+			return fmt.Errorf("%w: clock_nanosleep flags: %w", ErrParseFailure, err)
+		}
+		if flags&timerAbsTime != 0 {
+			// The duration of sleeps until an absolute time is not known.
+			return nil
+		}
+		duration, err := parseTimespec(fields[2])
+		if err != nil {
+			return err
+		}
+		logger.Debug("clock_nanosleep", "duration", duration)
+		r.recordSleep(syscall, duration)
+	case "epoll_wait", "epoll_pwait", "epoll_pwait2":
+		fields := strings.Split(args, ", ")
+		if len(fields) < 4 {
+			return fmt.Errorf("%w: %s has unexpected arguments %q", ErrParseFailure, syscall, args)
+		}
+		var duration time.Duration
+		var err error
+		if syscall == "epoll_pwait2" {
+			duration, err = parseTimespec(fields[3])
+		} else {
+			duration, err = parseEpollTimeout(fields[3])
+		}
+		if err != nil {
+			return err
+		}
+		logger.Debug(syscall, "timeout", duration)
+		r.recordSleep(syscall, duration)
+	case "sendto", "sendmsg":
+		r.recordDNSQuery(args, logger)
 	case "write":
+		r.recordDNSQuery(args, logger)
 		// The index of the start of bytes written. Bytes written is expected to be in hex.
 		bytesWrittenHexIndex := strings.LastIndex(args, hexPrefix)
 		// Return an error if we can't find the beginning of bytes written as a hex value or there is no value after the hex prefix.
@@ -226,7 +889,7 @@ func (r *Result) parseEnterSyscall(syscall, args string, logger *slog.Logger) er
 	return nil
 }
 
-func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) error {
+func (r *Result) parseExitSyscall(pid int, syscall, args string, logger *slog.Logger) error {
 	switch syscall {
 	case "creat":
 		match := creatPattern.FindStringSubmatch(args)
@@ -237,6 +900,7 @@ func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) err
 		path := match[1]
 		logger.Debug("creat", "path", path)
 		r.recordFileAccess(path, false, true, false)
+		r.recordFileWriter(pid, path)
 	case "open":
 		match := openPattern.FindStringSubmatch(args)
 		if match == nil {
@@ -247,6 +911,12 @@ func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) err
 		read, write := parseOpenFlags(match[2])
 		logger.Debug("open", "path", path, "read", read, "write", write)
 		r.recordFileAccess(path, read, write, false)
+		if read {
+			r.recordFileReader(pid, path)
+		}
+		if write {
+			r.recordFileWriter(pid, path)
+		}
 	case "openat":
 		match := openatPattern.FindStringSubmatch(args)
 		if match == nil {
@@ -257,6 +927,12 @@ func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) err
 		read, write := parseOpenFlags(match[3])
 		logger.Debug("openat", "path", path, "read", read, "write", write)
 		r.recordFileAccess(path, read, write, false)
+		if read {
+			r.recordFileReader(pid, path)
+		}
+		if write {
+			r.recordFileWriter(pid, path)
+		}
 	case "execve":
 		match := execvePattern.FindStringSubmatch(args)
 		if match == nil {
@@ -269,25 +945,45 @@ func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) err
 			return fmt.Errorf("%w: cmd and env: %w", ErrParseFailure, err)
 		}
 		r.recordCommand(cmd, env)
+		if m := execvePathPattern.FindStringSubmatch(args); m != nil {
+			r.recordRun(m[1], cmd)
+		}
+		if script, ok := runFile(cmd); ok && len(cmd) > 0 && script != cmd[0] {
+			r.recordRun(script, cmd)
+		}
+		r.recordProcessSyscall(pid, syscall, args, cmd, env)
+	case "setsid", "clone", "clone3", "fork", "vfork":
+		r.recordProcessSyscall(pid, syscall, args, nil, nil)
 	case "bind", "connect":
 		match := socketPattern.FindStringSubmatch(args)
 		if match == nil {
 			return fmt.Errorf("%w: socket args: %s", ErrParseFailure, args)
 		}
 		family := match[1]
-		if family != "AF_INET" && family != "AF_INET6" {
+		switch family {
+		case analysisrun.FamilyInet, analysisrun.FamilyInet6:
+			address := match[3]
+			port, err := parsePort(match[4])
+			if err != nil {
+				return fmt.Errorf("%w: port: %w", ErrParseFailure, err)
+			}
+			logger.Debug("socket", "family", family, "address", address, "port", port)
+			r.recordSocket(pid, family, address, port, syscall == "connect")
+			if syscall == "connect" {
+				r.recordDNSServer(args, family, address, port)
+			}
+		case analysisrun.FamilyUnix:
+			path, err := parseUnixSocketAddr(match[2])
+			if err != nil {
+				return err
+			}
+			logger.Debug("socket", "family", family, "address", path)
+			r.recordSocket(pid, family, path, 0, syscall == "connect")
+		default:
 			logger.Debug("Ignoring socket",
 				"family", family,
 				"socket", match[2])
-			return nil
 		}
-		address := match[3]
-		port, err := parsePort(match[4])
-		if err != nil {
-			return fmt.Errorf("%w: port: %w", ErrParseFailure, err)
-		}
-		logger.Debug("socket", "address", address, "port", port)
-		r.recordSocket(address, port)
 	case "stat", "fstat", "lstat":
 		match := statPattern.FindStringSubmatch(args)
 		if match == nil {
@@ -320,18 +1016,101 @@ func (r *Result) parseExitSyscall(syscall, args string, logger *slog.Logger) err
 		path := joinPaths(match[1], match[2])
 		logger.Debug("unlinkat", "path", path)
 		r.recordFileAccess(path, false, false, true)
+	case "symlink", "link":
+		match := linkPattern.FindStringSubmatch(args)
+		if match == nil {
+			return fmt.Errorf("%w: %s args: %s", ErrParseFailure, syscall, args)
+		}
+		logger.Debug(syscall, "path", match[2], "target", match[1])
+		r.recordLink(pid, match[2], match[1], syscall == "link")
+	case "symlinkat":
+		match := symlinkatPattern.FindStringSubmatch(args)
+		if match == nil {
+			return fmt.Errorf("%w: symlinkat args: %s", ErrParseFailure, args)
+		}
+		path := joinPaths(match[2], match[3])
+		logger.Debug("symlinkat", "path", path, "target", match[1])
+		r.recordLink(pid, path, match[1], false)
+	case "linkat":
+		match := linkatPattern.FindStringSubmatch(args)
+		if match == nil {
+			return fmt.Errorf("%w: linkat args: %s", ErrParseFailure, args)
+		}
+		path, target := joinPaths(match[3], match[4]), joinPaths(match[1], match[2])
+		logger.Debug("linkat", "path", path, "target", target)
+		r.recordLink(pid, path, target, true)
+	case "chmod", "fchmod":
+		match := chmodPattern.FindStringSubmatch(args)
+		if match == nil {
+			return fmt.Errorf("%w: %s args: %s", ErrParseFailure, syscall, args)
+		}
+		logger.Debug(syscall, "path", match[1], "mode", match[2])
+		return r.recordChmod(match[1], match[2])
+	case "fchmodat", "fchmodat2":
+		match := fchmodatPattern.FindStringSubmatch(args)
+		if match == nil {
+			return fmt.Errorf("%w: %s args: %s", ErrParseFailure, syscall, args)
+		}
+		path := joinPaths(match[1], match[2])
+		logger.Debug(syscall, "path", path, "mode", match[3])
+		return r.recordChmod(path, match[3])
 	}
 	return nil
 }
 
+// minimalSyscalls are the syscalls whose exit events are parsed by ParseMinimal.
+var minimalSyscalls = []string{"open", "openat", "creat", "execve", "connect", "bind"}
+
+// isMinimalSyscallLine returns whether line could be the exit event of one of the
+// minimalSyscalls. This is checked before matching the line against stracePattern,
+// which is much slower.
+func isMinimalSyscallLine(line string) bool {
+	for _, syscall := range minimalSyscalls {
+		if strings.Contains(line, " X "+syscall+"(") {
+			return true
+		}
+	}
+	return false
+}
+
 // Parse reads the output from strace and collects the files, sockets and commands that
 // were accessed. debugLogger can be used to log verbose information about strace parsing.
 func Parse(ctx context.Context, r io.Reader, debugLogger *slog.Logger) (*Result, error) {
+	return parse(ctx, r, debugLogger, false)
+}
+
+/*
+ParseMinimal is a faster version of Parse, which only collects the files opened,
+the sockets connected or bound to and the commands run. Only the exit events of the
+syscalls that make these are parsed (see minimalSyscalls), so the other data of the
+Result (e.g. the processes, sleeps and file write contents) is empty, and
+SyscallCount is zero. It is intended for quickly checking large numbers of packages
+for the most important signals, at the cost of completeness.
+*/
+func ParseMinimal(ctx context.Context, r io.Reader, debugLogger *slog.Logger) (*Result, error) {
+	return parse(ctx, r, debugLogger, true)
+}
+
+func parse(ctx context.Context, r io.Reader, debugLogger *slog.Logger, minimal bool) (*Result, error) {
 	result := &Result{
-		files:            make(map[string]*FileInfo),
-		sockets:          make(map[string]*SocketInfo),
-		commands:         make(map[string]*CommandInfo),
-		allWriteBufferId: make(map[string]struct{}),
+		files:              make(map[string]*FileInfo),
+		sockets:            make(map[string]*SocketInfo),
+		commands:           make(map[string]*CommandInfo),
+		allWriteBufferId:   make(map[string]struct{}),
+		privilegedSyscalls: make(map[string]int),
+		sleeps:             make(map[sleepKey]int),
+		fileEvents:         make(map[string]*fileEvents),
+		commandEvents:      make(map[string]int),
+		connectionEvents:   make(map[string]int),
+		runEvents:          make(map[string]*runEvent),
+		processes:          make(map[int]bool),
+		processCommands:    make(map[int][]string),
+		processEnvs:        make(map[int][]string),
+		daemonizedProcess:  make(map[int]bool),
+		processNames:       make(map[int]string),
+		seenLinks:          make(map[LinkInfo]bool),
+		dnsServers:         make(map[string]DNSQueryInfo),
+		dnsQueries:         make(map[string]*DNSQueryInfo),
 	}
 
 	// Use a buffered reader, rather than scanner, to allow for lines with
@@ -342,20 +1121,46 @@ func Parse(ctx context.Context, r io.Reader, debugLogger *slog.Logger) (*Result,
 		// Trim any trailing space
 		line = strings.TrimRightFunc(line, unicode.IsSpace)
 
-		match := stracePattern.FindStringSubmatch(line)
-		if match != nil {
-			if match[2] == "E" {
+		var match []string
+		if !minimal || isMinimalSyscallLine(line) {
+			match = stracePattern.FindStringSubmatch(line)
+		}
+		if match != nil && minimal {
+			pid, _ := parseTaskIDs(match[2])
+			if match[4] == "X" && slices.Contains(minimalSyscalls, match[5]) {
+				if err := result.parseExitSyscall(pid, match[5], match[6], debugLogger); errors.Is(err, ErrParseFailure) {
+					slog.WarnContext(ctx, "Failed to parse exit syscall", "error", err)
+				} else if err != nil {
+					return nil, err
+				}
+			}
+		} else if match != nil {
+			pid, ok := parseTaskIDs(match[2])
+			if ok {
+				// if parsing fails, the time is zero and the spawn rate is not measured
+				timestamp, _ := time.Parse(logTimestampLayout, match[1])
+				result.recordProcess(pid, timestamp)
+				result.processNames[pid] = match[3]
+				// Older versions only log the thread ID, so each thread is
+				// tracked as a process, and ends when it calls exit.
+				threadOnly := !strings.Contains(match[2], ":")
+				if match[4] == "E" && (match[5] == "exit_group" || (threadOnly && match[5] == "exit")) {
+					result.recordProcessExit(pid)
+				}
+			}
+			if match[4] == "E" {
+				result.recordSyscall(match[5])
 				// Analyze entry events.
-				if err := result.parseEnterSyscall(match[3], match[4], debugLogger); errors.Is(err, ErrParseFailure) {
+				if err := result.parseEnterSyscall(match[5], match[6], debugLogger); errors.Is(err, ErrParseFailure) {
 					// Log parsing errors and continue.
 					slog.WarnContext(ctx, "Failed to parse entry syscall", "error", err)
 				} else if err != nil {
 					return nil, err
 				}
 			}
-			if match[2] == "X" {
+			if match[4] == "X" {
 				// Analyze exit events.
-				if err := result.parseExitSyscall(match[3], match[4], debugLogger); errors.Is(err, ErrParseFailure) {
+				if err := result.parseExitSyscall(pid, match[5], match[6], debugLogger); errors.Is(err, ErrParseFailure) {
 					// Log parsing errors and continue.
 					slog.WarnContext(ctx, "Failed to parse exit syscall", "error", err)
 				} else if err != nil {
@@ -391,7 +1196,7 @@ func (r *Result) Files() []FileInfo {
 	return files
 }
 
-// Sockets returns all the IPv4 and IPv6 sockets from the parsed strace.
+// Sockets returns all the IPv4, IPv6 and Unix domain sockets from the parsed strace.
 func (r *Result) Sockets() []SocketInfo {
 	// Sort the keys so the output is in a stable order
 	keys := make([]string, 0, len(r.sockets))
@@ -407,6 +1212,98 @@ func (r *Result) Sockets() []SocketInfo {
 	return sockets
 }
 
+// DNSQueries returns the DNS queries sent to each DNS server in the parsed strace
+// (see DNSQueryInfo), ordered by the address of the server.
+func (r *Result) DNSQueries() []DNSQueryInfo {
+	keys := make([]string, 0, len(r.dnsQueries))
+	for k := range r.dnsQueries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	queries := make([]DNSQueryInfo, 0, len(keys))
+	for _, k := range keys {
+		queries = append(queries, *r.dnsQueries[k])
+	}
+	return queries
+}
+
+// SyscallCount returns the total number of syscalls made in the parsed strace,
+// including those that are not otherwise analyzed.
+func (r *Result) SyscallCount() int {
+	return r.syscallCount
+}
+
+// PrivilegedSyscalls returns the number of calls made to each privileged syscall
+// (e.g. setuid, ptrace, init_module, mount) in the parsed strace, sorted by name.
+func (r *Result) PrivilegedSyscalls() []SyscallInfo {
+	names := make([]string, 0, len(r.privilegedSyscalls))
+	for name := range r.privilegedSyscalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	syscalls := make([]SyscallInfo, 0, len(names))
+	for _, name := range names {
+		syscalls = append(syscalls, SyscallInfo{Name: name, Count: r.privilegedSyscalls[name]})
+	}
+	return syscalls
+}
+
+// Processes returns a summary of the processes in the parsed strace, including the
+// number created and the largest number that were alive at once, which can be used
+// to detect fork bombs.
+func (r *Result) Processes() ProcessInfo {
+	return r.processInfo
+}
+
+/*
+LingeringProcesses returns the processes that were still alive at the end of the
+parsed strace, sorted by process ID. Since a phase of dynamic analysis ends when the
+command being analyzed exits, these are processes that it left running in the
+background, such as daemons. The first process in the strace (the command itself)
+is not included, since it is only alive at the end if the strace was cut short.
+*/
+func (r *Result) LingeringProcesses() []LingeringProcessInfo {
+	var pids []int
+	for pid, alive := range r.processes {
+		if alive && pid != r.firstProcess {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+
+	processes := make([]LingeringProcessInfo, 0, len(pids))
+	for _, pid := range pids {
+		cmd, ok := r.processCommands[pid]
+		if !ok {
+			cmd = []string{r.processNames[pid]}
+		}
+		processes = append(processes, LingeringProcessInfo{
+			PID:        pid,
+			Command:    cmd,
+			Daemonized: r.daemonizedProcess[pid],
+		})
+	}
+	return processes
+}
+
+// Sleeps returns the sleeps (and waits with a timeout) of at least MinRecordedSleep
+// in the parsed strace, sorted by syscall name and then duration.
+func (r *Result) Sleeps() []SleepInfo {
+	sleeps := make([]SleepInfo, 0, len(r.sleeps))
+	for k, count := range r.sleeps {
+		sleeps = append(sleeps, SleepInfo{Syscall: k.syscall, Duration: k.duration, Count: count})
+	}
+	sort.Slice(sleeps, func(i, j int) bool {
+		if sleeps[i].Syscall != sleeps[j].Syscall {
+			return sleeps[i].Syscall < sleeps[j].Syscall
+		}
+		return sleeps[i].Duration < sleeps[j].Duration
+	})
+	return sleeps
+}
+
 // Commands returns all the exec'd commands from the parsed strace.
 func (r *Result) Commands() []CommandInfo {
 	// Sort the keys so the output is in a stable order
@@ -422,3 +1319,210 @@ func (r *Result) Commands() []CommandInfo {
 	}
 	return commands
 }
+
+// interpreters are the names of programs that run a script given as their first
+// non-option argument. Version suffixes (e.g. python3.11) are ignored.
+var interpreters = map[string]struct{}{
+	"node":   {},
+	"nodejs": {},
+	"python": {},
+	"ruby":   {},
+	"php":    {},
+	"perl":   {},
+	"sh":     {},
+	"bash":   {},
+	"dash":   {},
+}
+
+// inlineCodeOptions are interpreter options which mean that the code to run is
+// given on the command line, rather than in a script file.
+var inlineCodeOptions = map[string]struct{}{
+	"-c":     {},
+	"-e":     {},
+	"--eval": {},
+	"-r":     {},
+}
+
+// runFile returns the path of the file run by the given command: the script for
+// an interpreter, and otherwise the executable. The path may be relative to the
+// working directory of the command. If there is no such file, ok is false.
+func runFile(cmd []string) (file string, ok bool) {
+	if len(cmd) == 0 {
+		return "", false
+	}
+	name := strings.TrimRight(filepath.Base(cmd[0]), "0123456789.")
+	if _, isInterpreter := interpreters[name]; !isInterpreter {
+		return cmd[0], true
+	}
+	for _, arg := range cmd[1:] {
+		if _, inline := inlineCodeOptions[arg]; inline {
+			return "", false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return arg, true
+		}
+	}
+	return "", false
+}
+
+/*
+SelfModifications returns the files that were run by a command and then written to
+or deleted later in the parsed strace, such as an install script that deletes itself
+to cover its tracks. The file run by a command is the script passed to an interpreter
+(e.g. install.js for 'node install.js'), or otherwise the executable itself.
+
+Since the working directory of commands is not known, relative paths are matched
+against the end of the path of each file accessed. To reduce false matches, a file
+must also have been read after the command was run.
+
+Results are sorted by path, and then by command.
+*/
+func (r *Result) SelfModifications() []SelfModificationInfo {
+	var mods []SelfModificationInfo
+	for key, commandEvent := range r.commandEvents {
+		cmd := r.commands[key].Command
+		file, ok := runFile(cmd)
+		if !ok {
+			continue
+		}
+		file = filepath.Clean(file)
+		if strings.HasPrefix(file, "..") {
+			continue
+		}
+		for path, e := range r.fileEvents {
+			if path != file && (filepath.IsAbs(file) || !strings.HasSuffix(path, "/"+file)) {
+				continue
+			}
+			if e.lastRead <= commandEvent {
+				continue
+			}
+			write, del := e.lastWrite > commandEvent, e.lastDelete > commandEvent
+			if write || del {
+				mods = append(mods, SelfModificationInfo{Path: path, Command: cmd, Write: write, Delete: del})
+			}
+		}
+	}
+	sort.Slice(mods, func(i, j int) bool {
+		if mods[i].Path != mods[j].Path {
+			return mods[i].Path < mods[j].Path
+		}
+		return strings.Join(mods[i].Command, " ") < strings.Join(mods[j].Command, " ")
+	})
+	return mods
+}
+
+/*
+ConnectionsAfterCommands returns the commands in the parsed strace that were
+followed by a connection, each with the sockets that were connected to after the
+command was first run, such as a command whose output is then sent to a remote
+host. Since the process which connected is not known, the connections may have
+been made by any process.
+
+Results are sorted like Commands, and their sockets like Sockets.
+*/
+func (r *Result) ConnectionsAfterCommands() []CommandConnectionsInfo {
+	connections := make([]string, 0, len(r.connectionEvents))
+	for k := range r.connectionEvents {
+		connections = append(connections, k)
+	}
+	sort.Strings(connections)
+	commands := make([]string, 0, len(r.commandEvents))
+	for k := range r.commandEvents {
+		commands = append(commands, k)
+	}
+	sort.Strings(commands)
+
+	var result []CommandConnectionsInfo
+	for _, cmd := range commands {
+		var sockets []SocketInfo
+		for _, conn := range connections {
+			if r.connectionEvents[conn] > r.commandEvents[cmd] {
+				sockets = append(sockets, *r.sockets[conn])
+			}
+		}
+		if len(sockets) > 0 {
+			result = append(result, CommandConnectionsInfo{Command: r.commands[cmd].Command, Env: r.commands[cmd].Env, Sockets: sockets})
+		}
+	}
+	return result
+}
+
+/*
+ConnectionsAfterReads returns the sockets in the parsed strace that were connected
+to after the first read of any of the given files, such as a connection which sends
+their contents to a remote host. Since the process which connected is not known,
+the connections may have been made by any process. Files which were not read are
+ignored.
+
+Sockets are sorted like Sockets.
+*/
+func (r *Result) ConnectionsAfterReads(paths []string) []SocketInfo {
+	first := 0
+	for _, p := range paths {
+		if e, ok := r.fileEvents[p]; ok && e.firstRead > 0 && (first == 0 || e.firstRead < first) {
+			first = e.firstRead
+		}
+	}
+	if first == 0 {
+		return nil
+	}
+
+	connections := make([]string, 0, len(r.connectionEvents))
+	for k, event := range r.connectionEvents {
+		if event > first {
+			connections = append(connections, k)
+		}
+	}
+	sort.Strings(connections)
+
+	var sockets []SocketInfo
+	for _, k := range connections {
+		sockets = append(sockets, *r.sockets[k])
+	}
+	return sockets
+}
+
+/*
+WrittenFileRuns returns the files that were written and then run by a command later
+in the parsed strace, such as a payload that is downloaded and then executed. A file
+is run by a command if it is the executable given to execve, or the script passed to
+an interpreter (see SelfModifications).
+
+Relative paths of scripts are matched against the end of the path of each file
+written, since the working directory of commands is not known.
+
+Results are sorted by path, and then by command.
+*/
+func (r *Result) WrittenFileRuns() []WrittenFileRunInfo {
+	var runs []WrittenFileRunInfo
+	seen := make(map[string]bool)
+	for _, run := range r.runEvents {
+		file := filepath.Clean(run.path)
+		if strings.HasPrefix(file, "..") {
+			continue
+		}
+		for path, e := range r.fileEvents {
+			if path != file && (filepath.IsAbs(file) || !strings.HasSuffix(path, "/"+file)) {
+				continue
+			}
+			key := path + "\x00" + strings.Join(run.cmd, "\x00")
+			if e.firstWrite > 0 && e.firstWrite < run.event && !seen[key] {
+				seen[key] = true
+				runs = append(runs, WrittenFileRunInfo{Path: path, Command: run.cmd})
+			}
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].Path != runs[j].Path {
+			return runs[i].Path < runs[j].Path
+		}
+		return strings.Join(runs[i].Command, " ") < strings.Join(runs[j].Command, " ")
+	})
+	return runs
+}
+
+// Links returns the symbolic and hard links created in the parsed strace, in
+// the order they were created.
+func (r *Result) Links() []LinkInfo {
+	return slices.Clone(r.links)
+}
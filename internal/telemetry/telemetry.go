@@ -0,0 +1,231 @@
+/*
+Package telemetry instruments analysis runs with OpenTelemetry traces and metrics.
+
+Each stage of the analysis of a package (e.g. downloading it, static analysis, or
+a phase of dynamic analysis) is recorded as a span, and as a measurement of the
+analysis.stage.duration histogram and the analysis.stage.runs counter, which have
+the stage and its status as attributes so that failure rates can be computed.
+Verdicts are counted by label in the analysis.verdicts counter, and their findings
+by rule in the analysis.findings counter.
+
+Spans and metrics are recorded with the global OpenTelemetry providers, which do
+nothing until real providers are installed, so the instrumentation is free when
+telemetry is not configured. Setup installs providers which export to an OTLP
+collector, if one is configured with the standard OTEL_EXPORTER_OTLP_* environment
+variables. Commands run in the sandboxes export their own stages, as part of the
+trace of the stage that runs them, if they are given SandboxEnvironment.
+*/
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/verdict"
+)
+
+// instrumentationName identifies the tracer and meter of this package.
+const instrumentationName = "github.com/ossf/package-analysis"
+
+// Stages of the analysis of a package.
+const (
+	StageDownload     = "download"
+	StageStatic       = "static"
+	StageParse        = "parse"
+	StageDynamic      = "dynamic"
+	StageDynamicPhase = "dynamic_phase"
+)
+
+// Attribute keys of spans and metrics.
+const (
+	StageKey     = attribute.Key("stage")
+	StatusKey    = attribute.Key("status")
+	EcosystemKey = attribute.Key("ecosystem")
+	PhaseKey     = attribute.Key("phase")
+	RuleKey      = attribute.Key("rule")
+	LabelKey     = attribute.Key("label")
+)
+
+// statusError is the status recorded for a stage which failed with an error.
+const statusError = "error"
+
+type instruments struct {
+	duration metric.Float64Histogram
+	runs     metric.Int64Counter
+	verdicts metric.Int64Counter
+	findings metric.Int64Counter
+}
+
+var (
+	instrumentsOnce sync.Once
+	inst            instruments
+)
+
+// getInstruments returns the metric instruments, creating them on first use. The
+// global meter provider forwards them to the provider installed by Setup, even if
+// they are created before it is called.
+func getInstruments() instruments {
+	instrumentsOnce.Do(func() {
+		meter := otel.Meter(instrumentationName)
+		// Errors only occur for invalid instrument names, and a no-op instrument
+		// is returned with them.
+		inst.duration, _ = meter.Float64Histogram("analysis.stage.duration",
+			metric.WithUnit("s"),
+			metric.WithDescription("Time taken by each stage of analysis"))
+		inst.runs, _ = meter.Int64Counter("analysis.stage.runs",
+			metric.WithDescription("Number of times each stage of analysis was run, by status"))
+		inst.verdicts, _ = meter.Int64Counter("analysis.verdicts",
+			metric.WithDescription("Number of packages given each verdict label"))
+		inst.findings, _ = meter.Int64Counter("analysis.findings",
+			metric.WithDescription("Number of findings of each verdict rule"))
+	})
+	return inst
+}
+
+// Stage is a stage of analysis in progress, started by StartStage.
+type Stage struct {
+	name  string
+	attrs []attribute.KeyValue
+	span  trace.Span
+	start time.Time
+}
+
+// StartStage starts recording the stage of analysis with the given name (e.g.
+// StageDynamic), as a child of any span in ctx. attrs are added to the span and
+// to the metrics of the stage. The returned context holds the span of the stage,
+// so that later stages started with it are recorded as part of this one.
+func StartStage(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, *Stage) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &Stage{name: name, attrs: attrs, span: span, start: time.Now()}
+}
+
+// End records that the stage finished with the given status (e.g.
+// analysis.StatusCompleted) and error. If err is not nil, the span of the stage is
+// marked as failed, and the status is recorded as "error", since an error means
+// that the stage could not be run rather than that the package failed.
+func (s *Stage) End(ctx context.Context, status analysis.Status, err error) {
+	statusValue := string(status)
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+		statusValue = statusError
+	}
+	attrs := append([]attribute.KeyValue{StageKey.String(s.name)}, s.attrs...)
+	if statusValue != "" {
+		s.span.SetAttributes(StatusKey.String(statusValue))
+		attrs = append(attrs, StatusKey.String(statusValue))
+	}
+	s.span.End()
+
+	set := metric.WithAttributes(attrs...)
+	i := getInstruments()
+	i.duration.Record(ctx, time.Since(s.start).Seconds(), set)
+	i.runs.Add(ctx, 1, set)
+}
+
+// RecordVerdict counts the label of v, and the findings of each rule that
+// contributed to it. attrs (e.g. the ecosystem) are added to the measurements.
+func RecordVerdict(ctx context.Context, v verdict.Verdict, attrs ...attribute.KeyValue) {
+	i := getInstruments()
+	i.verdicts.Add(ctx, 1, metric.WithAttributes(append([]attribute.KeyValue{LabelKey.String(string(v.Label))}, attrs...)...))
+	for _, c := range v.Contributions {
+		i.findings.Add(ctx, int64(c.Count), metric.WithAttributes(append([]attribute.KeyValue{RuleKey.String(c.Rule)}, attrs...)...))
+	}
+}
+
+// Configured returns whether an OTLP collector is configured by the environment,
+// i.e. whether Setup installs exporting providers.
+func Configured() bool {
+	for _, name := range []string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_METRICS_ENDPOINT",
+	} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// traceContextVars are the environment variables which hold the W3C Trace Context
+// headers of the same name, in upper case, to pass a span to a sandbox.
+var traceContextVars = []string{"traceparent", "tracestate"}
+
+/*
+SandboxEnvironment returns the environment variables which let a command run in a
+sandbox export telemetry (see Setup) as part of the trace of ctx: the OTEL_*
+variables that configure the collector, except OTEL_SERVICE_NAME, so that the
+command keeps its own service name, and TRACEPARENT and TRACESTATE, which hold
+the span of ctx (see ContextFromEnvironment). It returns nothing if no collector is
+configured.
+*/
+func SandboxEnvironment(ctx context.Context) map[string]string {
+	if !Configured() {
+		return nil
+	}
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if k, v, _ := strings.Cut(kv, "="); strings.HasPrefix(k, "OTEL_") && k != "OTEL_SERVICE_NAME" {
+			env[k] = v
+		}
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	for _, name := range traceContextVars {
+		if v := carrier.Get(name); v != "" {
+			env[strings.ToUpper(name)] = v
+		}
+	}
+	return env
+}
+
+// ContextFromEnvironment returns ctx with the span held by the TRACEPARENT and
+// TRACESTATE environment variables (see SandboxEnvironment), if they are set, so
+// that the stages started with it are part of the trace of that span.
+func ContextFromEnvironment(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, name := range traceContextVars {
+		if v := os.Getenv(strings.ToUpper(name)); v != "" {
+			carrier.Set(name, v)
+		}
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
+
+/*
+Setup installs global trace and meter providers which export spans and metrics to
+the OTLP collector configured by the OTEL_EXPORTER_OTLP_* environment variables,
+using gRPC. serviceName is the name of the service in the exported data, unless
+it is overridden by OTEL_SERVICE_NAME.
+
+If no collector is configured (see Configured), Setup does nothing, and the global
+providers remain no-ops. The returned function flushes any buffered data and
+stops the providers; it should be called before the program exits.
+*/
+func Setup(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	if !Configured() {
+		return func(context.Context) error { return nil }, nil
+	}
+	p, err := newProviders(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(p.traces)
+	otel.SetMeterProvider(p.metrics)
+	return func(ctx context.Context) error {
+		return errors.Join(p.traces.Shutdown(ctx), p.metrics.Shutdown(ctx))
+	}, nil
+}
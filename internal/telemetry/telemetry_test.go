@@ -0,0 +1,116 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/verdict"
+)
+
+func TestStage(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx := context.Background()
+	ctx, dynamic := StartStage(ctx, StageDynamic, EcosystemKey.String("npm"))
+	phaseCtx, phase := StartStage(ctx, StageDynamicPhase, PhaseKey.String("install"))
+	phase.End(phaseCtx, analysis.StatusErrorTimeout, nil)
+	dynamic.End(ctx, "", errors.New("sandbox failed"))
+	RecordVerdict(ctx, verdict.Verdict{Label: verdict.Suspicious, Contributions: []verdict.Contribution{
+		{Rule: verdict.RuleIndirectEval, Count: 2},
+	}})
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("got %d spans, want 2", len(ended))
+	}
+	phaseSpan, dynamicSpan := ended[0], ended[1]
+	if phaseSpan.Name() != StageDynamicPhase || phaseSpan.Parent().SpanID() != dynamicSpan.SpanContext().SpanID() {
+		t.Errorf("phase span %q is not a child of the dynamic span", phaseSpan.Name())
+	}
+	if got := attributeValue(phaseSpan.Attributes(), StatusKey); got != string(analysis.StatusErrorTimeout) {
+		t.Errorf("phase span status = %q, want %q", got, analysis.StatusErrorTimeout)
+	}
+	if dynamicSpan.Status().Code != codes.Error || attributeValue(dynamicSpan.Attributes(), StatusKey) != statusError {
+		t.Errorf("dynamic span status = %v, %q; want error", dynamicSpan.Status(), attributeValue(dynamicSpan.Attributes(), StatusKey))
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &metrics); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	sums := make(map[string]int64)
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if data, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, p := range data.DataPoints {
+					stage, _ := p.Attributes.Value(StageKey)
+					sums[m.Name+"/"+stage.AsString()] += p.Value
+				}
+			}
+		}
+	}
+	want := map[string]int64{
+		"analysis.stage.runs/" + StageDynamic:      1,
+		"analysis.stage.runs/" + StageDynamicPhase: 1,
+		"analysis.verdicts/":                       1,
+		"analysis.findings/":                       2,
+	}
+	for name, n := range want {
+		if sums[name] != n {
+			t.Errorf("%s = %d, want %d", name, sums[name], n)
+		}
+	}
+}
+
+func TestSandboxEnvironment(t *testing.T) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	ctx, static := StartStage(context.Background(), StageStatic)
+	defer static.End(ctx, analysis.StatusCompleted, nil)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	if env := SandboxEnvironment(ctx); len(env) != 0 {
+		t.Errorf("SandboxEnvironment() without a collector = %v, want none", env)
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+	env := SandboxEnvironment(ctx)
+	if got := env["OTEL_EXPORTER_OTLP_ENDPOINT"]; got != "http://collector:4317" {
+		t.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT = %q, want http://collector:4317", got)
+	}
+	if env["TRACEPARENT"] == "" {
+		t.Fatalf("SandboxEnvironment() = %v, want TRACEPARENT", env)
+	}
+
+	// as in the sandbox
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	_, parse := StartStage(ContextFromEnvironment(context.Background()), StageParse)
+	want := trace.SpanContextFromContext(ctx)
+	if got := parse.span.SpanContext(); got.TraceID() != want.TraceID() {
+		t.Errorf("trace of stage in sandbox = %v, want %v", got.TraceID(), want.TraceID())
+	}
+}
+
+func attributeValue(attrs []attribute.KeyValue, key attribute.Key) string {
+	for _, a := range attrs {
+		if a.Key == key {
+			return a.Value.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package tlsanalyzer
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+)
+
+const (
+	recordTypeHandshake      = 0x16
+	handshakeTypeClientHello = 0x01
+	extensionServerName      = 0x0000
+	serverNameTypeHostName   = 0x00
+)
+
+type empty struct{}
+
+type endpoint struct {
+	address string
+	port    int
+}
+
+// TLSAnalyzer collects the server names (SNI) sent in TLS ClientHello messages,
+// which give the hostname that a client intended to connect to even if there is
+// no DNS lookup for it in the captured traffic (e.g. because the result was
+// cached, or resolution happened out of band).
+type TLSAnalyzer struct {
+	serverNames map[endpoint]map[string]empty
+}
+
+func New() *TLSAnalyzer {
+	return &TLSAnalyzer{
+		serverNames: make(map[endpoint]map[string]empty),
+	}
+}
+
+func (t *TLSAnalyzer) LayerTypes() []gopacket.LayerType {
+	return []gopacket.LayerType{layers.LayerTypeTCP}
+}
+
+func (t *TLSAnalyzer) Receive(l gopacket.Layer, p gopacket.Packet) {
+	// The layer must be TCP, and the packet must have a destination address.
+	tcp, ok := l.(*layers.TCP)
+	if !ok || len(tcp.Payload) == 0 || p.NetworkLayer() == nil {
+		return
+	}
+
+	name, ok := ServerName(tcp.Payload)
+	if !ok {
+		return
+	}
+
+	dst := net.IP(p.NetworkLayer().NetworkFlow().Dst().Raw())
+	key := endpoint{address: dst.String(), port: int(tcp.DstPort)}
+	if _, exists := t.serverNames[key]; !exists {
+		t.serverNames[key] = make(map[string]empty)
+	}
+	t.serverNames[key][name] = empty{}
+}
+
+// ServerNames returns the server names sent in TLS ClientHello messages to the
+// given IP address and port.
+//
+// Returns the server names found. Otherwise it returns an empty slice.
+func (t *TLSAnalyzer) ServerNames(address string, port int) []string {
+	// We parse the IP to ensure that it is normalized and to exit early if
+	// the address passed in is not valid.
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return []string{}
+	}
+
+	names := make([]string, 0)
+	for name := range t.serverNames[endpoint{address: ip.String(), port: port}] {
+		names = append(names, name)
+	}
+	return names
+}
+
+// reader reads big-endian, length-prefixed fields from a TLS message. Once a
+// read goes past the end of the data, all further reads fail.
+type reader struct {
+	data []byte
+	ok   bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if !r.ok || n > len(r.data) {
+		r.ok = false
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *reader) uint(n int) int {
+	v := 0
+	for _, b := range r.bytes(n) {
+		v = v<<8 | int(b)
+	}
+	return v
+}
+
+// vector reads a field prefixed by its length, which takes n bytes.
+func (r *reader) vector(n int) *reader {
+	return &reader{data: r.bytes(r.uint(n)), ok: r.ok}
+}
+
+/*
+ServerName returns the server name (SNI) from the TLS ClientHello message at the
+start of payload, if there is one. The ClientHello may be truncated, since it can
+be split across TCP segments; the server name is returned if it is before the
+point of truncation.
+
+The server name is lower-cased, and is rejected if it is not a plausible hostname.
+*/
+func ServerName(payload []byte) (string, bool) {
+	r := &reader{data: payload, ok: true}
+
+	// TLS record header: type, legacy version, length.
+	if r.uint(1) != recordTypeHandshake || r.uint(1) != 3 {
+		return "", false
+	}
+	r.bytes(3)
+
+	// Handshake header: type, length.
+	if r.uint(1) != handshakeTypeClientHello {
+		return "", false
+	}
+	r.bytes(3)
+
+	// ClientHello: legacy version, random, session ID, cipher suites, compression methods.
+	r.bytes(2 + 32)
+	r.vector(1)
+	r.vector(2)
+	r.vector(1)
+
+	// Extensions may be truncated, so they are read directly rather than as a vector.
+	r.bytes(2)
+	for r.ok && len(r.data) > 0 {
+		extensionType := r.uint(2)
+		extensionLength := r.uint(2)
+		if extensionType != extensionServerName {
+			r.bytes(extensionLength)
+			continue
+		}
+
+		names := r.vector(2)
+		for names.ok && len(names.data) > 0 {
+			nameType := names.uint(1)
+			name := names.vector(2)
+			if name.ok && nameType == serverNameTypeHostName && validHostname(name.data) {
+				return strings.ToLower(string(name.data)), true
+			}
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// validHostname returns whether name contains only the characters allowed in a
+// hostname, so that corrupt or malicious data is not reported as a server name.
+func validHostname(name []byte) bool {
+	if len(name) == 0 || len(name) > 253 {
+		return false
+	}
+	for _, c := range name {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '.' && c != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package tlsanalyzer
+
+import (
+	"crypto/tls"
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+)
+
+// clientHello returns the first TLS record sent by a client connecting to serverName.
+func clientHello(t *testing.T, serverName string) []byte {
+	t.Helper()
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		conn := tls.Client(client, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+		_ = conn.Handshake()
+	}()
+
+	buf := make([]byte, 64*1024)
+	n, err := server.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read ClientHello: %v", err)
+	}
+	client.Close()
+	return buf[:n]
+}
+
+func TestServerName(t *testing.T) {
+	hello := clientHello(t, "C2.Example.com")
+
+	tests := []struct {
+		name    string
+		payload []byte
+		want    string
+	}{
+		{"client hello", hello, "c2.example.com"},
+		{"truncated after server name", hello[:len(hello)-1], "c2.example.com"},
+		{"truncated before server name", hello[:60], ""},
+		{"no server name", clientHello(t, "198.51.100.7"), ""},
+		{"not a handshake", append([]byte{0x17}, hello[1:]...), ""},
+		{"http", []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := ServerName(tt.payload)
+			if got != tt.want || found != (tt.want != "") {
+				t.Errorf("ServerName() = (%q, %v), want %q", got, found, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceive(t *testing.T) {
+	packet := func(dst string, port int, payload []byte) gopacket.Packet {
+		ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.IPv4(10, 0, 0, 2), DstIP: net.ParseIP(dst)}
+		tcp := &layers.TCP{SrcPort: 40000, DstPort: layers.TCPPort(port), PSH: true, ACK: true}
+		if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+			t.Fatal(err)
+		}
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+		if err := gopacket.SerializeLayers(buf, opts, ip, tcp, gopacket.Payload(payload)); err != nil {
+			t.Fatal(err)
+		}
+		return gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+	}
+
+	analyzer := New()
+	for _, p := range []gopacket.Packet{
+		packet("203.0.113.5", 443, clientHello(t, "a.example.com")),
+		packet("203.0.113.5", 443, clientHello(t, "b.example.com")),
+		packet("203.0.113.5", 8443, clientHello(t, "c.example.com")),
+		packet("203.0.113.6", 443, []byte("not tls")),
+	} {
+		analyzer.Receive(p.Layer(layers.LayerTypeTCP), p)
+	}
+
+	tests := []struct {
+		address string
+		port    int
+		want    []string
+	}{
+		{"203.0.113.5", 443, []string{"a.example.com", "b.example.com"}},
+		{"203.0.113.5", 8443, []string{"c.example.com"}},
+		{"203.0.113.6", 443, []string{}},
+		{"not an ip", 443, []string{}},
+	}
+	for _, tt := range tests {
+		got := analyzer.ServerNames(tt.address, tt.port)
+		slices.Sort(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ServerNames(%q, %d) = %v, want %v", tt.address, tt.port, got, tt.want)
+		}
+	}
+}
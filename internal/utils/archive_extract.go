@@ -3,6 +3,7 @@ package utils
 import (
 	"archive/tar"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,20 +12,108 @@ import (
 	"strings"
 )
 
+// ErrDecompressionBomb is returned (wrapped) when extracting an archive that
+// exceeds the ExtractLimits, which suggests that it is a decompression bomb.
+var ErrDecompressionBomb = errors.New("decompression bomb suspected")
+
+// minRatioCheckSize is the extracted size below which the compression ratio of an
+// archive is not checked. Small archives of repetitive files (e.g. all whitespace)
+// can have a very high compression ratio without being a threat.
+const minRatioCheckSize = 16 << 20
+
+/*
+ExtractLimits bounds the resources used when extracting an archive, to protect
+against decompression bombs: small archives that expand to fill the disk. A limit
+of zero means that it is not enforced.
+
+The compression ratio is the extracted size of the archive (so far) divided by the
+size of the archive file, and is only checked once more than 16 MiB have been
+extracted.
+*/
+type ExtractLimits struct {
+	// MaxTotalSize is the largest total size, in bytes, of all extracted files.
+	MaxTotalSize int64
+	// MaxFiles is the largest number of entries (files and directories).
+	MaxFiles int
+	// MaxFileSize is the largest size, in bytes, of a single extracted file.
+	MaxFileSize int64
+	// MaxCompressionRatio is the largest compression ratio.
+	MaxCompressionRatio float64
+}
+
+// DefaultExtractLimits returns limits that are well above the size of even the
+// largest legitimate packages.
+func DefaultExtractLimits() ExtractLimits {
+	return ExtractLimits{
+		MaxTotalSize:        4 << 30,
+		MaxFiles:            500_000,
+		MaxFileSize:         1 << 30,
+		MaxCompressionRatio: 200,
+	}
+}
+
 // ExtractTarGzFile extracts a .tar.gz / .tgz file located at tgzPath,
-// using outputDir as the root of the extracted files.
+// using outputDir as the root of the extracted files. The DefaultExtractLimits
+// are enforced.
 func ExtractTarGzFile(tgzPath string, outputDir string) error {
+	return ExtractTarGzFileWithLimits(tgzPath, outputDir, DefaultExtractLimits())
+}
+
+/*
+ExtractTarGzFileWithLimits is like ExtractTarGzFile, but enforces the given limits.
+If a limit is exceeded, extraction is aborted with an error wrapping
+ErrDecompressionBomb. Files that have already been extracted are not removed.
+*/
+func ExtractTarGzFileWithLimits(tgzPath string, outputDir string, limits ExtractLimits) error {
 	f, err := os.Open(tgzPath)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
 	return processGzipFile(f, func(reader io.Reader) error {
-		return extractTar(reader, outputDir)
+		return extractTar(reader, outputDir, limits.checker(info.Size()))
 	})
 }
 
+// extractChecker enforces ExtractLimits on the entries of an archive as they are extracted.
+type extractChecker struct {
+	limits         ExtractLimits
+	compressedSize int64
+	files          int
+	totalSize      int64
+}
+
+func (l ExtractLimits) checker(compressedSize int64) *extractChecker {
+	return &extractChecker{limits: l, compressedSize: compressedSize}
+}
+
+// add records the next entry of an archive, and returns an error wrapping
+// ErrDecompressionBomb if it makes the archive exceed a limit.
+func (c *extractChecker) add(name string, size int64) error {
+	c.files++
+	c.totalSize += size
+	l := c.limits
+
+	switch {
+	case l.MaxFiles > 0 && c.files > l.MaxFiles:
+		return fmt.Errorf("%w: archive has more than %d entries", ErrDecompressionBomb, l.MaxFiles)
+	case l.MaxFileSize > 0 && size > l.MaxFileSize:
+		return fmt.Errorf("%w: %s is larger than %d bytes", ErrDecompressionBomb, name, l.MaxFileSize)
+	case l.MaxTotalSize > 0 && c.totalSize > l.MaxTotalSize:
+		return fmt.Errorf("%w: archive contents are larger than %d bytes", ErrDecompressionBomb, l.MaxTotalSize)
+	case l.MaxCompressionRatio > 0 && c.totalSize > minRatioCheckSize && c.compressedSize > 0 &&
+		float64(c.totalSize)/float64(c.compressedSize) > l.MaxCompressionRatio:
+		return fmt.Errorf("%w: archive compression ratio is more than %g", ErrDecompressionBomb, l.MaxCompressionRatio)
+	}
+	return nil
+}
+
 func processGzipFile(gzFile *os.File, process func(io.Reader) error) error {
 	unzippedBytes, err := gzip.NewReader(gzFile)
 	if err != nil {
@@ -45,9 +134,11 @@ func processGzipFile(gzFile *os.File, process func(io.Reader) error) error {
 
 /*
 extractTar extracts the contents of the given stream of bytes of a tar archive, using
-outputDir as the root of the extracted files.
+outputDir as the root of the extracted files. Each entry is checked against the
+limits before it is extracted. Since the tar reader returns exactly the number of
+bytes given in the header of an entry, the size in the header can be trusted.
 */
-func extractTar(tarStream io.Reader, outputDir string) error {
+func extractTar(tarStream io.Reader, outputDir string, checker *extractChecker) error {
 	if outputDir == "" {
 		return fmt.Errorf("outputDir is empty")
 	}
@@ -65,6 +156,10 @@ func extractTar(tarStream io.Reader, outputDir string) error {
 			return fmt.Errorf("archive path escapes output dir: %s", header.Name)
 		}
 
+		if err := checker.add(header.Name, header.Size); err != nil {
+			return err
+		}
+
 		switch header.Typeflag {
 		case tar.TypeDir:
 			// make dir only readable by current user
@@ -3,6 +3,7 @@ package utils
 import (
 	"archive/tar"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -335,3 +336,66 @@ func TestExtractZipSlip3(t *testing.T) {
 		t.Errorf("Error should be about path escaping output dir, instead got %v", err)
 	}
 }
+
+func TestExtractDecompressionBomb(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []*tar.Header
+		limits  ExtractLimits
+		wantErr bool
+	}{
+		{
+			name:    "within limits",
+			headers: []*tar.Header{makeDirHeader("test"), makeFileHeader("test/1.txt", 100), makeFileHeader("test/2.txt", 100)},
+			limits:  ExtractLimits{MaxTotalSize: 200, MaxFiles: 3, MaxFileSize: 100, MaxCompressionRatio: 1},
+		},
+		{
+			name:    "no limits",
+			headers: []*tar.Header{makeFileHeader("1.txt", minRatioCheckSize+1)},
+		},
+		{
+			name:    "too many files",
+			headers: []*tar.Header{makeFileHeader("1.txt", 1), makeFileHeader("2.txt", 1), makeFileHeader("3.txt", 1)},
+			limits:  ExtractLimits{MaxFiles: 2},
+			wantErr: true,
+		},
+		{
+			name:    "file too large",
+			headers: []*tar.Header{makeFileHeader("1.txt", 10), makeFileHeader("2.txt", 101)},
+			limits:  ExtractLimits{MaxFileSize: 100},
+			wantErr: true,
+		},
+		{
+			name:    "total too large",
+			headers: []*tar.Header{makeFileHeader("1.txt", 100), makeFileHeader("2.txt", 101)},
+			limits:  ExtractLimits{MaxTotalSize: 200},
+			wantErr: true,
+		},
+		{
+			// the file is all newlines, so is compressed by far more than 100 times
+			name:    "high compression ratio",
+			headers: []*tar.Header{makeFileHeader("1.txt", minRatioCheckSize+1)},
+			limits:  ExtractLimits{MaxCompressionRatio: 100},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, archivePath, extractPath, err := makePaths(t, "bomb")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := createTgzFile(archivePath, tt.headers); err != nil {
+				t.Fatalf("failed to create test tgz file: %v", err)
+			}
+
+			err = ExtractTarGzFileWithLimits(archivePath, extractPath, tt.limits)
+			if tt.wantErr != errors.Is(err, ErrDecompressionBomb) {
+				t.Errorf("ExtractTarGzFileWithLimits() error = %v, want %v: %v", err, ErrDecompressionBomb, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("ExtractTarGzFileWithLimits() error = %v", err)
+			}
+		})
+	}
+}
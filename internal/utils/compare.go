@@ -0,0 +1,13 @@
+package utils
+
+// FirstNonZero returns the first of the given comparison results that is not 0,
+// or 0 if they are all 0. It is used to compare by several keys in turn, e.g. in
+// the comparison function of slices.SortFunc.
+func FirstNonZero(cmps ...int) int {
+	for _, c := range cmps {
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
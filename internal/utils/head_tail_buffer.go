@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+)
+
+/*
+HeadTailBuffer is an io.Writer that retains at most the first HeadSize and the
+last TailSize bytes written to it, discarding anything in between. It allows a
+potentially unbounded stream of data to be captured in bounded memory, while
+keeping the parts that are usually of most interest.
+
+The zero value retains nothing; use NewHeadTailBuffer to create a HeadTailBuffer.
+*/
+type HeadTailBuffer struct {
+	head     []byte
+	headSize int
+	// tail is used as a ring buffer once it is full, with tailStart being the index
+	// of the oldest byte.
+	tail      []byte
+	tailSize  int
+	tailStart int
+	total     int64
+}
+
+// NewHeadTailBuffer returns a HeadTailBuffer retaining the first headSize and the
+// last tailSize bytes written to it.
+func NewHeadTailBuffer(headSize, tailSize int) *HeadTailBuffer {
+	if headSize < 0 || tailSize < 0 {
+		panic("sizes cannot be negative")
+	}
+	return &HeadTailBuffer{headSize: headSize, tailSize: tailSize}
+}
+
+// Write implements io.Writer. It never returns an error.
+func (b *HeadTailBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += int64(n)
+
+	if room := b.headSize - len(b.head); room > 0 {
+		take := min(room, len(p))
+		b.head = append(b.head, p[:take]...)
+		p = p[take:]
+	}
+	if b.tailSize == 0 || len(p) == 0 {
+		return n, nil
+	}
+
+	if len(p) >= b.tailSize {
+		// p replaces the whole tail
+		b.tail = append(b.tail[:0], p[len(p)-b.tailSize:]...)
+		b.tailStart = 0
+		return n, nil
+	}
+	if room := b.tailSize - len(b.tail); room > 0 {
+		take := min(room, len(p))
+		b.tail = append(b.tail, p[:take]...)
+		p = p[take:]
+	}
+	// the tail is full, so overwrite the oldest bytes
+	for len(p) > 0 {
+		copied := copy(b.tail[b.tailStart:], p)
+		p = p[copied:]
+		b.tailStart = (b.tailStart + copied) % b.tailSize
+	}
+	return n, nil
+}
+
+// TotalBytes returns the total number of bytes written to b.
+func (b *HeadTailBuffer) TotalBytes() int64 {
+	return b.total
+}
+
+// Truncated returns whether any bytes written to b were discarded.
+func (b *HeadTailBuffer) Truncated() bool {
+	return b.total > int64(len(b.head)+len(b.tail))
+}
+
+// DiscardedBytes returns the number of bytes written to b that were not retained.
+func (b *HeadTailBuffer) DiscardedBytes() int64 {
+	return b.total - int64(len(b.head)+len(b.tail))
+}
+
+/*
+WriteTo writes the retained bytes to w: the head, followed by the tail. If any
+bytes were discarded, a line noting how many is written between the head and tail.
+It implements io.WriterTo.
+*/
+func (b *HeadTailBuffer) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	write := func(p []byte) error {
+		n, err := w.Write(p)
+		written += int64(n)
+		return err
+	}
+
+	if err := write(b.head); err != nil {
+		return written, err
+	}
+	if b.Truncated() {
+		if err := write([]byte(fmt.Sprintf("\n[... %d bytes truncated ...]\n", b.DiscardedBytes()))); err != nil {
+			return written, err
+		}
+	}
+	if err := write(b.tail[b.tailStart:]); err != nil {
+		return written, err
+	}
+	if err := write(b.tail[:b.tailStart]); err != nil {
+		return written, err
+	}
+	return written, nil
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHeadTailBuffer(t *testing.T) {
+	tests := []struct {
+		name          string
+		headSize      int
+		tailSize      int
+		writes        []string
+		want          string
+		wantTruncated bool
+	}{
+		{
+			name:     "empty",
+			headSize: 4,
+			tailSize: 4,
+			want:     "",
+		},
+		{
+			name:     "fits in head",
+			headSize: 4,
+			tailSize: 4,
+			writes:   []string{"ab", "c"},
+			want:     "abc",
+		},
+		{
+			name:     "fits in head and tail",
+			headSize: 2,
+			tailSize: 4,
+			writes:   []string{"abcdef"},
+			want:     "abcdef",
+		},
+		{
+			name:          "single large write",
+			headSize:      2,
+			tailSize:      3,
+			writes:        []string{"abcdefghij"},
+			want:          "ab\n[... 5 bytes truncated ...]\nhij",
+			wantTruncated: true,
+		},
+		{
+			name:          "tail wraps around",
+			headSize:      2,
+			tailSize:      3,
+			writes:        []string{"ab", "cd", "ef", "g", "hi"},
+			want:          "ab\n[... 4 bytes truncated ...]\nghi",
+			wantTruncated: true,
+		},
+		{
+			name:          "head only",
+			headSize:      3,
+			tailSize:      0,
+			writes:        []string{"abcd", "ef"},
+			want:          "abc\n[... 3 bytes truncated ...]\n",
+			wantTruncated: true,
+		},
+		{
+			name:          "tail only",
+			headSize:      0,
+			tailSize:      3,
+			writes:        []string{"abcd", "e", "f"},
+			want:          "\n[... 3 bytes truncated ...]\ndef",
+			wantTruncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewHeadTailBuffer(tt.headSize, tt.tailSize)
+			var total int64
+			for _, w := range tt.writes {
+				if n, err := b.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v; want %d, nil", w, n, err, len(w))
+				}
+				total += int64(len(w))
+			}
+
+			var out bytes.Buffer
+			if _, err := b.WriteTo(&out); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("WriteTo() wrote %q, want %q", got, tt.want)
+			}
+			if got := b.Truncated(); got != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", got, tt.wantTruncated)
+			}
+			if got := b.TotalBytes(); got != total {
+				t.Errorf("TotalBytes() = %d, want %d", got, total)
+			}
+		})
+	}
+}
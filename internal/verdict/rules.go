@@ -0,0 +1,580 @@
+package verdict
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals/detections"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// Rules for findings of static analysis.
+const (
+	RuleReverseShell         = "static.reverse_shell"
+	RulePossibleReverseShell = "static.possible_reverse_shell"
+	RuleIndirectEval         = "static.indirect_eval"
+	RuleObfuscation          = "static.obfuscation"
+	RuleInternalAPI          = "static.internal_api"
+	RuleWasm                 = "static.wasm"
+	RuleWalletAddress        = "static.wallet_address"
+	RuleClipboardAccess      = "static.clipboard_access"
+	RuleInsecureTransport    = "static.insecure_transport"
+	RuleEnvURLRequest        = "static.env_url_request"
+	RuleDynamicURLRequest    = "static.dynamic_url_request"
+	RuleLongDelay            = "static.long_delay"
+	RuleUnexplainedNetwork   = "static.unexplained_network_imports"
+	RulePlatformGated        = "static.platform_gated"
+	RuleEarlyExit            = "static.early_exit"
+	RuleRemoteScript         = "static.remote_script"
+	RuleInputCapture         = "static.input_capture"
+	RuleTimingCheck          = "static.timing_check"
+	RuleShadowedCommand      = "static.shadowed_command"
+	RulePackedCode           = "static.packed_code"
+	RuleWorkerCode           = "static.worker_code"
+	RuleDecodedExecution     = "static.decoded_execution"
+	RuleOutsideImport        = "static.outside_import"
+	RuleNativeAddon          = "static.native_addon"
+	RuleBinaryPayload        = "static.binary_payload"
+	RuleCanaryExfiltrated    = "dynamic.canary_exfiltrated"
+	RuleCanaryRead           = "dynamic.canary_read"
+	RuleForkBomb             = "dynamic.fork_bomb"
+	RuleSandboxEvasion       = "dynamic.sandbox_evasion"
+	RuleSelfModification     = "dynamic.self_modification"
+	RuleDelayedPayload       = "dynamic.delayed_payload"
+	RulePrivilegedSyscall    = "dynamic.privileged_syscall"
+	RuleNetworkActivity      = "dynamic.network_activity"
+	RulePlainHTTP            = "dynamic.plain_http"
+	RuleWriteOutsidePackage  = "dynamic.write_outside_package"
+	RuleCommand              = "dynamic.command"
+	RuleDaemonProcess        = "dynamic.daemon_process"
+	RuleLingeringProcess     = "dynamic.lingering_process"
+	RuleAbruptTermination    = "dynamic.abrupt_termination"
+	RuleLinkOutsidePackage   = "dynamic.link_outside_package"
+	RuleBroadFileReads       = "dynamic.broad_file_reads"
+	RuleModuleWrite          = "dynamic.module_write"
+	RuleRegistryPublish      = "dynamic.registry_publish"
+	RuleRegistryTakeover     = "dynamic.registry_takeover"
+	RuleCredentialStoreRead  = "dynamic.credential_store_read"
+	RuleShellProfileWrite    = "dynamic.shell_profile_write"
+	RulePathModification     = "dynamic.path_modification"
+	RulePathHijack           = "dynamic.path_hijack"
+	RuleOutsidePackageLoad   = "dynamic.outside_package_load"
+	RuleExecutableDrop       = "dynamic.executable_drop"
+	RuleArmedExecutable      = "dynamic.armed_executable"
+	RuleNativeLibraryLoad    = "dynamic.native_library_load"
+	RuleReconExfiltration    = "dynamic.recon_exfiltration"
+	RuleStagedExecution      = "dynamic.staged_execution"
+	RuleSourceExfiltration   = "dynamic.source_exfiltration"
+	RuleDirectIPConnection   = "dynamic.direct_ip_connection"
+	RuleSecurityTampering    = "dynamic.security_tampering"
+)
+
+// defaultWeights are the weights of each rule, chosen so that a single strong
+// indicator of malice (e.g. a reverse shell or exfiltrated credentials) makes a
+// package likely malicious, while weak indicators only do so in combination.
+var defaultWeights = map[string]float64{
+	RuleReverseShell:         10,
+	RulePossibleReverseShell: 4,
+	RuleIndirectEval:         2,
+	RuleObfuscation:          2,
+	RuleInternalAPI:          2,
+	RuleWasm:                 1,
+	RuleWalletAddress:        2,
+	RuleClipboardAccess:      1.5,
+	RuleInsecureTransport:    1,
+	RuleEnvURLRequest:        3,
+	RuleDynamicURLRequest:    0.5,
+	RuleLongDelay:            1,
+	RuleUnexplainedNetwork:   1,
+	RulePlatformGated:        1,
+	RuleEarlyExit:            0.5,
+	RuleRemoteScript:         5,
+	RuleInputCapture:         3,
+	RuleTimingCheck:          2,
+	RuleShadowedCommand:      4,
+	RulePackedCode:           4,
+	RuleWorkerCode:           2,
+	RuleDecodedExecution:     8,
+	RuleOutsideImport:        4,
+	RuleNativeAddon:          3,
+	RuleBinaryPayload:        6,
+	RuleCanaryExfiltrated:    10,
+	RuleCanaryRead:           4,
+	RuleForkBomb:             5,
+	RuleSandboxEvasion:       3,
+	RuleSelfModification:     3,
+	RuleDelayedPayload:       2,
+	RulePrivilegedSyscall:    2,
+	RuleNetworkActivity:      1,
+	RulePlainHTTP:            1,
+	RuleWriteOutsidePackage:  1,
+	RuleCommand:              0.25,
+	RuleDaemonProcess:        4,
+	RuleLingeringProcess:     1,
+	RuleAbruptTermination:    1,
+	RuleLinkOutsidePackage:   3,
+	RuleBroadFileReads:       3,
+	RuleModuleWrite:          6,
+	RuleRegistryPublish:      4,
+	RuleRegistryTakeover:     10,
+	RuleCredentialStoreRead:  5,
+	RuleShellProfileWrite:    5,
+	RulePathModification:     1,
+	RulePathHijack:           10,
+	RuleOutsidePackageLoad:   4,
+	RuleExecutableDrop:       3,
+	RuleArmedExecutable:      7,
+	RuleNativeLibraryLoad:    5,
+	RuleReconExfiltration:    8,
+	RuleStagedExecution:      8,
+	RuleSourceExfiltration:   8,
+	RuleDirectIPConnection:   3,
+	RuleSecurityTampering:    10,
+}
+
+// ruleCategories are the categories of each rule (one of the staticapi.Category
+// constants), shared with the findings of static analysis.
+var ruleCategories = map[string]string{
+	RuleReverseShell:         staticapi.CategoryExecution,
+	RulePossibleReverseShell: staticapi.CategoryExecution,
+	RuleIndirectEval:         staticapi.CategoryObfuscation,
+	RuleObfuscation:          staticapi.CategoryObfuscation,
+	RuleInternalAPI:          staticapi.CategoryExecution,
+	RuleWasm:                 staticapi.CategoryExecution,
+	RuleWalletAddress:        staticapi.CategoryCredentialTheft,
+	RuleClipboardAccess:      staticapi.CategoryCredentialTheft,
+	RuleInsecureTransport:    staticapi.CategoryNetwork,
+	RuleEnvURLRequest:        staticapi.CategoryNetwork,
+	RuleDynamicURLRequest:    staticapi.CategoryNetwork,
+	RuleLongDelay:            staticapi.CategoryEvasion,
+	RuleUnexplainedNetwork:   staticapi.CategoryNetwork,
+	RulePlatformGated:        staticapi.CategoryEvasion,
+	RuleEarlyExit:            staticapi.CategoryEvasion,
+	RuleRemoteScript:         staticapi.CategoryExecution,
+	RuleInputCapture:         staticapi.CategoryCredentialTheft,
+	RuleTimingCheck:          staticapi.CategoryEvasion,
+	RuleShadowedCommand:      staticapi.CategoryExecution,
+	RulePackedCode:           staticapi.CategoryObfuscation,
+	RuleWorkerCode:           staticapi.CategoryObfuscation,
+	RuleDecodedExecution:     staticapi.CategoryObfuscation,
+	RuleOutsideImport:        staticapi.CategoryFilesystem,
+	RuleNativeAddon:          staticapi.CategoryExecution,
+	RuleBinaryPayload:        staticapi.CategoryObfuscation,
+	RuleCanaryExfiltrated:    staticapi.CategoryCredentialTheft,
+	RuleCanaryRead:           staticapi.CategoryCredentialTheft,
+	RuleForkBomb:             staticapi.CategoryExecution,
+	RuleSandboxEvasion:       staticapi.CategoryEvasion,
+	RuleSelfModification:     staticapi.CategoryEvasion,
+	RuleDelayedPayload:       staticapi.CategoryEvasion,
+	RulePrivilegedSyscall:    staticapi.CategoryExecution,
+	RuleNetworkActivity:      staticapi.CategoryNetwork,
+	RulePlainHTTP:            staticapi.CategoryNetwork,
+	RuleWriteOutsidePackage:  staticapi.CategoryFilesystem,
+	RuleCommand:              staticapi.CategoryExecution,
+	RuleDaemonProcess:        staticapi.CategoryExecution,
+	RuleLingeringProcess:     staticapi.CategoryExecution,
+	RuleAbruptTermination:    staticapi.CategoryEvasion,
+	RuleLinkOutsidePackage:   staticapi.CategoryFilesystem,
+	RuleBroadFileReads:       staticapi.CategoryFilesystem,
+	RuleModuleWrite:          staticapi.CategoryFilesystem,
+	RuleRegistryPublish:      staticapi.CategoryNetwork,
+	RuleRegistryTakeover:     staticapi.CategoryCredentialTheft,
+	RuleCredentialStoreRead:  staticapi.CategoryCredentialTheft,
+	RuleShellProfileWrite:    staticapi.CategoryFilesystem,
+	RulePathModification:     staticapi.CategoryExecution,
+	RulePathHijack:           staticapi.CategoryExecution,
+	RuleOutsidePackageLoad:   staticapi.CategoryFilesystem,
+	RuleExecutableDrop:       staticapi.CategoryFilesystem,
+	RuleArmedExecutable:      staticapi.CategoryExecution,
+	RuleNativeLibraryLoad:    staticapi.CategoryExecution,
+	RuleReconExfiltration:    staticapi.CategoryCredentialTheft,
+	RuleStagedExecution:      staticapi.CategoryExecution,
+	RuleSourceExfiltration:   staticapi.CategoryNetwork,
+	RuleDirectIPConnection:   staticapi.CategoryNetwork,
+	RuleSecurityTampering:    staticapi.CategoryEvasion,
+}
+
+// RuleCategory returns the category of rule, or the empty string if rule does not
+// exist.
+func RuleCategory(rule string) string {
+	return ruleCategories[rule]
+}
+
+// DefaultWeights returns the default weight of each rule.
+func DefaultWeights() map[string]float64 {
+	return maps.Clone(defaultWeights)
+}
+
+// packageRules are the rules that count at most once towards the score of a
+// package, since a package which binds to native code loads its addon from many
+// places and in every phase.
+var packageRules = map[string]bool{
+	RuleNativeAddon:       true,
+	RuleNativeLibraryLoad: true,
+}
+
+// maxCount returns the largest number of occurrences of rule that count towards
+// the score (see MaxCount).
+func maxCount(rule string) int {
+	if packageRules[rule] {
+		return 1
+	}
+	return MaxCount
+}
+
+// finding is a single occurrence of a rule, with an example that describes it.
+type finding struct {
+	rule    string
+	example string
+}
+
+// isObfuscated returns whether a file contains strings or identifiers that are
+// typical of obfuscated code.
+func isObfuscated(f staticapi.FileResult) bool {
+	if len(f.EscapedStrings) > 0 {
+		return true
+	}
+	for _, id := range f.SuspiciousIdentifiers {
+		if id.Rule == "hex" {
+			return true
+		}
+	}
+	return false
+}
+
+// inputCaptureDetail describes an input capture, e.g. `document.addEventListener("keydown")`.
+func inputCaptureDetail(c staticapi.InputCapture) string {
+	if c.Event != "" {
+		return fmt.Sprintf("%s(%q)", c.API, c.Event)
+	}
+	return c.API
+}
+
+// staticFindings returns the findings of the static analysis results.
+func staticFindings(r *staticapi.Results) []finding {
+	var findings []finding
+	add := func(rule string, format string, args ...any) {
+		findings = append(findings, finding{rule: rule, example: fmt.Sprintf(format, args...)})
+	}
+
+	for _, f := range r.Files {
+		for _, s := range f.ReverseShells {
+			// the streams of the shell are not known to be wired to the socket
+			rule := RulePossibleReverseShell
+			if s.SocketWired {
+				rule = RuleReverseShell
+			}
+			add(rule, "%s: %s via %s", f.Filename, s.Shell, s.SocketCallee)
+		}
+		for _, e := range f.IndirectEvals {
+			add(RuleIndirectEval, "%s: %s", f.Filename, e.Callee)
+		}
+		if isObfuscated(f) {
+			add(RuleObfuscation, "%s", f.Filename)
+		}
+		for _, u := range f.InternalAPIUsages {
+			add(RuleInternalAPI, "%s: %s", f.Filename, u.API)
+		}
+		for _, w := range f.WasmInstantiations {
+			add(RuleWasm, "%s: %s", f.Filename, w.Function)
+		}
+		for _, w := range f.WalletAddresses {
+			add(RuleWalletAddress, "%s: %s", f.Filename, w.Address)
+		}
+		for _, c := range f.ClipboardAccesses {
+			add(RuleClipboardAccess, "%s: %s", f.Filename, c.API)
+		}
+		for _, t := range f.InsecureTransport {
+			add(RuleInsecureTransport, "%s: %s", f.Filename, t.Detail)
+		}
+		for _, n := range f.NetworkRequests {
+			switch n.URLSource {
+			case detections.EnvironmentURL:
+				add(RuleEnvURLRequest, "%s: %s(%s)", f.Filename, n.API, n.URL)
+			case detections.DynamicURL:
+				add(RuleDynamicURLRequest, "%s: %s(%s)", f.Filename, n.API, n.URL)
+			}
+		}
+		for _, d := range f.LongDelays {
+			add(RuleLongDelay, "%s: %s(%d ms)", f.Filename, d.Function, d.DelayMs)
+		}
+		for _, c := range f.PlatformConditions {
+			if c.Significant {
+				add(RulePlatformGated, "%s: %s (%d chars)", f.Filename, c.Platform, c.GatedLength)
+			}
+		}
+		for _, e := range f.EarlyExits {
+			add(RuleEarlyExit, "%s: %s", f.Filename, e.Function)
+		}
+		for _, c := range f.ConfigCommands {
+			if c.RemoteExecution {
+				add(RuleRemoteScript, "%s: %s runs %q", f.Filename, c.Key, c.Command)
+			}
+		}
+		// input capture is routine in browser code, unless the input may be sent
+		// away, so this is found once for each file that also sends data
+		var captures []string
+		for _, c := range f.InputCaptures {
+			if c.NetworkSend {
+				captures = append(captures, inputCaptureDetail(c))
+			}
+		}
+		if len(captures) > 0 {
+			add(RuleInputCapture, "%s: %s", f.Filename, strings.Join(captures, ", "))
+		}
+		for _, c := range f.TimingChecks {
+			add(RuleTimingCheck, "%s: %s at %d:%d", f.Filename, c.API, c.Pos.Row(), c.Pos.Col())
+		}
+		// a low confidence classification rests on a single indicator, which may
+		// be found in ordinary code
+		for _, p := range f.PackedCode {
+			if p.Confidence != detections.PackedConfidenceLow {
+				add(RulePackedCode, "%s: %s confidence (%s)", f.Filename, p.Confidence, strings.Join(p.Indicators, ", "))
+			}
+		}
+		// workers running a script file are routine, but code built at runtime or
+		// embedded in a string is hidden from analysis of the main thread
+		for _, u := range f.ThreadUsages {
+			if u.Type == detections.ThreadWorker && u.WorkerSource != detections.WorkerSourceFile {
+				add(RuleWorkerCode, "%s: %s (%s)", f.Filename, u.API, u.WorkerSource)
+			}
+		}
+		for _, e := range f.DecodedExecutions {
+			add(RuleDecodedExecution, "%s: %s(%s(...)) at %d:%d", f.Filename, e.Sink, e.Decoder, e.Pos.Row(), e.Pos.Col())
+		}
+		for _, i := range f.OutsideImports {
+			add(RuleOutsideImport, "%s: %s at %d:%d", f.Filename, i.Module, i.Pos.Row(), i.Pos.Col())
+		}
+		for _, l := range f.NativeAddonLoads {
+			add(RuleNativeAddon, "%s: %s %s at %d:%d", f.Filename, l.Type, l.Library, l.Pos.Row(), l.Pos.Col())
+		}
+		// byte arrays which are not written out or compiled are usually tables or assets
+		for _, p := range f.BinaryPayloads {
+			if p.Sink != "" {
+				add(RuleBinaryPayload, "%s: %d bytes from %s passed to %s at %d:%d", f.Filename, p.Size, p.Constructor, p.Sink, p.Pos.Row(), p.Pos.Col())
+			}
+		}
+	}
+
+	if r.Manifest != nil && r.Manifest.UnexplainedNetworkImports {
+		add(RuleUnexplainedNetwork, "%s", strings.Join(r.Manifest.NetworkImports, ", "))
+	}
+	if r.Manifest != nil && len(r.Manifest.ShadowedCommands) > 0 {
+		add(RuleShadowedCommand, "bin %s", strings.Join(r.Manifest.ShadowedCommands, ", "))
+	}
+	return findings
+}
+
+// earlyExitLocations returns the calls in the static analysis results that
+// unconditionally end the process, e.g. "process.exit in index.js".
+func earlyExitLocations(r *staticapi.Results) []string {
+	var locations []string
+	if r == nil {
+		return locations
+	}
+	for _, f := range r.Files {
+		for _, e := range f.EarlyExits {
+			locations = append(locations, fmt.Sprintf("%s in %s", e.Function, f.Filename))
+		}
+	}
+	return locations
+}
+
+// destinationsDetail describes the hosts connected to, by hostname where known.
+func destinationsDetail(sockets []analysisrun.SocketResult) string {
+	destinations := make([]string, 0, len(sockets))
+	for _, d := range sockets {
+		host := d.Address
+		if len(d.Hostnames) > 0 {
+			host = d.Hostnames[0]
+		}
+		destinations = append(destinations, net.JoinHostPort(host, strconv.Itoa(d.Port)))
+	}
+	return strings.Join(destinations, ", ")
+}
+
+// reconDetail describes a reconnaissance command and the hosts connected to after
+// it.
+func reconDetail(r analysisrun.ReconExfiltrationResult) string {
+	return fmt.Sprintf("%s -> %s", r.Tool, destinationsDetail(r.Destinations))
+}
+
+// sourceExfiltrationDetail describes a repository or source tree that was read and
+// the hosts connected to after it.
+func sourceExfiltrationDetail(r analysisrun.SourceExfiltrationResult) string {
+	return fmt.Sprintf("%s %s (%d files) -> %s", r.Kind, r.Root, r.Files, destinationsDetail(r.Destinations))
+}
+
+// tamperingDetail describes an action which disables or evades security tooling.
+func tamperingDetail(t analysisrun.SecurityTamperingResult) string {
+	if t.Path != "" {
+		return fmt.Sprintf("%s: %s", t.Rule, t.Path)
+	}
+	return fmt.Sprintf("%s: %s", t.Rule, strings.Join(t.Command, " "))
+}
+
+// terminationDetail describes a phase that was ended by the package, combined with
+// the calls found by static analysis that may explain it.
+func terminationDetail(t analysisrun.TerminationResult, exits []string) string {
+	detail := fmt.Sprintf("exit(%d)", t.ExitCode)
+	if t.Reason == analysisrun.TerminationSignal {
+		detail = "killed by signal"
+		if t.Signal != "" {
+			detail = "killed by " + t.Signal
+		}
+	}
+	if len(exits) > 0 {
+		detail += " (" + strings.Join(exits, ", ") + ")"
+	}
+	return detail
+}
+
+// fileReadsDetail describes the breadth of the file reads of a phase, e.g.
+// "30 directories, sensitive: /etc (12), /root/.ssh (2)".
+func fileReadsDetail(r analysisrun.FileReadResult) string {
+	detail := fmt.Sprintf("%d directories", r.Directories)
+	if len(r.SensitiveReads) > 0 {
+		var dirs []string
+		for _, s := range r.SensitiveReads {
+			dirs = append(dirs, fmt.Sprintf("%s (%d)", s.Directory, s.Files))
+		}
+		detail += ", sensitive: " + strings.Join(dirs, ", ")
+	}
+	return detail
+}
+
+// dynamicFindings returns the findings of the dynamic analysis results. The
+// static analysis results (which may be nil) are used to explain the findings.
+func dynamicFindings(d *analysisrun.DynamicAnalysisData, static *staticapi.Results) []finding {
+	var findings []finding
+	add := func(rule string, phase analysisrun.DynamicPhase, detail string) {
+		example := string(phase)
+		if detail != "" {
+			example += ": " + detail
+		}
+		findings = append(findings, finding{rule: rule, example: example})
+	}
+
+	exits := earlyExitLocations(static)
+	for _, phase := range analysisrun.AllDynamicPhases() {
+		s := d.StraceSummary[phase]
+		if s == nil {
+			continue
+		}
+		for _, c := range s.Canaries {
+			if c.Exfiltrated {
+				add(RuleCanaryExfiltrated, phase, c.Path)
+			} else if c.Read {
+				add(RuleCanaryRead, phase, c.Path)
+			}
+		}
+		if s.ForkBomb {
+			add(RuleForkBomb, phase, fmt.Sprintf("%d processes", s.Processes.PeakCount))
+		}
+		if s.SandboxEvasion {
+			add(RuleSandboxEvasion, phase, "")
+		}
+		if s.DelayedPayload {
+			add(RuleDelayedPayload, phase, "")
+		}
+		for _, m := range s.SelfModifications {
+			add(RuleSelfModification, phase, m.Path)
+		}
+		for _, p := range s.PrivilegedSyscalls {
+			add(RulePrivilegedSyscall, phase, p.Name)
+		}
+		if s.HadNetworkActivity() {
+			add(RuleNetworkActivity, phase, "")
+		}
+		for _, c := range s.PlainHTTPConnections {
+			add(RulePlainHTTP, phase, fmt.Sprintf("%s:%d", c.Address, c.Port))
+		}
+		if s.WroteOutsidePackage() {
+			add(RuleWriteOutsidePackage, phase, "")
+		}
+		// the analysis commands and package managers run in every phase
+		for _, c := range s.Commands {
+			if !analysisrun.IsHarnessCommand(c.Command) {
+				add(RuleCommand, phase, strings.Join(c.Command, " "))
+			}
+		}
+		for _, p := range s.LingeringProcesses {
+			if p.Daemonized {
+				add(RuleDaemonProcess, phase, strings.Join(p.Command, " "))
+			} else {
+				add(RuleLingeringProcess, phase, strings.Join(p.Command, " "))
+			}
+		}
+		for _, l := range s.Links {
+			if l.OutsidePackage {
+				add(RuleLinkOutsidePackage, phase, fmt.Sprintf("%s -> %s", l.Path, l.Target))
+			}
+		}
+		if s.BroadFileReads {
+			add(RuleBroadFileReads, phase, fileReadsDetail(s.FileReads))
+		}
+		for _, w := range s.ModuleWrites {
+			add(RuleModuleWrite, phase, fmt.Sprintf("%s (%s)", w.Path, w.Kind))
+		}
+		for _, r := range s.RegistryPublishes {
+			add(RuleRegistryPublish, phase, fmt.Sprintf("%s (%s)", r.Action, r.Registry))
+		}
+		if s.RegistryTakeover {
+			add(RuleRegistryTakeover, phase, strings.Join(s.RegistryCredentialReads, ", "))
+		}
+		for _, r := range s.CredentialStoreReads {
+			add(RuleCredentialStoreRead, phase, fmt.Sprintf("%s (%s)", r.Store, strings.Join(r.Paths, ", ")))
+		}
+		for _, w := range s.ShellProfileWrites {
+			add(RuleShellProfileWrite, phase, w.Path)
+		}
+		for _, m := range s.PathModifications {
+			add(RulePathModification, phase, m.Directory)
+			if len(m.Written) > 0 {
+				add(RulePathHijack, phase, fmt.Sprintf("%s (%s)", m.Directory, strings.Join(m.Written, ", ")))
+			}
+		}
+		for _, m := range s.LoadedModules {
+			if m.OutsidePackage {
+				add(RuleOutsidePackageLoad, phase, m.Name)
+			}
+		}
+		for _, e := range s.ExecutableDrops {
+			detail := e.Path
+			if e.Format != "" {
+				detail = fmt.Sprintf("%s (%s)", e.Path, e.Format)
+			}
+			add(RuleExecutableDrop, phase, detail)
+			if e.MadeExecutable {
+				add(RuleArmedExecutable, phase, detail)
+			}
+		}
+		for _, l := range s.NativeLibraryLoads {
+			add(RuleNativeLibraryLoad, phase, l.Path)
+		}
+		for _, r := range s.ReconExfiltration {
+			add(RuleReconExfiltration, phase, reconDetail(r))
+		}
+		for _, e := range s.StagedExecutions {
+			add(RuleStagedExecution, phase, e.Path)
+		}
+		for _, r := range s.SourceExfiltration {
+			add(RuleSourceExfiltration, phase, sourceExfiltrationDetail(r))
+		}
+		for _, c := range s.DirectIPConnections {
+			add(RuleDirectIPConnection, phase, net.JoinHostPort(c.Address, strconv.Itoa(c.Port)))
+		}
+		for _, t := range s.SecurityTampering {
+			add(RuleSecurityTampering, phase, tamperingDetail(t))
+		}
+		switch s.Termination.Reason {
+		case analysisrun.TerminationExit, analysisrun.TerminationSignal:
+			add(RuleAbruptTermination, phase, terminationDetail(s.Termination, exits))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,243 @@
+/*
+Package verdict combines the findings of static and dynamic analysis of a package
+into a single scored verdict, with a breakdown of the findings that contributed.
+
+Each kind of finding is identified by a rule (e.g. RuleIndirectEval), which has a
+weight. The score of a package is the sum over all rules of the weight of the rule
+multiplied by the number of times it was found (up to MaxCount, so that a single
+kind of finding that occurs many times does not dominate, or once for the rules of
+native code, which benign addons trigger in many places). The label of the
+verdict is chosen by comparing the score with the thresholds of the Scorer.
+*/
+package verdict
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// Label summarises how likely a package is to be malicious.
+type Label string
+
+const (
+	Benign          Label = "benign"
+	Suspicious      Label = "suspicious"
+	LikelyMalicious Label = "likely_malicious"
+	// Inconclusive is the label of a package that would be Benign, but whose
+	// analysis hit a limit that cut it short (see analysisrun.Completeness), so
+	// the findings may be missing from the part of the package that was not seen.
+	Inconclusive Label = "inconclusive"
+)
+
+// Default thresholds of the score for the Suspicious and LikelyMalicious labels.
+const (
+	DefaultSuspiciousThreshold = 3.0
+	DefaultMaliciousThreshold  = 10.0
+)
+
+// MaxCount is the largest number of occurrences of a rule that count towards the score.
+const MaxCount = 5
+
+// maxExamples is the largest number of examples recorded for each contribution.
+const maxExamples = 3
+
+// ErrUnknownRule is returned when configuring a weight for a rule that does not exist.
+var ErrUnknownRule = errors.New("unknown verdict rule")
+
+// Contribution describes how much the findings of a single rule contributed to
+// the score of a package. Category is the category of the rule (see RuleCategory).
+// Examples holds some of the findings, e.g. the file (and code) or the dynamic
+// analysis phase (and command) involved.
+type Contribution struct {
+	Rule     string   `json:"rule"`
+	Category string   `json:"category"`
+	Weight   float64  `json:"weight"`
+	Count    int      `json:"count"`
+	Score    float64  `json:"score"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// Verdict is the overall assessment of a package. Contributions are ordered
+// from the largest score to the smallest, and only include rules that were
+// found and have a non-zero weight. Completeness lists the limits that were
+// hit while analysing the package.
+type Verdict struct {
+	Label         Label                    `json:"label"`
+	Score         float64                  `json:"score"`
+	Contributions []Contribution           `json:"contributions"`
+	Completeness  analysisrun.Completeness `json:"completeness"`
+}
+
+// AddLimits records that the analysis of the package also hit the given limits
+// (e.g. analysisrun.LimitDynamicSkipped), which are not known from the results
+// passed to Scorer.Score. A Benign verdict becomes Inconclusive if the analysis
+// is no longer complete.
+func (v *Verdict) AddLimits(limits ...string) {
+	v.Completeness = analysisrun.NewCompleteness(append(slices.Clone(v.Completeness.Limits), limits...)...)
+	if v.Label == Benign && !v.Completeness.Complete {
+		v.Label = Inconclusive
+	}
+}
+
+// InCategory returns the contributions whose category is one of categories, e.g.
+// staticapi.CategoryCredentialTheft, from both static and dynamic analysis. They
+// are in the same order as in v.
+func (v Verdict) InCategory(categories ...string) []Contribution {
+	var contributions []Contribution
+	for _, c := range v.Contributions {
+		if slices.Contains(categories, c.Category) {
+			contributions = append(contributions, c)
+		}
+	}
+	return contributions
+}
+
+// Scorer computes verdicts using a set of weights and thresholds.
+type Scorer struct {
+	weights             map[string]float64
+	suspiciousThreshold float64
+	maliciousThreshold  float64
+}
+
+type Option interface {
+	set(*Scorer)
+}
+
+type option func(*Scorer)
+
+func (o option) set(s *Scorer) { o(s) }
+
+// Weights overrides the weights of the given rules. A weight of zero disables
+// a rule. Rules that are not given keep their default weight (see DefaultWeights).
+func Weights(weights map[string]float64) Option {
+	return option(func(s *Scorer) {
+		for rule, weight := range weights {
+			s.weights[rule] = weight
+		}
+	})
+}
+
+// Thresholds sets the scores at (or above) which a package is labelled
+// Suspicious and LikelyMalicious.
+func Thresholds(suspicious, malicious float64) Option {
+	return option(func(s *Scorer) {
+		s.suspiciousThreshold = suspicious
+		s.maliciousThreshold = malicious
+	})
+}
+
+// New returns a Scorer which uses the default weights and thresholds, unless
+// they are overridden by opts.
+func New(opts ...Option) *Scorer {
+	s := &Scorer{
+		weights:             DefaultWeights(),
+		suspiciousThreshold: DefaultSuspiciousThreshold,
+		maliciousThreshold:  DefaultMaliciousThreshold,
+	}
+	for _, o := range opts {
+		o.set(s)
+	}
+	return s
+}
+
+/*
+LoadWeights reads weights for the Weights option from a JSON file holding an
+object which maps rule names to weights, e.g. {"static.indirect_eval": 3}.
+An error wrapping ErrUnknownRule is returned if the file names a rule that
+does not exist, to catch mistakes in the configuration.
+*/
+func LoadWeights(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var weights map[string]float64
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("failed to parse verdict weights: %w", err)
+	}
+	defaults := DefaultWeights()
+	for rule := range weights {
+		if _, ok := defaults[rule]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownRule, rule)
+		}
+	}
+	return weights, nil
+}
+
+// label returns the label for the given score.
+func (s *Scorer) label(score float64) Label {
+	switch {
+	case score >= s.maliciousThreshold:
+		return LikelyMalicious
+	case score >= s.suspiciousThreshold:
+		return Suspicious
+	default:
+		return Benign
+	}
+}
+
+/*
+Score computes the verdict for a package from the results of static analysis
+and dynamic analysis. Either may be nil if that analysis was not run, in which
+case the verdict is based on the other one only.
+
+The limits hit by either analysis are recorded in the Completeness of the verdict,
+and a package that would be Benign is labelled Inconclusive if they cut the
+analysis short, since a clean result is then not evidence that it is benign.
+*/
+func (s *Scorer) Score(static *staticapi.Results, dynamic *analysisrun.DynamicAnalysisData) Verdict {
+	var findings []finding
+	var limits []string
+	if static != nil {
+		findings = append(findings, staticFindings(static)...)
+		limits = append(limits, static.Limits()...)
+	}
+	if dynamic != nil {
+		findings = append(findings, dynamicFindings(dynamic, static)...)
+		for _, summary := range dynamic.StraceSummary {
+			if summary != nil {
+				limits = append(limits, summary.Limits()...)
+			}
+		}
+	}
+
+	contributions := make(map[string]*Contribution)
+	for _, f := range findings {
+		weight := s.weights[f.rule]
+		if weight == 0 {
+			continue
+		}
+		c := contributions[f.rule]
+		if c == nil {
+			c = &Contribution{Rule: f.rule, Category: ruleCategories[f.rule], Weight: weight}
+			contributions[f.rule] = c
+		}
+		c.Count++
+		if len(c.Examples) < maxExamples && f.example != "" && !slices.Contains(c.Examples, f.example) {
+			c.Examples = append(c.Examples, f.example)
+		}
+	}
+
+	verdict := Verdict{Contributions: []Contribution{}}
+	for _, c := range contributions {
+		c.Score = c.Weight * float64(min(c.Count, maxCount(c.Rule)))
+		verdict.Score += c.Score
+		verdict.Contributions = append(verdict.Contributions, *c)
+	}
+	slices.SortFunc(verdict.Contributions, func(a, b Contribution) int {
+		if n := cmp.Compare(b.Score, a.Score); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Rule, b.Rule)
+	})
+	verdict.Label = s.label(verdict.Score)
+	verdict.AddLimits(limits...)
+	return verdict
+}
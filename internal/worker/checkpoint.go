@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// CheckpointEntry records that the analysis of a package in a batch completed.
+// Ref is a reference to the stored results of the analysis (e.g. their path in
+// the result buckets), and may be empty.
+type CheckpointEntry struct {
+	Package   analysisrun.Key `json:"package"`
+	Ref       string          `json:"ref,omitempty"`
+	Completed time.Time       `json:"completed"`
+}
+
+/*
+Checkpoint records which packages of a batch have been analyzed, so that a batch
+which was interrupted can be resumed without analysing them again (see
+AnalyzeBatch).
+
+Implementations may be backed by any durable store, e.g. a local file (see
+FileCheckpoint) or a database shared by several workers. They must be safe for
+concurrent use.
+*/
+type Checkpoint interface {
+	// Completed returns the entry recorded for the package with the given key,
+	// and whether there was one.
+	Completed(ctx context.Context, key analysisrun.Key) (CheckpointEntry, bool, error)
+	// Record records that the analysis of entry.Package completed.
+	Record(ctx context.Context, entry CheckpointEntry) error
+}
+
+// MemoryCheckpoint is a Checkpoint which holds entries in memory, and so does
+// not survive a restart. The zero value is an empty checkpoint ready to use.
+type MemoryCheckpoint struct {
+	mu      sync.Mutex
+	entries map[analysisrun.Key]CheckpointEntry
+}
+
+func (c *MemoryCheckpoint) Completed(_ context.Context, key analysisrun.Key) (CheckpointEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry, ok, nil
+}
+
+func (c *MemoryCheckpoint) Record(_ context.Context, entry CheckpointEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[analysisrun.Key]CheckpointEntry)
+	}
+	c.entries[entry.Package] = entry
+	return nil
+}
+
+/*
+FileCheckpoint is a Checkpoint which appends entries to a local file as
+newline-delimited JSON, and syncs the file after each one, so that every
+completed package is recorded even if the process is killed.
+
+The entries already in the file are loaded when it is opened. A partly written
+last line, left by a crash during Record, is discarded.
+*/
+type FileCheckpoint struct {
+	mem MemoryCheckpoint
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenFileCheckpoint opens the checkpoint file at path, creating it if it
+// does not exist.
+func OpenFileCheckpoint(path string) (*FileCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	// only complete lines are kept
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+
+	c := &FileCheckpoint{}
+	for i, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var entry CheckpointEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint entry on line %d: %w", i+1, err)
+		}
+		c.mem.Record(context.Background(), entry)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	if err := f.Truncate(int64(len(data))); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	if _, err := f.Seek(int64(len(data)), io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	c.f = f
+	return c, nil
+}
+
+func (c *FileCheckpoint) Completed(ctx context.Context, key analysisrun.Key) (CheckpointEntry, bool, error) {
+	return c.mem.Completed(ctx, key)
+}
+
+func (c *FileCheckpoint) Record(ctx context.Context, entry CheckpointEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(line); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := c.f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync checkpoint: %w", err)
+	}
+	return c.mem.Record(ctx, entry)
+}
+
+// Close closes the checkpoint file.
+func (c *FileCheckpoint) Close() error {
+	return c.f.Close()
+}
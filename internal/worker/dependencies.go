@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/utils"
+)
+
+// DefaultDependencyDepth is the number of levels of dependencies analyzed by
+// AnalyzeDependencyTree if DependencyTreeOptions.MaxDepth is not set, i.e. only
+// the direct dependencies of the package.
+const DefaultDependencyDepth = 1
+
+// ErrDependencyLimit is recorded for packages in a dependency tree which were not
+// analyzed because DependencyTreeOptions.MaxPackages packages had been analyzed.
+var ErrDependencyLimit = errors.New("too many packages in dependency tree")
+
+// DependencyTreeOptions configures AnalyzeDependencyTree.
+type DependencyTreeOptions struct {
+	// MaxDepth is the number of levels of dependencies to analyze below the root
+	// package: 1 for its direct dependencies only, 2 to include their dependencies,
+	// and so on. If zero, DefaultDependencyDepth is used.
+	MaxDepth int
+	// Types are the types of dependencies that are followed. If empty, runtime and
+	// optional dependencies are followed, i.e. those that are installed along with
+	// the package.
+	Types []pkgmanager.DependencyType
+	// MaxPackages limits the number of distinct packages analyzed, including the
+	// root package. If zero, there is no limit.
+	MaxPackages int
+}
+
+/*
+DependencyNode is a package in a dependency tree, along with the result of analyzing
+it. Dependency is the dependency as declared by the parent package, and is empty for
+the root of the tree.
+
+Err is set if the dependency could not be resolved to a package (in which case
+Package is nil), if analysis failed, or if the dependencies of the package could not
+be read. If Duplicate is true, the package was already analyzed elsewhere in the
+tree (at the same or a lower depth): Result and Err are copied from there, and the
+dependencies of the package are only listed in that node.
+*/
+type DependencyNode[T any] struct {
+	Package      *pkgmanager.Pkg
+	Dependency   pkgmanager.Dependency
+	Result       T
+	Err          error
+	Duplicate    bool
+	Dependencies []*DependencyNode[T]
+}
+
+// Walk calls fn for n and each node in the tree below it, in depth-first order.
+func (n *DependencyNode[T]) Walk(fn func(node *DependencyNode[T], depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *DependencyNode[T]) walk(fn func(node *DependencyNode[T], depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Dependencies {
+		child.walk(fn, depth+1)
+	}
+}
+
+// dependencyTreeWalker holds the functions used to build a dependency tree, so
+// that they can be replaced in tests.
+type dependencyTreeWalker struct {
+	download func(pkg *pkgmanager.Pkg) (string, error)
+	manifest func(pkg *pkgmanager.Pkg) (*pkgmanager.Manifest, error)
+	resolve  func(manager *pkgmanager.PkgManager, dep pkgmanager.Dependency, locked map[string]string) (*pkgmanager.Pkg, error)
+}
+
+var defaultDependencyTreeWalker = dependencyTreeWalker{
+	download: DownloadToTempDir,
+	manifest: packageManifest,
+	resolve:  resolveDependency,
+}
+
+// packageManifest returns the manifest of pkg, which is a local package, extracting
+// its archive to a temporary directory if necessary.
+func packageManifest(pkg *pkgmanager.Pkg) (*pkgmanager.Manifest, error) {
+	manager := pkg.Manager()
+	if pkg.IsExtracted() {
+		return manager.Manifest(pkg.LocalPath())
+	}
+	archivePath := pkg.LocalPath()
+
+	extractDir, err := os.MkdirTemp("", "package-manifest-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(extractDir)
+	if err := manager.ExtractArchive(archivePath, extractDir, utils.DefaultExtractLimits()); err != nil {
+		return nil, fmt.Errorf("failed to extract package: %w", err)
+	}
+	return manager.Manifest(extractDir)
+}
+
+// resolveDependency returns the package for dep, using the version pinned in
+// locked if there is one, and otherwise the version that the package manager of
+// the ecosystem would choose for the version constraint of dep.
+func resolveDependency(manager *pkgmanager.PkgManager, dep pkgmanager.Dependency, locked map[string]string) (*pkgmanager.Pkg, error) {
+	if version := locked[dep.Name]; version != "" {
+		return manager.Package(dep.Name, version), nil
+	}
+	return manager.ResolveDependency(dep)
+}
+
+// packageKey identifies a package in a dependency tree, for deduplication.
+func packageKey(pkg *pkgmanager.Pkg) string {
+	return pkg.Name() + "@" + pkg.Version()
+}
+
+// analyzeNode analyzes the package of n, and reads its manifest if readManifest is
+// true, recording any errors in n. A package that is not local is downloaded once
+// to a temporary directory, and both analyzed and read as the local archive. The
+// manifest is nil if it was not read.
+func analyzeNode[T any](ctx context.Context, w dependencyTreeWalker, n *DependencyNode[T], readManifest bool, analyze func(context.Context, *pkgmanager.Pkg) (T, error)) *pkgmanager.Manifest {
+	pkg := n.Package
+	if !pkg.IsLocal() {
+		archivePath, err := w.download(pkg)
+		if err != nil {
+			n.Err = fmt.Errorf("failed to download package: %w", err)
+			return nil
+		}
+		defer os.RemoveAll(filepath.Dir(archivePath))
+		pkg = pkg.Manager().Local(pkg.Name(), pkg.Version(), archivePath).WithArtifactSHA256(pkg.ArtifactSHA256())
+	}
+
+	n.Result, n.Err = analyze(ctx, pkg)
+	if !readManifest {
+		return nil
+	}
+	manifest, err := w.manifest(pkg)
+	if err != nil {
+		n.Err = errors.Join(n.Err, fmt.Errorf("failed to read dependencies: %w", err))
+		return nil
+	}
+	return manifest
+}
+
+/*
+AnalyzeDependencyTree analyzes root and its dependencies, by calling analyze for
+each distinct package in the tree, and returns the tree of results. This shows
+whether anything installed along with a package is malicious, which is where
+attacks often hide.
+
+Dependencies are read from the manifest of each package, and resolved to versions
+using the versions pinned by the nearest lockfile in the tree, if any, or else the
+version that the ecosystem's package manager would choose for the declared version
+constraint (see pkgmanager.PkgManager.ResolveDependency). The tree is traversed
+breadth first up to opts.MaxDepth levels, and a package that appears more than once
+is only analyzed the first time (see DependencyNode.Duplicate). Each package is
+downloaded once, and the same archive is analyzed and read for its dependencies;
+analyze is given the local package of the download, which is removed afterwards.
+
+Errors resolving or analyzing a package are recorded in its node, so that the rest
+of the tree is still analyzed. An error is only returned if the ecosystem does not
+support reading manifests or resolving dependencies, or if ctx is done, in which
+case the partial tree is returned with it.
+*/
+func AnalyzeDependencyTree[T any](ctx context.Context, root *pkgmanager.Pkg, opts DependencyTreeOptions, analyze func(context.Context, *pkgmanager.Pkg) (T, error)) (*DependencyNode[T], error) {
+	return analyzeDependencyTree(ctx, defaultDependencyTreeWalker, root, opts, analyze)
+}
+
+func analyzeDependencyTree[T any](ctx context.Context, w dependencyTreeWalker, root *pkgmanager.Pkg, opts DependencyTreeOptions, analyze func(context.Context, *pkgmanager.Pkg) (T, error)) (*DependencyNode[T], error) {
+	manager := root.Manager()
+	if !manager.SupportsManifest() {
+		return nil, fmt.Errorf("%w for %s", pkgmanager.ErrManifestNotSupported, manager.Ecosystem())
+	}
+	if !manager.SupportsDependencyResolution() {
+		return nil, fmt.Errorf("%w for %s", pkgmanager.ErrDependencyResolutionNotSupported, manager.Ecosystem())
+	}
+
+	maxDepth := opts.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultDependencyDepth
+	}
+	types := opts.Types
+	if len(types) == 0 {
+		types = []pkgmanager.DependencyType{pkgmanager.RuntimeDependency, pkgmanager.OptionalDependency}
+	}
+
+	type queued struct {
+		node   *DependencyNode[T]
+		depth  int
+		locked map[string]string
+	}
+	tree := &DependencyNode[T]{Package: root}
+	queue := []queued{{node: tree}}
+	analyzed := make(map[string]*DependencyNode[T])
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return tree, err
+		}
+		q := queue[0]
+		queue = queue[1:]
+		n := q.node
+
+		key := packageKey(n.Package)
+		if first, ok := analyzed[key]; ok {
+			n.Duplicate, n.Result, n.Err = true, first.Result, first.Err
+			continue
+		}
+		if opts.MaxPackages > 0 && len(analyzed) >= opts.MaxPackages {
+			n.Err = ErrDependencyLimit
+			continue
+		}
+		analyzed[key] = n
+
+		slog.InfoContext(ctx, "Analyzing package in dependency tree", "name", n.Package.Name(), "version", n.Package.Version(), "depth", q.depth)
+		manifest := analyzeNode(ctx, w, n, q.depth < maxDepth, analyze)
+		if manifest == nil {
+			continue
+		}
+		locked := manifest.LockedVersions
+		if len(locked) == 0 {
+			locked = q.locked
+		}
+		for _, dep := range manifest.Dependencies {
+			if !slices.Contains(types, dep.Type) {
+				continue
+			}
+			child := &DependencyNode[T]{Dependency: dep}
+			n.Dependencies = append(n.Dependencies, child)
+			if child.Package, child.Err = w.resolve(n.Package.Manager(), dep, locked); child.Err == nil {
+				queue = append(queue, queued{node: child, depth: q.depth + 1, locked: locked})
+			}
+		}
+	}
+	return tree, nil
+}
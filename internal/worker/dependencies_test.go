@@ -0,0 +1,205 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+// fakeDependencyTreeWalker returns a walker for the packages in manifests, which
+// maps "name@version" to dependencies of the form "name@version" (or "name" for
+// dependencies that cannot be resolved). All dependencies are runtime ones, except
+// those with a "dev:" prefix. Packages are "downloaded" to an empty temporary
+// directory.
+func fakeDependencyTreeWalker(manifests map[string][]string) dependencyTreeWalker {
+	return dependencyTreeWalker{
+		download: func(pkg *pkgmanager.Pkg) (string, error) {
+			dir, err := os.MkdirTemp("", "fake-download-")
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(dir, pkg.Name()+".tgz"), nil
+		},
+		manifest: func(pkg *pkgmanager.Pkg) (*pkgmanager.Manifest, error) {
+			deps, ok := manifests[packageKey(pkg)]
+			if !ok {
+				return nil, errors.New("no manifest")
+			}
+			m := &pkgmanager.Manifest{}
+			for _, d := range deps {
+				depType := pkgmanager.RuntimeDependency
+				if rest, ok := strings.CutPrefix(d, "dev:"); ok {
+					d, depType = rest, pkgmanager.DevDependency
+				}
+				name, version, _ := strings.Cut(d, "@")
+				m.Dependencies = append(m.Dependencies, pkgmanager.Dependency{Name: name, Version: version, Type: depType})
+			}
+			return m, nil
+		},
+		resolve: func(manager *pkgmanager.PkgManager, dep pkgmanager.Dependency, _ map[string]string) (*pkgmanager.Pkg, error) {
+			if dep.Version == "" {
+				return nil, pkgmanager.ErrUnresolvableDependency
+			}
+			return manager.Package(dep.Name, dep.Version), nil
+		},
+	}
+}
+
+// describeTree returns a line for each node in the tree, indented by depth.
+func describeTree(tree *DependencyNode[string]) []string {
+	var lines []string
+	tree.Walk(func(n *DependencyNode[string], depth int) {
+		line := strings.Repeat("  ", depth)
+		switch {
+		case n.Package == nil:
+			line += n.Dependency.Name + " unresolved"
+		case n.Duplicate:
+			line += n.Result + " duplicate"
+		case errors.Is(n.Err, ErrDependencyLimit):
+			line += packageKey(n.Package) + " limit"
+		default:
+			line += n.Result
+		}
+		lines = append(lines, line)
+	})
+	return lines
+}
+
+func TestAnalyzeDependencyTree(t *testing.T) {
+	manifests := map[string][]string{
+		"root@1.0.0": {"a@1.0.0", "b@2.0.0", "missing", "dev:d@1.0.0"},
+		"a@1.0.0":    {"c@1.0.0"},
+		"b@2.0.0":    {"c@1.0.0", "a@1.0.0"},
+		"c@1.0.0":    {"e@1.0.0"},
+		"e@1.0.0":    {},
+	}
+	tests := []struct {
+		name string
+		opts DependencyTreeOptions
+		want []string
+	}{
+		{
+			name: "default depth",
+			opts: DependencyTreeOptions{},
+			want: []string{"root@1.0.0", "  a@1.0.0", "  b@2.0.0", "  missing unresolved"},
+		},
+		{
+			name: "depth 2",
+			opts: DependencyTreeOptions{MaxDepth: 2},
+			want: []string{
+				"root@1.0.0",
+				"  a@1.0.0", "    c@1.0.0",
+				"  b@2.0.0", "    c@1.0.0 duplicate", "    a@1.0.0 duplicate",
+				"  missing unresolved",
+			},
+		},
+		{
+			name: "depth 3",
+			opts: DependencyTreeOptions{MaxDepth: 3},
+			want: []string{
+				"root@1.0.0",
+				"  a@1.0.0", "    c@1.0.0", "      e@1.0.0",
+				"  b@2.0.0", "    c@1.0.0 duplicate", "    a@1.0.0 duplicate",
+				"  missing unresolved",
+			},
+		},
+		{
+			name: "dev dependencies",
+			opts: DependencyTreeOptions{Types: []pkgmanager.DependencyType{pkgmanager.DevDependency}},
+			want: []string{"root@1.0.0", "  d@1.0.0"},
+		},
+		{
+			name: "package limit",
+			opts: DependencyTreeOptions{MaxPackages: 2},
+			want: []string{"root@1.0.0", "  a@1.0.0", "  b@2.0.0 limit", "  missing unresolved"},
+		},
+	}
+	npm := pkgmanager.Manager(pkgecosystem.NPM)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var analyzed, downloaded []string
+			walker := fakeDependencyTreeWalker(manifests)
+			download := walker.download
+			walker.download = func(pkg *pkgmanager.Pkg) (string, error) {
+				downloaded = append(downloaded, packageKey(pkg))
+				return download(pkg)
+			}
+			analyze := func(_ context.Context, pkg *pkgmanager.Pkg) (string, error) {
+				if !pkg.IsLocal() {
+					t.Errorf("analyze(%s) of a package which was not downloaded", packageKey(pkg))
+				}
+				analyzed = append(analyzed, packageKey(pkg))
+				return packageKey(pkg), nil
+			}
+			tree, err := analyzeDependencyTree(context.Background(), walker, npm.Package("root", "1.0.0"), tt.opts, analyze)
+			if err != nil {
+				t.Fatalf("analyzeDependencyTree() error = %v", err)
+			}
+			if got := describeTree(tree); !slices.Equal(got, tt.want) {
+				t.Errorf("analyzeDependencyTree() = \n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+
+			slices.Sort(analyzed)
+			if len(slices.Compact(slices.Clone(analyzed))) != len(analyzed) {
+				t.Errorf("packages analyzed more than once: %v", analyzed)
+			}
+			// each package is downloaded once, for both analysis and its manifest
+			slices.Sort(downloaded)
+			if !slices.Equal(downloaded, analyzed) {
+				t.Errorf("packages downloaded = %v, want %v", downloaded, analyzed)
+			}
+		})
+	}
+}
+
+func TestAnalyzeDependencyTreeErrors(t *testing.T) {
+	npm := pkgmanager.Manager(pkgecosystem.NPM)
+	walker := fakeDependencyTreeWalker(map[string][]string{"root@1.0.0": {"a@1.0.0"}})
+	analyzeErr := errors.New("analysis failed")
+	analyze := func(_ context.Context, pkg *pkgmanager.Pkg) (string, error) {
+		if pkg.Name() == "a" {
+			return "", analyzeErr
+		}
+		return "", nil
+	}
+
+	tree, err := analyzeDependencyTree(context.Background(), walker, npm.Package("root", "1.0.0"), DependencyTreeOptions{MaxDepth: 2}, analyze)
+	if err != nil {
+		t.Fatalf("analyzeDependencyTree() error = %v", err)
+	}
+	if tree.Err != nil {
+		t.Errorf("root error = %v, want nil", tree.Err)
+	}
+	// a has no manifest, so both errors are recorded
+	if a := tree.Dependencies[0]; !errors.Is(a.Err, analyzeErr) || !strings.Contains(a.Err.Error(), "failed to read dependencies") {
+		t.Errorf("dependency error = %v", a.Err)
+	}
+
+	downloadErr := errors.New("download failed")
+	walker.download = func(*pkgmanager.Pkg) (string, error) { return "", downloadErr }
+	tree, err = analyzeDependencyTree(context.Background(), walker, npm.Package("root", "1.0.0"), DependencyTreeOptions{}, analyze)
+	if err != nil {
+		t.Fatalf("analyzeDependencyTree() error = %v", err)
+	}
+	if !errors.Is(tree.Err, downloadErr) || len(tree.Dependencies) != 0 {
+		t.Errorf("root with failed download = %v, %d dependencies, want %v, none", tree.Err, len(tree.Dependencies), downloadErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analyzeDependencyTree(ctx, walker, npm.Package("root", "1.0.0"), DependencyTreeOptions{}, analyze); !errors.Is(err, context.Canceled) {
+		t.Errorf("analyzeDependencyTree() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+
+	crates := pkgmanager.Manager(pkgecosystem.CratesIO)
+	if _, err := analyzeDependencyTree(context.Background(), walker, crates.Package("root", "1.0.0"), DependencyTreeOptions{}, analyze); !errors.Is(err, pkgmanager.ErrManifestNotSupported) {
+		t.Errorf("analyzeDependencyTree() for %s error = %v, want %v", crates.Ecosystem(), err, pkgmanager.ErrManifestNotSupported)
+	}
+}
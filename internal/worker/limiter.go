@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"expvar"
+	"sync/atomic"
+)
+
+/*
+Limiter bounds the number of packages that are analyzed at the same time. Each
+analysis runs sandboxes that use a lot of CPU, memory and disk, so running too
+many at once exhausts the host, while running too few leaves it underused.
+
+Acquire blocks until one of the slots of the limiter is free, so callers which
+acquire a slot before taking the next package from their input (e.g. before
+receiving the next message of a subscription) apply backpressure to it: packages
+stay queued until they can be analyzed, rather than piling up in the worker.
+
+The number of analyses in flight, and of callers waiting for a slot, can be
+monitored with InFlight and Waiting, or published with Publish.
+*/
+type Limiter struct {
+	slots    chan struct{}
+	inFlight atomic.Int64
+	waiting  atomic.Int64
+}
+
+// NewLimiter returns a Limiter which allows limit analyses to run at the same
+// time. A limit less than 1 is treated as 1.
+func NewLimiter(limit int) *Limiter {
+	return &Limiter{slots: make(chan struct{}, max(limit, 1))}
+}
+
+// Acquire waits for a free slot, and takes it. Release must be called when the
+// analysis is done. An error is returned, and no slot is taken, if ctx is done
+// before a slot is free.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// a slot may have been freed at the same time as ctx was done
+	if err := ctx.Err(); err != nil {
+		<-l.slots
+		return err
+	}
+	l.inFlight.Add(1)
+	return nil
+}
+
+// Release frees the slot taken by a call to Acquire.
+func (l *Limiter) Release() {
+	l.inFlight.Add(-1)
+	<-l.slots
+}
+
+// Limit returns the number of analyses that can run at the same time.
+func (l *Limiter) Limit() int {
+	return cap(l.slots)
+}
+
+// InFlight returns the number of analyses that are running.
+func (l *Limiter) InFlight() int {
+	return int(l.inFlight.Load())
+}
+
+// Waiting returns the number of callers waiting for a slot.
+func (l *Limiter) Waiting() int {
+	return int(l.waiting.Load())
+}
+
+// Publish publishes the limit, and the current number of analyses in flight and
+// waiting, as the expvar variable name, so that they are served in /debug/vars by
+// a server using http.DefaultServeMux (e.g. that of the profiler of the worker).
+// Like expvar.Publish, it panics if name is already in use.
+func (l *Limiter) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return map[string]int{
+			"limit":     l.Limit(),
+			"in_flight": l.InFlight(),
+			"waiting":   l.Waiting(),
+		}
+	}))
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// sandboxModuleLoadLogPath is the absolute path of the module load log inside
+// the sandbox. The analysis command records each module loaded during a phase
+// to this file, as one JSON object per line.
+const sandboxModuleLoadLogPath = "/module-loads.log"
+
+// moduleLoadJSON is a single entry of the module load log.
+type moduleLoadJSON struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Builtin     bool   `json:"builtin"`
+	FromPackage bool   `json:"fromPackage"`
+}
+
+// retrieveLoadedModules copies the module load log back from the sandbox and
+// returns the modules it records. If the analysis command did not write the log
+// (e.g. because it does not support recording module loads), nil is returned.
+func retrieveLoadedModules(ctx context.Context, sb sandbox.Sandbox) ([]analysisrun.LoadedModuleResult, error) {
+	logDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(logDir)
+	hostLogPath := filepath.Join(logDir, "module-loads.log")
+
+	if err := sb.CopyBackToHost(ctx, hostLogPath, sandboxModuleLoadLogPath); err != nil {
+		slog.DebugContext(ctx, "Could not retrieve module load log from sandbox", "error", err)
+		return nil, nil
+	}
+
+	f, err := os.Open(hostLogPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseModuleLoadLog(f)
+}
+
+// parseModuleLoadLog returns the modules recorded in the module load log read
+// from r, in the order they were first loaded. Duplicate entries are merged.
+func parseModuleLoadLog(r io.Reader) ([]analysisrun.LoadedModuleResult, error) {
+	modules := []analysisrun.LoadedModuleResult{}
+	index := make(map[[2]string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry moduleLoadJSON
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse module load log entry %q: %w", line, err)
+		}
+		key := [2]string{entry.Name, entry.Path}
+		if i, ok := index[key]; ok {
+			modules[i].FromPackage = modules[i].FromPackage || entry.FromPackage
+			continue
+		}
+		index[key] = len(modules)
+		modules = append(modules, analysisrun.LoadedModuleResult{
+			Name:        entry.Name,
+			Path:        entry.Path,
+			Builtin:     entry.Builtin,
+			FromPackage: entry.FromPackage,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read module load log: %w", err)
+	}
+	return modules, nil
+}
+
+// markOutsidePackageLoads sets OutsidePackage for the modules the package named
+// pkgName loaded by a path (rather than by the name of a module) which resolved to
+// a file outside the directory the package is installed in, e.g. a file of another
+// installed package, or of the runtime. A module loaded by an absolute path is
+// also marked if it could not be found, as the attempt alone is suspicious.
+func markOutsidePackageLoads(modules []analysisrun.LoadedModuleResult, pkgName string) {
+	installDir := "/node_modules/" + pkgName + "/"
+	for i, m := range modules {
+		if !m.FromPackage || m.Builtin || !isPathSpecifier(m.Name) {
+			continue
+		}
+		if m.Path == "" {
+			modules[i].OutsidePackage = path.IsAbs(strings.TrimPrefix(m.Name, "file://"))
+		} else {
+			modules[i].OutsidePackage = !strings.Contains(m.Path, installDir)
+		}
+	}
+}
+
+// isPathSpecifier returns whether the module name given to require is a path to a
+// file, rather than the name of a module.
+func isPathSpecifier(name string) bool {
+	return name == "." || name == ".." || strings.HasPrefix(name, "./") || strings.HasPrefix(name, "../") ||
+		strings.HasPrefix(name, "/") || strings.HasPrefix(name, "file:")
+}
+
+// CrossReferenceLoadedModules sets DynamicOnly for the modules loaded by the
+// package during dynamic analysis which are not imported by name (with require
+// or import) in any of the files in the static analysis results. These modules
+// are usually loaded with a name computed at runtime, which hides them from
+// static analysis. Modules loaded by dependencies of the package are ignored,
+// and a "node:" prefix is not significant.
+func CrossReferenceLoadedModules(static *staticapi.Results, dynamic *analysisrun.DynamicAnalysisData) {
+	if static == nil || dynamic == nil {
+		return
+	}
+
+	imported := make(map[string]bool)
+	for _, f := range static.Files {
+		for _, module := range f.Imports {
+			imported[strings.TrimPrefix(module, "node:")] = true
+		}
+	}
+
+	for _, s := range dynamic.StraceSummary {
+		if s == nil {
+			continue
+		}
+		for i, m := range s.LoadedModules {
+			s.LoadedModules[i].DynamicOnly = m.FromPackage && !imported[strings.TrimPrefix(m.Name, "node:")]
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+func TestParseModuleLoadLog(t *testing.T) {
+	log := `{"name":"foo","path":"/app/node_modules/foo/index.js","builtin":false,"fromPackage":false}
+{"name":"fs","path":"","builtin":true,"fromPackage":true}
+
+{"name":"./lib","path":"/app/node_modules/foo/lib.js","builtin":false,"fromPackage":false}
+{"name":"./lib","path":"/app/node_modules/foo/lib.js","builtin":false,"fromPackage":true}
+`
+	got, err := parseModuleLoadLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("parseModuleLoadLog() error = %v", err)
+	}
+	want := []analysisrun.LoadedModuleResult{
+		{Name: "foo", Path: "/app/node_modules/foo/index.js"},
+		{Name: "fs", Builtin: true, FromPackage: true},
+		{Name: "./lib", Path: "/app/node_modules/foo/lib.js", FromPackage: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseModuleLoadLog() = %v, want %v", got, want)
+	}
+
+	if _, err := parseModuleLoadLog(strings.NewReader("not json\n")); err == nil {
+		t.Errorf("parseModuleLoadLog() of invalid log succeeded")
+	}
+}
+
+func TestMarkOutsidePackageLoads(t *testing.T) {
+	modules := []analysisrun.LoadedModuleResult{
+		{Name: "fs", Builtin: true, FromPackage: true},
+		{Name: "debug", Path: "/app/node_modules/debug/index.js", FromPackage: true},
+		{Name: "./lib", Path: "/app/node_modules/@scope/foo/lib.js", FromPackage: true},
+		{Name: "../other/secret.js", Path: "/app/node_modules/other/secret.js", FromPackage: true},
+		{Name: "/usr/lib/node_modules/npm/lib/npm.js", Path: "/usr/lib/node_modules/npm/lib/npm.js", FromPackage: true},
+		{Name: "/root/.config/x.js", FromPackage: true},
+		{Name: "./missing", FromPackage: true},
+		{Name: "../ms", Path: "/app/node_modules/ms/index.js"},
+	}
+
+	markOutsidePackageLoads(modules, "@scope/foo")
+	want := []analysisrun.LoadedModuleResult{
+		{Name: "fs", Builtin: true, FromPackage: true},
+		{Name: "debug", Path: "/app/node_modules/debug/index.js", FromPackage: true},
+		{Name: "./lib", Path: "/app/node_modules/@scope/foo/lib.js", FromPackage: true},
+		{Name: "../other/secret.js", Path: "/app/node_modules/other/secret.js", FromPackage: true, OutsidePackage: true},
+		{Name: "/usr/lib/node_modules/npm/lib/npm.js", Path: "/usr/lib/node_modules/npm/lib/npm.js", FromPackage: true, OutsidePackage: true},
+		{Name: "/root/.config/x.js", FromPackage: true, OutsidePackage: true},
+		{Name: "./missing", FromPackage: true},
+		{Name: "../ms", Path: "/app/node_modules/ms/index.js"},
+	}
+	if !reflect.DeepEqual(modules, want) {
+		t.Errorf("markOutsidePackageLoads() =\n%v\nwant\n%v", modules, want)
+	}
+}
+
+func TestCrossReferenceLoadedModules(t *testing.T) {
+	static := &staticapi.Results{Files: []staticapi.FileResult{
+		{Filename: "index.js", Imports: []string{"node:fs", "./lib"}},
+		{Filename: "lib.js", Imports: []string{"debug"}},
+	}}
+	dynamic := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseImport: {
+				LoadedModules: []analysisrun.LoadedModuleResult{
+					{Name: "fs", Builtin: true, FromPackage: true},
+					{Name: "./lib", Path: "/app/node_modules/foo/lib.js", FromPackage: true},
+					{Name: "child_process", Builtin: true, FromPackage: true},
+					{Name: "ms", Path: "/app/node_modules/ms/index.js"},
+				},
+			},
+			analysisrun.DynamicPhaseInstall: nil,
+		},
+	}
+
+	CrossReferenceLoadedModules(static, dynamic)
+	want := []analysisrun.LoadedModuleResult{
+		{Name: "fs", Builtin: true, FromPackage: true},
+		{Name: "./lib", Path: "/app/node_modules/foo/lib.js", FromPackage: true},
+		{Name: "child_process", Builtin: true, FromPackage: true, DynamicOnly: true},
+		{Name: "ms", Path: "/app/node_modules/ms/index.js"},
+	}
+	if got := dynamic.StraceSummary[analysisrun.DynamicPhaseImport].LoadedModules; !reflect.DeepEqual(got, want) {
+		t.Errorf("CrossReferenceLoadedModules() loaded modules = %v, want %v", got, want)
+	}
+}
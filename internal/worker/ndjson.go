@@ -0,0 +1,148 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/staticanalysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+// PackageSummary combines the static and dynamic analysis results for a single
+// package. It is the record type written by NDJSONWriter. Static and Dynamic are
+// nil if the corresponding analysis was not run or failed.
+type PackageSummary struct {
+	Ecosystem string    `json:"ecosystem"`
+	Name      string    `json:"name"`
+	Version   string    `json:"version"`
+	Created   time.Time `json:"created"`
+	// ArtifactSHA256 is the SHA256 hash of the analysed package artifact, if it
+	// was computed (see AttachArtifactHash).
+	ArtifactSHA256 string `json:"artifact_sha256,omitempty"`
+	// GitSource is the git repository the package was fetched from, if it was
+	// analysed from git (see pkgmanager.PkgManager.FetchGit).
+	GitSource *analysisrun.GitSource `json:"git_source,omitempty"`
+
+	Static *staticapi.Results `json:"static,omitempty"`
+	// Dynamic holds the strace summaries of all dynamic analysis phases,
+	// merged into one (see analysisrun.DynamicAnalysisData.MergedStraceSummary).
+	Dynamic *analysisrun.StraceSummary `json:"dynamic,omitempty"`
+	// DynamicSkipped is the reason dynamic analysis was skipped, if the package
+	// was only analysed statically (see DynamicAnalysisSkipped).
+	DynamicSkipped string `json:"dynamic_skipped,omitempty"`
+	// Completeness lists the limits hit by static and dynamic analysis. A summary
+	// which is not complete may be missing findings, even if Static and Dynamic
+	// show nothing suspicious.
+	Completeness analysisrun.Completeness `json:"completeness"`
+}
+
+// NewPackageSummary creates a PackageSummary for pkg from the raw static analysis
+// sandbox output and the dynamic analysis data. Either may be empty or nil.
+func NewPackageSummary(pkg *pkgmanager.Pkg, staticData staticapi.SandboxData, dynamicData *analysisrun.DynamicAnalysisData) (*PackageSummary, error) {
+	s := &PackageSummary{
+		Ecosystem:      pkg.EcosystemName(),
+		Name:           pkg.Name(),
+		Version:        pkg.Version(),
+		Created:        time.Now().UTC(),
+		ArtifactSHA256: pkg.ArtifactSHA256(),
+		GitSource:      pkg.GitSource(),
+	}
+
+	var limits []string
+	if len(staticData) > 0 {
+		var internalResult staticanalysis.Result
+		if err := json.Unmarshal(staticData, &internalResult); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON data from sandbox into staticanalysis.Result: %w", err)
+		}
+		s.Static = internalResult.ToAPIResults()
+		limits = append(limits, s.Static.Limits()...)
+	}
+
+	if dynamicData != nil {
+		merged := dynamicData.MergedStraceSummary()
+		s.Dynamic = &merged
+		limits = append(limits, merged.Limits()...)
+	}
+	s.Completeness = analysisrun.NewCompleteness(limits...)
+
+	return s, nil
+}
+
+// flusher is implemented by buffered writers such as bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// NDJSONWriter writes PackageSummary records to an io.Writer as newline-delimited
+// JSON, one record per line. Each record is written with a single call to the
+// underlying Write method, and the writer is flushed afterwards if it supports
+// it, so that downstream consumers can process records as soon as they are written.
+// It is safe for concurrent use.
+type NDJSONWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewNDJSONWriter returns an NDJSONWriter which writes to w.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{w: w}
+}
+
+// Write writes s as a single line of JSON.
+func (nw *NDJSONWriter) Write(s *PackageSummary) error {
+	// json.Marshal escapes newlines in strings, so the record is always one line.
+	line, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	nw.mu.Lock()
+	defer nw.mu.Unlock()
+
+	if _, err := nw.w.Write(line); err != nil {
+		return err
+	}
+	if f, ok := nw.w.(flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// StreamPackageSummary writes a PackageSummary for pkg to the NDJSON stream in
+// dest. dynamicSkipped is the reason dynamic analysis was skipped, if it was. Both
+// analyses are expected to have been run, so empty staticData, or nil dynamicData
+// if dynamic analysis was not skipped, means that the analysis failed, which is
+// recorded in the Completeness of the summary. If dest has no stream, this is a
+// no-op.
+func StreamPackageSummary(pkg *pkgmanager.Pkg, dest *ResultStores, staticData staticapi.SandboxData, dynamicData *analysisrun.DynamicAnalysisData, dynamicSkipped string) error {
+	if dest.Stream == nil {
+		return nil
+	}
+
+	summary, err := NewPackageSummary(pkg, staticData, dynamicData)
+	if err != nil {
+		return err
+	}
+	limits := summary.Completeness.Limits
+	if len(staticData) == 0 {
+		limits = append(limits, analysisrun.LimitStaticError)
+	}
+	if dynamicSkipped != "" {
+		summary.DynamicSkipped = dynamicSkipped
+		limits = append(limits, analysisrun.LimitDynamicSkipped)
+	} else if dynamicData == nil {
+		limits = append(limits, analysisrun.LimitDynamicError)
+	}
+	summary.Completeness = analysisrun.NewCompleteness(limits...)
+
+	if err := dest.Stream.Write(summary); err != nil {
+		return fmt.Errorf("failed to write package summary to stream: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestNDJSONWriter(t *testing.T) {
+	pkg := pkgmanager.Manager(pkgecosystem.NPM).Package("example", "1.0.0")
+
+	dynamicData := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: &analysisrun.StraceSummary{
+				Stdout: []byte("line one\nline two\n"),
+				Commands: []analysisrun.CommandResult{
+					{Command: []string{"sh", "-c", "echo hi"}},
+				},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	buf := bufio.NewWriter(&out)
+	w := NewNDJSONWriter(buf)
+
+	summaries := []func() (*PackageSummary, error){
+		func() (*PackageSummary, error) { return NewPackageSummary(pkg, nil, dynamicData) },
+		func() (*PackageSummary, error) { return NewPackageSummary(pkg, []byte(`{"files": []}`), nil) },
+	}
+	for i, makeSummary := range summaries {
+		s, err := makeSummary()
+		if err != nil {
+			t.Fatalf("NewPackageSummary() #%d error = %v", i, err)
+		}
+		if err := w.Write(s); err != nil {
+			t.Fatalf("Write() #%d error = %v", i, err)
+		}
+		// each record must be flushed as soon as it is written
+		if got := strings.Count(out.String(), "\n"); got != i+1 {
+			t.Fatalf("after Write() #%d, output has %d lines, want %d", i, got, i+1)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+
+	var first, second PackageSummary
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("line 1 is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("line 2 is not valid JSON: %v", err)
+	}
+
+	if first.Name != "example" || first.Version != "1.0.0" || first.Ecosystem != string(pkgecosystem.NPM) {
+		t.Errorf("line 1 has package %s/%s@%s, want npm/example@1.0.0", first.Ecosystem, first.Name, first.Version)
+	}
+	if first.Static != nil {
+		t.Errorf("line 1 Static = %v, want nil", first.Static)
+	}
+	if first.Dynamic == nil || len(first.Dynamic.Commands) != 1 || string(first.Dynamic.Stdout) != "line one\nline two\n" {
+		t.Errorf("line 1 Dynamic = %+v, want merged install phase summary", first.Dynamic)
+	}
+	if second.Static == nil || second.Dynamic != nil {
+		t.Errorf("line 2 has Static = %v, Dynamic = %v; want only static results", second.Static, second.Dynamic)
+	}
+}
+
+func TestStreamPackageSummaryLimits(t *testing.T) {
+	pkg := pkgmanager.Manager(pkgecosystem.NPM).Package("example", "1.0.0")
+	dynamicData := &analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: &analysisrun.StraceSummary{Status: analysis.StatusCompleted},
+		},
+	}
+	staticData := []byte(`{"Files": [], "Limits": ["static.restricted_scope"]}`)
+
+	tests := []struct {
+		name           string
+		staticData     []byte
+		dynamicData    *analysisrun.DynamicAnalysisData
+		dynamicSkipped string
+		want           []string
+	}{
+		{
+			name:        "complete",
+			staticData:  []byte(`{"Files": []}`),
+			dynamicData: dynamicData,
+		},
+		{
+			name:        "static scope",
+			staticData:  staticData,
+			dynamicData: dynamicData,
+			want:        []string{analysisrun.LimitStaticScope},
+		},
+		{
+			name:        "static and dynamic errors",
+			dynamicData: nil,
+			want:        []string{analysisrun.LimitDynamicError, analysisrun.LimitStaticError},
+		},
+		{
+			name:           "dynamic skipped",
+			staticData:     staticData,
+			dynamicSkipped: "sandbox unavailable",
+			want:           []string{analysisrun.LimitDynamicSkipped, analysisrun.LimitStaticScope},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			dest := &ResultStores{Stream: NewNDJSONWriter(&out)}
+			if err := StreamPackageSummary(pkg, dest, tt.staticData, tt.dynamicData, tt.dynamicSkipped); err != nil {
+				t.Fatalf("StreamPackageSummary() error = %v", err)
+			}
+			var got PackageSummary
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			if want := analysisrun.NewCompleteness(tt.want...); !reflect.DeepEqual(got.Completeness, want) {
+				t.Errorf("Completeness = %+v, want %+v", got.Completeness, want)
+			}
+		})
+	}
+}
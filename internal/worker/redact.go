@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/redaction"
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+const (
+	redactionSourceStdout    = "stdout"
+	redactionSourceStderr    = "stderr"
+	redactionSourceStraceLog = "strace_log"
+	redactionSourceEnv       = "environment"
+)
+
+func recordRedactions(summary *analysisrun.StraceSummary, source string, redactions []redaction.Redaction) {
+	for _, r := range redactions {
+		summary.Redactions = append(summary.Redactions, analysisrun.RedactionResult{
+			Source: source,
+			Type:   r.Type,
+			Count:  r.Count,
+		})
+	}
+}
+
+// redactPhaseResult masks secrets in the captured stdout and stderr of a dynamic
+// analysis phase, in the environment of the commands run, in its raw strace log (if
+// kept), and in the contents of the write buffers saved during the phase.
+// The redactions made are recorded in the strace summary.
+func redactPhaseResult(ctx context.Context, redactor *redaction.Redactor, result *dynamicanalysis.Result) {
+	summary := &result.StraceSummary
+
+	var redactions []redaction.Redaction
+	summary.Stdout, redactions = redactor.Redact(summary.Stdout)
+	recordRedactions(summary, redactionSourceStdout, redactions)
+	summary.Stderr, redactions = redactor.Redact(summary.Stderr)
+	recordRedactions(summary, redactionSourceStderr, redactions)
+	recordRedactions(summary, redactionSourceEnv, redactEnvironment(redactor, summary.Commands))
+	if len(result.RawStraceLog) > 0 {
+		result.RawStraceLog, redactions = redactor.Redact(result.RawStraceLog)
+		recordRedactions(summary, redactionSourceStraceLog, redactions)
+	}
+
+	for _, fileWrite := range result.FileWritesSummary {
+		for _, writeInfo := range fileWrite.WriteInfo {
+			redactions, err := redactWriteBuffer(redactor, writeInfo.WriteBufferId)
+			if err != nil {
+				slog.WarnContext(ctx, "failed to redact write buffer", "id", writeInfo.WriteBufferId, "error", err)
+				continue
+			}
+			recordRedactions(summary, fileWrite.Path, redactions)
+		}
+	}
+}
+
+// redactEnvironment redacts the environment variables of each of commands in place
+// (e.g. the sentinel credentials set with sandbox.SetEnv), and returns the total
+// number of values redacted for each type, sorted by type.
+func redactEnvironment(redactor *redaction.Redactor, commands []analysisrun.CommandResult) []redaction.Redaction {
+	counts := map[string]int{}
+	var types []string
+	for i := range commands {
+		for j, v := range commands[i].Environment {
+			redacted, redactions := redactor.Redact([]byte(v))
+			if len(redactions) == 0 {
+				continue
+			}
+			commands[i].Environment[j] = string(redacted)
+			for _, r := range redactions {
+				if counts[r.Type] == 0 {
+					types = append(types, r.Type)
+				}
+				counts[r.Type] += r.Count
+			}
+		}
+	}
+	slices.Sort(types)
+	total := make([]redaction.Redaction, 0, len(types))
+	for _, t := range types {
+		total = append(total, redaction.Redaction{Type: t, Count: counts[t]})
+	}
+	return total
+}
+
+// redactWriteBuffer redacts the saved contents of a write buffer in place.
+func redactWriteBuffer(redactor *redaction.Redactor, id string) ([]redaction.Redaction, error) {
+	f, err := utils.OpenTempFile(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	redacted, redactions := redactor.Redact(data)
+	if len(redactions) == 0 {
+		return nil, nil
+	}
+	return redactions, utils.CreateAndWriteTempFile(id, redacted)
+}
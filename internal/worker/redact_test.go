@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/redaction"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestRedactPhaseResult(t *testing.T) {
+	const sentinel = "sentinel-npm-token-value"
+	githubToken := "ghp_" + "abcdefghijklmnopqrstuvwxyz0123456789"
+	redactor := redaction.New()
+	redactor.AddSentinel(redaction.NPMToken, sentinel)
+
+	result := &dynamicanalysis.Result{
+		StraceSummary: analysisrun.StraceSummary{
+			Stdout: []byte("token " + sentinel),
+			Commands: []analysisrun.CommandResult{
+				{Command: []string{"node", "install.js"}, Environment: []string{"HOME=/root", "NPM_TOKEN=" + sentinel}},
+				{Command: []string{"sh", "-c", "env"}, Environment: []string{"NPM_TOKEN=" + sentinel, "GITHUB_TOKEN=" + githubToken}},
+				{Command: []string{"ls"}},
+			},
+		},
+		RawStraceLog: []byte(`execve("/usr/bin/node", ["node"], ["NPM_TOKEN=` + sentinel + `"])`),
+	}
+	redactPhaseResult(context.Background(), redactor, result)
+
+	wantCommands := []analysisrun.CommandResult{
+		{Command: []string{"node", "install.js"}, Environment: []string{"HOME=/root", "NPM_TOKEN=[REDACTED:npm_token]"}},
+		{Command: []string{"sh", "-c", "env"}, Environment: []string{"NPM_TOKEN=[REDACTED:npm_token]", "GITHUB_TOKEN=[REDACTED:github_token]"}},
+		{Command: []string{"ls"}},
+	}
+	if got := result.StraceSummary.Commands; !reflect.DeepEqual(got, wantCommands) {
+		t.Errorf("Commands = %v, want %v", got, wantCommands)
+	}
+	if got, want := string(result.StraceSummary.Stdout), "token [REDACTED:npm_token]"; got != want {
+		t.Errorf("Stdout = %q, want %q", got, want)
+	}
+	if got, want := string(result.RawStraceLog), `execve("/usr/bin/node", ["node"], ["NPM_TOKEN=[REDACTED:npm_token]"])`; got != want {
+		t.Errorf("RawStraceLog = %q, want %q", got, want)
+	}
+
+	wantRedactions := []analysisrun.RedactionResult{
+		{Source: redactionSourceStdout, Type: redaction.NPMToken, Count: 1},
+		{Source: redactionSourceEnv, Type: redaction.GitHubToken, Count: 1},
+		{Source: redactionSourceEnv, Type: redaction.NPMToken, Count: 2},
+		{Source: redactionSourceStraceLog, Type: redaction.NPMToken, Count: 1},
+	}
+	if got := result.StraceSummary.Redactions; !reflect.DeepEqual(got, wantRedactions) {
+		t.Errorf("Redactions = %v, want %v", got, wantRedactions)
+	}
+}
@@ -1,21 +1,33 @@
 package worker
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/package-url/packageurl-go"
 
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/featureflags"
 	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/internal/telemetry"
 	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
 // ResolvePkg creates a Pkg object with the arguments passed to the worker process.
+// The version may be a tag of the package (see pkgmanager.PkgManager.Resolve), and
+// if it is empty, the latest version is used.
 func ResolvePkg(manager *pkgmanager.PkgManager, name, version, localPath string) (pkg *pkgmanager.Pkg, err error) {
 	switch {
 	case localPath != "":
 		pkg = manager.Local(name, version, localPath)
 	case version != "":
-		pkg = manager.Package(name, version)
+		// the version may be a tag, e.g. "next" for NPM
+		pkg, err = manager.Resolve(name, version)
+		if err != nil {
+			return nil, err
+		}
 	default:
 		pkg, err = manager.Latest(name)
 		if err != nil {
@@ -28,6 +40,60 @@ func ResolvePkg(manager *pkgmanager.PkgManager, name, version, localPath string)
 	return pkg, nil
 }
 
+// NeedsHostDownload returns whether pkg must be downloaded by the host before it is
+// analyzed, because it is fetched from a registry configured with a custom URL or
+// credentials (see pkgmanager.ConfigureRegistries), or with a Downloader other than
+// the default one (see pkgmanager.ConfigureDownloader). Such packages cannot be
+// fetched from inside the sandboxes, which do not have access to the registry or
+// downloader configuration.
+//
+// Packages are also downloaded by the host if the ArtifactHash feature is enabled,
+// so that the archive which is hashed (see AttachArtifactHash) is the one that is
+// analyzed in the sandboxes.
+func NeedsHostDownload(pkg *pkgmanager.Pkg) bool {
+	if pkg.IsLocal() {
+		return false
+	}
+	if featureflags.ArtifactHash.Enabled() {
+		return true
+	}
+	_, defaultDownloader := pkg.Manager().Downloader().(pkgmanager.RegistryDownloader)
+	return !defaultDownloader || !pkg.Manager().Registry().IsDefault()
+}
+
+// DownloadToTempDir downloads the archive of pkg into a new temporary directory
+// and returns the path to the archive. The caller is responsible for removing the
+// directory containing the archive once it is no longer needed.
+func DownloadToTempDir(pkg *pkgmanager.Pkg) (string, error) {
+	tempDir, err := os.MkdirTemp("", "package-download-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	archivePath, err := pkg.Manager().DownloadArchive(pkg.Name(), pkg.Version(), tempDir)
+	if err != nil {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to download package: %w", err)
+	}
+	return archivePath, nil
+}
+
+// downloadToHost downloads the archive of pkg on the host into a new temporary
+// directory (see DownloadToTempDir), as a telemetry stage, and returns the local
+// package of the archive, which keeps the artifact hash of pkg, and the sandbox
+// options which copy the archive into the sandbox at the same path. The caller is
+// responsible for removing the directory containing the archive (the directory
+// of the LocalPath of the returned package) once it is no longer needed.
+func downloadToHost(ctx context.Context, pkg *pkgmanager.Pkg) (*pkgmanager.Pkg, []sandbox.Option, error) {
+	_, download := telemetry.StartStage(ctx, telemetry.StageDownload, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+	archivePath, err := DownloadToTempDir(pkg)
+	download.End(ctx, analysis.StatusForError(err), err)
+	if err != nil {
+		return nil, nil, err
+	}
+	local := pkg.Manager().Local(pkg.Name(), pkg.Version(), archivePath).WithArtifactSHA256(pkg.ArtifactSHA256())
+	return local, []sandbox.Option{sandbox.Copy(archivePath, archivePath)}, nil
+}
+
 // ResolvePurl creates a Pkg object from the given purl
 // See https://github.com/package-url/purl-spec
 func ResolvePurl(purl packageurl.PackageURL) (*pkgmanager.Pkg, error) {
@@ -57,3 +123,25 @@ func ResolvePurl(purl packageurl.PackageURL) (*pkgmanager.Pkg, error) {
 
 	return pkg, nil
 }
+
+// FetchGitPkg fetches the package in the git repository given by spec (see
+// pkgmanager.ParseGitSource) into a new temporary directory, and returns it as an
+// extracted package whose version is the commit it was fetched at. The caller is
+// responsible for removing the directory (the LocalPath of the package) once it
+// is no longer needed.
+func FetchGitPkg(ctx context.Context, manager *pkgmanager.PkgManager, name, spec string) (*pkgmanager.Pkg, error) {
+	src, err := pkgmanager.ParseGitSource(spec)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "package-git-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	pkg, err := manager.FetchGit(ctx, name, src, dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to fetch package from git: %w", err)
+	}
+	return pkg, nil
+}
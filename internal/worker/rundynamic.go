@@ -6,6 +6,7 @@ import (
 	"crypto/rsa"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -20,17 +21,38 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/canary"
 	"github.com/ossf/package-analysis/internal/dynamicanalysis"
 	"github.com/ossf/package-analysis/internal/featureflags"
 	"github.com/ossf/package-analysis/internal/log"
 	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/redaction"
 	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/internal/telemetry"
 	"github.com/ossf/package-analysis/pkg/api/analysisrun"
-	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
 )
 
-// defaultDynamicAnalysisImage is container image name of the default dynamic analysis sandbox
-const defaultDynamicAnalysisImage = "gcr.io/ossf-malware-analysis/dynamic-analysis"
+// ErrNotPackageDir is returned by RunDynamicAnalysis if the path of an extracted
+// package (see pkgmanager.PkgManager.Extracted) is not a directory.
+var ErrNotPackageDir = errors.New("extracted package path is not a directory")
+
+// ErrSandboxUnavailable is returned (wrapped) by RunDynamicAnalysis if the sandbox
+// could not be created, e.g. because the container runtime is down or out of
+// resources, rather than because of the package being analysed.
+var ErrSandboxUnavailable = errors.New("sandbox unavailable")
+
+/*
+DynamicAnalysisSkipped returns whether the package should be analysed statically
+only after RunDynamicAnalysis returned err, and if so, the reason dynamic analysis
+was skipped, to be recorded with the results. This is the case if the
+StaticOnlyFallback feature is enabled and err is an ErrSandboxUnavailable.
+*/
+func DynamicAnalysisSkipped(err error) (string, bool) {
+	if !featureflags.StaticOnlyFallback.Enabled() || !errors.Is(err, ErrSandboxUnavailable) {
+		return "", false
+	}
+	return err.Error(), true
+}
 
 /*
 DynamicAnalysisResult holds all data and status from RunDynamicAnalysis.
@@ -50,23 +72,73 @@ type DynamicAnalysisResult struct {
 	Data         analysisrun.DynamicAnalysisData
 	LastRunPhase analysisrun.DynamicPhase
 	LastStatus   analysis.Status
+	// Minimal holds the compact results of each phase if MinimalCapture was
+	// used, in which case Data is empty.
+	Minimal analysisrun.DynamicAnalysisMinimalSummary
+	// RawStraceLogs holds the raw strace log of each phase that was run, if
+	// RawStraceLog was used.
+	RawStraceLogs map[analysisrun.DynamicPhase][]byte
 }
 
-func dynamicPhases(ecosystem pkgecosystem.Ecosystem) []analysisrun.DynamicPhase {
-	phases := analysisrun.DefaultDynamicPhases()
+// DynamicAnalysisOption configures optional behaviour of RunDynamicAnalysis.
+type DynamicAnalysisOption func(*dynamicAnalysisOptions)
 
-	// currently, the execute phase is only supported for python analysis
-	executePhaseSupported := map[pkgecosystem.Ecosystem]struct{}{
-		pkgecosystem.PyPI: {},
-	}
+type dynamicAnalysisOptions struct {
+	minimalCapture bool
+	rawLogBytes    int
+	stores         []dynamicanalysis.CredentialStore
+	tamperingRules []dynamicanalysis.TamperingRule
+	denyNetwork    bool
+}
 
-	if featureflags.CodeExecution.Enabled() {
-		if _, supported := executePhaseSupported[ecosystem]; supported {
-			phases = append(phases, analysisrun.DynamicPhaseExecute)
-		}
-	}
+/*
+MinimalCapture makes RunDynamicAnalysis run in minimal capture mode, which trades
+completeness for throughput, e.g. for a first pass over large numbers of packages.
+Only whether each phase connected to the network, ran commands or wrote outside
+the package directory is recorded, in DynamicAnalysisResult.Minimal (see
+analysisrun.MinimalSummary), and the full strace parsing, packet capture, canary
+files and retrieval of loaded modules, termination and execution logs are skipped.
+Packages for which DynamicAnalysisResult.Minimal.Tripped returns true can then be
+analysed in full.
+*/
+func MinimalCapture() DynamicAnalysisOption {
+	return func(o *dynamicAnalysisOptions) { o.minimalCapture = true }
+}
+
+// RawStraceLog makes RunDynamicAnalysis keep the raw strace log of each phase, of at
+// most maxBytes each, in DynamicAnalysisResult.RawStraceLogs, for investigating
+// behaviour in more depth than the summaries allow (see dynamicanalysis.WithRawStraceLog
+// for how larger logs are truncated). Secrets are masked in the logs as in the other
+// results, if redaction is enabled. The logs can be saved with SaveRawStraceLogs.
+func RawStraceLog(maxBytes int) DynamicAnalysisOption {
+	return func(o *dynamicAnalysisOptions) { o.rawLogBytes = maxBytes }
+}
 
-	return phases
+// CredentialStores sets the credential stores whose reads are reported by
+// RunDynamicAnalysis (see dynamicanalysis.WithCredentialStores).
+func CredentialStores(stores []dynamicanalysis.CredentialStore) DynamicAnalysisOption {
+	return func(o *dynamicAnalysisOptions) { o.stores = stores }
+}
+
+// TamperingRules sets the rules of the actions that disable or evade security tooling
+// which are reported by RunDynamicAnalysis (see dynamicanalysis.WithTamperingRules).
+func TamperingRules(rules []dynamicanalysis.TamperingRule) DynamicAnalysisOption {
+	return func(o *dynamicAnalysisOptions) { o.tamperingRules = rules }
+}
+
+/*
+DenyNetwork makes RunDynamicAnalysis run the package with the network of the sandbox
+disabled (see sandbox.Offline), so that nothing the package does can reach another
+host, while the connections it attempts are still traced and reported as denied
+(see dynamicanalysis.WithNetworkDenied). This is the recommended mode for packages
+which are not trusted.
+
+Since the package manager cannot reach the registry either, a package which is not
+local is downloaded on the host first and installed from its archive, and any
+dependencies which have to be fetched will fail to install.
+*/
+func DenyNetwork() DynamicAnalysisOption {
+	return func(o *dynamicAnalysisOptions) { o.denyNetwork = true }
 }
 
 // addSSHKeysToSandbox generates a new rsa private and public key pair
@@ -107,6 +179,20 @@ func addSSHKeysToSandbox(ctx context.Context, sb sandbox.Sandbox) error {
 	return sb.CopyIntoSandbox(ctx, tempdir+"/.", "/root/.ssh")
 }
 
+// addCanaryFilesToSandbox seeds decoy credential files into the sandbox, and
+// returns them. If an error occurs, it is logged and nil is returned.
+func addCanaryFilesToSandbox(ctx context.Context, sb sandbox.Sandbox) []canary.File {
+	canaries, err := canary.Generate()
+	if err == nil {
+		err = canary.Seed(ctx, sb, canaries)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Could not add canary files to sandbox", "error", err)
+		return nil
+	}
+	return canaries
+}
+
 // generateAWSKeys returns two strings. The first is an AWS access key id based
 // off of some known patterns and pseudorandom values. The second is a random 30
 // byte base64 encoded string to use as an AWS secret access key.
@@ -128,22 +214,58 @@ func generateAWSKeys() (string, string) {
 /*
 RunDynamicAnalysis runs dynamic analysis on the given package across the phases
 valid in the package ecosystem (e.g. import, install), in a sandbox created
-using the provided options. The options must specify the sandbox image to use.
+using the provided options. The sandbox uses the image required by the package
+ecosystem (see pkgmanager.PkgManager.SandboxImage), unless the options specify
+another image. If image pulling is disabled and the image does not exist locally,
+an error wrapping sandbox.ErrImageNotFound is returned.
 
 analysisCmd is an optional argument used to override the default command run
 inside the sandbox to perform the analysis. It must support the interface
 described under "Adding a new Runtime Analysis script" in sandboxes/README.md
 
+cmdOverrides optionally replaces the command and arguments run for individual
+phases, e.g. to compare an install with and without install scripts. Phases
+without an override run analysisCmd (or the ecosystem default) as normal.
+
+phaseEnv optionally sets extra environment variables for the analysis command of
+individual phases, e.g. CI=true to trigger install scripts which only run in CI.
+These take precedence over the environment set by the sandbox options (see
+sandbox.SetEnv), which in turn takes precedence over the environment of the
+sandbox image.
+
 All data and status relating to analysis (including errors produced by invalid packages)
 is returned in the DynamicAnalysisResult struct. Status and errors are also logged to stdout.
 
 The returned error holds any error that occurred in the runtime/sandbox infrastructure,
 excluding from within the analysis itself. In other words, it does not include errors
-produced by the package under analysis.
+produced by the package under analysis. If ctx is cancelled, the running phase is
+stopped, no further phases are run, and the returned error wraps ctx.Err().
+
+If pkg was created by pkgmanager.PkgManager.Extracted, its directory is copied
+into the sandbox and analysed in place of a downloaded package, and the caller
+does not need to add it to sbOpts. If the directory does not exist, an error
+is returned before the sandbox is created.
+
+opts can be used to change how the analysis is run, e.g. with MinimalCapture.
+
+The analysis, and each phase of it, is recorded as a telemetry stage (see
+telemetry.StartStage).
 */
-func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, analysisCmd string) (DynamicAnalysisResult, error) {
+func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, analysisCmd string, cmdOverrides dynamicanalysis.CommandOverrides, phaseEnv dynamicanalysis.PhaseEnvironments, opts ...DynamicAnalysisOption) (DynamicAnalysisResult, error) {
+	ctx, stage := telemetry.StartStage(ctx, telemetry.StageDynamic, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+	result, err := runDynamicAnalysis(ctx, pkg, sbOpts, analysisCmd, cmdOverrides, phaseEnv, opts...)
+	stage.End(ctx, result.LastStatus, err)
+	return result, err
+}
+
+func runDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, analysisCmd string, cmdOverrides dynamicanalysis.CommandOverrides, phaseEnv dynamicanalysis.PhaseEnvironments, opts ...DynamicAnalysisOption) (DynamicAnalysisResult, error) {
 	ctx = log.ContextWithAttrs(ctx, slog.String("mode", "dynamic"))
 
+	var o dynamicAnalysisOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	var beforeDynamic runtime.MemStats
 	runtime.ReadMemStats(&beforeDynamic)
 	slog.InfoContext(ctx, "Memory Stats, heap usage before dynamic analysis",
@@ -154,6 +276,33 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 		analysisCmd = dynamicanalysis.DefaultCommand(pkg.Ecosystem())
 	}
 
+	if pkg.IsExtracted() {
+		if err := checkPackageDir(pkg.LocalPath()); err != nil {
+			LogDynamicAnalysisError(ctx, pkg, "", err)
+			return DynamicAnalysisResult{}, err
+		}
+		// The analysis command is passed the same path (see MakeAnalysisArgs).
+		sbOpts = append(sbOpts, sandbox.Copy(pkg.LocalPath(), pkg.LocalPath()))
+	}
+
+	// The package is downloaded once on the host, and analyzed from that archive,
+	// if it cannot be downloaded in the sandbox, or if its lifecycle scripts are
+	// read from the archive (see declaredInstallScripts).
+	readInstallScripts := !o.minimalCapture && pkg.Manager().SupportsLifecycleScripts() && !pkg.IsExtracted()
+	if !pkg.IsLocal() && (o.denyNetwork || readInstallScripts) {
+		local, copyOpts, err := downloadToHost(ctx, pkg)
+		if err != nil {
+			LogDynamicAnalysisError(ctx, pkg, "", err)
+			return DynamicAnalysisResult{}, err
+		}
+		defer os.RemoveAll(filepath.Dir(local.LocalPath()))
+		pkg = local
+		sbOpts = append(sbOpts, copyOpts...)
+	}
+	if o.denyNetwork {
+		sbOpts = append(sbOpts, sandbox.Offline())
+	}
+
 	// Adding environment variable baits. We use mocked AWS keys since they are
 	// commonly added as environment variables and will be easy to query for in
 	// the analysis results. See AWS docs on environment variable configuration:
@@ -162,10 +311,20 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 	sbOpts = append(sbOpts, sandbox.SetEnv("AWS_ACCESS_KEY_ID", AWSAccessKeyId))
 	sbOpts = append(sbOpts, sandbox.SetEnv("AWS_SECRET_ACCESS_KEY", AWSSecretAccessKey))
 
+	var redactor *redaction.Redactor
+	if featureflags.RedactSecrets.Enabled() {
+		redactor = redaction.New()
+		redactor.AddSentinel(redaction.AWSAccessKeyID, AWSAccessKeyId)
+		redactor.AddSentinel("aws_secret_access_key", AWSSecretAccessKey)
+	}
+
+	// The image of the ecosystem comes first, so that it can be overridden by sbOpts.
+	sbOpts = append([]sandbox.Option{sandbox.Image(pkg.Manager().SandboxImage())}, sbOpts...)
 	sb := sandbox.New(sbOpts...)
 
 	defer func() {
-		if err := sb.Clean(ctx); err != nil {
+		// Clean up even if ctx was cancelled, so that the sandbox isn't left running.
+		if err := sb.Clean(context.WithoutCancel(ctx)); err != nil {
 			slog.ErrorContext(ctx, "Error cleaning up sandbox", "error", err)
 		}
 	}()
@@ -173,6 +332,11 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 	// initialise sandbox before copy/run
 	if err := sb.Init(ctx); err != nil {
 		LogDynamicAnalysisError(ctx, pkg, "", err)
+		// A missing image is a configuration error, which falling back to static
+		// analysis would hide.
+		if !errors.Is(err, sandbox.ErrImageNotFound) {
+			err = fmt.Errorf("%w: %w", ErrSandboxUnavailable, err)
+		}
 		return DynamicAnalysisResult{}, err
 	}
 
@@ -181,6 +345,11 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 		LogDynamicAnalysisError(ctx, pkg, "", err)
 	}
 
+	var canaries []canary.File
+	if featureflags.CanaryFiles.Enabled() && !o.minimalCapture {
+		canaries = addCanaryFilesToSandbox(ctx, sb)
+	}
+
 	result := DynamicAnalysisResult{
 		Data: analysisrun.DynamicAnalysisData{
 			StraceSummary:      make(analysisrun.DynamicAnalysisStraceSummary),
@@ -188,14 +357,46 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 			FileWriteBufferIds: make(analysisrun.DynamicAnalysisFileWriteBufferIds),
 		},
 	}
+	if o.minimalCapture {
+		result.Data = analysisrun.DynamicAnalysisData{}
+		result.Minimal = make(analysisrun.DynamicAnalysisMinimalSummary)
+	}
+	var phaseOpts []dynamicanalysis.RunOption
+	if o.rawLogBytes > 0 {
+		result.RawStraceLogs = make(map[analysisrun.DynamicPhase][]byte)
+		phaseOpts = append(phaseOpts, dynamicanalysis.WithRawStraceLog(o.rawLogBytes))
+	}
+	if o.stores != nil {
+		phaseOpts = append(phaseOpts, dynamicanalysis.WithCredentialStores(o.stores))
+	}
+	if o.tamperingRules != nil {
+		phaseOpts = append(phaseOpts, dynamicanalysis.WithTamperingRules(o.tamperingRules))
+	}
+	if o.denyNetwork {
+		phaseOpts = append(phaseOpts, dynamicanalysis.WithNetworkDenied())
+	}
 
 	// lastError holds the error that occurred in the most recently run dynamic analysis phase.
 	// This is not a part of the result because a non-nil value means that the error originated
 	// from our code, as opposed to the package under analysis
 	var lastError error
 
-	for _, phase := range dynamicPhases(pkg.Ecosystem()) {
-		if err := runDynamicAnalysisPhase(ctx, pkg, sb, analysisCmd, phase, &result); err != nil {
+	var installScripts []pkgmanager.LifecycleScript
+	if readInstallScripts {
+		installScripts = declaredInstallScripts(ctx, pkg)
+	}
+
+	for _, phase := range pkg.Manager().DynamicPhases() {
+		phaseCtx, phaseStage := telemetry.StartStage(ctx, telemetry.StageDynamicPhase,
+			telemetry.EcosystemKey.String(pkg.EcosystemName()), telemetry.PhaseKey.String(string(phase)))
+		var err error
+		if o.minimalCapture {
+			err = runMinimalDynamicAnalysisPhase(phaseCtx, pkg, sb, analysisCmd, cmdOverrides, phaseEnv[phase], phase, redactor, phaseOpts, &result)
+		} else {
+			err = runDynamicAnalysisPhase(phaseCtx, pkg, sb, analysisCmd, cmdOverrides, phaseEnv[phase], phase, redactor, canaries, phaseOpts, &result)
+		}
+		phaseStage.End(phaseCtx, result.LastStatus, err)
+		if err != nil {
 			// Error when trying to actually run; don't record the result for this phase
 			// or attempt subsequent phases
 			result.LastStatus = ""
@@ -203,6 +404,11 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 			break
 		}
 
+		if phase == analysisrun.DynamicPhaseInstall && installScripts != nil {
+			summary := result.Data.StraceSummary[phase]
+			summary.InstallScripts = dynamicanalysis.InstallScriptResults(installScripts, summary.Commands)
+		}
+
 		if result.LastStatus != analysis.StatusCompleted {
 			// Error caused by an issue with the package (probably).
 			// Don't continue with phases if this one did not complete successfully.
@@ -225,6 +431,42 @@ func RunDynamicAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandb
 	return result, nil
 }
 
+// checkPackageDir returns an error if dir is not an existing directory.
+func checkPackageDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("could not read package directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrNotPackageDir, dir)
+	}
+	return nil
+}
+
+// declaredInstallScripts returns the lifecycle scripts declared in the manifest
+// of the given package. If the ecosystem does not support lifecycle scripts, or
+// an error occurs when reading the package archive, nil is returned. Lifecycle
+// scripts are read from the local archive of the package, which is the one that
+// is analyzed, so nil is also returned for an extracted package, or a package
+// that was not downloaded on the host.
+func declaredInstallScripts(ctx context.Context, pkg *pkgmanager.Pkg) []pkgmanager.LifecycleScript {
+	manager := pkg.Manager()
+	if !manager.SupportsLifecycleScripts() || pkg.IsExtracted() || !pkg.IsLocal() {
+		return nil
+	}
+
+	scripts, err := manager.LifecycleScripts(pkg.LocalPath())
+	if err != nil {
+		slog.WarnContext(ctx, "Could not read lifecycle scripts", "error", err)
+		return nil
+	}
+
+	if scripts == nil {
+		scripts = []pkgmanager.LifecycleScript{}
+	}
+	return scripts
+}
+
 // openStraceDebugLogFile creates and returns the file to be used for debug logging of strace parsing
 // during a dynamic analysis phase. The file is created with the given filename in log.StraceDebugLogDir.
 // It is truncated on open (so a unique name per analysis phase should be used) and is the caller's
@@ -261,10 +503,51 @@ func straceDebugLogFilename(pkg *pkgmanager.Pkg, phase analysisrun.DynamicPhase)
 	return strings.ReplaceAll(filename, string(os.PathSeparator), "-")
 }
 
-func runDynamicAnalysisPhase(ctx context.Context, pkg *pkgmanager.Pkg, sb sandbox.Sandbox, analysisCmd string, phase analysisrun.DynamicPhase, result *DynamicAnalysisResult) error {
+// runMinimalDynamicAnalysisPhase is like runDynamicAnalysisPhase, but runs the phase
+// in minimal capture mode (see MinimalCapture) and records its MinimalSummary.
+func runMinimalDynamicAnalysisPhase(ctx context.Context, pkg *pkgmanager.Pkg, sb sandbox.Sandbox, analysisCmd string, cmdOverrides dynamicanalysis.CommandOverrides, env map[string]string, phase analysisrun.DynamicPhase, redactor *redaction.Redactor, opts []dynamicanalysis.RunOption, result *DynamicAnalysisResult) error {
+	phaseCtx := log.ContextWithAttrs(ctx, log.Label("phase", string(phase)))
+	startTime := time.Now()
+	cmd, args := cmdOverrides.Command(pkg, phase, analysisCmd)
+
+	straceLogger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	opts = append([]dynamicanalysis.RunOption{dynamicanalysis.WithEnv(env), dynamicanalysis.WithMinimalCapture()}, opts...)
+	phaseResult, err := dynamicanalysis.Run(phaseCtx, sb, cmd, args, straceLogger, opts...)
+	result.LastRunPhase = phase
+	slog.InfoContext(phaseCtx, "Dynamic analysis phase finished",
+		"error", err,
+		"dynamic_analysis_phase_duration", time.Since(startTime),
+		"minimal_capture", true,
+	)
+	if err != nil {
+		return err
+	}
+
+	if redactor != nil {
+		redactPhaseResult(phaseCtx, redactor, phaseResult)
+	}
+
+	result.Minimal[phase] = analysisrun.NewMinimalSummary(&phaseResult.StraceSummary)
+	if result.RawStraceLogs != nil {
+		result.RawStraceLogs[phase] = phaseResult.RawStraceLog
+	}
+	result.LastStatus = phaseResult.StraceSummary.Status
+	return nil
+}
+
+// runDynamicAnalysisPhase runs a single phase of dynamic analysis and records the results.
+// If cmdOverrides has an entry for the phase, it is run instead of analysisCmd.
+// env holds extra environment variables for the command (see dynamicanalysis.WithEnv).
+// If redactor is not nil, it is used to mask secrets in the captured output of the phase.
+// If canaries is not empty, the phase result records whether each canary file was touched.
+// opts holds extra options for running the phase (see dynamicanalysis.Run).
+func runDynamicAnalysisPhase(ctx context.Context, pkg *pkgmanager.Pkg, sb sandbox.Sandbox, analysisCmd string, cmdOverrides dynamicanalysis.CommandOverrides, env map[string]string, phase analysisrun.DynamicPhase, redactor *redaction.Redactor, canaries []canary.File, opts []dynamicanalysis.RunOption, result *DynamicAnalysisResult) error {
 	phaseCtx := log.ContextWithAttrs(ctx, log.Label("phase", string(phase)))
 	startTime := time.Now()
-	args := dynamicanalysis.MakeAnalysisArgs(pkg, phase)
+	cmd, args := cmdOverrides.Command(pkg, phase, analysisCmd)
+	if _, overridden := cmdOverrides[phase]; overridden {
+		slog.InfoContext(phaseCtx, "Using command override", "command", cmd, "args", args)
+	}
 
 	straceLogger := slog.New(slog.NewTextHandler(io.Discard, nil)) // default is nop logger
 	if logFile := openStraceDebugLogFile(phaseCtx, straceDebugLogFilename(pkg, phase)); logFile != nil {
@@ -276,7 +559,17 @@ func runDynamicAnalysisPhase(ctx context.Context, pkg *pkgmanager.Pkg, sb sandbo
 		straceLogger.InfoContext(phaseCtx, "running dynamic analysis")
 	}
 
-	phaseResult, err := dynamicanalysis.Run(phaseCtx, sb, analysisCmd, args, straceLogger)
+	runOpts := append([]dynamicanalysis.RunOption{dynamicanalysis.WithEnv(env), dynamicanalysis.WithPackageName(pkg.Name())}, opts...)
+	if len(env) > 0 {
+		slog.InfoContext(phaseCtx, "Using extra environment variables", "count", len(env))
+	}
+	var canaryDetector *canary.Detector
+	if len(canaries) > 0 {
+		canaryDetector = canary.NewDetector(canaries)
+		runOpts = append(runOpts, dynamicanalysis.WithPacketReceiver(canaryDetector))
+	}
+
+	phaseResult, err := dynamicanalysis.Run(phaseCtx, sb, cmd, args, straceLogger, runOpts...)
 	result.LastRunPhase = phase
 	runDuration := time.Since(startTime)
 	slog.InfoContext(phaseCtx, "Dynamic analysis phase finished",
@@ -288,9 +581,46 @@ func runDynamicAnalysisPhase(ctx context.Context, pkg *pkgmanager.Pkg, sb sandbo
 		return err
 	}
 
+	if lingering := phaseResult.StraceSummary.LingeringProcesses; len(lingering) > 0 {
+		slog.WarnContext(phaseCtx, "Processes still running at end of dynamic analysis phase",
+			"count", len(lingering),
+			"lingering_processes", lingering,
+		)
+	}
+
+	loadedModules, err := retrieveLoadedModules(phaseCtx, sb)
+	if err != nil {
+		// don't return this error, just log it
+		slog.ErrorContext(phaseCtx, "Error retrieving loaded modules", "error", err)
+	}
+	markOutsidePackageLoads(loadedModules, pkg.Name())
+	phaseResult.StraceSummary.LoadedModules = loadedModules
+
+	termination, err := retrieveTermination(phaseCtx, sb, phaseResult.StraceSummary.Status)
+	if err != nil {
+		// don't return this error, just log it
+		slog.ErrorContext(phaseCtx, "Error retrieving termination", "error", err)
+	}
+	phaseResult.StraceSummary.Termination = termination
+
+	if canaryDetector != nil {
+		phaseResult.StraceSummary.Canaries = canary.Results(canaries, phaseResult.StraceSummary.Files, canaryDetector)
+	}
+
+	if redactor != nil {
+		redactPhaseResult(phaseCtx, redactor, phaseResult)
+	}
+
+	// sort the results so that the output is the same for identical runs
+	phaseResult.StraceSummary.Canonicalize()
+	phaseResult.FileWritesSummary.Canonicalize()
+
 	result.Data.StraceSummary[phase] = &phaseResult.StraceSummary
 	result.Data.FileWritesSummary[phase] = &phaseResult.FileWritesSummary
 	result.Data.FileWriteBufferIds[phase] = phaseResult.FileWriteBufferIds
+	if result.RawStraceLogs != nil {
+		result.RawStraceLogs[phase] = phaseResult.RawStraceLog
+	}
 	result.LastStatus = phaseResult.StraceSummary.Status
 
 	if phase == analysisrun.DynamicPhaseExecute {
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/featureflags"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestCheckPackageDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "package.tgz")
+	if err := os.WriteFile(file, []byte("archive"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkPackageDir(dir); err != nil {
+		t.Errorf("checkPackageDir(%q) = %v, want nil", dir, err)
+	}
+	if err := checkPackageDir(file); !errors.Is(err, ErrNotPackageDir) {
+		t.Errorf("checkPackageDir(%q) = %v, want %v", file, err, ErrNotPackageDir)
+	}
+	if err := checkPackageDir(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("checkPackageDir(missing) = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestDynamicAnalysisSkipped(t *testing.T) {
+	unavailable := fmt.Errorf("%w: error creating container: exit status 125", ErrSandboxUnavailable)
+	failed := errors.New("sandbox run failed")
+
+	if _, skipped := DynamicAnalysisSkipped(unavailable); skipped {
+		t.Errorf("DynamicAnalysisSkipped() = true with StaticOnlyFallback disabled, want false")
+	}
+
+	if err := featureflags.Update("StaticOnlyFallback"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = featureflags.Update("-StaticOnlyFallback") })
+
+	if reason, skipped := DynamicAnalysisSkipped(unavailable); !skipped || reason != unavailable.Error() {
+		t.Errorf("DynamicAnalysisSkipped(%v) = (%q, %v), want (%q, true)", unavailable, reason, skipped, unavailable.Error())
+	}
+	for _, err := range []error{nil, failed} {
+		if _, skipped := DynamicAnalysisSkipped(err); skipped {
+			t.Errorf("DynamicAnalysisSkipped(%v) = true, want false", err)
+		}
+	}
+}
+
+func TestDeclaredInstallScriptsNotDownloaded(t *testing.T) {
+	npm := pkgmanager.Manager(pkgecosystem.NPM)
+	// The scripts are only read from an archive on the host, which is the one
+	// analyzed, and are never downloaded separately.
+	for _, pkg := range []*pkgmanager.Pkg{npm.Package("package", "1.0.0"), npm.Extracted("package", "1.0.0", t.TempDir())} {
+		if got := declaredInstallScripts(context.Background(), pkg); got != nil {
+			t.Errorf("declaredInstallScripts(%s) = %v, want nil", pkg.LocalPath(), got)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/ossf/package-analysis/internal/pkgmanager"
 	"github.com/ossf/package-analysis/internal/sandbox"
 	"github.com/ossf/package-analysis/internal/staticanalysis"
+	"github.com/ossf/package-analysis/internal/telemetry"
 	"github.com/ossf/package-analysis/internal/utils"
 	api "github.com/ossf/package-analysis/pkg/api/staticanalysis"
 )
@@ -25,15 +27,80 @@ const defaultStaticAnalysisImage = "gcr.io/ossf-malware-analysis/static-analysis
 const staticAnalyzeBinary = "/usr/local/bin/staticanalyze"
 
 // resultsJSONFile is the absolute path to the shared mount inside the static analysis sandbox
-// where the output results JSON data should be written.
+// where the output results JSON data should be written. It is mounted from a temporary
+// file on the host which is unique to each run, so that concurrent runs do not share it.
 const resultsJSONFile = "/results.json"
 
+// previousResultsJSONFile is the absolute path inside the static analysis sandbox
+// to which the results of the previous version of the package are copied.
+const previousResultsJSONFile = "/previous-results.json"
+
+// StaticAnalysisScope restricts static analysis to part of a package.
+// The zero value means that all files in the package are analyzed.
+type StaticAnalysisScope struct {
+	// EntryPoints restricts analysis to the package's declared entry point
+	// files (see pkgmanager.PkgManager.EntryPoints).
+	EntryPoints bool
+	// Paths restricts analysis to the given files or directories, relative to
+	// the root of the extracted package archive. If EntryPoints is also set,
+	// both the entry points and these paths are analyzed.
+	Paths []string
+	// NestedCodeDepth is the number of levels of string literals which look like
+	// code (e.g. eval payloads) to parse as JavaScript. See
+	// parsing.ParserConfig.NestedCodeDepth.
+	NestedCodeDepth int
+	// FileTimeout and PackageTimeout limit the time taken to parse each file
+	// (as a budget for each batch of files parsed together) and all files in
+	// the package, if positive. Files which are not parsed in
+	// time are marked as timed out in the results, and the rest are analyzed as
+	// normal. See staticanalysis.FileTimeout and staticanalysis.PackageTimeout.
+	FileTimeout    time.Duration
+	PackageTimeout time.Duration
+	// PreviousResults is the path of a file holding the data produced by static
+	// analysis of the previous version of the package, if not empty. The files
+	// which have not changed since that version are not analyzed again, and
+	// their results are reused. See staticanalysis.PreviousResults.
+	PreviousResults string
+}
+
+func (s StaticAnalysisScope) args() []string {
+	var args []string
+	if s.EntryPoints {
+		args = append(args, "-entry-points")
+	}
+	if len(s.Paths) > 0 {
+		args = append(args, "-paths", strings.Join(s.Paths, ","))
+	}
+	if s.NestedCodeDepth > 0 {
+		args = append(args, "-nested-code-depth", strconv.Itoa(s.NestedCodeDepth))
+	}
+	if s.FileTimeout > 0 {
+		args = append(args, "-file-timeout", s.FileTimeout.String())
+	}
+	if s.PackageTimeout > 0 {
+		args = append(args, "-package-timeout", s.PackageTimeout.String())
+	}
+	if s.PreviousResults != "" {
+		args = append(args, "-previous-results", previousResultsJSONFile)
+	}
+	return args
+}
+
 // RunStaticAnalysis performs the given static analysis tasks on package code,
 // in a sandboxed environment.
 //
 // To run all available static analyses, pass staticanalysis.All as tasks.
-// Use sbOpts to customise sandbox behaviour.
-func RunStaticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, tasks ...staticanalysis.Task) (api.SandboxData, analysis.Status, error) {
+// Use sbOpts to customise sandbox behaviour, and scope to analyze only
+// part of the package. The analysis is recorded as a telemetry stage (see
+// telemetry.StartStage).
+func RunStaticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, scope StaticAnalysisScope, tasks ...staticanalysis.Task) (api.SandboxData, analysis.Status, error) {
+	ctx, stage := telemetry.StartStage(ctx, telemetry.StageStatic, telemetry.EcosystemKey.String(pkg.EcosystemName()))
+	data, status, err := runStaticAnalysis(ctx, pkg, sbOpts, scope, tasks...)
+	stage.End(ctx, status, err)
+	return data, status, err
+}
+
+func runStaticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbox.Option, scope StaticAnalysisScope, tasks ...staticanalysis.Task) (api.SandboxData, analysis.Status, error) {
 	ctx = log.ContextWithAttrs(ctx, slog.String("mode", "static"))
 
 	slog.InfoContext(ctx, "Running static analysis", "tasks", tasks)
@@ -53,18 +120,33 @@ func RunStaticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbo
 	if pkg.IsLocal() {
 		args = append(args, "-local", pkg.LocalPath())
 	}
+	args = append(args, scope.args()...)
 
 	// create the results JSON file as an empty file, so it can be mounted into the container
-	resultsFile, err := os.Create(resultsJSONFile)
+	resultsFile, err := os.CreateTemp("", "static-results-*.json")
 	if err != nil {
 		return nil, "", fmt.Errorf("could not create results JSON file: %w", err)
 	}
 	_ = resultsFile.Close()
+	resultsPath := resultsFile.Name()
+	defer func() {
+		if err := os.Remove(resultsPath); err != nil {
+			slog.WarnContext(ctx, "Could not remove results JSON file", "path", resultsPath, "error", err)
+		}
+	}()
 
 	// for saving static analysis results inside the sandbox
 	sbOpts = append(sbOpts,
-		sandbox.Volume(resultsJSONFile, resultsJSONFile),
+		sandbox.Volume(resultsPath, resultsJSONFile),
 		sandbox.SetEnv("LOGGER_ENV", log.DefaultLoggingEnv().String()))
+	// so that the stages of the analysis in the sandbox (e.g. parsing) are exported
+	// as part of this one
+	for k, v := range telemetry.SandboxEnvironment(ctx) {
+		sbOpts = append(sbOpts, sandbox.SetEnv(k, v))
+	}
+	if scope.PreviousResults != "" {
+		sbOpts = append(sbOpts, sandbox.Copy(scope.PreviousResults, previousResultsJSONFile))
+	}
 
 	sb := sandbox.New(sbOpts...)
 	defer func() {
@@ -78,7 +160,7 @@ func RunStaticAnalysis(ctx context.Context, pkg *pkgmanager.Pkg, sbOpts []sandbo
 		return nil, "", fmt.Errorf("sandbox failed (%w)", err)
 	}
 
-	resultsJSON, err := os.ReadFile(resultsJSONFile)
+	resultsJSON, err := os.ReadFile(resultsPath)
 	if err != nil {
 		return nil, "", fmt.Errorf("could not read results JSON file: %w", err)
 	}
@@ -14,10 +14,10 @@ func StaticSandboxOptions() []sandbox.Option {
 }
 
 // DynamicSandboxOptions provides a set of sandbox options necessary to run
-// dynamic analysis sandboxes.
+// dynamic analysis sandboxes. The image is chosen by RunDynamicAnalysis according
+// to the package ecosystem.
 func DynamicSandboxOptions() []sandbox.Option {
 	return []sandbox.Option{
-		sandbox.Image(defaultDynamicAnalysisImage),
 		sandbox.EnableStrace(),
 		sandbox.EnableRawSockets(),
 		sandbox.EnablePacketLogging(),
@@ -1,8 +1,10 @@
 package worker
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -18,24 +20,45 @@ import (
 // ResultStores holds ResultStore instances for saving each kind of analysis data.
 // They can be nil, in which case calling the associated Upload function here is a no-op
 type ResultStores struct {
-	AnalyzedPackage      *resultstore.ResultStore
-	DynamicAnalysis      *resultstore.ResultStore
-	ExecutionLog         *resultstore.ResultStore
-	FileWrites           *resultstore.ResultStore
-	StaticAnalysis       *resultstore.ResultStore
-	AnalyzedPackageSaved bool
+	AnalyzedPackage resultstore.ResultStore
+	DynamicAnalysis resultstore.ResultStore
+	// NormalizedDynamicAnalysis, if not nil, receives a copy of the dynamic analysis
+	// results with run-specific values masked, for comparing different runs of the
+	// same package (see analysisrun.StraceSummary.Normalized).
+	NormalizedDynamicAnalysis resultstore.ResultStore
+	ExecutionLog              resultstore.ResultStore
+	StraceLog                 resultstore.ResultStore
+	FileWrites                resultstore.ResultStore
+	StaticAnalysis            resultstore.ResultStore
+	AnalyzedPackageSaved      bool
+	// Stream, if not nil, receives a combined summary of the results for
+	// each analyzed package (see StreamPackageSummary).
+	Stream *NDJSONWriter
 }
 
 // SaveDynamicAnalysisData saves the data from dynamic analysis to the corresponding bucket in the ResultStores.
-// This includes strace data, execution log, and file writes (in that order).
-// If any operation fails, the rest are aborted
+// This includes strace data, execution log, file writes and normalized strace data (in that order).
+// If saving any of the results other than the normalized strace data fails, the rest of them
+// are aborted. The normalized strace data is saved last, and even if the other results fail.
 func SaveDynamicAnalysisData(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, data analysisrun.DynamicAnalysisData) error {
+	err := saveDynamicAnalysisResults(ctx, pkg, dest, data)
+	if dest.NormalizedDynamicAnalysis != nil {
+		if normErr := resultstore.SaveDynamicAnalysis(ctx, dest.NormalizedDynamicAnalysis, pkg, data.Normalized(), ""); normErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to save normalized strace data to %s: %w", dest.NormalizedDynamicAnalysis, normErr))
+		}
+	}
+	return err
+}
+
+// saveDynamicAnalysisResults saves the results of SaveDynamicAnalysisData other than
+// the normalized strace data.
+func saveDynamicAnalysisResults(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, data analysisrun.DynamicAnalysisData) error {
 	if dest.DynamicAnalysis == nil {
 		// nothing to do
 		return nil
 	}
 
-	if err := dest.DynamicAnalysis.SaveDynamicAnalysis(ctx, pkg, data.StraceSummary, ""); err != nil {
+	if err := resultstore.SaveDynamicAnalysis(ctx, dest.DynamicAnalysis, pkg, data.StraceSummary, ""); err != nil {
 		return fmt.Errorf("failed to save strace data to %s: %w", dest.DynamicAnalysis, err)
 	}
 	if err := saveExecutionLog(ctx, pkg, dest, data); err != nil {
@@ -63,6 +86,50 @@ func SaveDynamicAnalysisData(ctx context.Context, pkg *pkgmanager.Pkg, dest *Res
 	return nil
 }
 
+// SaveMinimalDynamicAnalysisData saves the results of dynamic analysis run with
+// MinimalCapture to the dynamic analysis bucket in the ResultStores, as
+// minimal-<version>.json, so that they are not mistaken for full results.
+func SaveMinimalDynamicAnalysisData(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, data analysisrun.DynamicAnalysisMinimalSummary) error {
+	if dest.DynamicAnalysis == nil {
+		return nil
+	}
+
+	filename := "minimal.json"
+	if pkg.Version() != "" {
+		filename = fmt.Sprintf("minimal-%s.json", pkg.Version())
+	}
+
+	if err := resultstore.SaveDynamicAnalysis(ctx, dest.DynamicAnalysis, pkg, data, filename); err != nil {
+		return fmt.Errorf("failed to save minimal dynamic analysis data to %s: %w", dest.DynamicAnalysis, err)
+	}
+	return nil
+}
+
+// SaveRawStraceLogs saves the raw strace log of each dynamic analysis phase (see
+// RawStraceLog) to the strace log bucket in the ResultStores, as
+// strace-<version>-<phase>.log, in the order the phases were run.
+func SaveRawStraceLogs(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, logs map[analysisrun.DynamicPhase][]byte) error {
+	if dest.StraceLog == nil {
+		return nil
+	}
+
+	phases := make([]analysisrun.DynamicPhase, 0, len(logs))
+	for phase := range logs {
+		phases = append(phases, phase)
+	}
+	analysisrun.SortDynamicPhases(phases)
+	for _, phase := range phases {
+		filename := fmt.Sprintf("strace-%s.log", phase)
+		if pkg.Version() != "" {
+			filename = fmt.Sprintf("strace-%s-%s.log", pkg.Version(), phase)
+		}
+		if err := dest.StraceLog.Write(ctx, pkg, filename, bytes.NewReader(logs[phase])); err != nil {
+			return fmt.Errorf("failed to save %s strace log to %s: %w", phase, dest.StraceLog, err)
+		}
+	}
+	return nil
+}
+
 // saveExecutionLog saves the execution log to the dynamic analysis resultstore, only if it is nonempty
 func saveExecutionLog(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, data analysisrun.DynamicAnalysisData) error {
 	if dest.ExecutionLog == nil || len(data.ExecutionLog) == 0 {
@@ -75,13 +142,34 @@ func saveExecutionLog(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStor
 		execLogFilename = fmt.Sprintf("execution-log-%s.json", pkg.Version())
 	}
 
-	if err := dest.ExecutionLog.SaveDynamicAnalysis(ctx, pkg, data.ExecutionLog, execLogFilename); err != nil {
+	if err := resultstore.SaveDynamicAnalysis(ctx, dest.ExecutionLog, pkg, data.ExecutionLog, execLogFilename); err != nil {
 		return fmt.Errorf("failed to save execution log to %s: %w", dest.DynamicAnalysis, err)
 	}
 
 	return nil
 }
 
+// StaticAnalysisRecord converts the static analysis data produced by the sandbox
+// into the record that is saved for pkg.
+func StaticAnalysisRecord(pkg *pkgmanager.Pkg, data staticapi.SandboxData) (*staticapi.Record, error) {
+	var internalResult staticanalysis.Result
+	if err := json.Unmarshal(data, &internalResult); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON data from sandbox into staticanalysis.Result: %w", err)
+	}
+
+	key := analysisrun.Key{
+		Ecosystem: pkg.Ecosystem(),
+		Name:      pkg.Name(),
+		Version:   pkg.Version(),
+	}
+	record := staticapi.CreateRecord(internalResult.ToAPIResults(), key)
+	record.ArtifactSHA256 = pkg.ArtifactSHA256()
+	if g := pkg.GitSource(); g != nil {
+		record.GitSource = &staticapi.GitSource{Repository: g.Repository, Ref: g.Ref, Commit: g.Commit}
+	}
+	return record, nil
+}
+
 // SaveStaticAnalysisData saves the data from static analysis to the corresponding bucket in the ResultStores
 func SaveStaticAnalysisData(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores, data staticapi.SandboxData) error {
 	if dest.StaticAnalysis == nil {
@@ -91,20 +179,12 @@ func SaveStaticAnalysisData(ctx context.Context, pkg *pkgmanager.Pkg, dest *Resu
 		return nil
 	}
 
-	var internalResult staticanalysis.Result
-	if err := json.Unmarshal(data, &internalResult); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON data from sandbox into staticanalysis.Result: %w", err)
-	}
-
-	key := analysisrun.Key{
-		Ecosystem: pkg.Ecosystem(),
-		Name:      pkg.Name(),
-		Version:   pkg.Version(),
+	record, err := StaticAnalysisRecord(pkg, data)
+	if err != nil {
+		return err
 	}
-	serializableResult := internalResult.ToAPIResults()
-	record := staticapi.CreateRecord(serializableResult, key)
 
-	if err := dest.StaticAnalysis.SaveStaticAnalysis(ctx, pkg, record, ""); err != nil {
+	if err := resultstore.SaveStaticAnalysis(ctx, dest.StaticAnalysis, pkg, record, ""); err != nil {
 		return fmt.Errorf("failed to save static analysis results to %s: %w", dest.StaticAnalysis, err)
 	}
 
@@ -144,11 +224,11 @@ func SaveFileWritesData(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultSt
 
 // SaveAnalyzedPackage saves the analyzed package from static and dynamic analysis to the analyzed packages bucket in the ResultStores
 func SaveAnalyzedPackage(ctx context.Context, pkg *pkgmanager.Pkg, dest *ResultStores) error {
-	if pkg.IsLocal() {
+	if dest.AnalyzedPackage == nil || pkg.IsLocal() {
 		return nil
 	}
 
-	if err := dest.AnalyzedPackage.SaveAnalyzedPackage(ctx, pkg.Manager(), pkg); err != nil {
+	if err := resultstore.SaveAnalyzedPackage(ctx, dest.AnalyzedPackage, pkg.Manager(), pkg); err != nil {
 		return fmt.Errorf("failed to upload analyzed package to %s: %w", dest.AnalyzedPackage, err)
 	}
 
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/resultstore"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestSaveRawStraceLogs(t *testing.T) {
+	dir := t.TempDir()
+	pkg := pkgmanager.Manager(pkgecosystem.NPM).Extracted("package", "1.0.0", t.TempDir())
+	dest := &ResultStores{StraceLog: resultstore.NewLocal(dir)}
+	logs := map[analysisrun.DynamicPhase][]byte{
+		analysisrun.DynamicPhaseInstall: []byte("install log"),
+		analysisrun.DynamicPhaseImport:  []byte("import log"),
+	}
+
+	if err := SaveRawStraceLogs(context.Background(), pkg, dest, logs); err != nil {
+		t.Fatalf("SaveRawStraceLogs() error = %v", err)
+	}
+	for filename, want := range map[string]string{
+		"strace-1.0.0-install.log": "install log",
+		"strace-1.0.0-import.log":  "import log",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filename, got, want)
+		}
+	}
+
+	// nothing is saved without a store
+	if err := SaveRawStraceLogs(context.Background(), pkg, &ResultStores{}, logs); err != nil {
+		t.Errorf("SaveRawStraceLogs() without store error = %v", err)
+	}
+}
+
+func TestSaveDynamicAnalysisDataNormalizedError(t *testing.T) {
+	dir := t.TempDir()
+	// a file, which the normalized results cannot be saved in
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgmanager.Manager(pkgecosystem.NPM).Extracted("package", "1.0.0", t.TempDir())
+	dest := &ResultStores{
+		DynamicAnalysis:           resultstore.NewLocal(dir),
+		NormalizedDynamicAnalysis: resultstore.NewLocal(notDir),
+	}
+
+	if err := SaveDynamicAnalysisData(context.Background(), pkg, dest, analysisrun.DynamicAnalysisData{}); err == nil {
+		t.Errorf("SaveDynamicAnalysisData() succeeded, want error saving normalized results")
+	}
+	if _, err := os.Stat(filepath.Join(dir, resultstore.DefaultFilename(pkg))); err != nil {
+		t.Errorf("dynamic analysis results not saved: %v", err)
+	}
+}
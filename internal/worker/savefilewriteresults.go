@@ -11,13 +11,12 @@ import (
 	"github.com/ossf/package-analysis/pkg/api/analysisrun"
 )
 
-func saveFileWriteResults(rs *resultstore.ResultStore, ctx context.Context, pkg *pkgmanager.Pkg, data analysisrun.DynamicAnalysisData) error {
+func saveFileWriteResults(rs resultstore.ResultStore, ctx context.Context, pkg *pkgmanager.Pkg, data analysisrun.DynamicAnalysisData) error {
 	if rs == nil {
-		// TODO this should become a method on resultstore.ResultStore?
 		return errors.New("resultstore is nil")
 	}
 
-	if err := rs.SaveDynamicAnalysis(ctx, pkg, data.FileWritesSummary, ""); err != nil {
+	if err := resultstore.SaveDynamicAnalysis(ctx, rs, pkg, data.FileWritesSummary, ""); err != nil {
 		return fmt.Errorf("failed to upload file write analysis to blobstore = %w", err)
 	}
 	var allPhasesWriteBufferIdsArray []string
@@ -28,7 +27,7 @@ func saveFileWriteResults(rs *resultstore.ResultStore, ctx context.Context, pkg
 	// Remove potential duplicates across phases.
 	allPhasesWriteBufferIdsArray = utils.RemoveDuplicates(allPhasesWriteBufferIdsArray)
 	version := pkg.Version()
-	if err := rs.SaveTempFilesToZip(ctx, pkg, "write_buffers_"+version, allPhasesWriteBufferIdsArray); err != nil {
+	if err := resultstore.SaveTempFilesToZip(ctx, rs, pkg, "write_buffers_"+version, allPhasesWriteBufferIdsArray); err != nil {
 		return fmt.Errorf("failed to upload file write buffer results to blobstore = #{err}")
 	}
 	if err := utils.RemoveTempFilesDirectory(); err != nil {
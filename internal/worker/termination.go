@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+// sandboxTerminationLogPath is the absolute path of the termination log inside
+// the sandbox. The analysis command records how it ended to this file, as a
+// single JSON object.
+const sandboxTerminationLogPath = "/termination.log"
+
+// terminationJSON is the content of the termination log.
+type terminationJSON struct {
+	Reason string `json:"reason"`
+	Code   int    `json:"code"`
+	Signal string `json:"signal"`
+}
+
+// retrieveTermination returns how the analysis command of a phase with the given
+// status ended. Phases that timed out are always killed, otherwise the termination
+// log is copied back from the sandbox. If the analysis command did not write the
+// log (e.g. because it does not support recording its termination), the result is
+// empty.
+func retrieveTermination(ctx context.Context, sb sandbox.Sandbox, status analysis.Status) (analysisrun.TerminationResult, error) {
+	if status == analysis.StatusErrorTimeout {
+		return analysisrun.TerminationResult{Reason: analysisrun.TerminationTimeout}, nil
+	}
+
+	logDir, err := os.MkdirTemp("", "")
+	if err != nil {
+		return analysisrun.TerminationResult{}, err
+	}
+	defer os.RemoveAll(logDir)
+	hostLogPath := filepath.Join(logDir, "termination.log")
+
+	if err := sb.CopyBackToHost(ctx, hostLogPath, sandboxTerminationLogPath); err != nil {
+		slog.DebugContext(ctx, "Could not retrieve termination log from sandbox", "error", err)
+		return analysisrun.TerminationResult{}, nil
+	}
+
+	data, err := os.ReadFile(hostLogPath)
+	if err != nil {
+		return analysisrun.TerminationResult{}, err
+	}
+	return parseTerminationLog(data)
+}
+
+// parseTerminationLog returns the termination recorded in the termination log.
+func parseTerminationLog(data []byte) (analysisrun.TerminationResult, error) {
+	var t terminationJSON
+	if err := json.Unmarshal(data, &t); err != nil {
+		return analysisrun.TerminationResult{}, fmt.Errorf("failed to parse termination log: %w", err)
+	}
+	switch t.Reason {
+	case analysisrun.TerminationCompleted, analysisrun.TerminationExit, analysisrun.TerminationSignal:
+	default:
+		return analysisrun.TerminationResult{}, fmt.Errorf("unknown termination reason %q", t.Reason)
+	}
+	return analysisrun.TerminationResult{Reason: t.Reason, ExitCode: t.Code, Signal: t.Signal}, nil
+}
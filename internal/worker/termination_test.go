@@ -0,0 +1,53 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestParseTerminationLog(t *testing.T) {
+	tests := []struct {
+		name    string
+		log     string
+		want    analysisrun.TerminationResult
+		wantErr bool
+	}{
+		{
+			name: "completed",
+			log:  `{"reason":"completed","code":0}`,
+			want: analysisrun.TerminationResult{Reason: analysisrun.TerminationCompleted},
+		},
+		{
+			name: "explicit exit",
+			log:  `{"reason":"exit","code":3}`,
+			want: analysisrun.TerminationResult{Reason: analysisrun.TerminationExit, ExitCode: 3},
+		},
+		{
+			name: "signal",
+			log:  `{"reason":"signal","signal":"SIGTERM"}`,
+			want: analysisrun.TerminationResult{Reason: analysisrun.TerminationSignal, Signal: "SIGTERM"},
+		},
+		{
+			name:    "unknown reason",
+			log:     `{"reason":"crashed"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			log:     `not json`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTerminationLog([]byte(tt.log))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTerminationLog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTerminationLog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/dynamicanalysis"
+	"github.com/ossf/package-analysis/internal/featureflags"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/sandbox"
+	"github.com/ossf/package-analysis/internal/utils"
+)
+
+// ErrNoArtifactHash is returned by ArtifactHash for packages that have no single
+// artifact to hash, i.e. extracted packages (see pkgmanager.PkgManager.Extracted),
+// or no local artifact, i.e. packages that have not been downloaded on the host.
+var ErrNoArtifactHash = errors.New("package has no artifact to hash")
+
+/*
+VerdictCache holds the results of previous dynamic analyses, keyed by the SHA256
+hash of the analysed package artifact (see ArtifactHash). It is used by
+RunDynamicAnalysisCached to skip analysing artifacts which are byte-identical to
+one that has already been analysed.
+
+Implementations may be backed by any store, e.g. a database of previous results,
+or a fixed allowlist of known good artifacts. They must be safe for concurrent use.
+*/
+type VerdictCache interface {
+	// Get returns the result recorded for the artifact with the given hash,
+	// and whether there was one.
+	Get(ctx context.Context, hash string) (DynamicAnalysisResult, bool, error)
+	// Put records the result of analysing the artifact with the given hash.
+	Put(ctx context.Context, hash string, result DynamicAnalysisResult) error
+}
+
+// MemoryVerdictCache is a VerdictCache which holds results in memory.
+// The zero value is an empty cache ready to use.
+type MemoryVerdictCache struct {
+	mu      sync.Mutex
+	results map[string]DynamicAnalysisResult
+}
+
+func (c *MemoryVerdictCache) Get(_ context.Context, hash string) (DynamicAnalysisResult, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[hash]
+	return result, ok, nil
+}
+
+func (c *MemoryVerdictCache) Put(_ context.Context, hash string, result DynamicAnalysisResult) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.results == nil {
+		c.results = make(map[string]DynamicAnalysisResult)
+	}
+	c.results[hash] = result
+	return nil
+}
+
+/*
+ArtifactHash returns the hex-encoded SHA256 hash of the archive of the given package.
+If the hash is already known (see AttachArtifactHash), it is returned as is. For a
+local package, the hash of the local archive is returned.
+
+The hash must be of the bytes that are analyzed, so the archive is not downloaded
+here, separately from the copy given to the sandboxes: a package that is not local
+must be downloaded on the host first (see DownloadToTempDir), and analyzed as the
+local archive. ErrNoArtifactHash is returned for such packages, and for extracted
+packages, which have no archive.
+*/
+func ArtifactHash(pkg *pkgmanager.Pkg) (string, error) {
+	if hash := pkg.ArtifactSHA256(); hash != "" {
+		return hash, nil
+	}
+	if pkg.IsExtracted() || !pkg.IsLocal() {
+		return "", ErrNoArtifactHash
+	}
+	return utils.SHA256Hash(pkg.LocalPath())
+}
+
+/*
+AttachArtifactHash returns a copy of pkg recording the hash of its archive (see
+ArtifactHash), which is then included in the analysis results saved for it, if the
+ArtifactHash feature is enabled. If the feature is disabled, pkg is returned
+unchanged. If the hash cannot be computed (e.g. for extracted packages), the error
+is logged and pkg is returned unchanged.
+
+It must be called after the package is downloaded on the host (see
+NeedsHostDownload and DownloadToTempDir), with the local package of the download,
+so that the hashed archive is the one given to the sandboxes.
+*/
+func AttachArtifactHash(ctx context.Context, pkg *pkgmanager.Pkg) *pkgmanager.Pkg {
+	if !featureflags.ArtifactHash.Enabled() {
+		return pkg
+	}
+	hash, err := ArtifactHash(pkg)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not hash package artifact", "error", err)
+		return pkg
+	}
+	slog.InfoContext(ctx, "Hashed package artifact", "artifact_sha256", hash)
+	return pkg.WithArtifactSHA256(hash)
+}
+
+/*
+RunDynamicAnalysisCached is like RunDynamicAnalysis, but first looks up the hash of the
+package artifact in cache. If a result is found, it is returned without running the
+analysis, and cached is true. Otherwise, the analysis is run, and if it completed
+successfully, its result is added to the cache.
+
+A package that is not local is downloaded once on the host, and the downloaded
+archive is both hashed and copied into the sandbox, so that the cached result is
+that of the bytes which were hashed.
+
+If the artifact hash cannot be computed, or the cache returns an error, the error is
+logged and the analysis is run as normal, without using the cache.
+
+Results are cached by artifact hash alone, so a cache should not be shared between
+runs with different cmdOverrides or phaseEnv.
+*/
+func RunDynamicAnalysisCached(ctx context.Context, pkg *pkgmanager.Pkg, cache VerdictCache, sbOpts []sandbox.Option, analysisCmd string, cmdOverrides dynamicanalysis.CommandOverrides, phaseEnv dynamicanalysis.PhaseEnvironments) (result DynamicAnalysisResult, cached bool, err error) {
+	if !pkg.IsLocal() && pkg.ArtifactSHA256() == "" {
+		local, copyOpts, err := downloadToHost(ctx, pkg)
+		if err != nil {
+			LogDynamicAnalysisError(ctx, pkg, "", err)
+			return DynamicAnalysisResult{}, false, err
+		}
+		defer os.RemoveAll(filepath.Dir(local.LocalPath()))
+		pkg = local
+		sbOpts = append(slices.Clone(sbOpts), copyOpts...)
+	}
+
+	hash, err := ArtifactHash(pkg)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not hash package artifact, not using verdict cache", "error", err)
+		result, err = RunDynamicAnalysis(ctx, pkg, sbOpts, analysisCmd, cmdOverrides, phaseEnv)
+		return result, false, err
+	}
+
+	if result, found, err := cache.Get(ctx, hash); err != nil {
+		slog.WarnContext(ctx, "Could not read verdict cache", "artifact_sha256", hash, "error", err)
+	} else if found {
+		slog.InfoContext(ctx, "Using cached dynamic analysis result", "artifact_sha256", hash)
+		return result, true, nil
+	}
+
+	result, err = RunDynamicAnalysis(ctx, pkg, sbOpts, analysisCmd, cmdOverrides, phaseEnv)
+	if err == nil && result.LastStatus == analysis.StatusCompleted {
+		if err := cache.Put(ctx, hash, result); err != nil {
+			slog.WarnContext(ctx, "Could not update verdict cache", "artifact_sha256", hash, "error", err)
+		}
+	}
+	return result, false, err
+}
@@ -0,0 +1,146 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/internal/featureflags"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+)
+
+func TestArtifactHash(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.tgz")
+	if err := os.WriteFile(archive, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manager := pkgmanager.Manager(pkgecosystem.NPM)
+
+	got, err := ArtifactHash(manager.Local("package", "1.0.0", archive))
+	if err != nil {
+		t.Fatalf("ArtifactHash(local) error = %v", err)
+	}
+	if want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"; got != want {
+		t.Errorf("ArtifactHash(local) = %q, want %q", got, want)
+	}
+
+	if _, err := ArtifactHash(manager.Extracted("package", "1.0.0", dir)); !errors.Is(err, ErrNoArtifactHash) {
+		t.Errorf("ArtifactHash(extracted) error = %v, want %v", err, ErrNoArtifactHash)
+	}
+	// not downloaded on the host, so there are no analyzed bytes to hash yet
+	if _, err := ArtifactHash(manager.Package("package", "1.0.0")); !errors.Is(err, ErrNoArtifactHash) {
+		t.Errorf("ArtifactHash(remote) error = %v, want %v", err, ErrNoArtifactHash)
+	}
+}
+
+func TestAttachArtifactHash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.tgz")
+	if err := os.WriteFile(archive, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	manager := pkgmanager.Manager(pkgecosystem.NPM)
+	pkg := manager.Local("package", "1.0.0", archive)
+
+	if got := AttachArtifactHash(ctx, pkg); got.ArtifactSHA256() != "" {
+		t.Errorf("AttachArtifactHash() with feature disabled hash = %q, want none", got.ArtifactSHA256())
+	}
+
+	if err := featureflags.Update("ArtifactHash"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = featureflags.Update("-ArtifactHash") }()
+
+	got := AttachArtifactHash(ctx, pkg)
+	const want = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got.ArtifactSHA256() != want {
+		t.Errorf("AttachArtifactHash() hash = %q, want %q", got.ArtifactSHA256(), want)
+	}
+	if pkg.ArtifactSHA256() != "" {
+		t.Errorf("AttachArtifactHash() modified the original package")
+	}
+	// The attached hash is used instead of hashing the archive again.
+	if err := os.Remove(archive); err != nil {
+		t.Fatal(err)
+	}
+	if hash, err := ArtifactHash(got); err != nil || hash != want {
+		t.Errorf("ArtifactHash() = %q, %v, want %q, nil", hash, err, want)
+	}
+	if got := AttachArtifactHash(ctx, manager.Extracted("package", "1.0.0", dir)); got.ArtifactSHA256() != "" {
+		t.Errorf("AttachArtifactHash(extracted) hash = %q, want none", got.ArtifactSHA256())
+	}
+}
+
+func TestNeedsHostDownloadArtifactHash(t *testing.T) {
+	manager := pkgmanager.Manager(pkgecosystem.NPM)
+	pkg := manager.Package("package", "1.0.0")
+	if NeedsHostDownload(pkg) {
+		t.Errorf("NeedsHostDownload() with feature disabled = true, want false")
+	}
+
+	if err := featureflags.Update("ArtifactHash"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = featureflags.Update("-ArtifactHash") }()
+
+	// downloaded on the host, so that the hashed archive is the analyzed one
+	if !NeedsHostDownload(pkg) {
+		t.Errorf("NeedsHostDownload() = false, want true")
+	}
+	if NeedsHostDownload(manager.Local("package", "1.0.0", "package.tgz")) {
+		t.Errorf("NeedsHostDownload(local) = true, want false")
+	}
+}
+
+func TestRunDynamicAnalysisCachedHit(t *testing.T) {
+	ctx := context.Background()
+	archive := filepath.Join(t.TempDir(), "package.tgz")
+	if err := os.WriteFile(archive, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgmanager.Manager(pkgecosystem.NPM).Local("package", "1.0.0", archive)
+
+	hash, err := ArtifactHash(pkg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DynamicAnalysisResult{LastStatus: analysis.StatusCompleted}
+	cache := &MemoryVerdictCache{}
+	if err := cache.Put(ctx, hash, want); err != nil {
+		t.Fatal(err)
+	}
+
+	// No sandbox options are given, so this would fail if the analysis was run.
+	got, cached, err := RunDynamicAnalysisCached(ctx, pkg, cache, nil, "", nil, nil)
+	if err != nil || !cached {
+		t.Fatalf("RunDynamicAnalysisCached() = _, %v, %v, want _, true, nil", cached, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RunDynamicAnalysisCached() = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryVerdictCache(t *testing.T) {
+	ctx := context.Background()
+	var cache MemoryVerdictCache
+
+	if _, found, err := cache.Get(ctx, "abc"); found || err != nil {
+		t.Errorf("Get() on empty cache = _, %v, %v, want _, false, nil", found, err)
+	}
+
+	want := DynamicAnalysisResult{LastStatus: analysis.StatusCompleted}
+	if err := cache.Put(ctx, "abc", want); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	got, found, err := cache.Get(ctx, "abc")
+	if !found || err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Get() = %v, %v, %v, want %v, true, nil", got, found, err, want)
+	}
+}
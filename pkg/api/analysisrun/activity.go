@@ -0,0 +1,142 @@
+package analysisrun
+
+// ActivityLevel summarises how much a package did during a single dynamic
+// analysis phase. It can be used to distinguish packages that ran but did
+// (almost) nothing from those that were actively doing things.
+type ActivityLevel struct {
+	// FilesWritten is the number of distinct files opened for writing or written to.
+	FilesWritten int
+	// Connections is the number of distinct Internet (IPv4 or IPv6) sockets
+	// connected or bound to. Unix domain sockets are not counted, since they
+	// are used by many programs to talk to local services (e.g. syslog).
+	Connections int
+	// Processes is the number of distinct commands executed.
+	Processes int
+	// Syscalls is the total number of syscalls made.
+	Syscalls int
+}
+
+// IsIdle returns true if no files were written, no connections were made
+// and no processes were spawned during the phase.
+func (a ActivityLevel) IsIdle() bool {
+	return a.FilesWritten == 0 && a.Connections == 0 && a.Processes == 0
+}
+
+// Activity returns the ActivityLevel computed from the strace summary.
+func (s *StraceSummary) Activity() ActivityLevel {
+	if s == nil {
+		return ActivityLevel{}
+	}
+
+	filesWritten := 0
+	for _, f := range s.Files {
+		if f.Write {
+			filesWritten++
+		}
+	}
+	connections := 0
+	for _, socket := range s.Sockets {
+		if socket.Family == FamilyInet || socket.Family == FamilyInet6 {
+			connections++
+		}
+	}
+
+	return ActivityLevel{
+		FilesWritten: filesWritten,
+		Connections:  connections,
+		Processes:    len(s.Commands),
+		Syscalls:     s.SyscallCount,
+	}
+}
+
+// ActivityLevels returns the ActivityLevel for each phase that has a strace summary.
+func (d DynamicAnalysisData) ActivityLevels() map[DynamicPhase]ActivityLevel {
+	levels := make(map[DynamicPhase]ActivityLevel, len(d.StraceSummary))
+	for phase, summary := range d.StraceSummary {
+		levels[phase] = summary.Activity()
+	}
+	return levels
+}
+
+// HadNetworkActivity returns true if any sockets other than Unix domain sockets
+// were used, or any DNS queries were made, apart from the traffic of package
+// managers to the package registries (see IsRegistryHost). Sockets to port 53 are
+// not counted when DNS queries were captured, since the queries themselves are.
+func (s *StraceSummary) HadNetworkActivity() bool {
+	if s == nil {
+		return false
+	}
+	for _, r := range s.DNS {
+		for _, q := range r.Queries {
+			if !IsRegistryHost(q.Hostname) {
+				return true
+			}
+		}
+	}
+	for _, socket := range s.Sockets {
+		if socket.Family == FamilyUnix || socket.toRegistry() || (socket.Port == 53 && len(s.DNS) > 0) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// WroteOutsidePackage returns true if any file outside PackageDir (and the
+// directories that packages are installed into) was written to, other than the
+// log files of the analysis commands and the caches of package managers (see
+// IsHarnessFile).
+func (s *StraceSummary) WroteOutsidePackage() bool {
+	if s == nil {
+		return false
+	}
+	for _, f := range s.Files {
+		if f.Write && !pseudoFiles[f.Path] && !IsPackageFile(f.Path) && !IsHarnessFile(f.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// SpawnedProcesses returns true if any external commands other than the analysis
+// commands and package managers (see IsHarnessCommand) were executed. The counts
+// of Processes are not used, since they include the processes of the harness.
+func (s *StraceSummary) SpawnedProcesses() bool {
+	if s == nil {
+		return false
+	}
+	for _, c := range s.Commands {
+		if !IsHarnessCommand(c.Command) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyPhase returns true if f returns true for the strace summary of any phase.
+func (d DynamicAnalysisData) anyPhase(f func(*StraceSummary) bool) bool {
+	for _, summary := range d.StraceSummary {
+		if f(summary) {
+			return true
+		}
+	}
+	return false
+}
+
+// HadNetworkActivity returns true if there was network activity in any phase.
+// See StraceSummary.HadNetworkActivity.
+func (d DynamicAnalysisData) HadNetworkActivity() bool {
+	return d.anyPhase((*StraceSummary).HadNetworkActivity)
+}
+
+// WroteOutsidePackage returns true if a file outside the package directory was
+// written to in any phase. See StraceSummary.WroteOutsidePackage.
+func (d DynamicAnalysisData) WroteOutsidePackage() bool {
+	return d.anyPhase((*StraceSummary).WroteOutsidePackage)
+}
+
+// SpawnedProcesses returns true if external processes were spawned in any phase.
+// See StraceSummary.SpawnedProcesses.
+func (d DynamicAnalysisData) SpawnedProcesses() bool {
+	return d.anyPhase((*StraceSummary).SpawnedProcesses)
+}
@@ -0,0 +1,194 @@
+package analysisrun_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestActivityLevels(t *testing.T) {
+	data := analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseInstall: {
+				Files: []analysisrun.FileResult{
+					{Path: "/tmp/a", Write: true},
+					{Path: "/etc/passwd", Read: true},
+					{Path: "/tmp/b", Read: true, Write: true},
+				},
+				Sockets: []analysisrun.SocketResult{
+					{Family: analysisrun.FamilyInet, Address: "1.2.3.4", Port: 443},
+					{Family: analysisrun.FamilyInet6, Address: "::1", Port: 8080},
+					{Family: analysisrun.FamilyUnix, Address: "/dev/log"},
+				},
+				Commands:     []analysisrun.CommandResult{{Command: []string{"sh"}}, {Command: []string{"curl"}}},
+				SyscallCount: 1234,
+			},
+			analysisrun.DynamicPhaseImport: {
+				Files:        []analysisrun.FileResult{{Path: "/app/index.js", Read: true}},
+				Sockets:      []analysisrun.SocketResult{{Family: analysisrun.FamilyUnix, Address: "/var/run/nscd/socket"}},
+				SyscallCount: 50,
+			},
+		},
+	}
+
+	want := map[analysisrun.DynamicPhase]analysisrun.ActivityLevel{
+		analysisrun.DynamicPhaseInstall: {FilesWritten: 2, Connections: 2, Processes: 2, Syscalls: 1234},
+		analysisrun.DynamicPhaseImport:  {Syscalls: 50},
+	}
+
+	got := data.ActivityLevels()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ActivityLevels() = %v, want %v", got, want)
+	}
+
+	if got[analysisrun.DynamicPhaseInstall].IsIdle() {
+		t.Errorf("install phase IsIdle() = true, want false")
+	}
+	if !got[analysisrun.DynamicPhaseImport].IsIdle() {
+		t.Errorf("import phase IsIdle() = false, want true")
+	}
+}
+
+func TestActivityRollups(t *testing.T) {
+	tests := []struct {
+		name        string
+		summary     *analysisrun.StraceSummary
+		wantNetwork bool
+		wantWrites  bool
+		wantSpawned bool
+	}{
+		{
+			name:    "nil summary",
+			summary: nil,
+		},
+		{
+			name: "idle",
+			summary: &analysisrun.StraceSummary{
+				Files:   []analysisrun.FileResult{{Path: "/etc/passwd", Read: true}, {Path: "/dev/null", Write: true}},
+				Sockets: []analysisrun.SocketResult{{Family: "AF_UNIX", Address: "/var/run/nscd/socket"}},
+			},
+		},
+		{
+			name: "writes inside package",
+			summary: &analysisrun.StraceSummary{
+				Files: []analysisrun.FileResult{
+					{Path: "/app/node_modules/foo/index.js", Write: true},
+					{Path: "/usr/local/lib/python3.11/site-packages/foo/__init__.py", Write: true},
+				},
+			},
+		},
+		{
+			name: "write outside package",
+			summary: &analysisrun.StraceSummary{
+				Files: []analysisrun.FileResult{{Path: "/application/x", Write: true}, {Path: "/root/.bashrc", Write: true}},
+			},
+			wantWrites: true,
+		},
+		{
+			name: "socket",
+			summary: &analysisrun.StraceSummary{
+				Sockets: []analysisrun.SocketResult{{Family: "AF_INET", Address: "1.2.3.4", Port: 443}},
+			},
+			wantNetwork: true,
+		},
+		{
+			name: "dns",
+			summary: &analysisrun.StraceSummary{
+				DNS: []analysisrun.DNSResult{{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "example.com"}}}},
+			},
+			wantNetwork: true,
+		},
+		{
+			name: "command",
+			summary: &analysisrun.StraceSummary{
+				Commands: []analysisrun.CommandResult{{Command: []string{"sh", "-c", "id"}}},
+			},
+			wantSpawned: true,
+		},
+		{
+			name: "harness processes",
+			summary: &analysisrun.StraceSummary{
+				Commands:  []analysisrun.CommandResult{{Command: []string{"npm", "install", "foo"}}},
+				Processes: analysisrun.ProcessResult{Spawned: 3, Execs: 1},
+			},
+		},
+		{
+			name:    "benign npm install",
+			summary: benignInstallSummary(),
+		},
+		{
+			name: "install script",
+			summary: func() *analysisrun.StraceSummary {
+				s := benignInstallSummary()
+				s.Commands = append(s.Commands, analysisrun.CommandResult{Command: []string{"sh", "-c", "node install.js"}})
+				s.Files = append(s.Files, analysisrun.FileResult{Path: "/tmp/payload", Write: true})
+				s.DNS[0].Queries = append(s.DNS[0].Queries, analysisrun.DNSQueries{Hostname: "example.com"})
+				return s
+			}(),
+			wantNetwork: true,
+			wantWrites:  true,
+			wantSpawned: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.HadNetworkActivity(); got != tt.wantNetwork {
+				t.Errorf("HadNetworkActivity() = %v, want %v", got, tt.wantNetwork)
+			}
+			if got := tt.summary.WroteOutsidePackage(); got != tt.wantWrites {
+				t.Errorf("WroteOutsidePackage() = %v, want %v", got, tt.wantWrites)
+			}
+			if got := tt.summary.SpawnedProcesses(); got != tt.wantSpawned {
+				t.Errorf("SpawnedProcesses() = %v, want %v", got, tt.wantSpawned)
+			}
+
+			data := analysisrun.DynamicAnalysisData{
+				StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+					analysisrun.DynamicPhaseImport:  {},
+					analysisrun.DynamicPhaseInstall: tt.summary,
+				},
+			}
+			if got := data.HadNetworkActivity(); got != tt.wantNetwork {
+				t.Errorf("DynamicAnalysisData.HadNetworkActivity() = %v, want %v", got, tt.wantNetwork)
+			}
+			if got := data.WroteOutsidePackage(); got != tt.wantWrites {
+				t.Errorf("DynamicAnalysisData.WroteOutsidePackage() = %v, want %v", got, tt.wantWrites)
+			}
+			if got := data.SpawnedProcesses(); got != tt.wantSpawned {
+				t.Errorf("DynamicAnalysisData.SpawnedProcesses() = %v, want %v", got, tt.wantSpawned)
+			}
+		})
+	}
+}
+
+// benignInstallSummary returns the strace summary of the install phase of a
+// package without install scripts, which only has the activity of the harness
+// and npm.
+func benignInstallSummary() *analysisrun.StraceSummary {
+	return &analysisrun.StraceSummary{
+		Files: []analysisrun.FileResult{
+			{Path: "/app/package.json", Read: true, Write: true},
+			{Path: "/app/node_modules/foo/index.js", Write: true},
+			{Path: "/root/.npm/_cacache/index-v5/1f/2e/abc", Read: true, Write: true},
+			{Path: "/root/.npm/_logs/2024-01-01T00_00_00_000Z-debug-0.log", Write: true},
+			{Path: "/tmp/npm-1-abc/package.tgz", Write: true},
+			{Path: "/execution.log", Write: true},
+			{Path: "/module-loads.log", Write: true},
+			{Path: "/dev/null", Write: true},
+		},
+		Sockets: []analysisrun.SocketResult{
+			{Family: analysisrun.FamilyUnix, Address: "/var/run/nscd/socket"},
+			{Family: analysisrun.FamilyInet, Address: "10.0.2.3", Port: 53},
+			{Family: analysisrun.FamilyInet, Address: "104.16.1.35", Port: 443, Hostnames: []string{"registry.npmjs.org"}, ServerNames: []string{"registry.npmjs.org"}},
+		},
+		Commands: []analysisrun.CommandResult{
+			{Command: []string{"/usr/local/bin/analyze-node.js", "--package", "foo", "--phase", "install"}},
+			{Command: []string{"node", "/usr/local/bin/analyze-node.js", "--package", "foo", "--phase", "install"}},
+			{Command: []string{"npm", "init", "--force"}},
+			{Command: []string{"node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "install", "foo"}},
+		},
+		DNS:       []analysisrun.DNSResult{{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "registry.npmjs.org", Types: []string{"A", "AAAA"}}}}},
+		Processes: analysisrun.ProcessResult{Spawned: 12, Execs: 4},
+	}
+}
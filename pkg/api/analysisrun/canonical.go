@@ -0,0 +1,174 @@
+package analysisrun
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/utils"
+)
+
+// sortSockets sorts sockets by family, address and port, and the hostnames and
+// server names of each socket alphabetically.
+func sortSockets(sockets []SocketResult) {
+	for _, sock := range sockets {
+		slices.Sort(sock.Hostnames)
+		slices.Sort(sock.ServerNames)
+	}
+	slices.SortStableFunc(sockets, func(a, b SocketResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Family, b.Family), cmp.Compare(a.Address, b.Address), cmp.Compare(a.Port, b.Port))
+	})
+}
+
+/*
+Canonicalize sorts the entries of the strace summary into a canonical order, so that
+summaries of the same behaviour serialize to identical output regardless of the order
+in which the behaviour was observed or collected (e.g. by iterating over a map). This
+allows results from different runs of the same package to be compared directly.
+
+Entries are sorted as follows:
+
+  - Files, Canaries and SelfModifications by path (and then command).
+  - Sockets, PlainHTTPConnections, DeniedConnections and DirectIPConnections by
+    family, address and port, and their Hostnames and ServerNames alphabetically.
+  - Commands by command line, then environment.
+  - DNS results by class, their Queries by hostname, and query Types alphabetically.
+  - PrivilegedSyscalls by name, and Redactions by source and then type.
+  - EnvironmentChecks by category, source and indicator.
+  - Sleeps by syscall and then duration.
+  - LingeringProcesses by command, with processes that were not daemonized first.
+  - LoadedModules by name and then path.
+  - Links by path and then target.
+  - The SensitiveReads of FileReads by directory.
+  - ReconExfiltration by tool and then command, and their Destinations like
+    Sockets.
+  - StagedExecutions by path, then writer, then command.
+  - SourceExfiltration by kind and then root, and their Destinations like Sockets.
+  - SecurityTampering by rule, then path, then command.
+
+InstallScripts are left in the order that they are run, and Stdout and Stderr are
+not modified.
+*/
+func (s *StraceSummary) Canonicalize() {
+	slices.SortStableFunc(s.Files, func(a, b FileResult) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	sortSockets(s.Sockets)
+	sortSockets(s.PlainHTTPConnections)
+	sortSockets(s.DeniedConnections)
+	sortSockets(s.DirectIPConnections)
+
+	slices.SortStableFunc(s.Commands, func(a, b CommandResult) int {
+		return utils.FirstNonZero(slices.Compare(a.Command, b.Command), slices.Compare(a.Environment, b.Environment))
+	})
+
+	for i := range s.DNS {
+		for _, q := range s.DNS[i].Queries {
+			slices.Sort(q.Types)
+		}
+		slices.SortStableFunc(s.DNS[i].Queries, func(a, b DNSQueries) int {
+			return cmp.Compare(a.Hostname, b.Hostname)
+		})
+	}
+	slices.SortStableFunc(s.DNS, func(a, b DNSResult) int {
+		return cmp.Compare(a.Class, b.Class)
+	})
+
+	slices.SortStableFunc(s.PrivilegedSyscalls, func(a, b SyscallResult) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	slices.SortStableFunc(s.Redactions, func(a, b RedactionResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Type, b.Type))
+	})
+	slices.SortStableFunc(s.Canaries, func(a, b CanaryResult) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+	slices.SortStableFunc(s.EnvironmentChecks, func(a, b EnvironmentCheckResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Category, b.Category), cmp.Compare(a.Source, b.Source), cmp.Compare(a.Indicator, b.Indicator))
+	})
+	slices.SortStableFunc(s.Sleeps, func(a, b SleepResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Syscall, b.Syscall), cmp.Compare(a.DurationMs, b.DurationMs))
+	})
+	slices.SortStableFunc(s.SelfModifications, func(a, b SelfModificationResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.LingeringProcesses, func(a, b LingeringProcessResult) int {
+		return utils.FirstNonZero(slices.Compare(a.Command, b.Command), compareBools(a.Daemonized, b.Daemonized))
+	})
+	slices.SortStableFunc(s.LoadedModules, func(a, b LoadedModuleResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Path, b.Path))
+	})
+	slices.SortStableFunc(s.Links, func(a, b LinkResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Target, b.Target))
+	})
+	slices.SortStableFunc(s.FileReads.SensitiveReads, func(a, b SensitiveReadResult) int {
+		return cmp.Compare(a.Directory, b.Directory)
+	})
+	slices.SortStableFunc(s.ModuleWrites, func(a, b ModuleWriteResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.RegistryPublishes, func(a, b RegistryPublishResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Registry, b.Registry), cmp.Compare(a.Action, b.Action), slices.Compare(a.Command, b.Command))
+	})
+	slices.Sort(s.RegistryCredentialReads)
+	slices.SortStableFunc(s.CredentialStoreReads, func(a, b CredentialStoreReadResult) int {
+		return cmp.Compare(a.Store, b.Store)
+	})
+	for _, r := range s.CredentialStoreReads {
+		slices.Sort(r.Paths)
+	}
+	slices.SortStableFunc(s.ShellProfileWrites, func(a, b ShellProfileWriteResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.PathModifications, func(a, b PathModificationResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Directory, b.Directory), slices.Compare(a.Command, b.Command))
+	})
+	for _, m := range s.PathModifications {
+		slices.Sort(m.Written)
+	}
+	slices.SortStableFunc(s.ExecutableDrops, func(a, b ExecutableDropResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.NativeLibraryLoads, func(a, b NativeLibraryLoadResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.ReconExfiltration, func(a, b ReconExfiltrationResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Tool, b.Tool), slices.Compare(a.Command, b.Command))
+	})
+	for _, r := range s.ReconExfiltration {
+		sortSockets(r.Destinations)
+	}
+	slices.SortStableFunc(s.StagedExecutions, func(a, b StagedExecutionResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Path, b.Path), slices.Compare(a.Writer, b.Writer), slices.Compare(a.Command, b.Command))
+	})
+	slices.SortStableFunc(s.SourceExfiltration, func(a, b SourceExfiltrationResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Root, b.Root))
+	})
+	for _, r := range s.SourceExfiltration {
+		sortSockets(r.Destinations)
+	}
+	slices.SortStableFunc(s.SecurityTampering, func(a, b SecurityTamperingResult) int {
+		return utils.FirstNonZero(cmp.Compare(a.Rule, b.Rule), cmp.Compare(a.Path, b.Path), slices.Compare(a.Command, b.Command))
+	})
+}
+
+// compareBools orders false before true.
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Canonicalize sorts the file writes by path, so that summaries of the same writes
+// serialize to identical output. The writes to each file are left in the order that
+// they were made.
+func (f FileWritesSummary) Canonicalize() {
+	slices.SortStableFunc(f, func(a, b FileWriteResult) int {
+		return cmp.Compare(a.Path, b.Path)
+	})
+}
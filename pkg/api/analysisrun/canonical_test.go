@@ -0,0 +1,123 @@
+package analysisrun
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStraceSummaryCanonicalize(t *testing.T) {
+	summary := StraceSummary{
+		Files: []FileResult{{Path: "/tmp/b", Write: true}, {Path: "/etc/passwd", Read: true}},
+		Sockets: []SocketResult{
+			{Family: "AF_INET", Address: "10.0.0.2", Port: 443, Hostnames: []string{"b.example.com", "a.example.com"}, ServerNames: []string{"y.example.com", "x.example.com"}},
+			{Family: "AF_INET", Address: "10.0.0.1", Port: 80},
+		},
+		Commands: []CommandResult{{Command: []string{"sh", "-c", "id"}}, {Command: []string{"node", "index.js"}}},
+		DNS: []DNSResult{
+			{Class: "IN", Queries: []DNSQueries{
+				{Hostname: "z.example.com", Types: []string{"AAAA", "A"}},
+				{Hostname: "a.example.com", Types: []string{"A"}},
+			}},
+			{Class: "CH"},
+		},
+		PrivilegedSyscalls: []SyscallResult{{Name: "setuid", Count: 1}, {Name: "ptrace", Count: 2}},
+		Redactions:         []RedactionResult{{Source: "stdout", Type: "token"}, {Source: "stderr", Type: "token"}},
+		InstallScripts:     []InstallScriptResult{{Name: "preinstall"}, {Name: "install"}},
+		Sleeps:             []SleepResult{{Syscall: "nanosleep", DurationMs: 60000}, {Syscall: "nanosleep", DurationMs: 5000}},
+		PlainHTTPConnections: []SocketResult{
+			{Family: "AF_INET", Address: "10.0.0.4", Port: 80},
+			{Family: "AF_INET", Address: "10.0.0.3", Port: 80, Hostnames: []string{"d.example.com", "c.example.com"}},
+		},
+		LingeringProcesses: []LingeringProcessResult{
+			{Command: []string{"node", "miner.js"}, Daemonized: true},
+			{Command: []string{"node", "miner.js"}},
+			{Command: []string{"nc", "-l", "4444"}},
+		},
+		LoadedModules: []LoadedModuleResult{
+			{Name: "os", Builtin: true, FromPackage: true},
+			{Name: "./lib", Path: "/app/node_modules/pkg/lib/index.js", FromPackage: true},
+			{Name: "./lib", Path: "/app/node_modules/dep/lib.js"},
+		},
+		Links: []LinkResult{
+			{Path: "/app/z", Target: "/etc"},
+			{Path: "/app/a", Target: "b"},
+			{Path: "/app/a", Target: "a"},
+		},
+	}
+	want := StraceSummary{
+		Files: []FileResult{{Path: "/etc/passwd", Read: true}, {Path: "/tmp/b", Write: true}},
+		Sockets: []SocketResult{
+			{Family: "AF_INET", Address: "10.0.0.1", Port: 80},
+			{Family: "AF_INET", Address: "10.0.0.2", Port: 443, Hostnames: []string{"a.example.com", "b.example.com"}, ServerNames: []string{"x.example.com", "y.example.com"}},
+		},
+		Commands: []CommandResult{{Command: []string{"node", "index.js"}}, {Command: []string{"sh", "-c", "id"}}},
+		DNS: []DNSResult{
+			{Class: "CH"},
+			{Class: "IN", Queries: []DNSQueries{
+				{Hostname: "a.example.com", Types: []string{"A"}},
+				{Hostname: "z.example.com", Types: []string{"A", "AAAA"}},
+			}},
+		},
+		PrivilegedSyscalls: []SyscallResult{{Name: "ptrace", Count: 2}, {Name: "setuid", Count: 1}},
+		Redactions:         []RedactionResult{{Source: "stderr", Type: "token"}, {Source: "stdout", Type: "token"}},
+		// install scripts are kept in the order they are run
+		InstallScripts: []InstallScriptResult{{Name: "preinstall"}, {Name: "install"}},
+		Sleeps:         []SleepResult{{Syscall: "nanosleep", DurationMs: 5000}, {Syscall: "nanosleep", DurationMs: 60000}},
+		PlainHTTPConnections: []SocketResult{
+			{Family: "AF_INET", Address: "10.0.0.3", Port: 80, Hostnames: []string{"c.example.com", "d.example.com"}},
+			{Family: "AF_INET", Address: "10.0.0.4", Port: 80},
+		},
+		LingeringProcesses: []LingeringProcessResult{
+			{Command: []string{"nc", "-l", "4444"}},
+			{Command: []string{"node", "miner.js"}},
+			{Command: []string{"node", "miner.js"}, Daemonized: true},
+		},
+		LoadedModules: []LoadedModuleResult{
+			{Name: "./lib", Path: "/app/node_modules/dep/lib.js"},
+			{Name: "./lib", Path: "/app/node_modules/pkg/lib/index.js", FromPackage: true},
+			{Name: "os", Builtin: true, FromPackage: true},
+		},
+		Links: []LinkResult{
+			{Path: "/app/a", Target: "a"},
+			{Path: "/app/a", Target: "b"},
+			{Path: "/app/z", Target: "/etc"},
+		},
+	}
+
+	summary.Canonicalize()
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Canonicalize() = %+v\nwant %+v", summary, want)
+	}
+
+	// canonicalizing is idempotent, and so produces identical JSON
+	before, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary.Canonicalize()
+	after, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Errorf("Canonicalize() is not idempotent:\n%s\n%s", before, after)
+	}
+}
+
+func TestFileWritesSummaryCanonicalize(t *testing.T) {
+	writes := FileWritesSummary{
+		{Path: "/tmp/z", WriteInfo: []WriteInfo{{WriteBufferId: "2"}, {WriteBufferId: "1"}}},
+		{Path: "/tmp/a", WriteInfo: []WriteInfo{{WriteBufferId: "3"}}},
+	}
+	want := FileWritesSummary{
+		{Path: "/tmp/a", WriteInfo: []WriteInfo{{WriteBufferId: "3"}}},
+		// writes are kept in the order they were made
+		{Path: "/tmp/z", WriteInfo: []WriteInfo{{WriteBufferId: "2"}, {WriteBufferId: "1"}}},
+	}
+
+	writes.Canonicalize()
+	if !reflect.DeepEqual(writes, want) {
+		t.Errorf("Canonicalize() = %+v, want %+v", writes, want)
+	}
+}
@@ -0,0 +1,89 @@
+package analysisrun
+
+import (
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+)
+
+// Limits that can be hit while analysing a package, as listed by Completeness.
+const (
+	// LimitStaticFileTimeout means that some files were not parsed by static
+	// analysis because they took too long (see staticanalysis.FileResult.TimedOut).
+	LimitStaticFileTimeout = "static.file_timeout"
+	// LimitStaticError means that static analysis failed, so there are no static
+	// analysis results for the package.
+	LimitStaticError = "static.error"
+	// LimitStaticScope means that static analysis was restricted to part of the
+	// package (e.g. its entry points), so the other files were not analysed.
+	LimitStaticScope = "static.restricted_scope"
+	// LimitStaticExtraction means that extracting the package archive stopped at
+	// one of the extraction limits (e.g. its total size), so only the files that
+	// were extracted before were analysed statically.
+	LimitStaticExtraction = "static.extraction_limit"
+	// LimitDynamicTimeout means that a dynamic analysis phase was killed at its
+	// timeout, so the package may not have finished what it was doing.
+	LimitDynamicTimeout = "dynamic.timeout"
+	// LimitDynamicSkipped means that dynamic analysis was not run, e.g. because
+	// the sandbox was unavailable, so the package was only analysed statically.
+	LimitDynamicSkipped = "dynamic.skipped"
+	// LimitDynamicError means that a dynamic analysis phase could not be run or
+	// failed for a reason other than the package (e.g. a sandbox error), so it and
+	// the later phases are missing from the results.
+	LimitDynamicError = "dynamic.error"
+	// LimitDynamicMinimal means that dynamic analysis ran in minimal capture mode,
+	// which only records the cheapest signals (see MinimalSummary), so there are
+	// no full dynamic analysis results for the package.
+	LimitDynamicMinimal = "dynamic.minimal_capture"
+	// LimitStraceLogTruncated means that the retained copy of the strace log of
+	// a phase is missing data from the middle (see StraceSummary.StraceLogTruncated).
+	LimitStraceLogTruncated = "dynamic.strace_log_truncated"
+)
+
+/*
+Completeness records whether the analysis of a package ran in full, or which
+limits were hit on the way. A result that is not Complete only describes part of
+what the package does, so finding nothing in it does not mean the package is benign.
+
+Limits lists the limits that were hit, in alphabetical order. Complete is false if
+any of them cut the analysis short. LimitStraceLogTruncated does not, since the
+strace summary is computed from the whole log and only the retained log is missing
+data; it is listed so that consumers of the raw log know it is incomplete.
+*/
+type Completeness struct {
+	Complete bool     `json:"complete"`
+	Limits   []string `json:"limits"`
+}
+
+// NewCompleteness returns the Completeness of an analysis which hit the given
+// limits (e.g. LimitDynamicTimeout). Duplicate limits are ignored.
+func NewCompleteness(limits ...string) Completeness {
+	c := Completeness{Complete: true, Limits: []string{}}
+	for _, limit := range limits {
+		if slices.Contains(c.Limits, limit) {
+			continue
+		}
+		c.Limits = append(c.Limits, limit)
+		if limit != LimitStraceLogTruncated {
+			c.Complete = false
+		}
+	}
+	slices.Sort(c.Limits)
+	return c
+}
+
+// Limits returns the limits (e.g. LimitDynamicTimeout) which were hit in the
+// phase (or phases, if s is merged) summarised by s.
+func (s *StraceSummary) Limits() []string {
+	var limits []string
+	if s.Status == analysis.StatusErrorTimeout || s.Termination.Reason == TerminationTimeout {
+		limits = append(limits, LimitDynamicTimeout)
+	}
+	if s.Status == analysis.StatusErrorOther {
+		limits = append(limits, LimitDynamicError)
+	}
+	if s.StraceLogTruncated {
+		limits = append(limits, LimitStraceLogTruncated)
+	}
+	return limits
+}
@@ -0,0 +1,82 @@
+package analysisrun_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestNewCompleteness(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits []string
+		want   analysisrun.Completeness
+	}{
+		{
+			name: "no limits",
+			want: analysisrun.Completeness{Complete: true, Limits: []string{}},
+		},
+		{
+			name:   "strace log truncated",
+			limits: []string{analysisrun.LimitStraceLogTruncated},
+			want:   analysisrun.Completeness{Complete: true, Limits: []string{analysisrun.LimitStraceLogTruncated}},
+		},
+		{
+			name:   "sorted and deduplicated",
+			limits: []string{analysisrun.LimitStaticFileTimeout, analysisrun.LimitDynamicTimeout, analysisrun.LimitStaticFileTimeout},
+			want:   analysisrun.Completeness{Limits: []string{analysisrun.LimitDynamicTimeout, analysisrun.LimitStaticFileTimeout}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analysisrun.NewCompleteness(tt.limits...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewCompleteness(%v) = %+v, want %+v", tt.limits, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStraceSummaryLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary analysisrun.StraceSummary
+		want    []string
+	}{
+		{
+			name:    "completed",
+			summary: analysisrun.StraceSummary{Status: analysis.StatusCompleted},
+		},
+		{
+			name:    "timeout status",
+			summary: analysisrun.StraceSummary{Status: analysis.StatusErrorTimeout},
+			want:    []string{analysisrun.LimitDynamicTimeout},
+		},
+		{
+			name: "killed at timeout",
+			summary: analysisrun.StraceSummary{
+				Status:             analysis.StatusErrorAnalysis,
+				Termination:        analysisrun.TerminationResult{Reason: analysisrun.TerminationTimeout},
+				StraceLogTruncated: true,
+			},
+			want: []string{analysisrun.LimitDynamicTimeout, analysisrun.LimitStraceLogTruncated},
+		},
+		{
+			name:    "error",
+			summary: analysisrun.StraceSummary{Status: analysis.StatusErrorOther},
+			want:    []string{analysisrun.LimitDynamicError},
+		},
+		{
+			name:    "package failure",
+			summary: analysisrun.StraceSummary{Status: analysis.StatusErrorAnalysis},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.Limits(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Limits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package analysisrun
+
+import (
+	"path"
+	"strings"
+)
+
+// harnessLogFiles are the files in the dynamic analysis sandbox that the analysis
+// commands write their logs to.
+var harnessLogFiles = map[string]bool{
+	"/execution.log":    true,
+	"/module-loads.log": true,
+	"/termination.log":  true,
+}
+
+// analysisCommands are the names of the analysis commands run in the dynamic
+// analysis sandbox.
+var analysisCommands = map[string]bool{
+	"analyze-node.js":   true,
+	"analyze-python.py": true,
+	"analyze-ruby.rb":   true,
+	"analyze-php.php":   true,
+	"analyze-rust.py":   true,
+}
+
+// packageManagers are the names of the package managers (and the compiler run by
+// cargo) that the analysis commands install packages with.
+var packageManagers = map[string]bool{
+	"npm":           true,
+	"npm-cli.js":    true,
+	"pip":           true,
+	"pip3":          true,
+	"gem":           true,
+	"composer":      true,
+	"composer.phar": true,
+	"cargo":         true,
+	"rustc":         true,
+}
+
+// packageManagerDirs are the caches and temporary directories that package managers
+// write to while installing packages in the dynamic analysis sandbox, as path.Match
+// patterns (see packageInstallDirs).
+var packageManagerDirs = []string{
+	HomeDir + "/.npm",
+	HomeDir + "/.cache/pip",
+	HomeDir + "/.cache/node-gyp",
+	HomeDir + "/.cache/composer",
+	HomeDir + "/.composer/cache",
+	HomeDir + "/.gem/specs",
+	HomeDir + "/.local/share/gem/specs",
+	HomeDir + "/.cargo/git",
+	HomeDir + "/.cargo/.package-cache",
+	"/usr/local/cargo/git",
+	"/usr/local/cargo/.package-cache",
+	"/tmp/npm-*",
+	"/tmp/pip-*",
+}
+
+// registryHosts are the hosts of the package registries that package managers
+// download packages and their metadata from.
+var registryHosts = map[string]bool{
+	"registry.npmjs.org":     true,
+	"registry.yarnpkg.com":   true,
+	"pypi.org":               true,
+	"files.pythonhosted.org": true,
+	"rubygems.org":           true,
+	"index.rubygems.org":     true,
+	"crates.io":              true,
+	"index.crates.io":        true,
+	"static.crates.io":       true,
+	"packagist.org":          true,
+	"repo.packagist.org":     true,
+}
+
+// shellMetacharacters are the characters which make a shell script more than a
+// single command line.
+const shellMetacharacters = ";&|`$()<>\n"
+
+// IsHarnessLogFile returns whether p is one of the log files of the analysis
+// commands in the dynamic analysis sandbox.
+func IsHarnessLogFile(p string) bool {
+	return harnessLogFiles[path.Clean(p)]
+}
+
+// IsHarnessFile returns whether writing to p is done by the analysis commands or
+// the package managers rather than by the package, i.e. whether it is a log file
+// of the analysis commands or is in a cache or temporary directory of a package
+// manager.
+func IsHarnessFile(p string) bool {
+	p = path.Clean(p)
+	if harnessLogFiles[p] {
+		return true
+	}
+	for _, dir := range packageManagerDirs {
+		if inDir(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+IsHarnessCommand returns whether cmd runs one of the analysis commands or a package
+manager, either directly, as the script or module of an interpreter (e.g. 'node
+/usr/local/bin/analyze-node.js' and 'python3 -m pip'), or as the only command of a
+shell script (e.g. 'sh -c "composer.phar require foo"').
+
+The scripts that package managers run for packages (e.g. 'sh -c "node install.js"')
+are not harness commands. A package manager run by the package itself is, so this
+must not be used to hide what the package does, only to tell the activity of the
+harness apart from it.
+*/
+func IsHarnessCommand(cmd []string) bool {
+	return runsProgram(cmd, analysisCommands) || runsProgram(cmd, packageManagers)
+}
+
+// IsPackageManagerCommand returns whether cmd runs a package manager, in the same
+// way as IsHarnessCommand. Unlike the analysis commands, which load the package
+// into their own process in the import phase, package managers run the code of
+// packages in processes of their own (e.g. lifecycle scripts).
+func IsPackageManagerCommand(cmd []string) bool {
+	return runsProgram(cmd, packageManagers)
+}
+
+// runsProgram returns whether cmd runs one of the programs with the given names
+// (see IsHarnessCommand).
+func runsProgram(cmd []string, names map[string]bool) bool {
+	if len(cmd) > 2 && shells[path.Base(cmd[0])] && cmd[1] == "-c" {
+		if strings.ContainsAny(cmd[2], shellMetacharacters) {
+			return false
+		}
+		cmd = strings.Fields(cmd[2])
+	}
+	switch {
+	case len(cmd) == 0:
+		return false
+	case names[path.Base(cmd[0])]:
+		return true
+	case len(cmd) > 2 && cmd[1] == "-m":
+		return names[cmd[2]]
+	case len(cmd) > 1:
+		return names[path.Base(cmd[1])]
+	}
+	return false
+}
+
+// IsRegistryHost returns whether host is one of the package registries that
+// package managers install packages from.
+func IsRegistryHost(host string) bool {
+	return registryHosts[host]
+}
+
+// toRegistry returns whether the socket was connected to a package registry,
+// as known from its hostnames or server names.
+func (s SocketResult) toRegistry() bool {
+	for _, h := range s.Hostnames {
+		if IsRegistryHost(h) {
+			return true
+		}
+	}
+	for _, h := range s.ServerNames {
+		if IsRegistryHost(h) {
+			return true
+		}
+	}
+	return false
+}
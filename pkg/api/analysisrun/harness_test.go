@@ -0,0 +1,60 @@
+package analysisrun_test
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestIsHarnessFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/execution.log", want: true},
+		{path: "/module-loads.log", want: true},
+		{path: "/termination.log", want: true},
+		{path: "/root/.npm/_cacache/content-v2/sha512/ab/cd", want: true},
+		{path: "/root/.cache/pip/http/1/2/3", want: true},
+		{path: "/tmp/pip-install-abc/foo/setup.py", want: true},
+		{path: "/tmp/npm-12-abc/package.tgz", want: true},
+		{path: "/usr/local/cargo/git/db/foo", want: true},
+		{path: "/tmp/payload", want: false},
+		{path: "/tmp/pipe", want: false},
+		{path: "/root/.npmrc", want: false},
+		{path: "/root/.cache/evil", want: false},
+		{path: "/app/execution.log", want: false},
+	}
+	for _, tt := range tests {
+		if got := analysisrun.IsHarnessFile(tt.path); got != tt.want {
+			t.Errorf("IsHarnessFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestIsHarnessCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []string
+		want bool
+	}{
+		{name: "analysis command", cmd: []string{"/usr/local/bin/analyze-node.js", "--phase", "install"}, want: true},
+		{name: "analysis script", cmd: []string{"python3", "/usr/local/bin/analyze-python.py", "install", "foo"}, want: true},
+		{name: "package manager", cmd: []string{"npm", "install", "foo"}, want: true},
+		{name: "package manager script", cmd: []string{"node", "/usr/local/lib/node_modules/npm/bin/npm-cli.js", "install"}, want: true},
+		{name: "package manager module", cmd: []string{"/usr/bin/python3", "-m", "pip", "install", "foo"}, want: true},
+		{name: "shell running package manager", cmd: []string{"sh", "-c", "composer.phar 'require' 'foo/bar'"}, want: true},
+		{name: "lifecycle script", cmd: []string{"sh", "-c", "node install.js"}},
+		{name: "package manager in script", cmd: []string{"sh", "-c", "npm install foo && curl http://example.com"}},
+		{name: "package code", cmd: []string{"node", "install.js"}},
+		{name: "other command", cmd: []string{"curl", "http://example.com"}},
+		{name: "empty", cmd: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analysisrun.IsHarnessCommand(tt.cmd); got != tt.want {
+				t.Errorf("IsHarnessCommand(%q) = %v, want %v", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,485 @@
+package analysisrun
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+)
+
+/*
+MergedStraceSummary combines the strace summaries of each phase into a single
+package-level summary, describing everything the package did across all phases.
+Phases are merged in the order they are run (see AllDynamicPhases), followed by
+any other phases in alphabetical order. Data is combined as follows:
+
+  - Status is the status of the first phase that did not complete successfully,
+    or StatusCompleted if all phases completed. Since later phases are not run
+    after a phase fails, this is the status of the run as a whole. If there are
+    no phases, Status is empty.
+  - Stdout and Stderr are the concatenation of the output of each phase.
+  - Files are merged by path, and a file has Read, Write or Delete set if it had
+    that flag set in any phase.
+  - Sockets are merged by family, address and port, with the union of hostnames
+    and server names, and are Resolved if they were in any phase.
+  - Commands are deduplicated, as are DNS queries (with the union of query types).
+  - SyscallCount and the counts of PrivilegedSyscalls and Redactions are summed.
+  - StraceLogTruncated is set if it was set for any phase.
+  - InstallScripts are concatenated (they are only present for the install phase).
+  - Canaries are merged by path, and a canary has Read or Exfiltrated set if it
+    had that flag set in any phase.
+  - EnvironmentChecks are deduplicated, and SandboxEvasion is set if it was set
+    for any phase.
+  - Sleeps are merged by syscall and duration with their counts summed, and
+    DelayedPayload is set if it was set for any phase.
+  - SelfModifications are merged by path and command, and have Write or Delete
+    set if they had that flag set in any phase.
+  - The Spawned and Execs counts of Processes are summed, and their PeakCount and
+    PeakSpawnRate are the largest of any phase. ForkBomb is set if it was set for
+    any phase.
+  - PlainHTTPConnections are merged like Sockets.
+  - LingeringProcesses are merged by command, and are Daemonized if they were
+    in any phase.
+  - LoadedModules are merged by name and path, and have FromPackage,
+    DynamicOnly or OutsidePackage set if they had that flag set in any phase.
+  - Termination is the termination of the first phase that did not run to
+    completion, or of the last phase with a known termination otherwise.
+  - The CPU time and byte counts of Resources are summed, and their PeakMemoryBytes
+    and PeakThreads are the largest of any phase. Resources is nil if it is nil
+    for every phase.
+  - Links are deduplicated.
+  - The Directories count of FileReads is the largest of any phase, and its
+    SensitiveReads are merged by directory, with the largest count of files of
+    any phase (since the same files are often read in each phase).
+    BroadFileReads is set if it was set for any phase.
+  - ModuleWrites are merged by path and command.
+  - RegistryPublishes are merged by registry, action and command, and are
+    Connected if they were in any phase. RegistryCredentialReads are deduplicated,
+    and RegistryTakeover is set if it was set for any phase.
+  - CredentialStoreReads are merged by store, and list the paths read in any phase.
+  - ShellProfileWrites are merged by path and command.
+  - PathModifications are merged by directory, keeping the command of the first
+    phase, and list the files written in the directory in any phase. PathHijack
+    is set if it was set for any phase.
+  - ExecutableDrops are merged by path and command, and are MadeExecutable if
+    they were in any phase.
+  - NativeLibraryLoads are merged by path and command, and are Written if they
+    were in any phase.
+  - ReconExfiltration is merged by tool and command, with the union of the
+    destinations of each phase.
+  - StagedExecutions are deduplicated.
+  - NetworkDenied is set if it was set for any phase, and DeniedConnections are
+    merged like Sockets.
+  - SourceExfiltration is merged by kind and root, with the largest number of
+    files of any phase and the union of the destinations of each phase.
+  - DirectIPConnections are merged like Sockets.
+  - SecurityTampering is deduplicated.
+
+Entries in the merged summary are ordered by their first appearance.
+*/
+func (d DynamicAnalysisData) MergedStraceSummary() StraceSummary {
+	var merged StraceSummary
+
+	files := make(map[string]int)
+	sockets := make(map[socketKey]int)
+	plainHTTP := make(map[socketKey]int)
+	deniedConnections := make(map[socketKey]int)
+	directIPConnections := make(map[socketKey]int)
+	commands := make(map[string]bool)
+	syscalls := make(map[string]int)
+	redactions := make(map[[2]string]int)
+	dnsClasses := make(map[string]int)
+	canaries := make(map[string]int)
+	envChecks := make(map[EnvironmentCheckResult]bool)
+	type sleepKey struct {
+		syscall    string
+		durationMs int64
+	}
+	sleeps := make(map[sleepKey]int)
+	selfMods := make(map[string]int)
+	lingering := make(map[string]int)
+	loadedModules := make(map[[2]string]int)
+	links := make(map[LinkResult]bool)
+	sensitiveReads := make(map[string]int)
+	moduleWrites := make(map[string]bool)
+	registryPublishes := make(map[string]int)
+	credentialReads := make(map[string]bool)
+	credentialStores := make(map[string]int)
+	credentialStorePaths := make(map[string]bool)
+	shellProfileWrites := make(map[string]bool)
+	pathModifications := make(map[string]int)
+	pathModificationWrites := make(map[string]bool)
+	executableDrops := make(map[string]int)
+	nativeLibraryLoads := make(map[string]int)
+	reconExfiltration := make(map[string]int)
+	stagedExecutions := make(map[string]bool)
+	sourceExfiltration := make(map[[2]string]int)
+	securityTampering := make(map[string]bool)
+
+	for _, phase := range orderedPhases(d.StraceSummary) {
+		s := d.StraceSummary[phase]
+		if s == nil {
+			continue
+		}
+
+		if merged.Status == "" || merged.Status == analysis.StatusCompleted {
+			merged.Status = s.Status
+		}
+		if s.Termination.Reason != "" && (merged.Termination.Reason == "" || merged.Termination.Reason == TerminationCompleted) {
+			merged.Termination = s.Termination
+		}
+		if r := s.Resources; r != nil {
+			if merged.Resources == nil {
+				merged.Resources = &ResourceUsageResult{}
+			}
+			merged.Resources.CPUTimeMs += r.CPUTimeMs
+			merged.Resources.BlockReadBytes += r.BlockReadBytes
+			merged.Resources.BlockWriteBytes += r.BlockWriteBytes
+			merged.Resources.NetReceivedBytes += r.NetReceivedBytes
+			merged.Resources.NetSentBytes += r.NetSentBytes
+			merged.Resources.PeakMemoryBytes = max(merged.Resources.PeakMemoryBytes, r.PeakMemoryBytes)
+			merged.Resources.PeakThreads = max(merged.Resources.PeakThreads, r.PeakThreads)
+		}
+		merged.Stdout = append(merged.Stdout, s.Stdout...)
+		merged.Stderr = append(merged.Stderr, s.Stderr...)
+		merged.SyscallCount += s.SyscallCount
+		merged.StraceLogTruncated = merged.StraceLogTruncated || s.StraceLogTruncated
+
+		for _, f := range s.Files {
+			if i, ok := files[f.Path]; ok {
+				merged.Files[i].Read = merged.Files[i].Read || f.Read
+				merged.Files[i].Write = merged.Files[i].Write || f.Write
+				merged.Files[i].Delete = merged.Files[i].Delete || f.Delete
+			} else {
+				files[f.Path] = len(merged.Files)
+				merged.Files = append(merged.Files, f)
+			}
+		}
+
+		merged.Sockets = mergeSockets(merged.Sockets, sockets, s.Sockets)
+		merged.PlainHTTPConnections = mergeSockets(merged.PlainHTTPConnections, plainHTTP, s.PlainHTTPConnections)
+		merged.NetworkDenied = merged.NetworkDenied || s.NetworkDenied
+		merged.DeniedConnections = mergeSockets(merged.DeniedConnections, deniedConnections, s.DeniedConnections)
+		merged.DirectIPConnections = mergeSockets(merged.DirectIPConnections, directIPConnections, s.DirectIPConnections)
+
+		for _, c := range s.Commands {
+			key := strings.Join(c.Command, "\x00") + "\x01" + strings.Join(c.Environment, "\x00")
+			if !commands[key] {
+				commands[key] = true
+				merged.Commands = append(merged.Commands, c)
+			}
+		}
+
+		for _, sc := range s.PrivilegedSyscalls {
+			if i, ok := syscalls[sc.Name]; ok {
+				merged.PrivilegedSyscalls[i].Count += sc.Count
+			} else {
+				syscalls[sc.Name] = len(merged.PrivilegedSyscalls)
+				merged.PrivilegedSyscalls = append(merged.PrivilegedSyscalls, sc)
+			}
+		}
+
+		for _, r := range s.Redactions {
+			key := [2]string{r.Source, r.Type}
+			if i, ok := redactions[key]; ok {
+				merged.Redactions[i].Count += r.Count
+			} else {
+				redactions[key] = len(merged.Redactions)
+				merged.Redactions = append(merged.Redactions, r)
+			}
+		}
+
+		merged.InstallScripts = append(merged.InstallScripts, s.InstallScripts...)
+
+		for _, c := range s.Canaries {
+			if i, ok := canaries[c.Path]; ok {
+				merged.Canaries[i].Read = merged.Canaries[i].Read || c.Read
+				merged.Canaries[i].Exfiltrated = merged.Canaries[i].Exfiltrated || c.Exfiltrated
+			} else {
+				canaries[c.Path] = len(merged.Canaries)
+				merged.Canaries = append(merged.Canaries, c)
+			}
+		}
+
+		for _, c := range s.EnvironmentChecks {
+			if !envChecks[c] {
+				envChecks[c] = true
+				merged.EnvironmentChecks = append(merged.EnvironmentChecks, c)
+			}
+		}
+		merged.SandboxEvasion = merged.SandboxEvasion || s.SandboxEvasion
+
+		for _, sl := range s.Sleeps {
+			key := sleepKey{sl.Syscall, sl.DurationMs}
+			if i, ok := sleeps[key]; ok {
+				merged.Sleeps[i].Count += sl.Count
+			} else {
+				sleeps[key] = len(merged.Sleeps)
+				merged.Sleeps = append(merged.Sleeps, sl)
+			}
+		}
+		merged.DelayedPayload = merged.DelayedPayload || s.DelayedPayload
+
+		for _, m := range s.SelfModifications {
+			key := m.Path + "\x01" + strings.Join(m.Command, "\x00")
+			if i, ok := selfMods[key]; ok {
+				merged.SelfModifications[i].Write = merged.SelfModifications[i].Write || m.Write
+				merged.SelfModifications[i].Delete = merged.SelfModifications[i].Delete || m.Delete
+			} else {
+				selfMods[key] = len(merged.SelfModifications)
+				merged.SelfModifications = append(merged.SelfModifications, m)
+			}
+		}
+
+		merged.Processes.Spawned += s.Processes.Spawned
+		merged.Processes.Execs += s.Processes.Execs
+		merged.Processes.PeakCount = max(merged.Processes.PeakCount, s.Processes.PeakCount)
+		merged.Processes.PeakSpawnRate = max(merged.Processes.PeakSpawnRate, s.Processes.PeakSpawnRate)
+		merged.ForkBomb = merged.ForkBomb || s.ForkBomb
+
+		for _, p := range s.LingeringProcesses {
+			key := strings.Join(p.Command, "\x00")
+			if i, ok := lingering[key]; ok {
+				merged.LingeringProcesses[i].Daemonized = merged.LingeringProcesses[i].Daemonized || p.Daemonized
+			} else {
+				lingering[key] = len(merged.LingeringProcesses)
+				merged.LingeringProcesses = append(merged.LingeringProcesses, p)
+			}
+		}
+
+		for _, m := range s.LoadedModules {
+			key := [2]string{m.Name, m.Path}
+			if i, ok := loadedModules[key]; ok {
+				merged.LoadedModules[i].FromPackage = merged.LoadedModules[i].FromPackage || m.FromPackage
+				merged.LoadedModules[i].DynamicOnly = merged.LoadedModules[i].DynamicOnly || m.DynamicOnly
+				merged.LoadedModules[i].OutsidePackage = merged.LoadedModules[i].OutsidePackage || m.OutsidePackage
+			} else {
+				loadedModules[key] = len(merged.LoadedModules)
+				merged.LoadedModules = append(merged.LoadedModules, m)
+			}
+		}
+
+		for _, l := range s.Links {
+			if !links[l] {
+				links[l] = true
+				merged.Links = append(merged.Links, l)
+			}
+		}
+
+		merged.FileReads.Directories = max(merged.FileReads.Directories, s.FileReads.Directories)
+		for _, r := range s.FileReads.SensitiveReads {
+			if i, ok := sensitiveReads[r.Directory]; ok {
+				merged.FileReads.SensitiveReads[i].Files = max(merged.FileReads.SensitiveReads[i].Files, r.Files)
+			} else {
+				sensitiveReads[r.Directory] = len(merged.FileReads.SensitiveReads)
+				merged.FileReads.SensitiveReads = append(merged.FileReads.SensitiveReads, r)
+			}
+		}
+		merged.BroadFileReads = merged.BroadFileReads || s.BroadFileReads
+
+		for _, w := range s.ModuleWrites {
+			key := w.Path + "\x01" + strings.Join(w.Command, "\x00")
+			if !moduleWrites[key] {
+				moduleWrites[key] = true
+				merged.ModuleWrites = append(merged.ModuleWrites, w)
+			}
+		}
+
+		for _, r := range s.RegistryPublishes {
+			key := r.Registry + "\x01" + r.Action + "\x01" + strings.Join(r.Command, "\x00")
+			if i, ok := registryPublishes[key]; ok {
+				merged.RegistryPublishes[i].Connected = merged.RegistryPublishes[i].Connected || r.Connected
+			} else {
+				registryPublishes[key] = len(merged.RegistryPublishes)
+				merged.RegistryPublishes = append(merged.RegistryPublishes, r)
+			}
+		}
+		for _, p := range s.RegistryCredentialReads {
+			if !credentialReads[p] {
+				credentialReads[p] = true
+				merged.RegistryCredentialReads = append(merged.RegistryCredentialReads, p)
+			}
+		}
+		merged.RegistryTakeover = merged.RegistryTakeover || s.RegistryTakeover
+
+		for _, r := range s.CredentialStoreReads {
+			i, ok := credentialStores[r.Store]
+			if !ok {
+				i = len(merged.CredentialStoreReads)
+				credentialStores[r.Store] = i
+				merged.CredentialStoreReads = append(merged.CredentialStoreReads, CredentialStoreReadResult{Store: r.Store})
+			}
+			for _, p := range r.Paths {
+				if key := r.Store + "\x01" + p; !credentialStorePaths[key] {
+					credentialStorePaths[key] = true
+					merged.CredentialStoreReads[i].Paths = append(merged.CredentialStoreReads[i].Paths, p)
+				}
+			}
+		}
+
+		for _, w := range s.ShellProfileWrites {
+			key := w.Path + "\x01" + strings.Join(w.Command, "\x00")
+			if !shellProfileWrites[key] {
+				shellProfileWrites[key] = true
+				merged.ShellProfileWrites = append(merged.ShellProfileWrites, w)
+			}
+		}
+		for _, m := range s.PathModifications {
+			i, ok := pathModifications[m.Directory]
+			if !ok {
+				i = len(merged.PathModifications)
+				pathModifications[m.Directory] = i
+				merged.PathModifications = append(merged.PathModifications, PathModificationResult{Directory: m.Directory, Command: m.Command})
+			}
+			for _, p := range m.Written {
+				if key := m.Directory + "\x01" + p; !pathModificationWrites[key] {
+					pathModificationWrites[key] = true
+					merged.PathModifications[i].Written = append(merged.PathModifications[i].Written, p)
+				}
+			}
+		}
+		merged.PathHijack = merged.PathHijack || s.PathHijack
+		for _, e := range s.ExecutableDrops {
+			key := e.Path + "\x01" + strings.Join(e.Command, "\x00")
+			i, ok := executableDrops[key]
+			if !ok {
+				executableDrops[key] = len(merged.ExecutableDrops)
+				merged.ExecutableDrops = append(merged.ExecutableDrops, e)
+				continue
+			}
+			d := &merged.ExecutableDrops[i]
+			d.MadeExecutable = d.MadeExecutable || e.MadeExecutable
+			if d.Format == "" {
+				d.Format, d.Interpreter = e.Format, e.Interpreter
+			}
+		}
+		for _, l := range s.NativeLibraryLoads {
+			key := l.Path + "\x01" + strings.Join(l.Command, "\x00")
+			if i, ok := nativeLibraryLoads[key]; ok {
+				merged.NativeLibraryLoads[i].Written = merged.NativeLibraryLoads[i].Written || l.Written
+				continue
+			}
+			nativeLibraryLoads[key] = len(merged.NativeLibraryLoads)
+			merged.NativeLibraryLoads = append(merged.NativeLibraryLoads, l)
+		}
+		for _, r := range s.ReconExfiltration {
+			key := r.Tool + "\x01" + strings.Join(r.Command, "\x00")
+			i, ok := reconExfiltration[key]
+			if !ok {
+				i = len(merged.ReconExfiltration)
+				reconExfiltration[key] = i
+				merged.ReconExfiltration = append(merged.ReconExfiltration, ReconExfiltrationResult{Tool: r.Tool, Command: r.Command})
+			}
+			m := &merged.ReconExfiltration[i]
+			for _, dest := range r.Destinations {
+				if !slices.ContainsFunc(m.Destinations, func(sock SocketResult) bool {
+					return sock.Family == dest.Family && sock.Address == dest.Address && sock.Port == dest.Port
+				}) {
+					m.Destinations = append(m.Destinations, dest)
+				}
+			}
+		}
+		for _, e := range s.StagedExecutions {
+			key := e.Path + "\x01" + strings.Join(e.Writer, "\x00") + "\x01" + strings.Join(e.Command, "\x00")
+			if !stagedExecutions[key] {
+				stagedExecutions[key] = true
+				merged.StagedExecutions = append(merged.StagedExecutions, e)
+			}
+		}
+		for _, r := range s.SourceExfiltration {
+			key := [2]string{r.Kind, r.Root}
+			i, ok := sourceExfiltration[key]
+			if !ok {
+				i = len(merged.SourceExfiltration)
+				sourceExfiltration[key] = i
+				merged.SourceExfiltration = append(merged.SourceExfiltration, SourceExfiltrationResult{Kind: r.Kind, Root: r.Root})
+			}
+			m := &merged.SourceExfiltration[i]
+			m.Files = max(m.Files, r.Files)
+			for _, dest := range r.Destinations {
+				if !slices.ContainsFunc(m.Destinations, func(sock SocketResult) bool {
+					return sock.Family == dest.Family && sock.Address == dest.Address && sock.Port == dest.Port
+				}) {
+					m.Destinations = append(m.Destinations, dest)
+				}
+			}
+		}
+		for _, t := range s.SecurityTampering {
+			key := t.Rule + "\x01" + t.Path + "\x01" + strings.Join(t.Command, "\x00")
+			if !securityTampering[key] {
+				securityTampering[key] = true
+				merged.SecurityTampering = append(merged.SecurityTampering, t)
+			}
+		}
+
+		for _, dns := range s.DNS {
+			i, ok := dnsClasses[dns.Class]
+			if !ok {
+				i = len(merged.DNS)
+				dnsClasses[dns.Class] = i
+				merged.DNS = append(merged.DNS, DNSResult{Class: dns.Class})
+			}
+			merged.DNS[i].Queries = mergeDNSQueries(merged.DNS[i].Queries, dns.Queries)
+		}
+	}
+
+	return merged
+}
+
+// orderedPhases returns the phases present in summaries, with known phases
+// in the order that they are run, followed by any others sorted by name.
+func orderedPhases(summaries DynamicAnalysisStraceSummary) []DynamicPhase {
+	phases := make([]DynamicPhase, 0, len(summaries))
+	for phase := range summaries {
+		phases = append(phases, phase)
+	}
+	SortDynamicPhases(phases)
+	return phases
+}
+
+func mergeDNSQueries(queries []DNSQueries, more []DNSQueries) []DNSQueries {
+	for _, q := range more {
+		i := slices.IndexFunc(queries, func(existing DNSQueries) bool { return existing.Hostname == q.Hostname })
+		if i < 0 {
+			queries = append(queries, DNSQueries{Hostname: q.Hostname, Types: appendMissing(nil, q.Types...)})
+		} else {
+			queries[i].Types = appendMissing(queries[i].Types, q.Types...)
+		}
+	}
+	return queries
+}
+
+// appendMissing appends each of values to s, if it is not already present.
+type socketKey struct {
+	family, address string
+	port            int
+}
+
+// mergeSockets merges sockets into merged, which are indexed by key in indices. A
+// socket which is already in merged gains the hostnames and server names of the
+// new one, and is Resolved if either was.
+func mergeSockets(merged []SocketResult, indices map[socketKey]int, sockets []SocketResult) []SocketResult {
+	for _, sock := range sockets {
+		key := socketKey{sock.Family, sock.Address, sock.Port}
+		if i, ok := indices[key]; ok {
+			merged[i].Hostnames = appendMissing(merged[i].Hostnames, sock.Hostnames...)
+			merged[i].ServerNames = appendMissing(merged[i].ServerNames, sock.ServerNames...)
+			merged[i].Resolved = merged[i].Resolved || sock.Resolved
+		} else {
+			indices[key] = len(merged)
+			sock.Hostnames = appendMissing(nil, sock.Hostnames...)
+			sock.ServerNames = appendMissing(nil, sock.ServerNames...)
+			merged = append(merged, sock)
+		}
+	}
+	return merged
+}
+
+func appendMissing(s []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+	return s
+}
@@ -0,0 +1,407 @@
+package analysisrun_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestMergedStraceSummary(t *testing.T) {
+	data := analysisrun.DynamicAnalysisData{
+		StraceSummary: analysisrun.DynamicAnalysisStraceSummary{
+			analysisrun.DynamicPhaseImport: {
+				Status: analysis.StatusErrorAnalysis,
+				Stdout: []byte("import\n"),
+				Files: []analysisrun.FileResult{
+					{Path: "/tmp/a", Read: true},
+					{Path: "/app/index.js", Read: true},
+				},
+				Sockets: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "1.2.3.4", Port: 443, Hostnames: []string{"b.example.com"}, ServerNames: []string{"c2.example.com"}},
+				},
+				Commands:           []analysisrun.CommandResult{{Command: []string{"sh", "-c", "id"}}},
+				SyscallCount:       50,
+				PrivilegedSyscalls: []analysisrun.SyscallResult{{Name: "setuid", Count: 1}},
+				Canaries: []analysisrun.CanaryResult{
+					{Path: "/root/.aws/credentials", Read: true, Exfiltrated: true},
+					{Path: "/root/.kube/config"},
+				},
+				EnvironmentChecks: []analysisrun.EnvironmentCheckResult{
+					{Category: "container", Source: "file", Indicator: "/.dockerenv"},
+					{Category: "hardware", Source: "file", Indicator: "/proc/cpuinfo"},
+				},
+				Sleeps:         []analysisrun.SleepResult{{Syscall: "nanosleep", DurationMs: 600000, Count: 1}},
+				DelayedPayload: true,
+				SelfModifications: []analysisrun.SelfModificationResult{
+					{Path: "/app/x.js", Command: []string{"node", "x.js"}, Delete: true},
+				},
+				Processes: analysisrun.ProcessResult{Spawned: 3, Execs: 2, PeakCount: 2, PeakSpawnRate: 2},
+				PlainHTTPConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 80, Hostnames: []string{"b.example.com"}},
+				},
+				LingeringProcesses: []analysisrun.LingeringProcessResult{
+					{Command: []string{"node", "miner.js"}, Daemonized: true},
+				},
+				LoadedModules: []analysisrun.LoadedModuleResult{
+					{Name: "os", Builtin: true, FromPackage: true, DynamicOnly: true},
+					{Name: "../other/secret.js", Path: "/app/node_modules/other/secret.js", FromPackage: true, OutsidePackage: true},
+				},
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationExit, ExitCode: 0},
+				Resources: &analysisrun.ResourceUsageResult{
+					CPUTimeMs: 500, PeakMemoryBytes: 1 << 20, BlockReadBytes: 10, BlockWriteBytes: 20,
+					NetReceivedBytes: 30, NetSentBytes: 40, PeakThreads: 12,
+				},
+				Links: []analysisrun.LinkResult{
+					{Path: "/app/etc", Target: "/etc", OutsidePackage: true},
+					{Path: "/app/passwd", Target: "/etc/passwd", Hard: true, OutsidePackage: true},
+				},
+				FileReads: analysisrun.FileReadResult{
+					Directories:    30,
+					SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: 2}, {Directory: "/root/.ssh", Files: 3}},
+				},
+				BroadFileReads: true,
+				ModuleWrites: []analysisrun.ModuleWriteResult{
+					{Path: "/app/node_modules/debug/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: []string{"node", "index.js"}},
+					{Path: "/root/.npm/_cacache/index-v5/00/ab", Kind: analysisrun.ModuleWriteCache, Command: []string{"node", "index.js"}},
+				},
+				RegistryPublishes: []analysisrun.RegistryPublishResult{
+					{Registry: "registry.npmjs.org", Action: "npm publish", Command: []string{"npm", "publish"}, Connected: true},
+					{Registry: "upload.pypi.org", Action: "connection", Connected: true},
+				},
+				RegistryCredentialReads: []string{"/root/.npmrc", "/root/.pypirc"},
+				RegistryTakeover:        true,
+				CredentialStoreReads: []analysisrun.CredentialStoreReadResult{
+					{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa"}},
+				},
+				ShellProfileWrites: []analysisrun.ShellProfileWriteResult{
+					{Path: "/root/.bashrc", Command: []string{"node", "install.js"}},
+					{Path: "/root/.profile"},
+				},
+				PathModifications: []analysisrun.PathModificationResult{
+					{Directory: "/tmp/bin", Command: []string{"git", "status"}, Written: []string{"/tmp/bin/git", "/tmp/bin/sudo"}},
+				},
+				PathHijack: true,
+				ExecutableDrops: []analysisrun.ExecutableDropResult{
+					{Path: "/tmp/x", Format: analysisrun.ExecutableELF, MadeExecutable: true, Command: []string{"node", "install.js"}},
+				},
+				NativeLibraryLoads: []analysisrun.NativeLibraryLoadResult{
+					{Path: "/app/node_modules/pkg/addon.node", Command: []string{"node", "index.js"}},
+				},
+				ReconExfiltration: []analysisrun.ReconExfiltrationResult{
+					{Tool: "whoami", Command: []string{"whoami"}, Destinations: []analysisrun.SocketResult{
+						{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+						{Family: "AF_INET", Address: "9.9.9.9", Port: 443},
+					}},
+				},
+				StagedExecutions: []analysisrun.StagedExecutionResult{
+					{Path: "/tmp/payload", Writer: []string{"curl", "-o", "/tmp/payload"}, Command: []string{"/tmp/payload"}},
+				},
+				NetworkDenied: true,
+				DeniedConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 443, Hostnames: []string{"c2.example.com"}},
+				},
+				SourceExfiltration: []analysisrun.SourceExfiltrationResult{
+					{Kind: "git", Root: "/app/.git", Files: 12, Destinations: []analysisrun.SocketResult{{Family: "AF_INET", Address: "5.6.7.8", Port: 443}}},
+					{Kind: "source", Root: "/root/project", Files: 25, Destinations: []analysisrun.SocketResult{{Family: "AF_INET", Address: "9.9.9.9", Port: 443}}},
+				},
+				DirectIPConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "9.9.9.9", Port: 443},
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 4444, ServerNames: []string{"c2.example.com"}},
+				},
+				SecurityTampering: []analysisrun.SecurityTamperingResult{
+					{Rule: "log_tampering", Command: []string{"truncate", "-s", "0", "/var/log/auth.log"}, Path: "/var/log/auth.log"},
+					{Rule: "shell_history", Command: []string{"sh", "-c", "unset HISTFILE"}},
+				},
+				DNS: []analysisrun.DNSResult{
+					{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "a.example.com", Types: []string{"AAAA"}}}},
+				},
+			},
+			analysisrun.DynamicPhaseInstall: {
+				Status: analysis.StatusCompleted,
+				Stdout: []byte("install\n"),
+				Files: []analysisrun.FileResult{
+					{Path: "/tmp/a", Write: true},
+				},
+				Sockets: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "1.2.3.4", Port: 443, Hostnames: []string{"a.example.com"}, ServerNames: []string{"a.example.com"}, Resolved: true},
+				},
+				Commands:           []analysisrun.CommandResult{{Command: []string{"sh", "-c", "id"}}, {Command: []string{"node"}}},
+				SyscallCount:       1000,
+				StraceLogTruncated: true,
+				PrivilegedSyscalls: []analysisrun.SyscallResult{{Name: "setuid", Count: 2}},
+				Redactions:         []analysisrun.RedactionResult{{Source: "stdout", Type: "github_token", Count: 1}},
+				InstallScripts:     []analysisrun.InstallScriptResult{{Name: "postinstall", Script: "node x.js", Executed: true}},
+				Canaries: []analysisrun.CanaryResult{
+					{Path: "/root/.aws/credentials"},
+					{Path: "/root/.kube/config", Read: true},
+				},
+				EnvironmentChecks: []analysisrun.EnvironmentCheckResult{
+					{Category: "container", Source: "file", Indicator: "/.dockerenv"},
+				},
+				SandboxEvasion: true,
+				Sleeps: []analysisrun.SleepResult{
+					{Syscall: "epoll_wait", DurationMs: 5000, Count: 2},
+					{Syscall: "nanosleep", DurationMs: 600000, Count: 1},
+				},
+				SelfModifications: []analysisrun.SelfModificationResult{
+					{Path: "/app/x.js", Command: []string{"node", "x.js"}, Write: true},
+				},
+				Processes: analysisrun.ProcessResult{Spawned: 600, Execs: 1, PeakCount: 300, PeakSpawnRate: 1},
+				ForkBomb:  true,
+				PlainHTTPConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 80, Hostnames: []string{"a.example.com"}},
+				},
+				LingeringProcesses: []analysisrun.LingeringProcessResult{
+					{Command: []string{"nc", "-l", "4444"}},
+					{Command: []string{"node", "miner.js"}},
+				},
+				LoadedModules: []analysisrun.LoadedModuleResult{
+					{Name: "debug", Path: "/app/node_modules/debug/index.js"},
+					{Name: "os", Builtin: true},
+					{Name: "../other/secret.js", Path: "/app/node_modules/other/secret.js", FromPackage: true},
+				},
+				Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationCompleted, ExitCode: 1},
+				Resources: &analysisrun.ResourceUsageResult{
+					CPUTimeMs: 1500, PeakMemoryBytes: 1 << 19, BlockReadBytes: 1, BlockWriteBytes: 2,
+					NetReceivedBytes: 3, NetSentBytes: 4, PeakThreads: 20,
+				},
+				Links: []analysisrun.LinkResult{
+					{Path: "/app/etc", Target: "/etc", OutsidePackage: true},
+				},
+				FileReads: analysisrun.FileReadResult{
+					Directories:    3,
+					SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: 5}},
+				},
+				ModuleWrites: []analysisrun.ModuleWriteResult{
+					{Path: "/root/.npm/_cacache/index-v5/00/ab", Kind: analysisrun.ModuleWriteCache, Command: []string{"node", "index.js"}},
+				},
+				RegistryPublishes: []analysisrun.RegistryPublishResult{
+					{Registry: "registry.npmjs.org", Action: "npm publish", Command: []string{"npm", "publish"}},
+				},
+				RegistryCredentialReads: []string{"/root/.npmrc"},
+				CredentialStoreReads: []analysisrun.CredentialStoreReadResult{
+					{Store: "aws", Paths: []string{"/root/.aws/credentials"}},
+					{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa", "/root/.ssh/id_ed25519"}},
+				},
+				ShellProfileWrites: []analysisrun.ShellProfileWriteResult{
+					{Path: "/root/.bashrc", Command: []string{"node", "install.js"}},
+				},
+				PathModifications: []analysisrun.PathModificationResult{
+					{Directory: "/tmp/bin", Command: []string{"node", "install.js"}, Written: []string{"/tmp/bin/git"}},
+					{Directory: "/app/bin", Command: []string{"sh", "-c", "setup"}},
+				},
+				ExecutableDrops: []analysisrun.ExecutableDropResult{
+					{Path: "/tmp/x", Format: analysisrun.ExecutableELF, Command: []string{"node", "install.js"}},
+					{Path: "/tmp/run.sh", Format: analysisrun.ExecutableScript, Interpreter: "/bin/sh", MadeExecutable: true, Command: []string{"sh", "-c", "setup"}},
+				},
+				NativeLibraryLoads: []analysisrun.NativeLibraryLoadResult{
+					{Path: "/app/node_modules/pkg/addon.node", Written: true, Command: []string{"node", "index.js"}},
+				},
+				ReconExfiltration: []analysisrun.ReconExfiltrationResult{
+					{Tool: "whoami", Command: []string{"whoami"}, Destinations: []analysisrun.SocketResult{
+						{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+					}},
+					{Tool: "hostname", Command: []string{"hostname"}, Destinations: []analysisrun.SocketResult{
+						{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+					}},
+				},
+				StagedExecutions: []analysisrun.StagedExecutionResult{
+					{Path: "/dev/shm/x.sh", Writer: []string{"node", "install.js"}, Command: []string{"sh", "/dev/shm/x.sh"}},
+					{Path: "/tmp/payload", Writer: []string{"curl", "-o", "/tmp/payload"}, Command: []string{"/tmp/payload"}},
+				},
+				DeniedConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "1.2.3.4", Port: 80},
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+				},
+				SourceExfiltration: []analysisrun.SourceExfiltrationResult{
+					{Kind: "git", Root: "/app/.git", Files: 10, Destinations: []analysisrun.SocketResult{{Family: "AF_INET", Address: "1.2.3.4", Port: 80}}},
+				},
+				DirectIPConnections: []analysisrun.SocketResult{
+					{Family: "AF_INET", Address: "5.6.7.8", Port: 4444},
+				},
+				SecurityTampering: []analysisrun.SecurityTamperingResult{
+					{Rule: "shell_history", Command: []string{"sh", "-c", "unset HISTFILE"}},
+				},
+				DNS: []analysisrun.DNSResult{
+					{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "a.example.com", Types: []string{"A"}}}},
+				},
+			},
+		},
+	}
+
+	want := analysisrun.StraceSummary{
+		Status: analysis.StatusErrorAnalysis,
+		Stdout: []byte("install\nimport\n"),
+		Files: []analysisrun.FileResult{
+			{Path: "/tmp/a", Read: true, Write: true},
+			{Path: "/app/index.js", Read: true},
+		},
+		Sockets: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "1.2.3.4", Port: 443, Hostnames: []string{"a.example.com", "b.example.com"}, ServerNames: []string{"a.example.com", "c2.example.com"}, Resolved: true},
+		},
+		Commands:           []analysisrun.CommandResult{{Command: []string{"sh", "-c", "id"}}, {Command: []string{"node"}}},
+		SyscallCount:       1050,
+		StraceLogTruncated: true,
+		PrivilegedSyscalls: []analysisrun.SyscallResult{{Name: "setuid", Count: 3}},
+		Redactions:         []analysisrun.RedactionResult{{Source: "stdout", Type: "github_token", Count: 1}},
+		InstallScripts:     []analysisrun.InstallScriptResult{{Name: "postinstall", Script: "node x.js", Executed: true}},
+		Canaries: []analysisrun.CanaryResult{
+			{Path: "/root/.aws/credentials", Read: true, Exfiltrated: true},
+			{Path: "/root/.kube/config", Read: true},
+		},
+		EnvironmentChecks: []analysisrun.EnvironmentCheckResult{
+			{Category: "container", Source: "file", Indicator: "/.dockerenv"},
+			{Category: "hardware", Source: "file", Indicator: "/proc/cpuinfo"},
+		},
+		SandboxEvasion: true,
+		Sleeps: []analysisrun.SleepResult{
+			{Syscall: "epoll_wait", DurationMs: 5000, Count: 2},
+			{Syscall: "nanosleep", DurationMs: 600000, Count: 2},
+		},
+		DelayedPayload: true,
+		SelfModifications: []analysisrun.SelfModificationResult{
+			{Path: "/app/x.js", Command: []string{"node", "x.js"}, Write: true, Delete: true},
+		},
+		Processes: analysisrun.ProcessResult{Spawned: 603, Execs: 3, PeakCount: 300, PeakSpawnRate: 2},
+		ForkBomb:  true,
+		PlainHTTPConnections: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "5.6.7.8", Port: 80, Hostnames: []string{"a.example.com", "b.example.com"}},
+		},
+		LingeringProcesses: []analysisrun.LingeringProcessResult{
+			{Command: []string{"nc", "-l", "4444"}},
+			{Command: []string{"node", "miner.js"}, Daemonized: true},
+		},
+		LoadedModules: []analysisrun.LoadedModuleResult{
+			{Name: "debug", Path: "/app/node_modules/debug/index.js"},
+			{Name: "os", Builtin: true, FromPackage: true, DynamicOnly: true},
+			{Name: "../other/secret.js", Path: "/app/node_modules/other/secret.js", FromPackage: true, OutsidePackage: true},
+		},
+		Termination: analysisrun.TerminationResult{Reason: analysisrun.TerminationExit},
+		Resources: &analysisrun.ResourceUsageResult{
+			CPUTimeMs: 2000, PeakMemoryBytes: 1 << 20, BlockReadBytes: 11, BlockWriteBytes: 22,
+			NetReceivedBytes: 33, NetSentBytes: 44, PeakThreads: 20,
+		},
+		Links: []analysisrun.LinkResult{
+			{Path: "/app/etc", Target: "/etc", OutsidePackage: true},
+			{Path: "/app/passwd", Target: "/etc/passwd", Hard: true, OutsidePackage: true},
+		},
+		FileReads: analysisrun.FileReadResult{
+			Directories:    30,
+			SensitiveReads: []analysisrun.SensitiveReadResult{{Directory: "/etc", Files: 5}, {Directory: "/root/.ssh", Files: 3}},
+		},
+		BroadFileReads: true,
+		ModuleWrites: []analysisrun.ModuleWriteResult{
+			{Path: "/root/.npm/_cacache/index-v5/00/ab", Kind: analysisrun.ModuleWriteCache, Command: []string{"node", "index.js"}},
+			{Path: "/app/node_modules/debug/index.js", Kind: analysisrun.ModuleWriteNodeModules, Command: []string{"node", "index.js"}},
+		},
+		RegistryPublishes: []analysisrun.RegistryPublishResult{
+			{Registry: "registry.npmjs.org", Action: "npm publish", Command: []string{"npm", "publish"}, Connected: true},
+			{Registry: "upload.pypi.org", Action: "connection", Connected: true},
+		},
+		RegistryCredentialReads: []string{"/root/.npmrc", "/root/.pypirc"},
+		RegistryTakeover:        true,
+		CredentialStoreReads: []analysisrun.CredentialStoreReadResult{
+			{Store: "aws", Paths: []string{"/root/.aws/credentials"}},
+			{Store: "ssh", Paths: []string{"/root/.ssh/id_rsa", "/root/.ssh/id_ed25519"}},
+		},
+		ShellProfileWrites: []analysisrun.ShellProfileWriteResult{
+			{Path: "/root/.bashrc", Command: []string{"node", "install.js"}},
+			{Path: "/root/.profile"},
+		},
+		PathModifications: []analysisrun.PathModificationResult{
+			{Directory: "/tmp/bin", Command: []string{"node", "install.js"}, Written: []string{"/tmp/bin/git", "/tmp/bin/sudo"}},
+			{Directory: "/app/bin", Command: []string{"sh", "-c", "setup"}},
+		},
+		PathHijack: true,
+		ExecutableDrops: []analysisrun.ExecutableDropResult{
+			{Path: "/tmp/x", Format: analysisrun.ExecutableELF, MadeExecutable: true, Command: []string{"node", "install.js"}},
+			{Path: "/tmp/run.sh", Format: analysisrun.ExecutableScript, Interpreter: "/bin/sh", MadeExecutable: true, Command: []string{"sh", "-c", "setup"}},
+		},
+		NativeLibraryLoads: []analysisrun.NativeLibraryLoadResult{
+			{Path: "/app/node_modules/pkg/addon.node", Written: true, Command: []string{"node", "index.js"}},
+		},
+		ReconExfiltration: []analysisrun.ReconExfiltrationResult{
+			{Tool: "whoami", Command: []string{"whoami"}, Destinations: []analysisrun.SocketResult{
+				{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+				{Family: "AF_INET", Address: "9.9.9.9", Port: 443},
+			}},
+			{Tool: "hostname", Command: []string{"hostname"}, Destinations: []analysisrun.SocketResult{
+				{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+			}},
+		},
+		StagedExecutions: []analysisrun.StagedExecutionResult{
+			{Path: "/dev/shm/x.sh", Writer: []string{"node", "install.js"}, Command: []string{"sh", "/dev/shm/x.sh"}},
+			{Path: "/tmp/payload", Writer: []string{"curl", "-o", "/tmp/payload"}, Command: []string{"/tmp/payload"}},
+		},
+		NetworkDenied: true,
+		DeniedConnections: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "1.2.3.4", Port: 80},
+			{Family: "AF_INET", Address: "5.6.7.8", Port: 443, Hostnames: []string{"c2.example.com"}},
+		},
+		SourceExfiltration: []analysisrun.SourceExfiltrationResult{
+			{Kind: "git", Root: "/app/.git", Files: 12, Destinations: []analysisrun.SocketResult{
+				{Family: "AF_INET", Address: "1.2.3.4", Port: 80},
+				{Family: "AF_INET", Address: "5.6.7.8", Port: 443},
+			}},
+			{Kind: "source", Root: "/root/project", Files: 25, Destinations: []analysisrun.SocketResult{{Family: "AF_INET", Address: "9.9.9.9", Port: 443}}},
+		},
+		DirectIPConnections: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "5.6.7.8", Port: 4444, ServerNames: []string{"c2.example.com"}},
+			{Family: "AF_INET", Address: "9.9.9.9", Port: 443},
+		},
+		SecurityTampering: []analysisrun.SecurityTamperingResult{
+			{Rule: "shell_history", Command: []string{"sh", "-c", "unset HISTFILE"}},
+			{Rule: "log_tampering", Command: []string{"truncate", "-s", "0", "/var/log/auth.log"}, Path: "/var/log/auth.log"},
+		},
+		DNS: []analysisrun.DNSResult{
+			{Class: "IN", Queries: []analysisrun.DNSQueries{{Hostname: "a.example.com", Types: []string{"A", "AAAA"}}}},
+		},
+	}
+
+	if got := data.MergedStraceSummary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MergedStraceSummary() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestMergedStraceSummaryStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses map[analysisrun.DynamicPhase]analysis.Status
+		want     analysis.Status
+	}{
+		{
+			name:     "no phases",
+			statuses: map[analysisrun.DynamicPhase]analysis.Status{},
+			want:     "",
+		},
+		{
+			name: "all completed",
+			statuses: map[analysisrun.DynamicPhase]analysis.Status{
+				analysisrun.DynamicPhaseInstall: analysis.StatusCompleted,
+				analysisrun.DynamicPhaseImport:  analysis.StatusCompleted,
+			},
+			want: analysis.StatusCompleted,
+		},
+		{
+			name: "first failure wins",
+			statuses: map[analysisrun.DynamicPhase]analysis.Status{
+				analysisrun.DynamicPhaseInstall: analysis.StatusErrorTimeout,
+				analysisrun.DynamicPhaseImport:  analysis.StatusErrorAnalysis,
+			},
+			want: analysis.StatusErrorTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := analysisrun.DynamicAnalysisData{StraceSummary: analysisrun.DynamicAnalysisStraceSummary{}}
+			for phase, status := range tt.statuses {
+				data.StraceSummary[phase] = &analysisrun.StraceSummary{Status: status}
+			}
+			if got := data.MergedStraceSummary().Status; got != tt.want {
+				t.Errorf("MergedStraceSummary().Status = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package analysisrun
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+)
+
+// DynamicAnalysisMinimalSummary holds the MinimalSummary of each phase of dynamic
+// analysis run in minimal capture mode.
+type DynamicAnalysisMinimalSummary map[DynamicPhase]*MinimalSummary
+
+/*
+MinimalSummary is the compact result of a dynamic analysis phase run in minimal
+capture mode, which only records the cheapest signals that a package warrants
+full analysis. It is intended as a first pass over large numbers of packages:
+packages for which Tripped returns true can then be analysed in full.
+
+Connections lists the distinct addresses ("address:port") of the sockets other
+than Unix domain sockets that were connected or bound to, apart from those of
+package managers and those to package registries. Commands lists the distinct command lines that were run (without
+their environment), and ShellCommands counts those which were run by a shell (e.g.
+'sh -c SCRIPT'), as lifecycle scripts and child_process.exec are, other than the
+harness commands (see IsHarnessCommand). OutsideWrites lists the files outside the
+package directory that were opened for writing, other than the log files of the
+analysis commands and the caches of package managers (see
+StraceSummary.WroteOutsidePackage).
+*/
+type MinimalSummary struct {
+	Status             analysis.Status
+	Connections        []string
+	Commands           [][]string
+	ShellCommands      int
+	OutsideWrites      []string
+	StraceLogTruncated bool
+}
+
+// shells are the names of the shells recognised by MinimalSummary.ShellCommands.
+var shells = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true}
+
+// NewMinimalSummary returns the MinimalSummary of the (possibly partial) strace
+// summary s, which only needs the Status, Files, Sockets and Commands to be set.
+func NewMinimalSummary(s *StraceSummary) *MinimalSummary {
+	m := &MinimalSummary{
+		Status:             s.Status,
+		Connections:        []string{},
+		Commands:           [][]string{},
+		OutsideWrites:      []string{},
+		StraceLogTruncated: s.StraceLogTruncated,
+	}
+	for _, socket := range s.Sockets {
+		if socket.Family == FamilyUnix || socket.toRegistry() {
+			continue
+		}
+		if c := fmt.Sprintf("%s:%d", socket.Address, socket.Port); !slices.Contains(m.Connections, c) {
+			m.Connections = append(m.Connections, c)
+		}
+	}
+	for _, c := range s.Commands {
+		if len(c.Command) == 0 || slices.ContainsFunc(m.Commands, func(cmd []string) bool { return slices.Equal(cmd, c.Command) }) {
+			continue
+		}
+		m.Commands = append(m.Commands, c.Command)
+		if len(c.Command) > 2 && shells[c.Command[0]] && c.Command[1] == "-c" && !IsHarnessCommand(c.Command) {
+			m.ShellCommands++
+		}
+	}
+	for _, f := range s.Files {
+		if f.Write && !pseudoFiles[f.Path] && !IsPackageFile(f.Path) && !IsHarnessFile(f.Path) {
+			m.OutsideWrites = append(m.OutsideWrites, f.Path)
+		}
+	}
+	slices.Sort(m.Connections)
+	slices.Sort(m.OutsideWrites)
+	return m
+}
+
+// Tripped returns true if the phase connected to the network, ran a shell command
+// or wrote outside the package directory, or did not complete, so that the package
+// should be analysed in full. Commands which were not run by a shell are not counted,
+// since the analysis commands themselves run programs (e.g. the package manager), and
+// neither is the activity of the harness (see MinimalSummary).
+func (m *MinimalSummary) Tripped() bool {
+	if m == nil {
+		return false
+	}
+	return m.Status != analysis.StatusCompleted || len(m.Connections) > 0 || m.ShellCommands > 0 || len(m.OutsideWrites) > 0
+}
+
+// Tripped returns true if the MinimalSummary of any phase is tripped.
+// See MinimalSummary.Tripped.
+func (d DynamicAnalysisMinimalSummary) Tripped() bool {
+	for _, m := range d {
+		if m.Tripped() {
+			return true
+		}
+	}
+	return false
+}
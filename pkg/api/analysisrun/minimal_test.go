@@ -0,0 +1,114 @@
+package analysisrun_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/internal/analysis"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestNewMinimalSummary(t *testing.T) {
+	s := &analysisrun.StraceSummary{
+		Status: analysis.StatusCompleted,
+		Files: []analysisrun.FileResult{
+			{Path: "/tmp/x", Write: true},
+			{Path: "/app/node_modules/pkg/build.js", Write: true},
+			{Path: "/dev/null", Write: true},
+			{Path: "/etc/passwd", Read: true},
+		},
+		Sockets: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "1.2.3.4", Port: 443, Hostnames: []string{"example.com"}},
+			{Family: "AF_INET", Address: "1.2.3.4", Port: 443},
+			{Family: "AF_UNIX", Address: "/var/run/nscd/socket"},
+		},
+		Commands: []analysisrun.CommandResult{
+			{Command: []string{"npm", "install", "pkg"}, Environment: []string{"HOME=/root"}},
+			{Command: []string{"npm", "install", "pkg"}, Environment: []string{"HOME=/tmp"}},
+			{Command: []string{"sh", "-c", "node build.js"}},
+		},
+	}
+
+	want := &analysisrun.MinimalSummary{
+		Status:        analysis.StatusCompleted,
+		Connections:   []string{"1.2.3.4:443"},
+		Commands:      [][]string{{"npm", "install", "pkg"}, {"sh", "-c", "node build.js"}},
+		ShellCommands: 1,
+		OutsideWrites: []string{"/tmp/x"},
+	}
+	if got := analysisrun.NewMinimalSummary(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewMinimalSummary() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMinimalSummaryBenignInstall(t *testing.T) {
+	s := &analysisrun.StraceSummary{
+		Status: analysis.StatusCompleted,
+		Files: []analysisrun.FileResult{
+			{Path: "/app/composer.json", Write: true},
+			{Path: "/app/vendor/foo/bar/src/Bar.php", Write: true},
+			{Path: "/root/.cache/composer/files/foo/bar/1.0.zip", Write: true},
+			{Path: "/execution.log", Write: true},
+		},
+		Sockets: []analysisrun.SocketResult{
+			{Family: "AF_INET", Address: "54.36.53.46", Port: 443, Hostnames: []string{"repo.packagist.org"}},
+		},
+		Commands: []analysisrun.CommandResult{
+			{Command: []string{"php", "/usr/local/bin/analyze-php.php", "--package", "foo/bar"}},
+			{Command: []string{"sh", "-c", "composer.phar 'require' '--no-progress' 'foo/bar'"}},
+		},
+	}
+
+	got := analysisrun.NewMinimalSummary(s)
+	if got.Tripped() {
+		t.Errorf("NewMinimalSummary() = %+v, want not tripped", got)
+	}
+}
+
+func TestMinimalSummaryTripped(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary *analysisrun.MinimalSummary
+		want    bool
+	}{
+		{
+			name:    "nothing",
+			summary: &analysisrun.MinimalSummary{Status: analysis.StatusCompleted, Commands: [][]string{{"npm", "install", "pkg"}}},
+			want:    false,
+		},
+		{
+			name:    "connection",
+			summary: &analysisrun.MinimalSummary{Status: analysis.StatusCompleted, Connections: []string{"1.2.3.4:443"}},
+			want:    true,
+		},
+		{
+			name:    "shell command",
+			summary: &analysisrun.MinimalSummary{Status: analysis.StatusCompleted, ShellCommands: 1},
+			want:    true,
+		},
+		{
+			name:    "outside write",
+			summary: &analysisrun.MinimalSummary{Status: analysis.StatusCompleted, OutsideWrites: []string{"/tmp/x"}},
+			want:    true,
+		},
+		{
+			name:    "timeout",
+			summary: &analysisrun.MinimalSummary{Status: analysis.StatusErrorTimeout},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.summary.Tripped(); got != tt.want {
+				t.Errorf("Tripped() = %v, want %v", got, tt.want)
+			}
+			d := analysisrun.DynamicAnalysisMinimalSummary{
+				analysisrun.DynamicPhaseImport:  {Status: analysis.StatusCompleted},
+				analysisrun.DynamicPhaseInstall: tt.summary,
+			}
+			if got := d.Tripped(); got != tt.want {
+				t.Errorf("DynamicAnalysisMinimalSummary.Tripped() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
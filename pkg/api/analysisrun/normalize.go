@@ -0,0 +1,283 @@
+package analysisrun
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Placeholders that replace values which vary between runs of the same package.
+const (
+	PIDPlaceholder       = "<PID>"
+	TimestampPlaceholder = "<TIMESTAMP>"
+	RandomPlaceholder    = "<RANDOM>"
+)
+
+var (
+	// timestampPattern matches ISO 8601 / RFC 3339 style dates and times,
+	// e.g. 2024-01-02T03:04:05.678Z or 2024-01-02 03:04:05+01:00.
+	timestampPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+
+	// procPIDPattern matches process IDs in paths under /proc, e.g. /proc/1234/maps
+	// or /proc/1234/task/1235.
+	procPIDPattern = regexp.MustCompile(`(/proc/|/task/)\d+\b`)
+
+	// pidPattern matches process IDs in output, e.g. "pid 1234" or "PID=1234".
+	pidPattern = regexp.MustCompile(`(?i)(\bpid[ =:]\s*)\d+\b`)
+
+	// tempPathPattern matches paths inside temporary directories, in which packages
+	// and package managers create files with random names.
+	tempPathPattern = regexp.MustCompile(`(?:/tmp|/var/tmp|/dev/shm)(?:/[^\s'"/:;,]+)+`)
+
+	// tempNamePartPattern matches the parts of a file name in a temporary directory
+	// that may be random, e.g. "1234" and "8d9f2a" in "npm-1234-8d9f2a".
+	tempNamePartPattern = regexp.MustCompile(`[A-Za-z0-9_]+`)
+)
+
+// isRandomNamePart returns whether part of a file name looks randomly generated,
+// i.e. it is a long run of letters and digits, or a number.
+func isRandomNamePart(part string) bool {
+	hasDigit := strings.ContainsAny(part, "0123456789")
+	allDigits := strings.Trim(part, "0123456789") == ""
+	return (len(part) >= 6 && hasDigit) || (len(part) >= 3 && allDigits)
+}
+
+/*
+NormalizeValue replaces values in s which vary between runs of the same package
+with placeholders, so that the results of different runs can be compared:
+
+  - Timestamps are replaced with TimestampPlaceholder.
+  - Process IDs in paths under /proc, and after "pid" in output, are replaced
+    with PIDPlaceholder.
+  - Parts of file names in temporary directories (/tmp, /var/tmp and /dev/shm)
+    that look randomly generated are replaced with RandomPlaceholder.
+*/
+func NormalizeValue(s string) string {
+	s = timestampPattern.ReplaceAllLiteralString(s, TimestampPlaceholder)
+	s = procPIDPattern.ReplaceAllString(s, "${1}"+PIDPlaceholder)
+	s = pidPattern.ReplaceAllString(s, "${1}"+PIDPlaceholder)
+	return tempPathPattern.ReplaceAllStringFunc(s, func(path string) string {
+		return tempNamePartPattern.ReplaceAllStringFunc(path, func(part string) string {
+			if isRandomNamePart(part) {
+				return RandomPlaceholder
+			}
+			return part
+		})
+	})
+}
+
+func normalizeValues(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	normalized := make([]string, len(values))
+	for i, v := range values {
+		normalized[i] = NormalizeValue(v)
+	}
+	return normalized
+}
+
+/*
+Normalized returns a copy of the strace summary in which values that vary between
+runs of the same package (timestamps, process IDs and random temporary file names)
+are replaced with placeholders by NormalizeValue, and which is then canonicalized
+(see Canonicalize). The summary itself is not modified, so the raw values remain
+available.
+
+Values are normalized in Stdout and Stderr, the paths of Files, SelfModifications
+and Unix domain Sockets, and the arguments and environment of Commands (and the
+arguments of LingeringProcesses), and the paths of LoadedModules and Links. Files that
+have the same path after normalization are merged, as are identical Commands.
+*/
+func (s *StraceSummary) Normalized() StraceSummary {
+	n := *s
+	if s.Stdout != nil {
+		n.Stdout = []byte(NormalizeValue(string(s.Stdout)))
+	}
+	if s.Stderr != nil {
+		n.Stderr = []byte(NormalizeValue(string(s.Stderr)))
+	}
+
+	n.Files = nil
+	files := make(map[string]int)
+	for _, f := range s.Files {
+		f.Path = NormalizeValue(f.Path)
+		if i, ok := files[f.Path]; ok {
+			n.Files[i].Read = n.Files[i].Read || f.Read
+			n.Files[i].Write = n.Files[i].Write || f.Write
+			n.Files[i].Delete = n.Files[i].Delete || f.Delete
+			continue
+		}
+		files[f.Path] = len(n.Files)
+		n.Files = append(n.Files, f)
+	}
+
+	n.Sockets = normalizeSockets(s.Sockets)
+	n.PlainHTTPConnections = normalizeSockets(s.PlainHTTPConnections)
+	n.DeniedConnections = normalizeSockets(s.DeniedConnections)
+	n.DirectIPConnections = normalizeSockets(s.DirectIPConnections)
+
+	n.Commands = nil
+	commands := make(map[string]bool)
+	for _, c := range s.Commands {
+		c = CommandResult{Command: normalizeValues(c.Command), Environment: normalizeValues(c.Environment)}
+		key := strings.Join(c.Command, "\x00") + "\x01" + strings.Join(c.Environment, "\x00")
+		if !commands[key] {
+			commands[key] = true
+			n.Commands = append(n.Commands, c)
+		}
+	}
+
+	n.SelfModifications = nil
+	for _, m := range s.SelfModifications {
+		m.Path = NormalizeValue(m.Path)
+		m.Command = normalizeValues(m.Command)
+		n.SelfModifications = append(n.SelfModifications, m)
+	}
+
+	n.LingeringProcesses = nil
+	for _, p := range s.LingeringProcesses {
+		p.Command = normalizeValues(p.Command)
+		n.LingeringProcesses = append(n.LingeringProcesses, p)
+	}
+
+	n.LoadedModules = nil
+	for _, m := range s.LoadedModules {
+		m.Path = NormalizeValue(m.Path)
+		n.LoadedModules = append(n.LoadedModules, m)
+	}
+
+	n.Links = nil
+	for _, l := range s.Links {
+		l.Path = NormalizeValue(l.Path)
+		l.Target = NormalizeValue(l.Target)
+		n.Links = append(n.Links, l)
+	}
+
+	n.ModuleWrites = nil
+	for _, w := range s.ModuleWrites {
+		w.Path = NormalizeValue(w.Path)
+		w.Command = normalizeValues(w.Command)
+		n.ModuleWrites = append(n.ModuleWrites, w)
+	}
+
+	n.RegistryPublishes = nil
+	for _, r := range s.RegistryPublishes {
+		r.Action = NormalizeValue(r.Action)
+		r.Command = normalizeValues(r.Command)
+		n.RegistryPublishes = append(n.RegistryPublishes, r)
+	}
+
+	n.RegistryCredentialReads = normalizeValues(s.RegistryCredentialReads)
+
+	n.CredentialStoreReads = nil
+	for _, r := range s.CredentialStoreReads {
+		r.Paths = normalizeValues(r.Paths)
+		n.CredentialStoreReads = append(n.CredentialStoreReads, r)
+	}
+
+	n.ShellProfileWrites = nil
+	for _, w := range s.ShellProfileWrites {
+		w.Command = normalizeValues(w.Command)
+		n.ShellProfileWrites = append(n.ShellProfileWrites, w)
+	}
+
+	n.PathModifications = nil
+	for _, m := range s.PathModifications {
+		m.Command = normalizeValues(m.Command)
+		m.Written = normalizeValues(m.Written)
+		n.PathModifications = append(n.PathModifications, m)
+	}
+
+	n.ExecutableDrops = nil
+	for _, e := range s.ExecutableDrops {
+		e.Path = NormalizeValue(e.Path)
+		e.Command = normalizeValues(e.Command)
+		n.ExecutableDrops = append(n.ExecutableDrops, e)
+	}
+
+	n.NativeLibraryLoads = nil
+	for _, l := range s.NativeLibraryLoads {
+		l.Path = NormalizeValue(l.Path)
+		l.Command = normalizeValues(l.Command)
+		n.NativeLibraryLoads = append(n.NativeLibraryLoads, l)
+	}
+
+	n.ReconExfiltration = nil
+	for _, r := range s.ReconExfiltration {
+		r.Command = normalizeValues(r.Command)
+		r.Destinations = normalizeSockets(r.Destinations)
+		n.ReconExfiltration = append(n.ReconExfiltration, r)
+	}
+
+	n.StagedExecutions = nil
+	for _, e := range s.StagedExecutions {
+		e.Path = NormalizeValue(e.Path)
+		e.Writer = normalizeValues(e.Writer)
+		e.Command = normalizeValues(e.Command)
+		n.StagedExecutions = append(n.StagedExecutions, e)
+	}
+
+	n.SourceExfiltration = nil
+	for _, r := range s.SourceExfiltration {
+		r.Root = NormalizeValue(r.Root)
+		r.Destinations = normalizeSockets(r.Destinations)
+		n.SourceExfiltration = append(n.SourceExfiltration, r)
+	}
+
+	n.SecurityTampering = nil
+	for _, t := range s.SecurityTampering {
+		t.Path = NormalizeValue(t.Path)
+		t.Command = normalizeValues(t.Command)
+		n.SecurityTampering = append(n.SecurityTampering, t)
+	}
+
+	// Copy the remaining slices that Canonicalize sorts in place.
+	n.DNS = nil
+	for _, d := range s.DNS {
+		queries := make([]DNSQueries, 0, len(d.Queries))
+		for _, q := range d.Queries {
+			queries = append(queries, DNSQueries{Hostname: q.Hostname, Types: slices.Clone(q.Types)})
+		}
+		n.DNS = append(n.DNS, DNSResult{Class: d.Class, Queries: queries})
+	}
+	n.PrivilegedSyscalls = slices.Clone(s.PrivilegedSyscalls)
+	n.Redactions = slices.Clone(s.Redactions)
+	n.Canaries = slices.Clone(s.Canaries)
+	n.EnvironmentChecks = slices.Clone(s.EnvironmentChecks)
+	n.Sleeps = slices.Clone(s.Sleeps)
+	n.FileReads.SensitiveReads = slices.Clone(s.FileReads.SensitiveReads)
+
+	n.Canonicalize()
+	return n
+}
+
+// normalizeSockets returns a copy of sockets with the paths of Unix domain
+// sockets normalized.
+func normalizeSockets(sockets []SocketResult) []SocketResult {
+	var normalized []SocketResult
+	for _, sock := range sockets {
+		if sock.Family == FamilyUnix {
+			sock.Address = NormalizeValue(sock.Address)
+		}
+		sock.Hostnames = slices.Clone(sock.Hostnames)
+		sock.ServerNames = slices.Clone(sock.ServerNames)
+		normalized = append(normalized, sock)
+	}
+	return normalized
+}
+
+// Normalized returns a copy of the strace summaries of all phases, normalized
+// as described by StraceSummary.Normalized.
+func (d DynamicAnalysisData) Normalized() DynamicAnalysisStraceSummary {
+	normalized := make(DynamicAnalysisStraceSummary, len(d.StraceSummary))
+	for phase, summary := range d.StraceSummary {
+		if summary == nil {
+			normalized[phase] = nil
+			continue
+		}
+		n := summary.Normalized()
+		normalized[phase] = &n
+	}
+	return normalized
+}
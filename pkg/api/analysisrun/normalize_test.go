@@ -0,0 +1,101 @@
+package analysisrun
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"/proc/1234/maps", "/proc/<PID>/maps"},
+		{"/proc/1234/task/1240/status", "/proc/<PID>/task/<PID>/status"},
+		{"/proc/self/maps", "/proc/self/maps"},
+		{"started worker (pid 4321)", "started worker (pid <PID>)"},
+		{"PID=99 exited", "PID=<PID> exited"},
+		{"built at 2024-01-02T03:04:05.678Z", "built at <TIMESTAMP>"},
+		{"[2024-01-02 03:04:05+01:00] done", "[<TIMESTAMP>] done"},
+		{"/tmp/npm-1234-8d9f2a/package.json", "/tmp/npm-<RANDOM>-<RANDOM>/package.json"},
+		{"/tmp/tmpk3j_2x9q", "/tmp/<RANDOM>"},
+		{"cp /var/tmp/pip-install-a1b2c3d4/setup.py .", "cp /var/tmp/pip-install-<RANDOM>/setup.py ."},
+		{"/tmp/build/es5/index.js", "/tmp/build/es5/index.js"},
+		{"/app/lib/es2015/index.js", "/app/lib/es2015/index.js"},
+		{"/usr/lib/python3.11/os.py", "/usr/lib/python3.11/os.py"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := NormalizeValue(tt.value); got != tt.want {
+				t.Errorf("NormalizeValue(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStraceSummaryNormalized(t *testing.T) {
+	run := func(pid, tmp string) *StraceSummary {
+		return &StraceSummary{
+			Stdout: []byte("pid " + pid + "\n"),
+			Files: []FileResult{
+				{Path: "/tmp/" + tmp + "/a", Write: true},
+				{Path: "/proc/" + pid + "/status", Read: true},
+				{Path: "/proc/" + pid + "1/status", Read: true, Write: true},
+			},
+			Sockets: []SocketResult{
+				{Family: "AF_UNIX", Address: "/tmp/" + tmp + ".sock"},
+				{Family: "AF_INET", Address: "10.0.0.1", Port: 443, Hostnames: []string{"example.com"}},
+			},
+			Commands: []CommandResult{
+				{Command: []string{"rm", "-rf", "/tmp/" + tmp}},
+			},
+			SyscallCount: 100,
+			LingeringProcesses: []LingeringProcessResult{
+				{Command: []string{"node", "/tmp/" + tmp + "/miner.js"}, Daemonized: true},
+			},
+			LoadedModules: []LoadedModuleResult{
+				{Name: "./payload", Path: "/tmp/" + tmp + "/payload.js", FromPackage: true},
+			},
+			Links: []LinkResult{
+				{Path: "/app/cache", Target: "/tmp/" + tmp, OutsidePackage: true},
+			},
+		}
+	}
+
+	first, second := run("1234", "tmp8d9f2a"), run("5678", "tmpq1w2e3")
+	want := StraceSummary{
+		Stdout: []byte("pid <PID>\n"),
+		Files: []FileResult{
+			{Path: "/proc/<PID>/status", Read: true, Write: true},
+			{Path: "/tmp/<RANDOM>/a", Write: true},
+		},
+		Sockets: []SocketResult{
+			{Family: "AF_INET", Address: "10.0.0.1", Port: 443, Hostnames: []string{"example.com"}},
+			{Family: "AF_UNIX", Address: "/tmp/<RANDOM>.sock"},
+		},
+		Commands: []CommandResult{
+			{Command: []string{"rm", "-rf", "/tmp/<RANDOM>"}},
+		},
+		SyscallCount: 100,
+		LingeringProcesses: []LingeringProcessResult{
+			{Command: []string{"node", "/tmp/<RANDOM>/miner.js"}, Daemonized: true},
+		},
+		LoadedModules: []LoadedModuleResult{
+			{Name: "./payload", Path: "/tmp/<RANDOM>/payload.js", FromPackage: true},
+		},
+		Links: []LinkResult{
+			{Path: "/app/cache", Target: "/tmp/<RANDOM>", OutsidePackage: true},
+		},
+	}
+
+	for _, s := range []*StraceSummary{first, second} {
+		if got := s.Normalized(); !reflect.DeepEqual(got, want) {
+			t.Errorf("Normalized() = %+v\nwant %+v", got, want)
+		}
+	}
+
+	// the raw values are not modified
+	if want := run("1234", "tmp8d9f2a"); !reflect.DeepEqual(first, want) {
+		t.Errorf("Normalized() modified the summary: %+v\nwant %+v", first, want)
+	}
+}
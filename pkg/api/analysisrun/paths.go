@@ -0,0 +1,84 @@
+package analysisrun
+
+import (
+	"path"
+	"strings"
+)
+
+// PackageDir is the working directory of the dynamic analysis sandbox, into
+// which packages are installed (e.g. node_modules, vendor and cargo projects).
+const PackageDir = "/app"
+
+// HomeDir is the home directory of the user that runs the analysis command in
+// the dynamic analysis sandbox.
+const HomeDir = "/root"
+
+// packageInstallDirs are the directories outside PackageDir that package managers
+// install packages into in the dynamic analysis sandbox (e.g. Python site-packages),
+// as path.Match patterns so that versioned directories are matched.
+var packageInstallDirs = []string{
+	"/usr/lib/python*/site-packages",
+	"/usr/lib/python*/dist-packages",
+	"/usr/local/lib/python*/site-packages",
+	"/usr/local/lib/python*/dist-packages",
+	HomeDir + "/.local/lib/python*/site-packages",
+	"/usr/lib/ruby/gems/*/gems",
+	"/usr/local/lib/ruby/gems/*/gems",
+	"/usr/local/bundle/gems",
+	"/var/lib/gems/*/gems",
+	HomeDir + "/.gem/ruby/*/gems",
+	"/usr/local/cargo/registry",
+	HomeDir + "/.cargo/registry",
+}
+
+// pseudoFiles are device files which are commonly written to, but writing to
+// them does not change the filesystem.
+var pseudoFiles = map[string]bool{
+	"/dev/null":   true,
+	"/dev/tty":    true,
+	"/dev/stdout": true,
+	"/dev/stderr": true,
+}
+
+// inDir returns whether the clean absolute path p is dir, or inside it. dir may be
+// a path.Match pattern, whose wildcards match a single path component.
+func inDir(p, dir string) bool {
+	n := strings.Count(dir, "/")
+	// the first n components of p, which are compared with those of dir
+	if parts := strings.SplitN(p, "/", n+2); len(parts) > n+1 {
+		p = strings.Join(parts[:n+1], "/")
+	}
+	matched, _ := path.Match(dir, p)
+	return matched
+}
+
+// IsPackageFile returns whether path is inside PackageDir or a directory that
+// packages are installed into.
+func IsPackageFile(p string) bool {
+	p = path.Clean(p)
+	if inDir(p, PackageDir) {
+		return true
+	}
+	for _, dir := range packageInstallDirs {
+		if inDir(p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkPointsOutsidePackage returns whether a link created at linkPath to target
+// refers to a file outside PackageDir, the directories that packages are
+// installed into and HomeDir. A relative target of a symbolic link is resolved
+// against the directory containing the link. Such links can be used to make a
+// later write to a path inside the package affect a file elsewhere (e.g. /etc).
+func LinkPointsOutsidePackage(linkPath, target string) bool {
+	if !path.IsAbs(target) {
+		target = path.Join(path.Dir(linkPath), target)
+	}
+	target = path.Clean(target)
+	if inDir(target, HomeDir) {
+		return false
+	}
+	return !IsPackageFile(target)
+}
@@ -0,0 +1,55 @@
+package analysisrun_test
+
+import (
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+)
+
+func TestIsPackageFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/app", want: true},
+		{path: "/app/node_modules/foo/index.js", want: true},
+		{path: "/application/x", want: false},
+		{path: "/app/../etc/passwd", want: false},
+		{path: "/usr/local/lib/python3.11/site-packages/foo/__init__.py", want: true},
+		{path: "/usr/lib/python3/dist-packages/foo.py", want: true},
+		{path: "/root/.local/lib/python3.11/site-packages/foo/__init__.py", want: true},
+		{path: "/var/lib/gems/3.1.0/gems/foo-1.0/lib/foo.rb", want: true},
+		{path: "/usr/local/bundle/gems/foo-1.0/lib/foo.rb", want: true},
+		{path: "/usr/local/cargo/registry/src/foo-1.0/src/lib.rs", want: true},
+		// install directory names elsewhere in the path
+		{path: "/etc/gems/x", want: false},
+		{path: "/tmp/site-packages/evil.py", want: false},
+		{path: "/root/.ssh/cargo/registry/key", want: false},
+		{path: "/usr/local/lib/python3.11/site-packages-backup/x", want: false},
+	}
+	for _, tt := range tests {
+		if got := analysisrun.IsPackageFile(tt.path); got != tt.want {
+			t.Errorf("IsPackageFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLinkPointsOutsidePackage(t *testing.T) {
+	tests := []struct {
+		path, target string
+		want         bool
+	}{
+		{path: "/app/node_modules/evil/etc", target: "/etc", want: true},
+		{path: "/app/node_modules/evil/passwd", target: "../../../etc/passwd", want: true},
+		{path: "/app/node_modules/.bin/evil", target: "../evil/bin/cli.js", want: false},
+		{path: "/app/node_modules/evil/x", target: "/app/node_modules/other", want: false},
+		{path: "/tmp/x", target: "/root/.npmrc", want: false},
+		{path: "/tmp/x", target: "/usr/local/lib/python3.11/site-packages/foo", want: false},
+		{path: "/app/x", target: "/application", want: true},
+	}
+	for _, tt := range tests {
+		if got := analysisrun.LinkPointsOutsidePackage(tt.path, tt.target); got != tt.want {
+			t.Errorf("LinkPointsOutsidePackage(%q, %q) = %v, want %v", tt.path, tt.target, got, tt.want)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package analysisrun
 
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
 // DynamicPhase represents a way to 'run' a package during its usage lifecycle.
 // This is relevant to dynamic analysis.
 type DynamicPhase string
@@ -21,3 +27,42 @@ func DefaultDynamicPhases() []DynamicPhase {
 func AllDynamicPhases() []DynamicPhase {
 	return []DynamicPhase{DynamicPhaseInstall, DynamicPhaseImport, DynamicPhaseExecute}
 }
+
+// String returns the name of the phase, as used in serialized results (e.g. "install").
+func (p DynamicPhase) String() string {
+	return string(p)
+}
+
+// DisplayName returns a human-readable name for the phase (e.g. "Install"), for
+// presenting results.
+func (p DynamicPhase) DisplayName() string {
+	if p == "" {
+		return ""
+	}
+	return strings.ToUpper(string(p[:1])) + string(p[1:])
+}
+
+// Order returns the position of the phase in AllDynamicPhases, i.e. the order in
+// which it is run. Unknown phases are ordered after all known phases.
+func (p DynamicPhase) Order() int {
+	all := AllDynamicPhases()
+	if i := slices.Index(all, p); i >= 0 {
+		return i
+	}
+	return len(all)
+}
+
+// CompareDynamicPhases orders phases in the order that they are run (see Order),
+// and unknown phases by name.
+func CompareDynamicPhases(a, b DynamicPhase) int {
+	if n := cmp.Compare(a.Order(), b.Order()); n != 0 {
+		return n
+	}
+	return cmp.Compare(a, b)
+}
+
+// SortDynamicPhases sorts phases in place into the order given by CompareDynamicPhases,
+// so that results for each phase are consistently presented in the order they were run.
+func SortDynamicPhases(phases []DynamicPhase) {
+	slices.SortFunc(phases, CompareDynamicPhases)
+}
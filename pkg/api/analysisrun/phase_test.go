@@ -0,0 +1,38 @@
+package analysisrun
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDynamicPhaseDisplayName(t *testing.T) {
+	tests := []struct {
+		phase DynamicPhase
+		want  string
+	}{
+		{DynamicPhaseInstall, "Install"},
+		{DynamicPhaseImport, "Import"},
+		{DynamicPhaseExecute, "Execute"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.phase.DisplayName(); got != tt.want {
+			t.Errorf("%q.DisplayName() = %q, want %q", tt.phase, got, tt.want)
+		}
+	}
+}
+
+func TestSortDynamicPhases(t *testing.T) {
+	phases := []DynamicPhase{"zzz", DynamicPhaseExecute, "aaa", DynamicPhaseImport, DynamicPhaseInstall}
+	SortDynamicPhases(phases)
+
+	want := append(AllDynamicPhases(), "aaa", "zzz")
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("SortDynamicPhases() = %v, want %v", phases, want)
+	}
+	for i, phase := range AllDynamicPhases() {
+		if got := phase.Order(); got != i {
+			t.Errorf("%q.Order() = %d, want %d", phase, got, i)
+		}
+	}
+}
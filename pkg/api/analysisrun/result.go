@@ -31,7 +31,24 @@ type (
 type DynamicAnalysisRecord struct {
 	Package          Key   `json:"Package"`
 	CreatedTimestamp int64 `json:"CreatedTimestamp"`
-	Analysis         any   `json:"Analysis"`
+	// ArtifactSHA256 is the hex-encoded SHA256 hash of the analyzed package
+	// artifact, if it was computed.
+	ArtifactSHA256 string `json:"ArtifactSHA256,omitempty"`
+	// GitSource is the git repository the package was fetched from, if it was
+	// analyzed from git rather than from the registry.
+	GitSource *GitSource `json:"GitSource,omitempty"`
+	Analysis  any        `json:"Analysis"`
+}
+
+// GitSource records the git repository, and the ref (a branch, tag or commit)
+// within it, that a package was fetched from. Commit is the full hash of the
+// commit that the ref resolved to when the package was fetched, and is the
+// version of the package in the results, so that the analysis can be reproduced
+// even if the ref later moves.
+type GitSource struct {
+	Repository string `json:"Repository"`
+	Ref        string `json:"Ref,omitempty"`
+	Commit     string `json:"Commit"`
 }
 
 // DynamicAnalysisStraceRecord is a specialisation of DynamicAnalysisRecord that can be used for
@@ -39,6 +56,8 @@ type DynamicAnalysisRecord struct {
 type DynamicAnalysisStraceRecord struct {
 	Package          Key                          `json:"Package"`
 	CreatedTimestamp int64                        `json:"CreatedTimestamp"`
+	ArtifactSHA256   string                       `json:"ArtifactSHA256,omitempty"`
+	GitSource        *GitSource                   `json:"GitSource,omitempty"`
 	Analysis         DynamicAnalysisStraceSummary `json:"Analysis"`
 }
 
@@ -51,13 +70,145 @@ type DynamicAnalysisData struct {
 }
 
 type StraceSummary struct {
-	Status   analysis.Status
-	Stdout   []byte
-	Stderr   []byte
-	Files    []FileResult
-	Sockets  []SocketResult
-	Commands []CommandResult
-	DNS      []DNSResult
+	Status       analysis.Status
+	Stdout       []byte
+	Stderr       []byte
+	Files        []FileResult
+	Sockets      []SocketResult
+	Commands     []CommandResult
+	DNS          []DNSResult
+	SyscallCount int
+	// StraceLogTruncated is true if the strace log was too large to be retained in
+	// full. The summary still reflects the whole log, since it is parsed as it is
+	// produced, but the retained copy of the log is missing data from the middle.
+	StraceLogTruncated bool
+	// PrivilegedSyscalls lists privileged syscalls that were made (e.g. setuid,
+	// ptrace, init_module, mount), which may indicate privilege escalation or
+	// sandbox escape attempts.
+	PrivilegedSyscalls []SyscallResult
+	// Redactions records secret values that were masked in the captured output.
+	Redactions []RedactionResult
+	// InstallScripts lists the lifecycle scripts declared by the package
+	// manifest, and whether each was observed to run. It is only populated
+	// for the install phase, and only for ecosystems that support lifecycle scripts.
+	InstallScripts []InstallScriptResult
+	// Canaries lists the decoy credential files seeded into the sandbox, and
+	// whether each was read or had its contents sent over the network.
+	Canaries []CanaryResult
+	// EnvironmentChecks lists observed attempts to fingerprint the analysis
+	// environment (e.g. checking for virtual hardware or container files).
+	EnvironmentChecks []EnvironmentCheckResult
+	// SandboxEvasion is true if EnvironmentChecks indicate a likely attempt
+	// to detect the analysis sandbox and evade analysis.
+	SandboxEvasion bool
+	// Sleeps lists the long sleeps (and waits with a timeout) that were made.
+	Sleeps []SleepResult
+	// DelayedPayload is true if Sleeps include a sleep long enough to suggest
+	// an attempt to delay a payload until after analysis has finished.
+	DelayedPayload bool
+	// SelfModifications lists files that were run (e.g. as an install script)
+	// and then rewritten or deleted, which may be an attempt to cover tracks.
+	SelfModifications []SelfModificationResult
+	// Processes summarises the processes created, which can be used to tell a
+	// package that legitimately runs many commands from one that abuses resources.
+	Processes ProcessResult
+	// ForkBomb is true if Processes show processes being created so quickly,
+	// or in such numbers, that the package is likely to be a fork bomb.
+	ForkBomb bool
+	// PlainHTTPConnections lists the Sockets connected to a remote host on a port
+	// used for plain HTTP (e.g. 80), i.e. likely requests not protected by TLS.
+	PlainHTTPConnections []SocketResult
+	// LingeringProcesses lists processes that were still running when the phase
+	// ended, which distinguishes a package that leaves a resident process (e.g. a
+	// daemon) from one that only runs commands that exit.
+	LingeringProcesses []LingeringProcessResult
+	// LoadedModules lists the modules loaded at runtime (e.g. by require in
+	// Node.js), including modules loaded by dependencies and modules with names
+	// computed at runtime. It is only populated by analysis commands that
+	// record module loads, which currently only the NPM command does.
+	LoadedModules []LoadedModuleResult
+	// Termination records how the analysis command of the phase ended, which can
+	// explain behaviour that was cut short, e.g. by the package calling
+	// process.exit(). Its Reason is empty if this is not known.
+	Termination TerminationResult
+	// Resources records the resources used by the sandbox during the phase, as
+	// accounted by its cgroup, which is more reliable than counting syscalls for
+	// finding e.g. cryptominers or heavy downloads. It is nil if the sandbox
+	// could not measure its resource usage.
+	Resources *ResourceUsageResult
+	// Links lists the symbolic and hard links created, which can redirect later
+	// writes to a path inside the package to a file elsewhere (e.g. in /etc).
+	Links []LinkResult
+	// FileReads summarises how widely files were read across the filesystem, which
+	// distinguishes a package that walks directories (e.g. $HOME or /etc) to
+	// harvest data from one that only reads its own files.
+	FileReads FileReadResult
+	// BroadFileReads is true if FileReads show files read across so many
+	// directories, or in so many sensitive ones, that the package is likely to
+	// be snooping through the filesystem.
+	BroadFileReads bool
+	// ModuleWrites lists files written by the package in the global caches of
+	// package managers or the node_modules directories of other packages, which
+	// may be an attempt to tamper with other packages and spread to projects
+	// that use them.
+	ModuleWrites []ModuleWriteResult
+	// RegistryPublishes lists attempts to publish packages to a package registry,
+	// or to change the owners, access or tags of packages on it, e.g. by running
+	// npm publish or sending a PUT request to the registry API.
+	RegistryPublishes []RegistryPublishResult
+	// RegistryCredentialReads lists the registry credential files (e.g. ~/.npmrc)
+	// that were read by a process other than the package manager installing the
+	// package, or by a process publishing to a registry.
+	RegistryCredentialReads []string
+	// RegistryTakeover is true if there were both RegistryPublishes and
+	// RegistryCredentialReads, i.e. the package likely tried to use the credentials
+	// of the machine it was installed on to publish packages or seize them.
+	RegistryTakeover bool
+	// CredentialStoreReads lists the credential stores targeted by infostealers
+	// (e.g. browser login databases, ~/.ssh or ~/.aws) whose files were read by a
+	// process other than the tools that own them.
+	CredentialStoreReads []CredentialStoreReadResult
+	// ShellProfileWrites lists the writes to shell profile files (e.g. ~/.bashrc
+	// or /etc/profile.d/*), whose commands are run by every later shell.
+	ShellProfileWrites []ShellProfileWriteResult
+	// PathModifications lists the directories added to the PATH of the commands
+	// that were run, other than those in the PATH of the sandbox and those that
+	// package managers routinely add (e.g. node_modules/.bin).
+	PathModifications []PathModificationResult
+	// PathHijack is true if files were written in a directory that was added to
+	// PATH, i.e. the package likely planted a command to be run instead of the
+	// system command of the same name.
+	PathHijack bool
+	// ExecutableDrops lists the executables (binaries, and scripts that were made
+	// executable) written by commands other than package managers and compilers.
+	ExecutableDrops []ExecutableDropResult
+	// NativeLibraryLoads lists the native addons and shared libraries of the
+	// package, or written during the analysis, that were opened to be loaded.
+	NativeLibraryLoads []NativeLibraryLoadResult
+	// ReconExfiltration lists the system reconnaissance commands (e.g. whoami
+	// or hostname) that were followed by connections to remote hosts.
+	ReconExfiltration []ReconExfiltrationResult
+	// StagedExecutions lists the files written to a temporary directory that
+	// were then run, other than those written by package managers and compilers.
+	StagedExecutions []StagedExecutionResult
+	// NetworkDenied is true if the network of the sandbox was disabled for the
+	// phase, in which case every socket is a connection attempt that was denied.
+	NetworkDenied bool
+	// DeniedConnections lists the sockets of remote hosts that the package
+	// tried to connect to, if NetworkDenied is true.
+	DeniedConnections []SocketResult
+	// SourceExfiltration lists the git repositories and project source trees
+	// whose files were read broadly, followed by connections to remote hosts.
+	SourceExfiltration []SourceExfiltrationResult
+	// DirectIPConnections lists the Sockets connected to a remote host whose
+	// address was not resolved by a DNS lookup first (see SocketResult.Resolved),
+	// e.g. because it is hardcoded in the package, which is typical of command and
+	// control servers that avoid DNS logging.
+	DirectIPConnections []SocketResult
+	// SecurityTampering lists the actions that disable or evade security tooling,
+	// such as clearing the shell history, truncating logs or killing monitoring
+	// agents.
+	SecurityTampering []SecurityTamperingResult
 }
 
 type FileWritesSummary []FileWriteResult
@@ -79,10 +230,27 @@ type FileResult struct {
 	Delete bool
 }
 
+// Socket address families of SocketResult, which are also used for the sockets
+// recorded from strace logs.
+const (
+	FamilyInet  = "AF_INET"
+	FamilyInet6 = "AF_INET6"
+	FamilyUnix  = "AF_UNIX"
+)
+
 type SocketResult struct {
+	// Family is the address family of the socket, one of FamilyInet,
+	// FamilyInet6 or FamilyUnix.
+	Family    string
 	Address   string
 	Port      int
 	Hostnames []string
+	// ServerNames are the hostnames sent as the server name (SNI) in TLS
+	// connections to this address and port.
+	ServerNames []string
+	// Resolved is true if the address was returned by a DNS lookup made before
+	// the connection. It is false if network traffic was not captured.
+	Resolved bool
 }
 
 type CommandResult struct {
@@ -90,6 +258,302 @@ type CommandResult struct {
 	Environment []string
 }
 
+type SyscallResult struct {
+	Name  string
+	Count int
+}
+
+// RedactionResult records that Count values of the given Type were redacted
+// from Source, which is either "stdout", "stderr", "environment" (of the commands
+// run), "strace_log", or the path of a written file.
+type RedactionResult struct {
+	Source string
+	Type   string
+	Count  int
+}
+
+// InstallScriptResult records a lifecycle script (e.g. "postinstall") declared
+// in the package manifest, and whether it was executed during the install phase.
+type InstallScriptResult struct {
+	Name     string
+	Script   string
+	Executed bool
+}
+
+// CanaryResult records whether a decoy file seeded into the sandbox was read,
+// and whether its contents were detected in outbound network traffic.
+type CanaryResult struct {
+	Path        string
+	Read        bool
+	Exfiltrated bool
+}
+
+// EnvironmentCheckResult records an attempt to fingerprint the analysis
+// environment. Category is the kind of check (e.g. "virtualization" or "container")
+// and Source is how it was made: either "file", in which case Indicator is the path
+// of the file read, or "command", in which case Indicator is the command line run.
+type EnvironmentCheckResult struct {
+	Category  string
+	Source    string
+	Indicator string
+}
+
+// SleepResult records that Syscall (e.g. "nanosleep" or "epoll_wait") was called
+// Count times to sleep or wait for DurationMs milliseconds.
+type SleepResult struct {
+	Syscall    string
+	DurationMs int64
+	Count      int
+}
+
+// SelfModificationResult records that the file at Path was run by Command, and
+// was then written to (if Write is true) or deleted (if Delete is true).
+type SelfModificationResult struct {
+	Path    string
+	Command []string
+	Write   bool
+	Delete  bool
+}
+
+// ProcessResult records that Spawned processes were created, and Execs programs
+// were run (by execve). PeakCount is the largest number of processes alive at the
+// same time, and PeakSpawnRate is the largest number created within one second.
+type ProcessResult struct {
+	Spawned       int
+	Execs         int
+	PeakCount     int
+	PeakSpawnRate int
+}
+
+// LingeringProcessResult records that a process running Command was still alive
+// at the end of a phase. Daemonized is true if it (or the process that created
+// it) called setsid to detach from the session of the command being analyzed.
+type LingeringProcessResult struct {
+	Command    []string
+	Daemonized bool
+}
+
+// LoadedModuleResult records that the module Name (as given to require) was loaded
+// from Path, which is empty for built-in modules (when Builtin is true) and modules
+// that could not be found. FromPackage is true if the module was loaded by a file of
+// the package under analysis, rather than by one of its dependencies. DynamicOnly is
+// true if it was loaded by the package, but is not imported by name in any file found
+// by static analysis, e.g. because the name is computed at runtime. It is only set if
+// static analysis was run. OutsidePackage is true if the package loaded Name as a
+// path (e.g. "../other/index.js" or "/usr/lib/node_modules/npm/lib/npm.js") which
+// resolved to a file outside the package, such as a file of another package or of
+// the system.
+type LoadedModuleResult struct {
+	Name           string
+	Path           string
+	Builtin        bool
+	FromPackage    bool
+	DynamicOnly    bool
+	OutsidePackage bool
+}
+
+// Reasons for the termination of the analysis command of a phase.
+const (
+	// TerminationCompleted means that the command ran to completion, possibly
+	// exiting with a non-zero exit code.
+	TerminationCompleted = "completed"
+	// TerminationExit means that code of the package explicitly ended the process,
+	// e.g. by calling process.exit().
+	TerminationExit = "exit"
+	// TerminationSignal means that the process was ended by a signal (other than
+	// at the timeout of the phase).
+	TerminationSignal = "signal"
+	// TerminationTimeout means that the process was killed at the timeout of the phase.
+	TerminationTimeout = "timeout"
+)
+
+// TerminationResult records how the analysis command of a phase ended. Reason is
+// one of the Termination* constants. ExitCode is the exit code of the process, if
+// it exited, and Signal is the name of the signal that ended it (e.g. "SIGTERM"),
+// if the Reason is TerminationSignal and the signal is known. Only commands that
+// record their termination (currently only the NPM command) give a Reason other
+// than TerminationTimeout.
+type TerminationResult struct {
+	Reason   string
+	ExitCode int
+	Signal   string
+}
+
+// ResourceUsageResult records the resources used by the sandbox during a phase.
+// CPUTimeMs is the total CPU time used, and the Bytes fields are the total bytes
+// read and written by block I/O and received and sent over the network.
+// PeakMemoryBytes and PeakThreads are the most memory, and the most threads, in
+// use at once, which are sampled about once per second.
+type ResourceUsageResult struct {
+	CPUTimeMs        int64
+	PeakMemoryBytes  int64
+	BlockReadBytes   int64
+	BlockWriteBytes  int64
+	NetReceivedBytes int64
+	NetSentBytes     int64
+	PeakThreads      int
+}
+
+// LinkResult records that a link was created at Path. For a symbolic link,
+// Target is the target as given (relative to the directory of the link if it is
+// not absolute), and for a hard link (when Hard is true) it is the path of the
+// file linked to. OutsidePackage is true if the target is outside the package
+// directory and the home directory (see LinkPointsOutsidePackage).
+type LinkResult struct {
+	Path           string
+	Target         string
+	Hard           bool
+	OutsidePackage bool
+}
+
+// Kinds of ModuleWriteResult.
+const (
+	// ModuleWriteCache is a write to the global cache of a package manager
+	// (e.g. ~/.npm/_cacache), from which later installs are served.
+	ModuleWriteCache = "cache"
+	// ModuleWriteNodeModules is a write to the global node_modules directory,
+	// or to the directory of another package in a node_modules directory.
+	ModuleWriteNodeModules = "node_modules"
+)
+
+// ModuleWriteResult records that the file Path, of the given Kind (ModuleWriteCache
+// or ModuleWriteNodeModules), was written by a process running Command.
+type ModuleWriteResult struct {
+	Path    string
+	Kind    string
+	Command []string
+}
+
+// RegistryPublishResult records an attempt to publish or modify packages on the
+// registry with host Registry (e.g. "registry.npmjs.org"). Action describes the
+// attempt, as the tool and subcommand run (e.g. "npm publish"), the HTTP method
+// and URL of a request (e.g. "PUT https://registry.npmjs.org/lodash"), or
+// "connection" for a connection to a host only used for publishing. Command is the
+// command line run, and is empty for connections. Connected is true if a connection
+// to the registry was observed.
+type RegistryPublishResult struct {
+	Registry  string
+	Action    string
+	Command   []string
+	Connected bool
+}
+
+// CredentialStoreReadResult records that the files Paths of the credential store
+// Store (e.g. "chrome" or "ssh") were read.
+type CredentialStoreReadResult struct {
+	Store string
+	Paths []string
+}
+
+// ShellProfileWriteResult records that the shell profile file Path was written by
+// Command. Command is empty if the writer is not known.
+type ShellProfileWriteResult struct {
+	Path    string
+	Command []string
+}
+
+// PathModificationResult records that Directory was added to the PATH that
+// Command was run with. Written lists the files written in Directory.
+type PathModificationResult struct {
+	Directory string
+	Command   []string
+	Written   []string
+}
+
+// Formats of ExecutableDropResult.
+const (
+	ExecutableELF    = "elf"
+	ExecutableMachO  = "mach-o"
+	ExecutablePE     = "pe"
+	ExecutableScript = "script"
+)
+
+// ExecutableDropResult records that the executable Path, of the given Format (one
+// of the Executable* constants, or empty if its contents were not recorded), was
+// written by Command. Interpreter is the interpreter named by the shebang line of
+// a script. MadeExecutable is true if its mode was changed to make it executable.
+type ExecutableDropResult struct {
+	Path           string
+	Format         string
+	Interpreter    string
+	MadeExecutable bool
+	Command        []string
+}
+
+// NativeLibraryLoadResult records that the native addon or shared library Path
+// was opened by Command, as it is when loaded by dlopen. Written is true if the
+// library was written during the analysis (e.g. built or downloaded), rather than
+// shipped in the package. Command is empty if the reader is not known.
+type NativeLibraryLoadResult struct {
+	Path    string
+	Written bool
+	Command []string
+}
+
+// ReconExfiltrationResult records that Command ran the system reconnaissance tool
+// Tool (e.g. whoami, hostname or ifconfig), and that connections were then made to
+// the remote hosts Destinations, to which its output may have been sent.
+type ReconExfiltrationResult struct {
+	Tool         string
+	Command      []string
+	Destinations []SocketResult
+}
+
+// StagedExecutionResult records that the file Path, in a temporary directory (e.g.
+// /tmp), was written by Writer and then run by Command, either as its executable
+// or as the script passed to an interpreter.
+type StagedExecutionResult struct {
+	Path    string
+	Writer  []string
+	Command []string
+}
+
+// Kinds of SourceExfiltrationResult.
+const (
+	// SourceExfiltrationGit means that files in the .git directory of a repository were read.
+	SourceExfiltrationGit = "git"
+	// SourceExfiltrationSource means that the source files of a project were read.
+	SourceExfiltrationSource = "source"
+)
+
+// SourceExfiltrationResult records that Files files of a git repository (with Kind
+// SourceExfiltrationGit, and Root its .git directory) or of the source tree of a
+// project (with Kind SourceExfiltrationSource, and Root the top directory of the
+// project) were read, and that connections were then made to the remote hosts
+// Destinations, to which the code may have been sent.
+type SourceExfiltrationResult struct {
+	Kind         string
+	Root         string
+	Files        int
+	Destinations []SocketResult
+}
+
+// SecurityTamperingResult records that Command performed an action of the kind
+// Rule (e.g. "shell_history" or "security_processes") which disables or evades
+// security tooling: either it was run, or, if Path is set, it wrote or deleted the
+// file Path. Command is empty if the writer of Path is not known.
+type SecurityTamperingResult struct {
+	Rule    string
+	Command []string
+	Path    string
+}
+
+// FileReadResult records that files were read in Directories distinct directories,
+// not counting the package directory and the system, runtime and cache directories
+// that are read routinely. SensitiveReads lists the number of files read in each
+// directory that holds credentials or system configuration (e.g. /etc or ~/.ssh).
+type FileReadResult struct {
+	Directories    int
+	SensitiveReads []SensitiveReadResult
+}
+
+// SensitiveReadResult records that Files files were read in the sensitive
+// directory Directory, or its subdirectories.
+type SensitiveReadResult struct {
+	Directory string
+	Files     int
+}
+
 type DNSQueries struct {
 	Hostname string
 	Types    []string
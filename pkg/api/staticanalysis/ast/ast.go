@@ -0,0 +1,114 @@
+/*
+Package ast defines the schema of the syntax trees output by the parser when
+ParserConfig.FullAST is set, for consumers whose analyses need the structure of
+the code (e.g. the parent of a node, or the scope in which a name is declared),
+which is lost in the flat lists of tokens of the token package.
+
+The trees are pruned versions of those produced by the Babel parser. Nodes keep
+their Babel type (e.g. "CallExpression", see https://babeljs.io/docs/babel-types)
+and scalar properties (e.g. the name of an Identifier, the operator of a
+BinaryExpression, or the value of a literal), while source locations are reduced
+to start and end positions, and comments, tokens and other parser metadata are
+omitted. Changes to the schema which are not backwards compatible increment
+SchemaVersion.
+*/
+package ast
+
+import (
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// SchemaVersion is the version of the schema of Tree.
+const SchemaVersion = 1
+
+// Tree is the syntax tree of a single file.
+type Tree struct {
+	// Version is the SchemaVersion that the tree was output with.
+	Version int `json:"version"`
+	// Root is the Program node of the file.
+	Root *Node `json:"root"`
+}
+
+// Node is a node of a syntax tree.
+type Node struct {
+	// Type is the Babel type of the node, e.g. "Identifier" or "CallExpression".
+	Type string `json:"type"`
+	// Field is the property of the parent node that holds this node, e.g. "callee"
+	// or "arguments" for the children of a CallExpression. It is empty for the root.
+	Field string `json:"field,omitempty"`
+	// Pos and End are the positions of the start and end of the node in the source.
+	Pos token.Position `json:"pos"`
+	End token.Position `json:"end"`
+	// Attrs holds the scalar properties of the node (strings, numbers and booleans),
+	// e.g. "name" for identifiers, "operator" for expressions, or "value" and "raw"
+	// for literals. Properties of nested objects which are not nodes are flattened
+	// with a dot, e.g. "value.cooked" for a TemplateElement.
+	Attrs map[string]any `json:"attrs,omitempty"`
+	// Children holds the child nodes in the order of the properties of the node,
+	// and in source order within a property. Empty elements of arrays (e.g. the
+	// holes in [a, , b]) are omitted.
+	Children []*Node `json:"children,omitempty"`
+	// Scope is the ID of the scope created by the node (e.g. for a Program,
+	// function or block), or 0 if the node does not create one. IDs are unique
+	// within a tree, and the scope of the Program is 1.
+	Scope int `json:"scope,omitempty"`
+	// Binding is the variable that an Identifier refers to or declares, if it
+	// is declared in the file.
+	Binding *Binding `json:"binding,omitempty"`
+}
+
+// Binding is a variable declared in a scope.
+type Binding struct {
+	// Scope is the ID of the scope that the variable is declared in.
+	Scope int `json:"scope"`
+	// Kind is how the variable is declared, as given by Babel: "var", "let",
+	// "const", "param", "module" (imports), "hoisted" (function declarations)
+	// or "local" (e.g. the name of a function expression).
+	Kind string `json:"kind"`
+	// Declaration is true for the Identifier that declares the variable.
+	Declaration bool `json:"declaration,omitempty"`
+}
+
+// StringAttr returns the attribute of n with the given name if it is a string,
+// otherwise the empty string.
+func (n *Node) StringAttr(name string) string {
+	s, _ := n.Attrs[name].(string)
+	return s
+}
+
+// Child returns the first child of n in the given field, or nil if there is none.
+func (n *Node) Child(field string) *Node {
+	for _, c := range n.Children {
+		if c.Field == field {
+			return c
+		}
+	}
+	return nil
+}
+
+// ChildrenOf returns the children of n in the given field.
+func (n *Node) ChildrenOf(field string) []*Node {
+	var children []*Node
+	for _, c := range n.Children {
+		if c.Field == field {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// Walk calls visit for each node of the tree rooted at n in depth-first order,
+// with the parent of the node (nil for n). If visit returns false, the children
+// of the node are not visited.
+func Walk(n *Node, visit func(n, parent *Node) bool) {
+	walk(n, nil, visit)
+}
+
+func walk(n, parent *Node, visit func(n, parent *Node) bool) {
+	if n == nil || !visit(n, parent) {
+		return
+	}
+	for _, c := range n.Children {
+		walk(c, n, visit)
+	}
+}
@@ -0,0 +1,105 @@
+package ast
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// treeJSON is the tree output by the parser for `function f(x) { return x; }`.
+const treeJSON = `{
+  "version": 1,
+  "root": {
+    "type": "Program", "pos": [1, 0], "end": [1, 29], "attrs": {"sourceType": "script"}, "scope": 1,
+    "children": [{
+      "type": "FunctionDeclaration", "field": "body", "pos": [1, 0], "end": [1, 29],
+      "attrs": {"generator": false, "async": false}, "scope": 2,
+      "children": [
+        {"type": "Identifier", "field": "id", "pos": [1, 9], "end": [1, 10], "attrs": {"name": "f"},
+         "binding": {"scope": 1, "kind": "hoisted", "declaration": true}},
+        {"type": "Identifier", "field": "params", "pos": [1, 11], "end": [1, 12], "attrs": {"name": "x"},
+         "binding": {"scope": 2, "kind": "param", "declaration": true}},
+        {"type": "BlockStatement", "field": "body", "pos": [1, 14], "end": [1, 29], "children": [
+          {"type": "ReturnStatement", "field": "body", "pos": [1, 16], "end": [1, 27], "children": [
+            {"type": "Identifier", "field": "argument", "pos": [1, 23], "end": [1, 24], "attrs": {"name": "x"},
+             "binding": {"scope": 2, "kind": "param"}}
+          ]}
+        ]}
+      ]
+    }]
+  }
+}`
+
+func decodeTree(t *testing.T) Tree {
+	t.Helper()
+	var tree Tree
+	if err := json.Unmarshal([]byte(treeJSON), &tree); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return tree
+}
+
+func TestTreeDecode(t *testing.T) {
+	tree := decodeTree(t)
+	if tree.Version != SchemaVersion || tree.Root.Type != "Program" || tree.Root.Scope != 1 {
+		t.Fatalf("decoded tree = %+v, want a version %d Program", tree, SchemaVersion)
+	}
+
+	fn := tree.Root.Child("body")
+	if fn == nil || fn.Type != "FunctionDeclaration" {
+		t.Fatalf("Child(body) = %+v, want FunctionDeclaration", fn)
+	}
+	if name := fn.Child("id").StringAttr("name"); name != "f" {
+		t.Errorf("function name = %q, want f", name)
+	}
+	if params := fn.ChildrenOf("params"); len(params) != 1 || params[0].StringAttr("name") != "x" {
+		t.Errorf("ChildrenOf(params) = %v, want [x]", params)
+	}
+	if fn.Child("missing") != nil || fn.ChildrenOf("missing") != nil {
+		t.Errorf("missing field has children")
+	}
+	if got := fn.StringAttr("async"); got != "" {
+		t.Errorf("StringAttr(async) = %q, want empty for a boolean", got)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tree := decodeTree(t)
+
+	// resolve each reference to the declaration of its binding
+	declarations := make(map[Binding]*Node)
+	var references []*Node
+	Walk(tree.Root, func(n, parent *Node) bool {
+		if n.Binding == nil {
+			return true
+		}
+		if n.Binding.Declaration {
+			declarations[Binding{Scope: n.Binding.Scope, Kind: n.Binding.Kind}] = n
+		} else {
+			references = append(references, n)
+		}
+		return true
+	})
+	if len(references) != 1 {
+		t.Fatalf("got %d references, want 1", len(references))
+	}
+	decl := declarations[*references[0].Binding]
+	if decl == nil || decl.Field != "params" {
+		t.Errorf("reference resolves to %+v, want the parameter", decl)
+	}
+
+	var types, parents []string
+	Walk(tree.Root, func(n, parent *Node) bool {
+		types = append(types, n.Type)
+		if parent != nil {
+			parents = append(parents, parent.Type)
+		}
+		// don't descend into the body of the function
+		return n.Type != "BlockStatement"
+	})
+	wantTypes := []string{"Program", "FunctionDeclaration", "Identifier", "Identifier", "BlockStatement"}
+	wantParents := []string{"Program", "FunctionDeclaration", "FunctionDeclaration", "FunctionDeclaration"}
+	if !reflect.DeepEqual(types, wantTypes) || !reflect.DeepEqual(parents, wantParents) {
+		t.Errorf("Walk() visited %v with parents %v, want %v with parents %v", types, parents, wantTypes, wantParents)
+	}
+}
@@ -0,0 +1,132 @@
+package staticanalysis
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
+)
+
+// ErrDuplicateDetector is returned (wrapped) by DetectorRegistry.Register for a
+// detector with the same name as one already registered.
+var ErrDuplicateDetector = errors.New("detector already registered")
+
+// ErrUnknownDetector is returned (wrapped) by DetectorRegistry.Replace for a
+// detector whose name is not registered.
+var ErrUnknownDetector = errors.New("detector not registered")
+
+// Source holds what is known about a file given to a StaticDetector, other than
+// its syntax tree.
+type Source struct {
+	// PkgPath is the slash-separated path of the file relative to the package
+	// root, or empty if it is not known.
+	PkgPath string
+	// Tokens holds the tokens found in the file by the parser.
+	Tokens JsData
+	// Signals holds the signals collected from the file by the built-in analysis,
+	// in the fields of FileResult that hold them (e.g. IndirectEvals), which have
+	// no Findings yet. The other fields (e.g. Filename and SHA256) are not set.
+	Signals FileResult
+}
+
+/*
+StaticDetector finds code of interest in a single parsed file, and reports it as
+Findings, which are added to those of the file (see FileResult.Findings). This is
+the extension point for rules that are specific to an organization or too narrow to
+be built in, e.g. calls to a deprecated internal API.
+
+Analyze is given the syntax tree of the file (see ast.Tree), which is nil unless the
+parser was run with the full syntax tree, and the rest of its data. The Rule of each
+Finding should be unique to the detector, and its Category and Severity should be
+one of the constants of this package. Analyze must not modify tree or source, since
+they are shared with the other detectors.
+
+Name identifies the detector in a DetectorRegistry.
+*/
+type StaticDetector interface {
+	Name() string
+	Analyze(tree *ast.Tree, source Source) []Finding
+}
+
+/*
+DetectorRegistry holds the StaticDetectors that make the Findings of each file.
+Detectors run in the order they were registered, and their Findings are combined
+and ordered by position.
+
+The zero value is not usable; use NewDetectorRegistry, or start from the built-in
+detectors of the static analysis sandbox.
+*/
+type DetectorRegistry struct {
+	detectors []StaticDetector
+}
+
+// NewDetectorRegistry returns an empty DetectorRegistry, which makes no Findings.
+func NewDetectorRegistry() *DetectorRegistry {
+	return &DetectorRegistry{}
+}
+
+// Register adds d to the detectors of r. It is an error to register two detectors
+// with the same name.
+func (r *DetectorRegistry) Register(d StaticDetector) error {
+	if r.index(d.Name()) >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateDetector, d.Name())
+	}
+	r.detectors = append(r.detectors, d)
+	return nil
+}
+
+// Unregister removes the detector with the given name from r, e.g. to disable one
+// of the built-in rules. It returns whether the detector was registered.
+func (r *DetectorRegistry) Unregister(name string) bool {
+	i := r.index(name)
+	if i < 0 {
+		return false
+	}
+	r.detectors = slices.Delete(r.detectors, i, i+1)
+	return true
+}
+
+// Replace replaces the detector with the same name as d by d, which runs in its
+// place, e.g. to change how one of the built-in rules is detected. It is an error
+// if there is no detector with that name.
+func (r *DetectorRegistry) Replace(d StaticDetector) error {
+	i := r.index(d.Name())
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownDetector, d.Name())
+	}
+	r.detectors[i] = d
+	return nil
+}
+
+// index returns the index of the detector with the given name, or -1 if there
+// is none.
+func (r *DetectorRegistry) index(name string) int {
+	return slices.IndexFunc(r.detectors, func(d StaticDetector) bool { return d.Name() == name })
+}
+
+// Names returns the names of the registered detectors, in the order they run.
+func (r *DetectorRegistry) Names() []string {
+	names := make([]string, 0, len(r.detectors))
+	for _, d := range r.detectors {
+		names = append(names, d.Name())
+	}
+	return names
+}
+
+// Findings runs the registered detectors over the file with the given syntax tree
+// (which may be nil) and source, and returns their Findings ordered by position.
+// Findings at the same position are in the order the detectors were registered.
+func (r *DetectorRegistry) Findings(tree *ast.Tree, source Source) []Finding {
+	result := []Finding{}
+	for _, d := range r.detectors {
+		result = append(result, d.Analyze(tree, source)...)
+	}
+	slices.SortStableFunc(result, func(a, b Finding) int {
+		if a.Pos.Row() != b.Pos.Row() {
+			return a.Pos.Row() - b.Pos.Row()
+		}
+		return a.Pos.Col() - b.Pos.Col()
+	})
+	return result
+}
@@ -0,0 +1,78 @@
+package staticanalysis
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/ast"
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// rowDetector makes one Finding with its rule at each of the given rows.
+type rowDetector struct {
+	rule string
+	rows []int
+}
+
+func (d rowDetector) Name() string { return d.rule }
+
+func (d rowDetector) Analyze(_ *ast.Tree, _ Source) []Finding {
+	var result []Finding
+	for _, row := range d.rows {
+		result = append(result, Finding{Rule: d.rule, Pos: token.Position{row, 0}})
+	}
+	return result
+}
+
+func TestDetectorRegistry(t *testing.T) {
+	r := NewDetectorRegistry()
+	if got := r.Findings(nil, Source{}); len(got) != 0 {
+		t.Errorf("Findings() of an empty registry = %v, want none", got)
+	}
+
+	for _, d := range []StaticDetector{rowDetector{"a", []int{3, 1}}, rowDetector{"b", []int{1, 2}}, rowDetector{"c", []int{4}}} {
+		if err := r.Register(d); err != nil {
+			t.Fatalf("Register(%s) error = %v", d.Name(), err)
+		}
+	}
+	if err := r.Register(rowDetector{rule: "a"}); !errors.Is(err, ErrDuplicateDetector) {
+		t.Errorf("Register() of a duplicate error = %v, want %v", err, ErrDuplicateDetector)
+	}
+	if got, want := r.Names(), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+
+	// ordered by position, then by the order the detectors were registered
+	want := []Finding{
+		{Rule: "a", Pos: token.Position{1, 0}},
+		{Rule: "b", Pos: token.Position{1, 0}},
+		{Rule: "b", Pos: token.Position{2, 0}},
+		{Rule: "a", Pos: token.Position{3, 0}},
+		{Rule: "c", Pos: token.Position{4, 0}},
+	}
+	if got := r.Findings(nil, Source{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Findings() = %v, want %v", got, want)
+	}
+
+	if !r.Unregister("c") {
+		t.Errorf("Unregister(c) = false, want true")
+	}
+	if r.Unregister("c") {
+		t.Errorf("Unregister(c) again = true, want false")
+	}
+	if err := r.Replace(rowDetector{"b", []int{5}}); err != nil {
+		t.Errorf("Replace(b) error = %v", err)
+	}
+	if err := r.Replace(rowDetector{rule: "c"}); !errors.Is(err, ErrUnknownDetector) {
+		t.Errorf("Replace() of an unregistered detector error = %v, want %v", err, ErrUnknownDetector)
+	}
+	want = []Finding{
+		{Rule: "a", Pos: token.Position{1, 0}},
+		{Rule: "a", Pos: token.Position{3, 0}},
+		{Rule: "b", Pos: token.Position{5, 0}},
+	}
+	if got := r.Findings(nil, Source{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Findings() after Unregister and Replace = %v, want %v", got, want)
+	}
+}
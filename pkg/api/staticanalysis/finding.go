@@ -0,0 +1,87 @@
+package staticanalysis
+
+import (
+	"slices"
+
+	"github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+)
+
+// Severities of a Finding, from least to most likely to indicate malicious code.
+const (
+	SeverityInfo   = "info"
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Categories of a Finding, which group detectors by the kind of behaviour they
+// detect, so that consumers can select e.g. only the findings about credential
+// theft. The verdict rules of static and dynamic analysis use the same categories.
+const (
+	// CategoryNetwork is network access, e.g. requests to URLs built at runtime.
+	CategoryNetwork = "network"
+	// CategoryFilesystem is access to files outside the package, e.g. writes to
+	// other packages or shell profiles.
+	CategoryFilesystem = "filesystem"
+	// CategoryObfuscation is code hidden from inspection, e.g. packed code or code
+	// decoded from base64 and run.
+	CategoryObfuscation = "obfuscation"
+	// CategoryExecution is running commands or native code, e.g. a reverse shell
+	// or a native addon.
+	CategoryExecution = "execution"
+	// CategoryCredentialTheft is access to secrets, e.g. credential stores, the
+	// clipboard or input fields.
+	CategoryCredentialTheft = "credential_theft"
+	// CategoryEvasion is behaviour that avoids analysis, e.g. long delays or code
+	// gated on the platform.
+	CategoryEvasion = "evasion"
+)
+
+// Finding is a single detection in a file, in a form shared by all detectors, so
+// that results can be listed, filtered by severity or converted to other formats
+// (e.g. SARIF) without knowing about each kind of signal. The detailed signal is
+// kept in its own field of FileResult.
+//
+// Rule identifies the detector, e.g. "indirect_eval" for a finding that is also
+// recorded in FileResult.IndirectEvals. Category is one of the Category constants,
+// Severity is one of the Severity levels, Message describes what was found, and
+// Pos is its position in the source file, which is zero for files that are not
+// parsed as code (e.g. config files).
+type Finding struct {
+	Rule     string         `json:"rule"`
+	Category string         `json:"category"`
+	Severity string         `json:"severity"`
+	Message  string         `json:"message"`
+	Pos      token.Position `json:"pos"`
+}
+
+// FileFinding is a Finding together with the name of the file it was made in.
+type FileFinding struct {
+	Filename string
+	Finding
+}
+
+// FilterFindings returns the findings whose category is one of categories, in
+// the same order. If no categories are given, all findings are returned.
+func FilterFindings(findings []Finding, categories ...string) []Finding {
+	var filtered []Finding
+	for _, f := range findings {
+		if len(categories) == 0 || slices.Contains(categories, f.Category) {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// Findings returns the findings of all files whose category is one of categories,
+// ordered by file and then by position, as in each FileResult. If no categories
+// are given, all findings are returned.
+func (r *Results) Findings(categories ...string) []FileFinding {
+	var findings []FileFinding
+	for _, f := range r.Files {
+		for _, finding := range FilterFindings(f.Findings, categories...) {
+			findings = append(findings, FileFinding{Filename: f.Filename, Finding: finding})
+		}
+	}
+	return findings
+}
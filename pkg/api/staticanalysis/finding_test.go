@@ -0,0 +1,50 @@
+package staticanalysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResultsFindings(t *testing.T) {
+	eval := Finding{Rule: "indirect_eval", Category: CategoryObfuscation, Severity: SeverityMedium}
+	clipboard := Finding{Rule: "clipboard_access", Category: CategoryCredentialTheft, Severity: SeverityLow}
+	input := Finding{Rule: "input_capture", Category: CategoryCredentialTheft, Severity: SeverityMedium}
+	request := Finding{Rule: "network_request", Category: CategoryNetwork, Severity: SeverityInfo}
+	r := &Results{Files: []FileResult{
+		{Filename: "a.js", Findings: []Finding{eval, clipboard}},
+		{Filename: "b.js"},
+		{Filename: "c.js", Findings: []Finding{request, input}},
+	}}
+
+	tests := []struct {
+		name       string
+		categories []string
+		want       []FileFinding
+	}{
+		{
+			name:       "one category",
+			categories: []string{CategoryCredentialTheft},
+			want:       []FileFinding{{"a.js", clipboard}, {"c.js", input}},
+		},
+		{
+			name:       "several categories",
+			categories: []string{CategoryNetwork, CategoryObfuscation},
+			want:       []FileFinding{{"a.js", eval}, {"c.js", request}},
+		},
+		{
+			name: "all categories",
+			want: []FileFinding{{"a.js", eval}, {"a.js", clipboard}, {"c.js", request}, {"c.js", input}},
+		},
+		{
+			name:       "no findings",
+			categories: []string{CategoryEvasion},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Findings(tt.categories...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Findings(%v) = %+v, want %+v", tt.categories, got, tt.want)
+			}
+		})
+	}
+}
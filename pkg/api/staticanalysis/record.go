@@ -2,6 +2,10 @@ package staticanalysis
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/ossf/package-analysis/pkg/api/analysisrun"
@@ -25,14 +29,142 @@ type Record struct {
 	Name          string    `json:"name"`
 	Version       string    `json:"version"`
 	Created       time.Time `json:"created"`
-	Results       Results   `json:"results"`
+	// ArtifactSHA256 is the hex-encoded SHA256 hash of the analyzed package
+	// artifact, if it was computed.
+	ArtifactSHA256 string `json:"artifact_sha256,omitempty"`
+	// GitSource is the git repository the package was fetched from, if it was
+	// analyzed from git rather than from the registry.
+	GitSource *GitSource `json:"git_source,omitempty"`
+	Results   Results    `json:"results"`
+}
+
+// GitSource records the git repository and ref that a package was fetched from,
+// and the commit that the ref resolved to, which is also the version of the
+// package in the record (see analysisrun.GitSource).
+type GitSource struct {
+	Repository string `json:"repository"`
+	Ref        string `json:"ref,omitempty"`
+	Commit     string `json:"commit"`
 }
 
 // Results holds the output data from static analysis data, as part of the
 // Record struct which is a part of the Package Analysis API. These structs
 // are serialised to JSON to produce the JSON data files for static analysis.
 type Results struct {
-	Files []FileResult `json:"files"`
+	Files       []FileResult    `json:"files"`
+	Manifest    *ManifestResult `json:"manifest,omitempty"`
+	Fingerprint *Fingerprint    `json:"fingerprint,omitempty"`
+	// AnalysisLimits lists the limits (e.g. analysisrun.LimitStaticScope) which
+	// were hit by the analysis of the package as a whole, rather than of a file.
+	AnalysisLimits []string `json:"analysis_limits,omitempty"`
+}
+
+// TimedOutFiles returns the names of the files which were not parsed because they
+// took too long (see FileResult.TimedOut).
+func (r *Results) TimedOutFiles() []string {
+	var files []string
+	for _, f := range r.Files {
+		if f.TimedOut {
+			files = append(files, f.Filename)
+		}
+	}
+	return files
+}
+
+// Limits returns the limits (e.g. analysisrun.LimitStaticFileTimeout) which were
+// hit during static analysis (see analysisrun.Completeness).
+func (r *Results) Limits() []string {
+	limits := slices.Clone(r.AnalysisLimits)
+	if len(r.TimedOutFiles()) > 0 {
+		limits = append(limits, analysisrun.LimitStaticFileTimeout)
+	}
+	return limits
+}
+
+// ReusedFiles returns the names of the files whose results were reused from the
+// previous version of the package (see FileResult.Reused).
+func (r *Results) ReusedFiles() []string {
+	var files []string
+	for _, f := range r.Files {
+		if f.Reused {
+			files = append(files, f.Filename)
+		}
+	}
+	return files
+}
+
+// Fingerprint is a locality-sensitive hash of the code of a package, computed
+// from the identifiers, call targets and string literals in the analysed files.
+// Packages with similar code have fingerprints which differ in few bits, so the
+// fingerprints of two packages can be compared to find copied or forked code.
+// SimHash holds the 64-bit hash as 16 hexadecimal digits, and Features is the
+// number of distinct features that were hashed.
+type Fingerprint struct {
+	SimHash  string `json:"simhash"`
+	Features int    `json:"features"`
+}
+
+// Distance returns the number of bits that differ between the hashes of f and other,
+// which is between 0 (for identical or near-identical code) and 64. An error is
+// returned if either hash is not valid.
+func (f Fingerprint) Distance(other Fingerprint) (int, error) {
+	a, err := strconv.ParseUint(f.SimHash, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid simhash %q: %w", f.SimHash, err)
+	}
+	b, err := strconv.ParseUint(other.SimHash, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid simhash %q: %w", other.SimHash, err)
+	}
+	return bits.OnesCount64(a ^ b), nil
+}
+
+// ManifestResult holds the dependencies and scripts declared in the package manifest,
+// along with the result of cross-checking the declared dependencies against the
+// modules imported by the analysed files.
+type ManifestResult struct {
+	Dependencies []DeclaredDependency `json:"dependencies"`
+	Scripts      []DeclaredScript     `json:"scripts"`
+	Bins         []DeclaredBin        `json:"bins"`
+	// UndeclaredImports lists the packages imported by the analysed files
+	// which are not declared as dependencies of any type.
+	UndeclaredImports []string `json:"undeclared_imports"`
+	// UnusedDependencies lists the runtime dependencies which are neither
+	// imported by the analysed files nor mentioned in a script.
+	UnusedDependencies []string `json:"unused_dependencies"`
+	// NetworkImports lists the built-in network modules (e.g. http, net)
+	// imported by the analysed files.
+	NetworkImports []string `json:"network_imports"`
+	// UnexplainedNetworkImports is true if there are NetworkImports, but the
+	// name, description and keywords of the package do not mention networking.
+	UnexplainedNetworkImports bool `json:"unexplained_network_imports"`
+	// ShadowedCommands lists the names of Bins which are the same as common
+	// system commands (e.g. ls, git or npm). When the package is installed
+	// globally, or its node_modules/.bin directory is on PATH, these take the
+	// place of the real commands.
+	ShadowedCommands []string `json:"shadowed_commands"`
+}
+
+// DeclaredDependency is a dependency declared in the package manifest. Type is
+// one of "runtime", "dev", "optional" or "peer", and Version is the version
+// constraint given in the manifest.
+type DeclaredDependency struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+}
+
+// DeclaredScript is a script declared in the package manifest.
+type DeclaredScript struct {
+	Name   string `json:"name"`
+	Script string `json:"script"`
+}
+
+// DeclaredBin is an executable declared in the package manifest, which is
+// installed under Name and runs the file at Path within the package.
+type DeclaredBin struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
 }
 
 // CreateRecord associates a set of static analysis Results with an identifying Key,
@@ -51,6 +183,10 @@ func CreateRecord(r *Results, k analysisrun.Key) *Record {
 // FileResult holds static analysis data for a single file. Filename is the only
 // mandatory field, and holds the path to the file relative to the package root.
 // Other fields may be present or missing depending on whether relevant data was collected.
+// TimedOut is true if the file was not parsed because it took too long, in which case
+// there is no parsing or signals data for it. Reused is true if the file had not
+// changed since the previous version of the package, and its data was copied from
+// the results of that version rather than the file being analyzed again.
 type FileResult struct {
 	Filename              string                   `json:"filename"`
 	DetectedType          string                   `json:"detected_type,omitempty"`
@@ -58,6 +194,8 @@ type FileResult struct {
 	SHA256                string                   `json:"sha256,omitempty"`
 	LineLengths           *valuecounts.ValueCounts `json:"line_lengths,omitempty"`
 	Js                    *JsData                  `json:"js,omitempty"`
+	SourceEncoding        string                   `json:"source_encoding,omitempty"`
+	Imports               []string                 `json:"imports,omitempty"`
 	IdentifierLengths     *valuecounts.ValueCounts `json:"identifier_lengths,omitempty"`
 	StringLengths         *valuecounts.ValueCounts `json:"string_lengths,omitempty"`
 	Base64Strings         []string                 `json:"base64_strings,omitempty"`
@@ -66,6 +204,40 @@ type FileResult struct {
 	URLs                  []string                 `json:"urls,omitempty"`
 	SuspiciousIdentifiers []SuspiciousIdentifier   `json:"suspicious_identifiers,omitempty"`
 	EscapedStrings        []EscapedString          `json:"escaped_strings,omitempty"`
+	IndirectEvals         []IndirectEval           `json:"indirect_evals,omitempty"`
+	WasmInstantiations    []WasmInstantiation      `json:"wasm_instantiations,omitempty"`
+	LongDelays            []LongDelay              `json:"long_delays,omitempty"`
+	WalletAddresses       []WalletAddress          `json:"wallet_addresses,omitempty"`
+	ClipboardAccesses     []ClipboardAccess        `json:"clipboard_accesses,omitempty"`
+	ReverseShells         []ReverseShell           `json:"reverse_shells,omitempty"`
+	InsecureTransport     []InsecureTransport      `json:"insecure_transport,omitempty"`
+	InternalAPIUsages     []InternalAPIUsage       `json:"internal_api_usages,omitempty"`
+	NetworkRequests       []NetworkRequest         `json:"network_requests,omitempty"`
+	PlatformConditions    []PlatformCondition      `json:"platform_conditions,omitempty"`
+	EarlyExits            []EarlyExit              `json:"early_exits,omitempty"`
+	ConfigCommands        []ConfigCommand          `json:"config_commands,omitempty"`
+	InputCaptures         []InputCapture           `json:"input_captures,omitempty"`
+	TimingChecks          []TimingCheck            `json:"timing_checks,omitempty"`
+	PackedCode            []PackedCode             `json:"packed_code,omitempty"`
+	ThreadUsages          []ThreadUsage            `json:"thread_usages,omitempty"`
+	DecodedExecutions     []DecodedExecution       `json:"decoded_executions,omitempty"`
+	OutsideImports        []OutsideImport          `json:"outside_imports,omitempty"`
+	NativeAddonLoads      []NativeAddonLoad        `json:"native_addon_loads,omitempty"`
+	BinaryPayloads        []BinaryPayload          `json:"binary_payloads,omitempty"`
+	Findings              []Finding                `json:"findings,omitempty"`
+	ParseStats            *ParseStats              `json:"parse_stats,omitempty"`
+	TimedOut              bool                     `json:"timed_out,omitempty"`
+	Reused                bool                     `json:"reused,omitempty"`
+}
+
+// ParseStats records the size of a file and the cost of parsing it, which can be
+// used to find files that are slow to parse. InputBytes is the size of the file
+// given to the parser, ParseTimeMs is the wall time taken to parse it, and
+// OutputElements is the number of tokens produced by the parser.
+type ParseStats struct {
+	InputBytes     int64   `json:"input_bytes"`
+	ParseTimeMs    float64 `json:"parse_time_ms"`
+	OutputElements int     `json:"output_elements"`
 }
 
 type JsData struct {
@@ -1,5 +1,7 @@
 package staticanalysis
 
+import "github.com/ossf/package-analysis/pkg/api/staticanalysis/token"
+
 // EscapedString holds a string literal that contains a lot of character escaping.
 // This may indicate obfuscation.
 type EscapedString struct {
@@ -15,3 +17,274 @@ type SuspiciousIdentifier struct {
 	Name string `json:"name"`
 	Rule string `json:"rule"`
 }
+
+// IndirectEval records an indirect call to eval or the Function constructor,
+// e.g. this["ev" + "al"](code), globalThis["Function"](code) or (0, eval)(code).
+// Target is the function resolved as the target of the call ("eval" or "Function"),
+// Callee is the resolved name of the expression called, and Pos is the position
+// of the call in the source file.
+type IndirectEval struct {
+	Target string         `json:"target"`
+	Callee string         `json:"callee"`
+	Pos    token.Position `json:"pos"`
+}
+
+// WasmInstantiation records a call that compiles or instantiates a WebAssembly
+// module, e.g. WebAssembly.instantiate(bytes). Function is the WebAssembly API
+// function called (e.g. "instantiate" or "Module"), Source describes where the
+// module bytes come from ("inline", "fetched", "file" or "unknown"), and Pos is
+// the position of the call in the source file.
+type WasmInstantiation struct {
+	Function string         `json:"function"`
+	Source   string         `json:"source"`
+	Pos      token.Position `json:"pos"`
+}
+
+// LongDelay records a call that schedules code to run after a long, constant delay,
+// e.g. setTimeout(payload, 30 * 60 * 1000), which may be used to delay a payload
+// until after analysis has finished. Function is the timer function called (e.g.
+// "setTimeout"), DelayMs is the delay in milliseconds, and Pos is the position
+// of the call in the source file.
+type LongDelay struct {
+	Function string         `json:"function"`
+	DelayMs  int64          `json:"delay_ms"`
+	Pos      token.Position `json:"pos"`
+}
+
+// WalletAddress records a cryptocurrency wallet address found in a string literal.
+// Hardcoded wallet addresses are a sign of 'clipper' malware, which replaces
+// addresses in the clipboard or in generated output with the attacker's own.
+// Currency is the cryptocurrency the address belongs to (e.g. "bitcoin" or
+// "ethereum"), and Pos is the position of the string literal in the source file.
+type WalletAddress struct {
+	Currency string         `json:"currency"`
+	Address  string         `json:"address"`
+	Pos      token.Position `json:"pos"`
+}
+
+// ClipboardAccess records a call that reads or writes the clipboard, or imports
+// a module for doing so. API describes the clipboard API used, e.g.
+// "navigator.clipboard.writeText" or `require("clipboardy")`, and Pos is the
+// position of the call in the source file.
+type ClipboardAccess struct {
+	API string         `json:"api"`
+	Pos token.Position `json:"pos"`
+}
+
+// ReverseShell records a likely reverse shell: a shell that is run with its
+// standard streams wired to a socket connected to a remote host, giving that
+// host control of the machine. Shell is the shell run (e.g. "sh" or "cmd.exe").
+// SocketCallee and SocketPos describe the call that connects the socket, and
+// ShellCallee and Pos describe the call that runs the shell. SocketWired is true
+// if the standard streams were seen to be wired to the connected socket itself;
+// otherwise the file only connects a socket and wires the streams of a process to
+// some stream, which is a weaker indication.
+type ReverseShell struct {
+	Shell        string         `json:"shell"`
+	SocketCallee string         `json:"socket_callee"`
+	SocketPos    token.Position `json:"socket_pos"`
+	ShellCallee  string         `json:"shell_callee"`
+	Pos          token.Position `json:"pos"`
+	SocketWired  bool           `json:"socket_wired"`
+}
+
+// InsecureTransport records code that weakens the security of network connections.
+// Type is "tls_verification_disabled" for code that turns off verification of TLS
+// certificates (e.g. { rejectUnauthorized: false } or NODE_TLS_REJECT_UNAUTHORIZED=0),
+// which is often used to talk to a command and control server with a self-signed
+// certificate, or "plain_http" for a request to a remote host over plain HTTP.
+// Detail is the setting, command or URL found, and Pos is its position in the
+// source file.
+type InsecureTransport struct {
+	Type   string         `json:"type"`
+	Detail string         `json:"detail"`
+	Pos    token.Position `json:"pos"`
+}
+
+// InternalAPIUsage records a use of a low-level Node.js API for accessing
+// internals of the runtime or debugging a process, which is rare in published
+// packages and may indicate a sandbox escape or introspection attempt. Type is
+// "internal_binding" for process.binding and process._linkedBinding, "v8_internals"
+// for imports of the v8 module, "inspector" for imports of the inspector module,
+// or "debugger_activation" for code that activates the debugger of a process
+// (e.g. --inspect or process.kill(pid, "SIGUSR1")). API describes the API used,
+// and Pos is its position in the source file.
+type InternalAPIUsage struct {
+	Type string         `json:"type"`
+	API  string         `json:"api"`
+	Pos  token.Position `json:"pos"`
+}
+
+// NetworkRequest records an HTTP request made using fetch, the http and https
+// modules, or a popular HTTP client such as axios. API is the function called.
+// URLSource is "static" if the URL is a constant string, "environment" if it is
+// (partly) read from an environment variable, or "dynamic" if it is otherwise
+// constructed at runtime; requests to such URLs are more suspicious than ones
+// to constant URLs. URL is the constant URL, or a template of a non-constant URL
+// in which each non-constant part is replaced by ${name}, e.g. "https://${host}/".
+// Pos is the position of the call in the source file.
+type NetworkRequest struct {
+	API       string         `json:"api"`
+	URL       string         `json:"url"`
+	URLSource string         `json:"url_source"`
+	Pos       token.Position `json:"pos"`
+}
+
+// EarlyExit records a call that unconditionally ends the process, e.g. process.exit()
+// at the top level of a file. Code after the call, or in files that would be loaded
+// later, is never run. Function is the function called (e.g. "process.exit"), and Pos
+// is the position of the call in the source file.
+type EarlyExit struct {
+	Function string         `json:"function"`
+	Pos      token.Position `json:"pos"`
+}
+
+// InputCapture records browser code which captures user input: an event listener
+// (or handler property) for keystrokes, text input or form submission, e.g.
+// document.addEventListener("keydown", ...), or an access to the fields of forms,
+// e.g. document.querySelector("input[type=password]"). Type is "listener" or
+// "form_access". API is the function called or property assigned, with the selector
+// for DOM queries, and Event is the event listened for (empty for form access).
+// NetworkSend is true if the same file also sends data over the network (e.g. with
+// fetch or navigator.sendBeacon), which is the pattern of keyloggers and of skimmers
+// that steal payment details from web pages. Pos is the position of the call or
+// assignment in the source file, so that the handler can be inspected.
+type InputCapture struct {
+	Type        string         `json:"type"`
+	API         string         `json:"api"`
+	Event       string         `json:"event,omitempty"`
+	NetworkSend bool           `json:"network_send"`
+	Pos         token.Position `json:"pos"`
+}
+
+// TimingCheck records code that measures how long it takes to run and chooses what to
+// run next based on the result, e.g. if (Date.now() - start > 100) { process.exit() }
+// where start = Date.now() earlier in the same function. Obfuscated malware uses this
+// to detect that it is being stepped through in a debugger or slowed down by
+// instrumentation, and to bail out before its payload is run. API is the function used
+// to read the time (e.g. "Date.now" or "performance.now"), StartPos and EndPos are the
+// positions of the two reads of the time, GatedLength is the number of characters of
+// code that is only run depending on the elapsed time, and Pos is the position of the
+// subtraction in the source file.
+type TimingCheck struct {
+	API         string         `json:"api"`
+	StartPos    token.Position `json:"start_pos"`
+	EndPos      token.Position `json:"end_pos"`
+	GatedLength int            `json:"gated_length"`
+	Pos         token.Position `json:"pos"`
+}
+
+// PackedCode records code which is packed or self-extracting, i.e. which rebuilds its
+// real source at runtime, e.g. the output of eval(function(p,a,c,k,e,d){...}) packers
+// or of obfuscators which move all strings into an array. Indicators lists the
+// techniques found in the file ("eval", "array_dispatch" and "string_building"), and
+// Confidence ("low", "medium" or "high") depends on how they are combined. Pos is the
+// position of the call which runs the rebuilt code (or else the first access to the
+// string array) in the source file.
+type PackedCode struct {
+	Confidence string         `json:"confidence"`
+	Indicators []string       `json:"indicators"`
+	Pos        token.Position `json:"pos"`
+}
+
+// DecodedExecution records code that is decoded from base64 or hex and then run or
+// loaded, e.g. eval(atob("...")) or new Function(Buffer.from(s, "base64").toString()),
+// which is how most obfuscated malware hides its payload. Sink is the function called
+// to run the code (e.g. "eval", "Function" or "require"), Decoder is the function used
+// to decode it (e.g. "atob" or "Buffer.from"), Encoding is the encoding decoded, and
+// Variable is the variable that the decoded value was passed through, if any.
+// DecodePos is the position of the decoding call, and Pos is the position of the call
+// to Sink in the source file.
+type DecodedExecution struct {
+	Sink      string         `json:"sink"`
+	Decoder   string         `json:"decoder"`
+	Encoding  string         `json:"encoding"`
+	Variable  string         `json:"variable,omitempty"`
+	DecodePos token.Position `json:"decode_pos"`
+	Pos       token.Position `json:"pos"`
+}
+
+// OutsideImport records a require or import of a path outside the package, e.g. an
+// absolute path such as "/etc/passwd" or a relative path such as "../../other" that
+// leaves the package root, which reaches into the host or sibling packages rather
+// than the package itself or its dependencies. Module is the specifier as written,
+// and Pos is the position of the require or import in the source file.
+type OutsideImport struct {
+	Module string         `json:"module"`
+	Pos    token.Position `json:"pos"`
+}
+
+// NativeAddonLoad records code that loads a native addon, whose code runs outside
+// the JavaScript engine and so cannot be analyzed with the rest of the package: a
+// require or import of a .node file, a call to process.dlopen, or a require of a
+// module that loads addons built by node-gyp (e.g. "bindings"). Type is "require",
+// "dlopen" or "loader" respectively, and Library is the path of the library (or the
+// name of the loader module), if it is constant. Pos is the position of the call in
+// the source file.
+type NativeAddonLoad struct {
+	Type    string         `json:"type"`
+	Library string         `json:"library,omitempty"`
+	Pos     token.Position `json:"pos"`
+}
+
+// BinaryPayload records bytes assembled from literal data, which is how packed payloads
+// and shellcode are built in JavaScript: a large array of byte values passed to a
+// Buffer or typed array constructor (e.g. new Uint8Array([0x7f, 0x45, ...])), or a
+// buffer filled in one constant write at a time (e.g. buf[i] = 0x90). Constructor is
+// the function which creates the bytes (e.g. "Uint8Array", "Buffer.from" or
+// "Buffer.alloc"), Size is the number of bytes of literal data, and Writes is true if
+// they were written into an allocated buffer. Sink is the function which writes the
+// bytes to a file or compiles them as WebAssembly (e.g. "fs.writeFileSync" or
+// "WebAssembly.instantiate"), if any. Pos is the position of the call which creates
+// the bytes in the source file.
+type BinaryPayload struct {
+	Constructor string         `json:"constructor"`
+	Size        int            `json:"size"`
+	Writes      bool           `json:"writes,omitempty"`
+	Sink        string         `json:"sink,omitempty"`
+	Pos         token.Position `json:"pos"`
+}
+
+// ConfigCommand records a shell command embedded in a manifest or config file, e.g.
+// a script in package.json. Key is the path of the value holding the command in the
+// file (e.g. "scripts.postinstall"), and Command is the command itself. RemoteExecution
+// is true if the command downloads content and runs it, e.g. "curl https://... | sh".
+type ConfigCommand struct {
+	Key             string `json:"key"`
+	Command         string `json:"command"`
+	RemoteExecution bool   `json:"remote_execution"`
+}
+
+// PlatformCondition records a comparison that makes code depend on the operating
+// system it runs on, e.g. if (process.platform === "win32") { ... }. Code gated in
+// this way is not run when the package is analyzed on another platform, so may need
+// to be analyzed separately. Platform is the platform referenced, as a value of
+// process.platform (e.g. "win32" or "darwin"). Subject is the expression compared
+// (e.g. "process.platform" or "os.type()"), and Operator is the comparison operator,
+// or "case" for a case of a switch statement. GatedLength is the number of characters
+// of code that is only run depending on the comparison, and Significant is true if
+// this is large enough that the condition may gate a platform-specific payload.
+// Pos is the position of the comparison in the source file.
+type PlatformCondition struct {
+	Platform    string         `json:"platform"`
+	Subject     string         `json:"subject"`
+	Operator    string         `json:"operator"`
+	GatedLength int            `json:"gated_length"`
+	Significant bool           `json:"significant"`
+	Pos         token.Position `json:"pos"`
+}
+
+// ThreadUsage records a call that creates a thread or uses memory shared between
+// threads, which can be used to run a payload (e.g. a crypto miner) off the main
+// thread. Type is the kind of usage ("worker" for new Worker(...), "worker_threads"
+// for an import of the Node.js worker_threads module, or "shared_memory" for
+// SharedArrayBuffer and Atomics), and API is the constructor, function or module
+// used. For workers, WorkerSource describes where the code run by the thread comes
+// from ("inline", "file" or "dynamic"). Pos is the position of the call in the
+// source file.
+type ThreadUsage struct {
+	Type         string         `json:"type"`
+	API          string         `json:"api"`
+	WorkerSource string         `json:"worker_source,omitempty"`
+	Pos          token.Position `json:"pos"`
+}
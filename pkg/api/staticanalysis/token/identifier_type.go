@@ -56,6 +56,19 @@ func (t *IdentifierType) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalText serializes this IdentifierType using its string representation.
+// This allows IdentifierType to be used as a JSON object key.
+func (t IdentifierType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText deserializes an IdentifierType serialized using MarshalText.
+// Unrecognised names are deserialised as Unknown.
+func (t *IdentifierType) UnmarshalText(text []byte) error {
+	*t = ParseIdentifierType(string(text))
+	return nil
+}
+
 func IdentifierTypes() []IdentifierType {
 	return maps.Keys(stringValues)
 }
@@ -1,6 +1,8 @@
 package token
 
 import (
+	"strings"
+
 	"github.com/texttheater/golang-levenshtein/levenshtein"
 
 	"github.com/ossf/package-analysis/internal/staticanalysis/signals/stringentropy"
@@ -22,9 +24,10 @@ func (i *Identifier) ComputeEntropy(probs map[rune]float64) {
 
 // String records a string literal occurring in the source code.
 type String struct {
-	Value   string  `json:"value"`
-	Raw     string  `json:"raw"`
-	Entropy float64 `json:"entropy"`
+	Value   string   `json:"value"`
+	Raw     string   `json:"raw"`
+	Entropy float64  `json:"entropy"`
+	Pos     Position `json:"pos"`
 }
 
 // ComputeEntropy computes the entropy of this string literal's value under the
@@ -56,5 +59,154 @@ type Float struct {
 // Comment records the entire text of a source code comment.
 // It may contain newline characters.
 type Comment struct {
-	Text string `json:"text"`
+	Text string   `json:"text"`
+	Pos  Position `json:"pos"`
+}
+
+// Call records a call to a function, method or constructor in source code, for
+// which the name of the function called could be determined. Callee holds the
+// dotted name of the function, e.g. "eval", "console.log" or 'require("fs").readFileSync'.
+type Call struct {
+	Callee string `json:"callee"`
+	// New is true if the call is a constructor invocation, i.e. using 'new'.
+	New bool `json:"new,omitempty"`
+	// Computed is true if (part of) the callee name was resolved from a computed
+	// member access with a constant property, e.g. this["ev" + "al"].
+	Computed bool `json:"computed,omitempty"`
+	// Indirect is true if the callee was referenced through a sequence
+	// expression, e.g. (0, eval).
+	Indirect bool `json:"indirect,omitempty"`
+	// Unconditional is true if the call is made whenever the program is run (or
+	// the module is loaded), i.e. it is not inside a function, loop, try statement
+	// or conditional branch.
+	Unconditional bool      `json:"unconditional,omitempty"`
+	Args          []CallArg `json:"args"`
+	Pos           Position  `json:"pos"`
+}
+
+// FunctionName returns the last component of the callee name,
+// e.g. "readFileSync" for 'require("fs").readFileSync'.
+func (c Call) FunctionName() string {
+	if i := strings.LastIndex(c.Callee, "."); i >= 0 {
+		return c.Callee[i+1:]
+	}
+	return c.Callee
+}
+
+// CallArg describes an argument passed in a function call. Type is the kind of
+// expression passed, e.g. "String", "Numeric", "Identifier", "Member", "Call" or
+// "Function". For String and Numeric arguments, Value holds the value of the literal
+// (or constant expression), while for Identifier, Member and Call arguments, it holds
+// the name of the variable, object member or function called, if it could be determined.
+// Template (for template literals) and Concatenation (for strings concatenated with
+// non-constant values) arguments have a Value in which each non-constant part is
+// replaced by ${name}, e.g. "https://${host}/?d=${process.env.HOME}".
+type CallArg struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+// Import records a declaration in source code which imports another module: an import
+// declaration (e.g. import x from "module" or import "module"), or a re-export of
+// another module (e.g. export * from "module"), in which case Export is true. Module
+// is the module specifier. Calls to require and dynamic imports (import("module"))
+// are recorded as a Call instead.
+type Import struct {
+	Module string   `json:"module"`
+	Export bool     `json:"export,omitempty"`
+	Pos    Position `json:"pos"`
+}
+
+// Assignment records a constant value assigned to a named property in source code,
+// either in an object literal (e.g. { rejectUnauthorized: false }), in which case
+// Target is the property name, or by assignment to an object member (e.g.
+// process.env.NODE_TLS_REJECT_UNAUTHORIZED = "0"), in which case Target is the
+// dotted name of the member. Value describes the constant assigned, as for a call
+// argument; its Type is one of "Boolean", "Numeric", "String" or "Null".
+type Assignment struct {
+	Target string `json:"target"`
+	// Member is true if the value was assigned to an object member, rather than
+	// given for a property in an object literal.
+	Member bool     `json:"member,omitempty"`
+	Value  CallArg  `json:"value"`
+	Pos    Position `json:"pos"`
+}
+
+// Condition records a comparison in source code of an expression that identifies the
+// operating system the code is running on with a constant string, e.g.
+// process.platform === "win32". Subject is the dotted name of the expression compared
+// (e.g. "process.platform" or 'require("os").platform()'), Operator is the comparison
+// operator, or "case" for a case of a switch statement on Subject, and Value is the
+// constant compared with. GatedLength is the number of characters of source code that
+// is only run depending on the result of the comparison, e.g. the branches of an if
+// statement, or the statements of a switch case. It is zero if the result of the
+// comparison is not used directly to choose which code to run, e.g. if it is assigned
+// to a variable.
+type Condition struct {
+	Subject     string   `json:"subject"`
+	Operator    string   `json:"operator"`
+	Value       string   `json:"value"`
+	GatedLength int      `json:"gated_length"`
+	Pos         Position `json:"pos"`
+}
+
+// TimingCheck records a subtraction in source code of two reads of the current time
+// whose result is compared to choose which code to run, e.g.
+// if (Date.now() - start > 100) { ... } where start = Date.now() earlier in the same
+// function. API is the function used to read the time (e.g. "Date.now",
+// "performance.now" or "new Date()"), StartPos and EndPos are the positions of the
+// earlier and later reads (i.e. the right and left operands of the subtraction), and
+// Pos is the position of the subtraction. GatedLength is the number of characters of
+// source code that is only run depending on the result of the comparison.
+type TimingCheck struct {
+	API         string   `json:"api"`
+	StartPos    Position `json:"start_pos"`
+	EndPos      Position `json:"end_pos"`
+	GatedLength int      `json:"gated_length"`
+	Pos         Position `json:"pos"`
+}
+
+// DecodedExecution records a call in source code which runs (or loads) code decoded from
+// base64 or hex, e.g. eval(atob("...")) or new Function(code) where
+// code = Buffer.from(s, "base64").toString() earlier in an enclosing scope. Sink is the
+// function called to run the code (e.g. "eval", "Function" or "require"), Decoder is
+// the function called to decode it (e.g. "atob" or "Buffer.from"), Encoding is the
+// encoding decoded ("base64", "base64url" or "hex"), and Variable is the name of the
+// variable that the decoded value was passed through, or empty if it was passed
+// directly. DecodePos is the position of the decoding call, and Pos is the position
+// of the call to Sink.
+type DecodedExecution struct {
+	Sink      string   `json:"sink"`
+	Decoder   string   `json:"decoder"`
+	Encoding  string   `json:"encoding"`
+	Variable  string   `json:"variable,omitempty"`
+	DecodePos Position `json:"decode_pos"`
+	Pos       Position `json:"pos"`
+}
+
+// BinaryPayload records bytes assembled in source code from literal data, in the way
+// packed payloads and shellcode are built, e.g. new Uint8Array([0x7f, 0x45, ...]), or
+// a buffer from Buffer.alloc filled in with buf[i] = 0x90 or buf.writeUInt32LE(...).
+// Constructor is the function used to create the bytes (e.g. "Uint8Array",
+// "Buffer.from" or "Buffer.alloc"), Size is the number of bytes of literal data, and
+// Writes is true if they were written into an allocated buffer rather than given as
+// an array. Sink is the function that the bytes are passed to if they are written to
+// a file or compiled as WebAssembly (e.g. "fs.writeFileSync" or
+// "WebAssembly.instantiate"), or empty otherwise. Pos is the position of the call
+// which creates the bytes.
+type BinaryPayload struct {
+	Constructor string   `json:"constructor"`
+	Size        int      `json:"size"`
+	Writes      bool     `json:"writes,omitempty"`
+	Sink        string   `json:"sink,omitempty"`
+	Pos         Position `json:"pos"`
+}
+
+// PropertyName returns the last component of the target name,
+// e.g. "NODE_TLS_REJECT_UNAUTHORIZED" for "process.env.NODE_TLS_REJECT_UNAUTHORIZED".
+func (a Assignment) PropertyName() string {
+	if i := strings.LastIndex(a.Target, "."); i >= 0 {
+		return a.Target[i+1:]
+	}
+	return a.Target
 }
@@ -7,9 +7,17 @@
 
 const {spawnSync} = require('child_process');
 const fs = require('fs');
+const Module = require('module');
+const path = require('path');
 const process = require('process');
 
 const executionLogPath = '/execution.log';
+const moduleLoadLogPath = '/module-loads.log';
+const terminationLogPath = '/termination.log';
+
+// Set once code of the package may run in this process, so that an explicit exit
+// after this is attributed to the package.
+let packageLoaded = false;
 
 function install(pkg) {
   // Specify the package to install.
@@ -20,7 +28,16 @@ function install(pkg) {
     throw 'Failed to init npm';
   }
 
-  result = spawnSync('npm', ['install', installPkg], {stdio: 'inherit'});
+  const installArgs = ['install', installPkg];
+  // A directory (e.g. a package fetched from git) is packed and installed like
+  // npm does for git dependencies, which runs its prepare script, rather than
+  // being linked into node_modules.
+  const localStat = pkg.localFile ? fs.statSync(pkg.localFile, {throwIfNoEntry: false}) : null;
+  if (localStat && localStat.isDirectory()) {
+    installArgs.push('--install-links');
+  }
+
+  result = spawnSync('npm', installArgs, {stdio: 'inherit'});
   if (result.status === 0) {
     console.log('Install succeeded.');
   } else {
@@ -32,12 +49,100 @@ function install(pkg) {
   }
 }
 
+// Records each module loaded by require() (including modules loaded by the
+// package's dependencies) to the module load log, as one JSON object per line.
+// The log is truncated first, so that it only holds the loads of one phase.
+// Modules loaded with import are not recorded, since they bypass Module._load.
+function recordModuleLoads(pkg) {
+  try {
+    fs.writeFileSync(moduleLoadLogPath, '');
+  } catch (e) {
+    console.log(`Failed to create module load log: ${e}`);
+    return;
+  }
+
+  const pkgDir = path.join(process.cwd(), 'node_modules', pkg.name) + path.sep;
+  const seen = new Set();
+  const originalLoad = Module._load;
+  Module._load = function (request, parent, isMain) {
+    try {
+      let resolved = null;
+      try {
+        resolved = Module._resolveFilename(request, parent, isMain);
+      } catch (e) {
+        // the load will fail too; record the request without a path
+      }
+      const builtin = Module.builtinModules.includes(request.replace(/^node:/, ''));
+      const parentFile = (parent && parent.filename) ? parent.filename : '';
+      const fromPackage = parentFile.startsWith(pkgDir) &&
+          !parentFile.substring(pkgDir.length).split(path.sep).includes('node_modules');
+      const entry = {
+        name: request,
+        path: (builtin || resolved === null) ? '' : resolved,
+        builtin: builtin,
+        fromPackage: fromPackage,
+      };
+      const key = JSON.stringify(entry);
+      if (!seen.has(key)) {
+        seen.add(key);
+        fs.appendFileSync(moduleLoadLogPath, key + '\n');
+      }
+    } catch (e) {
+      // never interfere with loading the module
+    }
+    return originalLoad.apply(this, arguments);
+  };
+}
+
+// Records how this process ends to the termination log: 'completed' if it runs to
+// completion, 'exit' if the package ends it explicitly (e.g. with process.exit()),
+// or 'signal' if it is ended by a signal. A signal that cannot be handled (e.g.
+// SIGKILL) leaves the initial 'signal' record in place.
+function recordTermination() {
+  const record = (termination) => {
+    try {
+      fs.writeFileSync(terminationLogPath, JSON.stringify(termination));
+    } catch (e) {
+      // nothing else can be done while exiting
+    }
+  };
+  record({reason: 'signal', code: 0, signal: ''});
+
+  let explicitExit = false;
+  const originalExit = process.exit;
+  process.exit = function () {
+    explicitExit = packageLoaded;
+    return originalExit.apply(this, arguments);
+  };
+  const originalAbort = process.abort;
+  process.abort = function () {
+    if (packageLoaded) {
+      record({reason: 'exit', code: 134, signal: ''});
+    }
+    return originalAbort.apply(this, arguments);
+  };
+
+  process.on('exit', (code) => {
+    record({reason: explicitExit ? 'exit' : 'completed', code: code, signal: ''});
+  });
+
+  for (const signal of ['SIGTERM', 'SIGINT', 'SIGHUP']) {
+    process.once(signal, () => {
+      record({reason: 'signal', code: 0, signal: signal});
+      // re-raise the signal, now that there is no listener for it
+      process.kill(process.pid, signal);
+    });
+  }
+}
+
 function redirectConsoleWrite(stdoutWrite, stderrWrite) {
   process.stdout.write = stdoutWrite;
   process.stderr.write = stderrWrite;
 }
 
 function importPkg(pkg) {
+  recordModuleLoads(pkg);
+  packageLoaded = true;
   try {
     require(pkg.name);
   } catch (e) {
@@ -46,6 +151,8 @@ function importPkg(pkg) {
 }
 
 function executePkg(pkg) {
+  recordModuleLoads(pkg);
+  packageLoaded = true;
   // if we're here, module importing should have worked in import phase
   let mod = require(pkg.name);
 
@@ -199,4 +306,5 @@ if (!Array.from(phases.keys()).includes(phase)) {
 }
 
 // Execute the phase
+recordTermination();
 phases.get(phase).forEach((f) => f(pkg));
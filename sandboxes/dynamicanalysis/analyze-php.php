@@ -71,7 +71,12 @@ function install($package) {
         "--name=app/app",
     );
     $artifactPath = $package->artifactPath();
-    if ($artifactPath !== NULL) {
+    if ($package->local_path !== NULL && is_dir($package->local_path)) {
+        // An already extracted package is installed from its directory.
+        $arg = array("type" => "path", "url" => $package->local_path, "options" => array("symlink" => false));
+        $repositoryArg = sprintf('--repository=%s', json_encode($arg));
+        $args[] = $repositoryArg;
+    } else if ($artifactPath !== NULL) {
         $arg = array("type" => "artifact", "url" => $artifactPath);
         $repositoryArg = sprintf('--repository=%s', json_encode($arg));
         $args[] = $repositoryArg;
@@ -14,7 +14,21 @@ end
 
 def install(package)
   cmd = ["gem", "install"]
-  if package.local_file
+  if package.local_file && File.directory?(package.local_file)
+    # An already extracted package is built into a gem before installing.
+    gemspec = Dir.glob(File.join(package.local_file, "*.gemspec")).first
+    if gemspec.nil?
+      puts "Install failed: no gemspec found in #{package.local_file}"
+      exit 1
+    end
+    output, status = Open3.capture2e("gem", "build", File.basename(gemspec), chdir: package.local_file)
+    puts output
+    unless status.success?
+      puts "Install failed."
+      exit 1
+    end
+    cmd << Dir.glob(File.join(package.local_file, "*.gem")).first
+  elsif package.local_file
     cmd << package.local_file
   else
     if package.version
@@ -0,0 +1,431 @@
+/*
+Package runner implements the static analysis sandbox command (staticanalyze), which
+analyzes a single package as configured by its command line flags and writes the
+results as JSON.
+
+The command can be rebuilt with custom detectors, which contribute to the Findings
+of each file, by calling Run from the main function of another binary:
+
+	registry := runner.DefaultDetectorRegistry()
+	if err := registry.Register(myDetector{}); err != nil {
+		...
+	}
+	if err := runner.Run(runner.Detectors(registry)); err != nil {
+		...
+	}
+*/
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ossf/package-analysis/internal/log"
+	"github.com/ossf/package-analysis/internal/pkgmanager"
+	"github.com/ossf/package-analysis/internal/staticanalysis"
+	"github.com/ossf/package-analysis/internal/staticanalysis/basicdata"
+	"github.com/ossf/package-analysis/internal/staticanalysis/parsing"
+	"github.com/ossf/package-analysis/internal/staticanalysis/signals"
+	"github.com/ossf/package-analysis/internal/telemetry"
+	"github.com/ossf/package-analysis/internal/utils"
+	"github.com/ossf/package-analysis/internal/worker"
+	"github.com/ossf/package-analysis/pkg/api/analysisrun"
+	"github.com/ossf/package-analysis/pkg/api/pkgecosystem"
+	staticapi "github.com/ossf/package-analysis/pkg/api/staticanalysis"
+)
+
+var (
+	ecosystem   pkgecosystem.Ecosystem
+	packageName = flag.String("package", "", "package name (required)")
+	version     = flag.String("version", "", "package version (ignored if local file is specified)")
+	localFile   = flag.String("local", "", "local package archive containing package to be analysed. Name must match -package argument")
+	output      = flag.String("output", "", "where to write output JSON results (default stdout)")
+	help        = flag.Bool("help", false, "prints this help and list of available analyses")
+	analyses    = utils.CommaSeparatedFlags("analyses", []string{"all"}, "comma-separated list of static analysis tasks to perform")
+	entryPoints = flag.Bool("entry-points", false, "only analyze the package's declared entry point files (e.g. package.json main/exports)")
+	nestedDepth = flag.Int("nested-code-depth", 0, fmt.Sprintf("number of levels of string literals which look like code (e.g. eval payloads) to parse as JavaScript (at most %d)", parsing.MaxNestedCodeDepth))
+	fullAST     = flag.Bool("full-ast", false, "include the syntax tree of each JavaScript file in the parsing results (see pkg/api/staticanalysis/ast), which greatly increases their size")
+	paths       = utils.CommaSeparatedFlags("paths", nil, "comma-separated list of files or directories to analyze, relative to the extracted archive (default all files)")
+	previous    = flag.String("previous-results", "", "output JSON results of the previous version of the package; files that have not changed are not analyzed again, and their results are reused")
+
+	fileTimeout    = flag.Duration("file-timeout", 0, "time given to parse each file, as a budget for each batch of files parsed together; files in a batch that runs out of time are parsed again on their own, and those that take longer are recorded as timed out and skipped (0 for no limit)")
+	packageTimeout = flag.Duration("package-timeout", 0, "maximum time to parse all files in the package; files not parsed in time are recorded as timed out (0 for no limit)")
+
+	defaultLimits       = utils.DefaultExtractLimits()
+	maxExtractSize      = flag.Int64("max-extract-size", defaultLimits.MaxTotalSize, "maximum total size in bytes of the extracted package archive (0 for no limit)")
+	maxExtractFiles     = flag.Int("max-extract-files", defaultLimits.MaxFiles, "maximum number of entries in the package archive (0 for no limit)")
+	maxExtractFileSize  = flag.Int64("max-extract-file-size", defaultLimits.MaxFileSize, "maximum size in bytes of a single extracted file (0 for no limit)")
+	maxCompressionRatio = flag.Float64("max-compression-ratio", defaultLimits.MaxCompressionRatio, "maximum compression ratio of the package archive (0 for no limit)")
+)
+
+type workDirs struct {
+	baseDir    string
+	archiveDir string
+	extractDir string
+	parserDir  string
+}
+
+func (wd *workDirs) cleanup(ctx context.Context) {
+	if err := os.RemoveAll(wd.baseDir); err != nil {
+		slog.ErrorContext(ctx, "Failed to remove work dirs", "baseDir", wd.baseDir, "error", err)
+	}
+}
+
+const jsParserDirName = "jsparser"
+
+func checkTasks(names []string) ([]staticanalysis.Task, error) {
+	uniqueNames := utils.RemoveDuplicates(names)
+	var tasks []staticanalysis.Task
+	for _, name := range uniqueNames {
+		if task, ok := staticanalysis.TaskFromString(name); !ok {
+			return nil, fmt.Errorf("unrecognised static analysis task '%s'", name)
+		} else if task == staticanalysis.All {
+			tasks = append(tasks, staticanalysis.AllTasks()...)
+		} else {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks, nil
+}
+
+func printAllTasks() {
+	fmt.Fprintln(os.Stderr, "Available analysis tasks are:")
+	for _, task := range staticanalysis.AllTasks() {
+		fmt.Fprintln(os.Stderr, task)
+	}
+}
+
+func makeWorkDirs() (workDirs, error) {
+	baseDir, err := os.MkdirTemp("", "package-analysis-staticanalyze")
+	if err != nil {
+		return workDirs{}, err
+	}
+
+	archiveDir, err := os.MkdirTemp(baseDir, "archive")
+	if err != nil {
+		_ = os.RemoveAll(baseDir)
+		return workDirs{}, err
+	}
+	extractDir, err := os.MkdirTemp(baseDir, "extracted")
+	if err != nil {
+		_ = os.RemoveAll(baseDir)
+		return workDirs{}, err
+	}
+	parserDir, err := os.MkdirTemp(baseDir, "parser")
+	if err != nil {
+		_ = os.RemoveAll(baseDir)
+		return workDirs{}, err
+	}
+
+	return workDirs{
+		baseDir:    baseDir,
+		archiveDir: archiveDir,
+		extractDir: extractDir,
+		parserDir:  parserDir,
+	}, nil
+}
+
+// packageEntryPoints returns the entry points of the package extracted into extractDir.
+// If they cannot be determined, a warning is logged and nil is returned, so that the
+// whole package is analyzed instead.
+func packageEntryPoints(ctx context.Context, manager *pkgmanager.PkgManager, extractDir string) []string {
+	entryPoints, err := manager.EntryPoints(extractDir)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not determine package entry points, analyzing all files", "error", err)
+		return nil
+	}
+	if len(entryPoints) == 0 {
+		slog.WarnContext(ctx, "Package has no entry points, analyzing all files")
+	}
+	return entryPoints
+}
+
+// readPreviousResults reads the results of the previous version of the package from
+// the output JSON file at path.
+func readPreviousResults(path string) ([]staticanalysis.SingleResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var previous staticanalysis.Result
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return nil, fmt.Errorf("could not parse previous results: %w", err)
+	}
+	return previous.Files, nil
+}
+
+// checkManifest parses the manifest of the package extracted into extractDir, and
+// cross-checks it against the imports found in fileResults. If the manifest cannot
+// be parsed, a warning is logged and nil is returned.
+func checkManifest(ctx context.Context, manager *pkgmanager.PkgManager, extractDir string, fileResults []staticanalysis.SingleResult) *staticapi.ManifestResult {
+	manifest, err := manager.Manifest(extractDir)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not parse package manifest", "error", err)
+		return nil
+	}
+	return staticanalysis.CheckManifest(manifest, fileResults)
+}
+
+// Option configures Run, in addition to the command line flags.
+type Option interface {
+	set(*runConfig)
+}
+
+// runConfig holds the configuration of Run, which is adjustable by Option.
+type runConfig struct {
+	// detectors makes the findings of each parsed file, or is nil for the
+	// built-in detectors only
+	detectors *staticapi.DetectorRegistry
+}
+
+type option func(*runConfig)
+
+func (o option) set(config *runConfig) { o(config) }
+
+// Detectors makes the Findings of each file with the detectors registered in
+// registry (see staticanalysis.Detectors), rather than only the built-in ones.
+func Detectors(registry *staticapi.DetectorRegistry) Option {
+	return option(func(config *runConfig) {
+		config.detectors = registry
+	})
+}
+
+// DefaultDetectorRegistry returns a DetectorRegistry with a detector for each of
+// the built-in rules, to which custom detectors can be added (see Detectors).
+func DefaultDetectorRegistry() *staticapi.DetectorRegistry {
+	return signals.DefaultDetectorRegistry()
+}
+
+// Run runs the static analysis sandbox command, as configured by the command line
+// flags and options, and returns any error that stops the analysis.
+func Run(options ...Option) (err error) {
+	startTime := time.Now()
+
+	var config runConfig
+	for _, o := range options {
+		o.set(&config)
+	}
+
+	log.Initialize(os.Getenv("LOGGER_ENV"))
+
+	flag.TextVar(&ecosystem, "ecosystem", pkgecosystem.None, fmt.Sprintf("package ecosystem. Can be %s (required)", pkgecosystem.SupportedEcosystemsStrings))
+	analyses.InitFlag()
+	paths.InitFlag()
+	flag.Parse()
+
+	if len(os.Args) == 1 || *help {
+		flag.Usage()
+		fmt.Fprintln(os.Stderr, "")
+		printAllTasks()
+		return
+	}
+
+	if ecosystem == pkgecosystem.None || *packageName == "" {
+		flag.Usage()
+		return fmt.Errorf("ecosystem and package are required arguments")
+	}
+
+	manager := pkgmanager.Manager(ecosystem)
+	if manager == nil {
+		return fmt.Errorf("unsupported pkg manager for ecosystem %s", ecosystem)
+	}
+
+	pkg, err := worker.ResolvePkg(manager, *packageName, *version, *localFile)
+	if err != nil {
+		return fmt.Errorf("package error: %w", err)
+	}
+
+	analysisTasks, err := checkTasks(analyses.Values)
+	if err != nil {
+		printAllTasks()
+		return err
+	}
+
+	ctx := log.ContextWithAttrs(context.Background(),
+		log.Label("ecosystem", ecosystem.String()),
+		slog.String("package", *packageName),
+		slog.String("version", *version),
+	)
+
+	// The stages of the analysis (e.g. parsing) are exported as part of the static
+	// analysis stage of the host, if it configured a collector.
+	shutdownTelemetry, err := telemetry.Setup(ctx, "package-analysis-static-analysis")
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to set up telemetry", "error", err)
+	} else {
+		defer func() {
+			if err := shutdownTelemetry(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to flush telemetry", "error", err)
+			}
+		}()
+	}
+	ctx = telemetry.ContextFromEnvironment(ctx)
+
+	slog.InfoContext(ctx, "Static analysis launched",
+		"local_path", *localFile,
+		"output_file", *output,
+		"analyses", analysisTasks)
+
+	workDirs, err := makeWorkDirs()
+	if err != nil {
+		return fmt.Errorf("failed to create work directories: %w", err)
+	}
+	defer workDirs.cleanup(ctx)
+
+	startDownloadTime := time.Now()
+
+	var archivePath string
+	if *localFile != "" {
+		archivePath = *localFile
+	} else {
+		archivePath, err = manager.DownloadArchive(pkg.Name(), pkg.Version(), workDirs.archiveDir)
+		if err != nil {
+			return fmt.Errorf("error downloading archive: %w", err)
+		}
+	}
+
+	downloadTime := time.Since(startDownloadTime)
+
+	results := staticanalysis.Result{}
+
+	startArchiveAnalysisTime := time.Now()
+	archiveResult, err := basicdata.Analyze(ctx, []string{archivePath},
+		basicdata.SkipLineLengths(),
+		basicdata.FormatPaths(func(absPath string) string { return "/" }),
+	)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to analyze archive file", "error", err)
+	} else if len(archiveResult) != 1 {
+		slog.WarnContext(ctx, "archive file analysis: unexpected number of results", "len", len(archiveResult))
+	} else {
+		archiveInfo := archiveResult[0]
+		results.Archive = staticanalysis.ArchiveResult{
+			DetectedType: archiveInfo.DetectedType,
+			Size:         archiveInfo.Size,
+			SHA256:       archiveInfo.SHA256,
+		}
+	}
+
+	archiveAnalysisTime := time.Since(startArchiveAnalysisTime)
+
+	startExtractionTime := time.Now()
+
+	limits := utils.ExtractLimits{
+		MaxTotalSize:        *maxExtractSize,
+		MaxFiles:            *maxExtractFiles,
+		MaxFileSize:         *maxExtractFileSize,
+		MaxCompressionRatio: *maxCompressionRatio,
+	}
+	if err := manager.ExtractArchive(archivePath, workDirs.extractDir, limits); errors.Is(err, utils.ErrDecompressionBomb) {
+		// the files extracted before the limit was hit are analyzed
+		slog.WarnContext(ctx, "archive extraction stopped at limit", "error", err)
+		results.Limits = append(results.Limits, analysisrun.LimitStaticExtraction)
+	} else if err != nil {
+		return fmt.Errorf("archive extraction failed: %w", err)
+	}
+
+	extractionTime := time.Since(startExtractionTime)
+
+	jsParserConfig, parserInitErr := parsing.InitParser(ctx, filepath.Join(workDirs.parserDir, jsParserDirName))
+	if parserInitErr != nil {
+		slog.ErrorContext(ctx, "failed to init JS parser", "error", parserInitErr)
+	}
+	jsParserConfig.NestedCodeDepth = *nestedDepth
+	jsParserConfig.FullAST = *fullAST
+
+	selection := paths.Values
+	if *entryPoints {
+		selection = append(selection, packageEntryPoints(ctx, manager, workDirs.extractDir)...)
+	}
+	if len(selection) > 0 {
+		results.Limits = append(results.Limits, analysisrun.LimitStaticScope)
+	}
+
+	analyzeOptions := []staticanalysis.Option{
+		staticanalysis.FileTimeout(*fileTimeout),
+		staticanalysis.PackageTimeout(*packageTimeout),
+	}
+	if *previous != "" {
+		previousFiles, err := readPreviousResults(*previous)
+		if err != nil {
+			return fmt.Errorf("previous results error: %w", err)
+		}
+		analyzeOptions = append(analyzeOptions, staticanalysis.PreviousResults(previousFiles))
+	}
+	if config.detectors != nil {
+		analyzeOptions = append(analyzeOptions, staticanalysis.Detectors(config.detectors))
+	}
+
+	startAnalysisTime := time.Now()
+	var fileResults []staticanalysis.SingleResult
+	if len(selection) > 0 {
+		slog.InfoContext(ctx, "Analyzing selected files", "paths", selection)
+		fileResults, err = staticanalysis.AnalyzeSelectedFiles(ctx, workDirs.extractDir, selection, jsParserConfig, analysisTasks, analyzeOptions...)
+	} else {
+		fileResults, err = staticanalysis.AnalyzePackageFiles(ctx, workDirs.extractDir, jsParserConfig, analysisTasks, analyzeOptions...)
+	}
+	if err != nil {
+		return fmt.Errorf("static analysis error: %w", err)
+	}
+	results.Files = fileResults
+	// imports are only known if the files were parsed (Signals implies Parsing)
+	parsed := slices.Contains(analysisTasks, staticanalysis.Parsing) || slices.Contains(analysisTasks, staticanalysis.Signals)
+	if manager.SupportsManifest() && parsed {
+		results.Manifest = checkManifest(ctx, manager, workDirs.extractDir, fileResults)
+	}
+	if parsed {
+		results.Fingerprint = staticanalysis.Fingerprint(fileResults)
+	}
+
+	analysisTime := time.Since(startAnalysisTime)
+	startWritingResultsTime := time.Now()
+
+	jsonResult, err := json.Marshal(results)
+	if err != nil {
+		slog.WarnContext(ctx, fmt.Sprintf("unserialisable JSON: %v", results))
+		return fmt.Errorf("JSON marshal error: %w", err)
+	}
+
+	outputFile := os.Stdout
+	if *output != "" {
+		outputFile, err = os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("could not open/create output file %s: %w", *output, err)
+		}
+
+		defer func() {
+			if err := outputFile.Close(); err != nil {
+				slog.WarnContext(ctx, "could not close output file", "path", *output, "error", err)
+			}
+		}()
+	}
+
+	if _, writeErr := outputFile.Write(jsonResult); writeErr != nil {
+		return fmt.Errorf("could not write JSON results: %w", writeErr)
+	}
+
+	writingResultsTime := time.Since(startWritingResultsTime)
+
+	totalTime := time.Since(startTime)
+	otherTime := totalTime - writingResultsTime - analysisTime - extractionTime - archiveAnalysisTime - downloadTime
+
+	slog.InfoContext(ctx, "Execution times",
+		"download", downloadTime,
+		"archive analysis", archiveAnalysisTime,
+		"archive extraction", extractionTime,
+		"file analysis", analysisTime,
+		"writing results", writingResultsTime,
+		"other", otherTime,
+		"total", totalTime)
+
+	return nil
+}